@@ -0,0 +1,27 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package audio
+
+import "errors"
+
+// 注册给手势模块的内置动作名称
+const gestureActionToggleMute = "ToggleAudioMute"
+
+// toggleDefaultSinkMute 切换默认输出设备的静音状态
+func (a *Audio) toggleDefaultSinkMute() error {
+	sink := a.getDefaultSink()
+	if sink == nil {
+		return errors.New("default sink is nil")
+	}
+	sink.PropsMu.RLock()
+	mute := sink.Mute
+	sink.PropsMu.RUnlock()
+
+	busErr := sink.SetMute(!mute)
+	if busErr != nil {
+		return busErr
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ package audio
 import (
 	"time"
 
+	"github.com/linuxdeepin/dde-daemon/common/gestureaction"
 	"github.com/linuxdeepin/dde-daemon/loader"
 	"github.com/linuxdeepin/go-lib/dbusutil"
 	"github.com/linuxdeepin/go-lib/log"
@@ -78,6 +79,11 @@ func (m *Module) start() error {
 	if err != nil {
 		logger.Warning("failed to register for deepin sync:", err)
 	}
+
+	err = gestureaction.Register(gestureActionToggleMute, m.audio.toggleDefaultSinkMute)
+	if err != nil {
+		logger.Warning("failed to register gesture action:", err)
+	}
 	return nil
 }
 
@@ -98,6 +104,7 @@ func (m *Module) Stop() error {
 		return nil
 	}
 
+	gestureaction.Unregister(gestureActionToggleMute)
 	m.audio.destroy()
 
 	service := loader.GetService()
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Package gestureaction 供其他模块注册手势的内置动作，手势模块执行 built-in 类型的动作时查找这里注册的动作
+package gestureaction
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	mu      sync.RWMutex
+	actions = make(map[string]func() error)
+)
+
+// Register 注册名为 name 的内置动作，名称已被注册时返回错误，模块停止时应调用 Unregister
+func Register(name string, fn func() error) error {
+	if name == "" {
+		return errors.New("name of gesture action is empty")
+	}
+	if fn == nil {
+		return fmt.Errorf("gesture action %q is nil", name)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := actions[name]; ok {
+		return fmt.Errorf("gesture action %q is already registered", name)
+	}
+	actions[name] = fn
+	return nil
+}
+
+func Unregister(name string) {
+	mu.Lock()
+	delete(actions, name)
+	mu.Unlock()
+}
+
+func Get(name string) (func() error, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	fn, ok := actions[name]
+	return fn, ok
+}
+
+// List 返回已注册的动作名称，按名称排序
+func List() []string {
+	mu.RLock()
+	names := make([]string, 0, len(actions))
+	for name := range actions {
+		names = append(names, name)
+	}
+	mu.RUnlock()
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gestureaction
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	called := false
+	fn := func() error {
+		called = true
+		return nil
+	}
+	assert.NoError(t, Register("TestB", fn))
+	assert.NoError(t, Register("TestA", fn))
+	defer Unregister("TestA")
+	assert.Error(t, Register("TestB", fn))
+	assert.Error(t, Register("", fn))
+	assert.Error(t, Register("TestC", nil))
+	assert.Equal(t, []string{"TestA", "TestB"}, List())
+
+	got, ok := Get("TestB")
+	assert.True(t, ok)
+	assert.NoError(t, got())
+	assert.True(t, called)
+
+	Unregister("TestB")
+	_, ok = Get("TestB")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"TestA"}, List())
+}
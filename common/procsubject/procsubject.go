@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Package procsubject 为 session bus 上的调用者创建 polkit 的 unix-process subject。
+// session bus 上的连接名对 polkit 没有意义，无法使用 system-bus-name subject，
+// 因此使用调用者进程的 pid 和启动时间，polkit 会检查启动时间，防止进程退出后 pid 被其他进程复用。
+package procsubject
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	polkit "github.com/linuxdeepin/go-dbus-factory/system/org.freedesktop.policykit1"
+)
+
+// /proc/<pid>/stat 中进程名之后的字段从第 3 个字段开始，启动时间是第 22 个字段
+const statStartTimeIndex = 22 - 3
+
+// parseStartTime 解析 /proc/<pid>/stat 中进程的启动时间，单位为系统启动后的时钟周期数
+func parseStartTime(stat string) (uint64, error) {
+	// 进程名可能包含空格和括号，从最后一个右括号之后开始拆分
+	idx := strings.LastIndexByte(stat, ')')
+	if idx < 0 {
+		return 0, errors.New("invalid process stat")
+	}
+	fields := strings.Fields(stat[idx+1:])
+	if len(fields) <= statStartTimeIndex {
+		return 0, errors.New("invalid process stat")
+	}
+	return strconv.ParseUint(fields[statStartTimeIndex], 10, 64)
+}
+
+func getStartTime(pid uint32) (uint64, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+	return parseStartTime(string(data))
+}
+
+// New 返回 pid 对应进程的 unix-process subject
+func New(pid uint32) (polkit.Subject, error) {
+	startTime, err := getStartTime(pid)
+	if err != nil {
+		return polkit.Subject{}, err
+	}
+	subject := polkit.MakeSubject(polkit.SubjectKindUnixProcess)
+	subject.SetDetail("pid", pid)
+	subject.SetDetail("start-time", startTime)
+	return subject, nil
+}
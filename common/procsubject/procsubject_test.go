@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package procsubject
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：进程名中有空格和括号时也能解析启动时间
+func Test_parseStartTime(t *testing.T) {
+	stat := "1234 (my (app) x) S 1 1234 1234 0 -1 4194560 100 0 0 0 5 3 0 0 20 0 1 0 987654 12345678 100"
+	startTime, err := parseStartTime(stat)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(987654), startTime)
+
+	_, err = parseStartTime("1234 (app) S 1")
+	assert.Error(t, err)
+}
+
+// 测试：读取当前进程的启动时间
+func Test_getStartTime(t *testing.T) {
+	startTime, err := getStartTime(uint32(os.Getpid()))
+	assert.NoError(t, err)
+	assert.NotZero(t, startTime)
+}
@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Package ssidpolicy 管理员通过系统级 dconfig 下发的无线网络黑白名单，
+// 配置项为只读，只能由管理员通过 /etc/dsg/configs/overrides 修改。
+// 系统服务断开不允许的无线连接，会话服务据此过滤热点列表
+package ssidpolicy
+
+import (
+	"path"
+)
+
+const (
+	DSettingsAppID = "org.deepin.dde.daemon"
+	DSettingsName  = "org.deepin.dde.daemon.network"
+
+	DSettingsKeyBlocklist = "ssidBlocklist"
+	DSettingsKeyAllowlist = "ssidAllowlist"
+)
+
+type Policy struct {
+	// 支持 path.Match 格式的通配符，如 Guest-*
+	Blocklist []string
+	// 为空时不限制
+	Allowlist []string
+}
+
+func matchPatterns(ssid string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, err := path.Match(pattern, ssid); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows 黑名单优先于白名单
+func (p *Policy) Allows(ssid string) bool {
+	if matchPatterns(ssid, p.Blocklist) {
+		return false
+	}
+	if len(p.Allowlist) != 0 && !matchPatterns(ssid, p.Allowlist) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,28 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package ssidpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyAllows(t *testing.T) {
+	var p Policy
+	assert.True(t, p.Allows("deepin"))
+
+	p = Policy{Blocklist: []string{"Guest-*", "free"}}
+	assert.True(t, p.Allows("deepin"))
+	assert.False(t, p.Allows("Guest-5G"))
+	assert.False(t, p.Allows("free"))
+	assert.True(t, p.Allows("free wifi"))
+
+	p = Policy{Blocklist: []string{"Corp-Guest"}, Allowlist: []string{"Corp-*"}}
+	assert.True(t, p.Allows("Corp-Office"))
+	// 黑名单优先
+	assert.False(t, p.Allows("Corp-Guest"))
+	assert.False(t, p.Allows("deepin"))
+}
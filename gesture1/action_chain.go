@@ -0,0 +1,135 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 动作链中某一步执行失败后的处理方式
+const (
+	// 停止执行后面的动作，默认值
+	chainOnFailureStop = "stop"
+	// 忽略错误，继续执行后面的动作
+	chainOnFailureContinue = "continue"
+)
+
+const (
+	maxChainSteps = 10
+	// 每一步执行前等待的最长时间，单位毫秒
+	maxChainStepDelay = 5000
+)
+
+// ActionStep ActionTypeChain 类型的动作中的一步，json 中与 ActionInfo 的字段在同一层
+type ActionStep struct {
+	ActionInfo
+	Delay     uint32 `json:",omitempty"` // 执行前等待的时间，单位毫秒
+	OnFailure string `json:",omitempty"` // stop 或 continue，默认为 stop
+}
+
+type actionChain []*ActionStep
+
+func (steps actionChain) toString() string {
+	var parts []string
+	for _, step := range steps {
+		if step == nil {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("{%s, Delay=%d}", step.ActionInfo.toString(), step.Delay))
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+func newActionChain(data string) (actionChain, error) {
+	var steps actionChain
+	err := json.Unmarshal([]byte(data), &steps)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chain action: %v", err)
+	}
+	return steps, nil
+}
+
+func checkActionChain(steps actionChain, builtinSets map[string]func() error) error {
+	if len(steps) == 0 {
+		return errors.New("chain action is empty")
+	}
+	if len(steps) > maxChainSteps {
+		return fmt.Errorf("chain action has %d steps, at most %d", len(steps), maxChainSteps)
+	}
+	for i, step := range steps {
+		if step == nil {
+			return fmt.Errorf("step %d: step is null", i)
+		}
+		// 不支持嵌套的动作链
+		if step.Type == ActionTypeChain {
+			return fmt.Errorf("step %d: nested chain action is not supported", i)
+		}
+		err := checkActionInfo(step.ActionInfo, builtinSets)
+		if err != nil {
+			return fmt.Errorf("step %d: %v", i, err)
+		}
+		if step.Delay > maxChainStepDelay {
+			return fmt.Errorf("step %d: delay %d is longer than %d", i, step.Delay, maxChainStepDelay)
+		}
+		if step.OnFailure != "" && step.OnFailure != chainOnFailureStop && step.OnFailure != chainOnFailureContinue {
+			return fmt.Errorf("step %d: invalid failure policy %q", i, step.OnFailure)
+		}
+	}
+	return nil
+}
+
+// actions 返回动作本身，动作链返回其中每一步的动作
+func (action ActionInfo) actions() []ActionInfo {
+	if action.Type != ActionTypeChain {
+		return []ActionInfo{action}
+	}
+	result := make([]ActionInfo, 0, len(action.Chain))
+	for _, step := range action.Chain {
+		if step != nil {
+			result = append(result, step.ActionInfo)
+		}
+	}
+	return result
+}
+
+// runActionChain 按顺序执行每一步，exec 执行单个动作，sleep 用于等待
+func runActionChain(steps actionChain, exec func(action ActionInfo) error, sleep func(d time.Duration)) error {
+	for i, step := range steps {
+		if step.Delay > 0 {
+			sleep(time.Duration(step.Delay) * time.Millisecond)
+		}
+		err := exec(step.ActionInfo)
+		if err == nil {
+			continue
+		}
+		if step.OnFailure == chainOnFailureContinue {
+			logger.Warningf("step %d of chain action failed, continue: %v", i, err)
+			continue
+		}
+		return fmt.Errorf("step %d of chain action failed: %v", i, err)
+	}
+	return nil
+}
+
+// execActionChain 动作链中可能有等待，在新的 goroutine 中执行，避免阻塞后续的手势事件
+func (m *Manager) execActionChain(evInfo EventInfo, steps actionChain) error {
+	err := checkActionChain(steps, m.getBuiltinSets())
+	if err != nil {
+		return err
+	}
+	go func() {
+		err := runActionChain(steps, func(action ActionInfo) error {
+			return m.execAction(evInfo, action)
+		}, time.Sleep)
+		if err != nil {
+			logger.Warningf("failed to exec chain action of %s: %v", evInfo.toString(), err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：动作链的解析和检查
+func Test_newActionInfoChain(t *testing.T) {
+	builtinSets := map[string]func() error{"ShowWorkspace": nil}
+	action, err := newActionInfo(ActionTypeChain, `[{"Type":"built-in","Action":"ShowWorkspace"},`+
+		`{"Type":"commandline","Action":"deepin-terminal","Delay":300,"OnFailure":"continue"}]`)
+	assert.NoError(t, err)
+	assert.NoError(t, checkActionInfo(action, builtinSets))
+	assert.Len(t, action.Chain, 2)
+	assert.Equal(t, uint32(300), action.Chain[1].Delay)
+	assert.Equal(t, "deepin-terminal", action.Chain[1].Action)
+
+	_, err = newActionInfo(ActionTypeChain, `{"Type":"built-in"}`)
+	assert.Error(t, err)
+	assert.Error(t, checkActionInfo(ActionInfo{Type: ActionTypeChain}, builtinSets))
+
+	invalidChains := []string{
+		`[{"Type":"built-in","Action":"Unknown"}]`,
+		`[{"Type":"chain","Chain":[{"Type":"commandline","Action":"ls"}]}]`,
+		`[{"Type":"commandline","Action":"ls","Delay":60000}]`,
+		`[{"Type":"commandline","Action":"ls","OnFailure":"retry"}]`,
+		`[null]`,
+	}
+	for _, chain := range invalidChains {
+		action, err := newActionInfo(ActionTypeChain, chain)
+		assert.NoError(t, err)
+		assert.Error(t, checkActionInfo(action, builtinSets), chain)
+	}
+}
+
+// 测试：单个动作的配置格式不变
+func Test_ActionInfoJSONCompatible(t *testing.T) {
+	data, err := json.Marshal(ActionInfo{Type: ActionTypeShortcut, Action: "super+d"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"Type":"shortcut","Action":"super+d"}`, string(data))
+}
+
+// 测试：动作链按顺序执行，失败时根据 OnFailure 决定是否继续
+func Test_runActionChain(t *testing.T) {
+	steps := actionChain{
+		{ActionInfo: ActionInfo{Type: ActionTypeCommandline, Action: "a"}},
+		{ActionInfo: ActionInfo{Type: ActionTypeCommandline, Action: "fail"}, Delay: 100, OnFailure: chainOnFailureContinue},
+		{ActionInfo: ActionInfo{Type: ActionTypeCommandline, Action: "b"}},
+	}
+	var executed []string
+	var slept []time.Duration
+	exec := func(action ActionInfo) error {
+		executed = append(executed, action.Action)
+		if action.Action == "fail" {
+			return errors.New("failed")
+		}
+		return nil
+	}
+	sleep := func(d time.Duration) {
+		slept = append(slept, d)
+	}
+	assert.NoError(t, runActionChain(steps, exec, sleep))
+	assert.Equal(t, []string{"a", "fail", "b"}, executed)
+	assert.Equal(t, []time.Duration{100 * time.Millisecond}, slept)
+
+	executed = nil
+	steps[1].OnFailure = ""
+	assert.Error(t, runActionChain(steps, exec, sleep))
+	assert.Equal(t, []string{"a", "fail"}, executed)
+}
+
+// 测试：锁屏时只允许每一步都是多媒体键的动作链
+func Test_isLockSafeActionChain(t *testing.T) {
+	action := ActionInfo{Type: ActionTypeChain, Chain: actionChain{
+		{ActionInfo: ActionInfo{Type: ActionTypeShortcut, Action: "XF86AudioPlay"}},
+		{ActionInfo: ActionInfo{Type: ActionTypeShortcut, Action: "XF86AudioNext"}},
+	}}
+	assert.True(t, isLockSafeAction(action))
+	action.Chain = append(action.Chain, &ActionStep{ActionInfo: ActionInfo{Type: ActionTypeShortcut, Action: "super+d"}})
+	assert.False(t, isLockSafeAction(action))
+	assert.False(t, isLockSafeAction(ActionInfo{Type: ActionTypeChain}))
+}
@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	dbusActionBusSession = "session"
+	dbusActionBusSystem  = "system"
+
+	dbusActionTimeout = 5 * time.Second
+)
+
+// DBusCallInfo ActionTypeDBus 类型的动作直接调用的 D-Bus 方法
+type DBusCallInfo struct {
+	Bus       string // session 或 system，默认为 session
+	Service   string
+	Path      dbus.ObjectPath
+	Interface string
+	Method    string
+	// 参数的类型，每个参数一个字符，支持 s o b y n q i u x t d，如 "su"
+	Signature string   `json:",omitempty"`
+	Args      []string `json:",omitempty"`
+}
+
+func (call *DBusCallInfo) toString() string {
+	return fmt.Sprintf("%s %s %s %s.%s %v", call.Bus, call.Service, call.Path, call.Interface, call.Method, call.Args)
+}
+
+func newDBusCallInfo(data string) (*DBusCallInfo, error) {
+	var call DBusCallInfo
+	err := json.Unmarshal([]byte(data), &call)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dbus action: %v", err)
+	}
+	return &call, nil
+}
+
+func parseDBusArg(typ byte, value string) (interface{}, error) {
+	switch typ {
+	case 's':
+		return value, nil
+	case 'o':
+		path := dbus.ObjectPath(value)
+		if !path.IsValid() {
+			return nil, fmt.Errorf("invalid object path %q", value)
+		}
+		return path, nil
+	case 'b':
+		return strconv.ParseBool(value)
+	case 'y':
+		v, err := strconv.ParseUint(value, 10, 8)
+		return byte(v), err
+	case 'n':
+		v, err := strconv.ParseInt(value, 10, 16)
+		return int16(v), err
+	case 'q':
+		v, err := strconv.ParseUint(value, 10, 16)
+		return uint16(v), err
+	case 'i':
+		v, err := strconv.ParseInt(value, 10, 32)
+		return int32(v), err
+	case 'u':
+		v, err := strconv.ParseUint(value, 10, 32)
+		return uint32(v), err
+	case 'x':
+		return strconv.ParseInt(value, 10, 64)
+	case 't':
+		return strconv.ParseUint(value, 10, 64)
+	case 'd':
+		return strconv.ParseFloat(value, 64)
+	}
+	return nil, fmt.Errorf("unsupported argument type %q", typ)
+}
+
+// getArgs 按 Signature 将字符串参数转换为对应类型的值
+func (call *DBusCallInfo) getArgs() ([]interface{}, error) {
+	if len(call.Signature) != len(call.Args) {
+		return nil, fmt.Errorf("signature %q does not match %d arguments", call.Signature, len(call.Args))
+	}
+	args := make([]interface{}, 0, len(call.Args))
+	for i, value := range call.Args {
+		arg, err := parseDBusArg(call.Signature[i], value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid argument %d: %v", i, err)
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+func checkDBusCallInfo(call *DBusCallInfo) error {
+	if call == nil {
+		return errors.New("dbus action is empty")
+	}
+	if call.Bus != "" && call.Bus != dbusActionBusSession && call.Bus != dbusActionBusSystem {
+		return fmt.Errorf("invalid bus %q", call.Bus)
+	}
+	if call.Service == "" || call.Interface == "" || call.Method == "" {
+		return errors.New("service, interface and method of dbus action are required")
+	}
+	if !call.Path.IsValid() {
+		return fmt.Errorf("invalid object path %q", call.Path)
+	}
+	_, err := call.getArgs()
+	return err
+}
+
+func (m *Manager) callDBusAction(call *DBusCallInfo) error {
+	err := checkDBusCallInfo(call)
+	if err != nil {
+		return err
+	}
+	args, err := call.getArgs()
+	if err != nil {
+		return err
+	}
+
+	var conn *dbus.Conn
+	if call.Bus == dbusActionBusSystem {
+		conn, err = dbus.SystemBus()
+	} else {
+		conn, err = dbus.SessionBus()
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbusActionTimeout)
+	defer cancel()
+	return conn.Object(call.Service, call.Path).CallWithContext(ctx,
+		call.Interface+"."+call.Method, 0, args...).Err
+}
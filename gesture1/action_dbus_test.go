@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：D-Bus 动作参数的转换
+func Test_DBusCallInfoGetArgs(t *testing.T) {
+	call := &DBusCallInfo{
+		Signature: "sobiud",
+		Args:      []string{"hello world", "/org/deepin", "true", "-1", "2", "0.5"},
+	}
+	args, err := call.getArgs()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"hello world", dbus.ObjectPath("/org/deepin"), true, int32(-1), uint32(2), 0.5}, args)
+
+	call = &DBusCallInfo{Signature: "u", Args: []string{"-1"}}
+	_, err = call.getArgs()
+	assert.Error(t, err)
+	call = &DBusCallInfo{Signature: "ss", Args: []string{"a"}}
+	_, err = call.getArgs()
+	assert.Error(t, err)
+	call = &DBusCallInfo{Signature: "a", Args: []string{"a"}}
+	_, err = call.getArgs()
+	assert.Error(t, err)
+}
+
+// 测试：D-Bus 动作的检查和解析
+func Test_newActionInfoDBus(t *testing.T) {
+	action, err := newActionInfo(ActionTypeDBus, `{"Service":"org.deepin.dde.Launcher1",`+
+		`"Path":"/org/deepin/dde/Launcher1","Interface":"org.deepin.dde.Launcher1","Method":"Toggle"}`)
+	assert.NoError(t, err)
+	assert.NoError(t, checkActionInfo(action, nil))
+	assert.Equal(t, "Toggle", action.DBus.Method)
+
+	_, err = newActionInfo(ActionTypeDBus, "dbus-send")
+	assert.Error(t, err)
+	assert.Error(t, checkActionInfo(ActionInfo{Type: ActionTypeDBus}, nil))
+
+	call := *action.DBus
+	call.Bus = "user"
+	assert.Error(t, checkDBusCallInfo(&call))
+	call = *action.DBus
+	call.Path = "org/deepin"
+	assert.Error(t, checkDBusCallInfo(&call))
+	call = *action.DBus
+	call.Method = ""
+	assert.Error(t, checkDBusCallInfo(&call))
+}
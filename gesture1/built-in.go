@@ -5,7 +5,11 @@
 package gesture1
 
 import (
+	"fmt"
 	"os/exec"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/common/gestureaction"
 )
 
 const (
@@ -21,6 +25,11 @@ const (
 	wmTileDirectionRight
 )
 
+// 通知中心系统配置项，见 dde-session-ui 中的 SystemConfigItem
+const (
+	notificationSystemInfoDNDMode uint32 = iota
+)
+
 func (m *Manager) initBuiltinSets() {
 	m.builtinSets = map[string]func() error{
 		"ShowWorkspace":              m.toggleShowMultiTasking,
@@ -37,10 +46,43 @@ func (m *Manager) initBuiltinSets() {
 		"SplitWindowLeft":            m.doTileActiveWindowLeft,
 		"SplitWindowRight":           m.doTileActiveWindowRight,
 		"MoveWindow":                 m.doMoveActiveWindow,
+		"ToggleDoNotDisturb":         m.doToggleDoNotDisturb,
+		"ZoomIn":                     m.doZoomIn,
+		"ZoomOut":                    m.doZoomOut,
+		"ShowWorkspaceOverview":      m.doShowWorkspaceOverview,
+		"HideWorkspaceOverview":      m.doHideWorkspaceOverview,
+		"MouseRightButtonDown":       m.doMouseRightButtonDown,
+		"MouseRightButtonUp":         m.doMouseRightButtonUp,
+		"ShowClipboard":              m.doShowClipboard,
+		"HideClipboard":              m.doHideClipboard,
+		"ShowWidgets":                m.doShowWidgets,
+		"HideWidgets":                m.doHideWidgets,
+		"ShowNotificationCenter":     m.doShowNotificationCenter,
+		"HideNotificationCenter":     m.doHideNotificationCenter,
+		"RotateScreenRight":          m.doRotateScreenRight,
+		"RotateScreenLeft":           m.doRotateScreenLeft,
+	}
+}
+
+// getBuiltinSets 返回手势模块自己的内置动作和其他模块注册的内置动作，名称相同时使用手势模块自己的动作
+func (m *Manager) getBuiltinSets() map[string]func() error {
+	registered := gestureaction.List()
+	result := make(map[string]func() error, len(m.builtinSets)+len(registered))
+	for _, name := range registered {
+		if fn, ok := gestureaction.Get(name); ok {
+			result[name] = fn
+		}
+	}
+	for name, fn := range m.builtinSets {
+		result[name] = fn
 	}
+	return result
 }
 
 func (m *Manager) toggleShowDesktop() error {
+	if _useWayland {
+		return invokeGlobalShortcut("kwin", kwinShortcutShowDesktop)
+	}
 	return exec.Command("/usr/lib/deepin-daemon/desktop-toggle").Run()
 }
 
@@ -135,3 +177,130 @@ func (m *Manager) doTileActiveWindowRight() error {
 func (m *Manager) doMoveActiveWindow() error {
 	return m.wm.BeginToMoveActiveWindow(0)
 }
+
+func (m *Manager) getDoNotDisturb() (bool, error) {
+	v, err := m.notification.GetSystemInfo(0, notificationSystemInfoDNDMode)
+	if err != nil {
+		return false, err
+	}
+	enabled, ok := v.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("invalid DND mode value: %v", v)
+	}
+	return enabled, nil
+}
+
+func (m *Manager) doToggleDoNotDisturb() error {
+	enabled, err := m.getDoNotDisturb()
+	if err != nil {
+		// 通知服务不可用时不做任何操作
+		logger.Warning("failed to get DND mode:", err)
+		return nil
+	}
+	logger.Debug("toggle DND mode to", !enabled)
+	return m.notification.SetSystemInfo(0, notificationSystemInfoDNDMode, dbus.MakeVariant(!enabled))
+}
+
+// 通过快捷键缩放当前窗口的内容
+func (m *Manager) doZoomIn() error {
+	return m.sendKeys("ctrl+plus")
+}
+
+func (m *Manager) doZoomOut() error {
+	return m.sendKeys("ctrl+minus")
+}
+
+// 触摸屏长按模拟鼠标右键
+func (m *Manager) doMouseRightButtonDown() error {
+	return m.sendButton(mouseButtonRight, true)
+}
+
+func (m *Manager) doMouseRightButtonUp() error {
+	return m.sendButton(mouseButtonRight, false)
+}
+
+// 捏合打开多任务视图，已经打开时不做任何操作
+func (m *Manager) doShowWorkspaceOverview() error {
+	_, isShowMultiTask, err := m.getWmStates()
+	if err != nil {
+		return err
+	}
+	if !isShowMultiTask {
+		return m.toggleShowMultiTasking()
+	}
+	return nil
+}
+
+func (m *Manager) doHideWorkspaceOverview() error {
+	_, isShowMultiTask, err := m.getWmStates()
+	if err != nil {
+		return err
+	}
+	if isShowMultiTask {
+		return m.toggleShowMultiTasking()
+	}
+	return nil
+}
+
+// 触摸屏边缘手势的默认动作
+func (m *Manager) doShowClipboard() error {
+	return m.clipboard.Show(0)
+}
+
+func (m *Manager) doHideClipboard() error {
+	return m.clipboard.Hide(0)
+}
+
+func (m *Manager) doShowWidgets() error {
+	return m.showWidgets(true)
+}
+
+func (m *Manager) doHideWidgets() error {
+	return m.showWidgets(false)
+}
+
+// 通知中心可以作为触摸屏边缘的动作，使用 Show 和 Hide 而不是 Toggle，
+// 避免从边缘滑入时关闭已经打开的通知中心
+func (m *Manager) doShowNotificationCenter() error {
+	enabled, err := m.getDoNotDisturb()
+	if err != nil {
+		logger.Warning("failed to get DND mode:", err)
+	} else if enabled {
+		// 勿扰模式下不弹出通知中心
+		logger.Debug("DND mode is enabled, do not show notification center")
+		return nil
+	}
+	return m.notification.Show(0)
+}
+
+func (m *Manager) doHideNotificationCenter() error {
+	return m.notification.Hide(0)
+}
+
+func (m *Manager) showWidgets(show bool) error {
+	sessionBus, err := dbus.SessionBus()
+	if err != nil {
+		logger.Warning(err)
+		return err
+	}
+	obj := sessionBus.Object("org.deepin.dde.Widgets", "/org/deepin/dde/Widgets")
+	if show {
+		err = obj.Call("org.deepin.dde.Widgets.Show", 0).Err
+	} else {
+		err = obj.Call("org.deepin.dde.Widgets.Hide", 0).Err
+	}
+	if err != nil {
+		logger.Warning(err)
+	}
+	return err
+}
+
+// 双指旋转手势的动作，与 xrandr 一致，right 为顺时针旋转，left 为逆时针旋转，
+// 图片查看器等应用中的旋转使用快捷键动作
+func (m *Manager) doRotateScreenRight() error {
+	return m.rotateScreen(true)
+}
+
+func (m *Manager) doRotateScreenLeft() error {
+	return m.rotateScreen(false)
+}
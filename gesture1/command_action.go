@@ -0,0 +1,261 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/common/procsubject"
+	polkit "github.com/linuxdeepin/go-dbus-factory/system/org.freedesktop.policykit1"
+	"github.com/linuxdeepin/go-lib/strv"
+)
+
+// dconfig 配置项，commandline 类型动作的执行方式
+const (
+	// allowlist 或 shell，默认为 allowlist，该配置项只读，只能由管理员修改，
+	// 用户通过 SetCommandExecMode 的 polkit 认证后只在本次会话中切换到 shell
+	dconfigKeyCommandExecMode = "commandExecMode"
+	// allowlist 方式下允许执行的程序，不包含 / 的规则匹配程序名，其他规则匹配程序的绝对路径，
+	// 该配置项只读，不能包含 xdotool、终端等可以启动任意程序的程序
+	dconfigKeyCommandAllowlist = "commandAllowlist"
+	// none 或 scope，为 scope 时在受限的 systemd 临时 scope 中执行命令
+	dconfigKeyCommandSandbox = "commandSandbox"
+)
+
+const (
+	// 不经过 shell 解析参数，只执行允许列表中的程序
+	commandExecAllowlist = "allowlist"
+	// 通过 /bin/sh 执行任意命令
+	commandExecShell = "shell"
+)
+
+const (
+	commandSandboxNone  = "none"
+	commandSandboxScope = "scope"
+)
+
+// 切换到 shell 方式需要通过的 polkit 认证
+const polkitActionCommandShell = "org.deepin.dde.gesture.command-shell"
+
+// systemd 临时 scope 中命令可以使用的最大内存
+const commandSandboxMemoryMax = "512M"
+
+// allowlist 方式下不支持的 shell 语法，这些字符需要放在引号中
+const shellMetaChars = "|&;<>()$`\n"
+
+func isValidCommandExecMode(mode string) bool {
+	switch mode {
+	case commandExecAllowlist, commandExecShell:
+		return true
+	}
+	return false
+}
+
+func isValidCommandSandbox(sandbox string) bool {
+	switch sandbox {
+	case commandSandboxNone, commandSandboxScope:
+		return true
+	}
+	return false
+}
+
+// parseCommandArgs 按 shell 的引号和转义规则把命令拆分为参数，不展开变量和通配符，
+// 命令中有管道、重定向等需要 shell 执行的语法时返回错误
+func parseCommandArgs(cmd string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range cmd {
+		switch {
+		case escaped:
+			if quote == '"' && !strings.ContainsRune("\"\\$`", r) {
+				arg.WriteRune('\\')
+			}
+			arg.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\':
+			escaped = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case strings.ContainsRune(shellMetaChars, r):
+			return nil, fmt.Errorf("shell syntax %q is not supported in command %q", r, cmd)
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if escaped || quote != 0 {
+		return nil, fmt.Errorf("unterminated quote or escape in command %q", cmd)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("command is empty")
+	}
+	return args, nil
+}
+
+// isCommandAllowed 程序名在允许列表中，或者在 PATH 中找到的程序的绝对路径在允许列表中时返回 true
+func isCommandAllowed(name string, allowlist []string) bool {
+	if strv.Strv(allowlist).Contains(name) {
+		return true
+	}
+	if strings.Contains(name, "/") {
+		return false
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return false
+	}
+	return strv.Strv(allowlist).Contains(path)
+}
+
+// sandboxCommand 在 systemd 临时 scope 中执行时禁止访问网络并限制内存
+func sandboxCommand(args []string, sandbox string) []string {
+	if sandbox != commandSandboxScope {
+		return args
+	}
+	result := []string{"systemd-run", "--user", "--scope", "--quiet", "--collect",
+		"-p", "MemoryMax=" + commandSandboxMemoryMax,
+		"-p", "IPAddressDeny=any",
+		"--"}
+	return append(result, args...)
+}
+
+func (m *Manager) setCommandExecMode(mode string) {
+	if !isValidCommandExecMode(mode) {
+		logger.Warningf("invalid command exec mode %q", mode)
+		mode = commandExecAllowlist
+	}
+	m.mu.Lock()
+	m.commandExecMode = mode
+	m.mu.Unlock()
+}
+
+func (m *Manager) setCommandSandbox(sandbox string) {
+	if !isValidCommandSandbox(sandbox) {
+		logger.Warningf("invalid command sandbox %q", sandbox)
+		sandbox = commandSandboxNone
+	}
+	m.mu.Lock()
+	m.commandSandbox = sandbox
+	m.mu.Unlock()
+}
+
+func (m *Manager) setCommandAllowlist(list []string) {
+	m.mu.Lock()
+	m.commandAllowlist = list
+	m.mu.Unlock()
+}
+
+// commandArgs 返回执行命令使用的参数，allowlist 方式下命令不在允许列表中时返回错误
+func (m *Manager) commandArgs(cmd string) ([]string, error) {
+	m.mu.RLock()
+	mode, sandbox, allowlist := m.commandExecMode, m.commandSandbox, m.commandAllowlist
+	m.mu.RUnlock()
+
+	args := []string{"/bin/sh", "-c", cmd}
+	if mode != commandExecShell {
+		var err error
+		args, err = parseCommandArgs(cmd)
+		if err != nil {
+			return nil, err
+		}
+		if !isCommandAllowed(args[0], allowlist) {
+			return nil, fmt.Errorf("command %q is not in the allowlist", args[0])
+		}
+	}
+	return sandboxCommand(args, sandbox), nil
+}
+
+func (m *Manager) execCommandline(cmd string) error {
+	args, err := m.commandArgs(cmd)
+	if err != nil {
+		return err
+	}
+	// #nosec G204
+	out, err := exec.Command(args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v: %s", err, string(out))
+	}
+	return nil
+}
+
+// checkCommandAction 添加 commandline 类型的动作时检查命令能否执行
+func (m *Manager) checkCommandAction(action ActionInfo) error {
+	if action.Type != ActionTypeCommandline {
+		return nil
+	}
+	_, err := m.commandArgs(action.Action)
+	return err
+}
+
+func (m *Manager) checkPolkitAuth(sender dbus.Sender, actionId string) error {
+	pid, err := m.service.GetConnPID(string(sender))
+	if err != nil {
+		return err
+	}
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	subject, err := procsubject.New(pid)
+	if err != nil {
+		return err
+	}
+	authority := polkit.NewAuthority(systemBus)
+	result, err := authority.CheckAuthorization(0, subject, actionId, nil,
+		polkit.CheckAuthorizationFlagsAllowUserInteraction, "")
+	if err != nil {
+		return err
+	}
+	if !result.IsAuthorized {
+		return errors.New("not authorized")
+	}
+	return nil
+}
+
+// setCommandExecModeBySender 切换到 shell 方式需要通过 polkit 认证，切换回 allowlist 方式不需要，
+// 不保存到用户可以修改的配置中，重启后恢复为 dconfig 中的执行方式
+func (m *Manager) setCommandExecModeBySender(sender dbus.Sender, mode string) error {
+	if !isValidCommandExecMode(mode) {
+		return fmt.Errorf("invalid command exec mode %q", mode)
+	}
+	if mode == commandExecShell {
+		err := m.checkPolkitAuth(sender, polkitActionCommandShell)
+		if err != nil {
+			return err
+		}
+	}
+	m.setCommandExecMode(mode)
+	return nil
+}
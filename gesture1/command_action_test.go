@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：不经过 shell 拆分命令的参数
+func Test_parseCommandArgs(t *testing.T) {
+	args, err := parseCommandArgs(`dde-file-manager  --show-item '/tmp/a b' "c \"d\" \e" f\ g`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dde-file-manager", "--show-item", "/tmp/a b", `c "d" \e`, "f g"}, args)
+
+	args, err = parseCommandArgs(`xdotool key '' "$HOME"`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"xdotool", "key", "", "$HOME"}, args)
+
+	for _, cmd := range []string{"ls | grep a", "ls; rm a", "ls > a", "echo $HOME", "echo `id`", "a && b", "'a", `a\`, "  "} {
+		_, err = parseCommandArgs(cmd)
+		assert.Error(t, err, cmd)
+	}
+}
+
+// 测试：允许列表中的程序名和绝对路径
+func Test_isCommandAllowed(t *testing.T) {
+	allowlist := []string{"deepin-terminal", "/usr/local/bin/my-tool"}
+	assert.True(t, isCommandAllowed("deepin-terminal", allowlist))
+	assert.True(t, isCommandAllowed("/usr/local/bin/my-tool", allowlist))
+	assert.False(t, isCommandAllowed("/tmp/deepin-terminal", allowlist))
+	assert.False(t, isCommandAllowed("rm", allowlist))
+	assert.False(t, isCommandAllowed("rm", nil))
+}
+
+// 测试：allowlist、shell 和沙盒方式下执行命令使用的参数
+func Test_commandArgs(t *testing.T) {
+	m := &Manager{
+		commandExecMode:  commandExecAllowlist,
+		commandSandbox:   commandSandboxNone,
+		commandAllowlist: []string{"deepin-terminal"},
+	}
+	args, err := m.commandArgs("deepin-terminal -e top")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"deepin-terminal", "-e", "top"}, args)
+	_, err = m.commandArgs("rm -rf /tmp/a")
+	assert.Error(t, err)
+	_, err = m.commandArgs("deepin-terminal | cat")
+	assert.Error(t, err)
+	assert.Error(t, m.checkCommandAction(ActionInfo{Type: ActionTypeCommandline, Action: "rm a"}))
+	assert.NoError(t, m.checkCommandAction(ActionInfo{Type: ActionTypeShortcut, Action: "ctrl+a"}))
+
+	m.commandSandbox = commandSandboxScope
+	args, err = m.commandArgs("deepin-terminal")
+	assert.NoError(t, err)
+	assert.Equal(t, "systemd-run", args[0])
+	assert.Contains(t, args, "MemoryMax="+commandSandboxMemoryMax)
+	assert.Equal(t, []string{"--", "deepin-terminal"}, args[len(args)-2:])
+
+	m.commandExecMode = commandExecShell
+	m.commandSandbox = commandSandboxNone
+	args, err = m.commandArgs("ls | grep a")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/bin/sh", "-c", "ls | grep a"}, args)
+}
+
+// 测试：无效的执行方式和沙盒使用安全的默认值
+func Test_setCommandExecMode(t *testing.T) {
+	m := &Manager{}
+	m.setCommandExecMode("unknown")
+	assert.Equal(t, commandExecAllowlist, m.commandExecMode)
+	m.setCommandExecMode(commandExecShell)
+	assert.Equal(t, commandExecShell, m.commandExecMode)
+	m.setCommandSandbox("")
+	assert.Equal(t, commandSandboxNone, m.commandSandbox)
+}
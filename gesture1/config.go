@@ -5,12 +5,16 @@
 package gesture1
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/adrg/xdg"
+	"github.com/linuxdeepin/go-lib/strv"
 	"github.com/linuxdeepin/go-lib/xdg/basedir"
 )
 
@@ -18,6 +22,12 @@ const (
 	ActionTypeShortcut    = "shortcut"
 	ActionTypeCommandline = "commandline"
 	ActionTypeBuiltin     = "built-in"
+	// ActionTypeSignal 不执行动作，仅发出 GestureTriggered 信号，由外部程序处理
+	ActionTypeSignal = "signal"
+	// ActionTypeDBus 直接调用 DBus 字段中的 D-Bus 方法，不依赖 dbus-send 等命令
+	ActionTypeDBus = "dbus"
+	// ActionTypeChain 按顺序执行 Chain 字段中的多个动作
+	ActionTypeChain = "chain"
 )
 
 var (
@@ -25,6 +35,9 @@ var (
 	configSystemPath, _ = xdg.SearchDataFile("dde-daemon/gesture.json")
 )
 
+// dconfig 配置项，窗口管理器自己处理的手势，格式见 EventInfo.key，如 swipe-up-4
+const dconfigKeyWmNativeGestures = "wmNativeGestures"
+
 const (
 	gestureSchemaId         = "com.deepin.dde.gesture"
 	gsKeyTouchPadEnabled    = "touch-pad-enabled"
@@ -36,26 +49,109 @@ const (
 type ActionInfo struct {
 	Type   string
 	Action string
+	DBus   *DBusCallInfo `json:",omitempty"`
+	Chain  actionChain   `json:",omitempty"`
 }
 
 type EventInfo struct {
 	Name      string
 	Direction string
 	Fingers   int32
+	// 需要同时按住的修饰键，格式见 normalizeModifiers，如 ctrl+shift，为空时不要求修饰键
+	Modifiers string `json:",omitempty"`
+	// 点击位置所在的角，见 tapCorners，只有触摸屏的 tap 手势有位置，为空时不要求位置
+	Corner string `json:",omitempty"`
+}
+
+// 用户配置文件版本，版本 0 的配置文件只有手势信息数组，
+// 版本 2 的默认配置包含四指和五指手势，升级时补充到用户配置中
+const gestureConfigVersion = 2
+
+// 版本 2 补充的默认手势的最少手指数
+const multiFingerGestureMinFingers = 4
+
+// gestureConfig 用户配置文件内容，除手势信息外还保存需要在重启后保留的辅助配置
+type gestureConfig struct {
+	Version    int
+	Infos      gestureInfos
+	TouchEdges touchEdgeConfigs   `json:",omitempty"` // 触摸屏边缘手势的配置
+	Cooldowns  map[string]uint32  `json:",omitempty"` // 手势动作的冷却时间，单位毫秒，key 见 EventInfo.key
+	Thresholds *gestureThresholds `json:",omitempty"` // 手势的识别阈值
+	LongPress  *longPressConfig   `json:",omitempty"` // 触摸屏长按的行为
+	Stats      map[string]uint64  `json:",omitempty"` // 手势动作的执行次数，key 见 EventInfo.key
+	Failures   map[string]uint64  `json:",omitempty"` // 手势动作执行失败的次数，key 见 EventInfo.key
+	Tablet     *gestureMap        `json:",omitempty"` // 平板模式的手势映射，为空时使用默认的平板模式映射
 }
 
 type gestureInfo struct {
 	Event  EventInfo
 	Action ActionInfo
+	// 为 false 时暂时不执行动作，但保留动作的配置，为空时启用
+	Enabled *bool `json:",omitempty"`
 }
 type gestureInfos []*gestureInfo
 
+func (info *gestureInfo) isEnabled() bool {
+	return info.Enabled == nil || *info.Enabled
+}
+
 func (action ActionInfo) toString() string {
+	if action.DBus != nil {
+		return fmt.Sprintf("Type:%s, DBus=%s", action.Type, action.DBus.toString())
+	}
+	if action.Chain != nil {
+		return fmt.Sprintf("Type:%s, Chain=%s", action.Type, action.Chain.toString())
+	}
 	return fmt.Sprintf("Type:%s, Action=%s", action.Type, action.Action)
 }
 
+// newActionInfo ActionTypeDBus 类型的动作中 action 为 DBusCallInfo 的 json，
+// ActionTypeChain 类型的动作中 action 为 ActionStep 数组的 json
+func newActionInfo(actionType, action string) (ActionInfo, error) {
+	switch actionType {
+	case ActionTypeDBus:
+		call, err := newDBusCallInfo(action)
+		if err != nil {
+			return ActionInfo{}, err
+		}
+		return ActionInfo{Type: actionType, DBus: call}, nil
+	case ActionTypeChain:
+		steps, err := newActionChain(action)
+		if err != nil {
+			return ActionInfo{}, err
+		}
+		return ActionInfo{Type: actionType, Chain: steps}, nil
+	}
+	return ActionInfo{Type: actionType, Action: action}, nil
+}
+
 func (evInfo EventInfo) toString() string {
-	return fmt.Sprintf("Name=%s, Direction=%s, Fingers=%d", evInfo.Name, evInfo.Direction, evInfo.Fingers)
+	str := fmt.Sprintf("Name=%s, Direction=%s, Fingers=%d", evInfo.Name, evInfo.Direction, evInfo.Fingers)
+	if evInfo.Modifiers != "" {
+		str += ", Modifiers=" + evInfo.Modifiers
+	}
+	if evInfo.Corner != "" {
+		str += ", Corner=" + evInfo.Corner
+	}
+	return str
+}
+
+// key 有修饰键时以修饰键开头，如 ctrl+swipe-up-3，有角时以角结尾，如 tap-none-3-top-right
+func (evInfo EventInfo) key() string {
+	key := fmt.Sprintf("%s-%s-%d", evInfo.Name, evInfo.Direction, evInfo.Fingers)
+	if evInfo.Corner != "" {
+		key += "-" + evInfo.Corner
+	}
+	if evInfo.Modifiers != "" {
+		return evInfo.Modifiers + modifierSeparator + key
+	}
+	return key
+}
+
+// withoutModifiers 窗口管理器自己处理的手势不区分修饰键
+func (evInfo EventInfo) withoutModifiers() EventInfo {
+	evInfo.Modifiers = ""
+	return evInfo
 }
 
 func (infos gestureInfos) Get(evInfo EventInfo) *gestureInfo {
@@ -76,20 +172,206 @@ func (infos gestureInfos) Set(evInfo EventInfo, action ActionInfo) error {
 	return nil
 }
 
+// Add 添加手势，手势已存在时返回错误
+func (infos gestureInfos) Add(evInfo EventInfo, action ActionInfo) (gestureInfos, error) {
+	if infos.Get(evInfo) != nil {
+		return nil, fmt.Errorf("gesture info already exists for: %s", evInfo.toString())
+	}
+	result := make(gestureInfos, 0, len(infos)+1)
+	result = append(result, infos...)
+	return append(result, &gestureInfo{Event: evInfo, Action: action}), nil
+}
+
+// Update 修改手势的动作，返回新的手势列表，不修改原来的手势信息
+func (infos gestureInfos) Update(evInfo EventInfo, action ActionInfo) (gestureInfos, error) {
+	result := make(gestureInfos, 0, len(infos))
+	found := false
+	for _, info := range infos {
+		if info.Event == evInfo && !found {
+			info = &gestureInfo{Event: evInfo, Action: action, Enabled: info.Enabled}
+			found = true
+		}
+		result = append(result, info)
+	}
+	if !found {
+		return nil, fmt.Errorf("not found gesture info for: %s", evInfo.toString())
+	}
+	return result, nil
+}
+
+// SetEnabled 启用或禁用手势，返回新的手势列表，不修改原来的手势信息
+func (infos gestureInfos) SetEnabled(evInfo EventInfo, enabled bool) (gestureInfos, error) {
+	result := make(gestureInfos, 0, len(infos))
+	found := false
+	for _, info := range infos {
+		if info.Event == evInfo && !found {
+			info = &gestureInfo{Event: info.Event, Action: info.Action}
+			if !enabled {
+				info.Enabled = &enabled
+			}
+			found = true
+		}
+		result = append(result, info)
+	}
+	if !found {
+		return nil, fmt.Errorf("not found gesture info for: %s", evInfo.toString())
+	}
+	return result, nil
+}
+
+// Delete 删除手势，返回新的手势列表
+func (infos gestureInfos) Delete(evInfo EventInfo) (gestureInfos, error) {
+	result := make(gestureInfos, 0, len(infos))
+	for _, info := range infos {
+		if info.Event != evInfo {
+			result = append(result, info)
+		}
+	}
+	if len(result) == len(infos) {
+		return nil, fmt.Errorf("not found gesture info for: %s", evInfo.toString())
+	}
+	return result, nil
+}
+
+// editable 去掉触摸屏长按等内部使用的手势，这些手势不允许编辑
+func (infos gestureInfos) editable() gestureInfos {
+	result := make(gestureInfos, 0, len(infos))
+	for _, info := range infos {
+		if checkEventInfo(info.Event) == nil {
+			result = append(result, info)
+		}
+	}
+	return result
+}
+
+// mergeDefaults 补充 defaults 中手指数不少于 minFingers 且用户没有配置的手势
+func (infos gestureInfos) mergeDefaults(defaults gestureInfos, minFingers int32) gestureInfos {
+	result := make(gestureInfos, 0, len(infos))
+	result = append(result, infos...)
+	for _, info := range defaults {
+		if info.Event.Fingers < minFingers || result.Get(info.Event) != nil {
+			continue
+		}
+		result = append(result, &gestureInfo{Event: info.Event, Action: info.Action})
+	}
+	return result
+}
+
+// 可编辑的触摸板手势及其方向，与 system/gesture1 发出的 Event 信号一致
+var gestureEventDirections = map[string][]string{
+	"swipe":  {"up", "down", "left", "right"},
+	"pinch":  {"in", "out"},
+	"tap":    {"none"},
+	"rotate": {"clockwise", "counterclockwise"},
+}
+
+const (
+	// 双指滑动用于滚动，swipe 和 tap 至少需要三指，pinch 和 rotate 可以使用双指
+	minGestureFingers      = 3
+	minPinchGestureFingers = 2
+	maxGestureFingers      = 5
+)
+
+func checkEventInfo(evInfo EventInfo) error {
+	directions, ok := gestureEventDirections[evInfo.Name]
+	if !ok {
+		return fmt.Errorf("invalid gesture name %q", evInfo.Name)
+	}
+	if !strv.Strv(directions).Contains(evInfo.Direction) {
+		return fmt.Errorf("invalid direction %q for gesture %s", evInfo.Direction, evInfo.Name)
+	}
+	minFingers := int32(minGestureFingers)
+	if evInfo.Name == "pinch" || evInfo.Name == "rotate" {
+		minFingers = minPinchGestureFingers
+	}
+	if evInfo.Fingers < minFingers || evInfo.Fingers > maxGestureFingers {
+		return fmt.Errorf("invalid fingers %d for gesture %s", evInfo.Fingers, evInfo.Name)
+	}
+	err := checkTapCorner(evInfo)
+	if err != nil {
+		return err
+	}
+	return checkModifiers(evInfo.Modifiers)
+}
+
+func checkActionInfo(action ActionInfo, builtinSets map[string]func() error) error {
+	switch action.Type {
+	case ActionTypeShortcut, ActionTypeCommandline:
+		if strings.TrimSpace(action.Action) == "" {
+			return fmt.Errorf("action of type %s is empty", action.Type)
+		}
+	case ActionTypeBuiltin:
+		if _, ok := builtinSets[action.Action]; !ok {
+			return fmt.Errorf("invalid built-in action %q", action.Action)
+		}
+	case ActionTypeSignal:
+		// 由外部程序处理，不需要动作
+	case ActionTypeDBus:
+		return checkDBusCallInfo(action.DBus)
+	case ActionTypeChain:
+		return checkActionChain(action.Chain, builtinSets)
+	default:
+		return fmt.Errorf("invalid action type: %s", action.Type)
+	}
+	return nil
+}
+
 func newGestureInfosFromFile(filename string) (gestureInfos, error) {
+	cfg, err := newGestureConfigFromFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Infos, nil
+}
+
+func newGestureConfigFromFile(filename string) (*gestureConfig, error) {
 	content, err := ioutil.ReadFile(filepath.Clean(filename))
 	if err != nil {
 		return nil, err
 	}
 
+	content = bytes.TrimSpace(content)
 	if len(content) == 0 {
 		return nil, fmt.Errorf("file '%s' is empty", filename)
 	}
 
-	var infos gestureInfos
-	err = json.Unmarshal(content, &infos)
+	var cfg gestureConfig
+	// 兼容旧版本只有手势信息数组的配置文件
+	if content[0] == '[' {
+		err = json.Unmarshal(content, &cfg.Infos)
+	} else {
+		err = json.Unmarshal(content, &cfg)
+	}
 	if err != nil {
 		return nil, err
 	}
-	return infos, nil
+	return &cfg, nil
+}
+
+// writeFile 先写入临时文件再重命名，避免写入过程中崩溃导致配置文件损坏
+func (cfg *gestureConfig) writeFile(filename string) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	// #nosec G301
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return err
+	}
+
+	tmpFile := filename + ".tmp"
+	// #nosec G306
+	err = ioutil.WriteFile(tmpFile, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpFile, filename)
+	if err != nil {
+		_ = os.Remove(tmpFile)
+		return err
+	}
+	return nil
 }
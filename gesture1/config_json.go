@@ -0,0 +1,205 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/linuxdeepin/go-lib/strv"
+)
+
+// jsonErrorWithPosition 在 json 解析错误中加上出错位置的行号和列号
+func jsonErrorWithPosition(data []byte, offset int64, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) {
+		// Offset 是读取出错的字符之后的位置
+		offset = syntaxErr.Offset - 1
+	} else if errors.As(err, &typeErr) {
+		offset = typeErr.Offset
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	} else if offset < 0 {
+		offset = 0
+	}
+	line, column := 1, 1
+	for _, b := range data[:offset] {
+		if b == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return fmt.Errorf("line %d, column %d: %v", line, column, err)
+}
+
+// parseEventKey 解析 EventInfo.key 格式的字符串，如 swipe-up-3、ctrl+swipe-up-3 或 tap-none-3-top-left，
+// 角本身包含 -，因此只拆分前 3 个字段
+func parseEventKey(key string) (EventInfo, error) {
+	var modifiers string
+	if idx := strings.LastIndex(key, modifierSeparator); idx >= 0 {
+		modifiers, key = key[:idx], key[idx+1:]
+	}
+	fields := strings.SplitN(key, "-", 4)
+	if len(fields) < 3 {
+		return EventInfo{}, fmt.Errorf("invalid gesture key %q", key)
+	}
+	fingers, err := strconv.ParseInt(fields[2], 10, 32)
+	if err != nil {
+		return EventInfo{}, fmt.Errorf("invalid gesture key %q", key)
+	}
+	evInfo := EventInfo{Name: fields[0], Direction: fields[1], Fingers: int32(fingers), Modifiers: modifiers}
+	if len(fields) == 4 {
+		if !strv.Strv(tapCorners).Contains(fields[3]) {
+			return EventInfo{}, fmt.Errorf("invalid gesture key %q", key)
+		}
+		evInfo.Corner = fields[3]
+	}
+	return evInfo, nil
+}
+
+// decodeJSONStrict 解析 D-Bus 接口传入的 json 文档，不允许未知的字段
+func decodeJSONStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err != nil {
+		return jsonErrorWithPosition(data, dec.InputOffset(), err)
+	}
+	if dec.More() {
+		return jsonErrorWithPosition(data, dec.InputOffset(), errors.New("unexpected data after config"))
+	}
+	return nil
+}
+
+// parseGestureConfig 解析 SetGestureConfig 传入的配置
+func parseGestureConfig(data []byte) (*gestureConfig, error) {
+	var cfg gestureConfig
+	err := decodeJSONStrict(data, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// parseGestureMap 解析 SetGestureMap 传入的手势映射
+func parseGestureMap(data []byte) (*gestureMap, error) {
+	var gm gestureMap
+	err := decodeJSONStrict(data, &gm)
+	if err != nil {
+		return nil, err
+	}
+	return &gm, nil
+}
+
+// check 检查配置的内容，错误信息中包含出错的字段
+func (cfg *gestureConfig) check(builtinSets map[string]func() error, wmNativeGestures []string) error {
+	if cfg.Version != gestureConfigVersion {
+		return fmt.Errorf("Version: unsupported version %d, expect %d", cfg.Version, gestureConfigVersion)
+	}
+
+	err := checkGestureMap(cfg.Infos, cfg.TouchEdges, builtinSets, wmNativeGestures)
+	if err != nil {
+		return err
+	}
+	if cfg.Tablet != nil {
+		err = checkGestureMap(cfg.Tablet.Infos, cfg.Tablet.TouchEdges, builtinSets, wmNativeGestures)
+		if err != nil {
+			return fmt.Errorf("Tablet.%v", err)
+		}
+	}
+
+	if cfg.Thresholds != nil {
+		err = cfg.Thresholds.check()
+		if err != nil {
+			return fmt.Errorf("Thresholds.%v", err)
+		}
+	}
+
+	if cfg.LongPress != nil {
+		err = cfg.LongPress.check(builtinSets)
+		if err != nil {
+			return fmt.Errorf("LongPress.%v", err)
+		}
+	}
+
+	for key := range cfg.Cooldowns {
+		evInfo, err := parseEventKey(key)
+		if err == nil {
+			err = checkEventInfo(evInfo)
+		}
+		if err != nil {
+			return fmt.Errorf("Cooldowns[%q]: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// checkGestureMap 检查一种模式的手势和触摸屏边缘配置
+func checkGestureMap(infos gestureInfos, edges touchEdgeConfigs, builtinSets map[string]func() error, wmNativeGestures []string) error {
+	keys := make(map[string]bool, len(infos))
+	for i, info := range infos {
+		if info == nil {
+			return fmt.Errorf("Infos[%d]: gesture is null", i)
+		}
+		err := checkEventInfo(info.Event)
+		if err != nil {
+			return fmt.Errorf("Infos[%d].Event: %v", i, err)
+		}
+		key := info.Event.key()
+		if keys[key] {
+			return fmt.Errorf("Infos[%d].Event: duplicate gesture %s", i, key)
+		}
+		keys[key] = true
+		if strv.Strv(wmNativeGestures).Contains(info.Event.withoutModifiers().key()) {
+			return fmt.Errorf("Infos[%d].Event: gesture %s conflicts with window manager", i, key)
+		}
+		err = checkActionInfo(info.Action, builtinSets)
+		if err != nil {
+			return fmt.Errorf("Infos[%d].Action: %v", i, err)
+		}
+	}
+
+	for edge, edgeCfg := range edges {
+		if !isTouchEdge(edge) {
+			return fmt.Errorf("TouchEdges[%q]: invalid touch edge", edge)
+		}
+		if edgeCfg == nil {
+			return fmt.Errorf("TouchEdges[%q]: config is null", edge)
+		}
+		if edgeCfg.Action != nil {
+			err := checkActionInfo(*edgeCfg.Action, builtinSets)
+			if err != nil {
+				return fmt.Errorf("TouchEdges[%q].Action: %v", edge, err)
+			}
+		}
+		if edgeCfg.HideAction != nil {
+			err := checkActionInfo(*edgeCfg.HideAction, builtinSets)
+			if err != nil {
+				return fmt.Errorf("TouchEdges[%q].HideAction: %v", edge, err)
+			}
+		}
+		if edgeCfg.DoubleTapAction != nil {
+			if !isDoubleTapEdge(edge) {
+				return fmt.Errorf("TouchEdges[%q].DoubleTapAction: edge does not support double tap", edge)
+			}
+			err := checkActionInfo(*edgeCfg.DoubleTapAction, builtinSets)
+			if err != nil {
+				return fmt.Errorf("TouchEdges[%q].DoubleTapAction: %v", edge, err)
+			}
+		}
+		if edgeCfg.Distance > maxTouchEdgeDistance {
+			return fmt.Errorf("TouchEdges[%q].Distance: invalid distance %d", edge, edgeCfg.Distance)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：解析配置时返回出错的位置
+func Test_parseGestureConfig(t *testing.T) {
+	cfg, err := parseGestureConfig([]byte(`{
+	"Version": 2,
+	"Infos": [{"Event": {"Name": "swipe", "Direction": "up", "Fingers": 3},
+		"Action": {"Type": "built-in", "Action": "ShowWorkspace"}}],
+	"Cooldowns": {"swipe-up-3": 500}
+}`))
+	assert.NoError(t, err)
+	assert.Len(t, cfg.Infos, 1)
+	assert.Equal(t, uint32(500), cfg.Cooldowns["swipe-up-3"])
+
+	_, err = parseGestureConfig([]byte("{\n\t\"Version\": 2,\n\t\"Infos\": [,]\n}"))
+	assert.EqualError(t, err, "line 3, column 12: invalid character ',' looking for beginning of value")
+
+	_, err = parseGestureConfig([]byte("{\n\t\"Version\": \"2\"\n}"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2, column 16:")
+
+	_, err = parseGestureConfig([]byte(`{"Version": 2, "Unknown": 1}`))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown field")
+
+	_, err = parseGestureConfig([]byte(`{"Version": 2} {}`))
+	assert.Error(t, err)
+
+	gm, err := parseGestureMap([]byte(`{"Infos": [], "TouchEdges": {"top": {"Distance": 50}}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(50), gm.TouchEdges[touchEdgeTop].Distance)
+	_, err = parseGestureMap([]byte(`{"Version": 2}`))
+	assert.Error(t, err)
+}
+
+// 测试：检查配置的内容
+func Test_gestureConfigCheck(t *testing.T) {
+	builtinSets := map[string]func() error{"ShowWorkspace": nil}
+	swipeUp := &gestureInfo{
+		Event:  EventInfo{Name: "swipe", Direction: "up", Fingers: 3},
+		Action: ActionInfo{Type: ActionTypeBuiltin, Action: "ShowWorkspace"},
+	}
+	cfg := &gestureConfig{
+		Version:   gestureConfigVersion,
+		Infos:     gestureInfos{swipeUp},
+		Cooldowns: map[string]uint32{"swipe-up-3": 500},
+		TouchEdges: touchEdgeConfigs{
+			touchEdgeLeft: {Action: &ActionInfo{Type: ActionTypeSignal}, Distance: 100},
+		},
+	}
+	assert.NoError(t, cfg.check(builtinSets, nil))
+	assert.EqualError(t, cfg.check(builtinSets, []string{"swipe-up-3"}),
+		"Infos[0].Event: gesture swipe-up-3 conflicts with window manager")
+
+	cfg.Version = 1
+	assert.Error(t, cfg.check(builtinSets, nil))
+	cfg.Version = gestureConfigVersion
+
+	cfg.Infos = gestureInfos{swipeUp, swipeUp}
+	assert.EqualError(t, cfg.check(builtinSets, nil), "Infos[1].Event: duplicate gesture swipe-up-3")
+	cfg.Infos = gestureInfos{swipeUp}
+
+	cfg.TouchEdges[touchEdgeLeft].Distance = maxTouchEdgeDistance + 1
+	assert.Error(t, cfg.check(builtinSets, nil))
+	cfg.TouchEdges[touchEdgeLeft].Distance = 100
+
+	cfg.TouchEdges[touchEdgeLeft].DoubleTapAction = &ActionInfo{Type: ActionTypeSignal}
+	assert.EqualError(t, cfg.check(builtinSets, nil),
+		`TouchEdges["left"].DoubleTapAction: edge does not support double tap`)
+	cfg.TouchEdges[touchEdgeLeft].DoubleTapAction = nil
+
+	cfg.Tablet = &gestureMap{Infos: gestureInfos{swipeUp, swipeUp}}
+	assert.EqualError(t, cfg.check(builtinSets, nil), "Tablet.Infos[1].Event: duplicate gesture swipe-up-3")
+	cfg.Tablet = &gestureMap{Infos: gestureInfos{swipeUp}}
+	assert.NoError(t, cfg.check(builtinSets, nil))
+
+	cfg.Cooldowns["swipe-up"] = 100
+	assert.Error(t, cfg.check(builtinSets, nil))
+}
+
+// 测试：解析手势的 key
+func Test_parseEventKey(t *testing.T) {
+	evInfo, err := parseEventKey("pinch-in-2")
+	assert.NoError(t, err)
+	assert.Equal(t, EventInfo{Name: "pinch", Direction: "in", Fingers: 2}, evInfo)
+	assert.Equal(t, "pinch-in-2", evInfo.key())
+
+	_, err = parseEventKey("pinch-in-x")
+	assert.Error(t, err)
+
+	evInfo, err = parseEventKey("ctrl+shift+swipe-up-3")
+	assert.NoError(t, err)
+	assert.Equal(t, EventInfo{Name: "swipe", Direction: "up", Fingers: 3, Modifiers: "ctrl+shift"}, evInfo)
+	assert.Equal(t, "ctrl+shift+swipe-up-3", evInfo.key())
+
+	_, err = parseEventKey("tap-none-3-middle")
+	assert.Error(t, err)
+}
+
+// 测试：所有角的点击手势都能从 key 解析回来
+func Test_parseEventKeyCorner(t *testing.T) {
+	for _, corner := range tapCorners {
+		for _, modifiers := range []string{"", "ctrl"} {
+			want := EventInfo{Name: "tap", Direction: "none", Fingers: 3, Corner: corner, Modifiers: modifiers}
+			evInfo, err := parseEventKey(want.key())
+			assert.NoError(t, err)
+			assert.Equal(t, want, evInfo)
+		}
+	}
+}
@@ -5,6 +5,7 @@
 package gesture1
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -107,3 +108,173 @@ func Test_Set(t *testing.T) {
 	assert.Nil(t, infos.Set(EventInfo{Name: "swipe", Direction: "up", Fingers: 3}, action2))
 	assert.Nil(t, infos.Set(EventInfo{Name: "swipe", Direction: "down", Fingers: 3}, action2))
 }
+
+// 测试：配置文件原子写入
+func Test_gestureConfigWriteFile(t *testing.T) {
+	infos, err := newGestureInfosFromFile(configPath)
+	assert.NoError(t, err)
+
+	filename := filepath.Join(t.TempDir(), "deepin/dde-daemon/gesture.json")
+	cfg := &gestureConfig{
+		Version: gestureConfigVersion,
+		Infos:   infos,
+	}
+	assert.NoError(t, cfg.writeFile(filename))
+	assert.NoFileExists(t, filename+".tmp")
+
+	// 覆盖已存在的配置文件
+	cfg.Infos = infos[:1]
+	assert.NoError(t, cfg.writeFile(filename))
+	assert.NoFileExists(t, filename+".tmp")
+
+	cfg1, err := newGestureConfigFromFile(filename)
+	assert.NoError(t, err)
+	assert.Len(t, cfg1.Infos, 1)
+}
+
+// 测试：扩展配置的读写
+func Test_gestureConfigRoundTrip(t *testing.T) {
+	infos, err := newGestureInfosFromFile(configPath)
+	assert.NoError(t, err)
+
+	evInfo := EventInfo{Name: "swipe", Direction: "up", Fingers: 3}
+	cfg := &gestureConfig{
+		Version:   gestureConfigVersion,
+		Infos:     infos,
+		Cooldowns: map[string]uint32{evInfo.key(): 500},
+		Stats:     map[string]uint64{evInfo.key(): 10},
+	}
+	filename := filepath.Join(t.TempDir(), "gesture.json")
+	assert.NoError(t, cfg.writeFile(filename))
+
+	cfg1, err := newGestureConfigFromFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, gestureConfigVersion, cfg1.Version)
+	assert.Equal(t, len(infos), len(cfg1.Infos))
+	assert.NotNil(t, cfg1.Infos.Get(evInfo))
+	assert.Equal(t, uint32(500), cfg1.Cooldowns[evInfo.key()])
+	assert.Equal(t, uint64(10), cfg1.Stats[evInfo.key()])
+}
+
+// 测试：读取旧版本的配置文件
+func Test_newGestureConfigFromFileOldVersion(t *testing.T) {
+	cfg, err := newGestureConfigFromFile(configPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, cfg.Version)
+	assert.NotEmpty(t, cfg.Infos)
+	assert.Nil(t, cfg.Cooldowns)
+	assert.Nil(t, cfg.Stats)
+}
+
+// 测试：添加、修改和删除手势
+func Test_gestureInfosEdit(t *testing.T) {
+	infos, err := newGestureInfosFromFile(configPath)
+	assert.NoError(t, err)
+
+	evInfo := EventInfo{Name: "pinch", Direction: "in", Fingers: 3}
+	action := ActionInfo{Type: ActionTypeShortcut, Action: "ctrl+minus"}
+	infos1, err := infos.Add(evInfo, action)
+	assert.NoError(t, err)
+	assert.Len(t, infos1, len(infos)+1)
+	assert.Nil(t, infos.Get(evInfo))
+	_, err = infos1.Add(evInfo, action)
+	assert.Error(t, err)
+
+	action1 := ActionInfo{Type: ActionTypeBuiltin, Action: "ShowWorkspace"}
+	infos2, err := infos1.Update(evInfo, action1)
+	assert.NoError(t, err)
+	assert.Equal(t, action1, infos2.Get(evInfo).Action)
+	// 不修改原来的手势信息
+	assert.Equal(t, action, infos1.Get(evInfo).Action)
+	_, err = infos.Update(evInfo, action1)
+	assert.Error(t, err)
+
+	infos3, err := infos2.Delete(evInfo)
+	assert.NoError(t, err)
+	assert.Len(t, infos3, len(infos))
+	assert.Nil(t, infos3.Get(evInfo))
+	_, err = infos3.Delete(evInfo)
+	assert.Error(t, err)
+}
+
+// 测试：禁用手势时保留动作，修改动作时保留是否启用
+func Test_gestureInfosSetEnabled(t *testing.T) {
+	evInfo := EventInfo{Name: "swipe", Direction: "up", Fingers: 3}
+	action := ActionInfo{Type: ActionTypeBuiltin, Action: "ShowWorkspace"}
+	infos := gestureInfos{{Event: evInfo, Action: action}}
+	assert.True(t, infos.Get(evInfo).isEnabled())
+
+	infos1, err := infos.SetEnabled(evInfo, false)
+	assert.NoError(t, err)
+	assert.False(t, infos1.Get(evInfo).isEnabled())
+	assert.Equal(t, action, infos1.Get(evInfo).Action)
+	// 不修改原来的手势信息
+	assert.True(t, infos.Get(evInfo).isEnabled())
+
+	action1 := ActionInfo{Type: ActionTypeShortcut, Action: "ctrl+alt+d"}
+	infos2, err := infos1.Update(evInfo, action1)
+	assert.NoError(t, err)
+	assert.False(t, infos2.Get(evInfo).isEnabled())
+
+	infos3, err := infos2.SetEnabled(evInfo, true)
+	assert.NoError(t, err)
+	assert.True(t, infos3.Get(evInfo).isEnabled())
+	assert.Nil(t, infos3.Get(evInfo).Enabled)
+
+	_, err = infos.SetEnabled(EventInfo{Name: "pinch", Direction: "in", Fingers: 3}, false)
+	assert.Error(t, err)
+}
+
+// 测试：检查手势和动作
+func Test_checkGestureInfo(t *testing.T) {
+	assert.NoError(t, checkEventInfo(EventInfo{Name: "swipe", Direction: "up", Fingers: 3}))
+	assert.NoError(t, checkEventInfo(EventInfo{Name: "pinch", Direction: "out", Fingers: 2}))
+	assert.NoError(t, checkEventInfo(EventInfo{Name: "tap", Direction: "none", Fingers: 5}))
+	assert.Error(t, checkEventInfo(EventInfo{Name: "swipe", Direction: "in", Fingers: 3}))
+	assert.Error(t, checkEventInfo(EventInfo{Name: "swipe", Direction: "up", Fingers: 2}))
+	assert.Error(t, checkEventInfo(EventInfo{Name: "tap", Direction: "none", Fingers: 6}))
+	assert.Error(t, checkEventInfo(EventInfo{Name: "touch right button", Direction: "down", Fingers: 0}))
+
+	builtinSets := map[string]func() error{"ShowWorkspace": nil}
+	assert.NoError(t, checkActionInfo(ActionInfo{Type: ActionTypeShortcut, Action: "ctrl+minus"}, builtinSets))
+	assert.NoError(t, checkActionInfo(ActionInfo{Type: ActionTypeBuiltin, Action: "ShowWorkspace"}, builtinSets))
+	assert.NoError(t, checkActionInfo(ActionInfo{Type: ActionTypeSignal}, builtinSets))
+	assert.Error(t, checkActionInfo(ActionInfo{Type: ActionTypeCommandline, Action: " "}, builtinSets))
+	assert.Error(t, checkActionInfo(ActionInfo{Type: ActionTypeBuiltin, Action: "Unknown"}, builtinSets))
+	assert.Error(t, checkActionInfo(ActionInfo{Type: "unknown", Action: "ls"}, builtinSets))
+}
+
+// 测试：默认配置中的手势和动作都是有效的
+func Test_defaultConfigValid(t *testing.T) {
+	infos, err := newGestureInfosFromFile("../misc/dde-daemon/gesture.json")
+	assert.NoError(t, err)
+
+	m := &Manager{}
+	m.initBuiltinSets()
+	for _, info := range infos {
+		assert.NoError(t, checkEventInfo(info.Event), info.Event.toString())
+		assert.NoError(t, checkActionInfo(info.Action, m.builtinSets), info.Action.toString())
+	}
+	assert.NotNil(t, infos.Get(EventInfo{Name: "pinch", Direction: "in", Fingers: 4}))
+	assert.NotNil(t, infos.Get(EventInfo{Name: "pinch", Direction: "out", Fingers: 4}))
+}
+
+// 测试：升级时补充默认的四指和五指手势
+func Test_gestureInfosMergeDefaults(t *testing.T) {
+	defaults, err := newGestureInfosFromFile(configPath)
+	assert.NoError(t, err)
+
+	swipeUp3 := EventInfo{Name: "swipe", Direction: "up", Fingers: 3}
+	swipeUp4 := EventInfo{Name: "swipe", Direction: "up", Fingers: 4}
+	action := ActionInfo{Type: ActionTypeShortcut, Action: "ctrl+alt+u"}
+	infos := gestureInfos{{Event: swipeUp4, Action: action}}
+
+	merged := infos.mergeDefaults(defaults, multiFingerGestureMinFingers)
+	// 保留用户配置的动作
+	assert.Equal(t, action, merged.Get(swipeUp4).Action)
+	// 不补充三指手势
+	assert.Nil(t, merged.Get(swipeUp3))
+	assert.NotNil(t, merged.Get(EventInfo{Name: "swipe", Direction: "down", Fingers: 4}))
+	assert.NotNil(t, merged.Get(EventInfo{Name: "swipe", Direction: "left", Fingers: 5}))
+	assert.Len(t, infos, 1)
+}
@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// 手势冲突警告的类型
+const (
+	// 手势已经绑定了动作
+	conflictDuplicateGesture = "duplicate-gesture"
+	// 窗口管理器自己处理该手势
+	conflictWmNativeGesture = "wm-native-gesture"
+	// 快捷键动作模拟的按键与快捷键模块中的快捷键相同
+	conflictShortcut = "shortcut"
+)
+
+const (
+	keybindingService   = "org.deepin.dde.Keybinding1"
+	keybindingPath      = "/org/deepin/dde/Keybinding1"
+	keybindingInterface = "org.deepin.dde.Keybinding1"
+)
+
+// gestureWarning 不影响保存手势，由设置界面决定是否提示用户
+type gestureWarning struct {
+	Type    string
+	Gesture string // 见 EventInfo.key
+	Message string
+}
+
+// 快捷键模块中修饰键的名称
+var keystrokeModifiers = map[string]string{
+	"shift_l":   "Shift",
+	"shift_r":   "Shift",
+	"control_l": "Control",
+	"control_r": "Control",
+	"alt_l":     "Alt",
+	"alt_r":     "Alt",
+	"super_l":   "Super",
+	"super_r":   "Super",
+	"meta_l":    "Super",
+	"meta_r":    "Super",
+}
+
+// toKeystrokes 将 xdotool key 格式的按键转换为快捷键模块的格式，如 "ctrl+alt+t" 转换为 "<Control><Alt>t"
+func toKeystrokes(keys string) ([]string, error) {
+	combos, err := parseKeySequence(keys)
+	if err != nil {
+		return nil, err
+	}
+	var keystrokes []string
+	for _, combo := range combos {
+		var mods, key string
+		for _, name := range combo {
+			if mod, ok := keystrokeModifiers[strings.ToLower(name)]; ok {
+				mods += "<" + mod + ">"
+				continue
+			}
+			if key != "" {
+				return nil, fmt.Errorf("more than one key in %q", keys)
+			}
+			key = name
+		}
+		// 只有修饰键的按键不会触发快捷键
+		if key != "" {
+			keystrokes = append(keystrokes, mods+key)
+		}
+	}
+	return keystrokes, nil
+}
+
+// lookupConflictingShortcut 返回快捷键模块中使用 keystroke 的快捷键名称，没有时返回空字符串
+func lookupConflictingShortcut(keystroke string) (string, error) {
+	sessionBus, err := dbus.SessionBus()
+	if err != nil {
+		return "", err
+	}
+	var detail string
+	err = sessionBus.Object(keybindingService, keybindingPath).
+		Call(keybindingInterface+".LookupConflictingShortcut", 0, keystroke).Store(&detail)
+	if err != nil || detail == "" {
+		return "", err
+	}
+	var shortcut struct {
+		Id   string
+		Name string
+	}
+	err = json.Unmarshal([]byte(detail), &shortcut)
+	if err != nil {
+		return "", err
+	}
+	if shortcut.Name == "" {
+		return shortcut.Id, nil
+	}
+	return shortcut.Name, nil
+}
+
+// findGestureConflicts 检查 info 与已有手势、窗口管理器和快捷键模块的冲突，
+// lookupShortcut 用于查找快捷键模块中的快捷键
+func findGestureConflicts(info *gestureInfo, existing gestureInfos, wmNativeGestures []string,
+	lookupShortcut func(keystroke string) (string, error)) []gestureWarning {
+	var warnings []gestureWarning
+	key := info.Event.key()
+	if old := existing.Get(info.Event); old != nil {
+		warnings = append(warnings, gestureWarning{
+			Type:    conflictDuplicateGesture,
+			Gesture: key,
+			Message: fmt.Sprintf("gesture is already bound to %s", old.Action.toString()),
+		})
+	}
+	for _, native := range wmNativeGestures {
+		if native == info.Event.withoutModifiers().key() {
+			warnings = append(warnings, gestureWarning{
+				Type:    conflictWmNativeGesture,
+				Gesture: key,
+				Message: "gesture is handled by window manager",
+			})
+			break
+		}
+	}
+
+	// 动作链中的每个快捷键动作都需要检查
+	for _, action := range info.Action.actions() {
+		if action.Type != ActionTypeShortcut {
+			continue
+		}
+		keystrokes, err := toKeystrokes(action.Action)
+		if err != nil {
+			logger.Debugf("failed to convert %q to keystrokes: %v", action.Action, err)
+			continue
+		}
+		for _, keystroke := range keystrokes {
+			name, err := lookupShortcut(keystroke)
+			if err != nil {
+				logger.Debugf("failed to lookup shortcut %s: %v", keystroke, err)
+				continue
+			}
+			if name != "" {
+				warnings = append(warnings, gestureWarning{
+					Type:    conflictShortcut,
+					Gesture: key,
+					Message: fmt.Sprintf("keys %s are used by shortcut %q", keystroke, name),
+				})
+			}
+		}
+	}
+	return warnings
+}
+
+// marshalWarnings 没有警告时返回 "[]"
+func marshalWarnings(warnings []gestureWarning) (string, error) {
+	if warnings == nil {
+		warnings = []gestureWarning{}
+	}
+	data, err := json.Marshal(warnings)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// findConflicts existing 为空时只检查窗口管理器和快捷键模块的冲突
+func (m *Manager) findConflicts(infos gestureInfos, existing gestureInfos) []gestureWarning {
+	m.mu.RLock()
+	wmNativeGestures := m.wmNativeGestures
+	m.mu.RUnlock()
+
+	var warnings []gestureWarning
+	for _, info := range infos {
+		warnings = append(warnings, findGestureConflicts(info, existing, wmNativeGestures, lookupConflictingShortcut)...)
+	}
+	for _, warning := range warnings {
+		logger.Infof("gesture %s conflict: %s", warning.Gesture, warning.Message)
+	}
+	return warnings
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：按键转换为快捷键模块的格式
+func Test_toKeystrokes(t *testing.T) {
+	keystrokes, err := toKeystrokes("ctrl+alt+t")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"<Control><Alt>t"}, keystrokes)
+
+	keystrokes, err = toKeystrokes("super+Up ctrl+shift+Tab super")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"<Super>Up", "<Control><Shift>Tab"}, keystrokes)
+
+	_, err = toKeystrokes("ctrl+a+b")
+	assert.Error(t, err)
+}
+
+// 测试：检查手势的冲突
+func Test_findGestureConflicts(t *testing.T) {
+	swipeUp := EventInfo{Name: "swipe", Direction: "up", Fingers: 3}
+	existing := gestureInfos{{
+		Event:  swipeUp,
+		Action: ActionInfo{Type: ActionTypeBuiltin, Action: "ShowWorkspace"},
+	}}
+	lookup := func(keystroke string) (string, error) {
+		if keystroke == "<Super>d" {
+			return "Show desktop", nil
+		}
+		return "", nil
+	}
+
+	info := &gestureInfo{Event: swipeUp, Action: ActionInfo{Type: ActionTypeShortcut, Action: "super+d"}}
+	warnings := findGestureConflicts(info, existing, []string{"swipe-up-3"}, lookup)
+	if assert.Len(t, warnings, 3) {
+		assert.Equal(t, conflictDuplicateGesture, warnings[0].Type)
+		assert.Equal(t, conflictWmNativeGesture, warnings[1].Type)
+		assert.Equal(t, conflictShortcut, warnings[2].Type)
+		assert.Equal(t, "swipe-up-3", warnings[2].Gesture)
+	}
+
+	info = &gestureInfo{
+		Event:  EventInfo{Name: "swipe", Direction: "down", Fingers: 3},
+		Action: ActionInfo{Type: ActionTypeShortcut, Action: "super+a"},
+	}
+	assert.Empty(t, findGestureConflicts(info, existing, nil, lookup))
+
+	data, err := marshalWarnings(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", data)
+}
@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// 支持手势的设备类型，手势事件中不包含具体的设备，因此按设备类型启用或禁用手势
+const (
+	gestureDeviceTouchPad    = "touchpad"
+	gestureDeviceTouchScreen = "touchscreen"
+)
+
+// 设备类型对应的 gsettings 配置项
+var gestureDeviceGSettingsKeys = map[string]string{
+	gestureDeviceTouchPad:    gsKeyTouchPadEnabled,
+	gestureDeviceTouchScreen: gsKeyTouchScreenEnabled,
+}
+
+// gestureDevice ListGestureDevices 返回的设备信息
+type gestureDevice struct {
+	Type    string
+	Enabled bool
+	Devices []string // 已连接的该类型设备的名称
+}
+
+// parseTouchPadNames 解析 inputdevices 中 TouchPad 的 DeviceList 属性
+func parseTouchPadNames(deviceList string) ([]string, error) {
+	if deviceList == "" {
+		return nil, nil
+	}
+	var devices []struct {
+		Name string
+	}
+	err := json.Unmarshal([]byte(deviceList), &devices)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, dev := range devices {
+		names = append(names, dev.Name)
+	}
+	return names, nil
+}
+
+func (m *Manager) getTouchPadNames() ([]string, error) {
+	deviceList, err := m.touchPad.DeviceList().Get(0)
+	if err != nil {
+		return nil, err
+	}
+	return parseTouchPadNames(deviceList)
+}
+
+func (m *Manager) getTouchScreenNames() ([]string, error) {
+	touchScreens, err := m.display.TouchscreensV2().Get(0)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, ts := range touchScreens {
+		names = append(names, ts.Node)
+	}
+	return names, nil
+}
+
+func (m *Manager) listGestureDevices() []gestureDevice {
+	touchPads, err := m.getTouchPadNames()
+	if err != nil {
+		logger.Warning("failed to get touchpad devices:", err)
+	}
+	touchScreens, err := m.getTouchScreenNames()
+	if err != nil {
+		logger.Warning("failed to get touchscreen devices:", err)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return []gestureDevice{
+		{
+			Type:    gestureDeviceTouchPad,
+			Enabled: m.touchPadEnabled,
+			Devices: touchPads,
+		},
+		{
+			Type:    gestureDeviceTouchScreen,
+			Enabled: m.touchScreenEnabled,
+			Devices: touchScreens,
+		},
+	}
+}
+
+// setGestureDeviceEnabled 保存到 gsettings，并立即更新当前状态，不等待 gsettings 的变化信号
+func (m *Manager) setGestureDeviceEnabled(deviceType string, enabled bool) error {
+	key, ok := gestureDeviceGSettingsKeys[deviceType]
+	if !ok {
+		return fmt.Errorf("invalid gesture device type %q", deviceType)
+	}
+	if m.setting.GetBoolean(key) != enabled && !m.setting.SetBoolean(key, enabled) {
+		return fmt.Errorf("failed to set gsettings key %s", key)
+	}
+
+	m.mu.Lock()
+	if deviceType == gestureDeviceTouchPad {
+		m.touchPadEnabled = enabled
+	} else {
+		m.touchScreenEnabled = enabled
+	}
+	m.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,25 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：解析触摸板设备列表
+func Test_parseTouchPadNames(t *testing.T) {
+	names, err := parseTouchPadNames(`[{"Id":12,"Name":"SYNA3602:00 0911:5288 Touchpad","Enabled":true}]`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"SYNA3602:00 0911:5288 Touchpad"}, names)
+
+	names, err = parseTouchPadNames("")
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+
+	_, err = parseTouchPadNames("{")
+	assert.Error(t, err)
+}
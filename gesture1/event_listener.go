@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/godbus/dbus/v5"
+	ofdbus "github.com/linuxdeepin/go-dbus-factory/session/org.freedesktop.dbus"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// dconfig 配置项，允许订阅 GestureEvent 信号的程序，值为可执行文件的绝对路径或文件名
+const dconfigKeyEventListenerAllowlist = "eventListenerAllowlist"
+
+// GestureEvent 信号中没有坐标的手势(如触摸板手势)使用的坐标
+const noEventPosition = -1
+
+// 原始手势事件的名称，触摸板手势使用 system/gesture1 中 Event 信号的名称
+const (
+	gestureEventTouchEdge     = "touch edge"
+	gestureEventTouchMovement = "touch movement"
+	gestureEventTouchTap      = "touch tap"
+)
+
+// isExeInAllowlist exe 与某一项的绝对路径或文件名相同时返回 true
+func isExeInAllowlist(exe string, allowlist []string) bool {
+	if exe == "" {
+		return false
+	}
+	for _, item := range allowlist {
+		if item == exe || (!filepath.IsAbs(item) && item == filepath.Base(exe)) {
+			return true
+		}
+	}
+	return false
+}
+
+func getProcessExe(pid uint32) (string, error) {
+	return os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+}
+
+func (m *Manager) getEventListenerAllowlist() []string {
+	m.eventListenersMu.Lock()
+	defer m.eventListenersMu.Unlock()
+	return m.eventListenerAllowlist
+}
+
+// setEventListenerAllowlist 允许列表变化后移除不再允许的订阅者
+func (m *Manager) setEventListenerAllowlist(allowlist []string) {
+	m.eventListenersMu.Lock()
+	defer m.eventListenersMu.Unlock()
+	m.eventListenerAllowlist = allowlist
+	for sender, exe := range m.eventListeners {
+		if !isExeInAllowlist(exe, allowlist) {
+			logger.Infof("%s(%s) is removed from gesture event listeners", sender, exe)
+			delete(m.eventListeners, sender)
+		}
+	}
+}
+
+func (m *Manager) initEventListeners() {
+	m.eventListeners = make(map[string]string)
+	m.sessionSigLoop = dbusutil.NewSignalLoop(m.service.Conn(), 10)
+	m.sessionSigLoop.Start()
+	m.dbusDaemon = ofdbus.NewDBus(m.service.Conn())
+	m.dbusDaemon.InitSignalExt(m.sessionSigLoop, true)
+	_, err := m.dbusDaemon.ConnectNameOwnerChanged(func(name, oldOwner, newOwner string) {
+		// 订阅者退出时自动取消订阅
+		if newOwner == "" && oldOwner == name {
+			m.removeEventListener(name)
+		}
+	})
+	if err != nil {
+		logger.Warning("connect NameOwnerChanged failed:", err)
+	}
+}
+
+func (m *Manager) addEventListener(sender string) error {
+	pid, err := m.service.GetConnPID(sender)
+	if err != nil {
+		return err
+	}
+	exe, err := getProcessExe(pid)
+	if err != nil {
+		return err
+	}
+	if !isExeInAllowlist(exe, m.getEventListenerAllowlist()) {
+		return fmt.Errorf("%s is not allowed to subscribe gesture events", exe)
+	}
+
+	m.eventListenersMu.Lock()
+	m.eventListeners[sender] = exe
+	m.eventListenersMu.Unlock()
+	logger.Infof("%s(%s) subscribed gesture events", sender, exe)
+	return nil
+}
+
+func (m *Manager) removeEventListener(sender string) {
+	m.eventListenersMu.Lock()
+	exe, ok := m.eventListeners[sender]
+	delete(m.eventListeners, sender)
+	m.eventListenersMu.Unlock()
+	if ok {
+		logger.Infof("%s(%s) unsubscribed gesture events", sender, exe)
+	}
+}
+
+// broadcastGestureEvent 只把 GestureEvent 信号发送给订阅者，x 和 y 是触摸屏上的相对坐标，范围为 [0, 1]，
+// 锁屏时不发送，避免订阅者获取锁屏界面上的输入
+func (m *Manager) broadcastGestureEvent(name, direction string, fingers int32, x, y float64) {
+	m.eventListenersMu.Lock()
+	listeners := make([]string, 0, len(m.eventListeners))
+	for sender := range m.eventListeners {
+		listeners = append(listeners, sender)
+	}
+	m.eventListenersMu.Unlock()
+	if len(listeners) == 0 || m.isSessionLocked() {
+		return
+	}
+
+	conn := m.service.Conn()
+	for _, sender := range listeners {
+		msg := &dbus.Message{
+			Type: dbus.TypeSignal,
+			Headers: map[dbus.HeaderField]dbus.Variant{
+				dbus.FieldPath:        dbus.MakeVariant(dbus.ObjectPath(dbusServicePath)),
+				dbus.FieldInterface:   dbus.MakeVariant(dbusServiceIFC),
+				dbus.FieldMember:      dbus.MakeVariant("GestureEvent"),
+				dbus.FieldDestination: dbus.MakeVariant(sender),
+			},
+			Body: []interface{}{name, direction, fingers, x, y},
+		}
+		msg.Headers[dbus.FieldSignature] = dbus.MakeVariant(dbus.SignatureOf(msg.Body...))
+		call := conn.Send(msg, nil)
+		if call.Err != nil {
+			logger.Warningf("failed to send GestureEvent to %s: %v", sender, call.Err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：允许订阅手势事件的程序
+func Test_isExeInAllowlist(t *testing.T) {
+	allowlist := []string{"/usr/bin/deepin-draw", "krita"}
+	assert.True(t, isExeInAllowlist("/usr/bin/deepin-draw", allowlist))
+	assert.True(t, isExeInAllowlist("/opt/apps/krita/bin/krita", allowlist))
+	assert.False(t, isExeInAllowlist("/opt/deepin-draw", allowlist))
+	assert.False(t, isExeInAllowlist("/usr/bin/krita-helper", allowlist))
+	assert.False(t, isExeInAllowlist("", allowlist))
+	assert.False(t, isExeInAllowlist("/usr/bin/deepin-draw", nil))
+}
+
+// 测试：允许列表变化后移除不再允许的订阅者
+func Test_setEventListenerAllowlist(t *testing.T) {
+	m := &Manager{
+		eventListeners: map[string]string{
+			":1.10": "/usr/bin/deepin-draw",
+			":1.11": "/opt/apps/krita/bin/krita",
+		},
+	}
+	m.setEventListenerAllowlist([]string{"krita"})
+	assert.Equal(t, []string{"krita"}, m.getEventListenerAllowlist())
+	assert.Equal(t, map[string]string{":1.11": "/opt/apps/krita/bin/krita"}, m.eventListeners)
+}
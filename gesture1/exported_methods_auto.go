@@ -8,11 +8,53 @@ import (
 
 func (v *Manager) GetExportedMethods() dbusutil.ExportedMethods {
 	return dbusutil.ExportedMethods{
+		{
+			Name:    "AddGesture",
+			Fn:      v.AddGesture,
+			InArgs:  []string{"name", "direction", "fingers", "actionType", "action"},
+			OutArgs: []string{"warningsJSON"},
+		},
+		{
+			Name:   "DeleteGesture",
+			Fn:     v.DeleteGesture,
+			InArgs: []string{"name", "direction", "fingers"},
+		},
+		{
+			Name:   "EnableGesture",
+			Fn:     v.EnableGesture,
+			InArgs: []string{"name", "direction", "fingers", "enabled"},
+		},
 		{
 			Name:    "GetEdgeMoveStopDuration",
 			Fn:      v.GetEdgeMoveStopDuration,
 			OutArgs: []string{"duration"},
 		},
+		{
+			Name:    "GetGestureConfig",
+			Fn:      v.GetGestureConfig,
+			OutArgs: []string{"configJSON"},
+		},
+		{
+			Name:    "GetGestureMap",
+			Fn:      v.GetGestureMap,
+			InArgs:  []string{"mode"},
+			OutArgs: []string{"mapJSON"},
+		},
+		{
+			Name:    "GetGestureSettings",
+			Fn:      v.GetGestureSettings,
+			OutArgs: []string{"settingsJSON"},
+		},
+		{
+			Name:    "GetGestureStats",
+			Fn:      v.GetGestureStats,
+			OutArgs: []string{"statsJSON"},
+		},
+		{
+			Name:    "GetLongPressAction",
+			Fn:      v.GetLongPressAction,
+			OutArgs: []string{"mode", "actionType", "action"},
+		},
 		{
 			Name:    "GetLongPressDuration",
 			Fn:      v.GetLongPressDuration,
@@ -23,11 +65,76 @@ func (v *Manager) GetExportedMethods() dbusutil.ExportedMethods {
 			Fn:      v.GetShortPressDuration,
 			OutArgs: []string{"duration"},
 		},
+		{
+			Name:    "GetTouchEdges",
+			Fn:      v.GetTouchEdges,
+			OutArgs: []string{"edgesJSON"},
+		},
+		{
+			Name:    "GetWindowBlacklist",
+			Fn:      v.GetWindowBlacklist,
+			OutArgs: []string{"patterns"},
+		},
+		{
+			Name:    "ListBuiltinActions",
+			Fn:      v.ListBuiltinActions,
+			OutArgs: []string{"names"},
+		},
+		{
+			Name:    "ListGestureDevices",
+			Fn:      v.ListGestureDevices,
+			OutArgs: []string{"devicesJSON"},
+		},
+		{
+			Name:    "ListGestures",
+			Fn:      v.ListGestures,
+			OutArgs: []string{"gesturesJSON"},
+		},
+		{
+			Name: "ResetGestureStats",
+			Fn:   v.ResetGestureStats,
+		},
+		{
+			Name: "ResetToDefaults",
+			Fn:   v.ResetToDefaults,
+		},
+		{
+			Name:   "SetCommandExecMode",
+			Fn:     v.SetCommandExecMode,
+			InArgs: []string{"mode"},
+		},
 		{
 			Name:   "SetEdgeMoveStopDuration",
 			Fn:     v.SetEdgeMoveStopDuration,
 			InArgs: []string{"duration"},
 		},
+		{
+			Name:    "SetGestureConfig",
+			Fn:      v.SetGestureConfig,
+			InArgs:  []string{"configJSON"},
+			OutArgs: []string{"warningsJSON"},
+		},
+		{
+			Name:   "SetGestureDeviceEnabled",
+			Fn:     v.SetGestureDeviceEnabled,
+			InArgs: []string{"deviceType", "enabled"},
+		},
+		{
+			Name:    "SetGestureMap",
+			Fn:      v.SetGestureMap,
+			InArgs:  []string{"mode", "mapJSON"},
+			OutArgs: []string{"warningsJSON"},
+		},
+		{
+			Name:   "SetGestureSettings",
+			Fn:     v.SetGestureSettings,
+			InArgs: []string{"settingsJSON"},
+		},
+		{
+			Name:   "SetLongPressAction",
+			Fn:     v.SetLongPressAction,
+			InArgs: []string{"mode", "actionType", "action"},
+		},
 		{
 			Name:   "SetLongPressDuration",
 			Fn:     v.SetLongPressDuration,
@@ -38,5 +145,55 @@ func (v *Manager) GetExportedMethods() dbusutil.ExportedMethods {
 			Fn:     v.SetShortPressDuration,
 			InArgs: []string{"duration"},
 		},
+		{
+			Name:   "SetSuppressionOverride",
+			Fn:     v.SetSuppressionOverride,
+			InArgs: []string{"mode"},
+		},
+		{
+			Name:   "SetTouchEdgeAction",
+			Fn:     v.SetTouchEdgeAction,
+			InArgs: []string{"edge", "actionType", "action"},
+		},
+		{
+			Name:   "SetTouchEdgeDistance",
+			Fn:     v.SetTouchEdgeDistance,
+			InArgs: []string{"edge", "distance"},
+		},
+		{
+			Name:   "SetTouchEdgeDoubleTapAction",
+			Fn:     v.SetTouchEdgeDoubleTapAction,
+			InArgs: []string{"edge", "actionType", "action"},
+		},
+		{
+			Name:   "SetTouchEdgeHideAction",
+			Fn:     v.SetTouchEdgeHideAction,
+			InArgs: []string{"edge", "actionType", "action"},
+		},
+		{
+			Name:   "SetWindowBlacklist",
+			Fn:     v.SetWindowBlacklist,
+			InArgs: []string{"patterns"},
+		},
+		{
+			Name: "SubscribeGestureEvent",
+			Fn:   v.SubscribeGestureEvent,
+		},
+		{
+			Name: "UnsubscribeGestureEvent",
+			Fn:   v.UnsubscribeGestureEvent,
+		},
+		{
+			Name:    "UpdateGesture",
+			Fn:      v.UpdateGesture,
+			InArgs:  []string{"name", "direction", "fingers", "actionType", "action"},
+			OutArgs: []string{"warningsJSON"},
+		},
+		{
+			Name:    "ValidateGesture",
+			Fn:      v.ValidateGesture,
+			InArgs:  []string{"name", "direction", "fingers", "actionType", "action"},
+			OutArgs: []string{"warningsJSON"},
+		},
 	}
 }
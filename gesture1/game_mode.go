@@ -0,0 +1,233 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	x "github.com/linuxdeepin/go-x11-client"
+	"github.com/linuxdeepin/go-x11-client/util/wm/ewmh"
+)
+
+// dconfig 配置项，游戏窗口列表，规则的格式与 longpress-blacklist 相同，
+// 列表中的窗口全屏时屏蔽边缘手势和多指手势，避免游戏中误触切换工作区
+const dconfigKeyGameModeWindows = "gameModeWindows"
+
+// SetSuppressionOverride 的参数
+const (
+	// 游戏窗口全屏时自动屏蔽，默认值
+	suppressionAuto = "auto"
+	// 总是屏蔽
+	suppressionOn = "on"
+	// 总是不屏蔽
+	suppressionOff = "off"
+)
+
+// 全屏游戏时会屏蔽的多指手势的最少手指数
+const minSuppressedFingers = 3
+
+func isValidSuppressionOverride(mode string) bool {
+	switch mode {
+	case suppressionAuto, suppressionOn, suppressionOff:
+		return true
+	}
+	return false
+}
+
+// isSuppressibleGesture 触摸屏边缘手势和三指及以上的手势在全屏游戏时会被屏蔽
+func isSuppressibleGesture(evInfo EventInfo) bool {
+	switch evInfo.Name {
+	case touchEdgeEventName, touchMovementEventName, touchEdgeDoubleTapEventName:
+		return true
+	}
+	return evInfo.Fingers >= minSuppressedFingers
+}
+
+// filterGameModeWindows 忽略无效的规则
+func filterGameModeWindows(list []string) []string {
+	var result []string
+	for _, pattern := range list {
+		err := checkWindowBlacklistPattern(pattern)
+		if err != nil {
+			logger.Warning("invalid game mode window:", err)
+			continue
+		}
+		result = append(result, pattern)
+	}
+	return result
+}
+
+func (m *Manager) setGameModeWindows(list []string) {
+	list = filterGameModeWindows(list)
+	m.mu.Lock()
+	m.gameModeWindows = list
+	m.mu.Unlock()
+}
+
+// isGameModeActive 激活窗口在游戏窗口列表中并且全屏时返回 true
+func (m *Manager) isGameModeActive() bool {
+	m.mu.RLock()
+	list := m.gameModeWindows
+	m.mu.RUnlock()
+	if len(list) == 0 {
+		return false
+	}
+	return isInWindowBlacklist(getActiveWindowInfo(), list) && isActiveWindowFullscreen()
+}
+
+// updateSuppressed 更新并返回 Suppressed 属性，激活窗口或全屏状态变化时和处理手势时调用
+func (m *Manager) updateSuppressed() bool {
+	m.SuppressedMu.Lock()
+	override := m.suppressionOverride
+	m.SuppressedMu.Unlock()
+
+	var suppressed bool
+	switch override {
+	case suppressionOn:
+		suppressed = true
+	case suppressionOff:
+		suppressed = false
+	default:
+		suppressed = m.isGameModeActive()
+	}
+
+	m.SuppressedMu.Lock()
+	changed := m.Suppressed != suppressed
+	m.Suppressed = suppressed
+	m.SuppressedMu.Unlock()
+
+	if changed && m.service != nil {
+		logger.Info("gesture suppressed:", suppressed)
+		err := m.service.EmitPropertyChanged(m, "Suppressed", suppressed)
+		if err != nil {
+			logger.Warning(err)
+		}
+	}
+	return suppressed
+}
+
+// shouldSuppressGesture 全屏游戏时屏蔽 isSuppressibleGesture 的手势
+func (m *Manager) shouldSuppressGesture(evInfo EventInfo) bool {
+	if !isSuppressibleGesture(evInfo) {
+		return false
+	}
+	if m.updateSuppressed() {
+		logger.Debug("gesture is suppressed:", evInfo.toString())
+		return true
+	}
+	return false
+}
+
+func (m *Manager) setSuppressionOverride(mode string) error {
+	if !isValidSuppressionOverride(mode) {
+		return fmt.Errorf("invalid suppression override %q", mode)
+	}
+	m.SuppressedMu.Lock()
+	m.suppressionOverride = mode
+	m.SuppressedMu.Unlock()
+	m.updateSuppressed()
+	return nil
+}
+
+// initGameModeWatcher 激活窗口或它的全屏状态变化时更新 Suppressed 属性
+func (m *Manager) initGameModeWatcher() {
+	if _useWayland {
+		m.watchActiveWindowWayland()
+	} else {
+		m.watchActiveWindowX()
+	}
+	m.updateSuppressed()
+}
+
+func (m *Manager) watchActiveWindowWayland() {
+	signals := []struct {
+		ifc    string
+		member string
+	}{
+		{kwayland1WindowManagerInterface, "ActiveWindowChanged"},
+		// 不限制路径，非激活窗口的信号由 updateSuppressed 忽略
+		{kwayland1PlasmaWindowInterface, "FullscreenChanged"},
+	}
+	for _, signal := range signals {
+		err := dbusutil.NewMatchRuleBuilder().Type("signal").
+			Sender(kwayland1Service).
+			Interface(signal.ifc).
+			Member(signal.member).Build().AddTo(m.service.Conn())
+		if err != nil {
+			logger.Warning(err)
+			continue
+		}
+		m.sessionSigLoop.AddHandler(&dbusutil.SignalRule{
+			Name: signal.ifc + "." + signal.member,
+		}, func(sig *dbus.Signal) {
+			m.updateSuppressed()
+		})
+	}
+}
+
+func (m *Manager) watchActiveWindowX() {
+	conn := getX11Conn()
+	if conn == nil {
+		return
+	}
+	rootWin := conn.GetDefaultScreen().Root
+	err := x.ChangeWindowAttributesChecked(conn, rootWin, x.CWEventMask,
+		[]uint32{x.EventMaskPropertyChange}).Check(conn)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	atomActiveWindow, err := conn.GetAtom("_NET_ACTIVE_WINDOW")
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	atomWMState, err := conn.GetAtom("_NET_WM_STATE")
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+
+	var activeWin x.Window
+	// 同时监听激活窗口的 _NET_WM_STATE，窗口进入或退出全屏时更新
+	watchActiveWin := func() {
+		win, err := ewmh.GetActiveWindow(conn).Reply(conn)
+		if err != nil || win == activeWin {
+			return
+		}
+		activeWin = win
+		if win == 0 {
+			return
+		}
+		err = x.ChangeWindowAttributesChecked(conn, win, x.CWEventMask,
+			[]uint32{x.EventMaskPropertyChange}).Check(conn)
+		if err != nil {
+			logger.Debug("failed to watch active window:", err)
+		}
+	}
+
+	eventChan := make(chan x.GenericEvent, 10)
+	conn.AddEventChan(eventChan)
+	go func() {
+		watchActiveWin()
+		for ev := range eventChan {
+			if ev.GetEventCode() != x.PropertyNotifyEventCode {
+				continue
+			}
+			event, _ := x.NewPropertyNotifyEvent(ev)
+			if event == nil {
+				continue
+			}
+			if event.Window == rootWin && event.Atom == atomActiveWindow {
+				watchActiveWin()
+				m.updateSuppressed()
+			} else if event.Window == activeWin && event.Atom == atomWMState {
+				m.updateSuppressed()
+			}
+		}
+	}()
+}
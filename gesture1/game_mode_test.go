@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：全屏游戏时屏蔽的手势
+func Test_isSuppressibleGesture(t *testing.T) {
+	assert.True(t, isSuppressibleGesture(EventInfo{Name: touchEdgeEventName, Direction: "left", Fingers: 1}))
+	assert.True(t, isSuppressibleGesture(EventInfo{Name: touchMovementEventName, Direction: "right", Fingers: 1}))
+	assert.True(t, isSuppressibleGesture(EventInfo{Name: "swipe", Direction: "left", Fingers: 3}))
+	assert.True(t, isSuppressibleGesture(EventInfo{Name: "pinch", Direction: "in", Fingers: 4}))
+	assert.False(t, isSuppressibleGesture(EventInfo{Name: "rotate", Direction: "clockwise", Fingers: 2}))
+	assert.False(t, isSuppressibleGesture(EventInfo{Name: touchRightButton, Direction: "down", Fingers: 1}))
+}
+
+// 测试：屏蔽手势的手动设置
+func Test_isValidSuppressionOverride(t *testing.T) {
+	assert.True(t, isValidSuppressionOverride(suppressionAuto))
+	assert.True(t, isValidSuppressionOverride(suppressionOn))
+	assert.True(t, isValidSuppressionOverride(suppressionOff))
+	assert.False(t, isValidSuppressionOverride(""))
+	assert.False(t, isValidSuppressionOverride("always"))
+}
+
+// 测试：忽略游戏窗口列表中无效的规则
+func Test_filterGameModeWindows(t *testing.T) {
+	assert.Equal(t, []string{"class:steam_app_570", "dota2"},
+		filterGameModeWindows([]string{"class:steam_app_570", "class:", " ", "dota2"}))
+	assert.Nil(t, filterGameModeWindows(nil))
+}
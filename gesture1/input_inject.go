@@ -0,0 +1,308 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	x "github.com/linuxdeepin/go-x11-client"
+	"github.com/linuxdeepin/go-x11-client/util/keysyms"
+)
+
+// 模拟按键和鼠标按钮的方式，dconfig 配置项 inputInjectBackend 的值
+const (
+	// X11 下使用 xtest，Wayland 下使用 uinput，uinput 由 system/gesture1 创建
+	injectBackendAuto    = "auto"
+	injectBackendXTest   = "xtest"
+	injectBackendUInput  = "uinput"
+	injectBackendXdotool = "xdotool"
+)
+
+const dconfigKeyInputInjectBackend = "inputInjectBackend"
+
+// 鼠标按钮，与 X11 的按钮编号一致
+const (
+	mouseButtonLeft   uint8 = 1
+	mouseButtonMiddle uint8 = 2
+	mouseButtonRight  uint8 = 3
+)
+
+type inputInjector interface {
+	// sendKeys 模拟按键，格式与 xdotool key 相同，如 "ctrl+alt+u"，多组按键以空格分隔
+	sendKeys(keys string) error
+	sendButton(button uint8, press bool) error
+	// sendMotion 相对移动鼠标指针
+	sendMotion(dx, dy int32) error
+	destroy()
+}
+
+// xdotool 的修饰键别名
+var keyNameAliases = map[string]string{
+	"ctrl":    "Control_L",
+	"control": "Control_L",
+	"alt":     "Alt_L",
+	"shift":   "Shift_L",
+	"super":   "Super_L",
+	"meta":    "Meta_L",
+}
+
+// parseKeySequence 解析 xdotool key 格式的按键序列，返回每组同时按下的按键名称
+func parseKeySequence(keys string) ([][]string, error) {
+	var combos [][]string
+	for _, field := range strings.Fields(keys) {
+		var combo []string
+		for _, name := range strings.Split(field, "+") {
+			if name == "" {
+				return nil, fmt.Errorf("invalid keys %q", keys)
+			}
+			if alias, ok := keyNameAliases[strings.ToLower(name)]; ok {
+				name = alias
+			}
+			combo = append(combo, name)
+		}
+		combos = append(combos, combo)
+	}
+	if len(combos) == 0 {
+		return nil, errors.New("keys is empty")
+	}
+	return combos, nil
+}
+
+// keyEmitter 发送 X11 键码对应的按键和鼠标按钮事件
+type keyEmitter interface {
+	emitKey(code x.Keycode, press bool) error
+	emitButton(button uint8, press bool) error
+	emitMotion(dx, dy int32) error
+	destroy()
+}
+
+// nativeInjector 通过 X11 的键盘映射将按键名称转换为键码，由 xtest 或 uinput 发送事件，
+// Wayland 下使用 XWayland 的键盘映射
+type nativeInjector struct {
+	keySymbols *keysyms.KeySymbols
+	emitter    keyEmitter
+}
+
+func newNativeInjector(emitter keyEmitter) (*nativeInjector, error) {
+	conn := getX11Conn()
+	if conn == nil {
+		emitter.destroy()
+		return nil, errors.New("failed to connect to X server")
+	}
+	return &nativeInjector{
+		keySymbols: keysyms.NewKeySymbols(conn),
+		emitter:    emitter,
+	}, nil
+}
+
+// getKeycodes 获取按键的键码，需要 Shift 才能输入的按键(如 plus)同时返回 Shift 的键码
+func (inj *nativeInjector) getKeycodes(name string) ([]x.Keycode, error) {
+	sym, ok := keysyms.StringToKeysym(name)
+	if !ok {
+		return nil, fmt.Errorf("invalid key %q", name)
+	}
+	codes := inj.keySymbols.GetKeycodes(sym)
+	if len(codes) == 0 {
+		return nil, fmt.Errorf("no keycode for key %q", name)
+	}
+	code := codes[0]
+	if inj.keySymbols.GetKeysym(code, 0) != sym && inj.keySymbols.GetKeysym(code, 1) == sym {
+		shiftCodes := inj.keySymbols.GetKeycodes(keysyms.XK_Shift_L)
+		if len(shiftCodes) > 0 {
+			return []x.Keycode{shiftCodes[0], code}, nil
+		}
+	}
+	return []x.Keycode{code}, nil
+}
+
+func (inj *nativeInjector) sendKeys(keys string) error {
+	combos, err := parseKeySequence(keys)
+	if err != nil {
+		return err
+	}
+	for _, combo := range combos {
+		var codes []x.Keycode
+		for _, name := range combo {
+			keyCodes, err := inj.getKeycodes(name)
+			if err != nil {
+				return err
+			}
+			codes = append(codes, keyCodes...)
+		}
+		err = inj.sendCombo(codes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendCombo 依次按下所有按键，再按相反的顺序释放
+func (inj *nativeInjector) sendCombo(codes []x.Keycode) error {
+	var err error
+	pressed := 0
+	for _, code := range codes {
+		err = inj.emitter.emitKey(code, true)
+		if err != nil {
+			break
+		}
+		pressed++
+	}
+	// 出错时也要释放已经按下的按键
+	for i := pressed - 1; i >= 0; i-- {
+		releaseErr := inj.emitter.emitKey(codes[i], false)
+		if err == nil {
+			err = releaseErr
+		}
+	}
+	return err
+}
+
+func (inj *nativeInjector) sendButton(button uint8, press bool) error {
+	return inj.emitter.emitButton(button, press)
+}
+
+func (inj *nativeInjector) sendMotion(dx, dy int32) error {
+	return inj.emitter.emitMotion(dx, dy)
+}
+
+func (inj *nativeInjector) destroy() {
+	inj.emitter.destroy()
+}
+
+// xdotoolInjector 调用 xdotool 命令，用于原生方式不可用的环境
+type xdotoolInjector struct{}
+
+func (xdotoolInjector) sendKeys(keys string) error {
+	args := append([]string{"key"}, strings.Fields(keys)...)
+	// #nosec G204
+	out, err := exec.Command("xdotool", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("xdotool key failed: %v, %s", err, out)
+	}
+	return nil
+}
+
+func (xdotoolInjector) sendButton(button uint8, press bool) error {
+	action := "mouseup"
+	if press {
+		action = "mousedown"
+	}
+	// #nosec G204
+	out, err := exec.Command("xdotool", action, fmt.Sprint(button)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("xdotool %s failed: %v, %s", action, err, out)
+	}
+	return nil
+}
+
+func (xdotoolInjector) sendMotion(dx, dy int32) error {
+	// #nosec G204
+	out, err := exec.Command("xdotool", "mousemove_relative", "--", fmt.Sprint(dx), fmt.Sprint(dy)).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("xdotool mousemove_relative failed: %v, %s", err, out)
+	}
+	return nil
+}
+
+func (xdotoolInjector) destroy() {}
+
+func newInputInjector(backend string) (inputInjector, error) {
+	if backend == "" || backend == injectBackendAuto {
+		backend = injectBackendXTest
+		if _useWayland {
+			backend = injectBackendUInput
+		}
+	}
+
+	var emitter keyEmitter
+	var err error
+	switch backend {
+	case injectBackendXTest:
+		emitter, err = newXTestEmitter()
+	case injectBackendUInput:
+		emitter, err = newUInputEmitter()
+	case injectBackendXdotool:
+		return xdotoolInjector{}, nil
+	default:
+		return nil, fmt.Errorf("invalid input inject backend %q", backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newNativeInjector(emitter)
+}
+
+func (m *Manager) setInputInjectBackend(backend string) {
+	m.mu.Lock()
+	m.injectBackend = backend
+	injector := m.injector
+	m.injector = nil
+	m.mu.Unlock()
+	if injector != nil {
+		injector.destroy()
+	}
+}
+
+// getInputInjector 首次使用时创建，原生方式不可用时使用 xdotool。
+// 创建时需要连接 X 和等待虚拟设备，不能持有 m.mu
+func (m *Manager) getInputInjector() inputInjector {
+	m.mu.RLock()
+	injector, backend := m.injector, m.injectBackend
+	m.mu.RUnlock()
+	if injector != nil {
+		return injector
+	}
+
+	injector, err := newInputInjector(backend)
+	if err != nil {
+		logger.Warningf("failed to init input inject backend %q, fall back to xdotool: %v", backend, err)
+		injector = xdotoolInjector{}
+	}
+
+	m.mu.Lock()
+	if m.injector != nil || m.injectBackend != backend {
+		// 其他调用者已经创建，或者创建期间修改了方式
+		current := m.injector
+		m.mu.Unlock()
+		injector.destroy()
+		if current == nil {
+			return m.getInputInjector()
+		}
+		return current
+	}
+	m.injector = injector
+	m.mu.Unlock()
+	return injector
+}
+
+func (m *Manager) sendKeys(keys string) error {
+	return m.getInputInjector().sendKeys(keys)
+}
+
+// sendShortcut Wayland 下模拟的按键不一定能触发窗口管理器的全局快捷键，
+// 因此先查找并直接调用 keys 绑定的全局快捷键，没有绑定时再模拟按键
+func (m *Manager) sendShortcut(keys string) error {
+	if _useWayland {
+		invoked, err := invokeGlobalShortcutByKeys(keys)
+		if err != nil {
+			logger.Debugf("failed to invoke global shortcut %q: %v", keys, err)
+		} else if invoked {
+			return nil
+		}
+	}
+	return m.sendKeys(keys)
+}
+
+func (m *Manager) sendButton(button uint8, press bool) error {
+	return m.getInputInjector().sendButton(button, press)
+}
+
+func (m *Manager) sendMotion(dx, dy int32) error {
+	return m.getInputInjector().sendMotion(dx, dy)
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"errors"
+	"testing"
+
+	x "github.com/linuxdeepin/go-x11-client"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：解析 xdotool key 格式的按键
+func Test_parseKeySequence(t *testing.T) {
+	combos, err := parseKeySequence("ctrl+alt+u")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"Control_L", "Alt_L", "u"}}, combos)
+
+	combos, err = parseKeySequence(" super+d  Super+Tab ")
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"Super_L", "d"}, {"Super_L", "Tab"}}, combos)
+
+	_, err = parseKeySequence("")
+	assert.Error(t, err)
+	_, err = parseKeySequence("ctrl++")
+	assert.Error(t, err)
+}
+
+type testKeyEvent struct {
+	code  x.Keycode
+	press bool
+}
+
+type testEmitter struct {
+	events  []testKeyEvent
+	failAt  int
+	emitted int
+}
+
+func (e *testEmitter) emitKey(code x.Keycode, press bool) error {
+	e.emitted++
+	if e.emitted == e.failAt {
+		return errors.New("emit failed")
+	}
+	e.events = append(e.events, testKeyEvent{code: code, press: press})
+	return nil
+}
+
+func (e *testEmitter) emitButton(button uint8, press bool) error {
+	return nil
+}
+
+func (e *testEmitter) emitMotion(dx, dy int32) error {
+	return nil
+}
+
+func (e *testEmitter) destroy() {}
+
+// 测试：组合键按顺序按下，按相反的顺序释放
+func Test_nativeInjectorSendCombo(t *testing.T) {
+	emitter := &testEmitter{}
+	inj := &nativeInjector{emitter: emitter}
+	assert.NoError(t, inj.sendCombo([]x.Keycode{37, 64, 30}))
+	assert.Equal(t, []testKeyEvent{
+		{37, true}, {64, true}, {30, true},
+		{30, false}, {64, false}, {37, false},
+	}, emitter.events)
+
+	// 按下失败时释放已经按下的按键
+	emitter = &testEmitter{failAt: 2}
+	inj = &nativeInjector{emitter: emitter}
+	assert.Error(t, inj.sendCombo([]x.Keycode{37, 64}))
+	assert.Equal(t, []testKeyEvent{{37, true}, {37, false}}, emitter.events)
+}
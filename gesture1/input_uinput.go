@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	x "github.com/linuxdeepin/go-x11-client"
+)
+
+// evdev 键码与 X11 键码的差值
+const evdevKeycodeOffset = 8
+
+// uinputEmitter 会话服务没有权限打开 /dev/uinput，由 system/gesture1 通过 uinput
+// 创建虚拟键盘和鼠标发送事件，用于 Wayland
+type uinputEmitter struct {
+	obj dbus.BusObject
+}
+
+func newUInputEmitter() (*uinputEmitter, error) {
+	systemConn, err := dbus.SystemBus()
+	if err != nil {
+		return nil, err
+	}
+	return &uinputEmitter{
+		obj: systemConn.Object(systemGestureService, systemGesturePath),
+	}, nil
+}
+
+func (e *uinputEmitter) call(method string, args ...interface{}) error {
+	return e.obj.Call(systemGestureInterface+"."+method, 0, args...).Err
+}
+
+func (e *uinputEmitter) emitKey(code x.Keycode, press bool) error {
+	if code < evdevKeycodeOffset {
+		return fmt.Errorf("invalid keycode %d", code)
+	}
+	return e.call("InjectKey", uint16(code-evdevKeycodeOffset), press)
+}
+
+func (e *uinputEmitter) emitButton(button uint8, press bool) error {
+	return e.call("InjectButton", button, press)
+}
+
+func (e *uinputEmitter) emitMotion(dx, dy int32) error {
+	return e.call("InjectMotion", dx, dy)
+}
+
+// 虚拟设备由 system/gesture1 管理
+func (e *uinputEmitter) destroy() {}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"errors"
+
+	x "github.com/linuxdeepin/go-x11-client"
+	"github.com/linuxdeepin/go-x11-client/ext/test"
+)
+
+// xtestEmitter 通过 XTEST 扩展发送事件
+type xtestEmitter struct {
+	conn    *x.Conn
+	rootWin x.Window
+}
+
+func newXTestEmitter() (*xtestEmitter, error) {
+	conn := getX11Conn()
+	if conn == nil {
+		return nil, errors.New("failed to connect to X server")
+	}
+	_, err := test.GetVersion(conn, test.MajorVersion, test.MinorVersion).Reply(conn)
+	if err != nil {
+		return nil, err
+	}
+	return &xtestEmitter{
+		conn:    conn,
+		rootWin: conn.GetDefaultScreen().Root,
+	}, nil
+}
+
+func (e *xtestEmitter) fakeInput(evType uint8, detail uint8) error {
+	return test.FakeInputChecked(e.conn, evType, detail, x.TimeCurrentTime, e.rootWin, 0, 0, 0).Check(e.conn)
+}
+
+func (e *xtestEmitter) emitKey(code x.Keycode, press bool) error {
+	evType := uint8(x.KeyReleaseEventCode)
+	if press {
+		evType = x.KeyPressEventCode
+	}
+	return e.fakeInput(evType, uint8(code))
+}
+
+func (e *xtestEmitter) emitButton(button uint8, press bool) error {
+	evType := uint8(x.ButtonReleaseEventCode)
+	if press {
+		evType = x.ButtonPressEventCode
+	}
+	return e.fakeInput(evType, button)
+}
+
+// emitMotion MotionNotify 的 detail 为 1 时坐标为相对移动的距离
+func (e *xtestEmitter) emitMotion(dx, dy int32) error {
+	return test.FakeInputChecked(e.conn, x.MotionNotifyEventCode, 1, x.TimeCurrentTime, e.rootWin,
+		int16(dx), int16(dy), 0).Check(e.conn)
+}
+
+// X 连接由 getX11Conn 管理，不需要关闭
+func (e *xtestEmitter) destroy() {}
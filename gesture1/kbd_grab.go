@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"errors"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// dconfig 配置项，其他程序抓取键盘时是否屏蔽手势
+const (
+	// 为 false 时不检查键盘是否被抓取，与属性 KbdGrabCheckEnabled 一致
+	dconfigKeyKbdGrabCheckEnabled = "kbdGrabCheckEnabled"
+	// 抓取键盘时不屏蔽手势的窗口，规则的格式与 longpress-blacklist 相同，
+	// X11 无法查询抓取键盘的程序，因此匹配键盘被抓取时的激活窗口
+	dconfigKeyKbdGrabExceptions = "kbdGrabExceptions"
+)
+
+// filterKbdGrabExceptions 忽略无效的规则
+func filterKbdGrabExceptions(list []string) []string {
+	var result []string
+	for _, pattern := range list {
+		err := checkWindowBlacklistPattern(pattern)
+		if err != nil {
+			logger.Warning("invalid keyboard grab exception:", err)
+			continue
+		}
+		result = append(result, pattern)
+	}
+	return result
+}
+
+func (m *Manager) setKbdGrabExceptions(list []string) {
+	list = filterKbdGrabExceptions(list)
+	m.mu.Lock()
+	m.kbdGrabExceptions = list
+	m.mu.Unlock()
+}
+
+func (m *Manager) getKbdGrabCheckEnabled() bool {
+	m.KbdGrabCheckEnabledMu.RLock()
+	defer m.KbdGrabCheckEnabledMu.RUnlock()
+	return m.KbdGrabCheckEnabled
+}
+
+func (m *Manager) setKbdGrabCheckEnabled(enabled bool) {
+	m.KbdGrabCheckEnabledMu.Lock()
+	changed := m.KbdGrabCheckEnabled != enabled
+	m.KbdGrabCheckEnabled = enabled
+	m.KbdGrabCheckEnabledMu.Unlock()
+
+	if changed && m.service != nil {
+		err := m.service.EmitPropertyChanged(m, "KbdGrabCheckEnabled", enabled)
+		if err != nil {
+			logger.Warning(err)
+		}
+	}
+}
+
+// kbdGrabCheckEnabledWriteCb 保存到 dconfig，属性在 dconfig 的变化信号中更新
+func (m *Manager) kbdGrabCheckEnabledWriteCb(write *dbusutil.PropertyWrite) *dbus.Error {
+	enabled, ok := write.Value.(bool)
+	if !ok {
+		err := errors.New("type of value is not bool")
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	err := m.setGestureConfigBool(dconfigKeyKbdGrabCheckEnabled, enabled)
+	if err != nil {
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+// isKbdGrabbedByOthers 启用了检查、键盘被抓取并且激活窗口不在例外列表中时返回 true，
+// 锁屏由 shouldIgnoreGesture 按锁屏时的手势策略单独处理，不使用该函数
+func (m *Manager) isKbdGrabbedByOthers() bool {
+	if !m.getKbdGrabCheckEnabled() || !isKbdAlreadyGrabbed() {
+		return false
+	}
+	m.mu.RLock()
+	list := m.kbdGrabExceptions
+	m.mu.RUnlock()
+	if len(list) > 0 && isInWindowBlacklist(getActiveWindowInfo(), list) {
+		logger.Debug("keyboard is grabbed by a window in the exception list")
+		return false
+	}
+	return true
+}
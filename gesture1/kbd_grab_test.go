@@ -0,0 +1,26 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：忽略无效的例外规则
+func Test_filterKbdGrabExceptions(t *testing.T) {
+	assert.Equal(t, []string{"class:obs", "code"},
+		filterKbdGrabExceptions([]string{"class:obs", "class: ", "", "code"}))
+	assert.Nil(t, filterKbdGrabExceptions(nil))
+}
+
+// 测试：关闭检查后不再查询键盘是否被抓取
+func Test_isKbdGrabbedByOthers(t *testing.T) {
+	m := &Manager{}
+	m.setKbdGrabCheckEnabled(false)
+	assert.False(t, m.getKbdGrabCheckEnabled())
+	assert.False(t, m.isKbdGrabbedByOthers())
+}
@@ -0,0 +1,138 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// 锁屏时的手势策略，dconfig 配置项 lockedGesturePolicy 和属性 LockedGesturePolicy 的值
+const (
+	// 锁屏时不处理手势
+	lockedPolicyDisabled = "disabled"
+	// 锁屏时只执行多媒体键等不会泄露信息的快捷键动作
+	lockedPolicyLimited = "limited"
+	// 锁屏时与解锁时相同
+	lockedPolicyFull = "full"
+)
+
+const dconfigKeyLockedGesturePolicy = "lockedGesturePolicy"
+
+// limited 策略下允许模拟的按键前缀
+var lockSafeKeyPrefixes = []string{
+	"XF86Audio",
+	"XF86MonBrightness",
+	"XF86KbdBrightness",
+}
+
+func isValidLockedPolicy(policy string) bool {
+	switch policy {
+	case lockedPolicyDisabled, lockedPolicyLimited, lockedPolicyFull:
+		return true
+	}
+	return false
+}
+
+// isLockSafeAction 按键全部是多媒体键的快捷键动作返回 true，动作链中每一步都需要满足
+func isLockSafeAction(action ActionInfo) bool {
+	if action.Type == ActionTypeChain {
+		actions := action.actions()
+		for _, a := range actions {
+			if !isLockSafeAction(a) {
+				return false
+			}
+		}
+		return len(actions) > 0
+	}
+	if action.Type != ActionTypeShortcut {
+		return false
+	}
+	combos, err := parseKeySequence(action.Action)
+	if err != nil {
+		return false
+	}
+	for _, combo := range combos {
+		for _, name := range combo {
+			safe := false
+			for _, prefix := range lockSafeKeyPrefixes {
+				if strings.HasPrefix(name, prefix) {
+					safe = true
+					break
+				}
+			}
+			if !safe {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (m *Manager) getLockedGesturePolicy() string {
+	m.LockedGesturePolicyMu.RLock()
+	defer m.LockedGesturePolicyMu.RUnlock()
+	return m.LockedGesturePolicy
+}
+
+// setLockedGesturePolicy 无效的策略当作 disabled 处理
+func (m *Manager) setLockedGesturePolicy(policy string) {
+	if !isValidLockedPolicy(policy) {
+		logger.Warningf("invalid locked gesture policy %q, use %s", policy, lockedPolicyDisabled)
+		policy = lockedPolicyDisabled
+	}
+	m.LockedGesturePolicyMu.Lock()
+	changed := m.LockedGesturePolicy != policy
+	m.LockedGesturePolicy = policy
+	m.LockedGesturePolicyMu.Unlock()
+
+	if changed && m.service != nil {
+		err := m.service.EmitPropertyChanged(m, "LockedGesturePolicy", policy)
+		if err != nil {
+			logger.Warning(err)
+		}
+	}
+}
+
+func (m *Manager) isSessionLocked() bool {
+	isLocked, err := m.sessionmanager.Locked().Get(0)
+	if err != nil {
+		logger.Warning("get session locked failed:", err)
+		// 无法确定时按锁屏处理
+		return true
+	}
+	return isLocked
+}
+
+// isActionAllowed limited 策略下锁屏时只允许执行 isLockSafeAction 的动作
+func (m *Manager) isActionAllowed(action ActionInfo) bool {
+	if m.getLockedGesturePolicy() != lockedPolicyLimited || isLockSafeAction(action) {
+		return true
+	}
+	return !m.isSessionLocked()
+}
+
+// lockedGesturePolicyWriteCb 保存到 dconfig，属性在 dconfig 的变化信号中更新
+func (m *Manager) lockedGesturePolicyWriteCb(write *dbusutil.PropertyWrite) *dbus.Error {
+	policy, ok := write.Value.(string)
+	if !ok {
+		err := errors.New("type of value is not string")
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	if !isValidLockedPolicy(policy) {
+		return dbusutil.ToError(fmt.Errorf("invalid locked gesture policy %q", policy))
+	}
+	err := m.setGestureConfigString(dconfigKeyLockedGesturePolicy, policy)
+	if err != nil {
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
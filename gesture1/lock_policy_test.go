@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：锁屏时允许执行的动作
+func Test_isLockSafeAction(t *testing.T) {
+	assert.True(t, isLockSafeAction(ActionInfo{Type: ActionTypeShortcut, Action: "XF86AudioRaiseVolume"}))
+	assert.True(t, isLockSafeAction(ActionInfo{Type: ActionTypeShortcut, Action: "XF86AudioPlay XF86AudioNext"}))
+	assert.True(t, isLockSafeAction(ActionInfo{Type: ActionTypeShortcut, Action: "XF86MonBrightnessUp"}))
+	assert.False(t, isLockSafeAction(ActionInfo{Type: ActionTypeShortcut, Action: "ctrl+XF86AudioPlay"}))
+	assert.False(t, isLockSafeAction(ActionInfo{Type: ActionTypeShortcut, Action: "super+d"}))
+	assert.False(t, isLockSafeAction(ActionInfo{Type: ActionTypeCommandline, Action: "XF86AudioPlay"}))
+	assert.False(t, isLockSafeAction(ActionInfo{Type: ActionTypeBuiltin, Action: "ShowWorkspace"}))
+}
+
+// 测试：锁屏时的手势策略
+func Test_isValidLockedPolicy(t *testing.T) {
+	assert.True(t, isValidLockedPolicy(lockedPolicyDisabled))
+	assert.True(t, isValidLockedPolicy(lockedPolicyLimited))
+	assert.True(t, isValidLockedPolicy(lockedPolicyFull))
+	assert.False(t, isValidLockedPolicy(""))
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 触摸屏长按的行为，长按的时长见 tsSchemaKeyLongPress。
+// DDE 中没有提供环形菜单的服务，暂不支持打开环形菜单
+const (
+	// 模拟鼠标右键，默认值
+	longPressRightClick = "right-click"
+	// 执行 longPressConfig.Action
+	longPressCustom = "custom"
+)
+
+// longPressConfig 触摸屏长按的配置，为空时模拟鼠标右键
+type longPressConfig struct {
+	Mode   string
+	Action *ActionInfo `json:",omitempty"` // Mode 为 custom 时按下执行的动作
+}
+
+func isValidLongPressMode(mode string) bool {
+	switch mode {
+	case longPressRightClick, longPressCustom:
+		return true
+	}
+	return false
+}
+
+func (cfg *longPressConfig) check(builtinSets map[string]func() error) error {
+	if !isValidLongPressMode(cfg.Mode) {
+		return fmt.Errorf("Mode: invalid long press mode %q", cfg.Mode)
+	}
+	if cfg.Mode != longPressCustom {
+		return nil
+	}
+	if cfg.Action == nil {
+		return errors.New("Action: custom long press requires an action")
+	}
+	err := checkActionInfo(*cfg.Action, builtinSets)
+	if err != nil {
+		return fmt.Errorf("Action: %v", err)
+	}
+	return nil
+}
+
+// value 配置文件中没有长按配置或配置无效时模拟鼠标右键
+func (cfg *longPressConfig) value() longPressConfig {
+	if cfg == nil {
+		return longPressConfig{Mode: longPressRightClick}
+	}
+	if !isValidLongPressMode(cfg.Mode) || (cfg.Mode == longPressCustom && cfg.Action == nil) {
+		logger.Warningf("invalid long press config of mode %q, fall back to %s", cfg.Mode, longPressRightClick)
+		return longPressConfig{Mode: longPressRightClick}
+	}
+	return *cfg
+}
+
+// configValue 模拟鼠标右键时不保存到配置文件
+func (cfg longPressConfig) configValue() *longPressConfig {
+	if cfg.Mode == longPressRightClick || cfg.Mode == "" {
+		return nil
+	}
+	return &cfg
+}
+
+// action 返回长按按下或抬起时执行的动作，info 是模拟鼠标右键的手势，
+// 自定义动作只在按下时执行
+func (cfg longPressConfig) action(info *gestureInfo) (ActionInfo, bool) {
+	if cfg.Mode == longPressCustom {
+		if info.Event.Direction != "down" {
+			return ActionInfo{}, false
+		}
+		return *cfg.Action, true
+	}
+	return info.Action, true
+}
+
+func (m *Manager) getLongPress() longPressConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.longPress
+}
+
+// setLongPress 保存长按的配置，保存失败时不修改当前的配置
+func (m *Manager) setLongPress(cfg longPressConfig) error {
+	m.mu.Lock()
+	old := m.longPress
+	m.longPress = cfg
+	err := m.writeNoLock()
+	if err != nil {
+		m.longPress = old
+	}
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	m.applyLongPressEnable()
+	return nil
+}
+
+// applyLongPressEnable Wayland 下由 KWin 模拟鼠标右键，其他模式需要关闭 KWin 的长按
+func (m *Manager) applyLongPressEnable() {
+	if _useWayland {
+		setLongPressEnable(m.longPressEnable && m.getLongPress().Mode == longPressRightClick)
+	}
+}
+
+// shouldSkipLongPress 没有启用长按，或者 Wayland 下已经由 KWin 模拟鼠标右键时返回 true
+func (m *Manager) shouldSkipLongPress() bool {
+	if !m.longPressEnable {
+		return true
+	}
+	return _useWayland && m.getLongPress().Mode == longPressRightClick
+}
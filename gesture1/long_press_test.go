@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTouchRightButtonInfo(direction, action string) *gestureInfo {
+	return &gestureInfo{
+		Event:  EventInfo{Name: touchRightButton, Direction: direction},
+		Action: ActionInfo{Type: ActionTypeBuiltin, Action: action},
+	}
+}
+
+// 测试：检查长按的配置
+func Test_longPressConfigCheck(t *testing.T) {
+	builtinSets := map[string]func() error{"ShowWorkspace": nil}
+	assert.NoError(t, (&longPressConfig{Mode: longPressRightClick}).check(builtinSets))
+	assert.Error(t, (&longPressConfig{Mode: "radial-menu"}).check(builtinSets))
+	assert.Error(t, (&longPressConfig{Mode: "double-click"}).check(builtinSets))
+	assert.EqualError(t, (&longPressConfig{Mode: longPressCustom}).check(builtinSets),
+		"Action: custom long press requires an action")
+	assert.NoError(t, (&longPressConfig{Mode: longPressCustom,
+		Action: &ActionInfo{Type: ActionTypeBuiltin, Action: "ShowWorkspace"}}).check(builtinSets))
+	assert.Error(t, (&longPressConfig{Mode: longPressCustom,
+		Action: &ActionInfo{Type: ActionTypeBuiltin, Action: "Unknown"}}).check(builtinSets))
+}
+
+// 测试：没有配置或配置无效时模拟鼠标右键，模拟鼠标右键时不保存
+func Test_longPressConfigValue(t *testing.T) {
+	var cfg *longPressConfig
+	assert.Equal(t, longPressRightClick, cfg.value().Mode)
+	assert.Equal(t, longPressRightClick, (&longPressConfig{Mode: longPressCustom}).value().Mode)
+	assert.Equal(t, longPressRightClick, (&longPressConfig{Mode: "radial-menu"}).value().Mode)
+
+	assert.Nil(t, longPressConfig{Mode: longPressRightClick}.configValue())
+	custom := ActionInfo{Type: ActionTypeShortcut, Action: "ctrl+c"}
+	assert.NotNil(t, longPressConfig{Mode: longPressCustom, Action: &custom}.configValue())
+}
+
+// 测试：长按按下和抬起时执行的动作
+func Test_longPressConfigAction(t *testing.T) {
+	down := newTouchRightButtonInfo("down", "MouseRightButtonDown")
+	up := newTouchRightButtonInfo("up", "MouseRightButtonUp")
+
+	cfg := longPressConfig{Mode: longPressRightClick}
+	action, ok := cfg.action(down)
+	assert.True(t, ok)
+	assert.Equal(t, "MouseRightButtonDown", action.Action)
+	action, ok = cfg.action(up)
+	assert.True(t, ok)
+	assert.Equal(t, "MouseRightButtonUp", action.Action)
+
+	custom := ActionInfo{Type: ActionTypeShortcut, Action: "ctrl+c"}
+	cfg = longPressConfig{Mode: longPressCustom, Action: &custom}
+	action, ok = cfg.action(down)
+	assert.True(t, ok)
+	assert.Equal(t, custom, action)
+	_, ok = cfg.action(up)
+	assert.False(t, ok)
+}
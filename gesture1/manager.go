@@ -5,16 +5,12 @@
 package gesture1
 
 import (
-	"encoding/json"
 	"fmt"
-
-	"io/ioutil"
 	"math"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/godbus/dbus/v5"
 	dock "github.com/linuxdeepin/go-dbus-factory/session/com.deepin.dde.daemon.dock"
@@ -22,14 +18,17 @@ import (
 	wm "github.com/linuxdeepin/go-dbus-factory/session/com.deepin.wm"
 	clipboard "github.com/linuxdeepin/go-dbus-factory/session/org.deepin.dde.clipboard1"
 	display "github.com/linuxdeepin/go-dbus-factory/session/org.deepin.dde.display1"
+	inputdevices "github.com/linuxdeepin/go-dbus-factory/session/org.deepin.dde.inputdevices1"
 	sessionmanager "github.com/linuxdeepin/go-dbus-factory/session/org.deepin.dde.sessionmanager1"
 	sessionwatcher "github.com/linuxdeepin/go-dbus-factory/session/org.deepin.dde.sessionwatcher1"
+	ofdbus "github.com/linuxdeepin/go-dbus-factory/session/org.freedesktop.dbus"
 	daemon "github.com/linuxdeepin/go-dbus-factory/system/org.deepin.dde.daemon1"
 	gesture "github.com/linuxdeepin/go-dbus-factory/system/org.deepin.dde.gesture1"
 	gio "github.com/linuxdeepin/go-gir/gio-2.0"
 	"github.com/linuxdeepin/go-lib/dbusutil"
 	"github.com/linuxdeepin/go-lib/dbusutil/proxy"
 	"github.com/linuxdeepin/go-lib/gsettings"
+	"github.com/linuxdeepin/go-lib/strv"
 	dutils "github.com/linuxdeepin/go-lib/utils"
 )
 
@@ -43,6 +42,9 @@ const (
 	tsSchemaKeyBlacklist    = "longpress-blacklist"
 )
 
+// 触摸屏长按模拟鼠标右键的手势
+const touchRightButton = "touch right button"
+
 type deviceType int32 // 设备类型(触摸屏，触摸板)
 
 const (
@@ -57,6 +59,7 @@ func setUseWayland(value bool) {
 }
 
 type Manager struct {
+	service            *dbusutil.Service
 	wm                 wm.Wm
 	sysDaemon          daemon.Daemon
 	systemSigLoop      *dbusutil.SignalLoop
@@ -66,11 +69,21 @@ type Manager struct {
 	gesture            gesture.Gesture
 	dock               dock.Dock
 	display            display.Display
+	touchPad           inputdevices.TouchPad
 	setting            *gio.Settings
 	tsSetting          *gio.Settings
 	touchPadEnabled    bool
 	touchScreenEnabled bool
 	Infos              gestureInfos
+	touchEdges         touchEdgeConfigs
+	cooldowns          map[string]uint32
+	thresholds         gestureThresholds
+	longPress          longPressConfig
+	stats              map[string]uint64
+	failures           map[string]uint64
+	statsEnabled       bool
+	statsChanged       bool
+	lastExecTime       map[string]time.Time
 	sessionmanager     sessionmanager.SessionManager
 	clipboard          clipboard.Clipboard
 	notification       notification.Notification
@@ -81,9 +94,88 @@ type Manager struct {
 	oneFingerRightEnable  bool
 	configManagerPath     dbus.ObjectPath
 	sessionWatcher        sessionwatcher.SessionWatcher
+	injectBackend         string
+	injector              inputInjector
+	// 最近一次边缘滑动或多指滑动所在触摸屏的设备节点，由信号传入
+	lastTouchDevice string
+	// 窗口管理器自己处理的手势，见 EventInfo.key
+	wmNativeGestures []string
+
+	// 锁屏时的手势策略：disabled、limited 或 full
+	LockedGesturePolicy   string `prop:"access:rw"`
+	LockedGesturePolicyMu sync.RWMutex
+
+	// 为 false 时其他程序抓取键盘也不屏蔽手势，见 dconfigKeyKbdGrabCheckEnabled
+	KbdGrabCheckEnabled   bool `prop:"access:rw"`
+	KbdGrabCheckEnabledMu sync.RWMutex
+	// 抓取键盘时不屏蔽手势的窗口，见 dconfigKeyKbdGrabExceptions
+	kbdGrabExceptions []string
+
+	// 是否屏蔽边缘手势和多指手势，自动模式下全屏游戏时为 true
+	Suppressed          bool
+	SuppressedMu        sync.Mutex
+	suppressionOverride string
+	// 游戏窗口列表，见 dconfigKeyGameModeWindows
+	gameModeWindows []string
+
+	// commandline 类型动作的执行方式、沙盒和允许执行的程序，见 dconfigKeyCommandExecMode
+	commandExecMode  string
+	commandSandbox   string
+	commandAllowlist []string
+
+	// 订阅 GestureEvent 信号的程序，键为 D-Bus 连接名，值为可执行文件路径
+	eventListeners         map[string]string
+	eventListenerAllowlist []string
+	eventListenersMu       sync.Mutex
+	sessionSigLoop         *dbusutil.SignalLoop
+	dbusDaemon             ofdbus.DBus
+
+	// 正在进行的多指滑动，用于跟随手指切换工作区
+	workspaceSwipe   *workspaceSwipe
+	workspaceSwipeMu sync.Mutex
+	// 上一次多指滑动已经由三指拖动或跟随手指切换工作区处理
+	swipeHandled bool
+
+	// 正在进行的三指拖动
+	drag   *threeFingerDrag
+	dragMu sync.Mutex
+
+	// 上一次点击触摸屏边缘，用于识别双击边缘
+	edgeTap edgeTapTracker
+
+	// 当前使用的手势映射的模式，laptop 或 tablet，可翻转笔记本进入平板模式时切换
+	Mode   string
+	ModeMu sync.RWMutex
+	// 与 Mode 相同，由 mu 保护，Infos 和 touchEdges 是该模式的映射
+	mode string
+	// 另一种模式的手势映射
+	inactiveMap gestureMap
+
+	// nolint
+	signals *struct {
+		GestureTriggered struct {
+			name      string
+			direction string
+			fingers   int32
+		}
+
+		// 只发送给通过 SubscribeGestureEvent 订阅的程序
+		GestureEvent struct {
+			name      string
+			direction string
+			fingers   int32
+			x         float64
+			y         float64
+		}
+
+		// 切换笔记本模式和平板模式的手势映射时发送
+		ModeChanged struct {
+			mode string
+		}
+	}
 }
 
-func newManager() (*Manager, error) {
+func newManager(service *dbusutil.Service) (*Manager, error) {
 	setUseWayland(len(os.Getenv("WAYLAND_DISPLAY")) != 0)
 	sessionConn, err := dbus.SessionBus()
 	if err != nil {
@@ -100,34 +192,18 @@ func newManager() (*Manager, error) {
 		filename = configSystemPath
 	}
 
-	infos, err := newGestureInfosFromFile(filename)
+	cfg, err := newGestureConfigFromFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	// for touch long press
-	infos = append(infos, &gestureInfo{
-		Event: EventInfo{
-			Name:      "touch right button",
-			Direction: "down",
-			Fingers:   0,
-		},
-		Action: ActionInfo{
-			Type:   ActionTypeCommandline,
-			Action: "xdotool mousedown 3",
-		},
-	})
-	infos = append(infos, &gestureInfo{
-		Event: EventInfo{
-			Name:      "touch right button",
-			Direction: "up",
-			Fingers:   0,
-		},
-		Action: ActionInfo{
-			Type:   ActionTypeCommandline,
-			Action: "xdotool mouseup 3",
-		},
-	})
-
+	if filename == configUserPath && cfg.Version < gestureConfigVersion {
+		defaults, err := newGestureInfosFromFile(configSystemPath)
+		if err != nil {
+			logger.Warning("failed to load default gesture config:", err)
+		} else {
+			cfg.Infos = cfg.Infos.mergeDefaults(defaults, multiFingerGestureMinFingers)
+		}
+	}
 	setting, err := dutils.CheckAndNewGSettings(gestureSchemaId)
 	if err != nil {
 		return nil, err
@@ -138,9 +214,21 @@ func newManager() (*Manager, error) {
 		return nil, err
 	}
 
+	laptop, tablet := newGestureMaps(cfg)
 	m := &Manager{
+		service:            service,
 		userFile:           configUserPath,
-		Infos:              infos,
+		Infos:              laptop.Infos,
+		touchEdges:         laptop.TouchEdges,
+		inactiveMap:        tablet,
+		Mode:               gestureModeLaptop,
+		mode:               gestureModeLaptop,
+		cooldowns:          cfg.Cooldowns,
+		thresholds:         cfg.Thresholds.value(),
+		longPress:          cfg.LongPress.value(),
+		stats:              cfg.Stats,
+		failures:           cfg.Failures,
+		lastExecTime:       make(map[string]time.Time),
 		setting:            setting,
 		tsSetting:          tsSetting,
 		touchPadEnabled:    setting.GetBoolean(gsKeyTouchPadEnabled),
@@ -148,6 +236,7 @@ func newManager() (*Manager, error) {
 		wm:                 wm.NewWm(sessionConn),
 		dock:               dock.NewDock(sessionConn),
 		display:            display.NewDisplay(sessionConn),
+		touchPad:           inputdevices.NewTouchPad(sessionConn),
 		sysDaemon:          daemon.NewDaemon(systemConn),
 		sessionmanager:     sessionmanager.NewSessionManager(sessionConn),
 		clipboard:          clipboard.NewClipboard(sessionConn),
@@ -163,11 +252,24 @@ func newManager() (*Manager, error) {
 	m.oneFingerBottomEnable = m.getGestureConfigValue("oneFingerBottomEnable")
 	m.oneFingerLeftEnable = m.getGestureConfigValue("oneFingerLeftEnable")
 	m.oneFingerRightEnable = m.getGestureConfigValue("oneFingerRightEnable")
-
-	if _useWayland {
-		setLongPressEnable(m.longPressEnable)
+	m.injectBackend = m.getGestureConfigString(dconfigKeyInputInjectBackend)
+	m.setWmNativeGestures(m.getGestureConfigStrv(dconfigKeyWmNativeGestures))
+	m.setGameModeWindows(m.getGestureConfigStrv(dconfigKeyGameModeWindows))
+	m.setCommandExecMode(m.getGestureConfigString(dconfigKeyCommandExecMode))
+	m.setCommandAllowlist(m.getGestureConfigStrv(dconfigKeyCommandAllowlist))
+	m.setCommandSandbox(m.getGestureConfigString(dconfigKeyCommandSandbox))
+	m.setStatsEnabled(m.getGestureConfigValue(dconfigKeyGestureStatsEnabled))
+	m.KbdGrabCheckEnabled = m.getGestureConfigValue(dconfigKeyKbdGrabCheckEnabled)
+	m.setKbdGrabExceptions(m.getGestureConfigStrv(dconfigKeyKbdGrabExceptions))
+	m.setEventListenerAllowlist(m.getGestureConfigStrv(dconfigKeyEventListenerAllowlist))
+	m.suppressionOverride = suppressionAuto
+	m.LockedGesturePolicy = m.getGestureConfigString(dconfigKeyLockedGesturePolicy)
+	if !isValidLockedPolicy(m.LockedGesturePolicy) {
+		m.LockedGesturePolicy = lockedPolicyDisabled
 	}
 
+	m.applyLongPressEnable()
+
 	m.gesture = gesture.NewGesture(systemConn)
 	m.systemSigLoop = dbusutil.NewSignalLoop(systemConn, 10)
 
@@ -177,6 +279,40 @@ func newManager() (*Manager, error) {
 	return m, nil
 }
 
+// withTouchRightButtonInfos 触摸屏长按的手势由代码添加，忽略配置文件中保存的旧版本的动作
+func withTouchRightButtonInfos(cfgInfos gestureInfos) gestureInfos {
+	var infos gestureInfos
+	for _, info := range cfgInfos {
+		if info.Event.Name != touchRightButton {
+			infos = append(infos, info)
+		}
+	}
+	// for touch long press
+	infos = append(infos, &gestureInfo{
+		Event: EventInfo{
+			Name:      touchRightButton,
+			Direction: "down",
+			Fingers:   0,
+		},
+		Action: ActionInfo{
+			Type:   ActionTypeBuiltin,
+			Action: "MouseRightButtonDown",
+		},
+	})
+	infos = append(infos, &gestureInfo{
+		Event: EventInfo{
+			Name:      touchRightButton,
+			Direction: "up",
+			Fingers:   0,
+		},
+		Action: ActionInfo{
+			Type:   ActionTypeBuiltin,
+			Action: "MouseRightButtonUp",
+		},
+	})
+	return infos
+}
+
 func setLongPressEnable(enable bool) {
 	sessionBus, err := dbus.SessionBus()
 	if err != nil {
@@ -205,10 +341,130 @@ func (m *Manager) getGestureConfigValue(key string) bool {
 	return val
 }
 
+func (m *Manager) getGestureConfigString(key string) string {
+	systemConn, err := dbus.SystemBus()
+	if err != nil {
+		return ""
+	}
+	systemConnObj := systemConn.Object("org.desktopspec.ConfigManager", m.configManagerPath)
+	var val string
+	err = systemConnObj.Call("org.desktopspec.ConfigManager.Manager.value", 0, key).Store(&val)
+	if err != nil {
+		logger.Warning(err)
+		return ""
+	}
+	return val
+}
+
+func (m *Manager) setGestureConfigString(key, value string) error {
+	systemConn, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	systemConnObj := systemConn.Object("org.desktopspec.ConfigManager", m.configManagerPath)
+	return systemConnObj.Call("org.desktopspec.ConfigManager.Manager.setValue", 0, key, dbus.MakeVariant(value)).Err
+}
+
+func (m *Manager) setGestureConfigBool(key string, value bool) error {
+	systemConn, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	systemConnObj := systemConn.Object("org.desktopspec.ConfigManager", m.configManagerPath)
+	return systemConnObj.Call("org.desktopspec.ConfigManager.Manager.setValue", 0, key, dbus.MakeVariant(value)).Err
+}
+
+func (m *Manager) setGestureConfigDouble(key string, value float64) error {
+	systemConn, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	systemConnObj := systemConn.Object("org.desktopspec.ConfigManager", m.configManagerPath)
+	return systemConnObj.Call("org.desktopspec.ConfigManager.Manager.setValue", 0, key, dbus.MakeVariant(value)).Err
+}
+
+// getGestureConfigDouble dconfig 中的数值可能是整数或浮点数，读取失败时返回 ok 为 false
+func (m *Manager) getGestureConfigDouble(key string) (float64, bool) {
+	systemConn, err := dbus.SystemBus()
+	if err != nil {
+		return 0, false
+	}
+	systemConnObj := systemConn.Object("org.desktopspec.ConfigManager", m.configManagerPath)
+	var val dbus.Variant
+	err = systemConnObj.Call("org.desktopspec.ConfigManager.Manager.value", 0, key).Store(&val)
+	if err != nil {
+		logger.Warning(err)
+		return 0, false
+	}
+	switch v := val.Value().(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	}
+	logger.Warningf("type of %s is wrong", key)
+	return 0, false
+}
+
+func (m *Manager) getGestureConfigStrv(key string) []string {
+	systemConn, err := dbus.SystemBus()
+	if err != nil {
+		return nil
+	}
+	systemConnObj := systemConn.Object("org.desktopspec.ConfigManager", m.configManagerPath)
+	var val dbus.Variant
+	err = systemConnObj.Call("org.desktopspec.ConfigManager.Manager.value", 0, key).Store(&val)
+	if err != nil {
+		logger.Warning(err)
+		return nil
+	}
+	items, ok := val.Value().([]dbus.Variant)
+	if !ok {
+		logger.Warningf("type of %s is wrong", key)
+		return nil
+	}
+	var result []string
+	for _, item := range items {
+		if str, ok := item.Value().(string); ok && str != "" {
+			result = append(result, str)
+		}
+	}
+	return result
+}
+
 func (m *Manager) destroy() {
+	// 只在启用统计且统计有变化时保存，避免生成用户配置文件覆盖系统默认配置
+	m.mu.RLock()
+	statsChanged := m.statsEnabled && m.statsChanged
+	m.mu.RUnlock()
+	if statsChanged {
+		err := m.Write()
+		if err != nil {
+			logger.Warning("failed to save gesture config:", err)
+		}
+	}
+
 	m.gesture.RemoveHandler(proxy.RemoveAllHandlers)
 	m.systemSigLoop.Stop()
+	// 退出时松开三指拖动按住的鼠标左键
+	_, err := m.endThreeFingerDrag()
+	if err != nil {
+		logger.Warning("failed to end three finger drag:", err)
+	}
+	if m.dbusDaemon != nil {
+		m.dbusDaemon.RemoveHandler(proxy.RemoveAllHandlers)
+		m.sessionSigLoop.Stop()
+	}
 	m.setting.Unref()
+
+	m.mu.Lock()
+	if m.injector != nil {
+		m.injector.destroy()
+		m.injector = nil
+	}
+	m.mu.Unlock()
 }
 
 func (m *Manager) init() {
@@ -225,6 +481,8 @@ func (m *Manager) init() {
 	if err != nil {
 		logger.Warning("call SetEdgeMoveStopDuration failed:", err)
 	}
+	m.applyRotateAngleThreshold()
+	m.applyGestureThresholds()
 
 	systemConn, err := dbus.SystemBus()
 	if err != nil {
@@ -249,16 +507,55 @@ func (m *Manager) init() {
 		if !should {
 			return
 		}
+		m.broadcastGestureEvent(name, direction, fingers, noEventPosition, noEventPosition)
 
-		err = m.Exec(EventInfo{
+		evInfo := EventInfo{
 			Name:      name,
 			Direction: direction,
 			Fingers:   fingers,
-		})
+		}
+		if m.isSwipeHandled(evInfo) {
+			logger.Debug("swipe is already handled by its update:", evInfo.toString())
+			return
+		}
+		err = m.Exec(evInfo)
 		if err != nil {
 			logger.Error("Exec failed:", err)
 		}
 	})
+	m.initWorkspaceSwipe(systemConn)
+	m.initTabletMode(systemConn)
+	m.initTouchTap(systemConn)
+	m.initTouchTapEvent(systemConn)
+	m.initTouchScreenEvents(systemConn)
+
+	_, err = m.gesture.ConnectDbclickDown(func(fingers int32) {
+		err := m.handleDbclickDown(fingers)
+		if err != nil {
+			logger.Error("handleDbclickDown failed:", err)
+		}
+	})
+	if err != nil {
+		logger.Error("connect DbclickDown failed:", err)
+	}
+	_, err = m.gesture.ConnectSwipeMoving(func(fingers int32, accelX float64, accelY float64) {
+		err := m.handleSwipeMoving(fingers, accelX, accelY)
+		if err != nil {
+			logger.Error("handleSwipeMoving failed:", err)
+		}
+	})
+	if err != nil {
+		logger.Error("connect SwipeMoving failed:", err)
+	}
+	_, err = m.gesture.ConnectSwipeStop(func(fingers int32) {
+		err := m.handleSwipeStop(fingers)
+		if err != nil {
+			logger.Error("handleSwipeStop failed:", err)
+		}
+	})
+	if err != nil {
+		logger.Error("connect SwipeStop failed:", err)
+	}
 
 	m.systemSigLoop.AddHandler(&dbusutil.SignalRule{
 		Name: "org.desktopspec.ConfigManager.Manager.valueChanged",
@@ -277,15 +574,60 @@ func (m *Manager) init() {
 			case "longPressEnable":
 				m.longPressEnable = m.getGestureConfigValue("longPressEnable")
 				logger.Info("DConfig of longPressEnable : ", m.longPressEnable)
-				if _useWayland {
-					setLongPressEnable(m.longPressEnable)
-				}
+				m.applyLongPressEnable()
 			case "oneFingerLeftEnable":
 				m.oneFingerLeftEnable = m.getGestureConfigValue("oneFingerLeftEnable")
 				logger.Info("DConfig of oneFingerLeftEnable : ", m.oneFingerLeftEnable)
 			case "oneFingerRightEnable":
 				m.oneFingerRightEnable = m.getGestureConfigValue("oneFingerRightEnable")
 				logger.Info("DConfig of oneFingerRightEnable : ", m.oneFingerRightEnable)
+			case dconfigKeyInputInjectBackend:
+				backend := m.getGestureConfigString(dconfigKeyInputInjectBackend)
+				logger.Info("DConfig of inputInjectBackend : ", backend)
+				m.setInputInjectBackend(backend)
+			case dconfigKeyWmNativeGestures:
+				gestures := m.getGestureConfigStrv(dconfigKeyWmNativeGestures)
+				logger.Info("DConfig of wmNativeGestures : ", gestures)
+				m.setWmNativeGestures(gestures)
+			case dconfigKeyLockedGesturePolicy:
+				policy := m.getGestureConfigString(dconfigKeyLockedGesturePolicy)
+				logger.Info("DConfig of lockedGesturePolicy : ", policy)
+				m.setLockedGesturePolicy(policy)
+			case dconfigKeyRotateAngleThreshold:
+				m.applyRotateAngleThreshold()
+			case dconfigKeyGameModeWindows:
+				windows := m.getGestureConfigStrv(dconfigKeyGameModeWindows)
+				logger.Info("DConfig of gameModeWindows : ", windows)
+				m.setGameModeWindows(windows)
+				m.updateSuppressed()
+			case dconfigKeyCommandExecMode:
+				mode := m.getGestureConfigString(dconfigKeyCommandExecMode)
+				logger.Info("DConfig of commandExecMode : ", mode)
+				m.setCommandExecMode(mode)
+			case dconfigKeyCommandAllowlist:
+				list := m.getGestureConfigStrv(dconfigKeyCommandAllowlist)
+				logger.Info("DConfig of commandAllowlist : ", list)
+				m.setCommandAllowlist(list)
+			case dconfigKeyCommandSandbox:
+				sandbox := m.getGestureConfigString(dconfigKeyCommandSandbox)
+				logger.Info("DConfig of commandSandbox : ", sandbox)
+				m.setCommandSandbox(sandbox)
+			case dconfigKeyGestureStatsEnabled:
+				enabled := m.getGestureConfigValue(dconfigKeyGestureStatsEnabled)
+				logger.Info("DConfig of gestureStatsEnabled : ", enabled)
+				m.setStatsEnabled(enabled)
+			case dconfigKeyKbdGrabCheckEnabled:
+				enabled := m.getGestureConfigValue(dconfigKeyKbdGrabCheckEnabled)
+				logger.Info("DConfig of kbdGrabCheckEnabled : ", enabled)
+				m.setKbdGrabCheckEnabled(enabled)
+			case dconfigKeyKbdGrabExceptions:
+				list := m.getGestureConfigStrv(dconfigKeyKbdGrabExceptions)
+				logger.Info("DConfig of kbdGrabExceptions : ", list)
+				m.setKbdGrabExceptions(list)
+			case dconfigKeyEventListenerAllowlist:
+				list := m.getGestureConfigStrv(dconfigKeyEventListenerAllowlist)
+				logger.Info("DConfig of eventListenerAllowlist : ", list)
+				m.setEventListenerAllowlist(list)
 			default:
 				logger.Warning("Not use key : ", key)
 			}
@@ -306,7 +648,7 @@ func (m *Manager) init() {
 			return
 		}
 
-		context, pointFn, err := m.getTouchScreenRotationContext()
+		context, pointFn, err := m.getTouchScreenRotationContext(queryLastTouchDevice())
 		if err != nil {
 			logger.Error("getTouchScreenRotationContext failed:", err)
 		}
@@ -322,80 +664,52 @@ func (m *Manager) init() {
 		logger.Error("connect TouchEdgeMoveStopLeave failed:", err)
 	}
 
-	_, err = m.gesture.ConnectTouchEdgeEvent(func(direction string, scaleX float64, scaleY float64) {
-		should, err := m.shouldHandleEvent(deviceTouchScreen)
-		if err != nil {
-			logger.Error("shouldHandleEvent failed:", err)
-			return
-		}
-		if !should {
-			return
-		}
-		context, pointFn, err := m.getTouchScreenRotationContext()
-		if err != nil {
-			logger.Error("getTouchScreenRotationContext failed:", err)
-		}
-		p := &point{X: scaleX, Y: scaleY}
-		pointFn(p)
-		err = m.handleTouchEdgeEvent(context, direction, p)
-		if err != nil {
-			logger.Error("handleTouchEdgeEvent failed:", err)
-		}
-	})
-	if err != nil {
-		logger.Error("connect handleTouchEdgeEvent failed:", err)
-	}
-
-	_, err = m.gesture.ConnectTouchMovementEvent(func(direction string, fingers int32, startScaleX float64, startScaleY float64, endScaleX float64, endScaleY float64) {
-		should, err := m.shouldHandleEvent(deviceTouchScreen)
-		if err != nil {
-			logger.Error("shouldHandleEvent failed:", err)
-			return
-		}
-		if !should {
-			return
-		}
+	m.listenGSettingsChanged()
+	m.initEventListeners()
+	m.initGameModeWatcher()
 
-		context, pointFn, err := m.getTouchScreenRotationContext()
+	so := m.service.GetServerObject(m)
+	if so != nil {
+		err = so.SetWriteCallback(m, "LockedGesturePolicy", m.lockedGesturePolicyWriteCb)
 		if err != nil {
-			logger.Error("getTouchScreenRotationContext failed:", err)
+			logger.Warning("set write callback of LockedGesturePolicy failed:", err)
 		}
-
-		startP := &point{X: startScaleX, Y: startScaleY}
-		endP := &point{X: endScaleX, Y: endScaleY}
-		pointFn(startP)
-		pointFn(endP)
-
-		err = m.handleTouchMovementEvent(context, direction, fingers, startP, endP)
+		err = so.SetWriteCallback(m, "KbdGrabCheckEnabled", m.kbdGrabCheckEnabledWriteCb)
 		if err != nil {
-			logger.Error("handleTouchMovementEvent failed:", err)
+			logger.Warning("set write callback of KbdGrabCheckEnabled failed:", err)
 		}
-	})
-	if err != nil {
-		logger.Error("connect handleTouchMovementEvent failed:", err)
 	}
-	m.listenGSettingsChanged()
 }
 
 func (m *Manager) shouldIgnoreGesture(info *gestureInfo) bool {
 	// allow right button up when kbd grabbed
-	if (info.Event.Name != "touch right button" || info.Event.Direction != "up") && isKbdAlreadyGrabbed() {
-		// 多任务窗口下，不应该忽略手势操作
-		isShowMultiTask, err := m.wm.GetMultiTaskingStatus(0)
-		if err != nil {
-			logger.Warning(err)
-		} else if isShowMultiTask && info.Event.Name == "swipe" {
-			logger.Debug("should not ignore swipe event, because we are in multi task")
+	if info.Event.Name != touchRightButton || info.Event.Direction != "up" {
+		// 锁屏时是否处理手势只由锁屏时的手势策略决定，不受键盘抓取检查和例外窗口的影响
+		if m.isSessionLocked() {
+			if m.getLockedGesturePolicy() == lockedPolicyDisabled {
+				logger.Debug("session is locked, not exec action")
+				return true
+			}
 			return false
 		}
-		logger.Debug("another process grabbed keyboard, not exec action")
-		return true
+		if m.isKbdGrabbedByOthers() {
+			// 多任务窗口下，不应该忽略手势操作
+			isShowMultiTask, err := m.wm.GetMultiTaskingStatus(0)
+			if err != nil {
+				logger.Warning(err)
+			} else if isShowMultiTask && (info.Event.Name == "swipe" || info.Event.Name == "pinch") {
+				logger.Debugf("should not ignore %s event, because we are in multi task", info.Event.Name)
+				return false
+			}
+			logger.Debug("another process grabbed keyboard, not exec action")
+			return true
+		}
 	}
 
 	// TODO(jouyouyun): improve touch right button handler
-	if info.Event.Name == "touch right button" {
+	if info.Event.Name == touchRightButton {
 		// filter google chrome
-		if isInWindowBlacklist(getCurrentActionWindowCmd(), m.tsSetting.GetStrv(tsSchemaKeyBlacklist)) {
+		if isInWindowBlacklist(getActiveWindowInfo(), m.tsSetting.GetStrv(tsSchemaKeyBlacklist)) {
 			logger.Debug("the current active window in blacklist")
 			return true
 		}
@@ -417,56 +731,219 @@ func (m *Manager) Exec(evInfo EventInfo) error {
 		}
 	}
 
-	info := m.Infos.Get(evInfo)
+	info := m.lookupGesture(evInfo)
 	if info == nil {
 		logger.Infof("[Exec]: not found event info: %s", evInfo.toString())
 		return nil
 	}
 
 	logger.Debugf("[Exec]: event info:%s  action info:%s", info.Event.toString(), info.Action.toString())
+	if !info.isEnabled() {
+		logger.Debug("gesture is disabled:", info.Event.toString())
+		return nil
+	}
 	if m.shouldIgnoreGesture(info) {
 		return nil
 	}
 
-	if (!m.longPressEnable || _useWayland) && strings.Contains(string(info.Event.Name), "touch right button") {
+	if strings.Contains(string(info.Event.Name), touchRightButton) {
+		if m.shouldSkipLongPress() {
+			return nil
+		}
+		action, ok := m.getLongPress().action(info)
+		if !ok {
+			return nil
+		}
+		info = &gestureInfo{Event: info.Event, Action: action}
+	}
+
+	if m.isWmNativeGesture(info.Event) {
+		logger.Debug("gesture is handled by window manager:", info.Event.toString())
+		return nil
+	}
+
+	if m.shouldSuppressGesture(info.Event) {
+		return nil
+	}
+
+	if m.isInCooldown(info.Event) {
+		logger.Debug("gesture action is in cooldown:", info.Event.toString())
 		return nil
 	}
+	m.recordExec(info.Event)
 
-	var cmd = info.Action.Action
-	switch info.Action.Type {
+	return m.execGestureAction(info.Event, info.Action)
+}
+
+// execAction 执行手势的动作，evInfo 用于 signal 类型的动作
+func (m *Manager) execAction(evInfo EventInfo, action ActionInfo) error {
+	if !m.isActionAllowed(action) {
+		logger.Debug("action is not allowed while the screen is locked:", action.toString())
+		return nil
+	}
+
+	var cmd = action.Action
+	switch action.Type {
 	case ActionTypeCommandline:
-		break
+		return m.execCommandline(cmd)
 	case ActionTypeShortcut:
-		cmd = fmt.Sprintf("xdotool key %s", cmd)
+		return m.sendShortcut(cmd)
 	case ActionTypeBuiltin:
 		return m.handleBuiltinAction(cmd)
+	case ActionTypeSignal:
+		return m.emitGestureTriggered(evInfo)
+	case ActionTypeDBus:
+		return m.callDBusAction(action.DBus)
+	case ActionTypeChain:
+		return m.execActionChain(evInfo, action.Chain)
 	default:
-		return fmt.Errorf("invalid action type: %s", info.Action.Type)
+		return fmt.Errorf("invalid action type: %s", action.Type)
 	}
+}
 
-	// #nosec G204
-	out, err := exec.Command("/bin/sh", "-c", cmd).CombinedOutput()
+func (m *Manager) Write() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.writeNoLock()
+}
+
+func (m *Manager) writeNoLock() error {
+	laptop, tablet := m.gestureMapsNoLock()
+	cfg := &gestureConfig{
+		Version:    gestureConfigVersion,
+		Infos:      laptop.Infos,
+		TouchEdges: laptop.TouchEdges,
+		Cooldowns:  m.cooldowns,
+		Thresholds: m.thresholds.configValue(),
+		LongPress:  m.longPress.configValue(),
+		Stats:      m.stats,
+		Failures:   m.failures,
+		Tablet:     &tablet,
+	}
+	err := cfg.writeFile(m.userFile)
 	if err != nil {
-		return fmt.Errorf("%s", string(out))
+		return err
 	}
+	m.statsChanged = false
 	return nil
 }
 
-func (m *Manager) Write() error {
+// updateInfos 保存修改后的手势列表，保存失败时不修改当前的手势列表
+func (m *Manager) updateInfos(fn func(infos gestureInfos) (gestureInfos, error)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos, err := fn(m.Infos)
+	if err != nil {
+		return err
+	}
+	oldInfos := m.Infos
+	m.Infos = infos
+	err = m.writeNoLock()
+	if err != nil {
+		m.Infos = oldInfos
+		return err
+	}
+	return nil
+}
+
+// getGestureConfig 返回可编辑的配置，不包含内部使用的手势和执行次数统计
+func (m *Manager) getGestureConfig() *gestureConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	laptop, tablet := m.gestureMapsNoLock()
+	tablet = tablet.editable()
+	return &gestureConfig{
+		Version:    gestureConfigVersion,
+		Infos:      laptop.Infos.editable(),
+		TouchEdges: laptop.TouchEdges,
+		Cooldowns:  m.cooldowns,
+		Thresholds: m.thresholds.configValue(),
+		LongPress:  m.longPress.configValue(),
+		Tablet:     &tablet,
+	}
+}
+
+// setGestureConfig 替换全部配置并保存，cfg 中的执行次数统计被忽略，保存失败时不修改当前的配置
+func (m *Manager) setGestureConfig(cfg *gestureConfig) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// #nosec G301
-	err := os.MkdirAll(filepath.Dir(m.userFile), 0755)
+	oldLaptop, oldTablet := m.gestureMapsNoLock()
+	oldCooldowns, oldThresholds, oldLongPress := m.cooldowns, m.thresholds, m.longPress
+	m.setGestureMapsNoLock(newGestureMaps(cfg))
+	m.cooldowns = cfg.Cooldowns
+	m.thresholds = cfg.Thresholds.value()
+	m.longPress = cfg.LongPress.value()
+	err := m.writeNoLock()
 	if err != nil {
+		m.setGestureMapsNoLock(oldLaptop, oldTablet)
+		m.cooldowns, m.thresholds, m.longPress = oldCooldowns, oldThresholds, oldLongPress
 		return err
 	}
-	data, err := json.Marshal(m.Infos)
+	return nil
+}
+
+// resetToDefaults 删除用户配置文件，重新加载系统配置文件
+func (m *Manager) resetToDefaults() error {
+	infos, err := newGestureInfosFromFile(configSystemPath)
 	if err != nil {
 		return err
 	}
-	// #nosec G306
-	return ioutil.WriteFile(m.userFile, data, 0644)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	err = os.Remove(m.userFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	m.setGestureMapsNoLock(newGestureMaps(&gestureConfig{Infos: infos}))
+	m.cooldowns = nil
+	m.thresholds = gestureThresholds{}
+	m.longPress = longPressConfig{Mode: longPressRightClick}
+	m.stats = nil
+	m.failures = nil
+	m.statsChanged = false
+	m.lastExecTime = make(map[string]time.Time)
+	return nil
+}
+
+// setWmNativeGestures 设置窗口管理器自己处理的手势，这些手势不再执行配置的动作
+func (m *Manager) setWmNativeGestures(gestures []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.wmNativeGestures = gestures
+	for _, info := range m.Infos {
+		if strv.Strv(gestures).Contains(info.Event.withoutModifiers().key()) {
+			logger.Warningf("gesture %s conflicts with window manager, action %s is ignored",
+				info.Event.toString(), info.Action.toString())
+		}
+	}
+}
+
+func (m *Manager) isWmNativeGesture(evInfo EventInfo) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return strv.Strv(m.wmNativeGestures).Contains(evInfo.withoutModifiers().key())
+}
+
+// isInCooldown 距离上次执行该手势动作的时间小于配置的冷却时间时返回 true
+func (m *Manager) isInCooldown(evInfo EventInfo) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	cooldown := m.cooldowns[evInfo.key()]
+	if cooldown == 0 {
+		return false
+	}
+	last, ok := m.lastExecTime[evInfo.key()]
+	return ok && time.Since(last) < time.Duration(cooldown)*time.Millisecond
+}
+
+// recordExec 记录执行手势动作的时间，用于计算冷却时间
+func (m *Manager) recordExec(evInfo EventInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastExecTime[evInfo.key()] = time.Now()
 }
 
 func (m *Manager) listenGSettingsChanged() {
@@ -483,8 +960,16 @@ func (m *Manager) listenGSettingsChanged() {
 	})
 }
 
+// 将手势交给外部程序处理，由监听 GestureTriggered 信号的程序实现具体动作
+func (m *Manager) emitGestureTriggered(evInfo EventInfo) error {
+	if m.service == nil {
+		return fmt.Errorf("service is not ready, can not emit GestureTriggered for: %s", evInfo.toString())
+	}
+	return m.service.Emit(m, "GestureTriggered", evInfo.Name, evInfo.Direction, evInfo.Fingers)
+}
+
 func (m *Manager) handleBuiltinAction(cmd string) error {
-	fn := m.builtinSets[cmd]
+	fn := m.getBuiltinSets()[cmd]
 	if fn == nil {
 		return fmt.Errorf("invalid built-in action %q", cmd)
 	}
@@ -505,9 +990,145 @@ const (
 	Rotation_270 TouchScreensRotation = 8
 )
 
-// 获取触摸屏的旋转
-func (m *Manager) getTouchScreenRotation() (display.Monitor, TouchScreensRotation) {
-	// 读取触屏列表，取第一个触屏（目前触摸手势事件中不包含所属屏幕，因此不支持多个触摸屏）
+// initTouchScreenEvents 监听 system/gesture1 的触摸屏边缘滑动和多指滑动信号，
+// 信号中带有触摸屏的设备节点，用于查找对应的显示器
+func (m *Manager) initTouchScreenEvents(systemConn *dbus.Conn) {
+	for _, member := range []string{"TouchEdgeEvent", "TouchMovementEvent"} {
+		err := dbusutil.NewMatchRuleBuilder().Type("signal").
+			Path(systemGesturePath).
+			Interface(systemGestureInterface).
+			Member(member).Build().AddTo(systemConn)
+		if err != nil {
+			logger.Warning(err)
+		}
+	}
+
+	m.systemSigLoop.AddHandler(&dbusutil.SignalRule{
+		Path: systemGesturePath,
+		Name: systemGestureInterface + ".TouchEdgeEvent",
+	}, func(sig *dbus.Signal) {
+		var direction, devNode string
+		var scaleX, scaleY float64
+		err := dbus.Store(sig.Body, &direction, &scaleX, &scaleY, &devNode)
+		if err != nil {
+			logger.Warning("invalid TouchEdgeEvent signal:", err)
+			return
+		}
+		should, err := m.shouldHandleEvent(deviceTouchScreen)
+		if err != nil {
+			logger.Error("shouldHandleEvent failed:", err)
+			return
+		}
+		if !should {
+			return
+		}
+		m.setLastTouchDevice(devNode)
+		m.broadcastGestureEvent(gestureEventTouchEdge, direction, 1, scaleX, scaleY)
+		context, pointFn, err := m.getTouchScreenRotationContext(devNode)
+		if err != nil {
+			logger.Error("getTouchScreenRotationContext failed:", err)
+		}
+		p := &point{X: scaleX, Y: scaleY}
+		pointFn(p)
+		err = m.handleTouchEdgeEvent(context, direction, p)
+		if err != nil {
+			logger.Error("handleTouchEdgeEvent failed:", err)
+		}
+	})
+
+	m.systemSigLoop.AddHandler(&dbusutil.SignalRule{
+		Path: systemGesturePath,
+		Name: systemGestureInterface + ".TouchMovementEvent",
+	}, func(sig *dbus.Signal) {
+		var direction, devNode string
+		var fingers int32
+		var startScaleX, startScaleY, endScaleX, endScaleY float64
+		err := dbus.Store(sig.Body, &direction, &fingers, &startScaleX, &startScaleY, &endScaleX, &endScaleY, &devNode)
+		if err != nil {
+			logger.Warning("invalid TouchMovementEvent signal:", err)
+			return
+		}
+		should, err := m.shouldHandleEvent(deviceTouchScreen)
+		if err != nil {
+			logger.Error("shouldHandleEvent failed:", err)
+			return
+		}
+		if !should {
+			return
+		}
+		m.setLastTouchDevice(devNode)
+		m.broadcastGestureEvent(gestureEventTouchMovement, direction, fingers, endScaleX, endScaleY)
+
+		context, pointFn, err := m.getTouchScreenRotationContext(devNode)
+		if err != nil {
+			logger.Error("getTouchScreenRotationContext failed:", err)
+		}
+
+		startP := &point{X: startScaleX, Y: startScaleY}
+		endP := &point{X: endScaleX, Y: endScaleY}
+		pointFn(startP)
+		pointFn(endP)
+
+		err = m.handleTouchMovementEvent(context, direction, fingers, startP, endP)
+		if err != nil {
+			logger.Error("handleTouchMovementEvent failed:", err)
+		}
+	})
+}
+
+func (m *Manager) setLastTouchDevice(devNode string) {
+	m.mu.Lock()
+	m.lastTouchDevice = devNode
+	m.mu.Unlock()
+}
+
+// getLastTouchDevice 优先使用最近一次触摸屏手势信号中的设备节点，没有时从 system/gesture1 查询
+func (m *Manager) getLastTouchDevice() string {
+	m.mu.RLock()
+	devNode := m.lastTouchDevice
+	m.mu.RUnlock()
+	if devNode != "" {
+		return devNode
+	}
+	return queryLastTouchDevice()
+}
+
+// queryLastTouchDevice 从 system/gesture1 查询最近一次按下的触摸屏的设备节点，查询失败时返回空字符串
+func queryLastTouchDevice() string {
+	systemConn, err := dbus.SystemBus()
+	if err != nil {
+		logger.Warning(err)
+		return ""
+	}
+	var devNode string
+	err = systemConn.Object(systemGestureService, systemGesturePath).
+		Call(systemGestureInterface+".GetLastTouchDevice", 0).Store(&devNode)
+	if err != nil {
+		logger.Warning("failed to get last touch device:", err)
+		return ""
+	}
+	return devNode
+}
+
+// findTouchScreenOutput 返回设备节点为 devNode 的触摸屏映射到的显示器名称，
+// 找不到该触摸屏时使用第一个触摸屏
+func findTouchScreenOutput(touchScreens []display.TouchscreenV2, touchMap map[string]string, devNode string) string {
+	if len(touchScreens) == 0 || len(touchMap) == 0 {
+		return ""
+	}
+	if devNode != "" {
+		for _, touchScreen := range touchScreens {
+			if touchScreen.Node == devNode || touchScreen.DeviceNode == devNode {
+				return touchMap[touchScreen.UUID]
+			}
+		}
+		logger.Debugf("touch screen %s is not found, use the first one", devNode)
+	}
+	return touchMap[touchScreens[0].UUID]
+}
+
+// 获取触摸屏的旋转，devNode 为产生手势的触摸屏的设备节点，为空时使用第一个触摸屏
+func (m *Manager) getTouchScreenRotation(devNode string) (display.Monitor, TouchScreensRotation) {
 	touchScreens, err := m.display.TouchscreensV2().Get(0)
 	if err != nil {
 		logger.Warning(err)
@@ -519,11 +1140,8 @@ func (m *Manager) getTouchScreenRotation() (display.Monitor, TouchScreensRotatio
 		logger.Warning(err)
 	}
 
-	// 读取触摸屏的名字
-	var touchScreen string
-	if len(touchScreens) > 0 && len(touchMap) > 0 {
-		touchScreen = touchMap[touchScreens[0].UUID]
-	}
+	// 读取触摸屏对应的显示器名字
+	touchScreen := findTouchScreenOutput(touchScreens, touchMap, devNode)
 
 	// 读取失败，把主屏当做触摸屏
 	if touchScreen == "" {
@@ -588,9 +1206,9 @@ type touchEventContext struct {
 	screenWidth, screenHeight uint16
 }
 
-// func getTouchScreenRotationContext return a context represents the current touchScreen's rotation, and a func to transform point
-func (m *Manager) getTouchScreenRotationContext() (context *touchEventContext, pointTransformFn func(*point), err error) {
-	monitor, rotation := m.getTouchScreenRotation()
+// func getTouchScreenRotationContext return a context represents the rotation of the touchScreen devNode, and a func to transform point
+func (m *Manager) getTouchScreenRotationContext(devNode string) (context *touchEventContext, pointTransformFn func(*point), err error) {
+	monitor, rotation := m.getTouchScreenRotation(devNode)
 
 	var screenWidth, screenHeight uint16
 	if monitor == nil { // 如果获取失败则当作用户只有一个显示屏, 直接使用 x 的画布大小当作触摸屏大小
@@ -656,6 +1274,10 @@ func (m *Manager) getTouchScreenRotationContext() (context *touchEventContext, p
 func (m *Manager) handleTouchEdgeMoveStopLeave(context *touchEventContext, edge string, p *point, duration int32) error {
 	logger.Debugf("handleTouchEdgeMoveStopLeave: context:%+v edge:%s p: %+v", *context, edge, *p)
 
+	if m.shouldSuppressGesture(EventInfo{Name: touchEdgeEventName, Direction: edge, Fingers: 1}) {
+		return nil
+	}
+
 	if edge == context.bot && m.oneFingerBottomEnable {
 		position, err := m.dock.Position().Get(0)
 		if err != nil {
@@ -677,7 +1299,8 @@ func (m *Manager) handleTouchEdgeMoveStopLeave(context *touchEventContext, edge
 				dockPly = rect.Width
 			}
 
-			if (1-p.Y)*float64(context.screenHeight) > float64(dockPly) {
+			if (1-p.Y)*float64(context.screenHeight) > float64(dockPly) &&
+				m.isActionAllowed(ActionInfo{Type: ActionTypeBuiltin, Action: "ShowWorkspace"}) {
 				logger.Debug("show work space")
 				return m.handleBuiltinAction("ShowWorkspace")
 			}
@@ -686,37 +1309,18 @@ func (m *Manager) handleTouchEdgeMoveStopLeave(context *touchEventContext, edge
 	return nil
 }
 
-func (m *Manager) showWidgets(show bool) error {
-	sessionBus, err := dbus.SessionBus()
-	if err != nil {
-		logger.Warning(err)
-		return err
-	}
-	obj := sessionBus.Object("org.deepin.dde.Widgets", "/org/deepin/dde/Widgets")
-	if show {
-		err = obj.Call("org.deepin.dde.Widgets.Show", 0).Err
-	} else {
-		err = obj.Call("org.deepin.dde.Widgets.Hide", 0).Err
-	}
-	if err != nil {
-		logger.Warning(err)
-	}
-	return err
-}
-
 // edge: 该手势来自屏幕的哪条边
 // p:    该手势的终点
 func (m *Manager) handleTouchEdgeEvent(context *touchEventContext, edge string, p *point) error {
 	logger.Debugf("handleTouchEdgeEvent: context:%+v edge:%s p:%+v", *context, edge, *p)
-	switch edge {
-	case context.left:
-		if p.X*float64(context.screenHeight) > 100 && m.oneFingerLeftEnable {
-			return m.clipboard.Show(0)
-		}
-	case context.right:
-		if (1-p.X)*float64(context.screenWidth) > 100 && m.oneFingerRightEnable {
-			return m.showWidgets(true)
-		}
+	edge = context.logicalEdge(edge)
+	cfg := m.getTouchEdgeConfig(edge)
+	if cfg == nil || cfg.Action == nil || !m.isTouchEdgeEnabled(edge) {
+		return nil
+	}
+	evInfo := EventInfo{Name: touchEdgeEventName, Direction: edge, Fingers: 1}
+	if touchEdgeDistance(context, edge, p) > float64(cfg.Distance) && !m.shouldSuppressGesture(evInfo) {
+		return m.execGestureAction(evInfo, *cfg.Action)
 	}
 	return nil
 }
@@ -738,15 +1342,15 @@ func (m *Manager) handleTouchMovementEvent(context *touchEventContext, direction
 			return nil
 		}
 
-		switch direction {
-		case context.left:
-			if m.oneFingerLeftEnable {
-				return m.clipboard.Hide(0)
-			}
-		case context.right:
-			if m.oneFingerRightEnable {
-				return m.showWidgets(false)
+		// 向边缘滑动时执行该边缘的 HideAction，如隐藏从该边缘滑出的剪贴板
+		edge := context.logicalEdge(direction)
+		cfg := m.getTouchEdgeConfig(edge)
+		if cfg != nil && cfg.HideAction != nil && m.isTouchEdgeEnabled(edge) {
+			evInfo := EventInfo{Name: touchMovementEventName, Direction: edge, Fingers: fingers}
+			if m.shouldSuppressGesture(evInfo) {
+				return nil
 			}
+			return m.execGestureAction(evInfo, *cfg.HideAction)
 		}
 	}
 
@@ -755,26 +1359,26 @@ func (m *Manager) handleTouchMovementEvent(context *touchEventContext, direction
 
 // touchpad double click down
 func (m *Manager) handleDbclickDown(fingers int32) error {
-	if fingers == 3 {
-		return m.wm.TouchToMove(0, 0, 0)
+	if fingers == threeFingerDragFingers {
+		_, err := m.beginThreeFingerDrag()
+		return err
 	}
 	return nil
 }
 
 // touchpad swipe move
 func (m *Manager) handleSwipeMoving(fingers int32, accelX float64, accelY float64) error {
-	if fingers == 3 {
-		return m.wm.TouchToMove(0, int32(accelX), int32(accelY))
+	if fingers == threeFingerDragFingers {
+		return m.moveThreeFingerDrag(accelX, accelY)
 	}
 	return nil
 }
 
 // touchpad swipe stop or interrupted
 func (m *Manager) handleSwipeStop(fingers int32) error {
-	if fingers == 3 {
-		return m.wm.ClearMoveStatus(0)
-	}
-	return nil
+	// 手指数变化时也需要结束拖动
+	_, err := m.endThreeFingerDrag()
+	return err
 }
 
 // 多用户存在，防止非当前用户响应触摸屏手势
@@ -803,7 +1407,8 @@ func (m *Manager) shouldHandleEvent(devType deviceType) (bool, error) {
 		return false, fmt.Errorf("get login1 session locked failed: %v", err)
 	}
 
-	if isLocked {
+	// limited 策略下由 isActionAllowed 过滤锁屏时执行的动作
+	if isLocked && m.getLockedGesturePolicy() == lockedPolicyDisabled {
 		return false, nil
 	}
 
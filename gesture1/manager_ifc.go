@@ -5,54 +5,588 @@
 package gesture1
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
 	"github.com/godbus/dbus/v5"
 	"github.com/linuxdeepin/go-lib/dbusutil"
 )
 
 func (m *Manager) SetLongPressDuration(duration uint32) *dbus.Error {
-	if m.tsSetting.GetInt(tsSchemaKeyLongPress) == int32(duration) {
-		return nil
+	err := m.setLongPressDuration(duration)
+	return dbusutil.ToError(err)
+}
+
+func (m *Manager) GetLongPressDuration() (duration uint32, busErr *dbus.Error) {
+	return uint32(m.tsSetting.GetInt(tsSchemaKeyLongPress)), nil
+}
+
+func (m *Manager) SetShortPressDuration(duration uint32) *dbus.Error {
+	err := m.setShortPressDuration(duration)
+	return dbusutil.ToError(err)
+}
+
+func (m *Manager) GetShortPressDuration() (duration uint32, busErr *dbus.Error) {
+	return uint32(m.tsSetting.GetInt(tsSchemaKeyShortPress)), nil
+}
+
+func (m *Manager) SetEdgeMoveStopDuration(duration uint32) *dbus.Error {
+	err := m.setEdgeMoveStopDuration(duration)
+	return dbusutil.ToError(err)
+}
+
+func (m *Manager) GetEdgeMoveStopDuration() (duration uint32, busErr *dbus.Error) {
+	return uint32(m.tsSetting.GetInt(tsSchemaKeyEdgeMoveStop)), nil
+}
+
+// GetWindowBlacklist return the windows in which touchscreen long press does
+// not simulate the right button. A pattern starting with "class:" matches the
+// window class, others match the process command line.
+func (m *Manager) GetWindowBlacklist() (patterns []string, busErr *dbus.Error) {
+	return m.tsSetting.GetStrv(tsSchemaKeyBlacklist), nil
+}
+
+// SetWindowBlacklist replace the window blacklist, see GetWindowBlacklist.
+func (m *Manager) SetWindowBlacklist(patterns []string) *dbus.Error {
+	for _, pattern := range patterns {
+		err := checkWindowBlacklistPattern(pattern)
+		if err != nil {
+			return dbusutil.ToError(err)
+		}
+	}
+	if !m.tsSetting.SetStrv(tsSchemaKeyBlacklist, patterns) {
+		return dbusutil.ToError(fmt.Errorf("failed to set gsettings key %s", tsSchemaKeyBlacklist))
+	}
+	logger.Info("set window blacklist:", patterns)
+	return nil
+}
+
+// AddGesture bind the action to the touchpad gesture which has no action yet,
+// the change is saved to the user config file and takes effect immediately.
+// For the "dbus" action type, action is the method call marshaled by json.
+// For the "chain" action type, action is a json array of steps executed in
+// order, each step has the fields of an action, an optional Delay in
+// milliseconds before the step and an optional OnFailure ("stop" or "continue").
+// The returned warnings are the conflicts with the shortcuts, see ValidateGesture.
+func (m *Manager) AddGesture(name, direction string, fingers int32, actionType, action string) (warningsJSON string, busErr *dbus.Error) {
+	evInfo := EventInfo{Name: name, Direction: direction, Fingers: fingers}
+	actionInfo, err := newActionInfo(actionType, action)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	err = m.checkGesture(evInfo, actionInfo)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	err = m.updateInfos(func(infos gestureInfos) (gestureInfos, error) {
+		return infos.Add(evInfo, actionInfo)
+	})
+	if err != nil {
+		logger.Warning("failed to add gesture:", err)
+		return "", dbusutil.ToError(err)
+	}
+	logger.Infof("add gesture %s: %s", evInfo.toString(), actionInfo.toString())
+	warningsJSON, err = marshalWarnings(m.findConflicts(gestureInfos{{Event: evInfo, Action: actionInfo}}, nil))
+	return warningsJSON, dbusutil.ToError(err)
+}
+
+// UpdateGesture change the action of the touchpad gesture.
+// The returned warnings are the conflicts with the shortcuts, see ValidateGesture.
+func (m *Manager) UpdateGesture(name, direction string, fingers int32, actionType, action string) (warningsJSON string, busErr *dbus.Error) {
+	evInfo := EventInfo{Name: name, Direction: direction, Fingers: fingers}
+	actionInfo, err := newActionInfo(actionType, action)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	err = m.checkGesture(evInfo, actionInfo)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	err = m.updateInfos(func(infos gestureInfos) (gestureInfos, error) {
+		return infos.Update(evInfo, actionInfo)
+	})
+	if err != nil {
+		logger.Warning("failed to update gesture:", err)
+		return "", dbusutil.ToError(err)
+	}
+	logger.Infof("update gesture %s: %s", evInfo.toString(), actionInfo.toString())
+	warningsJSON, err = marshalWarnings(m.findConflicts(gestureInfos{{Event: evInfo, Action: actionInfo}}, nil))
+	return warningsJSON, dbusutil.ToError(err)
+}
+
+// ValidateGesture check the gesture without saving it. Invalid gestures and
+// actions are returned as error, conflicts are returned as a json array of
+// warnings, each has Type, Gesture and Message. Type is "duplicate-gesture"
+// if the gesture already has an action, "wm-native-gesture" if the window
+// manager handles the gesture, or "shortcut" if the keys of the shortcut
+// action are used by a shortcut of the keybinding module.
+func (m *Manager) ValidateGesture(name, direction string, fingers int32, actionType, action string) (warningsJSON string, busErr *dbus.Error) {
+	evInfo := EventInfo{Name: name, Direction: direction, Fingers: fingers}
+	actionInfo, err := newActionInfo(actionType, action)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	err = checkEventInfo(evInfo)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	err = checkActionInfo(actionInfo, m.getBuiltinSets())
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	m.mu.RLock()
+	existing := m.Infos
+	m.mu.RUnlock()
+	warningsJSON, err = marshalWarnings(m.findConflicts(gestureInfos{{Event: evInfo, Action: actionInfo}}, existing))
+	return warningsJSON, dbusutil.ToError(err)
+}
+
+// DeleteGesture remove the action of the touchpad gesture.
+func (m *Manager) DeleteGesture(name, direction string, fingers int32) *dbus.Error {
+	evInfo := EventInfo{Name: name, Direction: direction, Fingers: fingers}
+	err := checkEventInfo(evInfo)
+	if err != nil {
+		return dbusutil.ToError(err)
 	}
-	err := m.sysDaemon.SetLongPressDuration(0, duration)
+	err = m.updateInfos(func(infos gestureInfos) (gestureInfos, error) {
+		return infos.Delete(evInfo)
+	})
 	if err != nil {
+		logger.Warning("failed to delete gesture:", err)
 		return dbusutil.ToError(err)
 	}
-	m.tsSetting.SetInt(tsSchemaKeyLongPress, int32(duration))
+	logger.Info("delete gesture", evInfo.toString())
 	return nil
 }
 
-func (m *Manager) GetLongPressDuration() (duration uint32, busErr *dbus.Error) {
-	return uint32(m.tsSetting.GetInt(tsSchemaKeyLongPress)), nil
+// EnableGesture enable or disable the touchpad gesture without removing its
+// action, a disabled gesture does nothing until it is enabled again.
+func (m *Manager) EnableGesture(name, direction string, fingers int32, enabled bool) *dbus.Error {
+	evInfo := EventInfo{Name: name, Direction: direction, Fingers: fingers}
+	err := checkEventInfo(evInfo)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	err = m.updateInfos(func(infos gestureInfos) (gestureInfos, error) {
+		return infos.SetEnabled(evInfo, enabled)
+	})
+	if err != nil {
+		logger.Warning("failed to enable gesture:", err)
+		return dbusutil.ToError(err)
+	}
+	logger.Infof("set gesture %s enabled: %v", evInfo.toString(), enabled)
+	return nil
 }
 
-func (m *Manager) SetShortPressDuration(duration uint32) *dbus.Error {
-	if m.tsSetting.GetInt(tsSchemaKeyShortPress) == int32(duration) {
-		return nil
+// ListGestures return the touchpad gestures of the current mode and their
+// actions marshaled by json, disabled gestures have "Enabled": false.
+func (m *Manager) ListGestures() (gesturesJSON string, busErr *dbus.Error) {
+	m.mu.RLock()
+	data, err := json.Marshal(m.Infos.editable())
+	m.mu.RUnlock()
+	if err != nil {
+		return "", dbusutil.ToError(err)
 	}
-	err := m.gesture.SetShortPressDuration(0, duration)
+	return string(data), nil
+}
+
+// SetTouchEdgeAction set the action executed when one finger swipes in from
+// the touchscreen edge (top, bot, left or right), an empty actionType
+// disables the edge.
+func (m *Manager) SetTouchEdgeAction(edge, actionType, action string) *dbus.Error {
+	actionInfo, err := newTouchEdgeAction(actionType, action, m.getBuiltinSets())
 	if err != nil {
 		return dbusutil.ToError(err)
 	}
-	m.tsSetting.SetInt(tsSchemaKeyShortPress, int32(duration))
+	err = m.updateTouchEdges(func(edges touchEdgeConfigs) (touchEdgeConfigs, error) {
+		return edges.set(edge, func(cfg *touchEdgeConfig) {
+			cfg.Action = actionInfo
+		})
+	})
+	if err != nil {
+		logger.Warning("failed to set touch edge action:", err)
+		return dbusutil.ToError(err)
+	}
+	logger.Infof("set action of touch edge %s: %+v", edge, actionInfo)
 	return nil
 }
 
-func (m *Manager) GetShortPressDuration() (duration uint32, busErr *dbus.Error) {
-	return uint32(m.tsSetting.GetInt(tsSchemaKeyShortPress)), nil
+// SetTouchEdgeHideAction set the action executed when one finger swipes
+// towards the touchscreen edge, an empty actionType disables it.
+func (m *Manager) SetTouchEdgeHideAction(edge, actionType, action string) *dbus.Error {
+	actionInfo, err := newTouchEdgeAction(actionType, action, m.getBuiltinSets())
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	err = m.updateTouchEdges(func(edges touchEdgeConfigs) (touchEdgeConfigs, error) {
+		return edges.set(edge, func(cfg *touchEdgeConfig) {
+			cfg.HideAction = actionInfo
+		})
+	})
+	if err != nil {
+		logger.Warning("failed to set touch edge hide action:", err)
+		return dbusutil.ToError(err)
+	}
+	logger.Infof("set hide action of touch edge %s: %+v", edge, actionInfo)
+	return nil
 }
 
-func (m *Manager) SetEdgeMoveStopDuration(duration uint32) *dbus.Error {
-	if m.tsSetting.GetInt(tsSchemaKeyShortPress) == int32(duration) {
-		return nil
+// SetTouchEdgeDoubleTapAction set the action executed when one finger
+// double taps the top or bottom touchscreen edge, an empty actionType
+// disables it.
+func (m *Manager) SetTouchEdgeDoubleTapAction(edge, actionType, action string) *dbus.Error {
+	actionInfo, err := newTouchEdgeDoubleTapAction(edge, actionType, action, m.getBuiltinSets())
+	if err != nil {
+		return dbusutil.ToError(err)
 	}
-	err := m.gesture.SetEdgeMoveStopDuration(0, duration)
+	err = m.updateTouchEdges(func(edges touchEdgeConfigs) (touchEdgeConfigs, error) {
+		return edges.set(edge, func(cfg *touchEdgeConfig) {
+			cfg.DoubleTapAction = actionInfo
+		})
+	})
 	if err != nil {
+		logger.Warning("failed to set touch edge double tap action:", err)
 		return dbusutil.ToError(err)
 	}
-	m.tsSetting.SetInt(tsSchemaKeyEdgeMoveStop, int32(duration))
+	logger.Infof("set double tap action of touch edge %s: %+v", edge, actionInfo)
 	return nil
 }
 
-func (m *Manager) GetEdgeMoveStopDuration() (duration uint32, busErr *dbus.Error) {
-	return uint32(m.tsSetting.GetInt(tsSchemaKeyEdgeMoveStop)), nil
+// SetTouchEdgeDistance set how far in pixels the finger must swipe in from
+// the touchscreen edge to trigger its action.
+func (m *Manager) SetTouchEdgeDistance(edge string, distance uint32) *dbus.Error {
+	if distance > maxTouchEdgeDistance {
+		return dbusutil.ToError(fmt.Errorf("invalid touch edge distance %d", distance))
+	}
+	err := m.updateTouchEdges(func(edges touchEdgeConfigs) (touchEdgeConfigs, error) {
+		return edges.set(edge, func(cfg *touchEdgeConfig) {
+			cfg.Distance = distance
+		})
+	})
+	if err != nil {
+		logger.Warning("failed to set touch edge distance:", err)
+		return dbusutil.ToError(err)
+	}
+	logger.Infof("set distance of touch edge %s: %d", edge, distance)
+	return nil
+}
+
+// GetTouchEdges return the touchscreen edge configs marshaled by json.
+func (m *Manager) GetTouchEdges() (edgesJSON string, busErr *dbus.Error) {
+	m.mu.RLock()
+	data, err := json.Marshal(m.touchEdges)
+	m.mu.RUnlock()
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// ListGestureDevices return the gesture capable device types (touchpad and
+// touchscreen), whether their gestures are enabled and the connected
+// devices, marshaled by json.
+func (m *Manager) ListGestureDevices() (devicesJSON string, busErr *dbus.Error) {
+	data, err := json.Marshal(m.listGestureDevices())
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// SetGestureDeviceEnabled enable or disable the gestures of all devices of
+// the type, the change is saved to gsettings.
+func (m *Manager) SetGestureDeviceEnabled(deviceType string, enabled bool) *dbus.Error {
+	err := m.setGestureDeviceEnabled(deviceType, enabled)
+	if err != nil {
+		logger.Warning("failed to set gesture device enabled:", err)
+		return dbusutil.ToError(err)
+	}
+	logger.Infof("set gestures of %s enabled: %v", deviceType, enabled)
+	return nil
+}
+
+// GetGestureConfig return the whole editable gesture config marshaled by json,
+// including the config version, gestures, touchscreen edges, cooldowns and
+// the tablet mode map, see GetGestureMap.
+func (m *Manager) GetGestureConfig() (configJSON string, busErr *dbus.Error) {
+	data, err := json.Marshal(m.getGestureConfig())
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// SetGestureConfig replace the whole gesture config with the json document
+// returned by GetGestureConfig. The document is validated before it is
+// applied, the error tells the line and column or the field that is invalid.
+// The returned warnings are the conflicts with the shortcuts, see ValidateGesture.
+// A gesture may require held modifiers with the optional Modifiers field of
+// its Event, such as "ctrl+shift", in the order ctrl, alt, shift and super.
+// A touchscreen tap may require the corner it happens in with the optional
+// Corner field of its Event: "top-left", "top-right", "bottom-left" or
+// "bottom-right". Touchpad taps have no position and never match a corner.
+func (m *Manager) SetGestureConfig(configJSON string) (warningsJSON string, busErr *dbus.Error) {
+	cfg, err := parseGestureConfig([]byte(configJSON))
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	m.mu.RLock()
+	wmNativeGestures := m.wmNativeGestures
+	m.mu.RUnlock()
+	err = cfg.check(m.getBuiltinSets(), wmNativeGestures)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	err = m.setGestureConfig(cfg)
+	if err != nil {
+		logger.Warning("failed to set gesture config:", err)
+		return "", dbusutil.ToError(err)
+	}
+	logger.Info("gesture config is replaced")
+	m.applyGestureThresholds()
+	m.applyLongPressEnable()
+	warningsJSON, err = marshalWarnings(m.findConflicts(cfg.Infos, nil))
+	return warningsJSON, dbusutil.ToError(err)
+}
+
+// GetGestureMap return the gestures and touchscreen edges used in the mode
+// ("laptop" or "tablet") marshaled by json. The Mode property tells the
+// mode in use, the tablet map is used while a convertible is in tablet mode.
+func (m *Manager) GetGestureMap(mode string) (mapJSON string, busErr *dbus.Error) {
+	gm, err := m.getGestureMap(mode)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	data, err := json.Marshal(gm)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// SetGestureMap replace the gestures and touchscreen edges of the mode with
+// the json document returned by GetGestureMap, the map of the other mode is
+// not changed. The returned warnings are the conflicts with the shortcuts,
+// see ValidateGesture.
+func (m *Manager) SetGestureMap(mode, mapJSON string) (warningsJSON string, busErr *dbus.Error) {
+	gm, err := parseGestureMap([]byte(mapJSON))
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	m.mu.RLock()
+	wmNativeGestures := m.wmNativeGestures
+	m.mu.RUnlock()
+	err = checkGestureMap(gm.Infos, gm.TouchEdges, m.getBuiltinSets(), wmNativeGestures)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	err = m.setGestureMap(mode, gm)
+	if err != nil {
+		logger.Warning("failed to set gesture map:", err)
+		return "", dbusutil.ToError(err)
+	}
+	logger.Infof("gesture map of %s mode is replaced", mode)
+	warningsJSON, err = marshalWarnings(m.findConflicts(gm.Infos, nil))
+	return warningsJSON, dbusutil.ToError(err)
+}
+
+// ResetToDefaults remove the user gesture config and reload the system config.
+func (m *Manager) ResetToDefaults() *dbus.Error {
+	err := m.resetToDefaults()
+	if err != nil {
+		logger.Warning("failed to reset gesture config:", err)
+		return dbusutil.ToError(err)
+	}
+	logger.Info("gesture config is reset to defaults")
+	m.applyGestureThresholds()
+	m.applyLongPressEnable()
+	return nil
+}
+
+// GetLongPressAction return what a touchscreen long press does: mode is
+// "right-click" or "custom", actionType and action are the
+// custom action, see SetLongPressAction.
+func (m *Manager) GetLongPressAction() (mode, actionType, action string, busErr *dbus.Error) {
+	cfg := m.getLongPress()
+	if cfg.Action == nil {
+		return cfg.Mode, "", "", nil
+	}
+	// 与 newActionInfo 的参数格式一致
+	var value interface{}
+	switch cfg.Action.Type {
+	case ActionTypeDBus:
+		value = cfg.Action.DBus
+	case ActionTypeChain:
+		value = cfg.Action.Chain
+	default:
+		return cfg.Mode, cfg.Action.Type, cfg.Action.Action, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", "", "", dbusutil.ToError(err)
+	}
+	return cfg.Mode, cfg.Action.Type, string(data), nil
+}
+
+// SetLongPressAction set what a touchscreen long press does. "right-click"
+// emulates the right mouse button and "custom" executes the action when the
+// long press starts, actionType and action are the same as AddGesture and
+// are ignored by "right-click". The long press duration is set by
+// SetLongPressDuration.
+func (m *Manager) SetLongPressAction(mode, actionType, action string) *dbus.Error {
+	cfg := longPressConfig{Mode: mode}
+	if mode == longPressCustom {
+		actionInfo, err := newActionInfo(actionType, action)
+		if err != nil {
+			return dbusutil.ToError(err)
+		}
+		cfg.Action = &actionInfo
+	}
+	err := cfg.check(m.getBuiltinSets())
+	if err == nil {
+		err = m.setLongPress(cfg)
+	}
+	if err != nil {
+		logger.Warning("failed to set long press action:", err)
+		return dbusutil.ToError(err)
+	}
+	logger.Infof("set long press action: %s %+v", mode, cfg.Action)
+	return nil
+}
+
+// GetGestureSettings return the parameters used to recognize gestures
+// marshaled by json, grouped by gesture type: Swipe.Distance, Pinch.Scale,
+// Rotate.Angle in degrees, and TouchScreen.LongPressDuration,
+// ShortPressDuration, EdgeMoveStopDuration and DoubleTapInterval in
+// milliseconds, TouchScreen.MovementDistance in millimeters and
+// TouchScreen.EdgeTapSize and CornerSize in pixels.
+func (m *Manager) GetGestureSettings() (settingsJSON string, busErr *dbus.Error) {
+	data, err := json.Marshal(m.getGestureSettings())
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// SetGestureSettings replace the parameters used to recognize gestures, see
+// GetGestureSettings for the format. All fields except TouchScreen.EdgeTapSize,
+// DoubleTapInterval and CornerSize are required, the changes take effect immediately.
+func (m *Manager) SetGestureSettings(settingsJSON string) *dbus.Error {
+	settings, err := parseGestureSettings([]byte(settingsJSON))
+	if err == nil {
+		err = settings.check()
+	}
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	err = m.setGestureSettings(settings)
+	if err != nil {
+		logger.Warning("failed to set gesture settings:", err)
+		return dbusutil.ToError(err)
+	}
+	logger.Info("gesture settings are changed:", settingsJSON)
+	return nil
+}
+
+// ListBuiltinActions return the names of the actions can be used by the
+// "built-in" action type, including the actions registered by other modules,
+// sorted by name.
+func (m *Manager) ListBuiltinActions() (names []string, busErr *dbus.Error) {
+	builtinSets := m.getBuiltinSets()
+	names = make([]string, 0, len(builtinSets))
+	for name := range builtinSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// SubscribeGestureEvent subscribe the GestureEvent signal, which is sent to
+// the subscribers only, with name, direction and fingers of each gesture and
+// the touchscreen coordinates in [0, 1], or -1 for touchpad gestures. Only
+// the programs in the dconfig list eventListenerAllowlist can subscribe, the
+// subscription ends when the caller exits. No event is sent while the
+// session is locked.
+func (m *Manager) SubscribeGestureEvent(sender dbus.Sender) *dbus.Error {
+	err := m.addEventListener(string(sender))
+	if err != nil {
+		logger.Warning("failed to subscribe gesture events:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+// UnsubscribeGestureEvent stop sending the GestureEvent signal to the caller.
+func (m *Manager) UnsubscribeGestureEvent(sender dbus.Sender) *dbus.Error {
+	m.removeEventListener(string(sender))
+	return nil
+}
+
+// SetSuppressionOverride override the automatic gesture suppression during
+// fullscreen games, mode is one of "auto", "on" and "off". "auto" suppresses
+// edge and multi-finger gestures while the active window is fullscreen and in
+// the dconfig list gameModeWindows, "on" always suppresses them and "off"
+// never does. The override is reset to "auto" when the session restarts.
+func (m *Manager) SetSuppressionOverride(mode string) *dbus.Error {
+	err := m.setSuppressionOverride(mode)
+	if err != nil {
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+// SetCommandExecMode set how the "commandline" actions are executed.
+// "allowlist" splits the command into arguments without a shell and only
+// runs the programs in the commandAllowlist dconfig key, "shell" runs any
+// command with /bin/sh and requires the polkit authentication. The mode lasts
+// until the daemon restarts, the default is the read-only commandExecMode
+// dconfig key that only the administrator can change.
+func (m *Manager) SetCommandExecMode(sender dbus.Sender, mode string) *dbus.Error {
+	err := m.setCommandExecModeBySender(sender, mode)
+	if err != nil {
+		logger.Warning("failed to set command exec mode:", err)
+		return dbusutil.ToError(err)
+	}
+	logger.Info("set command exec mode:", mode)
+	return nil
+}
+
+func (m *Manager) checkGesture(evInfo EventInfo, action ActionInfo) error {
+	err := checkEventInfo(evInfo)
+	if err != nil {
+		return err
+	}
+	if m.isWmNativeGesture(evInfo) {
+		return fmt.Errorf("gesture %s conflicts with window manager", evInfo.toString())
+	}
+	err = checkActionInfo(action, m.getBuiltinSets())
+	if err != nil {
+		return err
+	}
+	return m.checkCommandAction(action)
+}
+
+// GetGestureStats return how many times each gesture action is executed and
+// how many of them failed, marshaled by json as a map from the gesture key
+// such as "swipe-up-3" to {"Triggered": n, "Failed": n}. Gestures are only
+// counted when the gestureStatsEnabled dconfig key is true.
+func (m *Manager) GetGestureStats() (statsJSON string, busErr *dbus.Error) {
+	data, err := json.Marshal(m.getGestureStats())
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return string(data), nil
+}
+
+// ResetGestureStats clear the gesture usage statistics.
+func (m *Manager) ResetGestureStats() *dbus.Error {
+	err := m.resetGestureStats()
+	if err != nil {
+		logger.Warning("failed to reset gesture stats:", err)
+		return dbusutil.ToError(err)
+	}
+	logger.Info("gesture stats are reset")
+	return nil
 }
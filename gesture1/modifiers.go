@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// 手势可以要求按住的修饰键，EventInfo.Modifiers 中按该顺序排列
+var gestureModifiers = []string{"ctrl", "alt", "shift", "super"}
+
+// 修饰键的其他名称
+var modifierAliases = map[string]string{
+	"control": "ctrl",
+	"meta":    "super",
+	"win":     "super",
+}
+
+const modifierSeparator = "+"
+
+// normalizeModifiers 转换为小写的修饰键名称，按 gestureModifiers 的顺序用 + 连接并去掉重复的修饰键，
+// 如 "Shift+Control" 转换为 "ctrl+shift"
+func normalizeModifiers(modifiers string) (string, error) {
+	if modifiers == "" {
+		return "", nil
+	}
+	held := make(map[string]bool)
+	for _, name := range strings.Split(modifiers, modifierSeparator) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if alias, ok := modifierAliases[name]; ok {
+			name = alias
+		}
+		valid := false
+		for _, modifier := range gestureModifiers {
+			if name == modifier {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return "", fmt.Errorf("invalid modifier %q in %q", name, modifiers)
+		}
+		held[name] = true
+	}
+	var result []string
+	for _, modifier := range gestureModifiers {
+		if held[modifier] {
+			result = append(result, modifier)
+		}
+	}
+	return strings.Join(result, modifierSeparator), nil
+}
+
+// checkModifiers 配置中的修饰键必须是 normalizeModifiers 的格式
+func checkModifiers(modifiers string) error {
+	normalized, err := normalizeModifiers(modifiers)
+	if err != nil {
+		return err
+	}
+	if normalized != modifiers {
+		return fmt.Errorf("invalid modifiers %q, expect %q", modifiers, normalized)
+	}
+	return nil
+}
+
+// hasModifiers 手势的某个配置要求按住修饰键时返回 true
+func (infos gestureInfos) hasModifiers(evInfo EventInfo) bool {
+	evInfo = evInfo.withoutModifiers()
+	for _, info := range infos {
+		if info.Event.Modifiers != "" && info.Event.withoutModifiers() == evInfo {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGestureInfo 没有与按住的修饰键对应的配置时，使用不要求修饰键的配置
+func matchGestureInfo(infos gestureInfos, evInfo EventInfo) *gestureInfo {
+	info := infos.Get(evInfo)
+	if info == nil && evInfo.Modifiers != "" {
+		info = infos.Get(evInfo.withoutModifiers())
+	}
+	return info
+}
+
+// lookupGesture 只在手势有要求修饰键的配置时查询按住的修饰键
+func (m *Manager) lookupGesture(evInfo EventInfo) *gestureInfo {
+	m.mu.RLock()
+	hasModifiers := m.Infos.hasModifiers(evInfo)
+	m.mu.RUnlock()
+	if hasModifiers {
+		modifiers, err := queryHeldModifiers()
+		if err != nil {
+			logger.Warning("failed to query held modifiers:", err)
+		}
+		evInfo.Modifiers = modifiers
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return matchGestureInfo(m.Infos, evInfo)
+}
+
+// queryHeldModifiers 从 system/gesture1 查询当前按住的修饰键，X11 和 Wayland 下都可以使用
+func queryHeldModifiers() (string, error) {
+	systemConn, err := dbus.SystemBus()
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	err = systemConn.Object(systemGestureService, systemGesturePath).
+		Call(systemGestureInterface+".GetHeldModifiers", 0).Store(&names)
+	if err != nil {
+		return "", err
+	}
+	return normalizeModifiers(strings.Join(names, modifierSeparator))
+}
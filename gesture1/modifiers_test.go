@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：修饰键的名称和顺序
+func Test_normalizeModifiers(t *testing.T) {
+	modifiers, err := normalizeModifiers("Shift+Control")
+	assert.NoError(t, err)
+	assert.Equal(t, "ctrl+shift", modifiers)
+
+	modifiers, err = normalizeModifiers("super + alt + meta")
+	assert.NoError(t, err)
+	assert.Equal(t, "alt+super", modifiers)
+
+	modifiers, err = normalizeModifiers("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", modifiers)
+
+	_, err = normalizeModifiers("ctrl+a")
+	assert.Error(t, err)
+	_, err = normalizeModifiers("ctrl+")
+	assert.Error(t, err)
+}
+
+// 测试：配置中的修饰键必须是规范的格式
+func Test_checkEventInfoModifiers(t *testing.T) {
+	evInfo := EventInfo{Name: "swipe", Direction: "up", Fingers: 3, Modifiers: "ctrl"}
+	assert.NoError(t, checkEventInfo(evInfo))
+	evInfo.Modifiers = "shift+ctrl"
+	assert.Error(t, checkEventInfo(evInfo))
+	evInfo.Modifiers = "hyper"
+	assert.Error(t, checkEventInfo(evInfo))
+}
+
+// 测试：按住修饰键时手势配置的查找
+func Test_matchGestureInfo(t *testing.T) {
+	plain := &gestureInfo{
+		Event:  EventInfo{Name: "swipe", Direction: "up", Fingers: 3},
+		Action: ActionInfo{Type: ActionTypeBuiltin, Action: "ToggleMaximize"},
+	}
+	withCtrl := &gestureInfo{
+		Event:  EventInfo{Name: "swipe", Direction: "up", Fingers: 3, Modifiers: "ctrl"},
+		Action: ActionInfo{Type: ActionTypeBuiltin, Action: "ShowAllWindow"},
+	}
+	infos := gestureInfos{plain, withCtrl}
+
+	assert.True(t, infos.hasModifiers(EventInfo{Name: "swipe", Direction: "up", Fingers: 3}))
+	assert.False(t, infos.hasModifiers(EventInfo{Name: "swipe", Direction: "down", Fingers: 3}))
+
+	assert.Equal(t, plain, matchGestureInfo(infos, EventInfo{Name: "swipe", Direction: "up", Fingers: 3}))
+	assert.Equal(t, withCtrl, matchGestureInfo(infos, EventInfo{Name: "swipe", Direction: "up", Fingers: 3, Modifiers: "ctrl"}))
+	assert.Equal(t, plain, matchGestureInfo(infos, EventInfo{Name: "swipe", Direction: "up", Fingers: 3, Modifiers: "shift"}))
+	assert.Nil(t, matchGestureInfo(gestureInfos{withCtrl}, EventInfo{Name: "swipe", Direction: "up", Fingers: 3}))
+}
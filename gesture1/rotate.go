@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	display "github.com/linuxdeepin/go-dbus-factory/session/org.deepin.dde.display1"
+)
+
+// 双指旋转超过该角度时 system/gesture1 发出 rotate 事件而不是 pinch 事件，单位度
+const (
+	dconfigKeyRotateAngleThreshold = "rotateAngleThreshold"
+	defaultRotateAngleThreshold    = 45.0
+	maxRotateAngleThreshold        = 180.0
+)
+
+const (
+	systemGestureService   = "org.deepin.dde.Gesture1"
+	systemGesturePath      = "/org/deepin/dde/Gesture1"
+	systemGestureInterface = "org.deepin.dde.Gesture1"
+)
+
+func isValidRotateAngleThreshold(angle float64) bool {
+	return angle > 0 && angle <= maxRotateAngleThreshold
+}
+
+// getRotateAngleThreshold 无效的值使用默认值
+func (m *Manager) getRotateAngleThreshold() float64 {
+	angle, ok := m.getGestureConfigDouble(dconfigKeyRotateAngleThreshold)
+	if !ok {
+		return defaultRotateAngleThreshold
+	}
+	if !isValidRotateAngleThreshold(angle) {
+		logger.Warningf("invalid rotate angle threshold %v, use %v", angle, defaultRotateAngleThreshold)
+		return defaultRotateAngleThreshold
+	}
+	return angle
+}
+
+// applyRotateAngleThreshold 将 dconfig 中的角度阈值设置到 system/gesture1
+func (m *Manager) applyRotateAngleThreshold() {
+	angle := m.getRotateAngleThreshold()
+	logger.Info("DConfig of rotateAngleThreshold : ", angle)
+
+	systemConn, err := dbus.SystemBus()
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	err = systemConn.Object(systemGestureService, systemGesturePath).
+		Call(systemGestureInterface+".SetRotateAngleThreshold", 0, angle).Err
+	if err != nil {
+		logger.Warning("call SetRotateAngleThreshold failed:", err)
+	}
+}
+
+// nextRotation 返回显示器顺时针或逆时针旋转 90 度后的旋转值，TouchScreensRotation 按逆时针方向递增
+func nextRotation(rotation TouchScreensRotation, clockwise bool) TouchScreensRotation {
+	if clockwise {
+		if rotation == Normal {
+			return Rotation_270
+		}
+		return rotation >> 1
+	}
+	if rotation == Rotation_270 {
+		return Normal
+	}
+	return rotation << 1
+}
+
+// rotateScreen 旋转最近使用的触摸屏所在的显示器，用于没有自动旋转的二合一设备
+func (m *Manager) rotateScreen(clockwise bool) error {
+	monitor, rotation := m.getTouchScreenRotation(m.getLastTouchDevice())
+	if monitor == nil {
+		return fmt.Errorf("failed to find the touch screen monitor")
+	}
+	return m.setMonitorRotation(monitor, nextRotation(rotation, clockwise))
+}
+
+func (m *Manager) setMonitorRotation(monitor display.Monitor, rotation TouchScreensRotation) error {
+	rotations, err := monitor.Rotations().Get(0)
+	if err != nil {
+		return err
+	}
+	supported := false
+	for _, r := range rotations {
+		if TouchScreensRotation(r) == rotation {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("rotation %d is not supported by monitor", rotation)
+	}
+
+	err = monitor.SetRotation(0, uint16(rotation))
+	if err != nil {
+		return err
+	}
+	err = m.display.ApplyChanges(0)
+	if err != nil {
+		return err
+	}
+	return m.display.Save(0)
+}
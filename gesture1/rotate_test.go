@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	display "github.com/linuxdeepin/go-dbus-factory/session/org.deepin.dde.display1"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：显示器旋转 90 度后的旋转值
+func Test_nextRotation(t *testing.T) {
+	assert.Equal(t, Rotation_270, nextRotation(Normal, true))
+	assert.Equal(t, Rotation_180, nextRotation(Rotation_270, true))
+	assert.Equal(t, Rotation_90, nextRotation(Rotation_180, true))
+	assert.Equal(t, Normal, nextRotation(Rotation_90, true))
+
+	assert.Equal(t, Rotation_90, nextRotation(Normal, false))
+	assert.Equal(t, Rotation_180, nextRotation(Rotation_90, false))
+	assert.Equal(t, Rotation_270, nextRotation(Rotation_180, false))
+	assert.Equal(t, Normal, nextRotation(Rotation_270, false))
+}
+
+// 测试：旋转手势的方向和手指数
+func Test_checkEventInfoRotate(t *testing.T) {
+	assert.NoError(t, checkEventInfo(EventInfo{Name: "rotate", Direction: "clockwise", Fingers: 2}))
+	assert.NoError(t, checkEventInfo(EventInfo{Name: "rotate", Direction: "counterclockwise", Fingers: 3}))
+	assert.Error(t, checkEventInfo(EventInfo{Name: "rotate", Direction: "in", Fingers: 2}))
+	assert.Error(t, checkEventInfo(EventInfo{Name: "rotate", Direction: "clockwise", Fingers: 1}))
+}
+
+// 测试：旋转手势的角度阈值
+func Test_isValidRotateAngleThreshold(t *testing.T) {
+	assert.True(t, isValidRotateAngleThreshold(defaultRotateAngleThreshold))
+	assert.True(t, isValidRotateAngleThreshold(maxRotateAngleThreshold))
+	assert.False(t, isValidRotateAngleThreshold(0))
+	assert.False(t, isValidRotateAngleThreshold(-30))
+	assert.False(t, isValidRotateAngleThreshold(270))
+}
+
+// 测试：根据产生手势的触摸屏的设备节点查找映射的显示器
+func Test_findTouchScreenOutput(t *testing.T) {
+	touchScreens := []display.TouchscreenV2{
+		{Node: "/dev/input/event5", DeviceNode: "/dev/input/event5", UUID: "uuid-a"},
+		{Node: "/dev/input/event7", DeviceNode: "/dev/input/event7", UUID: "uuid-b"},
+	}
+	touchMap := map[string]string{"uuid-a": "eDP-1", "uuid-b": "HDMI-1"}
+	assert.Equal(t, "HDMI-1", findTouchScreenOutput(touchScreens, touchMap, "/dev/input/event7"))
+	assert.Equal(t, "eDP-1", findTouchScreenOutput(touchScreens, touchMap, "/dev/input/event5"))
+	// 找不到触摸屏时使用第一个触摸屏
+	assert.Equal(t, "eDP-1", findTouchScreenOutput(touchScreens, touchMap, "/dev/input/event9"))
+	assert.Equal(t, "eDP-1", findTouchScreenOutput(touchScreens, touchMap, ""))
+	assert.Equal(t, "", findTouchScreenOutput(nil, touchMap, "/dev/input/event7"))
+	assert.Equal(t, "", findTouchScreenOutput(touchScreens, nil, "/dev/input/event7"))
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+// dconfig 配置项，为 true 时统计手势动作的执行次数和失败次数，默认不统计
+const dconfigKeyGestureStatsEnabled = "gestureStatsEnabled"
+
+// gestureStat GetGestureStats 返回的一个手势的统计
+type gestureStat struct {
+	Triggered uint64 // 执行动作的次数
+	Failed    uint64 // 动作返回错误的次数，包含在 Triggered 中
+}
+
+// newGestureStats 合并执行次数和失败次数，key 见 EventInfo.key
+func newGestureStats(triggered, failed map[string]uint64) map[string]gestureStat {
+	result := make(map[string]gestureStat, len(triggered))
+	for key, count := range triggered {
+		stat := result[key]
+		stat.Triggered = count
+		result[key] = stat
+	}
+	for key, count := range failed {
+		stat := result[key]
+		stat.Failed = count
+		result[key] = stat
+	}
+	return result
+}
+
+func (m *Manager) setStatsEnabled(enabled bool) {
+	m.mu.Lock()
+	m.statsEnabled = enabled
+	m.mu.Unlock()
+}
+
+// recordStat 启用统计时记录手势动作的执行结果，err 为动作返回的错误
+func (m *Manager) recordStat(evInfo EventInfo, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.statsEnabled {
+		return
+	}
+	key := evInfo.key()
+	if m.stats == nil {
+		m.stats = make(map[string]uint64)
+	}
+	m.stats[key]++
+	if err != nil {
+		if m.failures == nil {
+			m.failures = make(map[string]uint64)
+		}
+		m.failures[key]++
+	}
+	m.statsChanged = true
+}
+
+// execGestureAction 执行手势的动作并记录执行结果
+func (m *Manager) execGestureAction(evInfo EventInfo, action ActionInfo) error {
+	err := m.execAction(evInfo, action)
+	m.recordStat(evInfo, err)
+	return err
+}
+
+func (m *Manager) getGestureStats() map[string]gestureStat {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return newGestureStats(m.stats, m.failures)
+}
+
+// resetGestureStats 清空统计并保存，保存失败时不修改当前的统计
+func (m *Manager) resetGestureStats() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	oldStats, oldFailures := m.stats, m.failures
+	m.stats, m.failures = nil, nil
+	err := m.writeNoLock()
+	if err != nil {
+		m.stats, m.failures = oldStats, oldFailures
+		return err
+	}
+	return nil
+}
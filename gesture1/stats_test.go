@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：合并执行次数和失败次数
+func Test_newGestureStats(t *testing.T) {
+	stats := newGestureStats(map[string]uint64{"swipe-up-3": 5, "pinch-in-2": 1},
+		map[string]uint64{"swipe-up-3": 2})
+	assert.Equal(t, map[string]gestureStat{
+		"swipe-up-3": {Triggered: 5, Failed: 2},
+		"pinch-in-2": {Triggered: 1},
+	}, stats)
+	assert.Len(t, newGestureStats(nil, nil), 0)
+}
+
+// 测试：只有启用统计时才记录执行结果
+func Test_recordStat(t *testing.T) {
+	m := &Manager{}
+	evInfo := EventInfo{Name: "swipe", Direction: "up", Fingers: 3}
+	m.recordStat(evInfo, nil)
+	assert.Nil(t, m.stats)
+	assert.False(t, m.statsChanged)
+
+	m.setStatsEnabled(true)
+	m.recordStat(evInfo, nil)
+	m.recordStat(evInfo, errors.New("failed"))
+	assert.True(t, m.statsChanged)
+	assert.Equal(t, gestureStat{Triggered: 2, Failed: 1}, m.getGestureStats()[evInfo.key()])
+}
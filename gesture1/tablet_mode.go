@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// system/inputdevices1 根据可翻转笔记本的 SW_TABLET_MODE 开关更新 TabletMode 属性
+const (
+	systemInputDevicesService   = "org.deepin.dde.InputDevices1"
+	systemInputDevicesPath      = "/org/deepin/dde/InputDevices1"
+	systemInputDevicesInterface = systemInputDevicesService
+	propTabletMode              = "TabletMode"
+)
+
+// Mode 属性的值，每种模式使用各自的手势映射
+const (
+	gestureModeLaptop = "laptop"
+	gestureModeTablet = "tablet"
+)
+
+// gestureMap 一种模式下使用的手势和触摸屏边缘配置
+type gestureMap struct {
+	Infos      gestureInfos
+	TouchEdges touchEdgeConfigs `json:",omitempty"`
+}
+
+func isValidGestureMode(mode string) bool {
+	switch mode {
+	case gestureModeLaptop, gestureModeTablet:
+		return true
+	}
+	return false
+}
+
+func gestureModeOf(tabletMode bool) string {
+	if tabletMode {
+		return gestureModeTablet
+	}
+	return gestureModeLaptop
+}
+
+// defaultTabletGestureMap 平板模式下触摸板通常被翻到背面，默认不执行触摸板手势，
+// 触摸屏除了左右边缘外，从上边缘滑入显示工作区概览
+func defaultTabletGestureMap() gestureMap {
+	edges := defaultTouchEdgeConfigs()
+	top := *edges[touchEdgeTop]
+	top.Action = &ActionInfo{Type: ActionTypeBuiltin, Action: "ShowWorkspaceOverview"}
+	edges[touchEdgeTop] = &top
+	return gestureMap{TouchEdges: edges}
+}
+
+// newGestureMaps 返回配置中笔记本模式和平板模式的手势映射，配置中没有平板模式的映射时使用默认的映射
+func newGestureMaps(cfg *gestureConfig) (laptop, tablet gestureMap) {
+	laptop = gestureMap{
+		Infos:      withTouchRightButtonInfos(cfg.Infos),
+		TouchEdges: defaultTouchEdgeConfigs().merge(cfg.TouchEdges),
+	}
+	tablet = defaultTabletGestureMap()
+	if cfg.Tablet != nil {
+		tablet.Infos = cfg.Tablet.Infos
+		tablet.TouchEdges = tablet.TouchEdges.merge(cfg.Tablet.TouchEdges)
+	}
+	tablet.Infos = withTouchRightButtonInfos(tablet.Infos)
+	return laptop, tablet
+}
+
+// editable 返回可以通过 D-Bus 编辑的手势映射
+func (gm gestureMap) editable() gestureMap {
+	return gestureMap{Infos: gm.Infos.editable(), TouchEdges: gm.TouchEdges}
+}
+
+// gestureMapsNoLock Infos 和 touchEdges 是当前模式的映射，inactiveMap 是另一种模式的映射
+func (m *Manager) gestureMapsNoLock() (laptop, tablet gestureMap) {
+	active := gestureMap{Infos: m.Infos, TouchEdges: m.touchEdges}
+	if m.mode == gestureModeTablet {
+		return m.inactiveMap, active
+	}
+	return active, m.inactiveMap
+}
+
+func (m *Manager) setGestureMapsNoLock(laptop, tablet gestureMap) {
+	active, inactive := laptop, tablet
+	if m.mode == gestureModeTablet {
+		active, inactive = tablet, laptop
+	}
+	m.Infos, m.touchEdges = active.Infos, active.TouchEdges
+	m.inactiveMap = inactive
+}
+
+func (m *Manager) getGestureMap(mode string) (gestureMap, error) {
+	if !isValidGestureMode(mode) {
+		return gestureMap{}, fmt.Errorf("invalid gesture mode %q", mode)
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	laptop, tablet := m.gestureMapsNoLock()
+	if mode == gestureModeTablet {
+		return tablet.editable(), nil
+	}
+	return laptop.editable(), nil
+}
+
+// setGestureMap 替换一种模式的手势映射并保存，保存失败时不修改当前的映射
+func (m *Manager) setGestureMap(mode string, gm *gestureMap) error {
+	if !isValidGestureMode(mode) {
+		return fmt.Errorf("invalid gesture mode %q", mode)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldLaptop, oldTablet := m.gestureMapsNoLock()
+	laptop, tablet := oldLaptop, oldTablet
+	newMap := gestureMap{Infos: withTouchRightButtonInfos(gm.Infos)}
+	if mode == gestureModeTablet {
+		newMap.TouchEdges = defaultTabletGestureMap().TouchEdges.merge(gm.TouchEdges)
+		tablet = newMap
+	} else {
+		newMap.TouchEdges = defaultTouchEdgeConfigs().merge(gm.TouchEdges)
+		laptop = newMap
+	}
+	m.setGestureMapsNoLock(laptop, tablet)
+	err := m.writeNoLock()
+	if err != nil {
+		m.setGestureMapsNoLock(oldLaptop, oldTablet)
+		return err
+	}
+	return nil
+}
+
+func (m *Manager) isTabletMode() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.mode == gestureModeTablet
+}
+
+// setMode 切换当前使用的手势映射
+func (m *Manager) setMode(mode string) {
+	m.mu.Lock()
+	if m.mode == mode {
+		m.mu.Unlock()
+		return
+	}
+	laptop, tablet := m.gestureMapsNoLock()
+	m.mode = mode
+	m.setGestureMapsNoLock(laptop, tablet)
+	m.mu.Unlock()
+
+	m.ModeMu.Lock()
+	m.Mode = mode
+	m.ModeMu.Unlock()
+	logger.Info("gesture mode changed:", mode)
+
+	if mode == gestureModeTablet {
+		// 平板模式下不使用触摸板，结束进行中的三指拖动
+		m.handleThreeFingerDragEnd()
+	}
+	if m.service == nil {
+		return
+	}
+	err := m.service.EmitPropertyChanged(m, "Mode", mode)
+	if err != nil {
+		logger.Warning(err)
+	}
+	err = m.service.Emit(m, "ModeChanged", mode)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+// initTabletMode 跟随 system/inputdevices1 的 TabletMode 属性切换模式
+func (m *Manager) initTabletMode(systemConn *dbus.Conn) {
+	err := dbusutil.NewMatchRuleBuilder().Type("signal").
+		Path(systemInputDevicesPath).
+		Interface("org.freedesktop.DBus.Properties").
+		Member("PropertiesChanged").Build().AddTo(systemConn)
+	if err != nil {
+		logger.Warning(err)
+	}
+
+	m.systemSigLoop.AddHandler(&dbusutil.SignalRule{
+		Path: systemInputDevicesPath,
+		Name: "org.freedesktop.DBus.Properties.PropertiesChanged",
+	}, func(sig *dbus.Signal) {
+		var iface string
+		var changed map[string]dbus.Variant
+		var invalidated []string
+		err := dbus.Store(sig.Body, &iface, &changed, &invalidated)
+		if err != nil || iface != systemInputDevicesInterface {
+			return
+		}
+		value, ok := changed[propTabletMode]
+		if !ok {
+			return
+		}
+		tabletMode, ok := value.Value().(bool)
+		if !ok {
+			logger.Warning("invalid TabletMode value:", value)
+			return
+		}
+		m.setMode(gestureModeOf(tabletMode))
+	})
+
+	value, err := systemConn.Object(systemInputDevicesService, systemInputDevicesPath).
+		GetProperty(systemInputDevicesInterface + "." + propTabletMode)
+	if err != nil {
+		// 旧版本的 system/inputdevices1 没有该属性，总是使用笔记本模式
+		logger.Debug("failed to get TabletMode:", err)
+		return
+	}
+	tabletMode, ok := value.Value().(bool)
+	if !ok {
+		logger.Warning("invalid TabletMode value:", value)
+		return
+	}
+	m.setMode(gestureModeOf(tabletMode))
+}
@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：配置中没有平板模式的映射时，平板模式不执行触摸板手势并启用上边缘
+func Test_newGestureMaps(t *testing.T) {
+	cfg := &gestureConfig{
+		Infos: gestureInfos{newSwipeInfo("left", "SwitchWorkspace")},
+	}
+	laptop, tablet := newGestureMaps(cfg)
+	assert.NotNil(t, laptop.Infos.Get(EventInfo{Name: "swipe", Direction: "left", Fingers: 4}))
+	assert.Nil(t, laptop.TouchEdges[touchEdgeTop].Action)
+	assert.Nil(t, laptop.TouchEdges[touchEdgeTop].DoubleTapAction)
+	assert.Len(t, tablet.Infos.editable(), 0)
+	assert.NotNil(t, tablet.Infos.Get(EventInfo{Name: touchRightButton, Direction: "down"}))
+	assert.Equal(t, "ShowWorkspaceOverview", tablet.TouchEdges[touchEdgeTop].Action.Action)
+	assert.Nil(t, tablet.TouchEdges[touchEdgeTop].DoubleTapAction)
+	assert.NotNil(t, tablet.TouchEdges[touchEdgeLeft])
+
+	cfg.Tablet = &gestureMap{
+		Infos:      gestureInfos{newSwipeInfo("up", "ShowWorkspace")},
+		TouchEdges: touchEdgeConfigs{touchEdgeTop: {Distance: 50}},
+	}
+	_, tablet = newGestureMaps(cfg)
+	assert.Len(t, tablet.Infos.editable(), 1)
+	assert.Nil(t, tablet.TouchEdges[touchEdgeTop].Action)
+	assert.Equal(t, uint32(50), tablet.TouchEdges[touchEdgeTop].Distance)
+}
+
+// 测试：切换模式时交换当前使用的手势映射
+func Test_setMode(t *testing.T) {
+	laptop, tablet := newGestureMaps(&gestureConfig{
+		Infos: gestureInfos{newSwipeInfo("left", "SwitchWorkspace")},
+	})
+	m := &Manager{mode: gestureModeLaptop, Mode: gestureModeLaptop}
+	m.setGestureMapsNoLock(laptop, tablet)
+	evInfo := EventInfo{Name: "swipe", Direction: "left", Fingers: 4}
+	assert.NotNil(t, m.Infos.Get(evInfo))
+
+	m.setMode(gestureModeTablet)
+	assert.True(t, m.isTabletMode())
+	assert.Equal(t, gestureModeTablet, m.Mode)
+	assert.Nil(t, m.Infos.Get(evInfo))
+	assert.NotNil(t, m.touchEdges[touchEdgeTop].Action)
+
+	gotLaptop, gotTablet := m.gestureMapsNoLock()
+	assert.Equal(t, laptop, gotLaptop)
+	assert.Equal(t, tablet, gotTablet)
+
+	m.setMode(gestureModeLaptop)
+	assert.False(t, m.isTabletMode())
+	assert.NotNil(t, m.Infos.Get(evInfo))
+	assert.Nil(t, m.touchEdges[touchEdgeTop].Action)
+}
+
+// 测试：只有 laptop 和 tablet 两种模式
+func Test_isValidGestureMode(t *testing.T) {
+	assert.True(t, isValidGestureMode(gestureModeLaptop))
+	assert.True(t, isValidGestureMode(gestureModeTablet))
+	assert.False(t, isValidGestureMode("desktop"))
+	assert.Equal(t, gestureModeTablet, gestureModeOf(true))
+	assert.Equal(t, gestureModeLaptop, gestureModeOf(false))
+}
@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	"github.com/linuxdeepin/go-lib/strv"
+)
+
+// 触摸屏多指点击所在的角，EventInfo.Corner 的取值
+const (
+	tapCornerTopLeft     = "top-left"
+	tapCornerTopRight    = "top-right"
+	tapCornerBottomLeft  = "bottom-left"
+	tapCornerBottomRight = "bottom-right"
+)
+
+var tapCorners = []string{tapCornerTopLeft, tapCornerTopRight, tapCornerBottomLeft, tapCornerBottomRight}
+
+// 点击位置到相邻两条边缘的距离都不超过该值时认为点击了角，单位像素
+const defaultTapCornerSize = 150
+
+// checkTapCorner 只有 tap 手势可以要求角
+func checkTapCorner(evInfo EventInfo) error {
+	if evInfo.Corner == "" {
+		return nil
+	}
+	if evInfo.Name != "tap" {
+		return fmt.Errorf("gesture %s does not support corner", evInfo.Name)
+	}
+	if !strv.Strv(tapCorners).Contains(evInfo.Corner) {
+		return fmt.Errorf("invalid corner %q", evInfo.Corner)
+	}
+	return nil
+}
+
+// tapCorner 返回点击位置 p 所在的角，不在角中时返回空字符串
+func tapCorner(context *touchEventContext, p *point, size uint32) string {
+	near := func(edge string) bool {
+		return touchEdgeDistance(context, edge, p) <= float64(size)
+	}
+	switch {
+	case near(touchEdgeTop) && near(touchEdgeLeft):
+		return tapCornerTopLeft
+	case near(touchEdgeTop) && near(touchEdgeRight):
+		return tapCornerTopRight
+	case near(touchEdgeBot) && near(touchEdgeLeft):
+		return tapCornerBottomLeft
+	case near(touchEdgeBot) && near(touchEdgeRight):
+		return tapCornerBottomRight
+	}
+	return ""
+}
+
+// handleTouchTapEvent 第一个和最后一个触点都在同一个角中时执行要求该角的 tap 手势，
+// startP 和 endP 为旋转后的位置，不在角中的点击不执行手势
+func (m *Manager) handleTouchTapEvent(context *touchEventContext, fingers int32, startP, endP *point) error {
+	m.mu.RLock()
+	size := m.thresholds.withDefaults().CornerSize
+	m.mu.RUnlock()
+
+	corner := tapCorner(context, startP, size)
+	if corner == "" || tapCorner(context, endP, size) != corner {
+		return nil
+	}
+	return m.Exec(EventInfo{Name: "tap", Direction: "none", Fingers: fingers, Corner: corner})
+}
+
+// initTouchTapEvent 监听 system/gesture1 的 TouchTapEvent 信号，旧版本的 system/gesture1 没有该信号
+func (m *Manager) initTouchTapEvent(systemConn *dbus.Conn) {
+	err := dbusutil.NewMatchRuleBuilder().Type("signal").
+		Path(systemGesturePath).
+		Interface(systemGestureInterface).
+		Member("TouchTapEvent").Build().AddTo(systemConn)
+	if err != nil {
+		logger.Warning(err)
+	}
+
+	m.systemSigLoop.AddHandler(&dbusutil.SignalRule{
+		Path: systemGesturePath,
+		Name: systemGestureInterface + ".TouchTapEvent",
+	}, func(sig *dbus.Signal) {
+		var fingers int32
+		var startScaleX, startScaleY, endScaleX, endScaleY float64
+		err := dbus.Store(sig.Body, &fingers, &startScaleX, &startScaleY, &endScaleX, &endScaleY)
+		if err != nil {
+			logger.Warning("invalid TouchTapEvent signal:", err)
+			return
+		}
+		should, err := m.shouldHandleEvent(deviceTouchScreen)
+		if err != nil {
+			logger.Error("shouldHandleEvent failed:", err)
+			return
+		}
+		if !should {
+			return
+		}
+		// 单指点击已经在 TouchTap 信号中发送
+		if fingers > 1 {
+			m.broadcastGestureEvent(gestureEventTouchTap, "none", fingers, startScaleX, startScaleY)
+		}
+
+		context, pointFn, err := m.getTouchScreenRotationContext(queryLastTouchDevice())
+		if err != nil {
+			logger.Error("getTouchScreenRotationContext failed:", err)
+			return
+		}
+		startP := &point{X: startScaleX, Y: startScaleY}
+		pointFn(startP)
+		endP := &point{X: endScaleX, Y: endScaleY}
+		pointFn(endP)
+		err = m.handleTouchTapEvent(context, fingers, startP, endP)
+		if err != nil {
+			logger.Error("handleTouchTapEvent failed:", err)
+		}
+	})
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：点击位置到相邻两条边缘都足够近时才识别为角
+func Test_tapCorner(t *testing.T) {
+	context := &touchEventContext{
+		top: "top", bot: "bot", left: "left", right: "right",
+		screenWidth: 1920, screenHeight: 1080,
+	}
+	assert.Equal(t, tapCornerTopLeft, tapCorner(context, &point{X: 0.02, Y: 0.05}, defaultTapCornerSize))
+	assert.Equal(t, tapCornerTopRight, tapCorner(context, &point{X: 0.98, Y: 0.05}, defaultTapCornerSize))
+	assert.Equal(t, tapCornerBottomLeft, tapCorner(context, &point{X: 0.02, Y: 0.95}, defaultTapCornerSize))
+	assert.Equal(t, tapCornerBottomRight, tapCorner(context, &point{X: 0.98, Y: 0.95}, defaultTapCornerSize))
+	// 只靠近一条边缘
+	assert.Equal(t, "", tapCorner(context, &point{X: 0.5, Y: 0.05}, defaultTapCornerSize))
+	assert.Equal(t, "", tapCorner(context, &point{X: 0.5, Y: 0.5}, defaultTapCornerSize))
+	assert.Equal(t, "", tapCorner(context, &point{X: 0.98, Y: 0.05}, 20))
+}
+
+// 测试：只有 tap 手势可以要求角，角包含在 key 中
+func Test_checkTapCorner(t *testing.T) {
+	evInfo := EventInfo{Name: "tap", Direction: "none", Fingers: 3, Corner: tapCornerTopRight}
+	assert.NoError(t, checkEventInfo(evInfo))
+	assert.Equal(t, "tap-none-3-top-right", evInfo.key())
+
+	evInfo.Corner = "center"
+	assert.Error(t, checkEventInfo(evInfo))
+	assert.Error(t, checkEventInfo(EventInfo{Name: "swipe", Direction: "up", Fingers: 3, Corner: tapCornerTopLeft}))
+}
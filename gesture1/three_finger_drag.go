@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"math"
+)
+
+// gsettings 配置项，三指拖动的模式，schema 中没有该配置项时不启用
+const gsKeyThreeFingerDrag = "three-finger-drag"
+
+const (
+	threeFingerDragDisabled = "disabled"
+	// 三指移动激活窗口
+	threeFingerDragMoveWindow = "move-window"
+	// 三指移动时按住鼠标左键，模拟拖动锁定，可以拖动文件或选择文字
+	threeFingerDragLock = "drag-lock"
+)
+
+const threeFingerDragFingers = 3
+
+// threeFingerDrag 一次三指拖动的状态
+type threeFingerDrag struct {
+	mode string
+	// 还没有发送的不足一个像素的移动距离
+	remainX, remainY float64
+}
+
+func isValidThreeFingerDragMode(mode string) bool {
+	switch mode {
+	case threeFingerDragDisabled, threeFingerDragMoveWindow, threeFingerDragLock:
+		return true
+	}
+	return false
+}
+
+// split 返回本次需要移动的整数像素，小数部分累加到下一次移动
+func (drag *threeFingerDrag) split(dx, dy float64) (int32, int32) {
+	drag.remainX += dx
+	drag.remainY += dy
+	moveX := math.Trunc(drag.remainX)
+	moveY := math.Trunc(drag.remainY)
+	drag.remainX -= moveX
+	drag.remainY -= moveY
+	return int32(moveX), int32(moveY)
+}
+
+func (m *Manager) getThreeFingerDragMode() string {
+	if !m.setting.GetSchema().HasKey(gsKeyThreeFingerDrag) {
+		return threeFingerDragDisabled
+	}
+	mode := m.setting.GetString(gsKeyThreeFingerDrag)
+	if !isValidThreeFingerDragMode(mode) {
+		logger.Warningf("invalid three finger drag mode %q", mode)
+		return threeFingerDragDisabled
+	}
+	return mode
+}
+
+// beginThreeFingerDrag 未启用三指拖动或处于平板模式时返回 false
+func (m *Manager) beginThreeFingerDrag() (bool, error) {
+	mode := m.getThreeFingerDragMode()
+	if mode == threeFingerDragDisabled || m.isTabletMode() {
+		return false, nil
+	}
+	should, err := m.shouldHandleEvent(deviceTouchPad)
+	if err != nil || !should {
+		return false, err
+	}
+	if m.shouldSuppressGesture(EventInfo{Name: "swipe", Fingers: threeFingerDragFingers}) {
+		return false, nil
+	}
+
+	switch mode {
+	case threeFingerDragMoveWindow:
+		err = m.wm.TouchToMove(0, 0, 0)
+	case threeFingerDragLock:
+		err = m.sendButton(mouseButtonLeft, true)
+	}
+	if err != nil {
+		return false, err
+	}
+	m.dragMu.Lock()
+	m.drag = &threeFingerDrag{mode: mode}
+	m.dragMu.Unlock()
+	return true, nil
+}
+
+func (m *Manager) moveThreeFingerDrag(dx, dy float64) error {
+	m.dragMu.Lock()
+	defer m.dragMu.Unlock()
+	if m.drag == nil {
+		return nil
+	}
+	moveX, moveY := m.drag.split(dx, dy)
+	if moveX == 0 && moveY == 0 {
+		return nil
+	}
+	if m.drag.mode == threeFingerDragLock {
+		return m.sendMotion(moveX, moveY)
+	}
+	return m.wm.TouchToMove(0, moveX, moveY)
+}
+
+// endThreeFingerDrag 手指离开时结束移动窗口或松开鼠标左键，没有进行中的三指拖动时返回 false
+func (m *Manager) endThreeFingerDrag() (bool, error) {
+	m.dragMu.Lock()
+	drag := m.drag
+	m.drag = nil
+	m.dragMu.Unlock()
+	if drag == nil {
+		return false, nil
+	}
+	if drag.mode == threeFingerDragLock {
+		return true, m.sendButton(mouseButtonLeft, false)
+	}
+	return true, m.wm.ClearMoveStatus(0)
+}
+
+func (m *Manager) isThreeFingerDragging() bool {
+	m.dragMu.Lock()
+	defer m.dragMu.Unlock()
+	return m.drag != nil
+}
+
+// handleThreeFingerDragUpdate 处理三指滑动的 SwipeUpdate 信号，返回 true 时该滑动用于三指拖动
+func (m *Manager) handleThreeFingerDragUpdate(fingers int32, dx, dy float64) bool {
+	if fingers != threeFingerDragFingers {
+		// 手指数变化时结束拖动，避免一直按住鼠标左键
+		_, err := m.endThreeFingerDrag()
+		if err != nil {
+			logger.Warning("failed to end three finger drag:", err)
+		}
+		return false
+	}
+	if !m.isThreeFingerDragging() {
+		ok, err := m.beginThreeFingerDrag()
+		if err != nil {
+			logger.Warning("failed to begin three finger drag:", err)
+		}
+		if !ok {
+			return false
+		}
+	}
+	err := m.moveThreeFingerDrag(dx, dy)
+	if err != nil {
+		logger.Warning("failed to move three finger drag:", err)
+	}
+	return true
+}
+
+// handleThreeFingerDragEnd 处理 SwipeEnd 信号，返回 true 时随后的 swipe 事件不再执行动作
+func (m *Manager) handleThreeFingerDragEnd() bool {
+	ended, err := m.endThreeFingerDrag()
+	if err != nil {
+		logger.Warning("failed to end three finger drag:", err)
+	}
+	return ended
+}
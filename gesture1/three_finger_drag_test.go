@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：三指拖动的模式
+func Test_isValidThreeFingerDragMode(t *testing.T) {
+	assert.True(t, isValidThreeFingerDragMode(threeFingerDragDisabled))
+	assert.True(t, isValidThreeFingerDragMode(threeFingerDragMoveWindow))
+	assert.True(t, isValidThreeFingerDragMode(threeFingerDragLock))
+	assert.False(t, isValidThreeFingerDragMode(""))
+	assert.False(t, isValidThreeFingerDragMode("drag"))
+}
+
+// 测试：不足一个像素的移动累加到下一次移动
+func Test_threeFingerDragSplit(t *testing.T) {
+	drag := &threeFingerDrag{mode: threeFingerDragLock}
+	x, y := drag.split(0.6, -0.6)
+	assert.Equal(t, int32(0), x)
+	assert.Equal(t, int32(0), y)
+	x, y = drag.split(0.6, -0.6)
+	assert.Equal(t, int32(1), x)
+	assert.Equal(t, int32(-1), y)
+	x, y = drag.split(2.9, 3)
+	assert.Equal(t, int32(3), x)
+	assert.Equal(t, int32(2), y)
+}
@@ -0,0 +1,284 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// 与 system/gesture1 中的默认值一致
+const (
+	defaultSwipeDistanceThreshold = 70.0
+	defaultPinchScaleThreshold    = 1.0
+	// 单位毫米
+	defaultMovementDistanceThreshold = 10.0
+)
+
+// gestureThresholds 保存在用户配置文件中的识别阈值，值为 0 时使用默认值，
+// EdgeTapSize、DoubleTapInterval 和 CornerSize 只在 session 中使用，见 touch_edge_tap.go 和 tap_corner.go
+type gestureThresholds struct {
+	SwipeDistance     float64 `json:",omitempty"`
+	PinchScale        float64 `json:",omitempty"`
+	MovementDistance  float64 `json:",omitempty"`
+	EdgeTapSize       uint32  `json:",omitempty"` // 单位像素
+	DoubleTapInterval uint32  `json:",omitempty"` // 单位毫秒
+	CornerSize        uint32  `json:",omitempty"` // 单位像素
+}
+
+func (t gestureThresholds) withDefaults() gestureThresholds {
+	if t.SwipeDistance == 0 {
+		t.SwipeDistance = defaultSwipeDistanceThreshold
+	}
+	if t.PinchScale == 0 {
+		t.PinchScale = defaultPinchScaleThreshold
+	}
+	if t.MovementDistance == 0 {
+		t.MovementDistance = defaultMovementDistanceThreshold
+	}
+	if t.EdgeTapSize == 0 {
+		t.EdgeTapSize = defaultEdgeTapSize
+	}
+	if t.DoubleTapInterval == 0 {
+		t.DoubleTapInterval = defaultDoubleTapInterval
+	}
+	if t.CornerSize == 0 {
+		t.CornerSize = defaultTapCornerSize
+	}
+	return t
+}
+
+// value 配置文件中没有阈值时使用默认值
+func (t *gestureThresholds) value() gestureThresholds {
+	if t == nil {
+		return gestureThresholds{}
+	}
+	return *t
+}
+
+// configValue 全部使用默认值时不保存到配置文件中
+func (t gestureThresholds) configValue() *gestureThresholds {
+	if t == (gestureThresholds{}) {
+		return nil
+	}
+	return &t
+}
+
+func (t gestureThresholds) check() error {
+	if t.SwipeDistance < 0 {
+		return fmt.Errorf("SwipeDistance: invalid distance %v", t.SwipeDistance)
+	}
+	if t.PinchScale < 0 {
+		return fmt.Errorf("PinchScale: invalid scale %v", t.PinchScale)
+	}
+	if t.MovementDistance < 0 {
+		return fmt.Errorf("MovementDistance: invalid distance %v", t.MovementDistance)
+	}
+	if t.EdgeTapSize > maxTouchEdgeDistance {
+		return fmt.Errorf("EdgeTapSize: invalid size %d", t.EdgeTapSize)
+	}
+	if t.DoubleTapInterval > maxDoubleTapInterval {
+		return fmt.Errorf("DoubleTapInterval: invalid interval %d", t.DoubleTapInterval)
+	}
+	if t.CornerSize > maxTouchEdgeDistance {
+		return fmt.Errorf("CornerSize: invalid size %d", t.CornerSize)
+	}
+	return nil
+}
+
+// gestureSettings GetGestureSettings 和 SetGestureSettings 使用的按手势类型分组的识别参数，
+// 其中时长和旋转角度仍然保存在原来的 gsettings 和 dconfig 配置项中
+type gestureSettings struct {
+	Swipe struct {
+		Distance float64 // 触摸板滑动的最小移动距离
+	}
+	Pinch struct {
+		Scale float64 // 触摸板捏合的最小缩放比例
+	}
+	Rotate struct {
+		Angle float64 // 触摸板旋转的最小角度，单位度
+	}
+	TouchScreen struct {
+		LongPressDuration    uint32  // 单位毫秒
+		ShortPressDuration   uint32  // 单位毫秒
+		EdgeMoveStopDuration uint32  // 单位毫秒
+		MovementDistance     float64 // 边缘划入和滑动的最小距离，单位毫米
+		EdgeTapSize          uint32  // 双击边缘时点击位置到边缘的最大距离，单位像素，可以省略
+		DoubleTapInterval    uint32  // 双击边缘的最大间隔，单位毫秒，可以省略
+		CornerSize           uint32  // 多指点击时角的宽度和高度，单位像素，可以省略
+	}
+}
+
+// parseGestureSettings 不允许未知的字段，没有的字段值为 0，
+// 可以省略的字段值为 0 时使用默认值
+func parseGestureSettings(data []byte) (*gestureSettings, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var settings gestureSettings
+	err := dec.Decode(&settings)
+	if err != nil {
+		return nil, jsonErrorWithPosition(data, dec.InputOffset(), err)
+	}
+	return &settings, nil
+}
+
+func (s *gestureSettings) check() error {
+	if s.Swipe.Distance <= 0 {
+		return fmt.Errorf("Swipe.Distance: invalid distance %v", s.Swipe.Distance)
+	}
+	if s.Pinch.Scale <= 0 {
+		return fmt.Errorf("Pinch.Scale: invalid scale %v", s.Pinch.Scale)
+	}
+	if !isValidRotateAngleThreshold(s.Rotate.Angle) {
+		return fmt.Errorf("Rotate.Angle: invalid angle %v", s.Rotate.Angle)
+	}
+	if s.TouchScreen.LongPressDuration == 0 {
+		return errors.New("TouchScreen.LongPressDuration: duration is 0")
+	}
+	if s.TouchScreen.ShortPressDuration == 0 {
+		return errors.New("TouchScreen.ShortPressDuration: duration is 0")
+	}
+	if s.TouchScreen.MovementDistance <= 0 {
+		return fmt.Errorf("TouchScreen.MovementDistance: invalid distance %v", s.TouchScreen.MovementDistance)
+	}
+	if s.TouchScreen.EdgeTapSize > maxTouchEdgeDistance {
+		return fmt.Errorf("TouchScreen.EdgeTapSize: invalid size %d", s.TouchScreen.EdgeTapSize)
+	}
+	if s.TouchScreen.DoubleTapInterval > maxDoubleTapInterval {
+		return fmt.Errorf("TouchScreen.DoubleTapInterval: invalid interval %d", s.TouchScreen.DoubleTapInterval)
+	}
+	if s.TouchScreen.CornerSize > maxTouchEdgeDistance {
+		return fmt.Errorf("TouchScreen.CornerSize: invalid size %d", s.TouchScreen.CornerSize)
+	}
+	return nil
+}
+
+func (s *gestureSettings) thresholds() gestureThresholds {
+	return gestureThresholds{
+		SwipeDistance:     s.Swipe.Distance,
+		PinchScale:        s.Pinch.Scale,
+		MovementDistance:  s.TouchScreen.MovementDistance,
+		EdgeTapSize:       s.TouchScreen.EdgeTapSize,
+		DoubleTapInterval: s.TouchScreen.DoubleTapInterval,
+		CornerSize:        s.TouchScreen.CornerSize,
+	}
+}
+
+func (m *Manager) getGestureSettings() *gestureSettings {
+	m.mu.RLock()
+	thresholds := m.thresholds.withDefaults()
+	m.mu.RUnlock()
+
+	var s gestureSettings
+	s.Swipe.Distance = thresholds.SwipeDistance
+	s.Pinch.Scale = thresholds.PinchScale
+	s.Rotate.Angle = m.getRotateAngleThreshold()
+	s.TouchScreen.LongPressDuration = uint32(m.tsSetting.GetInt(tsSchemaKeyLongPress))
+	s.TouchScreen.ShortPressDuration = uint32(m.tsSetting.GetInt(tsSchemaKeyShortPress))
+	s.TouchScreen.EdgeMoveStopDuration = uint32(m.tsSetting.GetInt(tsSchemaKeyEdgeMoveStop))
+	s.TouchScreen.MovementDistance = thresholds.MovementDistance
+	s.TouchScreen.EdgeTapSize = thresholds.EdgeTapSize
+	s.TouchScreen.DoubleTapInterval = thresholds.DoubleTapInterval
+	s.TouchScreen.CornerSize = thresholds.CornerSize
+	return &s
+}
+
+// setGestureSettings 保存并立即应用到 system/gesture1
+func (m *Manager) setGestureSettings(s *gestureSettings) error {
+	err := m.updateThresholds(s.thresholds())
+	if err != nil {
+		return err
+	}
+	m.applyGestureThresholds()
+
+	if s.Rotate.Angle != m.getRotateAngleThreshold() {
+		// 在 dconfig 的变化信号中应用
+		err = m.setGestureConfigDouble(dconfigKeyRotateAngleThreshold, s.Rotate.Angle)
+		if err != nil {
+			return err
+		}
+	}
+	err = m.setLongPressDuration(s.TouchScreen.LongPressDuration)
+	if err != nil {
+		return err
+	}
+	err = m.setShortPressDuration(s.TouchScreen.ShortPressDuration)
+	if err != nil {
+		return err
+	}
+	return m.setEdgeMoveStopDuration(s.TouchScreen.EdgeMoveStopDuration)
+}
+
+// updateThresholds 保存失败时不修改当前的阈值
+func (m *Manager) updateThresholds(thresholds gestureThresholds) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	old := m.thresholds
+	m.thresholds = thresholds
+	err := m.writeNoLock()
+	if err != nil {
+		m.thresholds = old
+		return err
+	}
+	return nil
+}
+
+func (m *Manager) applyGestureThresholds() {
+	m.mu.RLock()
+	thresholds := m.thresholds.withDefaults()
+	m.mu.RUnlock()
+
+	systemConn, err := dbus.SystemBus()
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	err = systemConn.Object(systemGestureService, systemGesturePath).
+		Call(systemGestureInterface+".SetGestureThresholds", 0,
+			thresholds.SwipeDistance, thresholds.PinchScale, thresholds.MovementDistance).Err
+	if err != nil {
+		logger.Warning("call SetGestureThresholds failed:", err)
+	}
+}
+
+func (m *Manager) setLongPressDuration(duration uint32) error {
+	if m.tsSetting.GetInt(tsSchemaKeyLongPress) == int32(duration) {
+		return nil
+	}
+	err := m.sysDaemon.SetLongPressDuration(0, duration)
+	if err != nil {
+		return err
+	}
+	m.tsSetting.SetInt(tsSchemaKeyLongPress, int32(duration))
+	return nil
+}
+
+func (m *Manager) setShortPressDuration(duration uint32) error {
+	if m.tsSetting.GetInt(tsSchemaKeyShortPress) == int32(duration) {
+		return nil
+	}
+	err := m.gesture.SetShortPressDuration(0, duration)
+	if err != nil {
+		return err
+	}
+	m.tsSetting.SetInt(tsSchemaKeyShortPress, int32(duration))
+	return nil
+}
+
+func (m *Manager) setEdgeMoveStopDuration(duration uint32) error {
+	if m.tsSetting.GetInt(tsSchemaKeyEdgeMoveStop) == int32(duration) {
+		return nil
+	}
+	err := m.gesture.SetEdgeMoveStopDuration(0, duration)
+	if err != nil {
+		return err
+	}
+	m.tsSetting.SetInt(tsSchemaKeyEdgeMoveStop, int32(duration))
+	return nil
+}
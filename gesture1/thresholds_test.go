@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：识别阈值的默认值和保存到配置文件中的值
+func Test_gestureThresholds(t *testing.T) {
+	var thresholds gestureThresholds
+	assert.Nil(t, thresholds.configValue())
+	assert.Equal(t, gestureThresholds{
+		SwipeDistance:     defaultSwipeDistanceThreshold,
+		PinchScale:        defaultPinchScaleThreshold,
+		MovementDistance:  defaultMovementDistanceThreshold,
+		EdgeTapSize:       defaultEdgeTapSize,
+		DoubleTapInterval: defaultDoubleTapInterval,
+		CornerSize:        defaultTapCornerSize,
+	}, thresholds.withDefaults())
+
+	thresholds.SwipeDistance = 100
+	assert.Equal(t, &gestureThresholds{SwipeDistance: 100}, thresholds.configValue())
+	assert.Equal(t, 100.0, thresholds.withDefaults().SwipeDistance)
+	assert.Equal(t, defaultPinchScaleThreshold, thresholds.withDefaults().PinchScale)
+
+	var cfgThresholds *gestureThresholds
+	assert.Equal(t, gestureThresholds{}, cfgThresholds.value())
+
+	assert.NoError(t, thresholds.check())
+	thresholds.PinchScale = -1
+	assert.Error(t, thresholds.check())
+	thresholds.PinchScale = 0
+	thresholds.DoubleTapInterval = maxDoubleTapInterval + 1
+	assert.Error(t, thresholds.check())
+}
+
+// 测试：解析和检查 SetGestureSettings 传入的参数
+func Test_parseGestureSettings(t *testing.T) {
+	settings, err := parseGestureSettings([]byte(`{"Swipe":{"Distance":80},"Pinch":{"Scale":0.5},` +
+		`"Rotate":{"Angle":30},"TouchScreen":{"LongPressDuration":500,"ShortPressDuration":200,` +
+		`"EdgeMoveStopDuration":300,"MovementDistance":12}}`))
+	assert.NoError(t, err)
+	assert.NoError(t, settings.check())
+	assert.Equal(t, gestureThresholds{SwipeDistance: 80, PinchScale: 0.5, MovementDistance: 12}, settings.thresholds())
+	assert.Equal(t, uint32(300), settings.TouchScreen.EdgeMoveStopDuration)
+
+	settings, err = parseGestureSettings([]byte(`{"Swipe":{"Distance":80},"Pinch":{"Scale":0.5},` +
+		`"Rotate":{"Angle":30},"TouchScreen":{"LongPressDuration":500,"ShortPressDuration":200,` +
+		`"MovementDistance":12,"EdgeTapSize":30,"DoubleTapInterval":300,"CornerSize":200}}`))
+	assert.NoError(t, err)
+	assert.NoError(t, settings.check())
+	assert.Equal(t, uint32(30), settings.thresholds().EdgeTapSize)
+	assert.Equal(t, uint32(300), settings.thresholds().DoubleTapInterval)
+	assert.Equal(t, uint32(200), settings.thresholds().CornerSize)
+
+	_, err = parseGestureSettings([]byte(`{"Swipe":{"Velocity":1}}`))
+	assert.Error(t, err)
+
+	settings, err = parseGestureSettings([]byte(`{"Swipe":{"Distance":80}}`))
+	assert.NoError(t, err)
+	assert.Error(t, settings.check())
+}
@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"fmt"
+)
+
+// 触摸屏的边缘，与 system/gesture1 发出的 TouchEdgeEvent 信号一致
+const (
+	touchEdgeTop   = "top"
+	touchEdgeBot   = "bot"
+	touchEdgeLeft  = "left"
+	touchEdgeRight = "right"
+)
+
+const (
+	// 从边缘滑入的距离超过该值时执行边缘动作，单位像素
+	defaultTouchEdgeDistance = 100
+	maxTouchEdgeDistance     = 1000
+)
+
+// 执行边缘动作时使用的手势名称，动作为 signal 类型时作为 GestureTriggered 信号的参数
+const (
+	touchEdgeEventName     = "touch edge"
+	touchMovementEventName = "touch movement"
+)
+
+// touchEdgeConfig 触摸屏单指从边缘滑入时执行 Action，单指向该边缘滑动时执行 HideAction，
+// 双击上边缘或下边缘时执行 DoubleTapAction
+type touchEdgeConfig struct {
+	Action          *ActionInfo `json:",omitempty"`
+	HideAction      *ActionInfo `json:",omitempty"`
+	DoubleTapAction *ActionInfo `json:",omitempty"`
+	Distance        uint32
+}
+
+// touchEdgeConfigs key 为边缘名称，是旋转后用户看到的边缘
+type touchEdgeConfigs map[string]*touchEdgeConfig
+
+func isTouchEdge(edge string) bool {
+	switch edge {
+	case touchEdgeTop, touchEdgeBot, touchEdgeLeft, touchEdgeRight:
+		return true
+	}
+	return false
+}
+
+// 默认左边缘显示剪贴板，右边缘显示桌面小组件，默认不设置双击动作，
+// 避免点击窗口标题栏附近时误触发
+func defaultTouchEdgeConfigs() touchEdgeConfigs {
+	return touchEdgeConfigs{
+		touchEdgeTop: {
+			Distance: defaultTouchEdgeDistance,
+		},
+		touchEdgeLeft: {
+			Action:     &ActionInfo{Type: ActionTypeBuiltin, Action: "ShowClipboard"},
+			HideAction: &ActionInfo{Type: ActionTypeBuiltin, Action: "HideClipboard"},
+			Distance:   defaultTouchEdgeDistance,
+		},
+		touchEdgeRight: {
+			Action:     &ActionInfo{Type: ActionTypeBuiltin, Action: "ShowWidgets"},
+			HideAction: &ActionInfo{Type: ActionTypeBuiltin, Action: "HideWidgets"},
+			Distance:   defaultTouchEdgeDistance,
+		},
+	}
+}
+
+// merge 用户配置的边缘覆盖 edges 中对应的边缘，忽略无效的边缘
+func (edges touchEdgeConfigs) merge(user touchEdgeConfigs) touchEdgeConfigs {
+	result := make(touchEdgeConfigs, len(edges)+len(user))
+	for edge, cfg := range edges {
+		result[edge] = cfg
+	}
+	for edge, cfg := range user {
+		if !isTouchEdge(edge) || cfg == nil {
+			logger.Warningf("invalid touch edge config %q", edge)
+			continue
+		}
+		result[edge] = cfg
+	}
+	return result
+}
+
+// set 修改边缘的配置，返回新的配置，不修改原来的配置
+func (edges touchEdgeConfigs) set(edge string, fn func(cfg *touchEdgeConfig)) (touchEdgeConfigs, error) {
+	if !isTouchEdge(edge) {
+		return nil, fmt.Errorf("invalid touch edge %q", edge)
+	}
+	result := make(touchEdgeConfigs, len(edges)+1)
+	for k, v := range edges {
+		result[k] = v
+	}
+	cfg := touchEdgeConfig{Distance: defaultTouchEdgeDistance}
+	if old := edges[edge]; old != nil {
+		cfg = *old
+	}
+	fn(&cfg)
+	result[edge] = &cfg
+	return result, nil
+}
+
+// newTouchEdgeAction actionType 为空时返回 nil，表示该边缘不执行动作
+func newTouchEdgeAction(actionType, action string, builtinSets map[string]func() error) (*ActionInfo, error) {
+	if actionType == "" {
+		return nil, nil
+	}
+	actionInfo, err := newActionInfo(actionType, action)
+	if err != nil {
+		return nil, err
+	}
+	err = checkActionInfo(actionInfo, builtinSets)
+	if err != nil {
+		return nil, err
+	}
+	return &actionInfo, nil
+}
+
+// logicalEdge 将触摸屏的物理边缘转换为旋转后用户看到的边缘
+func (context *touchEventContext) logicalEdge(edge string) string {
+	switch edge {
+	case context.top:
+		return touchEdgeTop
+	case context.bot:
+		return touchEdgeBot
+	case context.left:
+		return touchEdgeLeft
+	case context.right:
+		return touchEdgeRight
+	}
+	return ""
+}
+
+// touchEdgeDistance 手势终点 p 到边缘 edge 的距离，单位像素
+func touchEdgeDistance(context *touchEventContext, edge string, p *point) float64 {
+	switch edge {
+	case touchEdgeTop:
+		return p.Y * float64(context.screenHeight)
+	case touchEdgeBot:
+		return (1 - p.Y) * float64(context.screenHeight)
+	case touchEdgeLeft:
+		return p.X * float64(context.screenWidth)
+	case touchEdgeRight:
+		return (1 - p.X) * float64(context.screenWidth)
+	}
+	return 0
+}
+
+func (m *Manager) isTouchEdgeEnabled(edge string) bool {
+	switch edge {
+	case touchEdgeBot:
+		return m.oneFingerBottomEnable
+	case touchEdgeLeft:
+		return m.oneFingerLeftEnable
+	case touchEdgeRight:
+		return m.oneFingerRightEnable
+	}
+	return true
+}
+
+func (m *Manager) getTouchEdgeConfig(edge string) *touchEdgeConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.touchEdges[edge]
+}
+
+// updateTouchEdges 保存修改后的边缘配置，保存失败时不修改当前的配置
+func (m *Manager) updateTouchEdges(fn func(edges touchEdgeConfigs) (touchEdgeConfigs, error)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	edges, err := fn(m.touchEdges)
+	if err != nil {
+		return err
+	}
+	oldEdges := m.touchEdges
+	m.touchEdges = edges
+	err = m.writeNoLock()
+	if err != nil {
+		m.touchEdges = oldEdges
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// 执行边缘双击动作时使用的手势名称，动作为 signal 类型时作为 GestureTriggered 信号的参数
+const touchEdgeDoubleTapEventName = "touch edge double tap"
+
+const (
+	// 点击位置到边缘的距离不超过该值时认为点击了边缘，单位像素
+	defaultEdgeTapSize = 40
+	// 两次点击的间隔不超过该值时认为是双击，单位毫秒
+	defaultDoubleTapInterval = 400
+	maxDoubleTapInterval     = 2000
+	// 两次点击的位置相距不超过该值时认为是双击，单位像素
+	doubleTapMaxDistance = 100
+)
+
+// 只有上下边缘支持双击，左右边缘用于滑出剪贴板和桌面小组件
+func isDoubleTapEdge(edge string) bool {
+	return edge == touchEdgeTop || edge == touchEdgeBot
+}
+
+// touchTapEdge 返回点击位置 p 所在的上边缘或下边缘，不在边缘时返回空字符串
+func touchTapEdge(context *touchEventContext, p *point, size uint32) string {
+	for _, edge := range []string{touchEdgeTop, touchEdgeBot} {
+		if touchEdgeDistance(context, edge, p) <= float64(size) {
+			return edge
+		}
+	}
+	return ""
+}
+
+// edgeTapTracker 记录上一次点击边缘的时间和位置，用于识别双击
+type edgeTapTracker struct {
+	mu   sync.Mutex
+	edge string
+	t    time.Time
+	x, y float64 // 单位像素
+}
+
+// tap 与上一次点击是同一条边缘、间隔不超过 interval 且位置相近时返回 true，
+// 识别为双击后清除记录，第三次点击重新开始识别
+func (tracker *edgeTapTracker) tap(now time.Time, edge string, x, y float64, interval time.Duration) bool {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if tracker.edge == edge && now.Sub(tracker.t) <= interval &&
+		math.Hypot(x-tracker.x, y-tracker.y) <= doubleTapMaxDistance {
+		tracker.edge = ""
+		return true
+	}
+	tracker.edge = edge
+	tracker.t = now
+	tracker.x, tracker.y = x, y
+	return false
+}
+
+// handleTouchTap p 为旋转后的点击位置
+func (m *Manager) handleTouchTap(context *touchEventContext, p *point) error {
+	m.mu.RLock()
+	thresholds := m.thresholds.withDefaults()
+	m.mu.RUnlock()
+
+	edge := touchTapEdge(context, p, thresholds.EdgeTapSize)
+	if edge == "" {
+		return nil
+	}
+	x, y := p.X*float64(context.screenWidth), p.Y*float64(context.screenHeight)
+	interval := time.Duration(thresholds.DoubleTapInterval) * time.Millisecond
+	if !m.edgeTap.tap(time.Now(), edge, x, y, interval) {
+		return nil
+	}
+
+	cfg := m.getTouchEdgeConfig(edge)
+	if cfg == nil || cfg.DoubleTapAction == nil || !m.isTouchEdgeEnabled(edge) {
+		return nil
+	}
+	evInfo := EventInfo{Name: touchEdgeDoubleTapEventName, Direction: edge, Fingers: 1}
+	if m.shouldSuppressGesture(evInfo) {
+		return nil
+	}
+	return m.execGestureAction(evInfo, *cfg.DoubleTapAction)
+}
+
+// initTouchTap 监听 system/gesture1 的 TouchTap 信号，旧版本的 system/gesture1 没有该信号
+func (m *Manager) initTouchTap(systemConn *dbus.Conn) {
+	err := dbusutil.NewMatchRuleBuilder().Type("signal").
+		Path(systemGesturePath).
+		Interface(systemGestureInterface).
+		Member("TouchTap").Build().AddTo(systemConn)
+	if err != nil {
+		logger.Warning(err)
+	}
+
+	m.systemSigLoop.AddHandler(&dbusutil.SignalRule{
+		Path: systemGesturePath,
+		Name: systemGestureInterface + ".TouchTap",
+	}, func(sig *dbus.Signal) {
+		var scaleX, scaleY float64
+		err := dbus.Store(sig.Body, &scaleX, &scaleY)
+		if err != nil {
+			logger.Warning("invalid TouchTap signal:", err)
+			return
+		}
+		should, err := m.shouldHandleEvent(deviceTouchScreen)
+		if err != nil {
+			logger.Error("shouldHandleEvent failed:", err)
+			return
+		}
+		if !should {
+			return
+		}
+		m.broadcastGestureEvent(gestureEventTouchTap, "none", 1, scaleX, scaleY)
+
+		context, pointFn, err := m.getTouchScreenRotationContext(queryLastTouchDevice())
+		if err != nil {
+			logger.Error("getTouchScreenRotationContext failed:", err)
+			return
+		}
+		p := &point{X: scaleX, Y: scaleY}
+		pointFn(p)
+		err = m.handleTouchTap(context, p)
+		if err != nil {
+			logger.Error("handleTouchTap failed:", err)
+		}
+	})
+}
+
+// newTouchEdgeDoubleTapAction 只有上下边缘可以设置双击动作
+func newTouchEdgeDoubleTapAction(edge, actionType, action string, builtinSets map[string]func() error) (*ActionInfo, error) {
+	if !isDoubleTapEdge(edge) {
+		return nil, fmt.Errorf("touch edge %q does not support double tap", edge)
+	}
+	return newTouchEdgeAction(actionType, action, builtinSets)
+}
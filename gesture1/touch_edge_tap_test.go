@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：只有点击上下边缘附近时才识别边缘
+func Test_touchTapEdge(t *testing.T) {
+	context := &touchEventContext{
+		top: "top", bot: "bot", left: "left", right: "right",
+		screenWidth: 1920, screenHeight: 1080,
+	}
+	assert.Equal(t, touchEdgeTop, touchTapEdge(context, &point{X: 0.5, Y: 0.02}, defaultEdgeTapSize))
+	assert.Equal(t, touchEdgeBot, touchTapEdge(context, &point{X: 0.5, Y: 0.99}, defaultEdgeTapSize))
+	assert.Equal(t, "", touchTapEdge(context, &point{X: 0.5, Y: 0.5}, defaultEdgeTapSize))
+	// 左右边缘不支持双击
+	assert.Equal(t, "", touchTapEdge(context, &point{X: 0.001, Y: 0.5}, defaultEdgeTapSize))
+	assert.Equal(t, "", touchTapEdge(context, &point{X: 0.5, Y: 0.05}, 20))
+}
+
+// 测试：同一条边缘间隔较短且位置相近的两次点击识别为双击
+func Test_edgeTapTracker(t *testing.T) {
+	var tracker edgeTapTracker
+	interval := defaultDoubleTapInterval * time.Millisecond
+	now := time.Now()
+
+	assert.False(t, tracker.tap(now, touchEdgeTop, 500, 10, interval))
+	assert.True(t, tracker.tap(now.Add(200*time.Millisecond), touchEdgeTop, 520, 12, interval))
+	// 识别为双击后重新开始
+	assert.False(t, tracker.tap(now.Add(300*time.Millisecond), touchEdgeTop, 520, 12, interval))
+
+	// 间隔太长
+	assert.False(t, tracker.tap(now.Add(time.Second), touchEdgeTop, 520, 12, interval))
+	// 位置相距太远
+	assert.False(t, tracker.tap(now.Add(1100*time.Millisecond), touchEdgeTop, 1500, 12, interval))
+	// 不同的边缘
+	assert.False(t, tracker.tap(now.Add(1200*time.Millisecond), touchEdgeBot, 1500, 1070, interval))
+	assert.True(t, tracker.tap(now.Add(1300*time.Millisecond), touchEdgeBot, 1490, 1075, interval))
+}
+
+// 测试：只有上下边缘可以设置双击动作
+func Test_newTouchEdgeDoubleTapAction(t *testing.T) {
+	builtinSets := map[string]func() error{"ToggleMaximize": nil}
+	action, err := newTouchEdgeDoubleTapAction(touchEdgeTop, ActionTypeBuiltin, "ToggleMaximize", builtinSets)
+	assert.NoError(t, err)
+	assert.Equal(t, "ToggleMaximize", action.Action)
+
+	action, err = newTouchEdgeDoubleTapAction(touchEdgeBot, "", "", builtinSets)
+	assert.NoError(t, err)
+	assert.Nil(t, action)
+
+	_, err = newTouchEdgeDoubleTapAction(touchEdgeLeft, ActionTypeBuiltin, "ToggleMaximize", builtinSets)
+	assert.Error(t, err)
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：旋转后的边缘转换和到边缘的距离
+func Test_touchEdgeRotation(t *testing.T) {
+	// Rotation_90 时物理的下边缘是用户看到的左边缘
+	context := &touchEventContext{
+		top: "left", bot: "right", left: "bot", right: "top",
+		screenWidth: 1080, screenHeight: 1920,
+	}
+	assert.Equal(t, touchEdgeLeft, context.logicalEdge("bot"))
+	assert.Equal(t, touchEdgeTop, context.logicalEdge("left"))
+	assert.Equal(t, "", context.logicalEdge("none"))
+
+	p := &point{X: 0.2, Y: 0.9}
+	assert.InDelta(t, 216, touchEdgeDistance(context, touchEdgeLeft, p), 0.001)
+	assert.InDelta(t, 864, touchEdgeDistance(context, touchEdgeRight, p), 0.001)
+	assert.InDelta(t, 1728, touchEdgeDistance(context, touchEdgeTop, p), 0.001)
+	assert.InDelta(t, 192, touchEdgeDistance(context, touchEdgeBot, p), 0.001)
+}
+
+// 测试：修改和合并边缘配置
+func Test_touchEdgeConfigs(t *testing.T) {
+	defaults := defaultTouchEdgeConfigs()
+	edges := defaults.merge(touchEdgeConfigs{
+		touchEdgeRight: {Distance: 200},
+		"middle":       {Distance: 200},
+	})
+	assert.Len(t, edges, 3)
+	assert.Equal(t, "ShowClipboard", edges[touchEdgeLeft].Action.Action)
+	assert.Nil(t, edges[touchEdgeRight].Action)
+	assert.Equal(t, uint32(200), edges[touchEdgeRight].Distance)
+
+	result, err := defaults.set(touchEdgeLeft, func(cfg *touchEdgeConfig) {
+		cfg.Distance = 50
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(50), result[touchEdgeLeft].Distance)
+	assert.Equal(t, "HideClipboard", result[touchEdgeLeft].HideAction.Action)
+	// 不修改原来的配置
+	assert.Equal(t, uint32(defaultTouchEdgeDistance), defaults[touchEdgeLeft].Distance)
+
+	result, err = defaults.set(touchEdgeTop, func(cfg *touchEdgeConfig) {
+		cfg.Action = &ActionInfo{Type: ActionTypeSignal}
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(defaultTouchEdgeDistance), result[touchEdgeTop].Distance)
+	assert.Nil(t, result[touchEdgeTop].DoubleTapAction)
+	assert.Len(t, result, 3)
+
+	_, err = defaults.set("middle", func(cfg *touchEdgeConfig) {})
+	assert.Error(t, err)
+}
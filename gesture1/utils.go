@@ -14,6 +14,7 @@ import (
 	x "github.com/linuxdeepin/go-x11-client"
 	"github.com/linuxdeepin/go-x11-client/util/keybind"
 	"github.com/linuxdeepin/go-x11-client/util/wm/ewmh"
+	"github.com/linuxdeepin/go-x11-client/util/wm/icccm"
 )
 
 var (
@@ -35,6 +36,9 @@ type Rect struct {
 }
 
 func isKbdAlreadyGrabbed() bool {
+	if _useWayland {
+		return isKbdAlreadyGrabbedWayland()
+	}
 	if getX11Conn() == nil {
 		return false
 	}
@@ -61,23 +65,80 @@ func isKbdAlreadyGrabbed() bool {
 	return false
 }
 
-func getCurrentActionWindowCmd() string {
+// activeWindowInfo 用于匹配窗口黑名单的激活窗口信息
+type activeWindowInfo struct {
+	Class    string // WM_CLASS 的 class，Wayland 下为 appId
+	Instance string // WM_CLASS 的 instance
+	Cmd      string // 进程的命令行
+}
+
+func getProcessCmdline(pid uint32) string {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		logger.Warning("Failed to read cmdline:", err)
+		return ""
+	}
+	return string(data)
+}
+
+func getActiveWindowInfo() activeWindowInfo {
+	if _useWayland {
+		return getActiveWindowInfoWayland()
+	}
+	var info activeWindowInfo
+	if getX11Conn() == nil {
+		return info
+	}
 	win, err := ewmh.GetActiveWindow(xconn).Reply(xconn)
 	if err != nil {
 		logger.Warning("Failed to get current active window:", err)
-		return ""
+		return info
+	}
+	wmClass, err := icccm.GetWMClass(xconn, win).Reply(xconn)
+	if err != nil {
+		logger.Warning("Failed to get current window class:", err)
+	} else {
+		info.Class = wmClass.Class
+		info.Instance = wmClass.Instance
 	}
+	// flatpak 等通过包装程序启动的窗口，进程的命令行与应用无关，需要同时按窗口类名匹配
 	pid, err := ewmh.GetWMPid(xconn, win).Reply(xconn)
 	if err != nil {
 		logger.Warning("Failed to get current window pid:", err)
-		return ""
+		return info
 	}
-	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	info.Cmd = getProcessCmdline(uint32(pid))
+	return info
+}
+
+func isActiveWindowFullscreen() bool {
+	if _useWayland {
+		return isActiveWindowFullscreenWayland()
+	}
+	if getX11Conn() == nil {
+		return false
+	}
+	win, err := ewmh.GetActiveWindow(xconn).Reply(xconn)
 	if err != nil {
-		logger.Warning("Failed to read cmdline:", err)
-		return ""
+		logger.Warning("Failed to get current active window:", err)
+		return false
 	}
-	return string(data)
+	atomFullscreen, err := xconn.GetAtom("_NET_WM_STATE_FULLSCREEN")
+	if err != nil {
+		logger.Warning(err)
+		return false
+	}
+	states, err := ewmh.GetWMState(xconn, win).Reply(xconn)
+	if err != nil {
+		logger.Warning("Failed to get current window state:", err)
+		return false
+	}
+	for _, state := range states {
+		if state == atomFullscreen {
+			return true
+		}
+	}
+	return false
 }
 
 func isSessionActive(sessionPath dbus.ObjectPath) bool {
@@ -118,9 +179,37 @@ func getX11Conn() *x.Conn {
 	return xconn
 }
 
-func isInWindowBlacklist(cmd string, list []string) bool {
+// 窗口黑名单中的规则，"class:" 开头的规则匹配窗口类名，"cmd:" 开头或没有前缀的规则匹配进程的命令行
+const (
+	blacklistPrefixClass = "class:"
+	blacklistPrefixCmd   = "cmd:"
+)
+
+func checkWindowBlacklistPattern(pattern string) error {
+	value := pattern
+	if strings.HasPrefix(pattern, blacklistPrefixClass) {
+		value = strings.TrimPrefix(pattern, blacklistPrefixClass)
+	} else if strings.HasPrefix(pattern, blacklistPrefixCmd) {
+		value = strings.TrimPrefix(pattern, blacklistPrefixCmd)
+	}
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("invalid window blacklist pattern %q", pattern)
+	}
+	return nil
+}
+
+// isInWindowBlacklist 窗口类名忽略大小写完全匹配，命令行包含规则的内容即匹配
+func isInWindowBlacklist(win activeWindowInfo, list []string) bool {
 	for _, v := range list {
-		if strings.Contains(cmd, v) {
+		if strings.HasPrefix(v, blacklistPrefixClass) {
+			class := strings.TrimPrefix(v, blacklistPrefixClass)
+			if class != "" && (strings.EqualFold(win.Class, class) || strings.EqualFold(win.Instance, class)) {
+				return true
+			}
+			continue
+		}
+		cmd := strings.TrimPrefix(v, blacklistPrefixCmd)
+		if cmd != "" && strings.Contains(win.Cmd, cmd) {
 			return true
 		}
 	}
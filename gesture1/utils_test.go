@@ -12,8 +12,27 @@ import (
 
 func Test_isInWindowBlacklist(t *testing.T) {
 	slice := []string{"window1", "window2", "window3"}
-	assert.True(t, isInWindowBlacklist("window1", slice))
-	assert.True(t, isInWindowBlacklist("window2", slice))
-	assert.True(t, isInWindowBlacklist("window3", slice))
-	assert.False(t, isInWindowBlacklist("window4", slice))
+	assert.True(t, isInWindowBlacklist(activeWindowInfo{Cmd: "window1"}, slice))
+	assert.True(t, isInWindowBlacklist(activeWindowInfo{Cmd: "window2"}, slice))
+	assert.True(t, isInWindowBlacklist(activeWindowInfo{Cmd: "window3"}, slice))
+	assert.False(t, isInWindowBlacklist(activeWindowInfo{Cmd: "window4"}, slice))
+}
+
+// 测试：按窗口类名和命令行匹配黑名单
+func Test_isInWindowBlacklistByClass(t *testing.T) {
+	list := []string{"class:Google-chrome", "cmd:/usr/bin/app", "class:"}
+	assert.True(t, isInWindowBlacklist(activeWindowInfo{Class: "google-chrome", Cmd: "/opt/google/chrome/chrome"}, list))
+	assert.True(t, isInWindowBlacklist(activeWindowInfo{Instance: "google-chrome"}, list))
+	assert.True(t, isInWindowBlacklist(activeWindowInfo{Class: "App", Cmd: "/usr/bin/app --flag"}, list))
+	assert.False(t, isInWindowBlacklist(activeWindowInfo{Class: "google-chrome-beta", Cmd: "/usr/bin/flatpak run"}, list))
+	assert.False(t, isInWindowBlacklist(activeWindowInfo{}, list))
+}
+
+// 测试：检查黑名单规则
+func Test_checkWindowBlacklistPattern(t *testing.T) {
+	assert.NoError(t, checkWindowBlacklistPattern("class:google-chrome"))
+	assert.NoError(t, checkWindowBlacklistPattern("cmd:chrome"))
+	assert.NoError(t, checkWindowBlacklistPattern("chrome"))
+	assert.Error(t, checkWindowBlacklistPattern("class:"))
+	assert.Error(t, checkWindowBlacklistPattern(" "))
 }
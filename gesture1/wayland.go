@@ -0,0 +1,252 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/godbus/dbus/v5"
+	kwayland "github.com/linuxdeepin/go-dbus-factory/session/org.deepin.dde.kwayland1"
+)
+
+// Wayland 下无法通过 X11 获取键盘抓取状态和激活窗口，改为调用窗口管理器的 D-Bus 接口
+const (
+	kwinService   = "org.kde.KWin"
+	kwinPath      = "/KWin"
+	kwinInterface = "org.kde.KWin"
+
+	kglobalAccelService            = "org.kde.kglobalaccel"
+	kglobalAccelPath               = "/kglobalaccel"
+	kglobalAccelInterface          = "org.kde.KGlobalAccel"
+	kglobalAccelComponentInterface = "org.kde.kglobalaccel.Component"
+
+	kwayland1Service                = "org.deepin.dde.KWayland1"
+	kwayland1WindowManagerInterface = "org.deepin.dde.KWayland1.WindowManager"
+	kwayland1PlasmaWindowInterface  = "org.deepin.dde.KWayland1.PlasmaWindow"
+	kwayland1PlasmaWindowPath       = "/org/deepin/dde/KWayland1/PlasmaWindow_%v"
+)
+
+// KWin 中显示桌面的全局快捷键
+const kwinShortcutShowDesktop = "Show Desktop"
+
+// Qt::KeyboardModifier 和 Qt::Key 中的定义，KGlobalAccel 使用 Qt 的键值查找快捷键
+const (
+	qtShiftModifier = 0x02000000
+	qtCtrlModifier  = 0x04000000
+	qtAltModifier   = 0x08000000
+	qtMetaModifier  = 0x10000000
+)
+
+// 修饰键的别名已经由 parseKeySequence 转换为 keysym 名称
+var qtModifiers = map[string]int32{
+	"shift_l":   qtShiftModifier,
+	"shift_r":   qtShiftModifier,
+	"control_l": qtCtrlModifier,
+	"control_r": qtCtrlModifier,
+	"alt_l":     qtAltModifier,
+	"alt_r":     qtAltModifier,
+	"super_l":   qtMetaModifier,
+	"super_r":   qtMetaModifier,
+	"meta_l":    qtMetaModifier,
+	"meta_r":    qtMetaModifier,
+}
+
+var qtKeys = map[string]int32{
+	"escape":    0x01000000,
+	"tab":       0x01000001,
+	"backspace": 0x01000003,
+	"return":    0x01000004,
+	"enter":     0x01000005,
+	"insert":    0x01000006,
+	"delete":    0x01000007,
+	"print":     0x01000009,
+	"home":      0x01000010,
+	"end":       0x01000011,
+	"left":      0x01000012,
+	"up":        0x01000013,
+	"right":     0x01000014,
+	"down":      0x01000015,
+	"prior":     0x01000016,
+	"page_up":   0x01000016,
+	"next":      0x01000017,
+	"page_down": 0x01000017,
+	"space":     0x20,
+	"plus":      0x2b,
+	"minus":     0x2d,
+	"equal":     0x3d,
+}
+
+const qtKeyF1 = 0x01000030
+
+// parseQtKey 将 xdotool key 格式的一组按键(如 "ctrl+alt+t")转换为 Qt 的键值
+func parseQtKey(keys string) (int32, error) {
+	combos, err := parseKeySequence(keys)
+	if err != nil {
+		return 0, err
+	}
+	if len(combos) != 1 {
+		return 0, fmt.Errorf("global shortcut %q should be a single key combination", keys)
+	}
+
+	var modifiers, key int32
+	for _, name := range combos[0] {
+		lower := strings.ToLower(name)
+		if mod, ok := qtModifiers[lower]; ok {
+			modifiers |= mod
+			continue
+		}
+		if key != 0 {
+			return 0, fmt.Errorf("more than one key in %q", keys)
+		}
+		key, err = parseQtKeyName(lower)
+		if err != nil {
+			return 0, err
+		}
+	}
+	if key == 0 {
+		return 0, fmt.Errorf("no key in %q", keys)
+	}
+	return modifiers | key, nil
+}
+
+func parseQtKeyName(name string) (int32, error) {
+	if key, ok := qtKeys[name]; ok {
+		return key, nil
+	}
+	if len(name) == 1 {
+		r := rune(name[0])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return int32(unicode.ToUpper(r)), nil
+		}
+	}
+	var n int32
+	_, err := fmt.Sscanf(name, "f%d", &n)
+	if err == nil && n >= 1 && n <= 35 && name == fmt.Sprintf("f%d", n) {
+		return qtKeyF1 + n - 1, nil
+	}
+	return 0, fmt.Errorf("unsupported key %q", name)
+}
+
+// kglobalAccelComponentPath 与 KGlobalAccel 中组件对象路径的规则一致，非字母数字的字符替换为 '_'
+func kglobalAccelComponentPath(component string) dbus.ObjectPath {
+	var sb strings.Builder
+	for _, r := range component {
+		if r < 0x80 && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteByte('_')
+		}
+	}
+	return dbus.ObjectPath("/component/" + sb.String())
+}
+
+func invokeGlobalShortcut(component, shortcut string) error {
+	sessionBus, err := dbus.SessionBus()
+	if err != nil {
+		return err
+	}
+	obj := sessionBus.Object(kglobalAccelService, kglobalAccelComponentPath(component))
+	return obj.Call(kglobalAccelComponentInterface+".invokeShortcut", 0, shortcut).Err
+}
+
+// invokeGlobalShortcutByKeys 查找 keys 绑定的全局快捷键并直接调用，没有绑定时返回 false
+func invokeGlobalShortcutByKeys(keys string) (bool, error) {
+	key, err := parseQtKey(keys)
+	if err != nil {
+		return false, err
+	}
+	sessionBus, err := dbus.SessionBus()
+	if err != nil {
+		return false, err
+	}
+	// 返回值依次为组件名、快捷键名、组件显示名、快捷键显示名
+	var action []string
+	err = sessionBus.Object(kglobalAccelService, kglobalAccelPath).
+		Call(kglobalAccelInterface+".action", 0, key).Store(&action)
+	if err != nil {
+		return false, err
+	}
+	if len(action) < 2 || action[0] == "" || action[1] == "" {
+		return false, nil
+	}
+	logger.Debugf("invoke global shortcut %s/%s for %s", action[0], action[1], keys)
+	return true, invokeGlobalShortcut(action[0], action[1])
+}
+
+// isKbdAlreadyGrabbedWayland XWayland 中的窗口抓取了键盘时返回 true
+func isKbdAlreadyGrabbedWayland() bool {
+	sessionBus, err := dbus.SessionBus()
+	if err != nil {
+		logger.Warning(err)
+		return false
+	}
+	var grabbed bool
+	err = sessionBus.Object(kwinService, kwinPath).Call(kwinInterface+".xwaylandGrabed", 0).Store(&grabbed)
+	if err != nil {
+		logger.Warning("failed to get xwayland grab state:", err)
+		return false
+	}
+	return grabbed
+}
+
+// getActiveKWaylandWindow 通过 KWayland 获取激活窗口
+func getActiveKWaylandWindow() (kwayland.Window, error) {
+	sessionBus, err := dbus.SessionBus()
+	if err != nil {
+		return nil, err
+	}
+	winId, err := kwayland.NewWindowManager(sessionBus).ActiveWindow(0)
+	if err != nil {
+		return nil, err
+	}
+	return kwayland.NewWindow(sessionBus, dbus.ObjectPath(fmt.Sprintf(kwayland1PlasmaWindowPath, winId)))
+}
+
+// getActiveWindowWayland 通过 KWayland 获取激活窗口的 appId 和 pid
+func getActiveWindowWayland() (appId string, pid uint32, err error) {
+	win, err := getActiveKWaylandWindow()
+	if err != nil {
+		return "", 0, err
+	}
+	appId, err = win.AppId(0)
+	if err != nil {
+		return "", 0, err
+	}
+	pid, err = win.Pid(0)
+	if err != nil {
+		return "", 0, err
+	}
+	return appId, pid, nil
+}
+
+// getActiveWindowInfoWayland Wayland 下使用窗口的 appId 作为窗口类名
+func getActiveWindowInfoWayland() activeWindowInfo {
+	appId, pid, err := getActiveWindowWayland()
+	if err != nil {
+		logger.Warning("Failed to get current active window:", err)
+		return activeWindowInfo{}
+	}
+	info := activeWindowInfo{Class: appId}
+	if pid != 0 {
+		info.Cmd = getProcessCmdline(pid)
+	}
+	return info
+}
+
+func isActiveWindowFullscreenWayland() bool {
+	win, err := getActiveKWaylandWindow()
+	if err != nil {
+		logger.Warning("Failed to get current active window:", err)
+		return false
+	}
+	fullscreen, err := win.IsFullscreen(0)
+	if err != nil {
+		logger.Warning("Failed to get fullscreen state of current active window:", err)
+		return false
+	}
+	return fullscreen
+}
@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试：按键转换为 Qt 的键值
+func Test_parseQtKey(t *testing.T) {
+	key, err := parseQtKey("ctrl+alt+t")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(qtCtrlModifier|qtAltModifier|'T'), key)
+
+	key, err = parseQtKey("super+Down")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(qtMetaModifier|0x01000015), key)
+
+	key, err = parseQtKey("ctrl+F12")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(qtCtrlModifier|(qtKeyF1+11)), key)
+
+	_, err = parseQtKey("ctrl+a ctrl+b")
+	assert.Error(t, err)
+	_, err = parseQtKey("ctrl+a+b")
+	assert.Error(t, err)
+	_, err = parseQtKey("ctrl")
+	assert.Error(t, err)
+	_, err = parseQtKey("ctrl+fx")
+	assert.Error(t, err)
+}
+
+// 测试：KGlobalAccel 组件的对象路径
+func Test_kglobalAccelComponentPath(t *testing.T) {
+	assert.Equal(t, dbus.ObjectPath("/component/kwin"), kglobalAccelComponentPath("kwin"))
+	assert.Equal(t, dbus.ObjectPath("/component/org_kde_spectacle_desktop"),
+		kglobalAccelComponentPath("org.kde.spectacle.desktop"))
+}
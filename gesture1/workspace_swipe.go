@@ -0,0 +1,231 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"math"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// 窗口管理器中跟随手指切换工作区的接口，窗口管理器没有实现时在手势结束后切换工作区
+const wmWorkspaceSwipeInterface = "com.deepin.wm.WorkspaceSwipe"
+
+const (
+	// 切换一个工作区需要的水平移动距离，单位与 libinput 的未加速距离相同
+	workspaceSwipeDistance = 300.0
+	// 水平移动超过该距离后才开始跟随手指，避免上下滑动时切换工作区
+	workspaceSwipeStartDistance = 20.0
+	// 松开手指时进度的绝对值不小于该值才切换工作区，否则回到当前工作区
+	workspaceSwipeCommitProgress = 0.5
+)
+
+// workspaceSwipe 一次多指滑动的状态
+type workspaceSwipe struct {
+	fingers int32
+	dx, dy  float64
+	// 向左滑动时进度的符号，为 0 时该手指数的左右滑动没有绑定切换工作区
+	sign float64
+	// 已经通知窗口管理器开始切换
+	active   bool
+	progress float64
+}
+
+// workspaceSwipeSign 左右滑动分别绑定了切换到下一个和上一个工作区时返回 1，
+// 相反时返回 -1，其他情况返回 0
+func workspaceSwipeSign(left, right *gestureInfo) float64 {
+	if left == nil || right == nil || !left.isEnabled() || !right.isEnabled() ||
+		left.Action.Type != ActionTypeBuiltin || right.Action.Type != ActionTypeBuiltin {
+		return 0
+	}
+	switch {
+	case left.Action.Action == "SwitchWorkspace" && right.Action.Action == "ReverseSwitchWorkspace":
+		return 1
+	case left.Action.Action == "ReverseSwitchWorkspace" && right.Action.Action == "SwitchWorkspace":
+		return -1
+	}
+	return 0
+}
+
+// workspaceSwipeProgress 返回 [-1, 1] 范围的进度，正数表示切换到下一个工作区
+func workspaceSwipeProgress(dx, sign float64) float64 {
+	progress := -dx * sign / workspaceSwipeDistance
+	return math.Max(-1, math.Min(1, progress))
+}
+
+// shouldStartWorkspaceSwipe 以水平方向为主且移动距离足够时开始跟随手指
+func shouldStartWorkspaceSwipe(dx, dy float64) bool {
+	return math.Abs(dx) >= workspaceSwipeStartDistance && math.Abs(dx) > math.Abs(dy)
+}
+
+func (m *Manager) initWorkspaceSwipe(systemConn *dbus.Conn) {
+	for _, member := range []string{"SwipeUpdate", "SwipeEnd"} {
+		err := dbusutil.NewMatchRuleBuilder().Type("signal").
+			Path(systemGesturePath).
+			Interface(systemGestureInterface).
+			Member(member).Build().AddTo(systemConn)
+		if err != nil {
+			logger.Warning(err)
+		}
+	}
+
+	m.systemSigLoop.AddHandler(&dbusutil.SignalRule{
+		Path: systemGesturePath,
+		Name: systemGestureInterface + ".SwipeUpdate",
+	}, func(sig *dbus.Signal) {
+		var fingers int32
+		var dx, dy float64
+		err := dbus.Store(sig.Body, &fingers, &dx, &dy)
+		if err != nil {
+			logger.Warning("invalid SwipeUpdate signal:", err)
+			return
+		}
+		if m.handleThreeFingerDragUpdate(fingers, dx, dy) {
+			return
+		}
+		m.handleWorkspaceSwipeUpdate(fingers, dx, dy)
+	})
+	m.systemSigLoop.AddHandler(&dbusutil.SignalRule{
+		Path: systemGesturePath,
+		Name: systemGestureInterface + ".SwipeEnd",
+	}, func(sig *dbus.Signal) {
+		var fingers int32
+		var cancelled bool
+		err := dbus.Store(sig.Body, &fingers, &cancelled)
+		if err != nil {
+			logger.Warning("invalid SwipeEnd signal:", err)
+			return
+		}
+		handled := m.handleThreeFingerDragEnd()
+		handled = m.handleWorkspaceSwipeEnd(cancelled) || handled
+		m.workspaceSwipeMu.Lock()
+		m.swipeHandled = handled
+		m.workspaceSwipeMu.Unlock()
+	})
+}
+
+func (m *Manager) callWorkspaceSwipe(method string, flags dbus.Flags, args ...interface{}) error {
+	obj := m.service.Conn().Object(m.wm.ServiceName_(), m.wm.Path_())
+	return obj.Call(wmWorkspaceSwipeInterface+"."+method, flags, args...).Err
+}
+
+// newWorkspaceSwipe 根据当前的手势配置确定滑动方向和工作区的对应关系
+func (m *Manager) newWorkspaceSwipe(fingers int32) *workspaceSwipe {
+	left := EventInfo{Name: "swipe", Direction: "left", Fingers: fingers}
+	right := EventInfo{Name: "swipe", Direction: "right", Fingers: fingers}
+	swipe := &workspaceSwipe{fingers: fingers}
+	if m.isWmNativeGesture(left) || m.isWmNativeGesture(right) {
+		return swipe
+	}
+	m.mu.RLock()
+	swipe.sign = workspaceSwipeSign(m.Infos.Get(left), m.Infos.Get(right))
+	m.mu.RUnlock()
+	return swipe
+}
+
+// canStartWorkspaceSwipe 与 Exec 中执行动作前的检查一致
+func (m *Manager) canStartWorkspaceSwipe(fingers int32, dx float64) bool {
+	should, err := m.shouldHandleEvent(deviceTouchPad)
+	if err != nil {
+		logger.Error("shouldHandleEvent failed:", err)
+		return false
+	}
+	if !should {
+		return false
+	}
+	m.mu.RLock()
+	info := m.Infos.Get(workspaceSwipeEvent(fingers, dx))
+	m.mu.RUnlock()
+	if info == nil || !info.isEnabled() || m.shouldIgnoreGesture(info) || m.shouldSuppressGesture(info.Event) {
+		return false
+	}
+	return m.isActionAllowed(info.Action)
+}
+
+func workspaceSwipeEvent(fingers int32, dx float64) EventInfo {
+	direction := "right"
+	if dx < 0 {
+		direction = "left"
+	}
+	return EventInfo{Name: "swipe", Direction: direction, Fingers: fingers}
+}
+
+func (m *Manager) handleWorkspaceSwipeUpdate(fingers int32, dx, dy float64) {
+	m.workspaceSwipeMu.Lock()
+	defer m.workspaceSwipeMu.Unlock()
+
+	swipe := m.workspaceSwipe
+	if swipe == nil || swipe.fingers != fingers {
+		swipe = m.newWorkspaceSwipe(fingers)
+		m.workspaceSwipe = swipe
+	}
+	swipe.dx += dx
+	swipe.dy += dy
+	if swipe.sign == 0 {
+		return
+	}
+
+	if !swipe.active {
+		if !shouldStartWorkspaceSwipe(swipe.dx, swipe.dy) {
+			return
+		}
+		if !m.canStartWorkspaceSwipe(fingers, swipe.dx) {
+			swipe.sign = 0
+			return
+		}
+		err := m.callWorkspaceSwipe("BeginWorkspaceSwipe", 0)
+		if err != nil {
+			// 窗口管理器不支持时由手势结束后的 swipe 事件切换工作区
+			logger.Debug("call BeginWorkspaceSwipe failed:", err)
+			swipe.sign = 0
+			return
+		}
+		swipe.active = true
+	}
+
+	swipe.progress = workspaceSwipeProgress(swipe.dx, swipe.sign)
+	// 更新很频繁，不等待回复
+	err := m.callWorkspaceSwipe("UpdateWorkspaceSwipe", dbus.FlagNoReplyExpected, swipe.progress)
+	if err != nil {
+		logger.Warning("call UpdateWorkspaceSwipe failed:", err)
+	}
+}
+
+// handleWorkspaceSwipeEnd 在 system/gesture1 发出 swipe 事件之前调用，已经跟随手指切换工作区时返回 true
+func (m *Manager) handleWorkspaceSwipeEnd(cancelled bool) bool {
+	m.workspaceSwipeMu.Lock()
+	defer m.workspaceSwipeMu.Unlock()
+
+	swipe := m.workspaceSwipe
+	m.workspaceSwipe = nil
+	if swipe == nil || !swipe.active {
+		return false
+	}
+
+	commit := !cancelled && math.Abs(swipe.progress) >= workspaceSwipeCommitProgress
+	err := m.callWorkspaceSwipe("EndWorkspaceSwipe", 0, commit)
+	if err != nil {
+		logger.Warning("call EndWorkspaceSwipe failed:", err)
+	}
+	if commit {
+		evInfo := workspaceSwipeEvent(swipe.fingers, swipe.dx)
+		m.recordExec(evInfo)
+		m.recordStat(evInfo, err)
+	}
+	return true
+}
+
+// isSwipeHandled 已经三指拖动或跟随手指切换了工作区时，忽略随后的 swipe 事件
+func (m *Manager) isSwipeHandled(evInfo EventInfo) bool {
+	if evInfo.Name != "swipe" {
+		return false
+	}
+	m.workspaceSwipeMu.Lock()
+	defer m.workspaceSwipeMu.Unlock()
+	handled := m.swipeHandled
+	m.swipeHandled = false
+	return handled
+}
@@ -0,0 +1,62 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSwipeInfo(direction, action string) *gestureInfo {
+	return &gestureInfo{
+		Event:  EventInfo{Name: "swipe", Direction: direction, Fingers: 4},
+		Action: ActionInfo{Type: ActionTypeBuiltin, Action: action},
+	}
+}
+
+// 测试：左右滑动绑定的动作与工作区切换方向的对应关系
+func Test_workspaceSwipeSign(t *testing.T) {
+	assert.Equal(t, 1.0, workspaceSwipeSign(newSwipeInfo("left", "SwitchWorkspace"),
+		newSwipeInfo("right", "ReverseSwitchWorkspace")))
+	assert.Equal(t, -1.0, workspaceSwipeSign(newSwipeInfo("left", "ReverseSwitchWorkspace"),
+		newSwipeInfo("right", "SwitchWorkspace")))
+	assert.Equal(t, 0.0, workspaceSwipeSign(newSwipeInfo("left", "SplitWindowLeft"),
+		newSwipeInfo("right", "SplitWindowRight")))
+	assert.Equal(t, 0.0, workspaceSwipeSign(newSwipeInfo("left", "SwitchWorkspace"), nil))
+
+	right := newSwipeInfo("right", "ReverseSwitchWorkspace")
+	right.Action.Type = ActionTypeCommandline
+	assert.Equal(t, 0.0, workspaceSwipeSign(newSwipeInfo("left", "SwitchWorkspace"), right))
+
+	enabled := false
+	right = newSwipeInfo("right", "ReverseSwitchWorkspace")
+	right.Enabled = &enabled
+	assert.Equal(t, 0.0, workspaceSwipeSign(newSwipeInfo("left", "SwitchWorkspace"), right))
+}
+
+// 测试：切换工作区的进度范围为 [-1, 1]
+func Test_workspaceSwipeProgress(t *testing.T) {
+	assert.Equal(t, 0.5, workspaceSwipeProgress(-workspaceSwipeDistance/2, 1))
+	assert.Equal(t, -0.5, workspaceSwipeProgress(workspaceSwipeDistance/2, 1))
+	assert.Equal(t, 0.5, workspaceSwipeProgress(workspaceSwipeDistance/2, -1))
+	assert.Equal(t, 1.0, workspaceSwipeProgress(-workspaceSwipeDistance*2, 1))
+	assert.Equal(t, -1.0, workspaceSwipeProgress(workspaceSwipeDistance*2, 1))
+}
+
+// 测试：水平移动足够远时才开始跟随手指
+func Test_shouldStartWorkspaceSwipe(t *testing.T) {
+	assert.True(t, shouldStartWorkspaceSwipe(-workspaceSwipeStartDistance, 0))
+	assert.True(t, shouldStartWorkspaceSwipe(30, 10))
+	assert.False(t, shouldStartWorkspaceSwipe(10, 0))
+	assert.False(t, shouldStartWorkspaceSwipe(30, 40))
+}
+
+// 测试：swipe 事件对应的方向
+func Test_workspaceSwipeEvent(t *testing.T) {
+	assert.Equal(t, "left", workspaceSwipeEvent(4, -10).Direction)
+	assert.Equal(t, "right", workspaceSwipeEvent(4, 10).Direction)
+	assert.Equal(t, int32(4), workspaceSwipeEvent(4, 10).Fingers)
+}
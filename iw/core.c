@@ -208,3 +208,82 @@ out:
     nl80211_cleanup(&nlstate);
     return 0;
 }
+
+static int txpower_handler(struct nl_msg *msg, void *arg)
+{
+    struct nlattr *tb_msg[NL80211_ATTR_MAX + 1];
+    struct genlmsghdr *gnlh = nlmsg_data(nlmsg_hdr(msg));
+    int *txpower = arg;
+
+    nla_parse(tb_msg, NL80211_ATTR_MAX, genlmsg_attrdata(gnlh, 0),
+              genlmsg_attrlen(gnlh, 0), NULL);
+
+    if (tb_msg[NL80211_ATTR_WIPHY_TX_POWER_LEVEL]) {
+        *txpower = (int)nla_get_u32(tb_msg[NL80211_ATTR_WIPHY_TX_POWER_LEVEL]);
+    }
+
+    return NL_SKIP;
+}
+
+// 查询网络接口的发射功率，单位 mBm，驱动未上报时 txpower 保持不变
+int
+wireless_txpower_query(int ifindex, int *txpower)
+{
+    struct nl80211_state nlstate;
+    int err = nl80211_init(&nlstate);
+    if (err) {
+        fprintf(stderr, "Failed to init nl\n");
+        return -1;
+    }
+
+    struct nl_cb *cb = NULL;
+    struct nl_msg *msg = NULL;
+    int ret = -1;
+
+    msg = nlmsg_alloc();
+    if (!msg) {
+        fprintf(stderr, "Failed to allocate netlink message\n");
+        goto out;
+    }
+
+    cb = nl_cb_alloc(NL_CB_DEFAULT);
+    if (!cb) {
+        fprintf(stderr, "Failed to allocate netlink callbacks\n");
+        goto out;
+    }
+
+    nl_cb_set(cb, NL_CB_VALID, NL_CB_CUSTOM, txpower_handler, txpower);
+
+    genlmsg_put(msg, 0, 0, nlstate.nl80211_id, 0, 0,
+                NL80211_CMD_GET_INTERFACE, 0);
+    if (nla_put_u32(msg, NL80211_ATTR_IFINDEX, ifindex) < 0) {
+        goto out;
+    }
+
+    err = nl_send_auto_complete(nlstate.socket, msg);
+    if (err < 0) {
+        goto out;
+    }
+    err = 1;
+
+    nl_cb_err(cb, NL_CB_CUSTOM, error_handler, &err);
+    nl_cb_set(cb, NL_CB_FINISH, NL_CB_CUSTOM, finish_handler, &err);
+    nl_cb_set(cb, NL_CB_ACK, NL_CB_CUSTOM, ack_handler, &err);
+
+    while (err > 0) {
+        nl_recvmsgs(nlstate.socket, cb);
+    }
+    ret = err;
+
+out:
+    if (cb) {
+      nl_cb_put(cb);
+    }
+
+    if (msg) {
+      nlmsg_free(msg);
+    }
+
+    nl80211_cleanup(&nlstate);
+    return ret;
+}
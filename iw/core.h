@@ -6,5 +6,6 @@
 #define __IW_CORE_H__
 
 int wireless_info_query();
+int wireless_txpower_query(int ifindex, int *txpower);
 
 #endif
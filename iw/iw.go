@@ -13,6 +13,7 @@ import "C"
 import (
 	"fmt"
 	"io/ioutil"
+	"net"
 	"strings"
 	"unsafe"
 
@@ -46,6 +47,26 @@ func ListWirelessInfo() (WirelessInfos, error) {
 	return infos, nil
 }
 
+// GetTxPower 获取无线网络接口的发射功率，单位 dBm
+func GetTxPower(ifc string) (float64, error) {
+	iface, err := net.InterfaceByName(ifc)
+	if err != nil {
+		return 0, err
+	}
+	// 驱动未上报时返回错误
+	const unknown = -1 << 31
+	var txpower C.int = unknown
+	ret := C.wireless_txpower_query(C.int(iface.Index), &txpower)
+	if int(ret) != 0 {
+		return 0, fmt.Errorf("query tx power of %s failed: %d", ifc, int(ret))
+	}
+	if txpower == unknown {
+		return 0, fmt.Errorf("tx power of %s is unknown", ifc)
+	}
+	// mBm 为 0.01 dBm
+	return float64(txpower) / 100, nil
+}
+
 func (infos WirelessInfos) ListMiracastDevice() WirelessInfos {
 	var ret WirelessInfos
 	for _, info := range infos {
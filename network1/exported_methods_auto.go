@@ -14,12 +14,100 @@ func (v *Manager) GetExportedMethods() dbusutil.ExportedMethods {
 			InArgs:  []string{"uuid", "apPath", "devPath"},
 			OutArgs: []string{"connection"},
 		},
+		{
+			Name:    "ActivateAccessPointAsync",
+			Fn:      v.ActivateAccessPointAsync,
+			InArgs:  []string{"uuid", "apPath", "devPath"},
+			OutArgs: []string{"token"},
+		},
+		{
+			Name:    "ActivateAccessPointByBssid",
+			Fn:      v.ActivateAccessPointByBssid,
+			InArgs:  []string{"uuid", "apPath", "devPath"},
+			OutArgs: []string{"connection"},
+		},
 		{
 			Name:    "ActivateConnection",
 			Fn:      v.ActivateConnection,
 			InArgs:  []string{"uuid", "devPath"},
 			OutArgs: []string{"cpath"},
 		},
+		{
+			Name:    "ActivateTetheringDevice",
+			Fn:      v.ActivateTetheringDevice,
+			InArgs:  []string{"devPath"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name: "CancelWirelessDisable",
+			Fn:   v.CancelWirelessDisable,
+		},
+		{
+			Name:    "CloneConnection",
+			Fn:      v.CloneConnection,
+			InArgs:  []string{"uuid", "newName"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name:    "ConnectHiddenAccessPoint",
+			Fn:      v.ConnectHiddenAccessPoint,
+			InArgs:  []string{"ssid", "secType", "devPath"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name:    "ConnectP2PPeer",
+			Fn:      v.ConnectP2PPeer,
+			InArgs:  []string{"devPath", "peerPath"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name:    "CreateBond",
+			Fn:      v.CreateBond,
+			InArgs:  []string{"name", "mode", "slaves"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name:    "CreateBridge",
+			Fn:      v.CreateBridge,
+			InArgs:  []string{"name", "slaves"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name:    "CreateConnectionFromTemplate",
+			Fn:      v.CreateConnectionFromTemplate,
+			InArgs:  []string{"templateType", "overridesJSON"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name:    "CreateEapConnection",
+			Fn:      v.CreateEapConnection,
+			InArgs:  []string{"devPath", "ssid", "configJSON"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name:    "CreateGsmConnection",
+			Fn:      v.CreateGsmConnection,
+			InArgs:  []string{"modemPath", "apn", "username", "password"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name:    "CreatePasspointConnection",
+			Fn:      v.CreatePasspointConnection,
+			InArgs:  []string{"devPath", "apPath", "credentialJSON"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name:    "CreateVlan",
+			Fn:      v.CreateVlan,
+			InArgs:  []string{"parent", "vlanId"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name:    "CreateWireGuardConnection",
+			Fn:      v.CreateWireGuardConnection,
+			InArgs:  []string{"id", "configJSON"},
+			OutArgs: []string{"connection"},
+		},
 		{
 			Name:   "DeactivateConnection",
 			Fn:     v.DeactivateConnection,
@@ -35,6 +123,26 @@ func (v *Manager) GetExportedMethods() dbusutil.ExportedMethods {
 			Fn:     v.DeleteConnection,
 			InArgs: []string{"uuid"},
 		},
+		{
+			Name:   "DeleteVirtualConnection",
+			Fn:     v.DeleteVirtualConnection,
+			InArgs: []string{"uuid"},
+		},
+		{
+			Name:   "DisableHotspot",
+			Fn:     v.DisableHotspot,
+			InArgs: []string{"devPath"},
+		},
+		{
+			Name:   "DisableVpnKillSwitch",
+			Fn:     v.DisableVpnKillSwitch,
+			InArgs: []string{"uuid"},
+		},
+		{
+			Name:   "DisableWirelessFor",
+			Fn:     v.DisableWirelessFor,
+			InArgs: []string{"seconds"},
+		},
 		{
 			Name:   "DisableWirelessHotspotMode",
 			Fn:     v.DisableWirelessHotspotMode,
@@ -45,32 +153,182 @@ func (v *Manager) GetExportedMethods() dbusutil.ExportedMethods {
 			Fn:     v.DisconnectDevice,
 			InArgs: []string{"devPath"},
 		},
+		{
+			Name:   "DisconnectP2PPeer",
+			Fn:     v.DisconnectP2PPeer,
+			InArgs: []string{"devPath"},
+		},
 		{
 			Name:   "EnableDevice",
 			Fn:     v.EnableDevice,
 			InArgs: []string{"devPath", "enabled"},
 		},
+		{
+			Name:   "EnableHotspot",
+			Fn:     v.EnableHotspot,
+			InArgs: []string{"devPath", "ssid", "passphrase", "band"},
+		},
+		{
+			Name:   "EnableVpnKillSwitch",
+			Fn:     v.EnableVpnKillSwitch,
+			InArgs: []string{"uuid"},
+		},
 		{
 			Name:   "EnableWirelessHotspotMode",
 			Fn:     v.EnableWirelessHotspotMode,
 			InArgs: []string{"devPath"},
 		},
+		{
+			Name:   "ExportConnection",
+			Fn:     v.ExportConnection,
+			InArgs: []string{"uuid", "filePath"},
+		},
+		{
+			Name: "ForgetAllWirelessNetworks",
+			Fn:   v.ForgetAllWirelessNetworks,
+		},
+		{
+			Name:   "ForgetWirelessNetwork",
+			Fn:     v.ForgetWirelessNetwork,
+			InArgs: []string{"ssidOrUuid"},
+		},
+		{
+			Name:    "GenerateWireGuardKeyPair",
+			Fn:      v.GenerateWireGuardKeyPair,
+			OutArgs: []string{"privateKey", "publicKey"},
+		},
+		{
+			Name:    "GetAccessPointInfo",
+			Fn:      v.GetAccessPointInfo,
+			InArgs:  []string{"apPath"},
+			OutArgs: []string{"infoJSON"},
+		},
 		{
 			Name:    "GetAccessPoints",
 			Fn:      v.GetAccessPoints,
 			InArgs:  []string{"path"},
 			OutArgs: []string{"apsJSON"},
 		},
+		{
+			Name:    "GetAccessPoints2",
+			Fn:      v.GetAccessPoints2,
+			InArgs:  []string{"devPath"},
+			OutArgs: []string{"aps", "token"},
+		},
+		{
+			Name:    "GetAccessPointsDiff",
+			Fn:      v.GetAccessPointsDiff,
+			InArgs:  []string{"devPath", "sinceToken"},
+			OutArgs: []string{"changed", "removed", "token", "full"},
+		},
+		{
+			Name:    "GetAccessPointsGrouped",
+			Fn:      v.GetAccessPointsGrouped,
+			InArgs:  []string{"path"},
+			OutArgs: []string{"groupsJSON"},
+		},
 		{
 			Name:    "GetActiveConnectionInfo",
 			Fn:      v.GetActiveConnectionInfo,
 			OutArgs: []string{"acinfosJSON"},
 		},
+		{
+			Name:    "GetActiveConnectionInfo2",
+			Fn:      v.GetActiveConnectionInfo2,
+			OutArgs: []string{"acinfosJSON"},
+		},
+		{
+			Name:    "GetApnSuggestions",
+			Fn:      v.GetApnSuggestions,
+			InArgs:  []string{"modemPath"},
+			OutArgs: []string{"apnsJSON"},
+		},
 		{
 			Name:    "GetAutoProxy",
 			Fn:      v.GetAutoProxy,
 			OutArgs: []string{"proxyAuto"},
 		},
+		{
+			Name:    "GetConnectionDataUsage",
+			Fn:      v.GetConnectionDataUsage,
+			InArgs:  []string{"uuid"},
+			OutArgs: []string{"usageJSON"},
+		},
+		{
+			Name:    "GetConnectionLocalResolution",
+			Fn:      v.GetConnectionLocalResolution,
+			InArgs:  []string{"uuid"},
+			OutArgs: []string{"mdns", "llmnr"},
+		},
+		{
+			Name:    "GetConnectionProxy",
+			Fn:      v.GetConnectionProxy,
+			InArgs:  []string{"uuid"},
+			OutArgs: []string{"configJSON"},
+		},
+		{
+			Name:    "GetConnectionRoutes",
+			Fn:      v.GetConnectionRoutes,
+			InArgs:  []string{"uuid"},
+			OutArgs: []string{"ipv4RoutesJSON", "ipv6RoutesJSON"},
+		},
+		{
+			Name:    "GetConnectionSecretMode",
+			Fn:      v.GetConnectionSecretMode,
+			InArgs:  []string{"uuid"},
+			OutArgs: []string{"mode"},
+		},
+		{
+			Name:    "GetConnectionShareInfo",
+			Fn:      v.GetConnectionShareInfo,
+			InArgs:  []string{"devPath"},
+			OutArgs: []string{"infoJSON"},
+		},
+		{
+			Name:    "GetConnectionZone",
+			Fn:      v.GetConnectionZone,
+			InArgs:  []string{"uuid"},
+			OutArgs: []string{"zone"},
+		},
+		{
+			Name:    "GetConnectionsOrderedByPriority",
+			Fn:      v.GetConnectionsOrderedByPriority,
+			InArgs:  []string{"connType"},
+			OutArgs: []string{"connsJSON"},
+		},
+		{
+			Name:    "GetEffectiveRoutingOrder",
+			Fn:      v.GetEffectiveRoutingOrder,
+			OutArgs: []string{"orderJSON"},
+		},
+		{
+			Name:    "GetFirewallZones",
+			Fn:      v.GetFirewallZones,
+			OutArgs: []string{"zones"},
+		},
+		{
+			Name:    "GetHotspotInfo",
+			Fn:      v.GetHotspotInfo,
+			InArgs:  []string{"devPath"},
+			OutArgs: []string{"infoJSON"},
+		},
+		{
+			Name:    "GetModems",
+			Fn:      v.GetModems,
+			OutArgs: []string{"modemsJSON"},
+		},
+		{
+			Name:    "GetNetworkEvents",
+			Fn:      v.GetNetworkEvents,
+			InArgs:  []string{"limit"},
+			OutArgs: []string{"eventsJSON"},
+		},
+		{
+			Name:    "GetP2PPeers",
+			Fn:      v.GetP2PPeers,
+			InArgs:  []string{"devPath"},
+			OutArgs: []string{"peersJSON"},
+		},
 		{
 			Name:    "GetProxy",
 			Fn:      v.GetProxy,
@@ -98,12 +356,69 @@ func (v *Manager) GetExportedMethods() dbusutil.ExportedMethods {
 			Fn:      v.GetSupportedConnectionTypes,
 			OutArgs: []string{"types"},
 		},
+		{
+			Name:    "GetVirtualDevices",
+			Fn:      v.GetVirtualDevices,
+			OutArgs: []string{"devicesJSON"},
+		},
+		{
+			Name:    "GetVpnAutoconnect",
+			Fn:      v.GetVpnAutoconnect,
+			InArgs:  []string{"vpnUuid"},
+			OutArgs: []string{"baseUuids"},
+		},
+		{
+			Name:    "GetWiredWakeOnLan",
+			Fn:      v.GetWiredWakeOnLan,
+			InArgs:  []string{"devPath"},
+			OutArgs: []string{"flags", "password"},
+		},
+		{
+			Name:    "GetWirelessDeviceRoles",
+			Fn:      v.GetWirelessDeviceRoles,
+			OutArgs: []string{"rolesJSON"},
+		},
+		{
+			Name:    "GetWirelessDiagnostics",
+			Fn:      v.GetWirelessDiagnostics,
+			InArgs:  []string{"devPath"},
+			OutArgs: []string{"diagnosticsJSON"},
+		},
+		{
+			Name:    "GetWirelessDisabledUntil",
+			Fn:      v.GetWirelessDisabledUntil,
+			OutArgs: []string{"until"},
+		},
+		{
+			Name:    "GetWirelessSharePayload",
+			Fn:      v.GetWirelessSharePayload,
+			InArgs:  []string{"uuid"},
+			OutArgs: []string{"payload"},
+		},
+		{
+			Name:    "ImportConnection",
+			Fn:      v.ImportConnection,
+			InArgs:  []string{"filePath", "fileType"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name:    "ImportWireGuardConfig",
+			Fn:      v.ImportWireGuardConfig,
+			InArgs:  []string{"filename"},
+			OutArgs: []string{"connection"},
+		},
 		{
 			Name:    "IsDeviceEnabled",
 			Fn:      v.IsDeviceEnabled,
 			InArgs:  []string{"devPath"},
 			OutArgs: []string{"enabled"},
 		},
+		{
+			Name:    "IsVpnKillSwitchEnabled",
+			Fn:      v.IsVpnKillSwitchEnabled,
+			InArgs:  []string{"uuid"},
+			OutArgs: []string{"enabled"},
+		},
 		{
 			Name:    "IsWirelessHotspotModeEnabled",
 			Fn:      v.IsWirelessHotspotModeEnabled,
@@ -116,6 +431,22 @@ func (v *Manager) GetExportedMethods() dbusutil.ExportedMethods {
 			InArgs:  []string{"devPath"},
 			OutArgs: []string{"connections"},
 		},
+		{
+			Name:    "ListSavedWirelessNetworks",
+			Fn:      v.ListSavedWirelessNetworks,
+			OutArgs: []string{"networksJSON"},
+		},
+		{
+			Name:    "ParseWirelessSharePayload",
+			Fn:      v.ParseWirelessSharePayload,
+			InArgs:  []string{"payload"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name:   "RegisterSecretRequest",
+			Fn:     v.RegisterSecretRequest,
+			InArgs: []string{"path"},
+		},
 		{
 			Name:   "RequestIPConflictCheck",
 			Fn:     v.RequestIPConflictCheck,
@@ -125,16 +456,101 @@ func (v *Manager) GetExportedMethods() dbusutil.ExportedMethods {
 			Name: "RequestWirelessScan",
 			Fn:   v.RequestWirelessScan,
 		},
+		{
+			Name:   "RequestWirelessScanOnDevice",
+			Fn:     v.RequestWirelessScanOnDevice,
+			InArgs: []string{"devPath"},
+		},
+		{
+			Name:   "ResetConnectionFailures",
+			Fn:     v.ResetConnectionFailures,
+			InArgs: []string{"uuid"},
+		},
 		{
 			Name:   "SetAutoProxy",
 			Fn:     v.SetAutoProxy,
 			InArgs: []string{"proxyAuto"},
 		},
+		{
+			Name:   "SetAutoWifiOffOnWired",
+			Fn:     v.SetAutoWifiOffOnWired,
+			InArgs: []string{"enabled"},
+		},
+		{
+			Name:   "SetConnectionAutoconnectPriority",
+			Fn:     v.SetConnectionAutoconnectPriority,
+			InArgs: []string{"uuid", "priority"},
+		},
+		{
+			Name:   "SetConnectionBandPreference",
+			Fn:     v.SetConnectionBandPreference,
+			InArgs: []string{"uuid", "band"},
+		},
+		{
+			Name:   "SetConnectionDNS",
+			Fn:     v.SetConnectionDNS,
+			InArgs: []string{"uuid", "servers", "searchDomains", "dnsOverTls"},
+		},
+		{
+			Name:   "SetConnectionDataLimit",
+			Fn:     v.SetConnectionDataLimit,
+			InArgs: []string{"uuid", "limit", "action", "resetDay"},
+		},
+		{
+			Name:   "SetConnectionIPv6Privacy",
+			Fn:     v.SetConnectionIPv6Privacy,
+			InArgs: []string{"uuid", "ip6Privacy", "addrGenMode"},
+		},
+		{
+			Name:   "SetConnectionLocalResolution",
+			Fn:     v.SetConnectionLocalResolution,
+			InArgs: []string{"uuid", "mdns", "llmnr"},
+		},
+		{
+			Name:   "SetConnectionMetered",
+			Fn:     v.SetConnectionMetered,
+			InArgs: []string{"uuid", "metered"},
+		},
+		{
+			Name:   "SetConnectionProxy",
+			Fn:     v.SetConnectionProxy,
+			InArgs: []string{"uuid", "configJSON"},
+		},
+		{
+			Name:   "SetConnectionRouteMetric",
+			Fn:     v.SetConnectionRouteMetric,
+			InArgs: []string{"uuid", "ipv4Metric", "ipv6Metric"},
+		},
+		{
+			Name:   "SetConnectionRoutes",
+			Fn:     v.SetConnectionRoutes,
+			InArgs: []string{"uuid", "ipv4RoutesJSON", "ipv6RoutesJSON"},
+		},
+		{
+			Name:   "SetConnectionSecretMode",
+			Fn:     v.SetConnectionSecretMode,
+			InArgs: []string{"uuid", "mode"},
+		},
+		{
+			Name:   "SetConnectionZone",
+			Fn:     v.SetConnectionZone,
+			InArgs: []string{"uuid", "zone"},
+		},
 		{
 			Name:   "SetDeviceManaged",
 			Fn:     v.SetDeviceManaged,
 			InArgs: []string{"devPathOrIfc", "managed"},
 		},
+		{
+			Name:   "SetIgnoreAutoDns",
+			Fn:     v.SetIgnoreAutoDns,
+			InArgs: []string{"enabled"},
+		},
+		{
+			Name:   "SetPrimaryWirelessDevice",
+			Fn:     v.SetPrimaryWirelessDevice,
+			InArgs: []string{"devPath"},
+		},
 		{
 			Name:   "SetProxy",
 			Fn:     v.SetProxy,
@@ -155,6 +571,82 @@ func (v *Manager) GetExportedMethods() dbusutil.ExportedMethods {
 			Fn:     v.SetProxyMethod,
 			InArgs: []string{"proxyMode"},
 		},
+		{
+			Name:   "SetVirtualConnectionSlaves",
+			Fn:     v.SetVirtualConnectionSlaves,
+			InArgs: []string{"uuid", "slaves"},
+		},
+		{
+			Name:   "SetVpnAutoconnect",
+			Fn:     v.SetVpnAutoconnect,
+			InArgs: []string{"vpnUuid", "baseUuid", "enabled"},
+		},
+		{
+			Name:   "SetWifiOffOnWiredMode",
+			Fn:     v.SetWifiOffOnWiredMode,
+			InArgs: []string{"mode"},
+		},
+		{
+			Name:   "SetWiredWakeOnLan",
+			Fn:     v.SetWiredWakeOnLan,
+			InArgs: []string{"devPath", "flags", "password"},
+		},
+		{
+			Name:   "SetWirelessOffSchedules",
+			Fn:     v.SetWirelessOffSchedules,
+			InArgs: []string{"schedules"},
+		},
+		{
+			Name:   "SetWirelessScanInterval",
+			Fn:     v.SetWirelessScanInterval,
+			InArgs: []string{"interval"},
+		},
+		{
+			Name:   "ShareConnectionTo",
+			Fn:     v.ShareConnectionTo,
+			InArgs: []string{"devPath"},
+		},
+		{
+			Name:   "StartP2PFind",
+			Fn:     v.StartP2PFind,
+			InArgs: []string{"devPath", "timeout"},
+		},
+		{
+			Name:    "StartWpsPbc",
+			Fn:      v.StartWpsPbc,
+			InArgs:  []string{"devPath"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name:    "StartWpsPin",
+			Fn:      v.StartWpsPin,
+			InArgs:  []string{"devPath", "pin"},
+			OutArgs: []string{"connection"},
+		},
+		{
+			Name:   "StopP2PFind",
+			Fn:     v.StopP2PFind,
+			InArgs: []string{"devPath"},
+		},
+		{
+			Name:   "StopSharingConnection",
+			Fn:     v.StopSharingConnection,
+			InArgs: []string{"devPath"},
+		},
+		{
+			Name:   "UnlockSimPin",
+			Fn:     v.UnlockSimPin,
+			InArgs: []string{"modemPath", "pin"},
+		},
+		{
+			Name:   "UnlockSimPuk",
+			Fn:     v.UnlockSimPuk,
+			InArgs: []string{"modemPath", "puk", "newPin"},
+		},
+		{
+			Name: "UnregisterSecretRequest",
+			Fn:   v.UnregisterSecretRequest,
+		},
 	}
 }
 func (v *SecretAgent) GetExportedMethods() dbusutil.ExportedMethods {
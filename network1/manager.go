@@ -5,6 +5,7 @@
 package network
 
 import (
+	"fmt"
 	"net/http"
 	"os"
 	"os/exec"
@@ -13,6 +14,7 @@ import (
 
 	dbus "github.com/godbus/dbus/v5"
 	"github.com/linuxdeepin/dde-daemon/common/dsync"
+	"github.com/linuxdeepin/dde-daemon/common/ssidpolicy"
 	"github.com/linuxdeepin/dde-daemon/network1/nm"
 	"github.com/linuxdeepin/dde-daemon/network1/proxychains"
 	"github.com/linuxdeepin/dde-daemon/session/common"
@@ -22,6 +24,7 @@ import (
 	airplanemode "github.com/linuxdeepin/go-dbus-factory/system/org.deepin.dde.airplanemode1"
 	ipwatchd "github.com/linuxdeepin/go-dbus-factory/system/org.deepin.dde.ipwatchd1"
 	sysNetwork "github.com/linuxdeepin/go-dbus-factory/system/org.deepin.dde.network1"
+	power "github.com/linuxdeepin/go-dbus-factory/system/org.deepin.dde.power1"
 	login1 "github.com/linuxdeepin/go-dbus-factory/system/org.freedesktop.login1"
 	nmdbus "github.com/linuxdeepin/go-dbus-factory/system/org.freedesktop.networkmanager"
 	"github.com/linuxdeepin/go-lib/dbusutil"
@@ -38,11 +41,43 @@ const (
 )
 
 const (
-	daemonConfigPath                   = "org.deepin.dde.daemon"
-	networkConfigPath                  = "org.deepin.dde.daemon.network"
-	dsettingsProtalAuthEnable          = "protalAuthEnable"
-	dsettingsResetWifiOSDEnableTimeout = "resetWifiOSDEnableTimeout"
-	dsettingsDisableFailureNotify      = "disableFailureNotify"
+	daemonConfigPath                       = "org.deepin.dde.daemon"
+	networkConfigPath                      = "org.deepin.dde.daemon.network"
+	dsettingsProtalAuthEnable              = "protalAuthEnable"
+	dsettingsResetWifiOSDEnableTimeout     = "resetWifiOSDEnableTimeout"
+	dsettingsDisableFailureNotify          = "disableFailureNotify"
+	dsettingsAutoWifiOffOnWired            = "autoWifiOffOnWired"
+	dsettingsWifiOffOnWiredMode            = "wifiOffOnWiredMode"
+	dsettingsAccessPointStaleScans         = "accessPointStaleScans"
+	dsettingsAccessPointRemoveScans        = "accessPointRemoveScans"
+	dsettingsAccessPointStrengthThreshold  = "accessPointStrengthThreshold"
+	dsettingsWirelessScanInterval          = "wirelessScanInterval"
+	dsettingsWirelessScanIntervalOnBattery = "wirelessScanIntervalOnBattery"
+	dsettingsLegacyAccessPointSignals      = "legacyAccessPointSignals"
+	dsettingsRoamingPolicy                 = "roamingPolicy"
+	dsettingsRoamingStrengthThreshold      = "roamingStrengthThreshold"
+	dsettingsCapacityRoaming               = "capacityRoaming"
+	dsettingsIgnoreAutoDns                 = "ignoreAutoDns"
+	dsettingsIP6AddrGenModeMigrated        = "ip6AddrGenModeMigrated"
+	dsettingsSsidBlocklist                 = "ssidBlocklist"
+	dsettingsSsidAllowlist                 = "ssidAllowlist"
+	dsettingsConnectionQualityMonitor      = "connectionQualityMonitor"
+	dsettingsConnectionQualityInterval     = "connectionQualityInterval"
+	dsettingsTetheringAutoActivate         = "tetheringAutoActivate"
+	dsettingsConnectionRetryLimit          = "connectionRetryLimit"
+	dsettingsConnectionBackoffInterval     = "connectionBackoffInterval"
+	dsettingsConnectionBackoffMax          = "connectionBackoffMax"
+	dsettingsDefaultWirelessZone           = "defaultWirelessZone"
+	dsettingsWirelessOffState              = "wirelessOffState"
+	dsettingsWirelessOffSchedules          = "wirelessOffSchedules"
+	dsettingsOpenNetworkVpn                = "openNetworkVpn"
+	dsettingsProxyAutoDetect               = "proxyAutoDetect"
+	dsettingsConnectionDataLimits          = "connectionDataLimits"
+	dsettingsPrimaryWirelessDevice         = "primaryWirelessDevice"
+	dsettingsWeakAccessPointStrength       = "weakAccessPointStrength"
+	dsettingsShowWeakAccessPoints          = "showWeakAccessPoints"
+	dsettingsSavedProxyState               = "savedProxyState"
+	dsettingsSecretRequestAllowlist        = "secretRequestAllowlist"
 
 	networkCoreDsgConfigPath    = "/usr/share/dsg/configs/org.deepin.dde.network/org.deepin.dde.network.json"
 	networkCoreConfigPath       = "org.deepin.dde.network"
@@ -80,6 +115,56 @@ type Manager struct {
 	NetworkingEnabled bool `prop:"access:rw"` // airplane mode for NetworkManager
 	VpnEnabled        bool `prop:"access:rw"`
 
+	// update by manager_wired_policy.go
+	wiredPolicyLock       sync.Mutex
+	AutoWifiOffOnWired    bool   // 有线网络连接时自动断开无线网络
+	WifiOffOnWiredMode    string // disconnect 或 deprioritize
+	WifiSuppressedByWired bool   // 当前是否因有线网络连接而断开了无线网络或降低了其优先级
+	wifiForcedOnWired     bool   // 有线网络连接期间用户手动连接了无线网络
+	wifiSuppressMode      string // 断开或降低无线网络优先级时使用的方式
+
+	// update by manager_dns.go
+	dnsLock       sync.Mutex
+	IgnoreAutoDns bool // 设置了 DNS 服务器的连接忽略 DHCP 获取的 DNS
+
+	// update by manager_conn_quality.go
+	connQualityLock     sync.Mutex
+	connQualityEnabled  bool
+	connQualityInterval time.Duration
+	connQualityTimer    *time.Timer
+	// 主连接的质量: unknown, good, degraded, broken
+	ConnectionQuality string
+
+	// update by manager_vpn_policy.go
+	vpnKillSwitchLock sync.Mutex
+	vpnKillSwitchUuid string // 阻断网络的 VPN 连接，为空时未阻断
+
+	// update by manager_connection_proxy.go
+	connProxyLock sync.Mutex
+	globalProxy   *proxySnapshot // 应用连接代理前的全局代理，主连接未单独设置代理时恢复
+	appliedProxy  *proxySnapshot // 应用的连接代理，用于判断用户是否又修改了代理
+
+	// update by manager_secret_request.go
+	secretRequestLock  sync.Mutex
+	secretRequestAgent *secretRequestAgent // 注册了 SecretRequest 的客户端，为空时使用密码对话框
+
+	// update by manager_data_limit.go
+	dataLimitLock    sync.Mutex
+	dataLimits       map[string]*connectionDataLimit // key 为连接的 uuid
+	dataLimitsSaved  string                          // 最近一次保存到 dconfig 的内容
+	dataLimitBlocked map[string]bool                 // 因流量达到上限关闭了自动连接的连接
+	dataCounters     map[dataCounterKey]uint64       // 上一次统计时网卡的收发字节数
+	dataLimitTimer   *time.Timer
+
+	// update by manager_wireless_role.go
+	wirelessRoleLock      sync.Mutex
+	primaryWirelessDevice string // 主无线网卡的 MAC 地址或接口名，为空时使用第一个网卡
+	wirelessRoles         map[dbus.ObjectPath]string
+
+	// update by manager_wpad.go
+	proxyAutoDetect  bool
+	proxyPrimaryPath dbus.ObjectPath
+
 	// hidden properties
 	wirelessEnabled bool
 	wwanEnabled     bool
@@ -94,7 +179,39 @@ type Manager struct {
 	Devices     string // array of device objects and marshaled by json
 
 	accessPointsLock sync.Mutex
-	accessPoints     map[dbus.ObjectPath][]*accessPoint
+	// 每个设备按发现顺序排列的热点列表
+	accessPoints map[dbus.ObjectPath][]*accessPoint
+	// 按热点路径索引的热点，用于快速查找
+	accessPointsByPath map[dbus.ObjectPath]*accessPoint
+	// 热点连续未被扫描到 apStaleScans 次后标记为 Stale，连续 apRemoveScans 次后移除，受 accessPointsLock 保护
+	apStaleScans  uint32
+	apRemoveScans uint32
+	// 热点列表的版本，用于 GetAccessPointsDiff
+	apRevisions accessPointRevisions
+	// update by manager_accesspoint_batch.go
+	apBatchLock     sync.Mutex
+	apBatches       map[dbus.ObjectPath]*accessPointsBatch
+	apBatchTimer    *time.Timer
+	legacyApSignals bool
+	// update by manager_scan_policy.go
+	scanPolicyLock        sync.Mutex
+	scanInterval          time.Duration
+	scanIntervalOnBattery time.Duration
+	onBattery             bool
+	scanTimer             *time.Timer
+	sysPower              power.Power
+
+	// 热点信号强度变化超过该值时才更新，避免密集环境下信号频繁发送
+	AccessPointStrengthThreshold uint32 `prop:"access:rw"`
+	// 同一网络不同频段热点之间的自动切换策略: off, prefer-5g, aggressive
+	RoamingPolicy string `prop:"access:rw"`
+	// prefer-5g 策略下当前 5G 热点信号强度高于该值时不切换
+	RoamingStrengthThreshold uint32 `prop:"access:rw"`
+	// 信号相近时是否切换到容量明显更大的热点
+	capacityRoaming bool
+	// 是否在热点列表中显示信号强度低于 weakApStrength 的热点
+	ShowWeakAccessPoints bool `prop:"access:rw"`
+	weakApStrength       uint32
 
 	// update by manager_connections.go
 	connectionsLock sync.Mutex
@@ -115,10 +232,59 @@ type Manager struct {
 
 	portalLastDetectionTime time.Time
 
+	// update by manager_ssid_policy.go
+	ssidPolicyLock sync.Mutex
+	ssidPolicy     ssidpolicy.Policy
+
+	// update by manager_wps.go
+	wpsLock     sync.Mutex
+	wpsSessions map[dbus.ObjectPath]*wpsSession
+
+	// update by manager_conn_backoff.go
+	connFailuresLock    sync.Mutex
+	connFailures        map[string]*connFailureRecord
+	connRetryLimit      uint32
+	connBackoffInterval time.Duration
+	connBackoffMax      time.Duration
+
+	// update by manager_event_journal.go
+	eventJournal *networkEventJournal
+
+	// update by manager_firewall_zone.go
+	firewallZoneLock sync.Mutex
+	// 新建无线连接的默认防火墙区域，为空时使用 firewalld 的默认区域
+	defaultWirelessZone string
+
+	// update by manager_wireless_schedule.go
+	wirelessOffLock       sync.Mutex
+	wirelessOffTimer      *time.Timer
+	wirelessScheduleTimer *time.Timer
+	wirelessOffSchedules  []wirelessOffSchedule
+
+	// update by manager_insecure_network.go
+	openNetworkVpnLock sync.Mutex
+	// 连接开放无线网络时自动激活的 VPN 连接的 uuid，为空时不自动激活
+	openNetworkVpn string
+
+	// update by manager_tethering.go
+	tetheringLock         sync.Mutex
+	tetheringDevices      map[dbus.ObjectPath]*tetheringDevice
+	tetheringAutoActivate bool
+	TetheringDevices      string // 手机通过 USB 或蓝牙共享网络的设备，marshaled by json
+
+	// update by manager_ap_activation.go
+	apActivationsLock sync.Mutex
+	apActivations     map[dbus.ObjectPath]*apActivation
+
 	WirelessAccessPoints    string `prop:"access:r"` //用于读取AP
 	debugChangeAPBand       string //调用接口切换ap频段
+	debugAPChannelLock      sync.Mutex
+	debugAPChannelEnabled   bool
+	checkAPStrengthLock     sync.Mutex
 	checkAPStrengthTimer    *time.Timer
-	protalAuthBrowserOpened bool // PORTAL认证中状态
+	activeApSsidLock        sync.Mutex
+	activeApSsid            map[dbus.ObjectPath][]byte // 缓存各无线设备最近一次读到的热点ssid
+	protalAuthBrowserOpened bool                       // PORTAL认证中状态
 
 	acinfosJSON string
 
@@ -128,6 +294,7 @@ type Manager struct {
 	connectionSettingsLock sync.Mutex
 
 	// dsg config : org.deepin.dde.daemon.network
+	networkConfig             configManager.Manager
 	protalAuthEnable          bool
 	wifiOSDEnable             bool
 	disableFailureNotify      bool
@@ -144,6 +311,13 @@ type Manager struct {
 		AccessPointAdded, AccessPointRemoved, AccessPointPropertiesChanged struct {
 			devPath, apJSON string
 		}
+		// 合并一段时间内的热点新增、变化和移除
+		AccessPointsChanged struct {
+			devPath string
+			added   []map[string]dbus.Variant
+			changed []map[string]dbus.Variant
+			removed []dbus.ObjectPath
+		}
 		DeviceEnabled struct {
 			devPath string
 			enabled bool
@@ -157,6 +331,94 @@ type Manager struct {
 		ProxyMethodChanged struct {
 			method string
 		}
+		// 会话代理被主连接、WPAD 或恢复的全局代理修改时发送，source 为 global、connection 或 wpad
+		ProxyChanged struct {
+			method string
+			source string
+		}
+		// rolesJSON 为无线网卡路径到角色的映射
+		WirelessDeviceRolesChanged struct {
+			rolesJSON string
+		}
+		// 连接在计费周期内的流量首次达到上限时发送
+		DataLimitReached struct {
+			uuid   string
+			usage  uint64
+			limit  uint64
+			action string
+		}
+		// 检测到需要认证的网络时发送，url 为认证页面地址
+		PortalDetected struct {
+			url string
+		}
+		HotspotStateChanged struct {
+			devPath string
+			enabled bool
+		}
+		HiddenAccessPointConnectFailed struct {
+			ssid    string
+			devPath string
+			reason  uint32
+		}
+		// reason 为设备状态变化的原因，message 为可以直接展示的错误信息
+		ConnectionFailed struct {
+			uuid    string
+			devPath string
+			reason  uint32
+			message string
+		}
+		// peerJSON 为 json 格式的 P2P 设备信息
+		P2PPeerAdded struct {
+			devPath  string
+			peerJSON string
+		}
+		P2PPeerRemoved struct {
+			devPath  string
+			peerPath string
+		}
+		// state 为 started, connected, failed 或 timeout
+		WpsStateChanged struct {
+			devPath string
+			state   string
+			reason  uint32
+		}
+		// lastScan 为 NetworkManager 的 LastScan 属性，即扫描完成时的 CLOCK_BOOTTIME 毫秒数
+		WirelessScanFinished struct {
+			devPath  string
+			lastScan int64
+		}
+		// VPN 意外断开阻断网络时 engaged 为 true，恢复网络时为 false
+		VpnKillSwitchStateChanged struct {
+			uuid    string
+			engaged bool
+		}
+		// rtt 为网关的平均延迟(毫秒)，loss 为丢包率(百分比)
+		ConnectionQualityChanged struct {
+			quality string
+			rtt     uint32
+			loss    uint32
+		}
+		// tetheringType 为 usb 或 bluetooth
+		TetheringDeviceAdded struct {
+			devPath       string
+			tetheringType string
+		}
+		// 连接连续失败 failures 次后不再自动重试
+		ConnectionGivenUp struct {
+			uuid     string
+			devPath  string
+			failures uint32
+		}
+		// stage 为 authenticating, getting-ip, done 或 failed
+		ActivationProgress struct {
+			token string
+			stage string
+		}
+		// 连接到未加密的无线网络时发送
+		InsecureNetworkActivated struct {
+			ssid    string
+			devPath string
+		}
 	}
 }
 
@@ -187,6 +449,14 @@ func (m *Manager) init() {
 	}
 
 	m.multiVpn = make(map[string]bool)
+	m.wpsSessions = make(map[dbus.ObjectPath]*wpsSession)
+	m.apActivations = make(map[dbus.ObjectPath]*apActivation)
+	m.tetheringDevices = make(map[dbus.ObjectPath]*tetheringDevice)
+	m.connFailures = make(map[string]*connFailureRecord)
+	m.eventJournal = newNetworkEventJournal(networkEventJournalSize)
+	m.setConnectionBackoffPolicy(defaultConnectionRetryLimit, defaultConnectionBackoffInterval,
+		defaultConnectionBackoffMax)
+	m.setDebugAPChannelEnabled(os.Getenv(debugAPChannelEnv) == "1")
 
 	sessionBus := m.service.Conn()
 	m.sessionSigLoop = dbusutil.NewSignalLoop(sessionBus, 10)
@@ -245,11 +515,24 @@ func (m *Manager) init() {
 
 	// 初始化配置
 	m.resetWifiOSDEnableTimeout = 300
+	m.apStaleScans = defaultAccessPointStaleScans
+	m.apRemoveScans = defaultAccessPointRemoveScans
+	m.AccessPointStrengthThreshold = defaultAccessPointStrengthThreshold
+	m.scanInterval = defaultWirelessScanInterval
+	m.scanIntervalOnBattery = defaultWirelessScanIntervalOnBattery
+	m.legacyApSignals = true
+	m.RoamingPolicy = defaultRoamingPolicy
+	m.RoamingStrengthThreshold = defaultRoamingStrengthThreshold
+	m.ShowWeakAccessPoints = true
+	m.weakApStrength = defaultWeakAccessPointStrength
+	m.WifiOffOnWiredMode = wifiOffOnWiredModeDisconnect
+	m.ConnectionQuality = connectionQualityUnknown
 	ds := configManager.NewConfigManager(m.sysSigLoop.Conn())
 	configManagerPath, err := ds.AcquireManager(0, daemonConfigPath, networkConfigPath, "")
 	if err == nil {
 		networkConfigManager, err := configManager.NewManager(m.sysSigLoop.Conn(), configManagerPath)
 		if err == nil {
+			m.networkConfig = networkConfigManager
 			getProtalAuthEnable := func() {
 				v, err := networkConfigManager.Value(0, dsettingsProtalAuthEnable)
 				if err != nil {
@@ -284,9 +567,347 @@ func (m *Manager) init() {
 				}
 			}
 
+			getAutoWifiOffOnWired := func() {
+				v, err := networkConfigManager.Value(0, dsettingsAutoWifiOffOnWired)
+				if err != nil {
+					logger.Warning(err)
+					return
+				}
+				enabled, ok := v.Value().(bool)
+				if !ok {
+					logger.Warning("type is wrong!")
+					return
+				}
+				m.setAutoWifiOffOnWired(enabled)
+			}
+
+			getWifiOffOnWiredMode := func() {
+				v, err := networkConfigManager.Value(0, dsettingsWifiOffOnWiredMode)
+				if err != nil {
+					logger.Warning(err)
+					return
+				}
+				mode, ok := v.Value().(string)
+				if !ok || !isValidWifiOffOnWiredMode(mode) {
+					logger.Warning("invalid wifi off on wired mode:", v.Value())
+					return
+				}
+				m.setWifiOffOnWiredMode(mode)
+			}
+
+			getAccessPointMissedScans := func() {
+				v, err := networkConfigManager.Value(0, dsettingsAccessPointStaleScans)
+				if err != nil {
+					logger.Warning(err)
+				} else if staleScans, ok := variantToUint32(v); ok {
+					m.accessPointsLock.Lock()
+					m.apStaleScans = staleScans
+					m.accessPointsLock.Unlock()
+				}
+				v, err = networkConfigManager.Value(0, dsettingsAccessPointRemoveScans)
+				if err != nil {
+					logger.Warning(err)
+				} else if removeScans, ok := variantToUint32(v); ok {
+					m.accessPointsLock.Lock()
+					m.apRemoveScans = removeScans
+					m.accessPointsLock.Unlock()
+				}
+			}
+
+			getAccessPointStrengthThreshold := func() {
+				v, err := networkConfigManager.Value(0, dsettingsAccessPointStrengthThreshold)
+				if err != nil {
+					logger.Warning(err)
+					return
+				}
+				threshold, ok := variantToUint32(v)
+				if !ok {
+					logger.Warning("type is wrong!")
+					return
+				}
+				m.PropsMu.Lock()
+				m.setPropAccessPointStrengthThreshold(threshold)
+				m.PropsMu.Unlock()
+			}
+
+			getWirelessScanIntervals := func() {
+				interval, intervalOnBattery := defaultWirelessScanInterval, defaultWirelessScanIntervalOnBattery
+				v, err := networkConfigManager.Value(0, dsettingsWirelessScanInterval)
+				if err != nil {
+					logger.Warning(err)
+				} else if seconds, ok := variantToUint32(v); ok {
+					interval = time.Duration(seconds) * time.Second
+				}
+				v, err = networkConfigManager.Value(0, dsettingsWirelessScanIntervalOnBattery)
+				if err != nil {
+					logger.Warning(err)
+				} else if seconds, ok := variantToUint32(v); ok {
+					intervalOnBattery = time.Duration(seconds) * time.Second
+				}
+				m.setWirelessScanIntervals(interval, intervalOnBattery)
+			}
+
+			getLegacyAccessPointSignals := func() {
+				v, err := networkConfigManager.Value(0, dsettingsLegacyAccessPointSignals)
+				if err != nil {
+					logger.Warning(err)
+					return
+				}
+				enabled, ok := v.Value().(bool)
+				if !ok {
+					logger.Warning("type is wrong!")
+					return
+				}
+				m.setLegacyAccessPointSignalsEnabled(enabled)
+			}
+
+			getRoamingPolicy := func() {
+				v, err := networkConfigManager.Value(0, dsettingsRoamingPolicy)
+				if err != nil {
+					logger.Warning(err)
+				} else if policy, ok := v.Value().(string); ok && isValidRoamingPolicy(policy) {
+					m.PropsMu.Lock()
+					m.setPropRoamingPolicy(policy)
+					m.PropsMu.Unlock()
+				} else {
+					logger.Warning("invalid roaming policy:", v.Value())
+				}
+				v, err = networkConfigManager.Value(0, dsettingsRoamingStrengthThreshold)
+				if err != nil {
+					logger.Warning(err)
+				} else if threshold, ok := variantToUint32(v); ok {
+					m.PropsMu.Lock()
+					m.setPropRoamingStrengthThreshold(threshold)
+					m.PropsMu.Unlock()
+				}
+				v, err = networkConfigManager.Value(0, dsettingsCapacityRoaming)
+				if err != nil {
+					logger.Warning(err)
+				} else if enabled, ok := v.Value().(bool); ok {
+					m.PropsMu.Lock()
+					m.capacityRoaming = enabled
+					m.PropsMu.Unlock()
+				}
+			}
+
+			getIgnoreAutoDns := func() {
+				v, err := networkConfigManager.Value(0, dsettingsIgnoreAutoDns)
+				if err != nil {
+					logger.Warning(err)
+					return
+				}
+				enabled, ok := v.Value().(bool)
+				if !ok {
+					logger.Warning("type is wrong!")
+					return
+				}
+				m.setIgnoreAutoDns(enabled)
+			}
+
+			getSsidPolicy := func() {
+				var lists [2][]string
+				for i, key := range []string{dsettingsSsidBlocklist, dsettingsSsidAllowlist} {
+					v, err := networkConfigManager.Value(0, key)
+					if err != nil {
+						logger.Warning(err)
+						continue
+					}
+					items, ok := v.Value().([]dbus.Variant)
+					if !ok {
+						logger.Warning("type is wrong!")
+						continue
+					}
+					for _, item := range items {
+						if pattern, ok := item.Value().(string); ok && pattern != "" {
+							lists[i] = append(lists[i], pattern)
+						}
+					}
+				}
+				m.setSsidPolicy(lists[0], lists[1])
+			}
+
+			getConnectionQualityMonitor := func() {
+				var enabled bool
+				interval := defaultConnectionQualityInterval
+				v, err := networkConfigManager.Value(0, dsettingsConnectionQualityMonitor)
+				if err != nil {
+					logger.Warning(err)
+				} else if value, ok := v.Value().(bool); ok {
+					enabled = value
+				} else {
+					logger.Warning("type is wrong!")
+				}
+				v, err = networkConfigManager.Value(0, dsettingsConnectionQualityInterval)
+				if err != nil {
+					logger.Warning(err)
+				} else if seconds, ok := variantToUint32(v); ok {
+					interval = time.Duration(seconds) * time.Second
+				}
+				m.setConnectionQualityMonitor(enabled, interval)
+			}
+
+			getTetheringAutoActivate := func() {
+				v, err := networkConfigManager.Value(0, dsettingsTetheringAutoActivate)
+				if err != nil {
+					logger.Warning(err)
+					return
+				}
+				enabled, ok := v.Value().(bool)
+				if !ok {
+					logger.Warning("type is wrong!")
+					return
+				}
+				m.setTetheringAutoActivate(enabled)
+			}
+
+			getConnectionBackoffPolicy := func() {
+				retryLimit := uint32(defaultConnectionRetryLimit)
+				interval := defaultConnectionBackoffInterval
+				max := defaultConnectionBackoffMax
+				v, err := networkConfigManager.Value(0, dsettingsConnectionRetryLimit)
+				if err != nil {
+					logger.Warning(err)
+				} else if value, ok := variantToUint32(v); ok {
+					retryLimit = value
+				}
+				v, err = networkConfigManager.Value(0, dsettingsConnectionBackoffInterval)
+				if err != nil {
+					logger.Warning(err)
+				} else if seconds, ok := variantToUint32(v); ok {
+					interval = time.Duration(seconds) * time.Second
+				}
+				v, err = networkConfigManager.Value(0, dsettingsConnectionBackoffMax)
+				if err != nil {
+					logger.Warning(err)
+				} else if seconds, ok := variantToUint32(v); ok {
+					max = time.Duration(seconds) * time.Second
+				}
+				m.setConnectionBackoffPolicy(retryLimit, interval, max)
+			}
+
+			getDefaultWirelessZone := func() {
+				v, err := networkConfigManager.Value(0, dsettingsDefaultWirelessZone)
+				if err != nil {
+					logger.Warning(err)
+					return
+				}
+				zone, ok := v.Value().(string)
+				if !ok {
+					logger.Warning("type is wrong!")
+					return
+				}
+				m.setDefaultWirelessZone(zone)
+			}
+
+			getWirelessOffSchedules := func() {
+				v, err := networkConfigManager.Value(0, dsettingsWirelessOffSchedules)
+				if err != nil {
+					logger.Warning(err)
+					return
+				}
+				items, ok := v.Value().([]dbus.Variant)
+				if !ok {
+					logger.Warning("type is wrong!")
+					return
+				}
+				var list []string
+				for _, item := range items {
+					if str, ok := item.Value().(string); ok && str != "" {
+						list = append(list, str)
+					}
+				}
+				schedules, err := parseWirelessOffSchedules(list)
+				if err != nil {
+					logger.Warning(err)
+					return
+				}
+				m.setWirelessOffSchedules(schedules)
+			}
+
+			getOpenNetworkVpn := func() {
+				v, err := networkConfigManager.Value(0, dsettingsOpenNetworkVpn)
+				if err != nil {
+					logger.Warning(err)
+					return
+				}
+				uuid, ok := v.Value().(string)
+				if !ok {
+					logger.Warning("type is wrong!")
+					return
+				}
+				m.setOpenNetworkVpn(uuid)
+			}
+
+			getProxyAutoDetect := func() {
+				v, err := networkConfigManager.Value(0, dsettingsProxyAutoDetect)
+				if err != nil {
+					logger.Warning(err)
+					return
+				}
+				enabled, ok := v.Value().(bool)
+				if !ok {
+					logger.Warning("type is wrong!")
+					return
+				}
+				m.setProxyAutoDetect(enabled)
+			}
+
+			getPrimaryWirelessDevice := func() {
+				v, err := networkConfigManager.Value(0, dsettingsPrimaryWirelessDevice)
+				if err != nil {
+					logger.Warning(err)
+					return
+				}
+				primary, ok := v.Value().(string)
+				if !ok {
+					logger.Warning("type is wrong!")
+					return
+				}
+				m.setPrimaryWirelessDevice(primary)
+			}
+
+			getWeakAccessPointPolicy := func() {
+				weakStrength := uint32(defaultWeakAccessPointStrength)
+				v, err := networkConfigManager.Value(0, dsettingsWeakAccessPointStrength)
+				if err != nil {
+					logger.Warning(err)
+				} else if value, ok := variantToUint32(v); ok {
+					weakStrength = value
+				}
+				showWeak := true
+				v, err = networkConfigManager.Value(0, dsettingsShowWeakAccessPoints)
+				if err != nil {
+					logger.Warning(err)
+				} else if value, ok := v.Value().(bool); ok {
+					showWeak = value
+				}
+				m.PropsMu.Lock()
+				m.weakApStrength = weakStrength
+				m.setPropShowWeakAccessPoints(showWeak)
+				m.PropsMu.Unlock()
+			}
+
 			getProtalAuthEnable()
 			getResetWifiOSDEnableTimeout()
 			getDisableFailureNotify()
+			getAutoWifiOffOnWired()
+			getWifiOffOnWiredMode()
+			getAccessPointMissedScans()
+			getAccessPointStrengthThreshold()
+			getWirelessScanIntervals()
+			getLegacyAccessPointSignals()
+			getRoamingPolicy()
+			getIgnoreAutoDns()
+			getSsidPolicy()
+			getConnectionQualityMonitor()
+			getTetheringAutoActivate()
+			getConnectionBackoffPolicy()
+			getDefaultWirelessZone()
+			getWirelessOffSchedules()
+			getOpenNetworkVpn()
+			getProxyAutoDetect()
+			getPrimaryWirelessDevice()
+			getWeakAccessPointPolicy()
 
 			networkConfigManager.InitSignalExt(m.sysSigLoop, true)
 			_, err = networkConfigManager.ConnectValueChanged(func(key string) {
@@ -296,6 +917,47 @@ func (m *Manager) init() {
 					getResetWifiOSDEnableTimeout()
 				} else if key == dsettingsDisableFailureNotify {
 					getDisableFailureNotify()
+				} else if key == dsettingsAutoWifiOffOnWired {
+					getAutoWifiOffOnWired()
+				} else if key == dsettingsWifiOffOnWiredMode {
+					getWifiOffOnWiredMode()
+				} else if key == dsettingsAccessPointStaleScans || key == dsettingsAccessPointRemoveScans {
+					getAccessPointMissedScans()
+				} else if key == dsettingsAccessPointStrengthThreshold {
+					getAccessPointStrengthThreshold()
+				} else if key == dsettingsWirelessScanInterval || key == dsettingsWirelessScanIntervalOnBattery {
+					getWirelessScanIntervals()
+				} else if key == dsettingsLegacyAccessPointSignals {
+					getLegacyAccessPointSignals()
+				} else if key == dsettingsRoamingPolicy || key == dsettingsRoamingStrengthThreshold ||
+					key == dsettingsCapacityRoaming {
+					getRoamingPolicy()
+				} else if key == dsettingsIgnoreAutoDns {
+					getIgnoreAutoDns()
+				} else if key == dsettingsSsidBlocklist || key == dsettingsSsidAllowlist {
+					getSsidPolicy()
+					go m.applySsidPolicy()
+				} else if key == dsettingsConnectionQualityMonitor || key == dsettingsConnectionQualityInterval {
+					getConnectionQualityMonitor()
+				} else if key == dsettingsTetheringAutoActivate {
+					getTetheringAutoActivate()
+				} else if key == dsettingsConnectionRetryLimit || key == dsettingsConnectionBackoffInterval ||
+					key == dsettingsConnectionBackoffMax {
+					getConnectionBackoffPolicy()
+				} else if key == dsettingsDefaultWirelessZone {
+					getDefaultWirelessZone()
+				} else if key == dsettingsWirelessOffSchedules {
+					getWirelessOffSchedules()
+				} else if key == dsettingsOpenNetworkVpn {
+					getOpenNetworkVpn()
+				} else if key == dsettingsProxyAutoDetect {
+					getProxyAutoDetect()
+					go m.applyConnectionProxy(nmGetPrimaryConnection())
+				} else if key == dsettingsPrimaryWirelessDevice {
+					getPrimaryWirelessDevice()
+				} else if key == dsettingsWeakAccessPointStrength || key == dsettingsShowWeakAccessPoints {
+					getWeakAccessPointPolicy()
+					go m.applyWeakAccessPointPolicy()
 				}
 			})
 			if err != nil {
@@ -329,10 +991,17 @@ func (m *Manager) init() {
 	m.initConnectionManage()
 	m.initDeviceManage()
 	m.initActiveConnectionManage()
+	m.initWifiP2PManage()
+	m.initTetheringManage()
 	m.initNMObjManager(systemBus)
 	m.stateHandler = newStateHandler(m.sysSigLoop, m)
 	m.initSysNetwork(systemBus)
 	m.initIPConflictManager(systemBus)
+	m.initWirelessScanPolicy(systemBus)
+	go m.migrateIP6AddrGenMode()
+	m.initWirelessOffState()
+	m.initDataLimits()
+	go m.reconcileVpnKillSwitch()
 
 	// monitor enable state
 	m.airplane.InitSignalExt(m.sysSigLoop, true)
@@ -444,7 +1113,7 @@ func (m *Manager) init() {
 	// update property Connectivity
 	_ = nmManager.Connectivity().ConnectChanged(func(hasValue bool, value uint32) {
 		logger.Debug("connectivity state changed ", hasValue, value)
-		if hasValue && value == nm.NM_CONNECTIVITY_PORTAL && m.protalAuthEnable && !m.enableLocalConnectivity {
+		if hasValue && value == nm.NM_CONNECTIVITY_PORTAL && !m.enableLocalConnectivity {
 			go m.doPortalAuthentication()
 		}
 		m.setPropConnectivity(value)
@@ -455,6 +1124,20 @@ func (m *Manager) init() {
 		logger.Warningf("get connectivity failed, err: %v", err)
 	}
 	m.setPropConnectivity(connectivity)
+
+	// 主连接变化时切换到该连接的代理
+	err = nmManager.PrimaryConnection().ConnectChanged(func(hasValue bool, value dbus.ObjectPath) {
+		if !hasValue {
+			return
+		}
+		m.applyConnectionProxy(value)
+	})
+	if err != nil {
+		logger.Warning(err)
+	}
+	m.initProxyState()
+	m.applyConnectionProxy(nmGetPrimaryConnection())
+
 	go func() {
 		time.Sleep(3 * time.Second)
 		m.checkConnectivity()
@@ -567,10 +1250,13 @@ func (m *Manager) destroy() {
 	m.setPropNetworkingEnabled(false)
 	m.updatePropState()
 
-	if m.checkAPStrengthTimer != nil {
-		m.checkAPStrengthTimer.Stop()
-		m.checkAPStrengthTimer = nil
-	}
+	m.stopCheckAPStrength()
+	m.destroyWirelessScanPolicy()
+	m.destroyConnectionQualityMonitor()
+	m.destroyConnectionFailures()
+	m.destroyWirelessOff()
+	m.destroyDataLimits()
+	m.stopAccessPointsChanged()
 }
 
 func watchNetworkManagerRestart(m *Manager) {
@@ -671,7 +1357,25 @@ func (m *Manager) initNMObjManager(systemBus *dbus.Conn) {
 	}
 }
 
+// doPortalAuthentication 总是发送 PortalDetected 信号，开启认证时再打开认证页面
 func (m *Manager) doPortalAuthentication() {
+	portal, err := detectPortalUrl()
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	logger.Debugf("portal addr is %v", portal)
+	err = m.service.Emit(m, "PortalDetected", portal)
+	if err != nil {
+		logger.Warning("failed to emit signal:", err)
+	}
+	if !m.protalAuthEnable {
+		return
+	}
+	m.openPortalAuthentication(portal)
+}
+
+func (m *Manager) openPortalAuthentication(portal string) {
 	err := exec.Command("pgrep", "startdde").Run()
 	if err != nil {
 		return
@@ -683,6 +1387,17 @@ func (m *Manager) doPortalAuthentication() {
 		return
 	}
 
+	err = exec.Command(`xdg-open`, portal).Run()
+	if err != nil {
+		logger.Warningf("xdg open windows failed, err: %v", err)
+		return
+	}
+	m.portalLastDetectionTime = time.Now()
+	m.protalAuthBrowserOpened = true
+}
+
+// detectPortalUrl 访问探测地址，从重定向中获取认证页面地址
+func detectPortalUrl() (string, error) {
 	// http client to get url
 	client := &http.Client{
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
@@ -693,23 +1408,14 @@ func (m *Manager) doPortalAuthentication() {
 	detectUrl := "http://detectportal.deepin.com"
 	res, err := client.Get(detectUrl)
 	if err != nil {
-		logger.Warningf("get remote http failed ,err: %v", err)
-		return
+		return "", fmt.Errorf("get remote http failed, err: %v", err)
 	}
 	// get portal addr from response
 	portal, err := getRedirectFromResponse(res, detectUrl)
 	if err != nil {
-		logger.Warningf("get redirect hosts failed, err: %v", err)
-		return
-	}
-	logger.Debugf("portal addr is %v", portal)
-	err = exec.Command(`xdg-open`, portal).Run()
-	if err != nil {
-		logger.Warningf("xdg open windows failed, err: %v", err)
-		return
+		return "", fmt.Errorf("get redirect hosts failed, err: %v", err)
 	}
-	m.portalLastDetectionTime = time.Now()
-	m.protalAuthBrowserOpened = true
+	return portal, nil
 }
 
 // auto connect vpn
@@ -817,7 +1523,8 @@ func (m *Manager) checkConnectivity() {
 		logger.Warning(err)
 		return
 	}
-	if connectivity == nm.NM_CONNECTIVITY_PORTAL && m.protalAuthEnable && !m.enableLocalConnectivity {
+	m.setPropConnectivity(connectivity)
+	if connectivity == nm.NM_CONNECTIVITY_PORTAL && !m.enableLocalConnectivity {
 		m.doPortalAuthentication()
 	}
 }
@@ -5,8 +5,10 @@
 package network
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	dbus "github.com/godbus/dbus/v5"
@@ -24,9 +26,27 @@ const (
 	apSecPsk
 	apSecEap
 	apSecSae
+	apSecOwe
 )
 const scanWifiDelayTime = 10 * time.Second
-const channelAutoChangeThreshold = 65
+
+const (
+	defaultAccessPointStaleScans  = 1
+	defaultAccessPointRemoveScans = 3
+
+	defaultAccessPointStrengthThreshold = 5
+)
+
+// 自动切换频段的默认信号强度阈值
+const defaultRoamingStrengthThreshold = 65
+
+// 热点所在的频段，a 和 bg 与 NetworkManager 的 band 设置一致，NetworkManager 暂不支持设置 6G 频段
+const (
+	bandA       = "a"
+	bandBG      = "bg"
+	band6G      = "6g"
+	bandUnknown = "unknown"
+)
 
 // frequency range
 const (
@@ -34,6 +54,8 @@ const (
 	frequency5GLowerlimit = 4915
 	frequency2GUpperlimit = 2484
 	frequency2GLowerlimit = 2412
+	frequency6GUpperlimit = 7125
+	frequency6GLowerlimit = 5925
 )
 
 func (v apSecType) String() string {
@@ -48,6 +70,8 @@ func (v apSecType) String() string {
 		return "sae"
 	case apSecEap:
 		return "wpa-eap"
+	case apSecOwe:
+		return "owe"
 	default:
 		return fmt.Sprintf("<invalid apSecType %d>", v)
 	}
@@ -57,16 +81,35 @@ type accessPoint struct {
 	nmAp    nmdbus.AccessPoint
 	devPath dbus.ObjectPath
 
-	Ssid         string
+	Ssid string
+	// 原始的 ssid 字节，json 中为 base64 编码，Ssid 无法正确解码(如 GBK 编码)时用于匹配和创建连接
+	RawSsid      []byte
+	Bssid        string
 	Secured      bool
 	SecuredInEap bool
 	Strength     uint8
 	Path         dbus.ObjectPath
 	Frequency    uint32
+	// 频段，见 getBandByFrequency
+	Band string
 	// add hidden property
 	Hidden  bool
 	Flags   uint32
 	KeyMgmt string // 直接表明推荐的 keymgmt，不要让前后端两套逻辑
+	// 最大速率，单位 kb/s
+	MaxBitrate uint32
+	// BSS Load 中的信道利用率，取值 0-255，-1 表示未知
+	Load int32
+	// 是否为 Hotspot 2.0(Passpoint) 热点
+	IsPasspoint bool
+	// 连续多次扫描未发现该热点，热点可能已经不存在
+	Stale bool
+	// 信号强度低于弱信号阈值，ShowWeakAccessPoints 为 false 时不在热点列表中显示
+	Ignored bool
+
+	missedScans uint32
+	// 最近一次变化时的热点列表版本
+	revision uint64
 }
 
 func (m *Manager) newAccessPoint(devPath, apPath dbus.ObjectPath) (ap *accessPoint, err error) {
@@ -79,12 +122,19 @@ func (m *Manager) newAccessPoint(devPath, apPath dbus.ObjectPath) (ap *accessPoi
 		nmAp:    nmAp,
 		devPath: devPath,
 		Path:    apPath,
+		Load:    apLoadUnknown,
 	}
-	ap.updateProps()
+	ap.updateProps(0)
+	ap.Ignored = isWeakAccessPoint(ap.Strength, m.getWeakAccessPointStrength())
 	if len(ap.Ssid) == 0 {
 		err = fmt.Errorf("ignore hidden access point")
 		return
 	}
+	// 管理员禁止的热点不显示
+	if !m.isSsidAllowed(ap.Ssid) {
+		err = fmt.Errorf("ignore access point %q which is not allowed", ap.Ssid)
+		return
+	}
 
 	// add hidden
 	if m.isHidden(ap.Ssid) {
@@ -100,37 +150,37 @@ func (m *Manager) newAccessPoint(devPath, apPath dbus.ObjectPath) (ap *accessPoi
 			return
 		}
 
-		if ap.updateProps() {
-			m.PropsMu.Lock()
-			m.updatePropWirelessAccessPoints()
-			m.PropsMu.Unlock()
+		m.PropsMu.RLock()
+		threshold := m.AccessPointStrengthThreshold
+		weakStrength := m.weakApStrength
+		m.PropsMu.RUnlock()
+		if !ap.updateProps(threshold) {
+			return
 		}
+		ap.Ignored = isWeakAccessPoint(ap.Strength, weakStrength)
+		m.apRevisions.touch(ap)
+
+		m.PropsMu.Lock()
+		m.updatePropWirelessAccessPoints()
+		m.PropsMu.Unlock()
 
+		m.queueAccessPointChanged(ap)
 	})
 	if err != nil {
 		logger.Warning("failed to monitor changing properties of AccessPoint", err)
 	}
 
-	apJSON, _ := marshalJSON(ap)
-	err1 := m.service.Emit(m, "AccessPointAdded", string(devPath), apJSON)
-	if err1 != nil {
-		logger.Warning("failed to emit signal:", err1)
-	}
-
+	m.emitAccessPointAdded(ap)
 	return
 }
 
 func (m *Manager) destroyAccessPoint(ap *accessPoint) {
-	// emit AccessPointRemoved signal
-	apJSON, _ := marshalJSON(ap)
-	err := m.service.Emit(m, "AccessPointRemoved", string(ap.devPath), apJSON)
-	if err != nil {
-		logger.Warning("failed to emit signal:", err)
-	}
+	m.emitAccessPointRemoved(ap)
 	nmDestroyAccessPoint(ap.nmAp)
 }
 
-func (a *accessPoint) updateProps() bool {
+// updateProps 更新热点属性，只有信号强度变化达到 strengthThreshold 或其他属性变化时才返回 true
+func (a *accessPoint) updateProps(strengthThreshold uint32) bool {
 	ssid, err := a.nmAp.Ssid().Get(0)
 	if err != nil {
 		logger.Warning(err)
@@ -156,18 +206,66 @@ func (a *accessPoint) updateProps() bool {
 		logger.Warning(err)
 		return false
 	}
+	bssid, err := a.nmAp.HwAddress().Get(0)
+	if err != nil {
+		logger.Warning(err)
+		return false
+	}
+	maxBitrate, err := a.nmAp.MaxBitrate().Get(0)
+	if err != nil {
+		logger.Warning(err)
+		return false
+	}
+
+	changed := a.setProps(decodeSsid(ssid), bssid, typ, strength, frequency, flags,
+		getKeyMgmtFromAP(a.nmAp), strengthThreshold)
+	if a.MaxBitrate != maxBitrate {
+		a.MaxBitrate = maxBitrate
+		changed = true
+	}
+	if !bytes.Equal(a.RawSsid, ssid) {
+		a.RawSsid = ssid
+		changed = true
+	}
+	return changed
+}
+
+func (a *accessPoint) setProps(ssid, bssid string, typ apSecType, strength uint8, frequency, flags uint32,
+	keyMgmt string, strengthThreshold uint32) bool {
+	// OWE 加密但无需密码
+	secured := typ != apSecNone && typ != apSecOwe
+	securedInEap := typ == apSecEap
+
+	changed := a.Ssid != ssid || a.Bssid != bssid || a.Secured != secured ||
+		a.SecuredInEap != securedInEap || a.Frequency != frequency ||
+		a.Flags != flags || a.KeyMgmt != keyMgmt
+	if !changed && !isStrengthChanged(a.Strength, strength, strengthThreshold) {
+		return false
+	}
 
-	a.Ssid = decodeSsid(ssid)
-	a.Secured = typ != apSecNone
-	a.SecuredInEap = typ == apSecEap
+	a.Ssid = ssid
+	a.Bssid = bssid
+	a.Secured = secured
+	a.SecuredInEap = securedInEap
 	a.Strength = strength
 	a.Frequency = frequency
+	a.Band = getBandByFrequency(frequency)
 	a.Flags = flags
-	a.KeyMgmt = getKeyMgmtFromAP(a.nmAp)
-
+	a.KeyMgmt = keyMgmt
 	return true
 }
 
+func isStrengthChanged(oldStrength, newStrength uint8, threshold uint32) bool {
+	if oldStrength == newStrength {
+		return false
+	}
+	diff := uint32(newStrength) - uint32(oldStrength)
+	if oldStrength > newStrength {
+		diff = uint32(oldStrength) - uint32(newStrength)
+	}
+	return diff >= threshold
+}
+
 func getKeyMgmtFromAP(ap nmdbus.AccessPoint) string {
 	keymgmt := "none"
 
@@ -202,6 +300,11 @@ func getKeyMgmtFromAP(ap nmdbus.AccessPoint) string {
 		keymgmt = "sae"
 	}
 
+	// Enhanced Open
+	if rsnFlags&nm.NM_802_11_AP_SEC_KEY_MGMT_OWE != 0 {
+		keymgmt = "owe"
+	}
+
 	if wpaFlags&nm.NM_802_11_AP_SEC_KEY_MGMT_802_1X != 0 ||
 		rsnFlags&nm.NM_802_11_AP_SEC_KEY_MGMT_802_1X != 0 {
 		keymgmt = "wpa-eap"
@@ -243,7 +346,8 @@ func doParseApSecType(flags, wpaFlags, rsnFlags uint32) apSecType {
 	if wpaFlags != nm.NM_802_11_AP_SEC_NONE {
 		r = apSecPsk
 	}
-	if rsnFlags != nm.NM_802_11_AP_SEC_NONE {
+	// OWE_TM 是 OWE 过渡模式中开放的热点，仍按无加密处理
+	if rsnFlags&^nm.NM_802_11_AP_SEC_KEY_MGMT_OWE_TM != nm.NM_802_11_AP_SEC_NONE {
 		r = apSecPsk
 	}
 	if (wpaFlags&nm.NM_802_11_AP_SEC_KEY_MGMT_802_1X != 0) || (rsnFlags&nm.NM_802_11_AP_SEC_KEY_MGMT_802_1X != 0) {
@@ -253,17 +357,21 @@ func doParseApSecType(flags, wpaFlags, rsnFlags uint32) apSecType {
 	if wpaFlags&nm.NM_802_11_AP_SEC_KEY_MGMT_SAE != 0 || rsnFlags&nm.NM_802_11_AP_SEC_KEY_MGMT_SAE != 0 {
 		r = apSecSae
 	}
+	if rsnFlags&nm.NM_802_11_AP_SEC_KEY_MGMT_OWE != 0 {
+		r = apSecOwe
+	}
 	if (wpaFlags&nm.NM_802_11_AP_SEC_KEY_MGMT_EAP_SUITE_B_192 != 0) || (rsnFlags&nm.NM_802_11_AP_SEC_KEY_MGMT_EAP_SUITE_B_192 != 0) {
 		r = apSecEap
 	}
 	return r
 }
 
-func (m *Manager) isAccessPointActivated(devPath dbus.ObjectPath, ssid string) bool {
+// isAccessPointActivated 按原始的 ssid 字节匹配，避免解码后不同的 ssid 被当作同一个
+func (m *Manager) isAccessPointActivated(devPath dbus.ObjectPath, ssid []byte) bool {
 	for _, path := range nmGetActiveConnections() {
 		aconn := m.newActiveConnection(path)
 		if aconn.typ == nm.NM_SETTING_WIRELESS_SETTING_NAME && isDBusPathInArray(devPath, aconn.Devices) {
-			if ssid == string(nmGetWirelessConnectionSsidByUuid(aconn.Uuid)) {
+			if bytes.Equal(ssid, nmGetWirelessConnectionSsidByUuid(aconn.Uuid)) {
 				return true
 			}
 		}
@@ -280,6 +388,8 @@ func (m *Manager) clearAccessPoints() {
 		}
 	}
 	m.accessPoints = make(map[dbus.ObjectPath][]*accessPoint)
+	m.accessPointsByPath = make(map[dbus.ObjectPath]*accessPoint)
+	m.apRevisions.reset()
 }
 
 func (m *Manager) initAccessPoints(devPath dbus.ObjectPath, apPaths []dbus.ObjectPath) {
@@ -294,7 +404,21 @@ func (m *Manager) initAccessPoints(devPath dbus.ObjectPath, apPaths []dbus.Objec
 	}
 
 	m.accessPointsLock.Lock()
+	newPaths := make(map[dbus.ObjectPath]bool, len(accessPoints))
+	for _, ap := range accessPoints {
+		m.apRevisions.touch(ap)
+		newPaths[ap.Path] = true
+	}
+	for _, ap := range m.accessPoints[devPath] {
+		delete(m.accessPointsByPath, ap.Path)
+		if !newPaths[ap.Path] {
+			m.apRevisions.recordRemoved(ap)
+		}
+	}
 	m.accessPoints[devPath] = accessPoints
+	for _, ap := range accessPoints {
+		m.accessPointsByPath[ap.Path] = ap
+	}
 	m.accessPointsLock.Unlock()
 }
 
@@ -311,6 +435,53 @@ func (m *Manager) isHidden(ssid string) bool {
 	return false
 }
 
+// syncAccessPoints 根据 NetworkManager 上报的热点列表更新热点，scanned 表示是否为一次扫描完成后的列表。
+// 扫描完成时未上报的热点累加错过的扫描次数，达到 apStaleScans 次标记为 Stale，达到 apRemoveScans 次后才移除，
+// 避免信号边缘的热点频繁增删。
+func (m *Manager) syncAccessPoints(devPath dbus.ObjectPath, apPaths []dbus.ObjectPath, scanned bool) {
+	reported := make(map[dbus.ObjectPath]bool, len(apPaths))
+	for _, apPath := range apPaths {
+		reported[apPath] = true
+	}
+
+	var shouldRemove []dbus.ObjectPath
+	for _, ap := range m.accessPoints[devPath] {
+		if reported[ap.Path] {
+			ap.missedScans = 0
+			m.setAccessPointStale(ap, false)
+			continue
+		}
+		if !scanned {
+			continue
+		}
+		ap.missedScans++
+		if ap.missedScans >= m.apRemoveScans {
+			shouldRemove = append(shouldRemove, ap.Path)
+		} else if ap.missedScans >= m.apStaleScans {
+			m.setAccessPointStale(ap, true)
+		}
+	}
+
+	for _, apPath := range shouldRemove {
+		m.removeAccessPoint(devPath, apPath)
+	}
+
+	for _, apPath := range apPaths {
+		m.addAccessPoint(devPath, apPath)
+	}
+}
+
+// setAccessPointStale 更新热点的 Stale 状态并通知前端，m.accessPointsLock 需要被持有，
+// 调用者随后需要更新 WirelessAccessPoints 属性
+func (m *Manager) setAccessPointStale(ap *accessPoint, stale bool) {
+	if ap.Stale == stale {
+		return
+	}
+	ap.Stale = stale
+	m.apRevisions.touch(ap)
+	m.queueAccessPointChanged(ap)
+}
+
 func (m *Manager) addAccessPoint(devPath, apPath dbus.ObjectPath) {
 	if m.isAccessPointExists(devPath, apPath) {
 		return
@@ -320,19 +491,34 @@ func (m *Manager) addAccessPoint(devPath, apPath dbus.ObjectPath) {
 		return
 	}
 	//logger.Debug("add access point", devPath, apPath)
-	m.accessPoints[devPath] = append(m.accessPoints[devPath], ap)
+	m.apRevisions.touch(ap)
+	m.indexAccessPoint(ap)
+}
+
+// indexAccessPoint 将热点加入设备的有序列表和按路径查找的索引
+func (m *Manager) indexAccessPoint(ap *accessPoint) {
+	m.accessPoints[ap.devPath] = append(m.accessPoints[ap.devPath], ap)
+	m.accessPointsByPath[ap.Path] = ap
 }
 
 func (m *Manager) removeAccessPoint(devPath, apPath dbus.ObjectPath) {
-	i := m.getAccessPointIndex(devPath, apPath)
-	if i < 0 {
+	ap := m.getAccessPoint(devPath, apPath)
+	if ap == nil {
 		return
 	}
-	m.accessPoints[devPath] = m.doRemoveAccessPoint(m.accessPoints[devPath], i)
+	// 只有移除时需要在设备的列表中查找热点的位置
+	for i, item := range m.accessPoints[devPath] {
+		if item == ap {
+			m.accessPoints[devPath] = m.doRemoveAccessPoint(m.accessPoints[devPath], i)
+			return
+		}
+	}
 }
 
 func (m *Manager) doRemoveAccessPoint(aps []*accessPoint, i int) []*accessPoint {
 	m.destroyAccessPoint(aps[i])
+	m.apRevisions.recordRemoved(aps[i])
+	delete(m.accessPointsByPath, aps[i].Path)
 	copy(aps[i:], aps[i+1:])
 	aps[len(aps)-1] = nil
 	aps = aps[:len(aps)-1]
@@ -340,17 +526,28 @@ func (m *Manager) doRemoveAccessPoint(aps []*accessPoint, i int) []*accessPoint
 }
 
 func (m *Manager) isAccessPointExists(devPath, apPath dbus.ObjectPath) bool {
-	i := m.getAccessPointIndex(devPath, apPath)
-	return i >= 0
+	return m.getAccessPoint(devPath, apPath) != nil
 }
 
-func (m *Manager) getAccessPointIndex(devPath, apPath dbus.ObjectPath) int {
-	for i, ap := range m.accessPoints[devPath] {
-		if ap.Path == apPath {
-			return i
+// getAccessPoint 通过热点路径查找设备的热点，不存在时返回 nil，需持有 accessPointsLock
+func (m *Manager) getAccessPoint(devPath, apPath dbus.ObjectPath) *accessPoint {
+	ap := m.accessPointsByPath[apPath]
+	if ap == nil || ap.devPath != devPath {
+		return nil
+	}
+	return ap
+}
+
+// getAccessPointRawSsid 根据显示的 ssid 在设备的热点中查找原始的 ssid 字节，找不到时使用 utf-8 编码
+func (m *Manager) getAccessPointRawSsid(devPath dbus.ObjectPath, ssid string) []byte {
+	m.accessPointsLock.Lock()
+	defer m.accessPointsLock.Unlock()
+	for _, ap := range m.accessPoints[devPath] {
+		if ap.Ssid == ssid && len(ap.RawSsid) > 0 {
+			return ap.RawSsid
 		}
 	}
-	return -1
+	return []byte(ssid)
 }
 
 // GetAccessPoints return all access points object which marshaled by json.
@@ -374,6 +571,20 @@ func (m *Manager) ActivateAccessPoint(uuid string, apPath, devPath dbus.ObjectPa
 	return cpath, nil
 }
 
+// ActivateAccessPointByBssid works like ActivateAccessPoint, but pins
+// the connection to the BSSID of the access point, so NetworkManager
+// will not roam to other access points with the same SSID. The pin is
+// cleared when the connection is activated by ActivateAccessPoint.
+func (m *Manager) ActivateAccessPointByBssid(uuid string, apPath, devPath dbus.ObjectPath) (connection dbus.ObjectPath,
+	busErr *dbus.Error) {
+	cpath, err := m.doActivateAccessPoint(uuid, apPath, devPath, false, true)
+	if err != nil {
+		logger.Warning("failed to activate access point by bssid:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
 func (m *Manager) fixApKeyMgmtChange(uuid string, keymgmt string, saved bool, devPath dbus.ObjectPath) (needUserEdit bool, err error) {
 	var cpath dbus.ObjectPath
 	cpath, err = nmGetConnectionByUuid(uuid)
@@ -481,6 +692,8 @@ func fixApSecTypeChange(uuid string, secType apSecType) (needUserEdit bool, err
 		err = logicSetSettingVkWirelessSecurityKeyMgmt(connData, "wpa-psk")
 	case apSecSae:
 		err = logicSetSettingVkWirelessSecurityKeyMgmt(connData, "sae")
+	case apSecOwe:
+		err = logicSetSettingVkWirelessSecurityKeyMgmt(connData, "owe")
 	case apSecEap:
 		needUserEdit = true
 		return
@@ -502,9 +715,59 @@ func fixApSecTypeChange(uuid string, secType apSecType) (needUserEdit bool, err
 	return
 }
 
+// 连接的 user-data 中记录 bssid 是由 ActivateAccessPointByBssid 绑定的，
+// 用户在连接编辑中设置的 bssid 不会被清除
+const connBssidPinnedUserData = "org.deepin.bssid-pinned"
+
+// setConnectionBssidPin 将连接绑定到指定 bssid 的热点，bssid 为空时清除之前绑定的 bssid，
+// 返回连接是否有修改
+func setConnectionBssidPin(data connectionData, bssid []byte) bool {
+	userData := getSettingUserData(data)
+	if len(bssid) == 0 {
+		if userData[connBssidPinnedUserData] == "" {
+			return false
+		}
+		delete(userData, connBssidPinnedUserData)
+		removeSettingKey(data, nm.NM_SETTING_WIRELESS_SETTING_NAME, nm.NM_SETTING_WIRELESS_BSSID)
+	} else {
+		if userData == nil {
+			userData = make(map[string]string)
+		}
+		userData[connBssidPinnedUserData] = "true"
+		setSettingWirelessBssid(data, bssid)
+	}
+	if len(userData) != 0 {
+		addSetting(data, nm.NM_SETTING_USER_SETTING_NAME)
+		setSettingUserData(data, userData)
+	} else {
+		removeSetting(data, nm.NM_SETTING_USER_SETTING_NAME)
+	}
+	return true
+}
+
+// updateConnectionBssid 将连接绑定到指定 bssid 的热点，bssid 为空时清除之前绑定的 bssid
+func updateConnectionBssid(uuid string, bssid []byte) (err error) {
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		return
+	}
+	connData, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return
+	}
+	if !setConnectionBssidPin(connData, bssid) {
+		return
+	}
+	return updateConnectionData(cpath, connData)
+}
+
 // ActivateAccessPoint add and activate connection for access point.
 func (m *Manager) activateAccessPoint(uuid string, apPath, devPath dbus.ObjectPath, saved bool) (cpath dbus.ObjectPath, err error) {
-	logger.Debugf("ActivateAccessPoint: uuid=%s, apPath=%s, devPath=%s", uuid, apPath, devPath)
+	return m.doActivateAccessPoint(uuid, apPath, devPath, saved, false)
+}
+
+func (m *Manager) doActivateAccessPoint(uuid string, apPath, devPath dbus.ObjectPath, saved, pinBssid bool) (cpath dbus.ObjectPath, err error) {
+	logger.Debugf("ActivateAccessPoint: uuid=%s, apPath=%s, devPath=%s, pinBssid=%v", uuid, apPath, devPath, pinBssid)
 
 	cpath = "/"
 	var nmAp nmdbus.AccessPoint
@@ -513,6 +776,27 @@ func (m *Manager) activateAccessPoint(uuid string, apPath, devPath dbus.ObjectPa
 		return
 	}
 	keymgmt := getKeyMgmtFromAP(nmAp)
+	apSsid, err := nmAp.Ssid().Get(0)
+	if err != nil {
+		logger.Warning("failed to get Ap Ssid:", err)
+		return
+	}
+	err = m.checkSsidAllowed(decodeSsid(apSsid))
+	if err != nil {
+		return
+	}
+
+	var bssid []byte
+	if pinBssid {
+		var hwAddr string
+		hwAddr, err = nmAp.HwAddress().Get(0)
+		if err != nil {
+			logger.Warning("failed to get Ap bssid:", err)
+			return
+		}
+		bssid = convertMacAddressToArrayByte(hwAddr)
+	}
+
 	if uuid != "" {
 		var needUserEdit bool
 		needUserEdit, err = m.fixApKeyMgmtChange(uuid, keymgmt, saved, devPath)
@@ -523,6 +807,14 @@ func (m *Manager) activateAccessPoint(uuid string, apPath, devPath dbus.ObjectPa
 			err = errors.New("need user edit")
 			return
 		}
+		// 不绑定 bssid 时清除之前绑定的 bssid，否则无法再连接同名的其他热点
+		err = updateConnectionBssid(uuid, bssid)
+		if err != nil {
+			if pinBssid {
+				return
+			}
+			logger.Warning("failed to clear bssid of connection:", err)
+		}
 		cpath, err = m.activateConnection(uuid, devPath)
 		if err != nil {
 			return
@@ -548,6 +840,10 @@ func (m *Manager) activateAccessPoint(uuid string, apPath, devPath dbus.ObjectPa
 		if m.isHidden(string(ssid)) {
 			setSettingWirelessHidden(data, true)
 		}
+		if pinBssid {
+			setConnectionBssidPin(data, bssid)
+		}
+		m.applyDefaultWirelessZone(data)
 		if saved {
 			cpath, _, err = nmAddAndActivateConnection(data, devPath, true)
 		} else {
@@ -563,150 +859,257 @@ func (m *Manager) activateAccessPoint(uuid string, apPath, devPath dbus.ObjectPa
 	return
 }
 
-func (m *Manager) DebugChangeAPChannel(band string) *dbus.Error {
-	if band != "a" && band != "bg" {
+// 只有设置了该环境变量才允许调用 DebugChangeAPChannel，避免正式环境误触发热点切换
+const debugAPChannelEnv = "DDE_NETWORK_DEBUG_AP_CHANNEL"
+
+func (m *Manager) setDebugAPChannelEnabled(enabled bool) {
+	m.debugAPChannelLock.Lock()
+	m.debugAPChannelEnabled = enabled
+	m.debugAPChannelLock.Unlock()
+}
+
+func (m *Manager) isDebugAPChannelEnabled() bool {
+	m.debugAPChannelLock.Lock()
+	defer m.debugAPChannelLock.Unlock()
+	return m.debugAPChannelEnabled
+}
+
+// DebugChangeAPChannel is for debugging only, it requires the
+// DDE_NETWORK_DEBUG_AP_CHANNEL=1 environment variable.
+func (m *Manager) DebugChangeAPChannel(sender dbus.Sender, band string) *dbus.Error {
+	pid, err := m.service.GetConnPID(string(sender))
+	if err != nil {
+		logger.Warning(err)
+	}
+	exe, _ := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	logger.Warningf("DebugChangeAPChannel called by %s, pid: %d, exe: %q, band: %q", sender, pid, exe, band)
+
+	if !m.isDebugAPChannelEnabled() {
+		return dbusutil.ToError(errors.New("debug AP channel is not enabled"))
+	}
+	if band != bandA && band != bandBG && band != band6G {
 		return dbusutil.ToError(errors.New("band input error"))
 	}
-	err := m.RequestWirelessScan()
-	if err != nil {
-		logger.Warning("RequestWirelessScan: ", err)
-		return dbusutil.ToError(err)
+	busErr := m.RequestWirelessScan()
+	if busErr != nil {
+		logger.Warning("RequestWirelessScan: ", busErr)
+		return busErr
 	}
+	m.checkAPStrengthLock.Lock()
 	m.debugChangeAPBand = band
+	m.checkAPStrengthLock.Unlock()
 	return nil
 }
 
-func (m *Manager) findAPByBand(ssid string, accessPoints []*accessPoint, band string) (apNow *accessPoint) {
+// findAPByBand 返回指定 ssid 中信号最强的热点，band 不为空时只在该频段中查找，
+// byCapacity 为 true 时在信号与最强热点相近的热点中选择容量最大的
+func findAPByBand(ssid string, accessPoints []*accessPoint, band string, byCapacity bool) (apNow *accessPoint) {
 	logger.Debug("findAPByBand:", ssid, band)
-	apNow = nil
-	var strengthMax uint8 = 0
+	var candidates []*accessPoint
 	for _, ap := range accessPoints {
-		if band == "" {
-			if ap.Ssid == ssid && ap.Strength > strengthMax {
-				apNow = ap
-				strengthMax = ap.Strength
-			}
-		} else {
-			for _, ap := range accessPoints {
-				if ap.Ssid == ssid && band == "a" &&
-					ap.Frequency >= frequency5GLowerlimit &&
-					ap.Frequency <= frequency5GUpperlimit {
-					apNow = ap
-					break
-				} else if ap.Ssid == ssid && band == "bg" &&
-					ap.Frequency >= frequency2GLowerlimit &&
-					ap.Frequency <= frequency2GUpperlimit {
-					apNow = ap
-					break
-				}
-			}
+		if ap.Ssid != ssid {
+			continue
+		}
+		if band != "" && !isFrequencyInBand(ap.Frequency, band) {
+			continue
+		}
+		candidates = append(candidates, ap)
+		if apNow == nil || ap.Strength > apNow.Strength {
+			apNow = ap
+		}
+	}
+	if !byCapacity || apNow == nil {
+		return
+	}
+	// 都和最强的热点比较，结果不受热点顺序影响
+	strongest := apNow
+	for _, ap := range candidates {
+		if !isStrengthComparable(ap.Strength, strongest.Strength) {
+			continue
+		}
+		capacity, curCapacity := ap.capacity(), apNow.capacity()
+		if capacity > curCapacity || (capacity == curCapacity && ap.Strength > apNow.Strength) {
+			apNow = ap
 		}
 	}
 	return
 }
 
-func (m *Manager) getBandByFrequency(freq uint32) (band string) {
-	if freq >= frequency5GLowerlimit &&
-		freq <= frequency5GUpperlimit {
-		band = "a"
-	} else if freq >= frequency2GLowerlimit &&
-		freq <= frequency2GUpperlimit {
-		band = "bg"
-	} else {
-		band = "unknown"
+func isFrequencyInBand(freq uint32, band string) bool {
+	switch band {
+	case bandA:
+		return freq >= frequency5GLowerlimit && freq <= frequency5GUpperlimit
+	case bandBG:
+		return freq >= frequency2GLowerlimit && freq <= frequency2GUpperlimit
+	case band6G:
+		return freq >= frequency6GLowerlimit && freq <= frequency6GUpperlimit
 	}
-	return
+	return false
+}
+
+// isHighBandFrequency 5G 和 6G 频段
+func isHighBandFrequency(freq uint32) bool {
+	return isFrequencyInBand(freq, bandA) || isFrequencyInBand(freq, band6G)
+}
+
+func getBandByFrequency(freq uint32) (band string) {
+	for _, band := range []string{bandA, bandBG, band6G} {
+		if isFrequencyInBand(freq, band) {
+			return band
+		}
+	}
+	return bandUnknown
+}
+
+// fixActiveApSsid 读到有效 ssid 时更新缓存，读到空 ssid 时返回缓存中的 ssid
+func (m *Manager) fixActiveApSsid(devPath dbus.ObjectPath, ssid []byte) []byte {
+	m.activeApSsidLock.Lock()
+	defer m.activeApSsidLock.Unlock()
+	if len(ssid) == 0 {
+		return m.activeApSsid[devPath]
+	}
+	if m.activeApSsid == nil {
+		m.activeApSsid = make(map[dbus.ObjectPath][]byte)
+	}
+	m.activeApSsid[devPath] = ssid
+	return ssid
+}
+
+func (m *Manager) clearActiveApSsid(devPath dbus.ObjectPath) {
+	m.activeApSsidLock.Lock()
+	delete(m.activeApSsid, devPath)
+	m.activeApSsidLock.Unlock()
 }
 
 func (m *Manager) checkAPStrength() {
-	band := m.debugChangeAPBand
+	m.checkAPStrengthLock.Lock()
+	debugBand := m.debugChangeAPBand
 	m.debugChangeAPBand = ""
 	m.checkAPStrengthTimer = nil
-	logger.Debug("checkAPStrength:")
-	if devices, ok := m.devices[deviceWifi]; ok {
-		for _, dev := range devices {
-			apPath, _ := dev.nmDev.Wireless().ActiveAccessPoint().Get(0)
-			nmAp, err := nmNewAccessPoint(apPath)
-			if err != nil {
-				continue
-			}
+	m.checkAPStrengthLock.Unlock()
 
-			frequency, _ := nmAp.Frequency().Get(0)
-			strength, _ := nmAp.Strength().Get(0)
-			ssid, _ := nmAp.Ssid().Get(0)
+	policy, threshold := m.getRoamingPolicy()
+	logger.Debug("checkAPStrength:", policy, threshold)
+	if debugBand == "" && policy == roamingPolicyOff {
+		return
+	}
 
-			aPath, err := dev.nmDev.Device().ActiveConnection().Get(0)
-			if err != nil || !isObjPathValid(aPath) {
-				continue
-			}
-			aConn, err := nmNewActiveConnection(aPath)
-			if err != nil {
-				logger.Error(err)
-				continue
-			}
+	m.devicesLock.Lock()
+	devices := make([]*device, len(m.devices[deviceWifi]))
+	copy(devices, m.devices[deviceWifi])
+	m.devicesLock.Unlock()
+	for _, dev := range devices {
+		// 有多个无线网卡时只在主网卡上漫游
+		if len(devices) > 1 && m.getWirelessRole(dev.Path) != wirelessRolePrimary {
+			continue
+		}
+		band := debugBand
+		apPath, _ := dev.nmDev.Wireless().ActiveAccessPoint().Get(0)
+		nmAp, err := nmNewAccessPoint(apPath)
+		if err != nil {
+			continue
+		}
 
-			state, err := aConn.State().Get(0)
-			if err != nil {
-				logger.Error(err)
-				continue
-			}
-			//当热点还没有连接成功时,不需要切换
-			if state != nm.NM_ACTIVE_CONNECTION_STATE_ACTIVATED {
-				logger.Debug("do not need change if connection not be activated")
-				continue
-			}
+		frequency, _ := nmAp.Frequency().Get(0)
+		strength, _ := nmAp.Strength().Get(0)
+		ssid, _ := nmAp.Ssid().Get(0)
+		// NM 短暂异常时读到的 ssid 可能为空，使用缓存的 ssid，都为空时不做频段切换
+		ssid = m.fixActiveApSsid(dev.Path, ssid)
+		if len(ssid) == 0 {
+			logger.Debug("active ap ssid is empty, skip band check:", dev.Path)
+			continue
+		}
 
-			if band == "" {
-				//当前信号比较好，无需切换
-				if strength > channelAutoChangeThreshold && frequency >= frequency5GLowerlimit &&
-					frequency <= frequency5GUpperlimit {
-					continue
-				}
-			}
+		aPath, err := dev.nmDev.Device().ActiveConnection().Get(0)
+		if err != nil || !isObjPathValid(aPath) {
+			continue
+		}
+		aConn, err := nmNewActiveConnection(aPath)
+		if err != nil {
+			logger.Error(err)
+			continue
+		}
 
-			apNow := m.findAPByBand(decodeSsid(ssid), m.accessPoints[dev.Path], band)
-			if apNow == nil {
-				logger.Debug("not found AP ")
-				continue
-			}
-			if apNow.Path == apPath {
-				logger.Debug("no need to change AP")
-				continue
-			}
-			logger.Debug("changeAPChanel, apPath:", apPath)
-			if band == "" {
-				// 信号强度改变小于1格，不发生切换
-				if apNow.Strength < strength+20 {
-					continue
-				}
-				if apNow.Frequency >= frequency5GLowerlimit &&
-					apNow.Frequency <= frequency5GUpperlimit {
-					band = "a"
-				} else {
-					band = "bg"
-				}
-			}
-			if band == m.getBandByFrequency(frequency) {
-				logger.Debug("no need to change AP")
-				continue
-			}
+		state, err := aConn.State().Get(0)
+		if err != nil {
+			logger.Error(err)
+			continue
+		}
+		//当热点还没有连接成功时,不需要切换
+		if state != nm.NM_ACTIVE_CONNECTION_STATE_ACTIVATED {
+			logger.Debug("do not need change if connection not be activated")
+			continue
+		}
 
-			connPath, err := aConn.Connection().Get(0)
-			if err != nil {
-				logger.Error(err)
-				continue
-			}
-			conn := m.getConnection(connPath)
-			err = m.updateConnectionBand(conn, band)
-			if err != nil {
-				logger.Error(err)
-				continue
+		capacityRoaming := m.isCapacityRoamingEnabled()
+		if capacityRoaming {
+			m.updateAccessPointsLoad(dev)
+		}
+
+		m.accessPointsLock.Lock()
+		var bestPath dbus.ObjectPath
+		var bestStrength uint8
+		var bestFrequency uint32
+		var roamForCapacity bool
+		apNow := findAPByBand(decodeSsid(ssid), m.accessPoints[dev.Path], band, capacityRoaming)
+		if apNow != nil {
+			bestPath, bestStrength, bestFrequency = apNow.Path, apNow.Strength, apNow.Frequency
+			if apCurrent := m.getAccessPoint(dev.Path, apPath); capacityRoaming && apCurrent != nil {
+				roamForCapacity = shouldRoamForCapacity(policy, threshold, apCurrent, apNow)
 			}
-			_, err = m.activateAccessPoint(conn.Uuid, apNow.Path, dev.Path, false)
-			if err != nil {
-				logger.Error(err)
+		}
+		m.accessPointsLock.Unlock()
+		if apNow == nil {
+			logger.Debug("not found AP ")
+			continue
+		}
+		if bestPath == apPath {
+			logger.Debug("no need to change AP")
+			continue
+		}
+		logger.Debug("changeAPChanel, apPath:", apPath)
+		if band == "" {
+			if !shouldRoam(policy, threshold, strength, frequency, bestStrength) && !roamForCapacity {
 				continue
 			}
+			band = getBandByFrequency(bestFrequency)
+		}
+		if band == getBandByFrequency(frequency) {
+			logger.Debug("no need to change AP")
+			continue
+		}
+
+		connPath, err := aConn.Connection().Get(0)
+		if err != nil {
+			logger.Error(err)
+			continue
+		}
+		conn := m.getConnection(connPath)
+		if conn == nil {
+			continue
+		}
+		// 用户锁定了频段时不自动切换
+		if conn.BandPreference != bandPreferenceAuto {
+			logger.Debug("band of connection is locked:", conn.Uuid, conn.BandPreference)
+			continue
+		}
+		// 支持 802.11r 时直接漫游，不支持时修改频段后重新激活连接
+		err = m.tryFastRoam(dev, apPath, bestPath, band)
+		if err == nil {
+			continue
+		}
+		logger.Debug("can not fast roam, reactivate the connection:", err)
+		err = m.updateConnectionBand(conn, band)
+		if err != nil {
+			logger.Error(err)
+			continue
+		}
+		_, err = m.activateAccessPoint(conn.Uuid, bestPath, dev.Path, false)
+		if err != nil {
+			logger.Error(err)
+			continue
 		}
+		m.addNetworkEvent(networkEventApSwitch, dev.Interface, conn.Id,
+			fmt.Sprintf("switch from %s band to %s band", getBandByFrequency(frequency), band))
 	}
 }
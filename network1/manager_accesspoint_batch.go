@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"sort"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+// 在该时间窗口内的热点变化合并为一次 AccessPointsChanged 信号
+const accessPointsChangedDelay = 300 * time.Millisecond
+
+// accessPointsBatch 记录一个设备在时间窗口内的热点变化
+type accessPointsBatch struct {
+	added   map[dbus.ObjectPath]map[string]dbus.Variant
+	changed map[dbus.ObjectPath]map[string]dbus.Variant
+	removed map[dbus.ObjectPath]bool
+}
+
+func newAccessPointsBatch() *accessPointsBatch {
+	return &accessPointsBatch{
+		added:   make(map[dbus.ObjectPath]map[string]dbus.Variant),
+		changed: make(map[dbus.ObjectPath]map[string]dbus.Variant),
+		removed: make(map[dbus.ObjectPath]bool),
+	}
+}
+
+func (b *accessPointsBatch) add(apPath dbus.ObjectPath, props map[string]dbus.Variant) {
+	delete(b.removed, apPath)
+	delete(b.changed, apPath)
+	b.added[apPath] = props
+}
+
+func (b *accessPointsBatch) change(apPath dbus.ObjectPath, props map[string]dbus.Variant) {
+	if _, ok := b.added[apPath]; ok {
+		// 窗口内新增的热点，直接更新新增的属性
+		b.added[apPath] = props
+		return
+	}
+	b.changed[apPath] = props
+}
+
+func (b *accessPointsBatch) remove(apPath dbus.ObjectPath) {
+	delete(b.changed, apPath)
+	if _, ok := b.added[apPath]; ok {
+		// 窗口内新增后又被移除，前端无需感知
+		delete(b.added, apPath)
+		return
+	}
+	b.removed[apPath] = true
+}
+
+func (b *accessPointsBatch) isEmpty() bool {
+	return len(b.added) == 0 && len(b.changed) == 0 && len(b.removed) == 0
+}
+
+func sortedAccessPointProps(props map[dbus.ObjectPath]map[string]dbus.Variant) []map[string]dbus.Variant {
+	paths := make([]string, 0, len(props))
+	for apPath := range props {
+		paths = append(paths, string(apPath))
+	}
+	sort.Strings(paths)
+	result := make([]map[string]dbus.Variant, 0, len(paths))
+	for _, apPath := range paths {
+		result = append(result, props[dbus.ObjectPath(apPath)])
+	}
+	return result
+}
+
+func (b *accessPointsBatch) result() (added, changed []map[string]dbus.Variant, removed []dbus.ObjectPath) {
+	added = sortedAccessPointProps(b.added)
+	changed = sortedAccessPointProps(b.changed)
+	removed = make([]dbus.ObjectPath, 0, len(b.removed))
+	for apPath := range b.removed {
+		removed = append(removed, apPath)
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		return removed[i] < removed[j]
+	})
+	return
+}
+
+func (m *Manager) isLegacyAccessPointSignalsEnabled() bool {
+	m.apBatchLock.Lock()
+	defer m.apBatchLock.Unlock()
+	return m.legacyApSignals
+}
+
+func (m *Manager) setLegacyAccessPointSignalsEnabled(enabled bool) {
+	m.apBatchLock.Lock()
+	m.legacyApSignals = enabled
+	m.apBatchLock.Unlock()
+}
+
+// queueAccessPointsChanged 记录热点变化，并在时间窗口结束后统一发送 AccessPointsChanged 信号
+func (m *Manager) queueAccessPointsChanged(devPath dbus.ObjectPath, fn func(batch *accessPointsBatch)) {
+	m.apBatchLock.Lock()
+	defer m.apBatchLock.Unlock()
+	if m.apBatches == nil {
+		m.apBatches = make(map[dbus.ObjectPath]*accessPointsBatch)
+	}
+	batch := m.apBatches[devPath]
+	if batch == nil {
+		batch = newAccessPointsBatch()
+		m.apBatches[devPath] = batch
+	}
+	fn(batch)
+
+	if m.apBatchTimer == nil {
+		m.apBatchTimer = time.AfterFunc(accessPointsChangedDelay, m.flushAccessPointsChanged)
+	}
+}
+
+func (m *Manager) flushAccessPointsChanged() {
+	m.apBatchLock.Lock()
+	batches := m.apBatches
+	m.apBatches = nil
+	m.apBatchTimer = nil
+	m.apBatchLock.Unlock()
+
+	for devPath, batch := range batches {
+		if batch.isEmpty() {
+			continue
+		}
+		added, changed, removed := batch.result()
+		err := m.service.Emit(m, "AccessPointsChanged", string(devPath), added, changed, removed)
+		if err != nil {
+			logger.Warning("failed to emit signal:", err)
+		}
+	}
+}
+
+func (m *Manager) stopAccessPointsChanged() {
+	m.apBatchLock.Lock()
+	defer m.apBatchLock.Unlock()
+	if m.apBatchTimer != nil {
+		m.apBatchTimer.Stop()
+		m.apBatchTimer = nil
+	}
+	m.apBatches = nil
+}
+
+func (m *Manager) emitAccessPointAdded(ap *accessPoint) {
+	props := ap.toVariantMap()
+	m.queueAccessPointsChanged(ap.devPath, func(batch *accessPointsBatch) {
+		batch.add(ap.Path, props)
+	})
+	if m.isLegacyAccessPointSignalsEnabled() {
+		apJSON, _ := marshalJSON(ap)
+		err := m.service.Emit(m, "AccessPointAdded", string(ap.devPath), apJSON)
+		if err != nil {
+			logger.Warning("failed to emit signal:", err)
+		}
+	}
+}
+
+// queueAccessPointChanged 热点属性变化只通过 AccessPointsChanged 通知，
+// 旧的客户端从 WirelessAccessPoints 属性获取变化，不发送 AccessPointPropertiesChanged
+func (m *Manager) queueAccessPointChanged(ap *accessPoint) {
+	props := ap.toVariantMap()
+	m.queueAccessPointsChanged(ap.devPath, func(batch *accessPointsBatch) {
+		batch.change(ap.Path, props)
+	})
+}
+
+func (m *Manager) emitAccessPointRemoved(ap *accessPoint) {
+	m.queueAccessPointsChanged(ap.devPath, func(batch *accessPointsBatch) {
+		batch.remove(ap.Path)
+	})
+	if m.isLegacyAccessPointSignalsEnabled() {
+		apJSON, _ := marshalJSON(ap)
+		err := m.service.Emit(m, "AccessPointRemoved", string(ap.devPath), apJSON)
+		if err != nil {
+			logger.Warning("failed to emit signal:", err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	dbus "github.com/godbus/dbus/v5"
+)
+
+// 最多保留的已移除热点记录，更早的 token 需要全量同步
+const maxRemovedAccessPointRecords = 256
+
+type removedAccessPoint struct {
+	devPath  dbus.ObjectPath
+	apPath   dbus.ObjectPath
+	revision uint64
+}
+
+// accessPointRevisions 记录热点列表的版本，用于增量获取热点变化，需在 accessPointsLock 中使用
+type accessPointRevisions struct {
+	revision uint64
+	removed  []removedAccessPoint
+	// 早于该版本的 token 无法计算增量
+	floor uint64
+}
+
+func (r *accessPointRevisions) next() uint64 {
+	r.revision++
+	return r.revision
+}
+
+func (r *accessPointRevisions) touch(ap *accessPoint) {
+	ap.revision = r.next()
+}
+
+func (r *accessPointRevisions) recordRemoved(ap *accessPoint) {
+	r.removed = append(r.removed, removedAccessPoint{
+		devPath:  ap.devPath,
+		apPath:   ap.Path,
+		revision: r.next(),
+	})
+	if len(r.removed) > maxRemovedAccessPointRecords {
+		n := len(r.removed) - maxRemovedAccessPointRecords
+		r.floor = r.removed[n-1].revision
+		r.removed = append(r.removed[:0], r.removed[n:]...)
+	}
+}
+
+// reset 丢弃所有记录，之后的增量请求都需要全量同步
+func (r *accessPointRevisions) reset() {
+	r.floor = r.next()
+	r.removed = nil
+}
+
+// diff 返回 since 之后变化和移除的热点，full 为 true 时 changed 为全部热点
+func (r *accessPointRevisions) diff(devPath dbus.ObjectPath, aps []*accessPoint, since uint64) (changed []*accessPoint,
+	removed []dbus.ObjectPath, full bool) {
+	full = since < r.floor || since > r.revision
+	for _, ap := range aps {
+		if full || ap.revision > since {
+			changed = append(changed, ap)
+		}
+	}
+	if full {
+		return
+	}
+	for _, item := range r.removed {
+		if item.devPath == devPath && item.revision > since {
+			removed = append(removed, item.apPath)
+		}
+	}
+	return
+}
+
+func (a *accessPoint) toVariantMap() map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"Ssid":         dbus.MakeVariant(a.Ssid),
+		"RawSsid":      dbus.MakeVariant(a.RawSsid),
+		"Bssid":        dbus.MakeVariant(a.Bssid),
+		"Secured":      dbus.MakeVariant(a.Secured),
+		"SecuredInEap": dbus.MakeVariant(a.SecuredInEap),
+		"Strength":     dbus.MakeVariant(a.Strength),
+		"Path":         dbus.MakeVariant(a.Path),
+		"Frequency":    dbus.MakeVariant(a.Frequency),
+		"Band":         dbus.MakeVariant(a.Band),
+		"Hidden":       dbus.MakeVariant(a.Hidden),
+		"Flags":        dbus.MakeVariant(a.Flags),
+		"KeyMgmt":      dbus.MakeVariant(a.KeyMgmt),
+		"Stale":        dbus.MakeVariant(a.Stale),
+		"IsPasspoint":  dbus.MakeVariant(a.IsPasspoint),
+		"Ignored":      dbus.MakeVariant(a.Ignored),
+	}
+}
+
+func accessPointsToVariantMaps(aps []*accessPoint) []map[string]dbus.Variant {
+	result := make([]map[string]dbus.Variant, 0, len(aps))
+	for _, ap := range aps {
+		result = append(result, ap.toVariantMap())
+	}
+	return result
+}
+
+// GetAccessPoints2 return all access points of the device as a{sv}
+// list, and a token which could be passed to GetAccessPointsDiff.
+func (m *Manager) GetAccessPoints2(devPath dbus.ObjectPath) (aps []map[string]dbus.Variant, token uint64,
+	busErr *dbus.Error) {
+	m.accessPointsLock.Lock()
+	defer m.accessPointsLock.Unlock()
+	return accessPointsToVariantMaps(m.accessPoints[devPath]), m.apRevisions.revision, nil
+}
+
+// GetAccessPointsDiff return the access points of the device changed
+// or removed since the token. If full is true, the token is too old and
+// changed contains all access points, the caller should replace its
+// list instead of merging.
+func (m *Manager) GetAccessPointsDiff(devPath dbus.ObjectPath, sinceToken uint64) (changed []map[string]dbus.Variant,
+	removed []dbus.ObjectPath, token uint64, full bool, busErr *dbus.Error) {
+	m.accessPointsLock.Lock()
+	defer m.accessPointsLock.Unlock()
+	changedAps, removed, full := m.apRevisions.diff(devPath, m.accessPoints[devPath], sinceToken)
+	if removed == nil {
+		removed = make([]dbus.ObjectPath, 0)
+	}
+	return accessPointsToVariantMaps(changedAps), removed, m.apRevisions.revision, full, nil
+}
@@ -0,0 +1,349 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func newTestAccessPoints() []*accessPoint {
+	return []*accessPoint{
+		{Ssid: "deepin", Strength: 40, Frequency: 2412, Path: "/ap/0"},
+		{Ssid: "deepin", Strength: 70, Frequency: 2437, Path: "/ap/1"},
+		{Ssid: "deepin", Strength: 30, Frequency: 5180, Path: "/ap/2"},
+		{Ssid: "deepin", Strength: 50, Frequency: 5745, Path: "/ap/3"},
+		{Ssid: "uos", Strength: 90, Frequency: 5180, Path: "/ap/4"},
+		{Ssid: "uos", Strength: 80, Frequency: 2412, Path: "/ap/5"},
+	}
+}
+
+func (*testWrapper) TestFindAPByBand(c *C.C) {
+	aps := newTestAccessPoints()
+
+	tests := []struct {
+		ssid string
+		band string
+		path string
+	}{
+		{"deepin", "", "/ap/1"},
+		{"deepin", "bg", "/ap/1"},
+		{"deepin", "a", "/ap/3"},
+		{"uos", "", "/ap/4"},
+		{"uos", "a", "/ap/4"},
+		{"uos", "bg", "/ap/5"},
+	}
+	for _, t := range tests {
+		ap := findAPByBand(t.ssid, aps, t.band, false)
+		c.Assert(ap, C.NotNil)
+		c.Check(string(ap.Path), C.Equals, t.path)
+	}
+
+	c.Check(findAPByBand("unknown", aps, "", false), C.IsNil)
+	c.Check(findAPByBand("deepin", aps, "unknown", false), C.IsNil)
+	c.Check(findAPByBand("deepin", nil, "a", false), C.IsNil)
+	c.Check(findAPByBand("deepin", []*accessPoint{
+		{Ssid: "deepin", Strength: 90, Frequency: 2412},
+	}, "a", false), C.IsNil)
+	c.Check(findAPByBand("deepin", []*accessPoint{
+		{Ssid: "deepin", Strength: 90, Frequency: 5180},
+		{Ssid: "deepin", Strength: 60, Frequency: 5975, Path: "/ap/6g"},
+	}, "6g", false).Path, C.Equals, dbus.ObjectPath("/ap/6g"))
+
+	// 按容量选择时只在指定频段内比较
+	c.Check(findAPByBand("deepin", []*accessPoint{
+		{Ssid: "deepin", Strength: 80, Frequency: 2412, MaxBitrate: 866000, Load: apLoadUnknown, Path: "/ap/bg"},
+		{Ssid: "deepin", Strength: 70, Frequency: 5180, MaxBitrate: 54000, Load: apLoadUnknown, Path: "/ap/a0"},
+		{Ssid: "deepin", Strength: 65, Frequency: 5745, MaxBitrate: 866000, Load: apLoadUnknown, Path: "/ap/a1"},
+	}, "a", true).Path, C.Equals, dbus.ObjectPath("/ap/a1"))
+}
+
+func (*testWrapper) TestIsFrequencyInBand(c *C.C) {
+	c.Check(isFrequencyInBand(2412, "bg"), C.Equals, true)
+	c.Check(isFrequencyInBand(2484, "bg"), C.Equals, true)
+	c.Check(isFrequencyInBand(2412, "a"), C.Equals, false)
+	c.Check(isFrequencyInBand(4915, "a"), C.Equals, true)
+	c.Check(isFrequencyInBand(5825, "a"), C.Equals, true)
+	c.Check(isFrequencyInBand(5825, "bg"), C.Equals, false)
+	c.Check(isFrequencyInBand(5180, ""), C.Equals, false)
+	c.Check(isFrequencyInBand(5955, "6g"), C.Equals, true)
+	c.Check(isFrequencyInBand(5955, "a"), C.Equals, false)
+
+	c.Check(getBandByFrequency(2437), C.Equals, "bg")
+	c.Check(getBandByFrequency(5180), C.Equals, "a")
+	c.Check(getBandByFrequency(6115), C.Equals, "6g")
+	c.Check(getBandByFrequency(60480), C.Equals, "unknown")
+}
+
+func (*testWrapper) TestDoParseApSecType(c *C.C) {
+	tests := []struct {
+		flags, wpaFlags, rsnFlags uint32
+		secType                   apSecType
+	}{
+		{nm.NM_802_11_AP_FLAGS_NONE, nm.NM_802_11_AP_SEC_NONE, nm.NM_802_11_AP_SEC_NONE, apSecNone},
+		{nm.NM_802_11_AP_FLAGS_PRIVACY, nm.NM_802_11_AP_SEC_NONE, nm.NM_802_11_AP_SEC_NONE, apSecWep},
+		{nm.NM_802_11_AP_FLAGS_PRIVACY, nm.NM_802_11_AP_SEC_NONE, nm.NM_802_11_AP_SEC_KEY_MGMT_PSK, apSecPsk},
+		{nm.NM_802_11_AP_FLAGS_PRIVACY, nm.NM_802_11_AP_SEC_NONE, nm.NM_802_11_AP_SEC_KEY_MGMT_802_1X, apSecEap},
+		{nm.NM_802_11_AP_FLAGS_PRIVACY, nm.NM_802_11_AP_SEC_NONE, nm.NM_802_11_AP_SEC_KEY_MGMT_SAE, apSecSae},
+		// WPA2/WPA3 混合模式优先使用 sae
+		{nm.NM_802_11_AP_FLAGS_PRIVACY, nm.NM_802_11_AP_SEC_NONE,
+			nm.NM_802_11_AP_SEC_KEY_MGMT_PSK | nm.NM_802_11_AP_SEC_KEY_MGMT_SAE, apSecSae},
+		{nm.NM_802_11_AP_FLAGS_PRIVACY, nm.NM_802_11_AP_SEC_NONE, nm.NM_802_11_AP_SEC_KEY_MGMT_OWE, apSecOwe},
+		{nm.NM_802_11_AP_FLAGS_NONE, nm.NM_802_11_AP_SEC_NONE, nm.NM_802_11_AP_SEC_KEY_MGMT_OWE_TM, apSecNone},
+	}
+	for _, t := range tests {
+		c.Check(doParseApSecType(t.flags, t.wpaFlags, t.rsnFlags), C.Equals, t.secType)
+	}
+}
+
+func (*testWrapper) TestNewWirelessConnectionDataKeyMgmt(c *C.C) {
+	for _, keyMgmt := range []string{"wpa-psk", "sae", "owe"} {
+		data := newWirelessConnectionData("test", "uuid", []byte("test"), keyMgmt, "")
+		c.Check(getSettingWirelessSecurityKeyMgmt(data), C.Equals, keyMgmt)
+		secType, err := getApSecTypeFromConnData(data)
+		c.Check(err, C.IsNil)
+		c.Check(secType.String(), C.Equals, keyMgmt)
+	}
+
+	data := newWirelessConnectionData("test", "uuid", []byte("test"), "none", "")
+	c.Check(isSettingExists(data, nm.NM_SETTING_WIRELESS_SECURITY_SETTING_NAME), C.Equals, false)
+}
+
+func (*testWrapper) TestSetConnectionBssidPin(c *C.C) {
+	bssid := []byte{0, 1, 2, 3, 4, 5}
+	data := newWirelessConnectionData("test", "uuid", []byte("test"), "wpa-psk", "")
+	c.Check(setConnectionBssidPin(data, nil), C.Equals, false)
+
+	c.Check(setConnectionBssidPin(data, bssid), C.Equals, true)
+	c.Check(getSettingWirelessBssid(data), C.DeepEquals, bssid)
+	c.Check(setConnectionBssidPin(data, nil), C.Equals, true)
+	c.Check(isSettingWirelessBssidExists(data), C.Equals, false)
+	c.Check(isSettingExists(data, nm.NM_SETTING_USER_SETTING_NAME), C.Equals, false)
+
+	// 用户设置的 bssid 不清除
+	setSettingWirelessBssid(data, bssid)
+	c.Check(setConnectionBssidPin(data, nil), C.Equals, false)
+	c.Check(getSettingWirelessBssid(data), C.DeepEquals, bssid)
+}
+
+func (*testWrapper) TestCheckHiddenApKeyMgmt(c *C.C) {
+	for _, keyMgmt := range []string{"none", "wep", "wpa-psk", "sae", "owe"} {
+		c.Check(checkHiddenApKeyMgmt(keyMgmt), C.IsNil)
+	}
+	c.Check(checkHiddenApKeyMgmt("wpa-eap"), C.NotNil)
+	c.Check(checkHiddenApKeyMgmt(""), C.NotNil)
+	c.Check(checkHiddenApKeyMgmt("wpa3"), C.NotNil)
+}
+
+func (*testWrapper) TestIsStrengthChanged(c *C.C) {
+	c.Check(isStrengthChanged(50, 50, 0), C.Equals, false)
+	c.Check(isStrengthChanged(50, 51, 0), C.Equals, true)
+	c.Check(isStrengthChanged(50, 54, 5), C.Equals, false)
+	c.Check(isStrengthChanged(50, 55, 5), C.Equals, true)
+	c.Check(isStrengthChanged(50, 46, 5), C.Equals, false)
+	c.Check(isStrengthChanged(50, 45, 5), C.Equals, true)
+}
+
+func (*testWrapper) TestAccessPointSetProps(c *C.C) {
+	ap := &accessPoint{}
+	c.Check(ap.setProps("deepin", "00:11:22:33:44:55", apSecPsk, 50, 2412, 1, "wpa-psk", 5), C.Equals, true)
+	c.Check(ap.Secured, C.Equals, true)
+	// 信号强度小幅波动不更新
+	c.Check(ap.setProps("deepin", "00:11:22:33:44:55", apSecPsk, 53, 2412, 1, "wpa-psk", 5), C.Equals, false)
+	c.Check(ap.Strength, C.Equals, uint8(50))
+	c.Check(ap.setProps("deepin", "00:11:22:33:44:55", apSecPsk, 56, 2412, 1, "wpa-psk", 5), C.Equals, true)
+	c.Check(ap.Strength, C.Equals, uint8(56))
+	// 其他属性变化时立即更新
+	c.Check(ap.setProps("deepin", "00:11:22:33:44:55", apSecSae, 57, 2412, 1, "sae", 5), C.Equals, true)
+	c.Check(ap.KeyMgmt, C.Equals, "sae")
+	c.Check(ap.setProps("deepin", "00:11:22:33:44:55", apSecSae, 57, 5180, 1, "sae", 5), C.Equals, true)
+	c.Check(ap.Frequency, C.Equals, uint32(5180))
+}
+
+func BenchmarkFindAPByBand(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		aps := make([]*accessPoint, 0, n)
+		for i := 0; i < n; i++ {
+			freq := uint32(2412)
+			if i%2 == 0 {
+				freq = 5180
+			}
+			aps = append(aps, &accessPoint{
+				Ssid:      "deepin",
+				Strength:  uint8(i % 100),
+				Frequency: freq,
+			})
+		}
+		b.Run(fmt.Sprintf("aps-%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				findAPByBand("deepin", aps, "a", false)
+			}
+		})
+	}
+}
+
+func (*testWrapper) TestAccessPointRevisionsDiff(c *C.C) {
+	var r accessPointRevisions
+	dev := dbus.ObjectPath("/dev/0")
+	ap1 := &accessPoint{devPath: dev, Path: "/ap/1"}
+	ap2 := &accessPoint{devPath: dev, Path: "/ap/2"}
+	r.touch(ap1)
+	r.touch(ap2)
+	token := r.revision
+
+	// 无变化
+	changed, removed, full := r.diff(dev, []*accessPoint{ap1, ap2}, token)
+	c.Check(changed, C.HasLen, 0)
+	c.Check(removed, C.HasLen, 0)
+	c.Check(full, C.Equals, false)
+
+	r.touch(ap2)
+	ap3 := &accessPoint{devPath: dev, Path: "/ap/3"}
+	r.recordRemoved(ap3)
+	changed, removed, full = r.diff(dev, []*accessPoint{ap1, ap2}, token)
+	c.Check(changed, C.DeepEquals, []*accessPoint{ap2})
+	c.Check(removed, C.DeepEquals, []dbus.ObjectPath{"/ap/3"})
+	c.Check(full, C.Equals, false)
+
+	// 其他设备的移除记录不返回
+	_, removed, _ = r.diff("/dev/1", nil, token)
+	c.Check(removed, C.HasLen, 0)
+
+	// 未知的 token 需要全量同步
+	changed, _, full = r.diff(dev, []*accessPoint{ap1, ap2}, r.revision+1)
+	c.Check(changed, C.HasLen, 2)
+	c.Check(full, C.Equals, true)
+
+	r.reset()
+	changed, removed, full = r.diff(dev, []*accessPoint{ap1, ap2}, token)
+	c.Check(changed, C.HasLen, 2)
+	c.Check(removed, C.HasLen, 0)
+	c.Check(full, C.Equals, true)
+}
+
+func (*testWrapper) TestAccessPointRevisionsRemovedLimit(c *C.C) {
+	var r accessPointRevisions
+	dev := dbus.ObjectPath("/dev/0")
+	for i := 0; i < maxRemovedAccessPointRecords+10; i++ {
+		r.recordRemoved(&accessPoint{devPath: dev, Path: dbus.ObjectPath(fmt.Sprintf("/ap/%d", i))})
+	}
+	c.Check(r.removed, C.HasLen, maxRemovedAccessPointRecords)
+	_, _, full := r.diff(dev, nil, 5)
+	c.Check(full, C.Equals, true)
+	_, removed, full := r.diff(dev, nil, r.revision-1)
+	c.Check(full, C.Equals, false)
+	c.Check(removed, C.HasLen, 1)
+}
+
+func (*testWrapper) TestAccessPointsBatch(c *C.C) {
+	props := func(apPath string) map[string]dbus.Variant {
+		return map[string]dbus.Variant{"Path": dbus.MakeVariant(dbus.ObjectPath(apPath))}
+	}
+	batch := newAccessPointsBatch()
+	c.Check(batch.isEmpty(), C.Equals, true)
+
+	batch.add("/ap/2", props("/ap/2"))
+	batch.add("/ap/1", props("/ap/1"))
+	// 窗口内新增的热点属性变化仍作为新增
+	batch.change("/ap/1", props("/ap/1"))
+	batch.change("/ap/3", props("/ap/3"))
+	// 窗口内新增后移除的热点不需要通知
+	batch.add("/ap/4", props("/ap/4"))
+	batch.remove("/ap/4")
+	// 变化后移除只需通知移除
+	batch.change("/ap/5", props("/ap/5"))
+	batch.remove("/ap/5")
+
+	added, changed, removed := batch.result()
+	c.Check(added, C.DeepEquals, []map[string]dbus.Variant{props("/ap/1"), props("/ap/2")})
+	c.Check(changed, C.DeepEquals, []map[string]dbus.Variant{props("/ap/3")})
+	c.Check(removed, C.DeepEquals, []dbus.ObjectPath{"/ap/5"})
+
+	// 移除后又新增
+	batch.add("/ap/5", props("/ap/5"))
+	_, _, removed = batch.result()
+	c.Check(removed, C.HasLen, 0)
+}
+
+func (*testWrapper) TestShouldRoam(c *C.C) {
+	// 当前 5G 热点信号良好
+	c.Check(shouldRoam(roamingPolicyPrefer5G, 65, 70, 5180, 100), C.Equals, false)
+	c.Check(shouldRoam(roamingPolicyPrefer5G, 65, 40, 2412, 60), C.Equals, true)
+	c.Check(shouldRoam(roamingPolicyPrefer5G, 65, 40, 2412, 55), C.Equals, false)
+	c.Check(shouldRoam(roamingPolicyPrefer5G, 80, 70, 5180, 90), C.Equals, true)
+	c.Check(shouldRoam(roamingPolicyAggressive, 65, 70, 5180, 80), C.Equals, true)
+	c.Check(shouldRoam(roamingPolicyAggressive, 65, 70, 2412, 75), C.Equals, false)
+	c.Check(shouldRoam(roamingPolicyOff, 65, 10, 2412, 100), C.Equals, false)
+}
+
+func newTestAccessPointsManager(devPath dbus.ObjectPath, n int) *Manager {
+	m := &Manager{
+		accessPoints:       make(map[dbus.ObjectPath][]*accessPoint),
+		accessPointsByPath: make(map[dbus.ObjectPath]*accessPoint),
+	}
+	for i := 0; i < n; i++ {
+		m.indexAccessPoint(&accessPoint{
+			devPath: devPath,
+			Path:    dbus.ObjectPath(fmt.Sprintf("%s/ap/%d", devPath, i)),
+		})
+	}
+	return m
+}
+
+func (*testWrapper) TestGetAccessPoint(c *C.C) {
+	m := newTestAccessPointsManager("/dev/0", 3)
+	ap := m.getAccessPoint("/dev/0", "/dev/0/ap/1")
+	c.Assert(ap, C.NotNil)
+	c.Check(ap.Path, C.Equals, dbus.ObjectPath("/dev/0/ap/1"))
+	c.Check(m.accessPoints["/dev/0"], C.HasLen, 3)
+	c.Check(m.accessPoints["/dev/0"][1], C.Equals, ap)
+
+	// 热点属于其他设备
+	c.Check(m.getAccessPoint("/dev/1", "/dev/0/ap/1"), C.IsNil)
+	c.Check(m.isAccessPointExists("/dev/0", "/dev/0/ap/3"), C.Equals, false)
+}
+
+func BenchmarkGetAccessPoint(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		m := newTestAccessPointsManager("/dev/0", n)
+		apPath := dbus.ObjectPath(fmt.Sprintf("/dev/0/ap/%d", n-1))
+		b.Run(fmt.Sprintf("aps-%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m.getAccessPoint("/dev/0", apPath)
+			}
+		})
+	}
+}
+
+func BenchmarkIndexAccessPoint(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		b.Run(fmt.Sprintf("aps-%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				newTestAccessPointsManager("/dev/0", n)
+			}
+		})
+	}
+}
+
+func (*testWrapper) TestGetAccessPointRawSsid(c *C.C) {
+	// GBK 编码的“测试”
+	rawSsid := []byte{0xb2, 0xe2, 0xca, 0xd4}
+	m := newTestAccessPointsManager("/dev/0", 0)
+	m.indexAccessPoint(&accessPoint{devPath: "/dev/0", Path: "/ap/0", Ssid: "测试", RawSsid: rawSsid})
+	m.indexAccessPoint(&accessPoint{devPath: "/dev/0", Path: "/ap/1", Ssid: "deepin", RawSsid: []byte("deepin")})
+
+	c.Check(m.getAccessPointRawSsid("/dev/0", "测试"), C.DeepEquals, rawSsid)
+	c.Check(m.getAccessPointRawSsid("/dev/0", "deepin"), C.DeepEquals, []byte("deepin"))
+	c.Check(m.getAccessPointRawSsid("/dev/1", "测试"), C.DeepEquals, []byte("测试"))
+
+	apJSON, err := marshalJSON(m.accessPoints["/dev/0"][0])
+	c.Assert(err, C.IsNil)
+	c.Check(strings.Contains(apJSON, `"RawSsid":"suLK1A=="`), C.Equals, true)
+}
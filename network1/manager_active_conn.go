@@ -28,6 +28,7 @@ type activeConnection struct {
 	State          uint32
 	Vpn            bool
 	SpecificObject dbus.ObjectPath
+	Metered        bool // 是否按流量计费
 }
 
 var frequencyChannelMap = map[uint32]int32{
@@ -225,6 +226,9 @@ func (m *Manager) initActiveConnectionManage() {
 				{
 					// ipconfig changed
 					go m.updateActiveConnectionInfo()
+					if props, ok := sig.Body[1].(map[string]dbus.Variant); ok {
+						go m.recordIPChangeEvent(sig.Path, props)
+					}
 				}
 			}
 		}
@@ -278,6 +282,7 @@ func (m *Manager) doHandleVpnNotification(apath dbus.ObjectPath, state, reason u
 	}
 	// save current state
 	aConn.vpnState = state
+	go m.handleVpnKillSwitch(aConn.Uuid, state, reason)
 
 	// notification for vpn
 	switch state {
@@ -322,6 +327,10 @@ func (m *Manager) updateActiveConnState(apath dbus.ObjectPath, state uint32) {
 		return
 	}
 	aConn.State = state
+	if state == nm.NM_ACTIVE_CONNECTION_STATE_ACTIVATED {
+		// 激活后 NetworkManager 才能确定设备是否按流量计费
+		aConn.Metered = getActiveConnectionMetered(aConn.Uuid, aConn.Devices)
+	}
 
 	m.updatePropActiveConnections()
 }
@@ -344,6 +353,7 @@ func (m *Manager) newActiveConnection(path dbus.ObjectPath) (aconn *activeConnec
 		aconn.vpnType = nmGetConnectionVpnType(cpath)
 	}
 	aconn.SpecificObject, _ = nmAConn.SpecificObject().Get(0)
+	aconn.Metered = getActiveConnectionMetered(aconn.Uuid, aconn.Devices)
 
 	return
 }
@@ -445,13 +455,7 @@ func (m *Manager) doGetActiveConnectionInfo(apath, devPath dbus.ObjectPath) (act
 		hotspotInfo.Ssid = decodeSsid(ssid)
 		frequency, _ := nmAp.Frequency().Get(0)
 
-		if frequency >= 4915 && frequency <= 5825 {
-			hotspotInfo.Band = "a"
-		} else if frequency >= 2412 && frequency <= 2484 {
-			hotspotInfo.Band = "bg"
-		} else {
-			hotspotInfo.Band = "unknown"
-		}
+		hotspotInfo.Band = getBandByFrequency(frequency)
 
 		hotspotInfo.Channel = frequencyChannelMap[frequency]
 	}
@@ -477,6 +481,8 @@ func (m *Manager) doGetActiveConnectionInfo(apath, devPath dbus.ObjectPath) (act
 			security = Tr("WPA/WPA2 Personal")
 		case "sae":
 			security = Tr("WPA3 Personal")
+		case "owe":
+			security = Tr("Enhanced Open")
 		case "wpa-eap":
 			use8021xSecurity = true
 		}
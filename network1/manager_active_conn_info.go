@@ -0,0 +1,184 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"strconv"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// NetworkManager 中 DHCP 租约信息的 option key，dhclient 和内置客户端一致
+const (
+	dhcp4OptionLeaseTime = "dhcp_lease_time"
+	dhcp4OptionServer    = "dhcp_server_identifier"
+	dhcp6OptionLeaseTime = "max_life"
+	dhcp6OptionServer    = "dhcp6_server_id"
+	dhcpOptionExpiry     = "expiry"
+)
+
+type dhcpInfo struct {
+	Server    string
+	LeaseTime uint32 // 租约时长，单位秒
+	Expiry    int64  // 租约到期的 unix 时间戳
+	Options   map[string]string
+}
+
+type ipConfigInfo struct {
+	Method      string
+	Addresses   []addressDataItem
+	Gateway     string
+	Nameservers []string
+	Dhcp        *dhcpInfo // 没有通过 DHCP 获取地址时为 nil
+}
+
+type activeConnectionInfo2 struct {
+	IsPrimaryConnection bool
+	Device              dbus.ObjectPath
+	ActiveConnection    dbus.ObjectPath
+	SettingPath         dbus.ObjectPath
+	SpecificObject      dbus.ObjectPath
+	ConnectionType      string
+	Protocol            string
+	ConnectionName      string
+	ConnectionUuid      string
+	MobileNetworkType   string
+	Security            string
+	DeviceType          string
+	DeviceInterface     string
+	HwAddress           string
+	LinkSpeed           uint32 // 单位 Mbps，未知时为 0
+	IPv4                ipConfigInfo
+	IPv6                ipConfigInfo
+	Hotspot             hotspotConnectionInfo
+}
+
+// parseDhcpOptions 解析 NetworkManager Dhcp4Config/Dhcp6Config 的 Options 属性
+func parseDhcpOptions(options map[string]dbus.Variant, leaseTimeKey, serverKey string) *dhcpInfo {
+	if len(options) == 0 {
+		return nil
+	}
+	info := &dhcpInfo{
+		Options: make(map[string]string, len(options)),
+	}
+	for key, variant := range options {
+		value, ok := variant.Value().(string)
+		if !ok {
+			continue
+		}
+		info.Options[key] = value
+	}
+	if leaseTime, err := strconv.ParseUint(info.Options[leaseTimeKey], 10, 32); err == nil {
+		info.LeaseTime = uint32(leaseTime)
+	}
+	if expiry, err := strconv.ParseInt(info.Options[dhcpOptionExpiry], 10, 64); err == nil {
+		info.Expiry = expiry
+	}
+	info.Server = info.Options[serverKey]
+	return info
+}
+
+func nmGetDhcp4Info(path dbus.ObjectPath) *dhcpInfo {
+	dhcp4config, err := nmNewDhcp4Config(path)
+	if err != nil {
+		return nil
+	}
+	options, _ := dhcp4config.Options().Get(0)
+	return parseDhcpOptions(options, dhcp4OptionLeaseTime, dhcp4OptionServer)
+}
+
+func nmGetDhcp6Info(path dbus.ObjectPath) *dhcpInfo {
+	dhcp6config, err := nmNewDhcp6Config(path)
+	if err != nil {
+		return nil
+	}
+	options, _ := dhcp6config.Options().Get(0)
+	return parseDhcpOptions(options, dhcp6OptionLeaseTime, dhcp6OptionServer)
+}
+
+// GetActiveConnectionInfo2 returns detailed information of all active connections,
+// including IP addresses, gateways, DNS, DHCP lease and link speed, as a JSON
+// encoded array of activeConnectionInfo2.
+func (m *Manager) GetActiveConnectionInfo2() (acinfosJSON string, busErr *dbus.Error) {
+	acinfos := make([]activeConnectionInfo2, 0)
+	// get activated devices' connection information
+	for _, devPath := range nmGetDevices() {
+		if isDeviceStateActivated(nmGetDeviceState(devPath)) {
+			if info, err := m.doGetActiveConnectionInfo2(nmGetDeviceActiveConnection(devPath), devPath); err == nil {
+				acinfos = append(acinfos, info)
+			}
+		}
+	}
+	// get activated vpn connection information
+	for _, apath := range nmGetVpnActiveConnections() {
+		if nmAConn, err := nmNewActiveConnection(apath); err == nil {
+			if devs, _ := nmAConn.Devices().Get(0); len(devs) > 0 {
+				if info, err := m.doGetActiveConnectionInfo2(apath, devs[0]); err == nil {
+					acinfos = append(acinfos, info)
+				}
+			}
+		}
+	}
+	acinfosJSON, err := marshalJSON(acinfos)
+	return acinfosJSON, dbusutil.ToError(err)
+}
+
+func (m *Manager) doGetActiveConnectionInfo2(apath, devPath dbus.ObjectPath) (acInfo activeConnectionInfo2, err error) {
+	info, err := m.doGetActiveConnectionInfo(apath, devPath)
+	if err != nil {
+		return
+	}
+	acInfo = activeConnectionInfo2{
+		IsPrimaryConnection: info.IsPrimaryConnection,
+		Device:              info.Device,
+		ActiveConnection:    apath,
+		SettingPath:         info.SettingPath,
+		SpecificObject:      info.SpecificObject,
+		ConnectionType:      info.ConnectionType,
+		Protocol:            info.Protocol,
+		ConnectionName:      info.ConnectionName,
+		ConnectionUuid:      info.ConnectionUuid,
+		MobileNetworkType:   info.MobileNetworkType,
+		Security:            info.Security,
+		DeviceType:          info.DeviceType,
+		DeviceInterface:     info.DeviceInterface,
+		HwAddress:           info.HwAddress,
+		LinkSpeed:           nmGeneralGetDeviceSpeedNumeric(devPath),
+		IPv4: ipConfigInfo{
+			Addresses:   info.IPv4.Addresses,
+			Gateway:     info.IPv4.Gateway,
+			Nameservers: info.IPv4.Nameservers,
+		},
+		IPv6: ipConfigInfo{
+			Addresses:   info.IPv6.Addresses,
+			Gateway:     info.IPv6.Gateway,
+			Nameservers: info.IPv6.Nameservers,
+		},
+		Hotspot: info.Hotspot,
+	}
+
+	if data, err := nmGetConnectionData(info.SettingPath); err == nil {
+		if isSettingExists(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME) {
+			acInfo.IPv4.Method = getSettingIP4ConfigMethod(data)
+		}
+		if isSettingExists(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME) {
+			acInfo.IPv6.Method = getSettingIP6ConfigMethod(data)
+		}
+	}
+
+	nmAConn, err := nmNewActiveConnection(apath)
+	if err != nil {
+		return
+	}
+	if dhcp4Path, _ := nmAConn.Dhcp4Config().Get(0); isNmObjectPathValid(dhcp4Path) {
+		acInfo.IPv4.Dhcp = nmGetDhcp4Info(dhcp4Path)
+	}
+	if dhcp6Path, _ := nmAConn.Dhcp6Config().Get(0); isNmObjectPathValid(dhcp6Path) {
+		acInfo.IPv6.Dhcp = nmGetDhcp6Info(dhcp6Path)
+	}
+	return acInfo, nil
+}
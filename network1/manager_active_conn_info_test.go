@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	dbus "github.com/godbus/dbus/v5"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestParseDhcpOptions(c *C.C) {
+	c.Check(parseDhcpOptions(nil, dhcp4OptionLeaseTime, dhcp4OptionServer), C.IsNil)
+
+	info := parseDhcpOptions(map[string]dbus.Variant{
+		"dhcp_lease_time":        dbus.MakeVariant("86400"),
+		"dhcp_server_identifier": dbus.MakeVariant("192.168.1.1"),
+		"expiry":                 dbus.MakeVariant("1700000000"),
+		"routers":                dbus.MakeVariant("192.168.1.1"),
+	}, dhcp4OptionLeaseTime, dhcp4OptionServer)
+	c.Assert(info, C.NotNil)
+	c.Check(info.LeaseTime, C.Equals, uint32(86400))
+	c.Check(info.Server, C.Equals, "192.168.1.1")
+	c.Check(info.Expiry, C.Equals, int64(1700000000))
+	c.Check(info.Options["routers"], C.Equals, "192.168.1.1")
+
+	// 租约时长无法解析时为 0
+	info = parseDhcpOptions(map[string]dbus.Variant{
+		"max_life":        dbus.MakeVariant("forever"),
+		"dhcp6_server_id": dbus.MakeVariant("0:1:0:1"),
+	}, dhcp6OptionLeaseTime, dhcp6OptionServer)
+	c.Assert(info, C.NotNil)
+	c.Check(info.LeaseTime, C.Equals, uint32(0))
+	c.Check(info.Server, C.Equals, "0:1:0:1")
+}
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/utils"
+)
+
+// ActivationProgress 信号中的激活阶段
+const (
+	activationStageAuthenticating = "authenticating"
+	activationStageGettingIP      = "getting-ip"
+	activationStageDone           = "done"
+	activationStageFailed         = "failed"
+)
+
+// 超时未完成的激活不再跟踪，包含等待用户输入密码的时间
+const apActivationTimeout = 2 * time.Minute
+
+type apActivation struct {
+	token  string
+	apPath dbus.ObjectPath
+	stage  string
+	timer  *time.Timer
+}
+
+// getActivationStage 将设备状态映射为激活阶段，不对应任何阶段时返回空字符串
+func getActivationStage(state uint32) string {
+	switch state {
+	case nm.NM_DEVICE_STATE_PREPARE, nm.NM_DEVICE_STATE_CONFIG, nm.NM_DEVICE_STATE_NEED_AUTH:
+		return activationStageAuthenticating
+	case nm.NM_DEVICE_STATE_IP_CONFIG, nm.NM_DEVICE_STATE_IP_CHECK, nm.NM_DEVICE_STATE_SECONDARIES:
+		return activationStageGettingIP
+	case nm.NM_DEVICE_STATE_ACTIVATED:
+		return activationStageDone
+	case nm.NM_DEVICE_STATE_FAILED:
+		return activationStageFailed
+	}
+	return ""
+}
+
+// ActivateAccessPointAsync works like ActivateAccessPoint, but returns
+// an activation token immediately, the progress of the activation is
+// reported by the ActivationProgress signal with the token, stage is
+// authenticating, getting-ip, done or failed.
+func (m *Manager) ActivateAccessPointAsync(uuid string, apPath, devPath dbus.ObjectPath) (token string,
+	busErr *dbus.Error) {
+	act := &apActivation{
+		token:  utils.GenUuid(),
+		apPath: apPath,
+	}
+	m.startApActivation(devPath, act)
+
+	go func() {
+		_, err := m.activateAccessPoint(uuid, apPath, devPath, false)
+		if err != nil {
+			logger.Warning("failed to activate access point:", err)
+			m.finishApActivation(devPath, act, activationStageFailed)
+		}
+	}()
+	return act.token, nil
+}
+
+// startApActivation 开始跟踪设备上的激活，同一设备上未完成的激活视为失败
+func (m *Manager) startApActivation(devPath dbus.ObjectPath, act *apActivation) {
+	m.apActivationsLock.Lock()
+	prev := m.apActivations[devPath]
+	m.apActivations[devPath] = act
+	act.timer = time.AfterFunc(apActivationTimeout, func() {
+		m.finishApActivation(devPath, act, activationStageFailed)
+	})
+	m.apActivationsLock.Unlock()
+
+	if prev != nil {
+		prev.timer.Stop()
+		m.emitActivationProgress(prev.token, activationStageFailed)
+	}
+}
+
+// finishApActivation 结束跟踪设备上的激活，激活已经结束时不发送信号
+func (m *Manager) finishApActivation(devPath dbus.ObjectPath, act *apActivation, stage string) {
+	m.apActivationsLock.Lock()
+	if m.apActivations[devPath] != act {
+		m.apActivationsLock.Unlock()
+		return
+	}
+	act.timer.Stop()
+	delete(m.apActivations, devPath)
+	m.apActivationsLock.Unlock()
+
+	m.emitActivationProgress(act.token, stage)
+}
+
+// updateApActivation 根据设备状态变化发送 ActivationProgress 信号
+func (m *Manager) updateApActivation(dev *device, newState, oldState, reason uint32) {
+	m.apActivationsLock.Lock()
+	act := m.apActivations[dev.Path]
+	m.apActivationsLock.Unlock()
+	if act == nil {
+		return
+	}
+
+	if isConnectionFailed(newState, oldState, reason) {
+		m.finishApActivation(dev.Path, act, activationStageFailed)
+		return
+	}
+	stage := getActivationStage(newState)
+	if stage == activationStageDone {
+		m.finishApActivation(dev.Path, act, stage)
+		return
+	}
+
+	m.apActivationsLock.Lock()
+	changed := stage != "" && stage != act.stage && m.apActivations[dev.Path] == act
+	if changed {
+		act.stage = stage
+	}
+	m.apActivationsLock.Unlock()
+	if changed {
+		m.emitActivationProgress(act.token, stage)
+	}
+}
+
+func (m *Manager) emitActivationProgress(token, stage string) {
+	logger.Debugf("activation %s progress: %s", token, stage)
+	err := m.service.Emit(m, "ActivationProgress", token, stage)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
@@ -0,0 +1,21 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestGetActivationStage(c *C.C) {
+	c.Check(getActivationStage(nm.NM_DEVICE_STATE_PREPARE), C.Equals, activationStageAuthenticating)
+	c.Check(getActivationStage(nm.NM_DEVICE_STATE_NEED_AUTH), C.Equals, activationStageAuthenticating)
+	c.Check(getActivationStage(nm.NM_DEVICE_STATE_IP_CONFIG), C.Equals, activationStageGettingIP)
+	c.Check(getActivationStage(nm.NM_DEVICE_STATE_IP_CHECK), C.Equals, activationStageGettingIP)
+	c.Check(getActivationStage(nm.NM_DEVICE_STATE_ACTIVATED), C.Equals, activationStageDone)
+	c.Check(getActivationStage(nm.NM_DEVICE_STATE_FAILED), C.Equals, activationStageFailed)
+	c.Check(getActivationStage(nm.NM_DEVICE_STATE_DISCONNECTED), C.Equals, "")
+	c.Check(getActivationStage(nm.NM_DEVICE_STATE_DEACTIVATING), C.Equals, "")
+}
@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"net"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+const (
+	wpaSupplicantService   = "fi.w1.wpa_supplicant1"
+	wpaSupplicantPath      = "/fi/w1/wpa_supplicant1"
+	wpaSupplicantInterface = "fi.w1.wpa_supplicant1.Interface"
+	wpaSupplicantBSS       = "fi.w1.wpa_supplicant1.BSS"
+)
+
+const (
+	// 802.11 BSS Load 信息元素
+	ieBssLoad       = 11
+	ieBssLoadLength = 5
+
+	// 信号强度相差不超过该值时认为相近，优先选择容量更大的热点
+	apComparableStrength = 10
+	// 信号相近时新热点的容量至少是当前热点的 1.5 倍才切换
+	capacityRoamingRatioNum = 3
+	capacityRoamingRatioDen = 2
+
+	apLoadUnknown = -1
+)
+
+// parseBssLoad 从热点的信息元素中解析信道利用率，取值 0-255
+func parseBssLoad(ies []byte) (utilization uint8, ok bool) {
+	for len(ies) >= 2 {
+		id, length := ies[0], int(ies[1])
+		if len(ies) < 2+length {
+			return 0, false
+		}
+		if id == ieBssLoad && length == ieBssLoadLength {
+			// station count(2) + channel utilization(1) + available admission capacity(2)
+			return ies[4], true
+		}
+		ies = ies[2+length:]
+	}
+	return 0, false
+}
+
+// capacity 估算热点的可用容量，信道利用率未知时使用最大速率
+func (a *accessPoint) capacity() uint64 {
+	capacity := uint64(a.MaxBitrate)
+	if a.Load >= 0 {
+		capacity = capacity * uint64(256-a.Load) / 256
+	}
+	return capacity
+}
+
+func isStrengthComparable(s1, s2 uint8) bool {
+	diff := int(s1) - int(s2)
+	return diff <= apComparableStrength && diff >= -apComparableStrength
+}
+
+// shouldRoamForCapacity 判断是否因为信号相近的热点容量明显更大而切换
+func shouldRoamForCapacity(policy string, threshold uint32, cur, best *accessPoint) bool {
+	if policy == roamingPolicyOff || cur == nil || best == nil {
+		return false
+	}
+	// 当前 5G/6G 热点信号比较好，无需切换
+	if policy == roamingPolicyPrefer5G && uint32(cur.Strength) > threshold && isHighBandFrequency(cur.Frequency) {
+		return false
+	}
+	if !isStrengthComparable(best.Strength, cur.Strength) {
+		return false
+	}
+	return best.capacity()*capacityRoamingRatioDen >= cur.capacity()*capacityRoamingRatioNum &&
+		best.capacity() > cur.capacity()
+}
+
+// getSupplicantInterface 获取网络接口在 wpa_supplicant 中对应的对象路径
+func getSupplicantInterface(ifc string) (systemBus *dbus.Conn, ifcPath dbus.ObjectPath, err error) {
+	systemBus, err = dbus.SystemBus()
+	if err != nil {
+		return
+	}
+	err = systemBus.Object(wpaSupplicantService, wpaSupplicantPath).
+		Call(wpaSupplicantService+".GetInterface", 0, ifc).Store(&ifcPath)
+	return
+}
+
+// getSupplicantBssIEs 通过 wpa_supplicant 获取各 BSSID 的信息元素，
+// 没有权限访问 wpa_supplicant 时返回错误
+func getSupplicantBssIEs(ifc string) (iesMap map[string][]byte, err error) {
+	systemBus, ifcPath, err := getSupplicantInterface(ifc)
+	if err != nil {
+		return
+	}
+	variant, err := systemBus.Object(wpaSupplicantService, ifcPath).GetProperty(wpaSupplicantInterface + ".BSSs")
+	if err != nil {
+		return
+	}
+	bssPaths, _ := variant.Value().([]dbus.ObjectPath)
+
+	iesMap = make(map[string][]byte)
+	for _, bssPath := range bssPaths {
+		var props map[string]dbus.Variant
+		err := systemBus.Object(wpaSupplicantService, bssPath).
+			Call("org.freedesktop.DBus.Properties.GetAll", 0, wpaSupplicantBSS).Store(&props)
+		if err != nil {
+			continue
+		}
+		bssid, _ := props["BSSID"].Value().([]byte)
+		ies, _ := props["IEs"].Value().([]byte)
+		if len(bssid) == 6 {
+			iesMap[net.HardwareAddr(bssid).String()] = ies
+		}
+	}
+	return iesMap, nil
+}
+
+// getSysBssIEs 通过系统服务获取 wpa_supplicant 中各 BSSID 的信息元素，
+// 会话服务没有权限访问 wpa_supplicant
+func getSysBssIEs(ifc string) (iesMap map[string][]byte, err error) {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return
+	}
+	err = systemBus.Object(sysNetworkDest, sysNetworkPath).
+		Call(sysNetworkInterface+".GetBssInformationElements", 0, ifc).Store(&iesMap)
+	return
+}
+
+// getSupplicantBssLoads 获取各 BSSID 的信道利用率
+func getSupplicantBssLoads(ifc string) (loads map[string]uint8, err error) {
+	iesMap, err := getSysBssIEs(ifc)
+	if err != nil {
+		return
+	}
+	loads = make(map[string]uint8)
+	for bssid, ies := range iesMap {
+		if utilization, ok := parseBssLoad(ies); ok {
+			loads[bssid] = utilization
+		}
+	}
+	return loads, nil
+}
+
+// updateAccessPointsLoad 更新设备上热点的信道利用率，热点未广播 BSS Load 时为未知
+func (m *Manager) updateAccessPointsLoad(dev *device) {
+	loads, err := getSupplicantBssLoads(dev.Interface)
+	if err != nil {
+		logger.Debug("failed to get bss load:", err)
+	}
+
+	m.accessPointsLock.Lock()
+	defer m.accessPointsLock.Unlock()
+	for _, ap := range m.accessPoints[dev.Path] {
+		if utilization, ok := loads[strings.ToLower(ap.Bssid)]; ok {
+			ap.Load = int32(utilization)
+		} else {
+			ap.Load = apLoadUnknown
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestParseBssLoad(c *C.C) {
+	// SSID + BSS Load
+	ies := []byte{0, 3, 'a', 'b', 'c', 11, 5, 3, 0, 128, 0, 0}
+	utilization, ok := parseBssLoad(ies)
+	c.Check(ok, C.Equals, true)
+	c.Check(utilization, C.Equals, uint8(128))
+
+	_, ok = parseBssLoad([]byte{0, 3, 'a', 'b', 'c'})
+	c.Check(ok, C.Equals, false)
+	// 长度不合法
+	_, ok = parseBssLoad([]byte{11, 5, 3, 0})
+	c.Check(ok, C.Equals, false)
+	_, ok = parseBssLoad(nil)
+	c.Check(ok, C.Equals, false)
+}
+
+func (*testWrapper) TestFindAPByCapacity(c *C.C) {
+	slow := &accessPoint{Ssid: "test", Strength: 70, MaxBitrate: 54000, Load: apLoadUnknown}
+	fast := &accessPoint{Ssid: "test", Strength: 65, MaxBitrate: 866000, Load: apLoadUnknown}
+	weak := &accessPoint{Ssid: "test", Strength: 40, MaxBitrate: 866000, Load: apLoadUnknown}
+
+	// 未开启时选择信号最强的
+	c.Check(findAPByBand("test", []*accessPoint{slow, fast, weak}, "", false), C.Equals, slow)
+	// 信号相近时选择容量大的，信号相差较大的不参与比较
+	c.Check(findAPByBand("test", []*accessPoint{slow, fast, weak}, "", true), C.Equals, fast)
+
+	// 负载很高时容量下降
+	busy := &accessPoint{Ssid: "test", Strength: 68, MaxBitrate: 866000, Load: 250}
+	c.Check(findAPByBand("test", []*accessPoint{busy, fast}, "", true), C.Equals, fast)
+
+	// 信号依次相近的热点，结果不受顺序影响
+	a := &accessPoint{Ssid: "test", Strength: 80, MaxBitrate: 54000, Load: apLoadUnknown}
+	b := &accessPoint{Ssid: "test", Strength: 72, MaxBitrate: 144000, Load: apLoadUnknown}
+	d := &accessPoint{Ssid: "test", Strength: 64, MaxBitrate: 866000, Load: apLoadUnknown}
+	aps := []*accessPoint{a, b, d}
+	for i := 0; i < len(aps); i++ {
+		aps = append(aps[1:], aps[0])
+		c.Check(findAPByBand("test", aps, "", true), C.Equals, b)
+	}
+}
+
+func (*testWrapper) TestShouldRoamForCapacity(c *C.C) {
+	cur := &accessPoint{Strength: 60, Frequency: 2412, MaxBitrate: 144000, Load: apLoadUnknown}
+	best := &accessPoint{Strength: 55, Frequency: 2437, MaxBitrate: 300000, Load: apLoadUnknown}
+	c.Check(shouldRoamForCapacity(roamingPolicyAggressive, 65, cur, best), C.Equals, true)
+	c.Check(shouldRoamForCapacity(roamingPolicyOff, 65, cur, best), C.Equals, false)
+
+	// 容量提升不明显
+	best.MaxBitrate = 180000
+	c.Check(shouldRoamForCapacity(roamingPolicyAggressive, 65, cur, best), C.Equals, false)
+
+	// 信号相差较大
+	best.MaxBitrate, best.Strength = 300000, 30
+	c.Check(shouldRoamForCapacity(roamingPolicyAggressive, 65, cur, best), C.Equals, false)
+
+	// 当前 5G 热点信号良好
+	cur.Frequency, cur.Strength, best.Strength = 5180, 70, 65
+	c.Check(shouldRoamForCapacity(roamingPolicyPrefer5G, 65, cur, best), C.Equals, false)
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// accessPointGroup 同一 ssid 且加密方式相同的热点合并后的网络
+type accessPointGroup struct {
+	Ssid         string
+	KeyMgmt      string
+	Secured      bool
+	SecuredInEap bool
+	Hidden       bool
+	// 信号最强的热点
+	Path      dbus.ObjectPath
+	Bssid     string
+	Strength  uint8
+	Frequency uint32
+	// 合并的热点数量
+	Count    int
+	Band2_4G bool
+	Band5G   bool
+	Band6G   bool
+	// 所有热点都已过期
+	Stale bool
+}
+
+func (g *accessPointGroup) setStrongest(ap *accessPoint) {
+	g.Path = ap.Path
+	g.Bssid = ap.Bssid
+	g.Strength = ap.Strength
+	g.Frequency = ap.Frequency
+}
+
+func (g *accessPointGroup) addBand(freq uint32) {
+	switch getBandByFrequency(freq) {
+	case bandBG:
+		g.Band2_4G = true
+	case bandA:
+		g.Band5G = true
+	case band6G:
+		g.Band6G = true
+	}
+}
+
+// groupAccessPoints 按 ssid 和加密方式合并热点，保持热点列表原有的顺序
+func groupAccessPoints(accessPoints []*accessPoint) (groups []*accessPointGroup) {
+	type groupKey struct {
+		ssid    string
+		keyMgmt string
+	}
+	groupMap := make(map[groupKey]*accessPointGroup)
+	for _, ap := range accessPoints {
+		key := groupKey{ssid: ap.Ssid, keyMgmt: ap.KeyMgmt}
+		group, ok := groupMap[key]
+		if !ok {
+			group = &accessPointGroup{
+				Ssid:         ap.Ssid,
+				KeyMgmt:      ap.KeyMgmt,
+				Secured:      ap.Secured,
+				SecuredInEap: ap.SecuredInEap,
+				Stale:        true,
+			}
+			group.setStrongest(ap)
+			groupMap[key] = group
+			groups = append(groups, group)
+		} else if ap.Strength > group.Strength {
+			group.setStrongest(ap)
+		}
+		group.Count++
+		group.Hidden = group.Hidden || ap.Hidden
+		group.Stale = group.Stale && ap.Stale
+		group.addBand(ap.Frequency)
+	}
+	return
+}
+
+// GetAccessPointsGrouped works like GetAccessPoints, but merges the access
+// points with the same SSID and security into one network, reporting the
+// strongest BSSID, the number of access points and the available bands.
+func (m *Manager) GetAccessPointsGrouped(path dbus.ObjectPath) (groupsJSON string, busErr *dbus.Error) {
+	m.accessPointsLock.Lock()
+	groups := groupAccessPoints(m.accessPoints[path])
+	m.accessPointsLock.Unlock()
+	groupsJSON, err := marshalJSON(groups)
+	busErr = dbusutil.ToError(err)
+	return
+}
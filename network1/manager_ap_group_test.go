@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	dbus "github.com/godbus/dbus/v5"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestGroupAccessPoints(c *C.C) {
+	aps := []*accessPoint{
+		{Ssid: "office", KeyMgmt: "wpa-psk", Secured: true, Strength: 40, Frequency: 2437, Path: "/ap/1", Bssid: "aa:00:00:00:00:01"},
+		{Ssid: "guest", KeyMgmt: "none", Strength: 50, Frequency: 2412, Path: "/ap/2", Stale: true},
+		{Ssid: "office", KeyMgmt: "wpa-psk", Secured: true, Strength: 70, Frequency: 5180, Path: "/ap/3", Bssid: "aa:00:00:00:00:03"},
+		{Ssid: "office", KeyMgmt: "wpa-psk", Secured: true, Strength: 60, Frequency: 5975, Path: "/ap/4", Stale: true},
+		// 加密方式不同，单独作为一个网络
+		{Ssid: "office", KeyMgmt: "sae", Secured: true, Strength: 30, Frequency: 5180, Path: "/ap/5"},
+	}
+
+	groups := groupAccessPoints(aps)
+	c.Assert(groups, C.HasLen, 3)
+
+	office := groups[0]
+	c.Check(office.Ssid, C.Equals, "office")
+	c.Check(office.Count, C.Equals, 3)
+	c.Check(office.Path, C.Equals, dbus.ObjectPath("/ap/3"))
+	c.Check(office.Bssid, C.Equals, "aa:00:00:00:00:03")
+	c.Check(office.Strength, C.Equals, uint8(70))
+	c.Check(office.Band2_4G, C.Equals, true)
+	c.Check(office.Band5G, C.Equals, true)
+	c.Check(office.Band6G, C.Equals, true)
+	c.Check(office.Stale, C.Equals, false)
+
+	guest := groups[1]
+	c.Check(guest.Count, C.Equals, 1)
+	c.Check(guest.Band5G, C.Equals, false)
+	c.Check(guest.Stale, C.Equals, true)
+
+	c.Check(groups[2].KeyMgmt, C.Equals, "sae")
+	c.Check(groupAccessPoints(nil), C.HasLen, 0)
+}
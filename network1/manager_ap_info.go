@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"syscall"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// NetworkManager 1.20 新增的 mesh 模式，生成的常量中还没有
+const nm80211ModeMesh = 4
+
+// 热点的工作模式
+const (
+	apModeUnknown = "unknown"
+	apModeAdhoc   = "adhoc"
+	apModeInfra   = "infra"
+	apModeAp      = "ap"
+	apModeMesh    = "mesh"
+)
+
+type accessPointInfo struct {
+	Path       dbus.ObjectPath
+	Ssid       string
+	Bssid      string
+	Mode       string
+	Frequency  uint32
+	Channel    uint32
+	Band       string
+	Strength   uint8
+	MaxBitrate uint32 // 单位 kb/s
+	Flags      uint32
+	WpaFlags   uint32
+	RsnFlags   uint32
+	// 最近一次扫描到热点的 unix 时间戳，单位秒，0 表示从未扫描到
+	LastSeen int64
+	// 信号强度低于弱信号阈值
+	Ignored bool
+}
+
+func getAccessPointMode(mode uint32) string {
+	switch mode {
+	case nm.NM_802_11_MODE_ADHOC:
+		return apModeAdhoc
+	case nm.NM_802_11_MODE_INFRA:
+		return apModeInfra
+	case nm.NM_802_11_MODE_AP:
+		return apModeAp
+	case nm80211ModeMesh:
+		return apModeMesh
+	}
+	return apModeUnknown
+}
+
+// getChannelByFrequency 根据频率(MHz)计算信道号，无法识别时返回 0
+func getChannelByFrequency(freq uint32) uint32 {
+	switch {
+	case freq == 2484:
+		return 14
+	case isFrequencyInBand(freq, bandBG):
+		return (freq - 2407) / 5
+	case freq >= frequency5GLowerlimit && freq < 5000:
+		// 日本的 4.9G 频段
+		return (freq - 4000) / 5
+	case isFrequencyInBand(freq, bandA):
+		return (freq - 5000) / 5
+	case freq == 5935:
+		return 2
+	case isFrequencyInBand(freq, band6G):
+		return (freq - 5950) / 5
+	}
+	return 0
+}
+
+// getLastSeenTime 将 NetworkManager 的 LastSeen(CLOCK_BOOTTIME 秒数) 转换为 unix 时间戳
+func getLastSeenTime(lastSeen int32, uptime int64, now time.Time) int64 {
+	if lastSeen < 0 || int64(lastSeen) > uptime {
+		return 0
+	}
+	return now.Unix() - (uptime - int64(lastSeen))
+}
+
+// GetAccessPointInfo return the detail of the access point which marshaled
+// by json, including mode, max bitrate, raw WPA and RSN flags, BSSID, the
+// last seen time and channel number.
+func (m *Manager) GetAccessPointInfo(apPath dbus.ObjectPath) (infoJSON string, busErr *dbus.Error) {
+	info, err := getAccessPointInfo(apPath)
+	if err != nil {
+		logger.Warning("failed to get access point info:", err)
+		return "", dbusutil.ToError(err)
+	}
+	info.Ignored = isWeakAccessPoint(info.Strength, m.getWeakAccessPointStrength())
+	infoJSON, err = marshalJSON(info)
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	return infoJSON, nil
+}
+
+func getAccessPointInfo(apPath dbus.ObjectPath) (info *accessPointInfo, err error) {
+	nmAp, err := nmNewAccessPoint(apPath)
+	if err != nil {
+		return
+	}
+	ssid, err := nmAp.Ssid().Get(0)
+	if err != nil {
+		return
+	}
+	info = &accessPointInfo{
+		Path: apPath,
+		Ssid: decodeSsid(ssid),
+	}
+	info.Bssid, _ = nmAp.HwAddress().Get(0)
+	mode, _ := nmAp.Mode().Get(0)
+	info.Mode = getAccessPointMode(mode)
+	info.Frequency, _ = nmAp.Frequency().Get(0)
+	info.Channel = getChannelByFrequency(info.Frequency)
+	info.Band = getBandByFrequency(info.Frequency)
+	info.Strength, _ = nmAp.Strength().Get(0)
+	info.MaxBitrate, _ = nmAp.MaxBitrate().Get(0)
+	info.Flags, _ = nmAp.Flags().Get(0)
+	info.WpaFlags, _ = nmAp.WpaFlags().Get(0)
+	info.RsnFlags, _ = nmAp.RsnFlags().Get(0)
+
+	lastSeen, err := nmAp.LastSeen().Get(0)
+	if err != nil {
+		logger.Warning(err)
+		lastSeen = -1
+	}
+	var sysInfo syscall.Sysinfo_t
+	err = syscall.Sysinfo(&sysInfo)
+	if err != nil {
+		logger.Warning(err)
+		return info, nil
+	}
+	// sysinfo 的 uptime 包含系统休眠的时间，与 CLOCK_BOOTTIME 一致
+	info.LastSeen = getLastSeenTime(lastSeen, int64(sysInfo.Uptime), time.Now())
+	return info, nil
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"time"
+
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestGetChannelByFrequency(c *C.C) {
+	tests := []struct {
+		freq    uint32
+		channel uint32
+	}{
+		{2412, 1},
+		{2437, 6},
+		{2472, 13},
+		{2484, 14},
+		{4920, 184},
+		{5180, 36},
+		{5745, 149},
+		{5825, 165},
+		{5935, 2},
+		{5955, 1},
+		{6115, 33},
+		{60480, 0},
+	}
+	for _, t := range tests {
+		c.Check(getChannelByFrequency(t.freq), C.Equals, t.channel)
+	}
+}
+
+func (*testWrapper) TestGetAccessPointMode(c *C.C) {
+	c.Check(getAccessPointMode(nm.NM_802_11_MODE_INFRA), C.Equals, apModeInfra)
+	c.Check(getAccessPointMode(nm.NM_802_11_MODE_ADHOC), C.Equals, apModeAdhoc)
+	c.Check(getAccessPointMode(nm80211ModeMesh), C.Equals, apModeMesh)
+	c.Check(getAccessPointMode(nm.NM_802_11_MODE_UNKNOWN), C.Equals, apModeUnknown)
+}
+
+func (*testWrapper) TestGetLastSeenTime(c *C.C) {
+	now := time.Unix(10000, 0)
+	c.Check(getLastSeenTime(-1, 500, now), C.Equals, int64(0))
+	c.Check(getLastSeenTime(490, 500, now), C.Equals, int64(9990))
+	c.Check(getLastSeenTime(600, 500, now), C.Equals, int64(0))
+}
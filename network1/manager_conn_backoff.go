@@ -0,0 +1,243 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"sync"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+const (
+	defaultConnectionRetryLimit      = 5
+	defaultConnectionBackoffInterval = 10 * time.Second
+	defaultConnectionBackoffMax      = 10 * time.Minute
+)
+
+// connFailureRecord 连接连续自动连接失败的记录，退避期间禁止该连接自动连接
+type connFailureRecord struct {
+	failures uint32
+	devPath  dbus.ObjectPath
+	timer    *time.Timer
+	givenUp  bool
+	blocked  bool // 是否关闭了连接的自动连接
+}
+
+// userActivations 通过本服务激活的连接，这些连接激活失败时不计入自动连接失败次数
+var userActivations = struct {
+	mu    sync.Mutex
+	paths map[dbus.ObjectPath]time.Time
+}{paths: make(map[dbus.ObjectPath]time.Time)}
+
+const userActivationTimeout = time.Minute
+
+func markUserActivation(cpath dbus.ObjectPath) {
+	userActivations.mu.Lock()
+	userActivations.paths[cpath] = time.Now()
+	userActivations.mu.Unlock()
+}
+
+// takeUserActivation 连接开始激活时判断是否由本服务激活
+func takeUserActivation(cpath dbus.ObjectPath) bool {
+	userActivations.mu.Lock()
+	defer userActivations.mu.Unlock()
+	t, ok := userActivations.paths[cpath]
+	delete(userActivations.paths, cpath)
+	return ok && time.Since(t) < userActivationTimeout
+}
+
+// getBackoffDelay 第 n 次失败后的退避时间，每次翻倍，不超过 max
+func getBackoffDelay(failures uint32, interval, max time.Duration) time.Duration {
+	delay := interval
+	for i := uint32(1); i < failures; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+// setConnectionBackoffPolicy 设置退避策略，retryLimit 为 0 时不限制重试
+func (m *Manager) setConnectionBackoffPolicy(retryLimit uint32, interval, max time.Duration) {
+	if interval <= 0 {
+		interval = defaultConnectionBackoffInterval
+	}
+	if max < interval {
+		max = interval
+	}
+	m.connFailuresLock.Lock()
+	m.connRetryLimit = retryLimit
+	m.connBackoffInterval = interval
+	m.connBackoffMax = max
+	m.connFailuresLock.Unlock()
+	logger.Infof("connection backoff policy, retry limit: %d, interval: %v, max: %v", retryLimit, interval, max)
+}
+
+// recordConnectionFailure 记录连接自动连接失败，在退避时间内禁止该连接自动连接，
+// 达到重试上限后不再自动连接并发送 ConnectionGivenUp 信号
+func (m *Manager) recordConnectionFailure(uuid string, devPath dbus.ObjectPath) {
+	m.connFailuresLock.Lock()
+	if m.connRetryLimit == 0 {
+		m.connFailuresLock.Unlock()
+		return
+	}
+	record, ok := m.connFailures[uuid]
+	if !ok {
+		record = &connFailureRecord{}
+		m.connFailures[uuid] = record
+	}
+	if record.givenUp {
+		m.connFailuresLock.Unlock()
+		return
+	}
+	record.failures++
+	record.devPath = devPath
+	if record.timer != nil {
+		record.timer.Stop()
+		record.timer = nil
+	}
+	failures := record.failures
+	givenUp := failures >= m.connRetryLimit
+	record.givenUp = givenUp
+	delay := getBackoffDelay(failures, m.connBackoffInterval, m.connBackoffMax)
+	if !givenUp {
+		record.timer = time.AfterFunc(delay, func() {
+			m.connFailuresLock.Lock()
+			if m.connFailures[uuid] != record || record.timer == nil {
+				m.connFailuresLock.Unlock()
+				return
+			}
+			record.timer = nil
+			blocked := record.blocked
+			record.blocked = false
+			m.connFailuresLock.Unlock()
+			logger.Infof("backoff of connection %s finished, allow autoconnect", uuid)
+			if blocked && !m.isAutoconnectBlockedByDataLimit(uuid) {
+				setConnectionAutoconnectForBackoff(uuid, true)
+			}
+		})
+	}
+	needBlock := !record.blocked
+	record.blocked = true
+	m.connFailuresLock.Unlock()
+
+	if needBlock && !setConnectionAutoconnectForBackoff(uuid, false) {
+		// 连接本身未开启自动连接，无需恢复
+		m.connFailuresLock.Lock()
+		record.blocked = false
+		m.connFailuresLock.Unlock()
+	}
+	if !givenUp {
+		logger.Infof("connection %s failed %d times, retry after %v", uuid, failures, delay)
+		return
+	}
+	logger.Warningf("connection %s failed %d times, give up", uuid, failures)
+	err := m.service.Emit(m, "ConnectionGivenUp", uuid, string(devPath), failures)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+// clearConnectionFailures 清除连接的失败记录，恢复连接的自动连接
+func (m *Manager) clearConnectionFailures(uuid string) {
+	m.connFailuresLock.Lock()
+	record, ok := m.connFailures[uuid]
+	if !ok {
+		m.connFailuresLock.Unlock()
+		return
+	}
+	delete(m.connFailures, uuid)
+	if record.timer != nil {
+		record.timer.Stop()
+	}
+	m.connFailuresLock.Unlock()
+
+	if record.blocked && !m.isAutoconnectBlockedByDataLimit(uuid) {
+		setConnectionAutoconnectForBackoff(uuid, true)
+	}
+}
+
+// isAutoconnectBlockedByBackoff 连接是否在退避期间关闭了自动连接
+func (m *Manager) isAutoconnectBlockedByBackoff(uuid string) bool {
+	m.connFailuresLock.Lock()
+	defer m.connFailuresLock.Unlock()
+	record, ok := m.connFailures[uuid]
+	return ok && record.blocked
+}
+
+// setConnectionAutoconnectForBackoff 修改连接的自动连接，只影响该连接，不影响设备上的其他连接。
+// 关闭时只修改内存中的配置，不写入磁盘，NetworkManager 重启后即恢复；
+// 返回是否修改了连接，关闭时连接本身未开启自动连接则不修改
+func setConnectionAutoconnectForBackoff(uuid string, autoconnect bool) bool {
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		return false
+	}
+	nmConn, err := nmNewSettingsConnection(cpath)
+	if err != nil {
+		return false
+	}
+	data, err := nmConn.GetSettings(0)
+	if err != nil {
+		logger.Warning(err)
+		return false
+	}
+	if getSettingConnectionAutoconnect(data) == autoconnect {
+		return false
+	}
+	setSettingConnectionAutoconnect(data, autoconnect)
+	fixConnectionIP6Data(data)
+	if autoconnect {
+		err = nmConn.Update(0, data)
+	} else {
+		err = nmConn.UpdateUnsaved(0, data)
+	}
+	if err != nil {
+		logger.Warningf("failed to set autoconnect of connection %s to %v: %v", uuid, autoconnect, err)
+		return false
+	}
+	return true
+}
+
+// destroyConnectionFailures 退出时恢复退避期间关闭了自动连接的连接
+func (m *Manager) destroyConnectionFailures() {
+	m.connFailuresLock.Lock()
+	var blockedUuids []string
+	for uuid, record := range m.connFailures {
+		if record.timer != nil {
+			record.timer.Stop()
+		}
+		if record.blocked {
+			blockedUuids = append(blockedUuids, uuid)
+		}
+	}
+	m.connFailures = make(map[string]*connFailureRecord)
+	m.connFailuresLock.Unlock()
+
+	for _, uuid := range blockedUuids {
+		if !m.isAutoconnectBlockedByDataLimit(uuid) {
+			setConnectionAutoconnectForBackoff(uuid, true)
+		}
+	}
+}
+
+// ResetConnectionFailures clear the failure count of the connection and
+// allow NetworkManager to activate it automatically again after it was
+// given up, which is reported by the ConnectionGivenUp signal.
+func (m *Manager) ResetConnectionFailures(uuid string) *dbus.Error {
+	_, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	logger.Info("reset failures of connection", uuid)
+	m.clearConnectionFailures(uuid)
+	return nil
+}
@@ -0,0 +1,31 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestGetBackoffDelay(c *C.C) {
+	interval, max := 10*time.Second, time.Minute
+	c.Check(getBackoffDelay(1, interval, max), C.Equals, 10*time.Second)
+	c.Check(getBackoffDelay(2, interval, max), C.Equals, 20*time.Second)
+	c.Check(getBackoffDelay(3, interval, max), C.Equals, 40*time.Second)
+	c.Check(getBackoffDelay(4, interval, max), C.Equals, time.Minute)
+	c.Check(getBackoffDelay(100, interval, max), C.Equals, time.Minute)
+	c.Check(getBackoffDelay(1, 2*time.Minute, max), C.Equals, time.Minute)
+}
+
+func (*testWrapper) TestTakeUserActivation(c *C.C) {
+	cpath := dbus.ObjectPath("/org/freedesktop/NetworkManager/Settings/1")
+	c.Check(takeUserActivation(cpath), C.Equals, false)
+	markUserActivation(cpath)
+	c.Check(takeUserActivation(cpath), C.Equals, true)
+	// 只对本次激活有效
+	c.Check(takeUserActivation(cpath), C.Equals, false)
+}
@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"fmt"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	. "github.com/linuxdeepin/go-lib/gettext"
+)
+
+func isDeviceStateActivating(state uint32) bool {
+	return state >= nm.NM_DEVICE_STATE_PREPARE && state < nm.NM_DEVICE_STATE_ACTIVATED
+}
+
+// isConnectionFailed 判断设备状态变化是否表示本次激活失败，
+// 密码错误时 NetworkManager 会进入 NEED_AUTH 重新请求密码
+func isConnectionFailed(newState, oldState, reason uint32) bool {
+	switch newState {
+	case nm.NM_DEVICE_STATE_FAILED:
+		return true
+	case nm.NM_DEVICE_STATE_NEED_AUTH:
+		return reason == nm.NM_DEVICE_STATE_REASON_SUPPLICANT_DISCONNECT ||
+			reason == nm.NM_DEVICE_STATE_REASON_SUPPLICANT_TIMEOUT
+	case nm.NM_DEVICE_STATE_DISCONNECTED:
+		// 激活过程中被断开，用户主动断开或激活其他连接除外
+		return isDeviceStateActivating(oldState) && reason != nm.NM_DEVICE_STATE_REASON_USER_REQUESTED &&
+			reason != nm.NM_DEVICE_STATE_REASON_NEW_ACTIVATION && reason != nm.NM_DEVICE_STATE_REASON_CONNECTION_REMOVED
+	}
+	return false
+}
+
+// getConnectionFailedMessage 返回可以直接展示给用户的失败原因
+func getConnectionFailedMessage(reason uint32, id string) string {
+	switch reason {
+	case nm.NM_DEVICE_STATE_REASON_SUPPLICANT_DISCONNECT, nm.NM_DEVICE_STATE_REASON_SUPPLICANT_TIMEOUT:
+		return fmt.Sprintf(Tr("Connection failed, unable to connect %q, wrong password"), id)
+	case nm.NM_DEVICE_STATE_REASON_NO_SECRETS:
+		return fmt.Sprintf(Tr("Password is required to connect %q"), id)
+	case nm.NM_DEVICE_STATE_REASON_SSID_NOT_FOUND:
+		return fmt.Sprintf(Tr("The %q 802.11 WLAN network could not be found"), id)
+	case nm.NM_DEVICE_STATE_REASON_IP_CONFIG_UNAVAILABLE, nm.NM_DEVICE_STATE_REASON_DHCP_START_FAILED,
+		nm.NM_DEVICE_STATE_REASON_DHCP_ERROR, nm.NM_DEVICE_STATE_REASON_DHCP_FAILED:
+		return fmt.Sprintf(Tr("Unable to get an IP address for %q, please check your router"), id)
+	}
+	if msg, ok := deviceErrorTable[reason]; ok {
+		return msg
+	}
+	return deviceErrorTable[nm.NM_DEVICE_STATE_REASON_UNKNOWN]
+}
+
+// updateConnectionAttempt 记录设备当前激活的连接，激活失败时发送 ConnectionFailed 信号
+func (m *Manager) updateConnectionAttempt(dev *device, newState, oldState, reason uint32) {
+	if newState == nm.NM_DEVICE_STATE_PREPARE {
+		aconn, err := nmNewActiveConnection(nmGetDeviceActiveConnection(dev.Path))
+		if err != nil {
+			return
+		}
+		cpath, _ := aconn.Connection().Get(0)
+		if data, err := nmGetConnectionData(cpath); err == nil {
+			auto := !takeUserActivation(cpath)
+			m.devicesLock.Lock()
+			dev.attemptUuid = getSettingConnectionUuid(data)
+			dev.attemptId = getSettingConnectionId(data)
+			dev.attemptAuto = auto
+			m.devicesLock.Unlock()
+		}
+		return
+	}
+
+	m.devicesLock.Lock()
+	uuid, id, auto := dev.attemptUuid, dev.attemptId, dev.attemptAuto
+	if newState == nm.NM_DEVICE_STATE_ACTIVATED || isConnectionFailed(newState, oldState, reason) {
+		dev.attemptUuid, dev.attemptId = "", ""
+	}
+	m.devicesLock.Unlock()
+	if uuid != "" && newState == nm.NM_DEVICE_STATE_ACTIVATED {
+		m.clearConnectionFailures(uuid)
+		return
+	}
+	if uuid == "" || !isConnectionFailed(newState, oldState, reason) {
+		return
+	}
+
+	if dev.nmDevType == nm.NM_DEVICE_TYPE_ETHERNET && reason == nm.NM_DEVICE_STATE_REASON_CARRIER {
+		reason = CUSTOM_NM_DEVICE_STATE_REASON_CABLE_UNPLUGGED
+	}
+	m.emitConnectionFailed(uuid, dev.Path, reason, getConnectionFailedMessage(reason, id))
+	// 用户主动连接失败时不退避
+	if auto {
+		m.recordConnectionFailure(uuid, dev.Path)
+	}
+}
+
+func (m *Manager) emitConnectionFailed(uuid string, devPath dbus.ObjectPath, reason uint32, message string) {
+	logger.Infof("connection %s failed on %s, reason[%d] %s", uuid, devPath, reason, message)
+	err := m.service.Emit(m, "ConnectionFailed", uuid, string(devPath), reason, message)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestIsConnectionFailed(c *C.C) {
+	tests := []struct {
+		newState, oldState, reason uint32
+		failed                     bool
+	}{
+		{nm.NM_DEVICE_STATE_FAILED, nm.NM_DEVICE_STATE_IP_CONFIG, nm.NM_DEVICE_STATE_REASON_IP_CONFIG_UNAVAILABLE, true},
+		// 密码错误
+		{nm.NM_DEVICE_STATE_NEED_AUTH, nm.NM_DEVICE_STATE_CONFIG, nm.NM_DEVICE_STATE_REASON_SUPPLICANT_DISCONNECT, true},
+		// 首次连接请求密码
+		{nm.NM_DEVICE_STATE_NEED_AUTH, nm.NM_DEVICE_STATE_CONFIG, nm.NM_DEVICE_STATE_REASON_NONE, false},
+		{nm.NM_DEVICE_STATE_DISCONNECTED, nm.NM_DEVICE_STATE_CONFIG, nm.NM_DEVICE_STATE_REASON_SSID_NOT_FOUND, true},
+		{nm.NM_DEVICE_STATE_DISCONNECTED, nm.NM_DEVICE_STATE_CONFIG, nm.NM_DEVICE_STATE_REASON_USER_REQUESTED, false},
+		{nm.NM_DEVICE_STATE_DISCONNECTED, nm.NM_DEVICE_STATE_ACTIVATED, nm.NM_DEVICE_STATE_REASON_CARRIER, false},
+		{nm.NM_DEVICE_STATE_ACTIVATED, nm.NM_DEVICE_STATE_SECONDARIES, nm.NM_DEVICE_STATE_REASON_NONE, false},
+	}
+	for _, t := range tests {
+		c.Check(isConnectionFailed(t.newState, t.oldState, t.reason), C.Equals, t.failed,
+			C.Commentf("%d => %d, reason %d", t.oldState, t.newState, t.reason))
+	}
+}
+
+func (*testWrapper) TestGetConnectionFailedMessage(c *C.C) {
+	initNmStateReasons()
+	c.Check(getConnectionFailedMessage(nm.NM_DEVICE_STATE_REASON_SUPPLICANT_DISCONNECT, "deepin"), C.Equals,
+		`Connection failed, unable to connect "deepin", wrong password`)
+	c.Check(getConnectionFailedMessage(nm.NM_DEVICE_STATE_REASON_DHCP_FAILED, "deepin"), C.Equals,
+		getConnectionFailedMessage(nm.NM_DEVICE_STATE_REASON_IP_CONFIG_UNAVAILABLE, "deepin"))
+	c.Check(getConnectionFailedMessage(nm.NM_DEVICE_STATE_REASON_MODEM_BUSY, "deepin"), C.Equals,
+		"Dialing failed due to busy lines")
+}
@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"time"
+)
+
+// 主连接的质量，未开启监测或没有可以探测的网关时为 unknown
+const (
+	connectionQualityUnknown  = "unknown"
+	connectionQualityGood     = "good"
+	connectionQualityDegraded = "degraded"
+	connectionQualityBroken   = "broken"
+)
+
+const (
+	defaultConnectionQualityInterval = 30 * time.Second
+	minConnectionQualityInterval     = 5 * time.Second
+
+	// 每次探测发送的 ping 次数
+	connectionQualityProbes = 4
+	// 延迟或丢包率超过该值时认为连接质量下降
+	degradedConnectionRtt  = 200 * time.Millisecond
+	degradedConnectionLoss = 25
+)
+
+// classifyConnectionQuality 根据网关的平均延迟和丢包率(百分比)判断连接质量
+func classifyConnectionQuality(rtt time.Duration, loss uint32) string {
+	switch {
+	case loss >= 100:
+		return connectionQualityBroken
+	case loss >= degradedConnectionLoss || rtt >= degradedConnectionRtt:
+		return connectionQualityDegraded
+	}
+	return connectionQualityGood
+}
+
+func (m *Manager) setConnectionQualityMonitor(enabled bool, interval time.Duration) {
+	if interval < minConnectionQualityInterval {
+		interval = minConnectionQualityInterval
+	}
+	m.connQualityLock.Lock()
+	m.connQualityEnabled = enabled
+	m.connQualityInterval = interval
+	m.connQualityLock.Unlock()
+	logger.Infof("connection quality monitor, enabled: %v, interval: %v", enabled, interval)
+
+	if !enabled {
+		m.updateConnectionQuality(connectionQualityUnknown, 0, 0)
+	}
+	m.scheduleConnectionQualityCheck()
+}
+
+func (m *Manager) destroyConnectionQualityMonitor() {
+	m.connQualityLock.Lock()
+	if m.connQualityTimer != nil {
+		m.connQualityTimer.Stop()
+		m.connQualityTimer = nil
+	}
+	m.connQualityLock.Unlock()
+}
+
+// scheduleConnectionQualityCheck 按当前的间隔重新安排下一次探测
+func (m *Manager) scheduleConnectionQualityCheck() {
+	m.connQualityLock.Lock()
+	defer m.connQualityLock.Unlock()
+	if m.connQualityTimer != nil {
+		m.connQualityTimer.Stop()
+		m.connQualityTimer = nil
+	}
+	if !m.connQualityEnabled {
+		return
+	}
+	m.connQualityTimer = time.AfterFunc(m.connQualityInterval, func() {
+		m.checkConnectionQuality()
+		m.scheduleConnectionQualityCheck()
+	})
+}
+
+// probeGateway ping 网关，返回成功时的平均延迟和丢包率
+func (m *Manager) probeGateway(gateway string) (rtt time.Duration, loss uint32) {
+	var total time.Duration
+	var received uint32
+	for i := 0; i < connectionQualityProbes; i++ {
+		start := time.Now()
+		err := m.sysNetwork.Ping(0, gateway)
+		if err != nil {
+			logger.Debug("failed to ping gateway:", gateway, err)
+			continue
+		}
+		total += time.Since(start)
+		received++
+	}
+	loss = (connectionQualityProbes - received) * 100 / connectionQualityProbes
+	if received > 0 {
+		rtt = total / time.Duration(received)
+	}
+	return
+}
+
+func (m *Manager) checkConnectionQuality() {
+	apath := nmGetPrimaryConnection()
+	if apath == "" || apath == "/" {
+		m.updateConnectionQuality(connectionQualityUnknown, 0, 0)
+		return
+	}
+	aconn, err := nmNewActiveConnection(apath)
+	if err != nil {
+		m.updateConnectionQuality(connectionQualityUnknown, 0, 0)
+		return
+	}
+	ip4Path, _ := aconn.Ip4Config().Get(0)
+	var gateway string
+	if ip4Path != "" && ip4Path != "/" {
+		gateway = nmGetIp4ConfigInfo(ip4Path).Gateway
+	}
+	if gateway == "" {
+		m.updateConnectionQuality(connectionQualityUnknown, 0, 0)
+		return
+	}
+
+	rtt, loss := m.probeGateway(gateway)
+	logger.Debugf("connection quality of %s, gateway: %s, rtt: %v, loss: %d%%", apath, gateway, rtt, loss)
+	m.updateConnectionQuality(classifyConnectionQuality(rtt, loss), rtt, loss)
+}
+
+func (m *Manager) updateConnectionQuality(quality string, rtt time.Duration, loss uint32) {
+	m.PropsMu.Lock()
+	changed := m.setPropConnectionQuality(quality)
+	m.PropsMu.Unlock()
+	if !changed {
+		return
+	}
+	logger.Info("connection quality changed:", quality)
+	err := m.service.Emit(m, "ConnectionQualityChanged", quality, uint32(rtt/time.Millisecond), loss)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
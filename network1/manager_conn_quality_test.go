@@ -0,0 +1,19 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"time"
+
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestClassifyConnectionQuality(c *C.C) {
+	c.Check(classifyConnectionQuality(5*time.Millisecond, 0), C.Equals, connectionQualityGood)
+	c.Check(classifyConnectionQuality(300*time.Millisecond, 0), C.Equals, connectionQualityDegraded)
+	c.Check(classifyConnectionQuality(5*time.Millisecond, 50), C.Equals, connectionQualityDegraded)
+	// 全部丢包
+	c.Check(classifyConnectionQuality(0, 100), C.Equals, connectionQualityBroken)
+}
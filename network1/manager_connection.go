@@ -5,6 +5,7 @@
 package network
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"strconv"
@@ -40,6 +41,10 @@ type connection struct {
 	// works for wireless, olpc-mesh connections
 	Ssid   string
 	Hidden bool
+	// 用户设置的频段偏好，auto 表示允许自动切换频段
+	BandPreference string
+	// 多个可自动连接的网络同时可用时，优先级高的先连接
+	AutoconnectPriority int32
 }
 
 func (m *Manager) initConnectionManage() {
@@ -138,6 +143,7 @@ func (conn *connection) updateProps() connectionData {
 	conn.Uuid = getSettingConnectionUuid(cdata)
 	conn.Id = getSettingConnectionId(cdata)
 	conn.IfcName = getSettingConnectionInterfaceName(cdata)
+	conn.AutoconnectPriority = getSettingConnectionAutoconnectPriority(cdata)
 
 	switch getSettingConnectionType(cdata) {
 	case nm.NM_SETTING_GSM_SETTING_NAME, nm.NM_SETTING_CDMA_SETTING_NAME:
@@ -173,6 +179,7 @@ func (conn *connection) updateProps() connectionData {
 			conn.ClonedAddress = ""
 		}
 		conn.Hidden = getSettingWirelessHidden(cdata)
+		conn.BandPreference = getConnectionBandPreference(cdata)
 	}
 	return cdata
 }
@@ -534,6 +541,12 @@ func (m *Manager) activateConnection(uuid string, devPath dbus.ObjectPath) (cpat
 	}
 	// check if type is vpn, if not, should async device state
 	connTyp := getSettingConnectionType(connData)
+	if connTyp == nm.NM_SETTING_WIRELESS_SETTING_NAME && getSettingWirelessMode(connData) != nm.NM_SETTING_WIRELESS_MODE_AP {
+		err = m.checkSsidAllowed(decodeSsid(getSettingWirelessSsid(connData)))
+		if err != nil {
+			return
+		}
+	}
 	if connTyp != "vpn" {
 		// if need enable device
 		var enabled bool
@@ -664,6 +677,9 @@ func (m *Manager) doDisconnectDevice(devPath dbus.ObjectPath) (err error) {
 		return
 	}
 
+	// 主动断开时才清除缓存的热点 ssid
+	m.clearActiveApSsid(devPath)
+
 	devState, _ := nmDev.Device().State().Get(0)
 	if isDeviceStateInActivating(devState) {
 		err = nmDev.Device().Disconnect(0)
@@ -688,7 +704,7 @@ func (m *Manager) updateConnectionBand(conn *connection, band string) (err error
 	if isSettingIP6ConfigRoutesExists(cdata) {
 		setSettingIP6ConfigRoutes(cdata, getSettingIP6ConfigRoutes(cdata))
 	}
-	setSettingWirelessBand(cdata, band)
+	setSettingWirelessBandCompat(cdata, band)
 	err = conn.nmConn.Update(0, cdata)
 	if err != nil {
 		logger.Error(err)
@@ -696,3 +712,186 @@ func (m *Manager) updateConnectionBand(conn *connection, band string) (err error
 	}
 	return
 }
+
+const (
+	bandPreferenceAuto = "auto"
+	bandPreference2G   = "2.4"
+	bandPreference5G   = "5"
+	// NetworkManager 的 band 只有 a 和 bg，6G 频段无法锁定，设置时返回错误
+	bandPreference6G = "6"
+
+	// 记录在连接 user 设置中，表示频段由用户锁定，不再自动切换
+	userDataBandLocked = "org.deepin.dde.network.band-locked"
+)
+
+// setSettingWirelessBandCompat 自动切换到 6G 热点时使用，NetworkManager 不支持设置 6G 频段，
+// 此时去掉频段限制，由激活时指定的热点决定
+func setSettingWirelessBandCompat(cdata connectionData, band string) {
+	if band == band6G {
+		removeSettingWirelessBand(cdata)
+		return
+	}
+	setSettingWirelessBand(cdata, band)
+}
+
+func isConnectionBandLocked(cdata connectionData) bool {
+	if !isSettingExists(cdata, nm.NM_SETTING_USER_SETTING_NAME) {
+		return false
+	}
+	return getSettingUserData(cdata)[userDataBandLocked] == "true"
+}
+
+func getConnectionBandPreference(cdata connectionData) string {
+	if !isConnectionBandLocked(cdata) {
+		return bandPreferenceAuto
+	}
+	switch getSettingWirelessBand(cdata) {
+	case bandA:
+		return bandPreference5G
+	case bandBG:
+		return bandPreference2G
+	}
+	return bandPreferenceAuto
+}
+
+func setConnectionBandPreference(cdata connectionData, preference string) error {
+	var band string
+	switch preference {
+	case bandPreferenceAuto:
+	case bandPreference2G:
+		band = bandBG
+	case bandPreference5G:
+		band = bandA
+	case bandPreference6G:
+		return errors.New("the 6 GHz band can not be locked, NetworkManager only supports the a and bg bands")
+	default:
+		return fmt.Errorf("invalid band preference %q", preference)
+	}
+
+	userData := make(map[string]string)
+	if isSettingExists(cdata, nm.NM_SETTING_USER_SETTING_NAME) {
+		for k, v := range getSettingUserData(cdata) {
+			userData[k] = v
+		}
+	}
+	if band == "" {
+		removeSettingWirelessBand(cdata)
+		delete(userData, userDataBandLocked)
+	} else {
+		setSettingWirelessBand(cdata, band)
+		userData[userDataBandLocked] = "true"
+	}
+	// 频段变化后原信道可能不可用
+	removeSettingWirelessChannel(cdata)
+
+	if len(userData) == 0 {
+		removeSetting(cdata, nm.NM_SETTING_USER_SETTING_NAME)
+	} else {
+		addSetting(cdata, nm.NM_SETTING_USER_SETTING_NAME)
+		setSettingUserData(cdata, userData)
+	}
+	return nil
+}
+
+// SetConnectionBandPreference set the band preference of the wireless
+// connection, band could be "auto", "2.4" or "5". A band other than
+// "auto" is locked and will not be changed automatically. The "6" band
+// returns an error, NetworkManager can not lock a connection to 6 GHz.
+func (m *Manager) SetConnectionBandPreference(uuid, band string) *dbus.Error {
+	err := m.setConnectionBandPreference(uuid, band)
+	if err != nil {
+		logger.Warning("failed to set band preference:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setConnectionBandPreference(uuid, band string) (err error) {
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		return
+	}
+	cdata, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return
+	}
+	if getSettingConnectionType(cdata) != nm.NM_SETTING_WIRELESS_SETTING_NAME {
+		return fmt.Errorf("connection %s is not a wireless connection", uuid)
+	}
+
+	err = setConnectionBandPreference(cdata, band)
+	if err != nil {
+		return
+	}
+	return updateConnectionData(cpath, cdata)
+}
+
+// NetworkManager 允许的自动连接优先级范围
+const (
+	minAutoconnectPriority = -999
+	maxAutoconnectPriority = 999
+)
+
+// sortConnectionsByPriority 按自动连接优先级从高到低排序，优先级相同时按名称排序
+func sortConnectionsByPriority(conns connectionSlice) {
+	sort.SliceStable(conns, func(i, j int) bool {
+		if conns[i].AutoconnectPriority != conns[j].AutoconnectPriority {
+			return conns[i].AutoconnectPriority > conns[j].AutoconnectPriority
+		}
+		return conns[i].Id < conns[j].Id
+	})
+}
+
+// GetConnectionsOrderedByPriority return the connections of the type
+// ordered by autoconnect priority which marshaled by json, empty
+// connType means all types.
+func (m *Manager) GetConnectionsOrderedByPriority(connType string) (connsJSON string, busErr *dbus.Error) {
+	var conns connectionSlice
+	m.connectionsLock.Lock()
+	for typ, typeConns := range m.connections {
+		if connType == "" || connType == typ {
+			conns = append(conns, typeConns...)
+		}
+	}
+	m.connectionsLock.Unlock()
+
+	if conns == nil {
+		conns = make(connectionSlice, 0)
+	}
+	sortConnectionsByPriority(conns)
+	connsJSON, err := marshalJSON(conns)
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	return connsJSON, nil
+}
+
+// SetConnectionAutoconnectPriority set the autoconnect priority of the
+// connection, the connection with higher priority wins when several
+// connections are available, priority should be between -999 and 999.
+func (m *Manager) SetConnectionAutoconnectPriority(uuid string, priority int32) *dbus.Error {
+	err := m.setConnectionAutoconnectPriority(uuid, priority)
+	if err != nil {
+		logger.Warning("failed to set autoconnect priority:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setConnectionAutoconnectPriority(uuid string, priority int32) (err error) {
+	if priority < minAutoconnectPriority || priority > maxAutoconnectPriority {
+		return fmt.Errorf("invalid autoconnect priority %d", priority)
+	}
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		return
+	}
+	cdata, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return
+	}
+
+	setSettingConnectionAutoconnectPriority(cdata, priority)
+	return updateConnectionData(cpath, cdata)
+}
@@ -0,0 +1,233 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	"github.com/linuxdeepin/go-lib/keyfile"
+	"github.com/linuxdeepin/go-lib/utils"
+)
+
+const polkitActionCopyConnectionSecrets = "org.deepin.dde.network.copy-connection-secrets"
+
+// 连接模板的类型
+const (
+	connTemplateWired    = "wired"
+	connTemplateWireless = "wireless"
+)
+
+// hasConnectionSecretSettings 连接中是否有可能保存密码的 setting
+func hasConnectionSecretSettings(data connectionData) bool {
+	for _, setting := range connSecretSettings {
+		if isSettingExists(data, setting) {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareClonedConnectionData 修改复制的连接，使用新的 uuid 和名称，
+// 并去掉与网卡绑定的设置，使其可以用于其他网卡
+func prepareClonedConnectionData(data connectionData, id, uuid string) {
+	setSettingConnectionId(data, id)
+	setSettingConnectionUuid(data, uuid)
+	removeSettingConnectionInterfaceName(data)
+	removeSettingConnectionTimestamp(data)
+	if isSettingExists(data, nm.NM_SETTING_WIRED_SETTING_NAME) {
+		removeSettingWiredMacAddress(data)
+	}
+	if isSettingExists(data, nm.NM_SETTING_WIRELESS_SETTING_NAME) {
+		removeSettingWirelessMacAddress(data)
+	}
+}
+
+func newConnectionTemplateData(templateType, id, uuid string) (data connectionData, err error) {
+	switch templateType {
+	case connTemplateWired:
+		// 模板不绑定网卡，不使用 newWiredConnectionData
+		data = make(connectionData)
+		addSetting(data, nm.NM_SETTING_CONNECTION_SETTING_NAME)
+		setSettingConnectionId(data, id)
+		setSettingConnectionUuid(data, uuid)
+		setSettingConnectionType(data, nm.NM_SETTING_WIRED_SETTING_NAME)
+		addSetting(data, nm.NM_SETTING_WIRED_SETTING_NAME)
+		setSettingWiredDuplex(data, "full")
+		initSettingSectionIpv4(data)
+		initSettingSectionIpv6(data)
+	case connTemplateWireless:
+		data = newWirelessConnectionData(id, uuid, nil, "none", "")
+	default:
+		return nil, fmt.Errorf("invalid connection template type %q", templateType)
+	}
+	return data, nil
+}
+
+// applyConnectionOverrides 按 NetworkManager keyfile 的格式修改连接，overrides 的 key 为
+// setting 名称或 keyfile 中的别名，如 ipv4、wifi、802-1x，值与 keyfile 中的写法相同
+func applyConnectionOverrides(data connectionData, overrides map[string]map[string]string) (connectionData, error) {
+	content, err := formatNMKeyfile(data)
+	if err != nil {
+		return nil, err
+	}
+	kf := keyfile.NewKeyFile()
+	err = kf.LoadFromData(content)
+	if err != nil {
+		return nil, err
+	}
+	for setting, values := range overrides {
+		section := getNMKeyfileSectionName(setting)
+		for key, value := range values {
+			if section == nm.NM_SETTING_CONNECTION_SETTING_NAME &&
+				(key == nm.NM_SETTING_CONNECTION_UUID || key == nm.NM_SETTING_CONNECTION_TYPE) {
+				return nil, fmt.Errorf("%s.%s can not be overridden", setting, key)
+			}
+			kf.SetValue(section, key, value)
+		}
+	}
+	var buf bytes.Buffer
+	err = kf.SaveToWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return parseNMKeyfile(buf.Bytes())
+}
+
+// getConnectionDataWithSecrets 获取包含密码的连接配置，系统保存的密码由 NetworkManager 提供，
+// 用户保存的密码从密钥环中读取，密码标志保持不变
+func (m *Manager) getConnectionDataWithSecrets(cpath dbus.ObjectPath, uuid string) (data connectionData, err error) {
+	data, err = nmGetConnectionData(cpath)
+	if err != nil {
+		return
+	}
+	for _, setting := range connSecretSettings {
+		if !isSettingExists(data, setting) {
+			continue
+		}
+		secrets, err := nmGetConnectionSecrets(cpath, setting)
+		if err != nil {
+			logger.Debugf("failed to get secrets of %s: %v", setting, err)
+			continue
+		}
+		mergeConnectionSecrets(data, secrets)
+	}
+	if m.secretAgent == nil {
+		return
+	}
+	for setting, secretKeys := range secretSettingKeys {
+		if !isSettingExists(data, setting) {
+			continue
+		}
+		saved, err := m.secretAgent.getAll(uuid, setting)
+		if err != nil {
+			logger.Debug(err)
+			continue
+		}
+		for _, key := range secretKeys {
+			value, ok := saved[key]
+			if ok && !isSettingKeyExists(data, setting, key) {
+				setSettingKey(data, setting, key, value)
+			}
+		}
+	}
+	return
+}
+
+// CloneConnection copy the connection with the uuid to a new connection
+// named newName, including its saved secrets. The interface name and mac
+// address are removed so that the new connection can be used on other
+// devices. The caller needs to pass the polkit authentication if the
+// connection may contain secrets.
+func (m *Manager) CloneConnection(sender dbus.Sender, uuid, newName string) (connection dbus.ObjectPath, busErr *dbus.Error) {
+	cpath, err := m.cloneConnection(sender, uuid, newName)
+	if err != nil {
+		logger.Warning("failed to clone connection:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+func (m *Manager) cloneConnection(sender dbus.Sender, uuid, newName string) (cpath dbus.ObjectPath, err error) {
+	if newName == "" {
+		return "/", errors.New("name of the new connection is empty")
+	}
+	srcPath, data, err := m.getConnectionData(uuid)
+	if err != nil {
+		return "/", err
+	}
+	if hasConnectionSecretSettings(data) {
+		err = m.checkPolkitAuth(sender, polkitActionCopyConnectionSecrets)
+		if err != nil {
+			return "/", err
+		}
+		data, err = m.getConnectionDataWithSecrets(srcPath, uuid)
+		if err != nil {
+			return "/", err
+		}
+	}
+
+	newUuid := utils.GenUuid()
+	prepareClonedConnectionData(data, newName, newUuid)
+	fixConnectionIP6Data(data)
+	logger.Infof("clone connection %s to %s, uuid: %s", uuid, newName, newUuid)
+	return nmAddConnection(data)
+}
+
+// CreateConnectionFromTemplate create a connection from the template,
+// templateType is "wired" or "wireless". overridesJSON is a json object
+// whose keys are the setting names, such as "connection", "ipv4", "wifi"
+// and "802-1x", and whose values are the keys and values written in the
+// NetworkManager keyfile format, e.g.
+// {"connection":{"id":"Office"},"ipv4":{"method":"manual","address1":"192.168.1.10/24,192.168.1.1"}}
+func (m *Manager) CreateConnectionFromTemplate(templateType, overridesJSON string) (connection dbus.ObjectPath, busErr *dbus.Error) {
+	cpath, err := m.createConnectionFromTemplate(templateType, overridesJSON)
+	if err != nil {
+		logger.Warning("failed to create connection from template:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+func (m *Manager) createConnectionFromTemplate(templateType, overridesJSON string) (cpath dbus.ObjectPath, err error) {
+	var overrides map[string]map[string]string
+	if overridesJSON != "" {
+		err = json.Unmarshal([]byte(overridesJSON), &overrides)
+		if err != nil {
+			return "/", fmt.Errorf("invalid overrides: %v", err)
+		}
+	}
+	id := overrides[nm.NM_SETTING_CONNECTION_SETTING_NAME][nm.NM_SETTING_CONNECTION_ID]
+	if id == "" {
+		switch templateType {
+		case connTemplateWired:
+			id = m.getCreateConnectionName()
+		case connTemplateWireless:
+			// 无线连接默认使用 ssid 作为名称
+			id = overrides["wifi"][nm.NM_SETTING_WIRELESS_SSID]
+			if id == "" {
+				id = overrides[nm.NM_SETTING_WIRELESS_SETTING_NAME][nm.NM_SETTING_WIRELESS_SSID]
+			}
+		}
+	}
+	if id == "" {
+		return "/", errors.New("name of the connection is required")
+	}
+	data, err := newConnectionTemplateData(templateType, id, utils.GenUuid())
+	if err != nil {
+		return "/", err
+	}
+	data, err = applyConnectionOverrides(data, overrides)
+	if err != nil {
+		return "/", err
+	}
+	logger.Infof("create connection %s from %s template", getSettingConnectionId(data), templateType)
+	return nmAddConnection(data)
+}
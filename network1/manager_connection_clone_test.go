@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestPrepareClonedConnectionData(c *C.C) {
+	data := newWirelessConnectionData("test", "uuid", []byte("test"), "wpa-psk", "00:11:22:33:44:55")
+	setSettingConnectionInterfaceName(data, "wlan0")
+	prepareClonedConnectionData(data, "test copy", "new-uuid")
+	c.Check(getSettingConnectionId(data), C.Equals, "test copy")
+	c.Check(getSettingConnectionUuid(data), C.Equals, "new-uuid")
+	c.Check(isSettingConnectionInterfaceNameExists(data), C.Equals, false)
+	c.Check(isSettingWirelessMacAddressExists(data), C.Equals, false)
+	c.Check(string(getSettingWirelessSsid(data)), C.Equals, "test")
+	c.Check(hasConnectionSecretSettings(data), C.Equals, true)
+}
+
+func (*testWrapper) TestApplyConnectionOverrides(c *C.C) {
+	data, err := newConnectionTemplateData(connTemplateWireless, "office", "uuid")
+	c.Assert(err, C.IsNil)
+	c.Check(hasConnectionSecretSettings(data), C.Equals, false)
+
+	data, err = applyConnectionOverrides(data, map[string]map[string]string{
+		"wifi":          {"ssid": "office"},
+		"wifi-security": {"key-mgmt": "wpa-psk", "psk": "12345678"},
+		"ipv4":          {"method": "manual", "address1": "192.168.1.10/24,192.168.1.1"},
+	})
+	c.Assert(err, C.IsNil)
+	c.Check(getSettingConnectionId(data), C.Equals, "office")
+	c.Check(getSettingConnectionUuid(data), C.Equals, "uuid")
+	c.Check(getSettingConnectionType(data), C.Equals, nm.NM_SETTING_WIRELESS_SETTING_NAME)
+	c.Check(string(getSettingWirelessSsid(data)), C.Equals, "office")
+	c.Check(getSettingWirelessSecurityKeyMgmt(data), C.Equals, "wpa-psk")
+	c.Check(getSettingWirelessSecurityPsk(data), C.Equals, "12345678")
+	c.Check(getSettingIP4ConfigMethod(data), C.Equals, nm.NM_SETTING_IP4_CONFIG_METHOD_MANUAL)
+	c.Check(getSettingKey(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME, "gateway"), C.Equals, "192.168.1.1")
+
+	_, err = applyConnectionOverrides(data, map[string]map[string]string{
+		"connection": {"uuid": "other"},
+	})
+	c.Check(err, C.NotNil)
+	_, err = newConnectionTemplateData("bond", "test", "uuid")
+	c.Check(err, C.NotNil)
+}
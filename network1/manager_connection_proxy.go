@@ -0,0 +1,439 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// 手动代理保存在连接的 user setting 中，NetworkManager 的 proxy setting 只支持 PAC
+const (
+	connProxyUserDataPrefix      = "org.deepin.proxy."
+	connProxyUserDataMethod      = connProxyUserDataPrefix + "method"
+	connProxyUserDataIgnoreHosts = connProxyUserDataPrefix + "ignore-hosts"
+)
+
+var connProxyTypes = []string{proxyTypeHttp, proxyTypeHttps, proxyTypeFtp, proxyTypeSocks}
+
+type connectionProxyConfig struct {
+	// none, manual 或 auto，为空表示使用全局代理
+	Method string
+	PacUrl string
+	// host:port 格式
+	Http        string
+	Https       string
+	Ftp         string
+	Socks       string
+	IgnoreHosts []string
+}
+
+func (cfg *connectionProxyConfig) getProxy(proxyType string) string {
+	switch proxyType {
+	case proxyTypeHttp:
+		return cfg.Http
+	case proxyTypeHttps:
+		return cfg.Https
+	case proxyTypeFtp:
+		return cfg.Ftp
+	case proxyTypeSocks:
+		return cfg.Socks
+	}
+	return ""
+}
+
+func (cfg *connectionProxyConfig) setProxy(proxyType, value string) {
+	switch proxyType {
+	case proxyTypeHttp:
+		cfg.Http = value
+	case proxyTypeHttps:
+		cfg.Https = value
+	case proxyTypeFtp:
+		cfg.Ftp = value
+	case proxyTypeSocks:
+		cfg.Socks = value
+	}
+}
+
+func splitProxyHostPort(value string) (host string, port int32, err error) {
+	host, portStr, err := net.SplitHostPort(value)
+	if err != nil {
+		return
+	}
+	portInt, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil || host == "" || portInt == 0 {
+		err = fmt.Errorf("invalid proxy %q", value)
+		return
+	}
+	return host, int32(portInt), nil
+}
+
+func (cfg *connectionProxyConfig) check() error {
+	switch cfg.Method {
+	case "", proxyModeNone:
+	case proxyModeAuto:
+		u, err := url.Parse(cfg.PacUrl)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "file") {
+			return fmt.Errorf("invalid pac url %q", cfg.PacUrl)
+		}
+	case proxyModeManual:
+		hasProxy := false
+		for _, proxyType := range connProxyTypes {
+			value := cfg.getProxy(proxyType)
+			if value == "" {
+				continue
+			}
+			if _, _, err := splitProxyHostPort(value); err != nil {
+				return err
+			}
+			hasProxy = true
+		}
+		if !hasProxy {
+			return fmt.Errorf("no proxy server for manual proxy method")
+		}
+	default:
+		return fmt.Errorf("invalid proxy method %q", cfg.Method)
+	}
+	return nil
+}
+
+// getConnectionProxyConfig 读取连接的代理配置，未单独设置代理时返回 nil
+func getConnectionProxyConfig(data connectionData) *connectionProxyConfig {
+	userData := getSettingUserData(data)
+	method := userData[connProxyUserDataMethod]
+	if method == "" {
+		return nil
+	}
+	cfg := &connectionProxyConfig{
+		Method: method,
+		PacUrl: getSettingProxyPacUrl(data),
+	}
+	for _, proxyType := range connProxyTypes {
+		cfg.setProxy(proxyType, userData[connProxyUserDataPrefix+proxyType])
+	}
+	if ignoreHosts := userData[connProxyUserDataIgnoreHosts]; ignoreHosts != "" {
+		cfg.IgnoreHosts = strings.Split(ignoreHosts, ",")
+	}
+	return cfg
+}
+
+// setConnectionProxyConfig 将代理配置写入连接，cfg.Method 为空时清除连接的代理配置
+func setConnectionProxyConfig(data connectionData, cfg *connectionProxyConfig) {
+	userData := make(map[string]string)
+	for key, value := range getSettingUserData(data) {
+		if !strings.HasPrefix(key, connProxyUserDataPrefix) {
+			userData[key] = value
+		}
+	}
+
+	if cfg.Method != "" {
+		userData[connProxyUserDataMethod] = cfg.Method
+		for _, proxyType := range connProxyTypes {
+			if value := cfg.getProxy(proxyType); value != "" && cfg.Method == proxyModeManual {
+				userData[connProxyUserDataPrefix+proxyType] = value
+			}
+		}
+		if len(cfg.IgnoreHosts) != 0 {
+			userData[connProxyUserDataIgnoreHosts] = strings.Join(cfg.IgnoreHosts, ",")
+		}
+	}
+	if len(userData) != 0 {
+		addSetting(data, nm.NM_SETTING_USER_SETTING_NAME)
+		setSettingUserData(data, userData)
+	} else {
+		removeSetting(data, nm.NM_SETTING_USER_SETTING_NAME)
+	}
+
+	// 同步到 NetworkManager 的 proxy setting，便于其他程序使用 PAC
+	addSetting(data, nm.NM_SETTING_PROXY_SETTING_NAME)
+	if cfg.Method == proxyModeAuto {
+		setSettingProxyMethod(data, nm.NM_SETTING_PROXY_METHOD_AUTO)
+		setSettingProxyPacUrl(data, cfg.PacUrl)
+	} else {
+		setSettingProxyMethod(data, nm.NM_SETTING_PROXY_METHOD_NONE)
+		removeSettingKey(data, nm.NM_SETTING_PROXY_SETTING_NAME, nm.NM_SETTING_PROXY_PAC_URL)
+	}
+}
+
+// proxySnapshot 会话代理的 gsettings 配置
+type proxySnapshot struct {
+	Mode        string
+	AutoUrl     string
+	IgnoreHosts []string
+	Hosts       map[string]string
+	Ports       map[string]int32
+}
+
+func (s *proxySnapshot) equal(other *proxySnapshot) bool {
+	if s.Mode != other.Mode || s.AutoUrl != other.AutoUrl ||
+		strings.Join(s.IgnoreHosts, ",") != strings.Join(other.IgnoreHosts, ",") {
+		return false
+	}
+	for _, proxyType := range connProxyTypes {
+		if s.Hosts[proxyType] != other.Hosts[proxyType] ||
+			s.Ports[proxyType] != other.Ports[proxyType] {
+			return false
+		}
+	}
+	return true
+}
+
+// savedProxyState 切换到连接代理前保存的全局代理和当前应用的代理，
+// 保存到 dconfig 中，会话进程重启后仍能恢复全局代理
+type savedProxyState struct {
+	Global  *proxySnapshot
+	Applied *proxySnapshot
+}
+
+func getProxySnapshot() *proxySnapshot {
+	s := &proxySnapshot{
+		Mode:        proxySettings.GetString(gkeyProxyMode),
+		AutoUrl:     proxySettings.GetString(gkeyProxyAuto),
+		IgnoreHosts: proxySettings.GetStrv(gkeyProxyIgnoreHosts),
+		Hosts:       make(map[string]string),
+		Ports:       make(map[string]int32),
+	}
+	for _, proxyType := range connProxyTypes {
+		childSettings, err := getProxyChildSettings(proxyType)
+		if err != nil {
+			continue
+		}
+		s.Hosts[proxyType] = childSettings.GetString(gkeyProxyHost)
+		s.Ports[proxyType] = childSettings.GetInt(gkeyProxyPort)
+	}
+	return s
+}
+
+func (cfg *connectionProxyConfig) toSnapshot(global *proxySnapshot) *proxySnapshot {
+	s := &proxySnapshot{
+		Mode:        cfg.Method,
+		AutoUrl:     cfg.PacUrl,
+		IgnoreHosts: cfg.IgnoreHosts,
+		Hosts:       make(map[string]string),
+		Ports:       make(map[string]int32),
+	}
+	// 未设置忽略的主机时沿用全局配置
+	if len(s.IgnoreHosts) == 0 && global != nil {
+		s.IgnoreHosts = global.IgnoreHosts
+	}
+	for _, proxyType := range connProxyTypes {
+		host, port, err := splitProxyHostPort(cfg.getProxy(proxyType))
+		if err == nil {
+			s.Hosts[proxyType] = host
+			s.Ports[proxyType] = port
+		}
+	}
+	return s
+}
+
+func (m *Manager) applyProxySnapshot(s *proxySnapshot, source string) {
+	oldMode := proxySettings.GetString(gkeyProxyMode)
+	proxySettings.SetString(gkeyProxyAuto, s.AutoUrl)
+	proxySettings.SetStrv(gkeyProxyIgnoreHosts, s.IgnoreHosts)
+	for _, proxyType := range connProxyTypes {
+		childSettings, err := getProxyChildSettings(proxyType)
+		if err != nil {
+			continue
+		}
+		childSettings.SetString(gkeyProxyHost, s.Hosts[proxyType])
+		childSettings.SetInt(gkeyProxyPort, s.Ports[proxyType])
+	}
+	proxySettings.SetString(gkeyProxyMode, s.Mode)
+	if oldMode != s.Mode {
+		m.service.Emit(m, "ProxyMethodChanged", s.Mode)
+	}
+	m.service.Emit(m, "ProxyChanged", s.Mode, source)
+}
+
+// applyConnectionProxy 主连接变化时切换会话代理，主连接未单独设置代理时恢复全局代理，
+// 开启了代理自动检测时再通过 WPAD 查找代理
+func (m *Manager) applyConnectionProxy(primaryPath dbus.ObjectPath) {
+	var cfg *connectionProxyConfig
+	uuid := ""
+	if primaryPath != "" && primaryPath != "/" {
+		if aConn, err := nmNewActiveConnection(primaryPath); err == nil {
+			uuid, _ = aConn.Uuid().Get(0)
+			cpath, _ := aConn.Connection().Get(0)
+			if data, err := nmGetConnectionData(cpath); err == nil {
+				cfg = getConnectionProxyConfig(data)
+			}
+		}
+	}
+
+	m.connProxyLock.Lock()
+	defer m.connProxyLock.Unlock()
+	m.proxyPrimaryPath = primaryPath
+	if cfg == nil {
+		m.restoreGlobalProxy()
+		if m.proxyAutoDetect && isNmObjectPathValid(primaryPath) {
+			go m.applyWpadProxy(primaryPath)
+		}
+		return
+	}
+
+	logger.Infof("apply proxy of connection %s, method: %s", uuid, cfg.Method)
+	m.applyProxyOverGlobal(cfg, proxySourceConnection)
+}
+
+// isProxyEditedByUser 应用连接代理后用户又修改了会话代理
+func (m *Manager) isProxyEditedByUser() bool {
+	return m.appliedProxy != nil && !m.appliedProxy.equal(getProxySnapshot())
+}
+
+// applyProxyOverGlobal 应用连接或 WPAD 的代理，调用时需持有 connProxyLock
+func (m *Manager) applyProxyOverGlobal(cfg *connectionProxyConfig, source string) {
+	// 仅保存第一次切换前的全局代理，连接之间切换时不覆盖，
+	// 用户在此期间修改了代理时以用户的修改作为新的全局代理
+	if m.globalProxy == nil || m.isProxyEditedByUser() {
+		m.globalProxy = getProxySnapshot()
+	}
+	s := cfg.toSnapshot(m.globalProxy)
+	m.applyProxySnapshot(s, source)
+	m.appliedProxy = s
+	m.saveProxyState()
+}
+
+// restoreGlobalProxy 恢复切换前的全局代理，调用时需持有 connProxyLock
+func (m *Manager) restoreGlobalProxy() {
+	if m.globalProxy == nil {
+		return
+	}
+	if m.isProxyEditedByUser() {
+		logger.Info("proxy is edited by user, keep it")
+	} else {
+		logger.Info("restore global proxy")
+		m.applyProxySnapshot(m.globalProxy, proxySourceGlobal)
+	}
+	m.globalProxy = nil
+	m.appliedProxy = nil
+	m.saveProxyState()
+}
+
+// initProxyState 读取上次退出前保存的全局代理
+func (m *Manager) initProxyState() {
+	if m.networkConfig == nil {
+		return
+	}
+	v, err := m.networkConfig.Value(0, dsettingsSavedProxyState)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	str, _ := v.Value().(string)
+	if str == "" {
+		return
+	}
+	var state savedProxyState
+	err = json.Unmarshal([]byte(str), &state)
+	if err != nil || state.Global == nil || state.Applied == nil {
+		logger.Warning("invalid saved proxy state:", err)
+		return
+	}
+	m.connProxyLock.Lock()
+	m.globalProxy = state.Global
+	m.appliedProxy = state.Applied
+	m.connProxyLock.Unlock()
+}
+
+// saveProxyState 调用时需持有 connProxyLock
+func (m *Manager) saveProxyState() {
+	if m.networkConfig == nil {
+		return
+	}
+	var str string
+	if m.globalProxy != nil {
+		data, err := json.Marshal(&savedProxyState{
+			Global:  m.globalProxy,
+			Applied: m.appliedProxy,
+		})
+		if err != nil {
+			logger.Warning(err)
+			return
+		}
+		str = string(data)
+	}
+	err := m.networkConfig.SetValue(0, dsettingsSavedProxyState, dbus.MakeVariant(str))
+	if err != nil {
+		logger.Warning("failed to save proxy state:", err)
+	}
+}
+
+// GetConnectionProxy get the proxy config of the connection marshaled
+// by json, the Method is empty if the connection uses the global proxy.
+func (m *Manager) GetConnectionProxy(uuid string) (configJSON string, busErr *dbus.Error) {
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	data, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	cfg := getConnectionProxyConfig(data)
+	if cfg == nil {
+		cfg = &connectionProxyConfig{}
+	}
+	configJSON, err = marshalJSON(cfg)
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	return configJSON, nil
+}
+
+// SetConnectionProxy set the proxy used while the connection is the
+// primary connection, configJSON is the marshaled connectionProxyConfig,
+// an empty Method makes the connection use the global proxy.
+func (m *Manager) SetConnectionProxy(uuid, configJSON string) *dbus.Error {
+	var cfg connectionProxyConfig
+	err := json.Unmarshal([]byte(configJSON), &cfg)
+	if err == nil {
+		err = m.setConnectionProxy(uuid, &cfg)
+	}
+	if err != nil {
+		logger.Warning("failed to set connection proxy:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setConnectionProxy(uuid string, cfg *connectionProxyConfig) (err error) {
+	err = cfg.check()
+	if err != nil {
+		return
+	}
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		return
+	}
+	data, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return
+	}
+
+	setConnectionProxyConfig(data, cfg)
+	err = updateConnectionData(cpath, data)
+	if err != nil {
+		return
+	}
+
+	// 修改的是当前主连接时立即生效
+	primaryPath := nmGetPrimaryConnection()
+	if aConn, err := nmNewActiveConnection(primaryPath); err == nil {
+		if primaryUuid, _ := aConn.Uuid().Get(0); primaryUuid == uuid {
+			m.applyConnectionProxy(primaryPath)
+		}
+	}
+	return nil
+}
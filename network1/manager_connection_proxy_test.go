@@ -0,0 +1,69 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"encoding/json"
+
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestConnectionProxyConfigCheck(c *C.C) {
+	c.Check((&connectionProxyConfig{}).check(), C.IsNil)
+	c.Check((&connectionProxyConfig{Method: proxyModeNone}).check(), C.IsNil)
+	c.Check((&connectionProxyConfig{Method: proxyModeAuto, PacUrl: "http://wpad/wpad.dat"}).check(), C.IsNil)
+	c.Check((&connectionProxyConfig{Method: proxyModeManual, Http: "proxy.example.com:3128"}).check(), C.IsNil)
+
+	c.Check((&connectionProxyConfig{Method: "system"}).check(), C.NotNil)
+	c.Check((&connectionProxyConfig{Method: proxyModeAuto, PacUrl: "wpad.dat"}).check(), C.NotNil)
+	c.Check((&connectionProxyConfig{Method: proxyModeManual}).check(), C.NotNil)
+	c.Check((&connectionProxyConfig{Method: proxyModeManual, Http: "proxy.example.com"}).check(), C.NotNil)
+	c.Check((&connectionProxyConfig{Method: proxyModeManual, Socks: "proxy.example.com:70000"}).check(), C.NotNil)
+}
+
+func (*testWrapper) TestConnectionProxyConfigData(c *C.C) {
+	data := make(connectionData)
+	addSetting(data, nm.NM_SETTING_USER_SETTING_NAME)
+	setSettingUserData(data, map[string]string{"org.example.key": "value"})
+	c.Check(getConnectionProxyConfig(data), C.IsNil)
+
+	cfg := &connectionProxyConfig{
+		Method:      proxyModeManual,
+		Http:        "proxy.example.com:3128",
+		Socks:       "[::1]:1080",
+		IgnoreHosts: []string{"localhost", "127.0.0.0/8"},
+	}
+	setConnectionProxyConfig(data, cfg)
+	c.Check(getConnectionProxyConfig(data), C.DeepEquals, cfg)
+	c.Check(getSettingProxyMethod(data), C.Equals, int32(nm.NM_SETTING_PROXY_METHOD_NONE))
+	// 不影响其他程序保存的数据
+	c.Check(getSettingUserData(data)["org.example.key"], C.Equals, "value")
+
+	cfg = &connectionProxyConfig{Method: proxyModeAuto, PacUrl: "http://wpad/wpad.dat"}
+	setConnectionProxyConfig(data, cfg)
+	c.Check(getConnectionProxyConfig(data), C.DeepEquals, cfg)
+	c.Check(getSettingProxyMethod(data), C.Equals, int32(nm.NM_SETTING_PROXY_METHOD_AUTO))
+
+	// Method 为空时清除连接的代理配置
+	setConnectionProxyConfig(data, &connectionProxyConfig{})
+	c.Check(getConnectionProxyConfig(data), C.IsNil)
+	c.Check(getSettingUserData(data), C.DeepEquals, map[string]string{"org.example.key": "value"})
+
+	snapshot := (&connectionProxyConfig{Method: proxyModeManual, Socks: "[::1]:1080"}).toSnapshot(
+		&proxySnapshot{IgnoreHosts: []string{"localhost"}})
+	c.Check(snapshot.Hosts[proxyTypeSocks], C.Equals, "::1")
+	c.Check(snapshot.Ports[proxyTypeSocks], C.Equals, int32(1080))
+	c.Check(snapshot.IgnoreHosts, C.DeepEquals, []string{"localhost"})
+
+	// 保存到 dconfig 后能还原
+	data2, err := json.Marshal(&savedProxyState{Global: snapshot, Applied: snapshot})
+	c.Assert(err, C.IsNil)
+	var state savedProxyState
+	c.Assert(json.Unmarshal(data2, &state), C.IsNil)
+	c.Check(state.Global.equal(snapshot), C.Equals, true)
+	state.Applied.Ports[proxyTypeSocks] = 1081
+	c.Check(state.Applied.equal(snapshot), C.Equals, false)
+}
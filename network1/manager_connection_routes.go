@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+const (
+	nmSettingIPKeyRouteData = "route-data"
+	nmSettingIPKeyRoutes    = "routes"
+)
+
+// staticRoute 连接的静态路由，Metric 为空时使用连接的默认 metric
+type staticRoute struct {
+	Dest    string
+	Prefix  uint32
+	NextHop string  `json:",omitempty"`
+	Metric  *uint32 `json:",omitempty"`
+}
+
+func (r *staticRoute) check(isIPv6 bool) error {
+	family, maxPrefix := "ipv4", 32
+	if isIPv6 {
+		family, maxPrefix = "ipv6", 128
+	}
+	ip := net.ParseIP(r.Dest)
+	if ip == nil || (ip.To4() == nil) != isIPv6 {
+		return fmt.Errorf("destination %q is not a valid %s address", r.Dest, family)
+	}
+	if int(r.Prefix) > maxPrefix {
+		return fmt.Errorf("prefix %d of destination %s is out of range 0-%d", r.Prefix, r.Dest, maxPrefix)
+	}
+	network := ip.Mask(net.CIDRMask(int(r.Prefix), maxPrefix))
+	if !network.Equal(ip) {
+		return fmt.Errorf("destination %s/%d has host bits set, use the network address %s/%d instead",
+			r.Dest, r.Prefix, network, r.Prefix)
+	}
+	if r.NextHop != "" {
+		nextHop := net.ParseIP(r.NextHop)
+		if nextHop == nil || (nextHop.To4() == nil) != isIPv6 {
+			return fmt.Errorf("next hop %q of destination %s/%d is not a valid %s address",
+				r.NextHop, r.Dest, r.Prefix, family)
+		}
+		if nextHop.IsUnspecified() {
+			return fmt.Errorf("next hop of destination %s/%d could not be %s, leave it empty for an on-link route",
+				r.Dest, r.Prefix, r.NextHop)
+		}
+	}
+	return nil
+}
+
+func (r *staticRoute) toRouteData() map[string]dbus.Variant {
+	routeData := map[string]dbus.Variant{
+		"dest":   dbus.MakeVariant(net.ParseIP(r.Dest).String()),
+		"prefix": dbus.MakeVariant(r.Prefix),
+	}
+	if r.NextHop != "" {
+		routeData["next-hop"] = dbus.MakeVariant(net.ParseIP(r.NextHop).String())
+	}
+	if r.Metric != nil {
+		routeData["metric"] = dbus.MakeVariant(*r.Metric)
+	}
+	return routeData
+}
+
+func checkStaticRoutes(routes []staticRoute, isIPv6 bool) error {
+	family := "ipv4"
+	if isIPv6 {
+		family = "ipv6"
+	}
+	for i := range routes {
+		err := routes[i].check(isIPv6)
+		if err != nil {
+			return fmt.Errorf("%s route %d: %v", family, i+1, err)
+		}
+	}
+	return nil
+}
+
+func getConnectionRoutes(data connectionData, setting string) []staticRoute {
+	routes := make([]staticRoute, 0)
+	routeData, _ := getSettingKey(data, setting, nmSettingIPKeyRouteData).([]map[string]dbus.Variant)
+	for _, item := range routeData {
+		var route staticRoute
+		route.Dest, _ = item["dest"].Value().(string)
+		route.Prefix, _ = item["prefix"].Value().(uint32)
+		route.NextHop, _ = item["next-hop"].Value().(string)
+		if metric, ok := item["metric"].Value().(uint32); ok {
+			route.Metric = &metric
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// setConnectionRoutes 使用 route-data 保存路由，同时移除已废弃的 routes
+func setConnectionRoutes(data connectionData, setting string, routes []staticRoute) {
+	removeSettingKey(data, setting, nmSettingIPKeyRoutes)
+	if len(routes) == 0 {
+		removeSettingKey(data, setting, nmSettingIPKeyRouteData)
+		return
+	}
+	routeData := make([]map[string]dbus.Variant, 0, len(routes))
+	for i := range routes {
+		routeData = append(routeData, routes[i].toRouteData())
+	}
+	setSettingKey(data, setting, nmSettingIPKeyRouteData, routeData)
+}
+
+func unmarshalStaticRoutes(routesJSON string) (routes []staticRoute, err error) {
+	if routesJSON == "" {
+		return nil, nil
+	}
+	err = json.Unmarshal([]byte(routesJSON), &routes)
+	return
+}
+
+// GetConnectionRoutes get the static routes of the connection, the routes
+// are marshaled staticRoute lists.
+func (m *Manager) GetConnectionRoutes(uuid string) (ipv4RoutesJSON, ipv6RoutesJSON string, busErr *dbus.Error) {
+	_, data, err := m.getConnectionData(uuid)
+	if err != nil {
+		return "", "", dbusutil.ToError(err)
+	}
+	ipv4RoutesJSON, err = marshalJSON(getConnectionRoutes(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME))
+	if err != nil {
+		return "", "", dbusutil.ToError(err)
+	}
+	ipv6RoutesJSON, err = marshalJSON(getConnectionRoutes(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME))
+	if err != nil {
+		return "", "", dbusutil.ToError(err)
+	}
+	return ipv4RoutesJSON, ipv6RoutesJSON, nil
+}
+
+// SetConnectionRoutes replace the static routes of the connection, the
+// routes are marshaled staticRoute lists, an empty list removes all the
+// routes. The changes take effect after the connection is reactivated.
+func (m *Manager) SetConnectionRoutes(uuid, ipv4RoutesJSON, ipv6RoutesJSON string) *dbus.Error {
+	err := m.setConnectionRoutes(uuid, ipv4RoutesJSON, ipv6RoutesJSON)
+	if err != nil {
+		logger.Warning("failed to set connection routes:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setConnectionRoutes(uuid, ipv4RoutesJSON, ipv6RoutesJSON string) error {
+	ipv4Routes, err := unmarshalStaticRoutes(ipv4RoutesJSON)
+	if err != nil {
+		return fmt.Errorf("invalid ipv4 routes: %v", err)
+	}
+	ipv6Routes, err := unmarshalStaticRoutes(ipv6RoutesJSON)
+	if err != nil {
+		return fmt.Errorf("invalid ipv6 routes: %v", err)
+	}
+	err = checkStaticRoutes(ipv4Routes, false)
+	if err != nil {
+		return err
+	}
+	err = checkStaticRoutes(ipv6Routes, true)
+	if err != nil {
+		return err
+	}
+
+	cpath, data, err := m.getConnectionData(uuid)
+	if err != nil {
+		return err
+	}
+	if !isSettingExists(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME) && len(ipv4Routes) != 0 {
+		return fmt.Errorf("connection %s does not support ipv4", uuid)
+	}
+	if !isSettingExists(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME) && len(ipv6Routes) != 0 {
+		return fmt.Errorf("connection %s does not support ipv6", uuid)
+	}
+	if isSettingExists(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME) {
+		setConnectionRoutes(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME, ipv4Routes)
+	}
+	if isSettingExists(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME) {
+		setConnectionRoutes(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME, ipv6Routes)
+	}
+	return updateConnectionData(cpath, data)
+}
@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestStaticRouteCheck(c *C.C) {
+	c.Check((&staticRoute{Dest: "10.0.0.0", Prefix: 8}).check(false), C.IsNil)
+	c.Check((&staticRoute{Dest: "10.1.0.0", Prefix: 16, NextHop: "192.168.1.1"}).check(false), C.IsNil)
+	c.Check((&staticRoute{Dest: "2001:db8::", Prefix: 32, NextHop: "fe80::1"}).check(true), C.IsNil)
+
+	c.Check((&staticRoute{Dest: "10.0.0.0", Prefix: 33}).check(false), C.NotNil)
+	// 主机位不为 0
+	c.Check((&staticRoute{Dest: "10.0.0.1", Prefix: 8}).check(false), C.ErrorMatches, ".*use the network address 10.0.0.0/8.*")
+	// 地址族不一致
+	c.Check((&staticRoute{Dest: "10.0.0.0", Prefix: 8}).check(true), C.NotNil)
+	c.Check((&staticRoute{Dest: "10.0.0.0", Prefix: 8, NextHop: "fe80::1"}).check(false), C.NotNil)
+	c.Check((&staticRoute{Dest: "10.0.0.0", Prefix: 8, NextHop: "0.0.0.0"}).check(false), C.NotNil)
+
+	err := checkStaticRoutes([]staticRoute{{Dest: "10.0.0.0", Prefix: 8}, {Dest: "bad", Prefix: 8}}, false)
+	c.Check(err, C.ErrorMatches, "ipv4 route 2: .*")
+}
+
+func (*testWrapper) TestConnectionRoutesData(c *C.C) {
+	data := make(connectionData)
+	addSetting(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME)
+	setSettingIP4ConfigRoutes(data, [][]uint32{{167772160, 8, 0, 0}})
+
+	metric := uint32(100)
+	routes := []staticRoute{
+		{Dest: "10.0.0.0", Prefix: 8, NextHop: "192.168.1.1", Metric: &metric},
+		{Dest: "172.16.0.0", Prefix: 12},
+	}
+	setConnectionRoutes(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME, routes)
+	c.Check(isSettingIP4ConfigRoutesExists(data), C.Equals, false)
+	c.Check(getConnectionRoutes(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME), C.DeepEquals, routes)
+
+	setConnectionRoutes(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME, nil)
+	c.Check(getConnectionRoutes(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME), C.HasLen, 0)
+
+	routes, err := unmarshalStaticRoutes(`[{"Dest":"10.0.0.0","Prefix":8,"Metric":50}]`)
+	c.Assert(err, C.IsNil)
+	c.Check(*routes[0].Metric, C.Equals, uint32(50))
+}
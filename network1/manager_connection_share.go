@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"errors"
+	"fmt"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	. "github.com/linuxdeepin/go-lib/gettext"
+)
+
+type connectionShareInfo struct {
+	Enabled         bool
+	DeviceInterface string
+	// 共享出去的上游连接，即当前的主连接
+	Upstream string
+	Clients  []hotspotStation
+}
+
+// getSharedConnectionUuid 有线设备共享连接的 uuid，需要与设备默认连接的 uuid 区分
+func getSharedConnectionUuid(devPath dbus.ObjectPath) string {
+	devId, err := nmGeneralGetDeviceIdentifier(devPath)
+	if err != nil {
+		return ""
+	}
+	return strToUuid(devId + "-shared")
+}
+
+// getShareConnectionUuid 获取设备用于共享网络的连接，无线设备使用热点连接
+func getShareConnectionUuid(devPath dbus.ObjectPath) (uuid string, err error) {
+	switch devType := nmGetDeviceType(devPath); devType {
+	case nm.NM_DEVICE_TYPE_ETHERNET:
+		uuid = getSharedConnectionUuid(devPath)
+	case nm.NM_DEVICE_TYPE_WIFI:
+		uuid = nmGeneralGetDeviceUniqueUuid(devPath)
+	default:
+		return "", fmt.Errorf("device %s of type %d could not share connection", devPath, devType)
+	}
+	if uuid == "" {
+		return "", fmt.Errorf("failed to get identifier of device %s", devPath)
+	}
+	return
+}
+
+func newWiredSharedConnectionData(id, uuid string, devPath dbus.ObjectPath) (data connectionData) {
+	data = newWiredConnectionData(id, uuid, devPath)
+	setSettingConnectionAutoconnect(data, false)
+	setSettingIP4ConfigMethod(data, nm.NM_SETTING_IP4_CONFIG_METHOD_SHARED)
+	return
+}
+
+// ShareConnectionTo share the primary connection to the machines connected
+// to the device, the device will act as a gateway with ipv4.method=shared.
+// For a wireless device the hotspot must be configured by EnableHotspot first.
+func (m *Manager) ShareConnectionTo(devPath dbus.ObjectPath) *dbus.Error {
+	err := m.shareConnectionTo(devPath)
+	if err != nil {
+		logger.Warning("failed to share connection:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) shareConnectionTo(devPath dbus.ObjectPath) (err error) {
+	uuid, err := getShareConnectionUuid(devPath)
+	if err != nil {
+		return
+	}
+	// 不能把网络共享给提供网络的设备
+	if apath := nmGetDeviceActiveConnection(devPath); isNmObjectPathValid(apath) &&
+		apath == nmGetPrimaryConnection() && nmGetActiveConnectionUuid(apath) != uuid {
+		return fmt.Errorf("device %s provides the primary connection", devPath)
+	}
+
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		if nmGetDeviceType(devPath) == nm.NM_DEVICE_TYPE_WIFI {
+			return errors.New("hotspot is not configured, enable it by EnableHotspot first")
+		}
+		logger.Infof("new wired shared connection, uuid=%s, devPath=%s", uuid, devPath)
+		data := newWiredSharedConnectionData(Tr("Shared connection"), uuid, devPath)
+		_, _, err = nmAddAndActivateConnection(data, devPath, true)
+		return
+	}
+	_, err = nmActivateConnection(cpath, devPath)
+	return
+}
+
+// StopSharingConnection stop sharing the connection through the device.
+func (m *Manager) StopSharingConnection(devPath dbus.ObjectPath) *dbus.Error {
+	uuid, err := getShareConnectionUuid(devPath)
+	if err == nil {
+		err = m.deactivateConnection(uuid)
+	}
+	if err != nil {
+		logger.Warning("failed to stop sharing connection:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+// GetConnectionShareInfo return the marshaled sharing status of the
+// device, including the upstream connection and the connected clients.
+func (m *Manager) GetConnectionShareInfo(devPath dbus.ObjectPath) (infoJSON string, busErr *dbus.Error) {
+	info, err := m.getConnectionShareInfo(devPath)
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	infoJSON, err = marshalJSON(info)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return infoJSON, nil
+}
+
+func (m *Manager) getConnectionShareInfo(devPath dbus.ObjectPath) (info *connectionShareInfo, err error) {
+	uuid, err := getShareConnectionUuid(devPath)
+	if err != nil {
+		return
+	}
+	info = &connectionShareInfo{
+		DeviceInterface: nmGetDeviceInterface(devPath),
+		Clients:         make([]hotspotStation, 0),
+	}
+	apath := nmGetDeviceActiveConnection(devPath)
+	info.Enabled = isNmObjectPathValid(apath) && nmGetActiveConnectionUuid(apath) == uuid &&
+		nmGetActiveConnectionState(apath) == nm.NM_ACTIVE_CONNECTION_STATE_ACTIVATED
+	if !info.Enabled {
+		return info, nil
+	}
+	if primary := nmGetPrimaryConnection(); isNmObjectPathValid(primary) {
+		info.Upstream = nmGetActiveConnectionId(primary)
+	}
+	info.Clients = getHotspotStations(info.DeviceInterface)
+	return info, nil
+}
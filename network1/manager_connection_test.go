@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestConnectionBandPreference(c *C.C) {
+	data := newWirelessConnectionData("test", "uuid", []byte("test"), "wpa-psk", "")
+	c.Check(getConnectionBandPreference(data), C.Equals, bandPreferenceAuto)
+
+	// 自动切换写入的频段不算锁定
+	setSettingWirelessBand(data, "a")
+	c.Check(getConnectionBandPreference(data), C.Equals, bandPreferenceAuto)
+
+	c.Assert(setConnectionBandPreference(data, bandPreference2G), C.IsNil)
+	c.Check(getSettingWirelessBand(data), C.Equals, "bg")
+	c.Check(getConnectionBandPreference(data), C.Equals, bandPreference2G)
+
+	c.Assert(setConnectionBandPreference(data, bandPreference5G), C.IsNil)
+	c.Check(getSettingWirelessBand(data), C.Equals, "a")
+	c.Check(getConnectionBandPreference(data), C.Equals, bandPreference5G)
+
+	c.Assert(setConnectionBandPreference(data, bandPreferenceAuto), C.IsNil)
+	c.Check(isSettingWirelessBandExists(data), C.Equals, false)
+	c.Check(isSettingExists(data, nm.NM_SETTING_USER_SETTING_NAME), C.Equals, false)
+	c.Check(getConnectionBandPreference(data), C.Equals, bandPreferenceAuto)
+
+	// NetworkManager 无法锁定 6G 频段
+	c.Check(setConnectionBandPreference(data, bandPreference6G), C.NotNil)
+	c.Check(setConnectionBandPreference(data, "60"), C.NotNil)
+}
+
+func (*testWrapper) TestSortConnectionsByPriority(c *C.C) {
+	conns := connectionSlice{
+		{Id: "b", AutoconnectPriority: 0},
+		{Id: "work", AutoconnectPriority: 10},
+		{Id: "a", AutoconnectPriority: 0},
+		{Id: "guest", AutoconnectPriority: -10},
+		{Id: "home", AutoconnectPriority: 20},
+	}
+	sortConnectionsByPriority(conns)
+	var ids []string
+	for _, conn := range conns {
+		ids = append(ids, conn.Id)
+	}
+	c.Check(ids, C.DeepEquals, []string{"home", "work", "a", "b", "guest"})
+}
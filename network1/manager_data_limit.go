@@ -0,0 +1,442 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// 流量达到上限时执行的动作
+const (
+	dataLimitActionNotify     = "notify"
+	dataLimitActionMetered    = "metered"
+	dataLimitActionDisconnect = "disconnect"
+)
+
+const (
+	// 统计流量的间隔
+	dataUsageInterval = time.Minute
+	// 每月重置流量的日期超过当月的天数时在月末重置
+	maxDataLimitResetDay = 31
+)
+
+// connectionDataLimit 连接每个计费周期的流量上限和已使用的流量
+type connectionDataLimit struct {
+	Limit       uint64 // 单位字节
+	Action      string
+	ResetDay    uint32 // 每月重置流量的日期，1 - 31
+	PeriodStart int64  // 当前计费周期开始的 unix 时间戳
+	Usage       uint64 // 当前计费周期已使用的流量，单位字节
+	Reached     bool   // 当前计费周期是否已经执行过动作
+}
+
+func checkDataLimitAction(action string) error {
+	switch action {
+	case dataLimitActionNotify, dataLimitActionMetered, dataLimitActionDisconnect:
+		return nil
+	}
+	return fmt.Errorf("invalid data limit action %q", action)
+}
+
+func daysInMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// getDataLimitPeriodStart 获取 now 所在计费周期的开始时间
+func getDataLimitPeriodStart(now time.Time, resetDay uint32) time.Time {
+	year, month, _ := now.Date()
+	day := int(resetDay)
+	if days := daysInMonth(year, month); day > days {
+		day = days
+	}
+	start := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	if !now.Before(start) {
+		return start
+	}
+	// 还没有到本月的重置日期，计费周期从上个月开始
+	prev := time.Date(year, month-1, 1, 0, 0, 0, 0, now.Location())
+	day = int(resetDay)
+	if days := daysInMonth(prev.Year(), prev.Month()); day > days {
+		day = days
+	}
+	return time.Date(prev.Year(), prev.Month(), day, 0, 0, 0, 0, now.Location())
+}
+
+// updatePeriod 进入新的计费周期时清空已使用的流量
+func (l *connectionDataLimit) updatePeriod(now time.Time) {
+	start := getDataLimitPeriodStart(now, l.ResetDay).Unix()
+	if start != l.PeriodStart {
+		l.PeriodStart = start
+		l.Usage = 0
+		l.Reached = false
+	}
+}
+
+// addUsage 增加已使用的流量，首次达到上限时返回 true
+func (l *connectionDataLimit) addUsage(bytes uint64) bool {
+	l.Usage += bytes
+	if l.Limit == 0 || l.Usage < l.Limit || l.Reached {
+		return false
+	}
+	l.Reached = true
+	return true
+}
+
+// getDataCounterDelta 获取两次统计之间的流量，网卡重新创建后计数从 0 开始
+func getDataCounterDelta(prev, cur uint64) uint64 {
+	if cur >= prev {
+		return cur - prev
+	}
+	return cur
+}
+
+// blocksAutoconnect 流量达到上限后断开的连接在当前计费周期内不再自动连接
+func (l *connectionDataLimit) blocksAutoconnect() bool {
+	return l.Reached && l.Action == dataLimitActionDisconnect
+}
+
+// 需要持有 dataLimitLock
+func (m *Manager) loadDataLimits() map[string]*connectionDataLimit {
+	limits := make(map[string]*connectionDataLimit)
+	if m.networkConfig == nil {
+		return limits
+	}
+	v, err := m.networkConfig.Value(0, dsettingsConnectionDataLimits)
+	if err != nil {
+		logger.Warning(err)
+		return limits
+	}
+	str, _ := v.Value().(string)
+	m.dataLimitsSaved = str
+	if str == "" {
+		return limits
+	}
+	err = json.Unmarshal([]byte(str), &limits)
+	if err != nil {
+		logger.Warning("invalid connection data limits:", err)
+		return make(map[string]*connectionDataLimit)
+	}
+	return limits
+}
+
+// saveDataLimits 内容没有变化时不写入 dconfig，需要持有 dataLimitLock
+func (m *Manager) saveDataLimits() error {
+	if m.networkConfig == nil {
+		return errors.New("network dconfig is not available")
+	}
+	data, err := json.Marshal(m.dataLimits)
+	if err != nil {
+		return err
+	}
+	if string(data) == m.dataLimitsSaved {
+		return nil
+	}
+	err = m.networkConfig.SetValue(0, dsettingsConnectionDataLimits, dbus.MakeVariant(string(data)))
+	if err != nil {
+		return err
+	}
+	m.dataLimitsSaved = string(data)
+	return nil
+}
+
+func (m *Manager) initDataLimits() {
+	m.dataLimitLock.Lock()
+	m.dataLimits = m.loadDataLimits()
+	m.dataLimitBlocked = make(map[string]bool)
+	m.dataCounters = make(map[dataCounterKey]uint64)
+	now := time.Now()
+	for _, limit := range m.dataLimits {
+		limit.updatePeriod(now)
+	}
+	m.dataLimitLock.Unlock()
+	m.updateDataLimitAutoconnect()
+	m.scheduleDataUsageUpdate()
+}
+
+func (m *Manager) destroyDataLimits() {
+	m.dataLimitLock.Lock()
+	if m.dataLimitTimer != nil {
+		m.dataLimitTimer.Stop()
+		m.dataLimitTimer = nil
+	}
+	var blockedUuids []string
+	for uuid := range m.dataLimitBlocked {
+		blockedUuids = append(blockedUuids, uuid)
+	}
+	m.dataLimitBlocked = make(map[string]bool)
+	m.dataLimitLock.Unlock()
+
+	// 关闭自动连接只修改了内存中的配置，退出时恢复
+	for _, uuid := range blockedUuids {
+		setConnectionAutoconnectForBackoff(uuid, true)
+	}
+}
+
+// isAutoconnectBlockedByDataLimit 连接是否因流量达到上限禁止自动连接
+func (m *Manager) isAutoconnectBlockedByDataLimit(uuid string) bool {
+	m.dataLimitLock.Lock()
+	defer m.dataLimitLock.Unlock()
+	limit := m.dataLimits[uuid]
+	return limit != nil && limit.blocksAutoconnect()
+}
+
+// updateDataLimitAutoconnect 流量达到上限的连接关闭自动连接，进入新的计费周期或者
+// 修改了流量上限后恢复，退避期间由退避结束时恢复
+func (m *Manager) updateDataLimitAutoconnect() {
+	var blockUuids, unblockUuids []string
+	m.dataLimitLock.Lock()
+	for uuid, limit := range m.dataLimits {
+		if limit.blocksAutoconnect() && !m.dataLimitBlocked[uuid] {
+			blockUuids = append(blockUuids, uuid)
+		}
+	}
+	for uuid := range m.dataLimitBlocked {
+		if limit := m.dataLimits[uuid]; limit == nil || !limit.blocksAutoconnect() {
+			unblockUuids = append(unblockUuids, uuid)
+			delete(m.dataLimitBlocked, uuid)
+		}
+	}
+	m.dataLimitLock.Unlock()
+
+	for _, uuid := range blockUuids {
+		// 连接在退避期间已经关闭了自动连接时，也需要在流量上限解除后恢复
+		if setConnectionAutoconnectForBackoff(uuid, false) || m.isAutoconnectBlockedByBackoff(uuid) {
+			logger.Info("block autoconnect of connection which reached the data limit:", uuid)
+			m.dataLimitLock.Lock()
+			m.dataLimitBlocked[uuid] = true
+			m.dataLimitLock.Unlock()
+		}
+	}
+	for _, uuid := range unblockUuids {
+		if m.isAutoconnectBlockedByBackoff(uuid) {
+			continue
+		}
+		logger.Info("allow autoconnect of connection after the data limit is lifted:", uuid)
+		setConnectionAutoconnectForBackoff(uuid, true)
+	}
+}
+
+// scheduleDataUsageUpdate 有连接设置了流量上限时才统计流量
+func (m *Manager) scheduleDataUsageUpdate() {
+	m.dataLimitLock.Lock()
+	defer m.dataLimitLock.Unlock()
+	if m.dataLimitTimer != nil {
+		m.dataLimitTimer.Stop()
+		m.dataLimitTimer = nil
+	}
+	if len(m.dataLimits) == 0 {
+		return
+	}
+	m.dataLimitTimer = time.AfterFunc(dataUsageInterval, func() {
+		m.updateDataUsage()
+		m.scheduleDataUsageUpdate()
+	})
+}
+
+type dataCounterKey struct {
+	uuid string
+	ifc  string
+}
+
+// readDataCounters 读取设置了流量上限的活动连接的网卡收发字节数
+func (m *Manager) readDataCounters() map[dataCounterKey]uint64 {
+	m.dataLimitLock.Lock()
+	uuids := make(map[string]bool, len(m.dataLimits))
+	for uuid := range m.dataLimits {
+		uuids[uuid] = true
+	}
+	m.dataLimitLock.Unlock()
+
+	devPathsMap := make(map[string][]dbus.ObjectPath)
+	m.activeConnectionsLock.Lock()
+	for _, aconn := range m.activeConnections {
+		// VPN 的流量已经统计在底层的连接中
+		if aconn.Vpn || !uuids[aconn.Uuid] {
+			continue
+		}
+		devPathsMap[aconn.Uuid] = append([]dbus.ObjectPath(nil), aconn.Devices...)
+	}
+	m.activeConnectionsLock.Unlock()
+
+	counters := make(map[dataCounterKey]uint64)
+	for uuid, devPaths := range devPathsMap {
+		for _, devPath := range devPaths {
+			nmDev, err := nmNewDevice(devPath)
+			if err != nil {
+				continue
+			}
+			ifc, _ := nmDev.Device().IpInterface().Get(0)
+			if ifc == "" {
+				continue
+			}
+			counters[dataCounterKey{uuid: uuid, ifc: ifc}] = readNetStatistics(ifc, "rx_bytes") + readNetStatistics(ifc, "tx_bytes")
+		}
+	}
+	return counters
+}
+
+func (m *Manager) updateDataUsage() {
+	counters := m.readDataCounters()
+	now := time.Now()
+
+	type reachedLimit struct {
+		uuid  string
+		limit connectionDataLimit
+	}
+	var reached []reachedLimit
+	m.dataLimitLock.Lock()
+	usages := make(map[string]uint64)
+	for key, cur := range counters {
+		// 首次统计到的连接从下一次开始计算
+		if prev, ok := m.dataCounters[key]; ok {
+			usages[key.uuid] += getDataCounterDelta(prev, cur)
+		}
+	}
+	m.dataCounters = counters
+	changed := false
+	for uuid, limit := range m.dataLimits {
+		start := limit.PeriodStart
+		limit.updatePeriod(now)
+		if start != limit.PeriodStart {
+			changed = true
+		}
+		usage := usages[uuid]
+		if usage == 0 {
+			continue
+		}
+		changed = true
+		if limit.addUsage(usage) {
+			reached = append(reached, reachedLimit{uuid: uuid, limit: *limit})
+		}
+	}
+	if changed {
+		err := m.saveDataLimits()
+		if err != nil {
+			logger.Warning("failed to save connection data limits:", err)
+		}
+	}
+	m.dataLimitLock.Unlock()
+
+	// 在断开连接之前关闭自动连接，避免立即重新连接
+	m.updateDataLimitAutoconnect()
+	for _, r := range reached {
+		m.doDataLimitAction(r.uuid, r.limit)
+	}
+}
+
+func (m *Manager) doDataLimitAction(uuid string, limit connectionDataLimit) {
+	id := uuid
+	if _, data, err := m.getConnectionData(uuid); err == nil {
+		id = getSettingConnectionId(data)
+	}
+	logger.Infof("data usage of %s reached the limit %d, action: %s", id, limit.Limit, limit.Action)
+	if err := m.service.Emit(m, "DataLimitReached", uuid, limit.Usage, limit.Limit, limit.Action); err != nil {
+		logger.Warning(err)
+	}
+
+	var err error
+	switch limit.Action {
+	case dataLimitActionMetered:
+		err = m.setConnectionMetered(uuid, true)
+	case dataLimitActionDisconnect:
+		err = m.deactivateConnection(uuid)
+	}
+	if err != nil {
+		logger.Warningf("failed to %s connection %s: %v", limit.Action, id, err)
+	}
+	notifyDataLimitReached(id, limit.Action)
+}
+
+// SetConnectionDataLimit set the data limit in bytes of the connection for
+// each billing cycle which starts at resetDay of every month, the last day
+// is used if the month is shorter. action is "notify", "metered" or
+// "disconnect", which is taken once the usage of the cycle reaches the
+// limit. A limit of 0 removes the data limit of the connection.
+func (m *Manager) SetConnectionDataLimit(uuid string, limit uint64, action string, resetDay uint32) *dbus.Error {
+	err := m.setConnectionDataLimit(uuid, limit, action, resetDay)
+	if err != nil {
+		logger.Warning("failed to set connection data limit:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setConnectionDataLimit(uuid string, limit uint64, action string, resetDay uint32) error {
+	if limit != 0 {
+		err := checkDataLimitAction(action)
+		if err != nil {
+			return err
+		}
+		if resetDay < 1 || resetDay > maxDataLimitResetDay {
+			return fmt.Errorf("invalid reset day %d", resetDay)
+		}
+		_, err = nmGetConnectionByUuid(uuid)
+		if err != nil {
+			return err
+		}
+	}
+
+	m.dataLimitLock.Lock()
+	old := m.dataLimits[uuid]
+	if limit == 0 {
+		delete(m.dataLimits, uuid)
+	} else {
+		l := &connectionDataLimit{
+			Limit:    limit,
+			Action:   action,
+			ResetDay: resetDay,
+		}
+		// 重置日期不变时保留当前计费周期已使用的流量
+		if old != nil && old.ResetDay == resetDay {
+			l.PeriodStart = old.PeriodStart
+			l.Usage = old.Usage
+			l.Reached = old.Reached && old.Usage >= limit
+		}
+		l.updatePeriod(time.Now())
+		m.dataLimits[uuid] = l
+	}
+	err := m.saveDataLimits()
+	if err != nil {
+		// 保存失败时恢复原来的设置
+		if old != nil {
+			m.dataLimits[uuid] = old
+		} else {
+			delete(m.dataLimits, uuid)
+		}
+	}
+	m.dataLimitLock.Unlock()
+	if err != nil {
+		return err
+	}
+	logger.Infof("set data limit of %s to %d, action: %s, reset day: %d", uuid, limit, action, resetDay)
+	m.updateDataLimitAutoconnect()
+	m.scheduleDataUsageUpdate()
+	return nil
+}
+
+// GetConnectionDataUsage get the data limit and the usage in the current
+// billing cycle of the connection marshaled by json, the data usage is
+// only counted for the connections which have a data limit.
+func (m *Manager) GetConnectionDataUsage(uuid string) (usageJSON string, busErr *dbus.Error) {
+	m.dataLimitLock.Lock()
+	var limit connectionDataLimit
+	if l := m.dataLimits[uuid]; l != nil {
+		l.updatePeriod(time.Now())
+		limit = *l
+	}
+	m.dataLimitLock.Unlock()
+	usageJSON, err := marshalJSON(limit)
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	return usageJSON, nil
+}
@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"time"
+
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestCheckDataLimitAction(c *C.C) {
+	c.Check(checkDataLimitAction(dataLimitActionNotify), C.IsNil)
+	c.Check(checkDataLimitAction(dataLimitActionMetered), C.IsNil)
+	c.Check(checkDataLimitAction(dataLimitActionDisconnect), C.IsNil)
+	c.Check(checkDataLimitAction(""), C.NotNil)
+}
+
+func (*testWrapper) TestGetDataLimitPeriodStart(c *C.C) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+	}
+	c.Check(getDataLimitPeriodStart(date(2026, 10, 15).Add(time.Hour), 1), C.DeepEquals, date(2026, 10, 1))
+	c.Check(getDataLimitPeriodStart(date(2026, 10, 15), 15), C.DeepEquals, date(2026, 10, 15))
+	c.Check(getDataLimitPeriodStart(date(2026, 10, 14), 15), C.DeepEquals, date(2026, 9, 15))
+	// 重置日期超过当月天数时在月末重置
+	c.Check(getDataLimitPeriodStart(date(2026, 2, 28), 31), C.DeepEquals, date(2026, 2, 28))
+	c.Check(getDataLimitPeriodStart(date(2026, 3, 30), 31), C.DeepEquals, date(2026, 2, 28))
+	c.Check(getDataLimitPeriodStart(date(2026, 1, 5), 10), C.DeepEquals, date(2025, 12, 10))
+}
+
+func (*testWrapper) TestConnectionDataLimit(c *C.C) {
+	l := &connectionDataLimit{
+		Limit:    100,
+		Action:   dataLimitActionNotify,
+		ResetDay: 1,
+	}
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local)
+	l.updatePeriod(now)
+	c.Check(l.PeriodStart, C.Equals, time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local).Unix())
+
+	c.Check(l.addUsage(60), C.Equals, false)
+	c.Check(l.addUsage(40), C.Equals, true)
+	// 同一个计费周期只执行一次
+	c.Check(l.addUsage(10), C.Equals, false)
+	c.Check(l.Usage, C.Equals, uint64(110))
+
+	l.updatePeriod(now.AddDate(0, 0, 1))
+	c.Check(l.Usage, C.Equals, uint64(110))
+	l.updatePeriod(now.AddDate(0, 1, 0))
+	c.Check(l.Usage, C.Equals, uint64(0))
+	c.Check(l.Reached, C.Equals, false)
+}
+
+func (*testWrapper) TestGetDataCounterDelta(c *C.C) {
+	c.Check(getDataCounterDelta(100, 150), C.Equals, uint64(50))
+	c.Check(getDataCounterDelta(100, 30), C.Equals, uint64(30))
+}
+
+func (*testWrapper) TestDataLimitBlocksAutoconnect(c *C.C) {
+	l := &connectionDataLimit{
+		Limit:    100,
+		Action:   dataLimitActionDisconnect,
+		ResetDay: 1,
+	}
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.Local)
+	l.updatePeriod(now)
+	c.Check(l.blocksAutoconnect(), C.Equals, false)
+	l.addUsage(100)
+	c.Check(l.blocksAutoconnect(), C.Equals, true)
+	// 新的计费周期恢复自动连接
+	l.updatePeriod(now.AddDate(0, 1, 0))
+	c.Check(l.blocksAutoconnect(), C.Equals, false)
+
+	l.Action = dataLimitActionNotify
+	l.addUsage(100)
+	c.Check(l.blocksAutoconnect(), C.Equals, false)
+}
@@ -56,6 +56,110 @@ func (v *Manager) emitPropChangedVpnEnabled(value bool) error {
 	return v.service.EmitPropertyChanged(v, "VpnEnabled", value)
 }
 
+func (v *Manager) setPropAutoWifiOffOnWired(value bool) (changed bool) {
+	if v.AutoWifiOffOnWired != value {
+		v.AutoWifiOffOnWired = value
+		v.emitPropChangedAutoWifiOffOnWired(value)
+		return true
+	}
+	return false
+}
+
+func (v *Manager) emitPropChangedAutoWifiOffOnWired(value bool) error {
+	return v.service.EmitPropertyChanged(v, "AutoWifiOffOnWired", value)
+}
+
+func (v *Manager) setPropWifiOffOnWiredMode(value string) (changed bool) {
+	if v.WifiOffOnWiredMode != value {
+		v.WifiOffOnWiredMode = value
+		v.emitPropChangedWifiOffOnWiredMode(value)
+		return true
+	}
+	return false
+}
+
+func (v *Manager) emitPropChangedWifiOffOnWiredMode(value string) error {
+	return v.service.EmitPropertyChanged(v, "WifiOffOnWiredMode", value)
+}
+
+func (v *Manager) setPropIgnoreAutoDns(value bool) (changed bool) {
+	if v.IgnoreAutoDns != value {
+		v.IgnoreAutoDns = value
+		v.emitPropChangedIgnoreAutoDns(value)
+		return true
+	}
+	return false
+}
+
+func (v *Manager) emitPropChangedIgnoreAutoDns(value bool) error {
+	return v.service.EmitPropertyChanged(v, "IgnoreAutoDns", value)
+}
+
+func (v *Manager) setPropWifiSuppressedByWired(value bool) (changed bool) {
+	if v.WifiSuppressedByWired != value {
+		v.WifiSuppressedByWired = value
+		v.emitPropChangedWifiSuppressedByWired(value)
+		return true
+	}
+	return false
+}
+
+func (v *Manager) emitPropChangedWifiSuppressedByWired(value bool) error {
+	return v.service.EmitPropertyChanged(v, "WifiSuppressedByWired", value)
+}
+
+func (v *Manager) setPropAccessPointStrengthThreshold(value uint32) (changed bool) {
+	if v.AccessPointStrengthThreshold != value {
+		v.AccessPointStrengthThreshold = value
+		v.emitPropChangedAccessPointStrengthThreshold(value)
+		return true
+	}
+	return false
+}
+
+func (v *Manager) emitPropChangedAccessPointStrengthThreshold(value uint32) error {
+	return v.service.EmitPropertyChanged(v, "AccessPointStrengthThreshold", value)
+}
+
+func (v *Manager) setPropRoamingPolicy(value string) (changed bool) {
+	if v.RoamingPolicy != value {
+		v.RoamingPolicy = value
+		v.emitPropChangedRoamingPolicy(value)
+		return true
+	}
+	return false
+}
+
+func (v *Manager) emitPropChangedRoamingPolicy(value string) error {
+	return v.service.EmitPropertyChanged(v, "RoamingPolicy", value)
+}
+
+func (v *Manager) setPropRoamingStrengthThreshold(value uint32) (changed bool) {
+	if v.RoamingStrengthThreshold != value {
+		v.RoamingStrengthThreshold = value
+		v.emitPropChangedRoamingStrengthThreshold(value)
+		return true
+	}
+	return false
+}
+
+func (v *Manager) emitPropChangedRoamingStrengthThreshold(value uint32) error {
+	return v.service.EmitPropertyChanged(v, "RoamingStrengthThreshold", value)
+}
+
+func (v *Manager) setPropShowWeakAccessPoints(value bool) (changed bool) {
+	if v.ShowWeakAccessPoints != value {
+		v.ShowWeakAccessPoints = value
+		v.emitPropChangedShowWeakAccessPoints(value)
+		return true
+	}
+	return false
+}
+
+func (v *Manager) emitPropChangedShowWeakAccessPoints(value bool) error {
+	return v.service.EmitPropertyChanged(v, "ShowWeakAccessPoints", value)
+}
+
 func (v *Manager) setPropDevices(value string) (changed bool) {
 	if v.Devices != value {
 		v.Devices = value
@@ -69,6 +173,19 @@ func (v *Manager) emitPropChangedDevices(value string) error {
 	return v.service.EmitPropertyChanged(v, "Devices", value)
 }
 
+func (v *Manager) setPropTetheringDevices(value string) (changed bool) {
+	if v.TetheringDevices != value {
+		v.TetheringDevices = value
+		v.emitPropChangedTetheringDevices(value)
+		return true
+	}
+	return false
+}
+
+func (v *Manager) emitPropChangedTetheringDevices(value string) error {
+	return v.service.EmitPropertyChanged(v, "TetheringDevices", value)
+}
+
 func (v *Manager) setPropConnections(value string) (changed bool) {
 	if v.Connections != value {
 		v.Connections = value
@@ -107,3 +224,16 @@ func (v *Manager) setPropWirelessAccessPoints(value string) (changed bool) {
 func (v *Manager) emitPropChangedWirelessAccessPoints(value string) error {
 	return v.service.EmitPropertyChanged(v, "WirelessAccessPoints", value)
 }
+
+func (v *Manager) setPropConnectionQuality(value string) (changed bool) {
+	if v.ConnectionQuality != value {
+		v.ConnectionQuality = value
+		v.emitPropChangedConnectionQuality(value)
+		return true
+	}
+	return false
+}
+
+func (v *Manager) emitPropChangedConnectionQuality(value string) error {
+	return v.service.EmitPropertyChanged(v, "ConnectionQuality", value)
+}
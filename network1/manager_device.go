@@ -49,12 +49,20 @@ type device struct {
 	ActiveAp       dbus.ObjectPath
 	SupportHotspot bool
 	Mode           uint32
+	hotspotEnabled bool
 
 	// used for mobile device
 	MobileNetworkType   string
 	MobileSignalQuality uint32
+	MobileOperator      string
+	MobileRoaming       bool
 
 	InterfaceFlags uint32
+
+	// 当前正在激活的连接，激活失败时用于发送 ConnectionFailed 信号
+	attemptUuid string
+	attemptId   string
+	attemptAuto bool // 是否由 NetworkManager 自动连接
 }
 
 const (
@@ -68,10 +76,12 @@ func (m *Manager) initDeviceManage() {
 
 	m.accessPointsLock.Lock()
 	m.accessPoints = make(map[dbus.ObjectPath][]*accessPoint)
+	m.accessPointsByPath = make(map[dbus.ObjectPath]*accessPoint)
 	m.accessPointsLock.Unlock()
 
 	_, err := nmManager.ConnectDeviceAdded(func(path dbus.ObjectPath) {
 		m.addDevice(path)
+		m.updateWirelessRoles()
 	})
 	if err != nil {
 		logger.Warning(err)
@@ -79,6 +89,7 @@ func (m *Manager) initDeviceManage() {
 	_, err = nmManager.ConnectDeviceRemoved(func(path dbus.ObjectPath) {
 		notifyDeviceRemoved(path)
 		m.removeDevice(path)
+		m.updateWirelessRoles()
 	})
 	if err != nil {
 		logger.Warning(err)
@@ -86,6 +97,7 @@ func (m *Manager) initDeviceManage() {
 	for _, path := range nmGetDevices() {
 		m.addDevice(path)
 	}
+	m.updateWirelessRoles()
 }
 
 // 调整nmDevice的状态
@@ -204,9 +216,12 @@ func (m *Manager) newDevice(devPath dbus.ObjectPath) (dev *device, err error) {
 				return
 			}
 			m.devicesLock.Lock()
-			defer m.devicesLock.Unlock()
+			oldAp := dev.ActiveAp
 			dev.ActiveAp = value
+			devState, ifc := dev.State, dev.Interface
 			m.updatePropDevices()
+			m.devicesLock.Unlock()
+			m.recordRoamEvent(ifc, devState, oldAp, value)
 
 			// when wifi is roaming, wpa and network-manager will deal this situation,
 			// dde dont need try to re active connection or may cause error connection in OPT env
@@ -265,56 +280,52 @@ func (m *Manager) newDevice(devPath dbus.ObjectPath) (dev *device, err error) {
 				}
 
 				m.accessPointsLock.Lock()
-				shouldRemove := make([]dbus.ObjectPath, 0, len(m.accessPoints[devPath]))
-				for _, a := range m.accessPoints[devPath] {
-					var found bool
-					for _, v := range value {
-						if v == a.Path {
-							found = true
-							break
-						}
-					}
-
-					if !found {
-						shouldRemove = append(shouldRemove, a.Path)
-					}
-				}
+				m.syncAccessPoints(devPath, value, false)
 
-				shouldAdd := make([]dbus.ObjectPath, 0, len(value))
-				for _, v := range value {
-					var found bool
-					for _, a := range m.accessPoints[devPath] {
-						if v == a.Path {
-							found = true
-							break
-						}
-					}
-
-					if !found {
-						shouldAdd = append(shouldAdd, v)
-					}
-				}
+				m.PropsMu.Lock()
+				m.updatePropWirelessAccessPoints()
+				m.PropsMu.Unlock()
+				m.accessPointsLock.Unlock()
+			})
+			if err != nil {
+				logger.Warning("connect to AccessPoints changed failed:", err)
+			}
 
-				for _, a := range shouldRemove {
-					m.removeAccessPoint(devPath, a)
+			// 每次扫描完成后更新热点的新鲜度
+			err = nmDevWireless.LastScan().ConnectChanged(func(hasValue bool, value int64) {
+				if !hasValue {
+					return
 				}
 
-				for _, a := range shouldAdd {
-					m.addAccessPoint(devPath, a)
+				apPaths, err := nmDevWireless.AccessPoints().Get(0)
+				if err != nil {
+					logger.Warning(err)
+					return
 				}
 
+				m.accessPointsLock.Lock()
+				m.syncAccessPoints(devPath, apPaths, true)
+
 				m.PropsMu.Lock()
 				m.updatePropWirelessAccessPoints()
 				m.PropsMu.Unlock()
 				m.accessPointsLock.Unlock()
+
+				err = m.service.Emit(m, "WirelessScanFinished", string(devPath), value)
+				if err != nil {
+					logger.Warning(err)
+				}
+				m.scheduleCheckAPStrength()
+				go m.updateAccessPointsPasspoint(dev)
 			})
 			if err != nil {
-				logger.Warning("connect to AccessPoints changed failed:", err)
+				logger.Warning("connect to LastScan changed failed:", err)
 			}
 		}
 
 		accessPoints := nmGetAccessPoints(devPath)
 		m.initAccessPoints(dev.Path, accessPoints)
+		go m.updateAccessPointsPasspoint(dev)
 
 		m.WirelessAccessPoints, _ = marshalJSON(m.accessPoints)
 
@@ -375,6 +386,7 @@ func (m *Manager) newDevice(devPath dbus.ObjectPath) (dev *device, err error) {
 				logger.Warning(err)
 			}
 			dev.MobileSignalQuality = mmDoGetModemDeviceSignalQuality(mmDevModem)
+			m.initModemOperatorProps(dev)
 		}
 	}
 
@@ -396,7 +408,13 @@ func (m *Manager) newDevice(devPath dbus.ObjectPath) (dev *device, err error) {
 		m.devicesLock.Lock()
 		m.updatePropDevices()
 		m.devicesLock.Unlock()
-
+		m.updateHotspotState(dev, newState)
+		m.updateConnectionAttempt(dev, newState, oldState, reason)
+		m.updateApActivation(dev, newState, oldState, reason)
+		m.recordDeviceStateEvent(dev, newState, oldState, reason)
+		if newState == nm.NM_DEVICE_STATE_ACTIVATED && dev.nmDevType == nm.NM_DEVICE_TYPE_WIFI {
+			go m.checkInsecureNetwork(dev)
+		}
 	})
 	if err != nil {
 		logger.Warning(err)
@@ -522,6 +540,7 @@ func (m *Manager) removeDevice(devPath dbus.ObjectPath) {
 	defer m.devicesLock.Unlock()
 	m.devices[devType] = m.doRemoveDevice(m.devices[devType], i)
 	m.updatePropDevices()
+	m.clearActiveApSsid(devPath)
 }
 func (m *Manager) doRemoveDevice(devs []*device, i int) []*device {
 	logger.Infof("remove device %#v", devs[i])
@@ -718,6 +737,21 @@ func (m *Manager) RequestWirelessScan() *dbus.Error {
 	return nil
 }
 
+// RequestWirelessScanOnDevice request scanning on the wireless device only,
+// WirelessScanFinished will be emitted when the scan results are updated.
+func (m *Manager) RequestWirelessScanOnDevice(devPath dbus.ObjectPath) *dbus.Error {
+	dev := m.getDevice(devPath)
+	if dev == nil || dev.nmDevType != nm.NM_DEVICE_TYPE_WIFI {
+		return dbusutil.ToError(fmt.Errorf("invalid wireless device %s", devPath))
+	}
+	err := dev.nmDev.Wireless().RequestScan(0, nil)
+	if err != nil {
+		logger.Debug(err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
 func (m *Manager) wirelessReActiveConnection(nmDev nmdbus.Device) error {
 	wireless := nmDev.Wireless()
 	apPath, err := wireless.ActiveAccessPoint().Get(0)
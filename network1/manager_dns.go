@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+func isValidDnsOverTls(dnsOverTls int32) bool {
+	return dnsOverTls >= nm.NM_SETTING_CONNECTION_DNS_OVER_TLS_DEFAULT &&
+		dnsOverTls <= nm.NM_SETTING_CONNECTION_DNS_OVER_TLS_YES
+}
+
+// parseDnsServers 按 ipv4 和 ipv6 分开 DNS 服务器
+func parseDnsServers(servers []string) (ip4Dns []uint32, ip6Dns [][]byte, err error) {
+	for _, server := range servers {
+		ip := net.ParseIP(server)
+		if ip == nil {
+			return nil, nil, fmt.Errorf("invalid dns server %q", server)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			ip4Dns = append(ip4Dns, htonl(ipToUint32(ip4.String())))
+		} else {
+			ip6Dns = append(ip6Dns, []byte(ip.To16()))
+		}
+	}
+	return
+}
+
+func isIP4ConfigDisabled(data connectionData) bool {
+	return getSettingIP4ConfigMethod(data) == nm.NM_SETTING_IP4_CONFIG_METHOD_DISABLED
+}
+
+func isIP6ConfigDisabled(data connectionData) bool {
+	method := getSettingIP6ConfigMethod(data)
+	// NetworkManager 1.20 之后新增了 disabled
+	return method == nm.NM_SETTING_IP6_CONFIG_METHOD_IGNORE || method == "disabled"
+}
+
+// setConnectionDnsData 设置连接的 DNS 服务器和搜索域，ignoreAutoDns 为 true 时
+// 设置了 DNS 服务器的协议忽略 DHCP 获取的 DNS
+func setConnectionDnsData(data connectionData, servers, searchDomains []string, dnsOverTls int32,
+	ignoreAutoDns bool) error {
+	if !isValidDnsOverTls(dnsOverTls) {
+		return fmt.Errorf("invalid dns-over-tls value %d", dnsOverTls)
+	}
+	ip4Dns, ip6Dns, err := parseDnsServers(servers)
+	if err != nil {
+		return err
+	}
+	ip4Disabled := !isSettingExists(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME) || isIP4ConfigDisabled(data)
+	ip6Disabled := !isSettingExists(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME) || isIP6ConfigDisabled(data)
+	if len(ip4Dns) != 0 && ip4Disabled {
+		return errors.New("ipv4 is disabled on the connection")
+	}
+	if len(ip6Dns) != 0 && ip6Disabled {
+		return errors.New("ipv6 is disabled on the connection")
+	}
+	if len(searchDomains) != 0 && ip4Disabled && ip6Disabled {
+		return errors.New("both ipv4 and ipv6 are disabled on the connection")
+	}
+
+	if !ip4Disabled {
+		setSettingIP4ConfigDns(data, ip4Dns)
+		setSettingIP4ConfigIgnoreAutoDns(data, ignoreAutoDns && len(ip4Dns) != 0)
+		setSettingIP4ConfigDnsSearch(data, searchDomains)
+	}
+	if !ip6Disabled {
+		setSettingIP6ConfigDns(data, ip6Dns)
+		setSettingIP6ConfigIgnoreAutoDns(data, ignoreAutoDns && len(ip6Dns) != 0)
+		// 搜索域只需要设置在一个协议中
+		if ip4Disabled {
+			setSettingIP6ConfigDnsSearch(data, searchDomains)
+		} else {
+			setSettingIP6ConfigDnsSearch(data, nil)
+		}
+	}
+	setSettingKey(data, nm.NM_SETTING_CONNECTION_SETTING_NAME, nm.NM_SETTING_CONNECTION_DNS_OVER_TLS, dnsOverTls)
+	return nil
+}
+
+// updateConnectionIgnoreAutoDns 设置了 DNS 服务器的协议根据 ignoreAutoDns 更新 ignore-auto-dns，
+// 返回连接是否被修改
+func updateConnectionIgnoreAutoDns(data connectionData, ignoreAutoDns bool) (changed bool) {
+	if isSettingExists(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME) && len(getSettingIP4ConfigDns(data)) != 0 &&
+		getSettingIP4ConfigIgnoreAutoDns(data) != ignoreAutoDns {
+		setSettingIP4ConfigIgnoreAutoDns(data, ignoreAutoDns)
+		changed = true
+	}
+	if isSettingExists(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME) && len(getSettingIP6ConfigDns(data)) != 0 &&
+		getSettingIP6ConfigIgnoreAutoDns(data) != ignoreAutoDns {
+		setSettingIP6ConfigIgnoreAutoDns(data, ignoreAutoDns)
+		changed = true
+	}
+	return
+}
+
+func (m *Manager) getIgnoreAutoDns() bool {
+	m.PropsMu.RLock()
+	defer m.PropsMu.RUnlock()
+	return m.IgnoreAutoDns
+}
+
+// SetConnectionDNS set the dns servers, search domains and DNS over TLS
+// mode of the connection, dnsOverTls is -1 for default, 0 for no, 1 for
+// opportunistic and 2 for yes. Empty servers makes the connection use
+// the dns servers from DHCP only.
+func (m *Manager) SetConnectionDNS(uuid string, servers, searchDomains []string, dnsOverTls int32) *dbus.Error {
+	err := m.setConnectionDns(uuid, servers, searchDomains, dnsOverTls)
+	if err != nil {
+		logger.Warning("failed to set connection dns:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setConnectionDns(uuid string, servers, searchDomains []string, dnsOverTls int32) (err error) {
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		return
+	}
+	data, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return
+	}
+
+	err = setConnectionDnsData(data, servers, searchDomains, dnsOverTls, m.getIgnoreAutoDns())
+	if err != nil {
+		return
+	}
+	return updateConnectionData(cpath, data)
+}
+
+// SetIgnoreAutoDns set whether connections which have dns servers set
+// manually ignore the dns servers from DHCP.
+func (m *Manager) SetIgnoreAutoDns(enabled bool) *dbus.Error {
+	if m.networkConfig == nil {
+		return dbusutil.ToError(errors.New("network dconfig is not available"))
+	}
+	err := m.networkConfig.SetValue(0, dsettingsIgnoreAutoDns, dbus.MakeVariant(enabled))
+	if err != nil {
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	m.setIgnoreAutoDns(enabled)
+	return nil
+}
+
+func (m *Manager) setIgnoreAutoDns(enabled bool) {
+	m.PropsMu.Lock()
+	changed := m.setPropIgnoreAutoDns(enabled)
+	m.PropsMu.Unlock()
+	if changed {
+		go m.applyIgnoreAutoDns(enabled)
+	}
+}
+
+// applyIgnoreAutoDns 将开关同步到所有设置了 DNS 服务器的连接
+func (m *Manager) applyIgnoreAutoDns(enabled bool) {
+	m.dnsLock.Lock()
+	defer m.dnsLock.Unlock()
+	for _, cpath := range nmGetConnectionList() {
+		data, err := nmGetConnectionData(cpath)
+		if err != nil {
+			continue
+		}
+		if !updateConnectionIgnoreAutoDns(data, enabled) {
+			continue
+		}
+		err = updateConnectionData(cpath, data)
+		if err != nil {
+			logger.Warningf("failed to update ignore-auto-dns of %s: %v", getSettingConnectionId(data), err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"net"
+
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func newTestDnsConnectionData(ip6Method string) connectionData {
+	data := make(connectionData)
+	addSetting(data, nm.NM_SETTING_CONNECTION_SETTING_NAME)
+	addSetting(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME)
+	setSettingIP4ConfigMethod(data, nm.NM_SETTING_IP4_CONFIG_METHOD_AUTO)
+	addSetting(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME)
+	setSettingIP6ConfigMethod(data, ip6Method)
+	return data
+}
+
+func (*testWrapper) TestSetConnectionDnsData(c *C.C) {
+	data := newTestDnsConnectionData(nm.NM_SETTING_IP6_CONFIG_METHOD_AUTO)
+	err := setConnectionDnsData(data, []string{"1.1.1.1", "2606:4700:4700::1111"}, []string{"example.com"},
+		nm.NM_SETTING_CONNECTION_DNS_OVER_TLS_YES, true)
+	c.Assert(err, C.IsNil)
+	c.Check(getSettingIP4ConfigDns(data), C.DeepEquals, []uint32{htonl(ipToUint32("1.1.1.1"))})
+	c.Check(getSettingIP6ConfigDns(data), C.DeepEquals, [][]byte{[]byte(net.ParseIP("2606:4700:4700::1111"))})
+	c.Check(getSettingIP4ConfigDnsSearch(data), C.DeepEquals, []string{"example.com"})
+	c.Check(getSettingIP4ConfigIgnoreAutoDns(data), C.Equals, true)
+	c.Check(getSettingIP6ConfigIgnoreAutoDns(data), C.Equals, true)
+	c.Check(getSettingKey(data, nm.NM_SETTING_CONNECTION_SETTING_NAME, nm.NM_SETTING_CONNECTION_DNS_OVER_TLS),
+		C.Equals, int32(nm.NM_SETTING_CONNECTION_DNS_OVER_TLS_YES))
+
+	// 只设置了 ipv4 DNS 时，ipv6 继续使用自动获取的 DNS
+	err = setConnectionDnsData(data, []string{"1.1.1.1"}, nil, nm.NM_SETTING_CONNECTION_DNS_OVER_TLS_DEFAULT, true)
+	c.Assert(err, C.IsNil)
+	c.Check(getSettingIP6ConfigDns(data), C.HasLen, 0)
+	c.Check(getSettingIP6ConfigIgnoreAutoDns(data), C.Equals, false)
+
+	c.Check(setConnectionDnsData(data, []string{"dns.example.com"}, nil, -1, false), C.NotNil)
+	c.Check(setConnectionDnsData(data, nil, nil, 3, false), C.NotNil)
+
+	data = newTestDnsConnectionData(nm.NM_SETTING_IP6_CONFIG_METHOD_IGNORE)
+	c.Check(setConnectionDnsData(data, []string{"2606:4700:4700::1111"}, nil, -1, false), C.NotNil)
+	c.Check(setConnectionDnsData(data, []string{"1.1.1.1"}, nil, -1, false), C.IsNil)
+}
+
+func (*testWrapper) TestUpdateConnectionIgnoreAutoDns(c *C.C) {
+	data := newTestDnsConnectionData(nm.NM_SETTING_IP6_CONFIG_METHOD_AUTO)
+	c.Check(updateConnectionIgnoreAutoDns(data, true), C.Equals, false)
+
+	err := setConnectionDnsData(data, []string{"1.1.1.1"}, nil, -1, false)
+	c.Assert(err, C.IsNil)
+	c.Check(updateConnectionIgnoreAutoDns(data, true), C.Equals, true)
+	c.Check(getSettingIP4ConfigIgnoreAutoDns(data), C.Equals, true)
+	c.Check(getSettingIP6ConfigIgnoreAutoDns(data), C.Equals, false)
+	c.Check(updateConnectionIgnoreAutoDns(data, true), C.Equals, false)
+}
@@ -0,0 +1,65 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	"github.com/linuxdeepin/go-lib/utils"
+)
+
+// CreateEapConnection create an 802.1X enterprise wireless connection
+// for the ssid and activate it on the device, configJSON is the
+// marshaled eapConfig. The passwords are saved by the secret agent.
+func (m *Manager) CreateEapConnection(devPath dbus.ObjectPath, ssid, configJSON string) (connection dbus.ObjectPath,
+	busErr *dbus.Error) {
+	var cfg eapConfig
+	err := json.Unmarshal([]byte(configJSON), &cfg)
+	if err != nil {
+		logger.Warning(err)
+		return "/", dbusutil.ToError(err)
+	}
+	cpath, err := m.createEapConnection(devPath, ssid, &cfg)
+	if err != nil {
+		logger.Warning("failed to create eap connection:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+func (m *Manager) createEapConnection(devPath dbus.ObjectPath, ssid string, cfg *eapConfig) (cpath dbus.ObjectPath, err error) {
+	logger.Debugf("CreateEapConnection: ssid=%s, method=%s, devPath=%s", ssid, cfg.Method, devPath)
+
+	cpath = "/"
+	if len(ssid) == 0 || len(ssid) > maxSsidLength {
+		err = fmt.Errorf("invalid ssid %q", ssid)
+		return
+	}
+	devType, i := m.getDeviceIndex(devPath)
+	if i < 0 || devType != deviceWifi {
+		err = fmt.Errorf("invalid wireless device %q", devPath)
+		return
+	}
+	err = cfg.check(time.Now())
+	if err != nil {
+		return
+	}
+
+	hwAddr, err := nmGeneralGetDeviceHwAddr(devPath, true)
+	if err != nil {
+		logger.Warning("failed to get mac", err)
+	}
+	data, err := newEapConnectionData(ssid, utils.GenUuid(), m.getAccessPointRawSsid(devPath, ssid), hwAddr, cfg)
+	if err != nil {
+		return
+	}
+	m.applyDefaultWirelessZone(data)
+	cpath, _, err = nmAddAndActivateConnection(data, devPath, true)
+	return
+}
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// 网络事件的类型
+const (
+	networkEventConnect    = "connect"
+	networkEventDisconnect = "disconnect"
+	networkEventRoam       = "roam"
+	networkEventApSwitch   = "ap-switch"
+	networkEventIPChange   = "ip-change"
+)
+
+// 最多保留的网络事件数量，超出时覆盖最早的事件
+const networkEventJournalSize = 256
+
+type networkEvent struct {
+	Time       int64 // unix 时间戳，单位毫秒
+	Type       string
+	Device     string `json:",omitempty"` // 设备的网络接口名
+	Connection string `json:",omitempty"` // 连接的名称
+	Reason     string `json:",omitempty"`
+}
+
+// networkEventJournal 保存最近网络事件的环形缓冲区
+type networkEventJournal struct {
+	mu     sync.Mutex
+	events []networkEvent
+	next   int
+	full   bool
+}
+
+func newNetworkEventJournal(size int) *networkEventJournal {
+	return &networkEventJournal{
+		events: make([]networkEvent, size),
+	}
+}
+
+func (j *networkEventJournal) add(event networkEvent) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.events[j.next] = event
+	j.next++
+	if j.next == len(j.events) {
+		j.next = 0
+		j.full = true
+	}
+}
+
+// list 按时间顺序返回最近的 limit 个事件，limit 为 0 时返回全部事件
+func (j *networkEventJournal) list(limit uint32) []networkEvent {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	count := j.next
+	if j.full {
+		count = len(j.events)
+	}
+	if limit > 0 && int(limit) < count {
+		count = int(limit)
+	}
+	result := make([]networkEvent, 0, count)
+	start := j.next - count
+	if start < 0 {
+		start += len(j.events)
+	}
+	for i := 0; i < count; i++ {
+		result = append(result, j.events[(start+i)%len(j.events)])
+	}
+	return result
+}
+
+func (m *Manager) addNetworkEvent(eventType, device, connection, reason string) {
+	logger.Debugf("network event %s, device: %s, connection: %s, reason: %s", eventType, device, connection, reason)
+	m.eventJournal.add(networkEvent{
+		Time:       time.Now().UnixNano() / int64(time.Millisecond),
+		Type:       eventType,
+		Device:     device,
+		Connection: connection,
+		Reason:     reason,
+	})
+}
+
+// recordDeviceStateEvent 记录设备连接成功和断开的事件
+func (m *Manager) recordDeviceStateEvent(dev *device, newState, oldState, reason uint32) {
+	switch {
+	case newState == nm.NM_DEVICE_STATE_ACTIVATED:
+		var id string
+		if data, err := nmGetDeviceActiveConnectionData(dev.Path); err == nil {
+			id = getSettingConnectionId(data)
+		}
+		m.addNetworkEvent(networkEventConnect, dev.Interface, id, "")
+	case oldState == nm.NM_DEVICE_STATE_ACTIVATED:
+		m.addNetworkEvent(networkEventDisconnect, dev.Interface, "", deviceErrorTable[reason])
+	}
+}
+
+// recordRoamEvent 设备保持连接时热点变化，说明 NetworkManager 或 wpa_supplicant 进行了漫游，
+// ifc 和 devState 是持有 devicesLock 时读取的设备接口名和状态
+func (m *Manager) recordRoamEvent(ifc string, devState uint32, oldAp, newAp dbus.ObjectPath) {
+	if !isNmObjectPathValid(oldAp) || !isNmObjectPathValid(newAp) || oldAp == newAp ||
+		devState != nm.NM_DEVICE_STATE_ACTIVATED {
+		return
+	}
+	nmAp, err := nmNewAccessPoint(newAp)
+	if err != nil {
+		return
+	}
+	ssid, _ := nmAp.Ssid().Get(0)
+	bssid, _ := nmAp.HwAddress().Get(0)
+	m.addNetworkEvent(networkEventRoam, ifc, decodeSsid(ssid), "roam to "+bssid)
+}
+
+// recordIPChangeEvent 记录设备的 IP 地址变化，ipConfigPath 为变化的 IP4Config 或 IP6Config
+func (m *Manager) recordIPChangeEvent(ipConfigPath dbus.ObjectPath, props map[string]dbus.Variant) {
+	addressData, ok := props["AddressData"].Value().([]map[string]dbus.Variant)
+	if !ok {
+		return
+	}
+	var addresses []string
+	for _, item := range addressData {
+		address, _ := item["address"].Value().(string)
+		prefix, _ := item["prefix"].Value().(uint32)
+		if address != "" {
+			addresses = append(addresses, fmt.Sprintf("%s/%d", address, prefix))
+		}
+	}
+
+	var ifc string
+	for _, devPath := range nmGetDevices() {
+		nmDev, err := nmNewDevice(devPath)
+		if err != nil {
+			continue
+		}
+		ip4Path, _ := nmDev.Device().Ip4Config().Get(0)
+		ip6Path, _ := nmDev.Device().Ip6Config().Get(0)
+		if ip4Path == ipConfigPath || ip6Path == ipConfigPath {
+			ifc, _ = nmDev.Device().Interface().Get(0)
+			break
+		}
+	}
+	m.addNetworkEvent(networkEventIPChange, ifc, "", strings.Join(addresses, ", "))
+}
+
+// GetNetworkEvents return the recent network events which marshaled by
+// json in chronological order, such as connect, disconnect, roam, access
+// point switch and ip change, limit 0 means all the kept events.
+func (m *Manager) GetNetworkEvents(limit uint32) (eventsJSON string, busErr *dbus.Error) {
+	eventsJSON, err := marshalJSON(m.eventJournal.list(limit))
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	return eventsJSON, nil
+}
@@ -0,0 +1,31 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestNetworkEventJournal(c *C.C) {
+	journal := newNetworkEventJournal(3)
+	c.Check(journal.list(0), C.HasLen, 0)
+
+	types := func(events []networkEvent) (result []string) {
+		for _, event := range events {
+			result = append(result, event.Type)
+		}
+		return
+	}
+	journal.add(networkEvent{Type: "a"})
+	journal.add(networkEvent{Type: "b"})
+	c.Check(types(journal.list(0)), C.DeepEquals, []string{"a", "b"})
+	c.Check(types(journal.list(1)), C.DeepEquals, []string{"b"})
+
+	journal.add(networkEvent{Type: "c"})
+	journal.add(networkEvent{Type: "d"})
+	c.Check(types(journal.list(0)), C.DeepEquals, []string{"b", "c", "d"})
+	c.Check(types(journal.list(2)), C.DeepEquals, []string{"c", "d"})
+	c.Check(types(journal.list(10)), C.DeepEquals, []string{"b", "c", "d"})
+}
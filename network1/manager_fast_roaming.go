@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+// 与漫游相关的信息元素
+const (
+	ieRsn                   = 48
+	ieMobilityDomain        = 54
+	ieRmEnabledCapabilities = 70
+	ieExtendedCapabilities  = 127
+)
+
+// Extended Capabilities 中 BSS Transition(802.11v) 所在的位
+const extCapBssTransitionBit = 19
+
+// RSN 中的 FT AKM 类型，OUI 为 00-0F-AC
+const (
+	akmFt8021x       = 3
+	akmFtPsk         = 4
+	akmFtSae         = 9
+	akmFt8021xSha384 = 13
+)
+
+var rsnOui = [3]byte{0x00, 0x0f, 0xac}
+
+// roamingCapabilities 热点支持的快速漫游相关能力
+type roamingCapabilities struct {
+	// 802.11r
+	FastTransition bool
+	MobilityDomain uint16
+	// 802.11k
+	RadioMeasurement bool
+	// 802.11v
+	BssTransition bool
+}
+
+// parseRsnAkmSuites 解析 RSN 元素中的 AKM 类型，忽略非 00-0F-AC 的厂商自定义类型
+func parseRsnAkmSuites(ies []byte) (akms []uint8) {
+	body, ok := findInformationElement(ies, ieRsn)
+	// version(2) + group cipher(4) + pairwise count(2)
+	if !ok || len(body) < 8 {
+		return nil
+	}
+	pairwiseCount := int(binary.LittleEndian.Uint16(body[6:8]))
+	offset := 8 + pairwiseCount*4
+	if len(body) < offset+2 {
+		return nil
+	}
+	akmCount := int(binary.LittleEndian.Uint16(body[offset : offset+2]))
+	offset += 2
+	for i := 0; i < akmCount && len(body) >= offset+4; i++ {
+		suite := body[offset : offset+4]
+		if suite[0] == rsnOui[0] && suite[1] == rsnOui[1] && suite[2] == rsnOui[2] {
+			akms = append(akms, suite[3])
+		}
+		offset += 4
+	}
+	return
+}
+
+func isFastTransitionAkm(akm uint8) bool {
+	switch akm {
+	case akmFt8021x, akmFtPsk, akmFtSae, akmFt8021xSha384:
+		return true
+	}
+	return false
+}
+
+func getRoamingCapabilities(ies []byte) (caps roamingCapabilities) {
+	if body, ok := findInformationElement(ies, ieMobilityDomain); ok && len(body) >= 2 {
+		caps.MobilityDomain = binary.LittleEndian.Uint16(body[0:2])
+		for _, akm := range parseRsnAkmSuites(ies) {
+			if isFastTransitionAkm(akm) {
+				caps.FastTransition = true
+				break
+			}
+		}
+	}
+	_, caps.RadioMeasurement = findInformationElement(ies, ieRmEnabledCapabilities)
+	if body, ok := findInformationElement(ies, ieExtendedCapabilities); ok && len(body) > extCapBssTransitionBit/8 {
+		caps.BssTransition = body[extCapBssTransitionBit/8]&(1<<(extCapBssTransitionBit%8)) != 0
+	}
+	return
+}
+
+// canFastTransition 两个热点属于同一个移动域且都支持 FT 时，可以不重新激活连接直接漫游
+func canFastTransition(cur, target roamingCapabilities) bool {
+	return cur.FastTransition && target.FastTransition && cur.MobilityDomain == target.MobilityDomain
+}
+
+// supplicantRoam 让 wpa_supplicant 在当前 ESS 内漫游到指定的 BSSID
+func supplicantRoam(ifc, bssid string) error {
+	systemBus, ifcPath, err := getSupplicantInterface(ifc)
+	if err != nil {
+		return err
+	}
+	return systemBus.Object(wpaSupplicantService, ifcPath).
+		Call(wpaSupplicantInterface+".Roam", 0, strings.ToLower(bssid)).Err
+}
+
+// tryFastRoam 当前热点和目标热点都支持 802.11r 时通过 wpa_supplicant 漫游，
+// 避免断开连接后重新激活，不满足条件时返回错误，由调用者重新激活连接
+func (m *Manager) tryFastRoam(dev *device, curPath, targetPath dbus.ObjectPath, band string) error {
+	m.accessPointsLock.Lock()
+	cur := m.getAccessPoint(dev.Path, curPath)
+	target := m.getAccessPoint(dev.Path, targetPath)
+	var curBssid, targetBssid string
+	if cur != nil && target != nil {
+		curBssid, targetBssid = cur.Bssid, target.Bssid
+	}
+	m.accessPointsLock.Unlock()
+	if curBssid == "" || targetBssid == "" {
+		return errors.New("access point not found")
+	}
+
+	// 已应用的连接限制了频段时 wpa_supplicant 不会漫游到其他频段的热点
+	applied, _, err := dev.nmDev.Device().GetAppliedConnection(0, 0)
+	if err != nil {
+		return err
+	}
+	if connBand := getSettingWirelessBand(applied); connBand != "" && connBand != band {
+		return errors.New("band of the connection is restricted")
+	}
+
+	iesMap, err := getSupplicantBssIEs(dev.Interface)
+	if err != nil {
+		return err
+	}
+	curCaps := getRoamingCapabilities(iesMap[strings.ToLower(curBssid)])
+	targetCaps := getRoamingCapabilities(iesMap[strings.ToLower(targetBssid)])
+	logger.Debugf("roaming capabilities, current %s: %+v, target %s: %+v", curBssid, curCaps, targetBssid, targetCaps)
+	if !canFastTransition(curCaps, targetCaps) {
+		return errors.New("fast transition is not supported")
+	}
+
+	logger.Infof("fast roam from %s to %s on %s", curBssid, targetBssid, dev.Interface)
+	return supplicantRoam(dev.Interface, targetBssid)
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	C "gopkg.in/check.v1"
+)
+
+func newTestRsnIE(akms ...byte) []byte {
+	body := []byte{
+		0x01, 0x00, // version
+		0x00, 0x0f, 0xac, 0x04, // group cipher CCMP
+		0x01, 0x00, // pairwise count
+		0x00, 0x0f, 0xac, 0x04, // pairwise CCMP
+		byte(len(akms)), 0x00, // akm count
+	}
+	for _, akm := range akms {
+		body = append(body, 0x00, 0x0f, 0xac, akm)
+	}
+	body = append(body, 0x00, 0x00) // rsn capabilities
+	return append([]byte{ieRsn, byte(len(body))}, body...)
+}
+
+func (*testWrapper) TestParseRsnAkmSuites(c *C.C) {
+	c.Check(parseRsnAkmSuites(newTestRsnIE(2, 4)), C.DeepEquals, []uint8{2, 4})
+	c.Check(parseRsnAkmSuites(nil), C.IsNil)
+	// 长度不完整
+	c.Check(parseRsnAkmSuites([]byte{ieRsn, 4, 0x01, 0x00, 0x00, 0x0f}), C.IsNil)
+}
+
+func (*testWrapper) TestGetRoamingCapabilities(c *C.C) {
+	mdie := []byte{ieMobilityDomain, 3, 0x34, 0x12, 0x01}
+	rmie := []byte{ieRmEnabledCapabilities, 5, 0x02, 0x00, 0x00, 0x00, 0x00}
+	extcap := []byte{ieExtendedCapabilities, 3, 0x00, 0x00, 0x08}
+
+	var ies []byte
+	ies = append(ies, newTestRsnIE(2, 4)...)
+	ies = append(ies, mdie...)
+	ies = append(ies, rmie...)
+	ies = append(ies, extcap...)
+	caps := getRoamingCapabilities(ies)
+	c.Check(caps, C.Equals, roamingCapabilities{
+		FastTransition:   true,
+		MobilityDomain:   0x1234,
+		RadioMeasurement: true,
+		BssTransition:    true,
+	})
+
+	// 没有 FT AKM 时即使有 MDIE 也不支持 FT
+	noFt := append(newTestRsnIE(2), mdie...)
+	c.Check(getRoamingCapabilities(noFt).FastTransition, C.Equals, false)
+
+	other := caps
+	c.Check(canFastTransition(caps, other), C.Equals, true)
+	other.MobilityDomain = 0x5678
+	c.Check(canFastTransition(caps, other), C.Equals, false)
+	c.Check(canFastTransition(caps, roamingCapabilities{}), C.Equals, false)
+}
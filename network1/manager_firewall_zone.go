@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"fmt"
+	"sort"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+const (
+	firewalldService       = "org.fedoraproject.FirewallD1"
+	firewalldPath          = "/org/fedoraproject/FirewallD1"
+	firewalldZoneInterface = firewalldService + ".zone"
+)
+
+// getFirewalldZones 通过 firewalld 获取可用的区域，firewalld 未运行时返回错误
+func getFirewalldZones() (zones []string, err error) {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return
+	}
+	err = systemBus.Object(firewalldService, firewalldPath).
+		Call(firewalldZoneInterface+".getZones", 0).Store(&zones)
+	if err != nil {
+		return
+	}
+	sort.Strings(zones)
+	return
+}
+
+// checkConnectionZone 区域为空时使用 firewalld 的默认区域
+func checkConnectionZone(zone string, zones []string) error {
+	if zone == "" || isStringInArray(zone, zones) {
+		return nil
+	}
+	return fmt.Errorf("invalid firewall zone %q, available zones: %v", zone, zones)
+}
+
+func (m *Manager) setDefaultWirelessZone(zone string) {
+	m.firewallZoneLock.Lock()
+	m.defaultWirelessZone = zone
+	m.firewallZoneLock.Unlock()
+	logger.Info("default firewall zone of wireless connections:", zone)
+}
+
+// applyDefaultWirelessZone 为新建的无线连接设置默认的防火墙区域
+func (m *Manager) applyDefaultWirelessZone(data connectionData) {
+	m.firewallZoneLock.Lock()
+	zone := m.defaultWirelessZone
+	m.firewallZoneLock.Unlock()
+	if zone != "" {
+		setSettingConnectionZone(data, zone)
+		applyPublicZoneResolution(data)
+	}
+}
+
+// GetFirewallZones return the zones provided by firewalld, which could be
+// assigned to connections by SetConnectionZone.
+func (m *Manager) GetFirewallZones() (zones []string, busErr *dbus.Error) {
+	zones, err := getFirewalldZones()
+	if err != nil {
+		logger.Warning("failed to get firewall zones:", err)
+		return nil, dbusutil.ToError(err)
+	}
+	return zones, nil
+}
+
+// GetConnectionZone return the firewall zone of the connection, empty
+// means the default zone of firewalld.
+func (m *Manager) GetConnectionZone(uuid string) (zone string, busErr *dbus.Error) {
+	_, data, err := m.getConnectionData(uuid)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return getSettingConnectionZone(data), nil
+}
+
+// SetConnectionZone set the firewall zone of the connection such as
+// "public", "home" or "work", empty means the default zone of firewalld.
+// The changes take effect immediately if the connection is active.
+func (m *Manager) SetConnectionZone(uuid, zone string) *dbus.Error {
+	err := m.setConnectionZone(uuid, zone)
+	if err != nil {
+		logger.Warning("failed to set connection zone:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setConnectionZone(uuid, zone string) error {
+	if zone != "" {
+		zones, err := getFirewalldZones()
+		if err != nil {
+			return fmt.Errorf("failed to get firewall zones: %v", err)
+		}
+		err = checkConnectionZone(zone, zones)
+		if err != nil {
+			return err
+		}
+	}
+
+	cpath, data, err := m.getConnectionData(uuid)
+	if err != nil {
+		return err
+	}
+	if getSettingConnectionZone(data) == zone {
+		return nil
+	}
+	setSettingConnectionZone(data, zone)
+	applyPublicZoneResolution(data)
+	err = updateConnectionData(cpath, data)
+	if err != nil {
+		return err
+	}
+	logger.Infof("set firewall zone of connection %s to %q", uuid, zone)
+	nmReapplyActiveConnection(uuid)
+	return nil
+}
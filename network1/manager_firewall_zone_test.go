@@ -0,0 +1,28 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestCheckConnectionZone(c *C.C) {
+	zones := []string{"home", "public", "work"}
+	c.Check(checkConnectionZone("", zones), C.IsNil)
+	c.Check(checkConnectionZone("home", zones), C.IsNil)
+	c.Check(checkConnectionZone("trusted", zones), C.NotNil)
+	c.Check(checkConnectionZone("home", nil), C.NotNil)
+}
+
+func (*testWrapper) TestApplyDefaultWirelessZone(c *C.C) {
+	m := &Manager{}
+	data := newWirelessConnectionData("test", "uuid", []byte("test"), "none", "")
+	m.applyDefaultWirelessZone(data)
+	c.Check(getSettingConnectionZone(data), C.Equals, "")
+
+	m.setDefaultWirelessZone("public")
+	m.applyDefaultWirelessZone(data)
+	c.Check(getSettingConnectionZone(data), C.Equals, "public")
+}
@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	"github.com/linuxdeepin/go-lib/utils"
+)
+
+// ssid 最大长度为 32 字节
+const maxSsidLength = 32
+
+// checkHiddenApKeyMgmt 企业网络需要用户填写 EAP 方法和身份，只凭 ssid 创建的连接
+// NetworkManager 无法激活，需要在网络设置中编辑后再连接
+func checkHiddenApKeyMgmt(keyMgmt string) error {
+	if keyMgmt == apSecEap.String() {
+		return errors.New("the EAP method and identity are required for wpa-eap, edit the connection instead")
+	}
+	for _, secType := range []apSecType{apSecNone, apSecWep, apSecPsk, apSecSae, apSecOwe} {
+		if secType.String() == keyMgmt {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid security type %q", keyMgmt)
+}
+
+// ConnectHiddenAccessPoint create and activate a connection for a hidden
+// access point, secType is the key management of the access point, such
+// as "none", "wep", "wpa-psk", "sae" and "owe". The "wpa-eap" networks
+// are rejected, because the EAP method and identity have to be edited
+// first.
+func (m *Manager) ConnectHiddenAccessPoint(ssid, secType string, devPath dbus.ObjectPath) (connection dbus.ObjectPath,
+	busErr *dbus.Error) {
+	cpath, err := m.connectHiddenAccessPoint(ssid, secType, devPath)
+	if err != nil {
+		logger.Warning("failed to connect hidden access point:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+func (m *Manager) connectHiddenAccessPoint(ssid, secType string, devPath dbus.ObjectPath) (cpath dbus.ObjectPath, err error) {
+	logger.Debugf("ConnectHiddenAccessPoint: ssid=%s, secType=%s, devPath=%s", ssid, secType, devPath)
+
+	cpath = "/"
+	if len(ssid) == 0 || len(ssid) > maxSsidLength {
+		err = fmt.Errorf("invalid ssid %q", ssid)
+		return
+	}
+	err = m.checkSsidAllowed(ssid)
+	if err != nil {
+		return
+	}
+	err = checkHiddenApKeyMgmt(secType)
+	if err != nil {
+		return
+	}
+	devType, i := m.getDeviceIndex(devPath)
+	if i < 0 || devType != deviceWifi {
+		err = fmt.Errorf("invalid wireless device %q", devPath)
+		return
+	}
+
+	// 隐藏网络不会出现在扫描结果中，需要定向扫描
+	m.requestDirectedScan(devPath, []byte(ssid))
+
+	hwAddr, err := nmGeneralGetDeviceHwAddr(devPath, true)
+	if err != nil {
+		logger.Warning("failed to get mac", err)
+	}
+	data := newWirelessConnectionData(ssid, utils.GenUuid(), []byte(ssid), secType, hwAddr)
+	setSettingWirelessHidden(data, true)
+	m.applyDefaultWirelessZone(data)
+
+	var apath dbus.ObjectPath
+	cpath, apath, err = nmAddAndActivateConnection(data, devPath, true)
+	if err != nil {
+		m.emitHiddenAccessPointConnectFailed(ssid, devPath, nm.NM_ACTIVE_CONNECTION_STATE_REASON_UNKNOWN)
+		return
+	}
+	m.watchHiddenAccessPointActivation(ssid, devPath, apath)
+	return
+}
+
+func (m *Manager) requestDirectedScan(devPath dbus.ObjectPath, ssid []byte) {
+	nmDev, err := nmNewDevice(devPath)
+	if err != nil {
+		return
+	}
+	options := map[string]dbus.Variant{
+		"ssids": dbus.MakeVariant([][]byte{ssid}),
+	}
+	err = nmDev.Wireless().RequestScan(0, options)
+	if err != nil {
+		logger.Debug("failed to request directed scan:", err)
+	}
+}
+
+// watchHiddenAccessPointActivation 监听激活状态，关联失败时发送 HiddenAccessPointConnectFailed 信号
+func (m *Manager) watchHiddenAccessPointActivation(ssid string, devPath, apath dbus.ObjectPath) {
+	if apath == "" || apath == "/" {
+		return
+	}
+	aConn, err := nmNewActiveConnection(apath)
+	if err != nil {
+		return
+	}
+	aConn.InitSignalExt(m.sysSigLoop, true)
+
+	// 信号和下面读取的初始状态都可能是最终状态，只处理一次
+	var once sync.Once
+	handleState := func(state, reason uint32) {
+		if state != nm.NM_ACTIVE_CONNECTION_STATE_ACTIVATED && state != nm.NM_ACTIVE_CONNECTION_STATE_DEACTIVATED {
+			return
+		}
+		once.Do(func() {
+			nmDestroyActiveConnection(aConn)
+			if state == nm.NM_ACTIVE_CONNECTION_STATE_DEACTIVATED &&
+				reason != nm.NM_ACTIVE_CONNECTION_STATE_REASON_USER_DISCONNECTED {
+				m.emitHiddenAccessPointConnectFailed(ssid, devPath, reason)
+			}
+		})
+	}
+	_, err = aConn.ConnectStateChanged(handleState)
+	if err != nil {
+		logger.Warning(err)
+		nmDestroyActiveConnection(aConn)
+		return
+	}
+	// 连接信号之前可能已经激活完成或失败
+	state, err := aConn.State().Get(0)
+	if err != nil {
+		// 激活连接已经不存在
+		logger.Debug("failed to get active connection state:", err)
+		once.Do(func() {
+			nmDestroyActiveConnection(aConn)
+		})
+		return
+	}
+	handleState(state, nm.NM_ACTIVE_CONNECTION_STATE_REASON_UNKNOWN)
+}
+
+func (m *Manager) emitHiddenAccessPointConnectFailed(ssid string, devPath dbus.ObjectPath, reason uint32) {
+	logger.Infof("failed to connect hidden access point %s, reason: %d", ssid, reason)
+	err := m.service.Emit(m, "HiddenAccessPointConnectFailed", ssid, string(devPath), reason)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
@@ -0,0 +1,247 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// NetworkManager 共享连接的 dnsmasq 租约文件目录
+const nmDnsmasqLeasesDir = "/var/lib/NetworkManager"
+
+type hotspotStation struct {
+	MacAddress string
+	IP         string
+	Hostname   string
+}
+
+type hotspotInfo struct {
+	Enabled  bool
+	Ssid     string
+	Band     string
+	Secured  bool
+	Stations []hotspotStation
+}
+
+func checkHotspotArgs(ssid, passphrase, band string) error {
+	if len(ssid) == 0 || len(ssid) > maxSsidLength {
+		return fmt.Errorf("invalid ssid %q", ssid)
+	}
+	// 空密码表示开放热点
+	if passphrase != "" && (len(passphrase) < 8 || len(passphrase) > 63) {
+		return fmt.Errorf("the length of passphrase must be between 8 and 63")
+	}
+	switch band {
+	case "", "a", "bg":
+	default:
+		return fmt.Errorf("invalid band %q", band)
+	}
+	return nil
+}
+
+func setHotspotSettings(data connectionData, ssid, passphrase, band string) (err error) {
+	setSettingWirelessSsid(data, []byte(ssid))
+	if passphrase == "" {
+		err = logicSetSettingVkWirelessSecurityKeyMgmt(data, "none")
+	} else {
+		err = logicSetSettingVkWirelessSecurityKeyMgmt(data, "wpa-psk")
+		setSettingWirelessSecurityPsk(data, passphrase)
+	}
+	if err != nil {
+		return
+	}
+	// 频段变化后原信道可能不可用，由 NetworkManager 自动选择
+	removeSettingWirelessChannel(data)
+	if band == "" {
+		removeSettingWirelessBand(data)
+	} else {
+		setSettingWirelessBand(data, band)
+	}
+	return
+}
+
+// EnableHotspot create or update the hotspot connection of the
+// wireless device and activate it, empty passphrase means an open
+// hotspot, band could be "a", "bg" or empty for automatic.
+func (m *Manager) EnableHotspot(devPath dbus.ObjectPath, ssid, passphrase, band string) *dbus.Error {
+	// 网卡正在作为客户端连接网络时，开启热点后由其他空闲的无线网卡继续连接
+	clientUuid := getWirelessClientConnection(devPath)
+	err := m.enableHotspot(devPath, ssid, passphrase, band)
+	if err != nil {
+		logger.Warning("failed to enable hotspot:", err)
+		return dbusutil.ToError(err)
+	}
+	if clientUuid != "" {
+		go m.handOverWirelessClient(devPath, clientUuid)
+	}
+	return nil
+}
+
+func (m *Manager) enableHotspot(devPath dbus.ObjectPath, ssid, passphrase, band string) (err error) {
+	devType := nmGetDeviceType(devPath)
+	if devType != nm.NM_DEVICE_TYPE_WIFI {
+		return fmt.Errorf("not a wireless device %s %d", devPath, devType)
+	}
+	err = checkHotspotArgs(ssid, passphrase, band)
+	if err != nil {
+		return
+	}
+
+	uuid := nmGeneralGetDeviceUniqueUuid(devPath)
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		// connection not exists, create one
+		logger.Infof("new wireless hotspot connection, uuid=%s, devPath=%s", uuid, devPath)
+		data := newWirelessHotspotConnectionData("hotspot", uuid)
+		setSettingConnectionInterfaceName(data, nmGetDeviceInterface(devPath))
+		hwAddr, _ := nmGeneralGetDeviceHwAddr(devPath, true)
+		setSettingWirelessMacAddress(data, convertMacAddressToArrayByte(hwAddr))
+		err = setHotspotSettings(data, ssid, passphrase, band)
+		if err != nil {
+			return
+		}
+		_, _, err = nmAddAndActivateConnection(data, devPath, true)
+		return
+	}
+
+	data, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return
+	}
+	// 网卡的 mac 地址可能已经变化，热点连接总是绑定到当前网卡
+	setSettingConnectionInterfaceName(data, nmGetDeviceInterface(devPath))
+	if hwAddr, err := nmGeneralGetDeviceHwAddr(devPath, true); err == nil {
+		setSettingWirelessMacAddress(data, convertMacAddressToArrayByte(hwAddr))
+	} else {
+		removeSettingWirelessMacAddress(data)
+	}
+	err = setHotspotSettings(data, ssid, passphrase, band)
+	if err != nil {
+		return
+	}
+	err = updateConnectionData(cpath, data)
+	if err != nil {
+		return
+	}
+	_, err = nmActivateConnection(cpath, devPath)
+	return
+}
+
+// DisableHotspot deactivate the hotspot connection of the wireless device.
+func (m *Manager) DisableHotspot(devPath dbus.ObjectPath) *dbus.Error {
+	uuid := nmGeneralGetDeviceUniqueUuid(devPath)
+	err := m.deactivateConnection(uuid)
+	if err != nil {
+		logger.Warning("failed to disable hotspot:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+// GetHotspotInfo return the marshaled hotspot information of the
+// wireless device, including the connected stations.
+func (m *Manager) GetHotspotInfo(devPath dbus.ObjectPath) (infoJSON string, busErr *dbus.Error) {
+	info, err := m.getHotspotInfo(devPath)
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	infoJSON, err = marshalJSON(info)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return infoJSON, nil
+}
+
+func (m *Manager) getHotspotInfo(devPath dbus.ObjectPath) (info *hotspotInfo, err error) {
+	devType := nmGetDeviceType(devPath)
+	if devType != nm.NM_DEVICE_TYPE_WIFI {
+		return nil, fmt.Errorf("not a wireless device %s %d", devPath, devType)
+	}
+
+	info = &hotspotInfo{
+		Stations: make([]hotspotStation, 0),
+	}
+	uuid := nmGeneralGetDeviceUniqueUuid(devPath)
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		// 未创建过热点连接
+		return info, nil
+	}
+	data, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return nil, err
+	}
+	info.Ssid = decodeSsid(getSettingWirelessSsid(data))
+	info.Band = getSettingWirelessBand(data)
+	info.Secured = isSettingExists(data, nm.NM_SETTING_WIRELESS_SECURITY_SETTING_NAME)
+
+	apaths, _ := nmGetActiveConnectionByUuid(uuid)
+	info.Enabled = len(apaths) > 0
+	if info.Enabled {
+		info.Stations = getHotspotStations(nmGetDeviceInterface(devPath))
+	}
+	return info, nil
+}
+
+// getHotspotStations 通过 dnsmasq 租约文件获取连接到热点的设备
+func getHotspotStations(ifc string) []hotspotStation {
+	stations := make([]hotspotStation, 0)
+	if ifc == "" {
+		return stations
+	}
+	content, err := os.ReadFile(filepath.Join(nmDnsmasqLeasesDir, "dnsmasq-"+ifc+".leases"))
+	if err != nil {
+		logger.Debug(err)
+		return stations
+	}
+	return append(stations, parseDnsmasqLeases(content)...)
+}
+
+// parseDnsmasqLeases 解析 dnsmasq 租约，每行格式为: <过期时间> <mac> <ip> <主机名> <client-id>
+func parseDnsmasqLeases(content []byte) (stations []hotspotStation) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		station := hotspotStation{
+			MacAddress: strings.ToUpper(fields[1]),
+			IP:         fields[2],
+		}
+		if fields[3] != "*" {
+			station.Hostname = fields[3]
+		}
+		stations = append(stations, station)
+	}
+	return
+}
+
+// updateHotspotState 设备状态变化时检查热点是否开启，状态变化时发送 HotspotStateChanged 信号
+func (m *Manager) updateHotspotState(dev *device, state uint32) {
+	if dev.nmDevType != nm.NM_DEVICE_TYPE_WIFI {
+		return
+	}
+	enabled := state == nm.NM_DEVICE_STATE_ACTIVATED && dev.Mode == nm.NM_802_11_MODE_AP
+	if dev.hotspotEnabled == enabled {
+		return
+	}
+	dev.hotspotEnabled = enabled
+	err := m.service.Emit(m, "HotspotStateChanged", string(dev.Path), enabled)
+	if err != nil {
+		logger.Warning("failed to emit signal:", err)
+	}
+	m.updateWirelessRoles()
+}
@@ -0,0 +1,31 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestCheckHotspotArgs(c *C.C) {
+	c.Check(checkHotspotArgs("deepin", "", ""), C.IsNil)
+	c.Check(checkHotspotArgs("deepin", "12345678", "a"), C.IsNil)
+	c.Check(checkHotspotArgs("deepin", "12345678", "bg"), C.IsNil)
+	c.Check(checkHotspotArgs("", "12345678", ""), C.NotNil)
+	c.Check(checkHotspotArgs("deepin", "1234567", ""), C.NotNil)
+	c.Check(checkHotspotArgs("deepin", "12345678", "ac"), C.NotNil)
+}
+
+func (*testWrapper) TestParseDnsmasqLeases(c *C.C) {
+	content := []byte(`1700000000 aa:bb:cc:dd:ee:ff 10.42.0.23 phone 01:aa:bb:cc:dd:ee:ff
+1700000100 11:22:33:44:55:66 10.42.0.45 * *
+
+invalid line
+`)
+	stations := parseDnsmasqLeases(content)
+	c.Check(stations, C.DeepEquals, []hotspotStation{
+		{MacAddress: "AA:BB:CC:DD:EE:FF", IP: "10.42.0.23", Hostname: "phone"},
+		{MacAddress: "11:22:33:44:55:66", IP: "10.42.0.45"},
+	})
+}
@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	"github.com/linuxdeepin/go-lib/utils"
+)
+
+// 导入导出支持的文件格式
+const (
+	connFileTypeKeyfile   = "keyfile"
+	connFileTypeOpenvpn   = "openvpn"
+	connFileTypeWireguard = "wireguard"
+)
+
+// 可能包含密码的 setting，导出时需要单独获取
+var connSecretSettings = []string{
+	nm.NM_SETTING_WIRELESS_SECURITY_SETTING_NAME,
+	nm.NM_SETTING_802_1X_SETTING_NAME,
+	nm.NM_SETTING_VPN_SETTING_NAME,
+	nm.NM_SETTING_WIREGUARD_SETTING_NAME,
+	nm.NM_SETTING_PPPOE_SETTING_NAME,
+	nm.NM_SETTING_GSM_SETTING_NAME,
+}
+
+// guessConnFileType 根据文件扩展名判断格式
+func guessConnFileType(filePath string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".nmconnection":
+		return connFileTypeKeyfile, nil
+	case ".ovpn":
+		return connFileTypeOpenvpn, nil
+	case ".conf":
+		return connFileTypeWireguard, nil
+	}
+	return "", fmt.Errorf("unknown connection file type of %q", filePath)
+}
+
+func getConnFileName(filePath string) string {
+	name := filepath.Base(filePath)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// ImportConnection create a connection from a NetworkManager keyfile, an
+// OpenVPN .ovpn profile or a WireGuard wg-quick config, fileType is one of
+// keyfile, openvpn and wireguard, or empty to guess from the file extension.
+func (m *Manager) ImportConnection(filePath, fileType string) (connection dbus.ObjectPath, busErr *dbus.Error) {
+	cpath, err := m.importConnection(filePath, fileType)
+	if err != nil {
+		logger.Warningf("failed to import connection from %s: %v", filePath, err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+func (m *Manager) importConnection(filePath, fileType string) (cpath dbus.ObjectPath, err error) {
+	cpath = "/"
+	if fileType == "" {
+		fileType, err = guessConnFileType(filePath)
+		if err != nil {
+			return
+		}
+	}
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return
+	}
+
+	var data connectionData
+	switch fileType {
+	case connFileTypeKeyfile:
+		data, err = parseNMKeyfile(content)
+		if err != nil {
+			return
+		}
+		// uuid 已存在时使用新的 uuid，避免覆盖已有连接
+		uuid := getSettingConnectionUuid(data)
+		if uuid == "" || isConnectionUuidExists(uuid) {
+			setSettingConnectionUuid(data, utils.GenUuid())
+		}
+	case connFileTypeOpenvpn:
+		var homeDir string
+		homeDir, err = os.UserHomeDir()
+		if err != nil {
+			return
+		}
+		id := getConnFileName(filePath)
+		uuid := utils.GenUuid()
+		var vpnData map[string]string
+		vpnData, err = parseOvpnConfig(content, filepath.Dir(filePath), filepath.Join(homeDir, openvpnCertDir), uuid)
+		if err != nil {
+			return
+		}
+		data = newOpenvpnConnectionData(id, uuid, vpnData)
+	case connFileTypeWireguard:
+		var cfg *wireguardConfig
+		cfg, err = parseWgQuickConfig(content)
+		if err != nil {
+			return
+		}
+		return m.addWireguardConnection(getConnFileName(filePath), cfg)
+	default:
+		err = fmt.Errorf("unsupported connection file type %q", fileType)
+		return
+	}
+	return nmAddConnection(data)
+}
+
+func isConnectionUuidExists(uuid string) bool {
+	_, err := nmGetConnectionByUuid(uuid)
+	return err == nil
+}
+
+// ExportConnection write the connection with the uuid to filePath
+// including its saved secrets, the format is chosen by the file
+// extension, .nmconnection, .ovpn or .conf.
+func (m *Manager) ExportConnection(uuid, filePath string) *dbus.Error {
+	err := m.exportConnection(uuid, filePath)
+	if err != nil {
+		logger.Warningf("failed to export connection %s to %s: %v", uuid, filePath, err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) exportConnection(uuid, filePath string) error {
+	fileType, err := guessConnFileType(filePath)
+	if err != nil {
+		return err
+	}
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		return err
+	}
+	data, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return err
+	}
+	for _, setting := range connSecretSettings {
+		if !isSettingExists(data, setting) {
+			continue
+		}
+		secrets, err := nmGetConnectionSecrets(cpath, setting)
+		if err != nil {
+			// 密码由用户保存在密钥环中时无法获取，导出不含密码的配置
+			logger.Debugf("failed to get secrets of %s: %v", setting, err)
+			continue
+		}
+		mergeConnectionSecrets(data, secrets)
+	}
+
+	var content []byte
+	switch fileType {
+	case connFileTypeKeyfile:
+		content, err = formatNMKeyfile(data)
+	case connFileTypeOpenvpn:
+		if getSettingVpnServiceType(data) != nm.NM_DBUS_SERVICE_OPENVPN {
+			return errors.New("connection is not an openvpn connection")
+		}
+		vpnData := make(map[string]string)
+		for key, value := range getSettingVpnData(data) {
+			vpnData[key] = value
+		}
+		for key, value := range getSettingVpnSecrets(data) {
+			vpnData[key] = value
+		}
+		content, err = formatOvpnConfig(vpnData)
+	case connFileTypeWireguard:
+		if getSettingConnectionType(data) != nm.NM_SETTING_WIREGUARD_SETTING_NAME {
+			return errors.New("connection is not a wireguard connection")
+		}
+		content = formatWgQuickConfig(newWireguardConfigFromData(data))
+	}
+	if err != nil {
+		return err
+	}
+	// 文件中包含密码，只允许当前用户读写
+	return os.WriteFile(filePath, content, 0600)
+}
+
+// mergeConnectionSecrets 将 GetSecrets 获取的密码合并到连接配置中
+func mergeConnectionSecrets(data, secrets connectionData) {
+	for setting, values := range secrets {
+		for key, value := range values {
+			if setting == nm.NM_SETTING_WIREGUARD_SETTING_NAME && key == nm.NM_SETTING_WIREGUARD_PEERS {
+				mergeWireguardPeerSecrets(data, value)
+				continue
+			}
+			setSettingKey(data, setting, key, value.Value())
+		}
+	}
+}
+
+// mergeWireguardPeerSecrets GetSecrets 返回的 peers 只有 public-key 和 preshared-key，按 public-key 合并
+func mergeWireguardPeerSecrets(data connectionData, value dbus.Variant) {
+	secretPeers, _ := value.Value().([]map[string]dbus.Variant)
+	peers, _ := getSettingKey(data, nm.NM_SETTING_WIREGUARD_SETTING_NAME,
+		nm.NM_SETTING_WIREGUARD_PEERS).([]map[string]dbus.Variant)
+	for _, secretPeer := range secretPeers {
+		for _, peer := range peers {
+			if peer[nm.NM_WIREGUARD_PEER_ATTR_PUBLIC_KEY].Value() != secretPeer[nm.NM_WIREGUARD_PEER_ATTR_PUBLIC_KEY].Value() {
+				continue
+			}
+			for attr, v := range secretPeer {
+				peer[attr] = v
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"fmt"
+
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+)
+
+// isInsecureAccessPoint 未加密的普通热点，不包括 OWE 热点和本机开启的热点
+func isInsecureAccessPoint(mode, flags, wpaFlags, rsnFlags uint32) bool {
+	return mode == nm.NM_802_11_MODE_INFRA && doParseApSecType(flags, wpaFlags, rsnFlags) == apSecNone
+}
+
+func (m *Manager) setOpenNetworkVpn(uuid string) {
+	m.openNetworkVpnLock.Lock()
+	m.openNetworkVpn = uuid
+	m.openNetworkVpnLock.Unlock()
+	logger.Info("vpn for open wireless networks:", uuid)
+}
+
+func (m *Manager) getOpenNetworkVpn() string {
+	m.openNetworkVpnLock.Lock()
+	defer m.openNetworkVpnLock.Unlock()
+	return m.openNetworkVpn
+}
+
+// checkInsecureNetwork 无线设备连接成功后检查热点是否加密，未加密时发送
+// InsecureNetworkActivated 信号，并按策略自动激活 VPN
+func (m *Manager) checkInsecureNetwork(dev *device) {
+	apPath, err := dev.nmDev.Wireless().ActiveAccessPoint().Get(0)
+	if err != nil || !isNmObjectPathValid(apPath) {
+		return
+	}
+	nmAp, err := nmNewAccessPoint(apPath)
+	if err != nil {
+		return
+	}
+	mode, _ := nmAp.Mode().Get(0)
+	flags, err1 := nmAp.Flags().Get(0)
+	wpaFlags, err2 := nmAp.WpaFlags().Get(0)
+	rsnFlags, err3 := nmAp.RsnFlags().Get(0)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return
+	}
+	if !isInsecureAccessPoint(mode, flags, wpaFlags, rsnFlags) {
+		return
+	}
+	ssid, _ := nmAp.Ssid().Get(0)
+	ssidStr := decodeSsid(ssid)
+	logger.Infof("insecure wireless network %q activated on %s", ssidStr, dev.Path)
+	err = m.service.Emit(m, "InsecureNetworkActivated", ssidStr, string(dev.Path))
+	if err != nil {
+		logger.Warning(err)
+	}
+
+	uuid := m.getOpenNetworkVpn()
+	if uuid == "" {
+		return
+	}
+	err = m.activateOpenNetworkVpn(uuid)
+	if err != nil {
+		logger.Warning("failed to activate vpn for open network:", err)
+	}
+}
+
+func (m *Manager) activateOpenNetworkVpn(uuid string) error {
+	_, data, err := m.getVpnConnectionData(uuid)
+	if err != nil {
+		return fmt.Errorf("invalid vpn %s: %v", uuid, err)
+	}
+	// VPN 已经激活时不需要重复激活
+	if apaths, err := nmGetActiveConnectionByUuid(uuid); err == nil && len(apaths) > 0 {
+		return nil
+	}
+	logger.Infof("activate vpn %s for open network", getSettingConnectionId(data))
+	_, err = m.activateConnection(uuid, "/")
+	return err
+}
@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestIsInsecureAccessPoint(c *C.C) {
+	c.Check(isInsecureAccessPoint(nm.NM_802_11_MODE_INFRA, 0, 0, 0), C.Equals, true)
+	// WEP
+	c.Check(isInsecureAccessPoint(nm.NM_802_11_MODE_INFRA, nm.NM_802_11_AP_FLAGS_PRIVACY, 0, 0), C.Equals, false)
+	// WPA2-PSK
+	c.Check(isInsecureAccessPoint(nm.NM_802_11_MODE_INFRA, nm.NM_802_11_AP_FLAGS_PRIVACY, 0,
+		nm.NM_802_11_AP_SEC_KEY_MGMT_PSK), C.Equals, false)
+	// OWE
+	c.Check(isInsecureAccessPoint(nm.NM_802_11_MODE_INFRA, nm.NM_802_11_AP_FLAGS_PRIVACY, 0,
+		nm.NM_802_11_AP_SEC_KEY_MGMT_OWE), C.Equals, false)
+	// 本机开启的热点
+	c.Check(isInsecureAccessPoint(nm.NM_802_11_MODE_AP, 0, 0, 0), C.Equals, false)
+}
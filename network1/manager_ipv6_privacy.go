@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// SetConnectionIPv6Privacy set the ipv6 privacy extensions and address
+// generation mode of the connection. ip6Privacy is -1 for default, 0 for
+// disabled, 1 for preferring public address and 2 for preferring
+// temporary address; addrGenMode is 0 for EUI-64 and 1 for stable-privacy.
+func (m *Manager) SetConnectionIPv6Privacy(uuid string, ip6Privacy, addrGenMode int32) *dbus.Error {
+	err := m.setConnectionIPv6Privacy(uuid, ip6Privacy, addrGenMode)
+	if err != nil {
+		logger.Warning("failed to set connection ipv6 privacy:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setConnectionIPv6Privacy(uuid string, ip6Privacy, addrGenMode int32) (err error) {
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		return
+	}
+	data, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return
+	}
+
+	err = logicSetSettingIP6ConfigIp6Privacy(data, ip6Privacy)
+	if err != nil {
+		return
+	}
+	err = logicSetSettingIP6ConfigAddrGenMode(data, addrGenMode)
+	if err != nil {
+		return
+	}
+	return updateConnectionData(cpath, data)
+}
+
+// migrateIP6AddrGenMode 将已有的有线和无线连接的地址生成方式迁移为 stable-privacy，只执行一次
+func (m *Manager) migrateIP6AddrGenMode() {
+	if m.networkConfig == nil {
+		return
+	}
+	v, err := m.networkConfig.Value(0, dsettingsIP6AddrGenModeMigrated)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	if migrated, _ := v.Value().(bool); migrated {
+		return
+	}
+
+	for _, cpath := range nmGetConnectionList() {
+		data, err := nmGetConnectionData(cpath)
+		if err != nil {
+			continue
+		}
+		if !needMigrateIP6AddrGenMode(data) {
+			continue
+		}
+		setSettingIP6ConfigAddrGenMode(data, nm.NM_SETTING_IP6_CONFIG_ADDR_GEN_MODE_STABLE_PRIVACY)
+		logger.Infof("migrate addr-gen-mode of connection %s to stable-privacy", getSettingConnectionId(data))
+		err = updateConnectionData(cpath, data)
+		if err != nil {
+			logger.Warningf("failed to migrate addr-gen-mode of %s: %v", getSettingConnectionId(data), err)
+		}
+	}
+
+	err = m.networkConfig.SetValue(0, dsettingsIP6AddrGenModeMigrated, dbus.MakeVariant(true))
+	if err != nil {
+		logger.Warning(err)
+	}
+}
@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"fmt"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// connection.mdns 和 connection.llmnr 的取值，default 表示使用 NetworkManager 的全局配置
+const (
+	localResolutionDefault     = "default"
+	localResolutionNo          = "no"
+	localResolutionResolveOnly = "resolve-only"
+	localResolutionYes         = "yes"
+)
+
+const (
+	nmSettingConnectionMdns  = "mdns"
+	nmSettingConnectionLlmnr = "llmnr"
+)
+
+// 标记为 public 的网络默认只解析不发布本机名称
+const firewallZonePublic = "public"
+
+var localResolutionValues = map[string]int32{
+	localResolutionDefault:     -1,
+	localResolutionNo:          0,
+	localResolutionResolveOnly: 1,
+	localResolutionYes:         2,
+}
+
+func getLocalResolutionValue(mode string) (value int32, err error) {
+	value, ok := localResolutionValues[mode]
+	if !ok {
+		return 0, fmt.Errorf("invalid local resolution mode %q", mode)
+	}
+	return value, nil
+}
+
+func getLocalResolutionMode(value int32) string {
+	for mode, v := range localResolutionValues {
+		if v == value {
+			return mode
+		}
+	}
+	return localResolutionDefault
+}
+
+// getSettingConnectionLocalResolution 读取 connection 中 mdns 或 llmnr 的值，未设置时为 -1
+func getSettingConnectionLocalResolution(data connectionData, key string) int32 {
+	if !isSettingKeyExists(data, nm.NM_SETTING_CONNECTION_SETTING_NAME, key) {
+		return localResolutionValues[localResolutionDefault]
+	}
+	value, ok := doGetSettingKey(data, nm.NM_SETTING_CONNECTION_SETTING_NAME, key).(int32)
+	if !ok {
+		return localResolutionValues[localResolutionDefault]
+	}
+	return value
+}
+
+func setSettingConnectionLocalResolution(data connectionData, key string, value int32) {
+	setSettingKey(data, nm.NM_SETTING_CONNECTION_SETTING_NAME, key, value)
+}
+
+// applyPublicZoneResolution 连接属于 public 区域且未设置 mdns 和 llmnr 时，设置为只解析
+func applyPublicZoneResolution(data connectionData) {
+	if getSettingConnectionZone(data) != firewallZonePublic {
+		return
+	}
+	resolveOnly := localResolutionValues[localResolutionResolveOnly]
+	for _, key := range []string{nmSettingConnectionMdns, nmSettingConnectionLlmnr} {
+		if getSettingConnectionLocalResolution(data, key) == localResolutionValues[localResolutionDefault] {
+			setSettingConnectionLocalResolution(data, key, resolveOnly)
+		}
+	}
+}
+
+// GetConnectionLocalResolution return the mDNS and LLMNR mode of the
+// connection, which is one of "default", "no", "resolve-only" and "yes".
+func (m *Manager) GetConnectionLocalResolution(uuid string) (mdns, llmnr string, busErr *dbus.Error) {
+	_, data, err := m.getConnectionData(uuid)
+	if err != nil {
+		return "", "", dbusutil.ToError(err)
+	}
+	mdns = getLocalResolutionMode(getSettingConnectionLocalResolution(data, nmSettingConnectionMdns))
+	llmnr = getLocalResolutionMode(getSettingConnectionLocalResolution(data, nmSettingConnectionLlmnr))
+	return mdns, llmnr, nil
+}
+
+// SetConnectionLocalResolution set the mDNS and LLMNR mode of the
+// connection, mode is one of "default", "no", "resolve-only" and "yes".
+// "resolve-only" resolves the hostnames of the local network without
+// announcing the hostname of this machine. The changes take effect
+// immediately if the connection is active.
+func (m *Manager) SetConnectionLocalResolution(uuid, mdns, llmnr string) *dbus.Error {
+	err := m.setConnectionLocalResolution(uuid, mdns, llmnr)
+	if err != nil {
+		logger.Warning("failed to set connection local resolution:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setConnectionLocalResolution(uuid, mdns, llmnr string) error {
+	mdnsValue, err := getLocalResolutionValue(mdns)
+	if err != nil {
+		return err
+	}
+	llmnrValue, err := getLocalResolutionValue(llmnr)
+	if err != nil {
+		return err
+	}
+
+	cpath, data, err := m.getConnectionData(uuid)
+	if err != nil {
+		return err
+	}
+	setSettingConnectionLocalResolution(data, nmSettingConnectionMdns, mdnsValue)
+	setSettingConnectionLocalResolution(data, nmSettingConnectionLlmnr, llmnrValue)
+	err = updateConnectionData(cpath, data)
+	if err != nil {
+		return err
+	}
+	logger.Infof("set local resolution of connection %s, mdns: %s, llmnr: %s", uuid, mdns, llmnr)
+	nmReapplyActiveConnection(uuid)
+	return nil
+}
@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestLocalResolutionMode(c *C.C) {
+	for _, mode := range []string{localResolutionDefault, localResolutionNo,
+		localResolutionResolveOnly, localResolutionYes} {
+		value, err := getLocalResolutionValue(mode)
+		c.Check(err, C.IsNil)
+		c.Check(getLocalResolutionMode(value), C.Equals, mode)
+	}
+	_, err := getLocalResolutionValue("maybe")
+	c.Check(err, C.NotNil)
+	c.Check(getLocalResolutionMode(5), C.Equals, localResolutionDefault)
+}
+
+func (*testWrapper) TestApplyPublicZoneResolution(c *C.C) {
+	data := newWirelessConnectionData("test", "uuid", []byte("test"), "none", "")
+	applyPublicZoneResolution(data)
+	c.Check(getSettingConnectionLocalResolution(data, nmSettingConnectionMdns), C.Equals, int32(-1))
+
+	setSettingConnectionZone(data, firewallZonePublic)
+	setSettingConnectionLocalResolution(data, nmSettingConnectionLlmnr, 0)
+	applyPublicZoneResolution(data)
+	c.Check(getSettingConnectionLocalResolution(data, nmSettingConnectionMdns), C.Equals, int32(1))
+	// 用户已设置的值保持不变
+	c.Check(getSettingConnectionLocalResolution(data, nmSettingConnectionLlmnr), C.Equals, int32(0))
+}
@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// 手机热点常见的 ssid 关键字
+var tetheringSsidKeywords = []string{
+	"iphone",
+	"ipad",
+	"androidap",
+	"android_",
+	"galaxy",
+	"redmi",
+	"oneplus",
+	"realme",
+	"pixel",
+}
+
+// isTetheringSsid 根据 ssid 判断是否可能为手机热点
+func isTetheringSsid(ssid string) bool {
+	ssid = strings.ToLower(ssid)
+	for _, keyword := range tetheringSsidKeywords {
+		if strings.Contains(ssid, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// guessConnectionMetered 连接未设置是否按流量计费时，根据 ssid 猜测是否为手机热点，
+// 只用于判断当前的状态，不写入连接，与 NetworkManager 的 GUESS_YES 相同
+func guessConnectionMetered(data connectionData) bool {
+	if getSettingConnectionType(data) != nm.NM_SETTING_WIRELESS_SETTING_NAME {
+		return false
+	}
+	return isTetheringSsid(decodeSsid(getSettingWirelessSsid(data)))
+}
+
+func isMeteredValue(metered uint32) bool {
+	return metered == nm.NM_METERED_YES || metered == nm.NM_METERED_GUESS_YES
+}
+
+// getActiveConnectionMetered 优先使用连接中的设置，未设置时使用 NetworkManager 对设备的猜测，
+// 最后根据 ssid 猜测
+func getActiveConnectionMetered(uuid string, devPaths []dbus.ObjectPath) bool {
+	var data connectionData
+	if cpath, err := nmGetConnectionByUuid(uuid); err == nil {
+		data, _ = nmGetConnectionData(cpath)
+	}
+	if data != nil {
+		switch getSettingConnectionMetered(data) {
+		case nm.NM_METERED_YES:
+			return true
+		case nm.NM_METERED_NO:
+			return false
+		}
+	}
+	for _, devPath := range devPaths {
+		nmDev, err := nmNewDevice(devPath)
+		if err != nil {
+			continue
+		}
+		metered, _ := nmDev.Device().Metered().Get(0)
+		if isMeteredValue(metered) {
+			return true
+		}
+	}
+	if data != nil && guessConnectionMetered(data) {
+		logger.Infof("connection %s looks like a phone hotspot, guess it is metered", uuid)
+		return true
+	}
+	return false
+}
+
+// SetConnectionMetered set whether the connection is metered, so that
+// other modules could defer large downloads on it.
+func (m *Manager) SetConnectionMetered(uuid string, metered bool) *dbus.Error {
+	err := m.setConnectionMetered(uuid, metered)
+	if err != nil {
+		logger.Warning("failed to set connection metered:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setConnectionMetered(uuid string, metered bool) (err error) {
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		return
+	}
+	data, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return
+	}
+
+	if metered {
+		setSettingConnectionMetered(data, nm.NM_METERED_YES)
+	} else {
+		setSettingConnectionMetered(data, nm.NM_METERED_NO)
+	}
+	err = updateConnectionData(cpath, data)
+	if err != nil {
+		return
+	}
+
+	m.activeConnectionsLock.Lock()
+	defer m.activeConnectionsLock.Unlock()
+	changed := false
+	for _, aConn := range m.activeConnections {
+		if aConn.Uuid == uuid && aConn.Metered != metered {
+			aConn.Metered = metered
+			changed = true
+		}
+	}
+	if changed {
+		m.updatePropActiveConnections()
+	}
+	return
+}
@@ -0,0 +1,24 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestGuessConnectionMetered(c *C.C) {
+	c.Check(isTetheringSsid("Tom's iPhone"), C.Equals, true)
+	c.Check(isTetheringSsid("AndroidAP_1234"), C.Equals, true)
+	c.Check(isTetheringSsid("Redmi K40"), C.Equals, true)
+	c.Check(isTetheringSsid("deepin-office"), C.Equals, false)
+
+	data := newWirelessConnectionData("iPhone", "uuid", []byte("iPhone"), "wpa-psk", "")
+	c.Check(guessConnectionMetered(data), C.Equals, true)
+	// 只猜测，不修改连接
+	c.Check(isSettingConnectionMeteredExists(data), C.Equals, false)
+
+	data = newWirelessConnectionData("deepin", "uuid", []byte("deepin"), "wpa-psk", "")
+	c.Check(guessConnectionMetered(data), C.Equals, false)
+}
@@ -0,0 +1,224 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	"github.com/linuxdeepin/go-lib/utils"
+)
+
+const (
+	// 802.11u Interworking 信息元素，Hotspot 2.0(Passpoint) 热点必须广播
+	ieInterworking = 107
+	// 802.11u Roaming Consortium 信息元素，包含热点支持的运营商 OI
+	ieRoamingConsortium = 111
+)
+
+// passpointCredential 运营商提供的 Passpoint 凭据
+type passpointCredential struct {
+	// 运营商的 NAI realm，如 example.com
+	Realm string
+	// 运营商的 Home OI，十六进制字符串，如 001bc504bd
+	HomeOIs []string
+	Eap     eapConfig
+}
+
+func (cred *passpointCredential) check(now time.Time) error {
+	if cred.Realm == "" && len(cred.HomeOIs) == 0 {
+		return errors.New("realm or home OIs is required")
+	}
+	for i, oi := range cred.HomeOIs {
+		oi = strings.ToLower(oi)
+		if _, err := hex.DecodeString(oi); err != nil || len(oi) < 6 || len(oi) > 30 {
+			return fmt.Errorf("invalid home OI %q", oi)
+		}
+		cred.HomeOIs[i] = oi
+	}
+	return cred.Eap.check(now)
+}
+
+// findInformationElement 返回信息元素列表中第一个 id 匹配的元素内容
+func findInformationElement(ies []byte, id byte) (body []byte, ok bool) {
+	for len(ies) >= 2 {
+		length := int(ies[1])
+		if len(ies) < 2+length {
+			return nil, false
+		}
+		if ies[0] == id {
+			return ies[2 : 2+length], true
+		}
+		ies = ies[2+length:]
+	}
+	return nil, false
+}
+
+func isPasspointIEs(ies []byte) bool {
+	_, ok := findInformationElement(ies, ieInterworking)
+	return ok
+}
+
+// parseRoamingConsortium 解析 Roaming Consortium 元素中的 OI，最多包含 3 个
+func parseRoamingConsortium(ies []byte) (ois []string) {
+	body, ok := findInformationElement(ies, ieRoamingConsortium)
+	// number of ANQP OIs(1) + OI #1 and #2 lengths(1)
+	if !ok || len(body) < 2 {
+		return nil
+	}
+	lengths := []int{int(body[1] & 0x0f), int(body[1] >> 4)}
+	body = body[2:]
+	for _, length := range lengths {
+		if length == 0 || len(body) < length {
+			return
+		}
+		ois = append(ois, hex.EncodeToString(body[:length]))
+		body = body[length:]
+	}
+	if len(body) > 0 {
+		ois = append(ois, hex.EncodeToString(body))
+	}
+	return
+}
+
+// matchPasspointCredential 凭据设置了 Home OI 时，热点需要支持其中之一
+func matchPasspointCredential(cred *passpointCredential, ois []string) bool {
+	if len(cred.HomeOIs) == 0 {
+		return true
+	}
+	for _, oi := range cred.HomeOIs {
+		if isStringInArray(oi, ois) {
+			return true
+		}
+	}
+	return false
+}
+
+// newPasspointConnectionData 使用凭据为热点创建 802.1X 连接，使用 realm 校验服务器证书
+func newPasspointConnectionData(id, uuid string, ssid []byte, macAddress string,
+	cred *passpointCredential) (data connectionData, err error) {
+	cfg := cred.Eap
+	if cred.Realm != "" {
+		if cfg.DomainSuffixMatch == "" {
+			cfg.DomainSuffixMatch = cred.Realm
+		}
+		if cfg.AnonymousIdentity == "" && cfg.Method != "tls" {
+			cfg.AnonymousIdentity = "anonymous@" + cred.Realm
+		}
+	}
+	return newEapConnectionData(id, uuid, ssid, macAddress, &cfg)
+}
+
+// updateAccessPointsPasspoint 根据系统服务从 wpa_supplicant 读取的信息元素更新热点是否支持 Passpoint
+func (m *Manager) updateAccessPointsPasspoint(dev *device) {
+	iesMap, err := getSysBssIEs(dev.Interface)
+	if err != nil {
+		logger.Debug("failed to get bss information elements:", err)
+		return
+	}
+
+	m.accessPointsLock.Lock()
+	defer m.accessPointsLock.Unlock()
+	var changed bool
+	for _, ap := range m.accessPoints[dev.Path] {
+		isPasspoint := isPasspointIEs(iesMap[strings.ToLower(ap.Bssid)])
+		if ap.IsPasspoint == isPasspoint {
+			continue
+		}
+		ap.IsPasspoint = isPasspoint
+		m.apRevisions.touch(ap)
+		m.queueAccessPointChanged(ap)
+		changed = true
+	}
+	if changed {
+		m.PropsMu.Lock()
+		m.updatePropWirelessAccessPoints()
+		m.PropsMu.Unlock()
+	}
+}
+
+// CreatePasspointConnection create a connection for the Hotspot 2.0
+// (Passpoint) access point with the credential of the carrier and
+// activate it, credentialJSON is the marshaled passpointCredential
+// including realm, home OIs and EAP settings.
+func (m *Manager) CreatePasspointConnection(devPath, apPath dbus.ObjectPath, credentialJSON string) (connection dbus.ObjectPath,
+	busErr *dbus.Error) {
+	var cred passpointCredential
+	err := json.Unmarshal([]byte(credentialJSON), &cred)
+	if err != nil {
+		logger.Warning(err)
+		return "/", dbusutil.ToError(err)
+	}
+	cpath, err := m.createPasspointConnection(devPath, apPath, &cred)
+	if err != nil {
+		logger.Warning("failed to create passpoint connection:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+func (m *Manager) createPasspointConnection(devPath, apPath dbus.ObjectPath, cred *passpointCredential) (cpath dbus.ObjectPath,
+	err error) {
+	cpath = "/"
+	err = cred.check(time.Now())
+	if err != nil {
+		return
+	}
+	devType, i := m.getDeviceIndex(devPath)
+	if i < 0 || devType != deviceWifi {
+		err = fmt.Errorf("invalid wireless device %q", devPath)
+		return
+	}
+
+	m.accessPointsLock.Lock()
+	ap := m.getAccessPoint(devPath, apPath)
+	var ssid string
+	var rawSsid []byte
+	var bssid string
+	var isPasspoint bool
+	if ap != nil {
+		ssid, rawSsid, bssid, isPasspoint = ap.Ssid, ap.RawSsid, ap.Bssid, ap.IsPasspoint
+	}
+	m.accessPointsLock.Unlock()
+	if ap == nil {
+		err = fmt.Errorf("invalid access point %q", apPath)
+		return
+	}
+	if !isPasspoint {
+		err = fmt.Errorf("access point %s does not support passpoint", ssid)
+		return
+	}
+
+	if len(cred.HomeOIs) > 0 {
+		iesMap, err1 := getSysBssIEs(nmGetDeviceInterface(devPath))
+		if err1 != nil {
+			err = fmt.Errorf("failed to get roaming consortium of access point: %v", err1)
+			return
+		}
+		if !matchPasspointCredential(cred, parseRoamingConsortium(iesMap[strings.ToLower(bssid)])) {
+			err = fmt.Errorf("access point %s does not support the home OIs of the credential", ssid)
+			return
+		}
+	}
+
+	hwAddr, err := nmGeneralGetDeviceHwAddr(devPath, true)
+	if err != nil {
+		logger.Warning("failed to get mac", err)
+	}
+	logger.Infof("create passpoint connection for %s, realm: %s, home OIs: %v", ssid, cred.Realm, cred.HomeOIs)
+	data, err := newPasspointConnectionData(ssid, utils.GenUuid(), rawSsid, hwAddr, cred)
+	if err != nil {
+		return
+	}
+	m.applyDefaultWirelessZone(data)
+	cpath, _, err = nmAddAndActivateConnection(data, devPath, true)
+	return
+}
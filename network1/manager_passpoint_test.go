@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"time"
+
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestParsePasspointIEs(c *C.C) {
+	// SSID + Interworking + Roaming Consortium(2 个 ANQP OI，OI #1 3 字节，OI #2 5 字节，OI #3 3 字节)
+	ies := []byte{0, 3, 'a', 'b', 'c',
+		107, 1, 0x02,
+		111, 13, 2, 0x53, 0x50, 0x6f, 0x9a, 0x00, 0x1b, 0xc5, 0x04, 0xbd, 0x00, 0x40, 0x96}
+	c.Check(isPasspointIEs(ies), C.Equals, true)
+	c.Check(parseRoamingConsortium(ies), C.DeepEquals, []string{"506f9a", "001bc504bd", "004096"})
+
+	c.Check(isPasspointIEs([]byte{0, 3, 'a', 'b', 'c'}), C.Equals, false)
+	c.Check(parseRoamingConsortium([]byte{0, 3, 'a', 'b', 'c'}), C.HasLen, 0)
+	// 长度不合法
+	c.Check(isPasspointIEs([]byte{107, 5, 0}), C.Equals, false)
+	c.Check(parseRoamingConsortium([]byte{111, 3, 0, 0x05, 0x50}), C.HasLen, 0)
+}
+
+func (*testWrapper) TestPasspointCredential(c *C.C) {
+	cred := &passpointCredential{
+		HomeOIs: []string{"001BC504BD"},
+		Eap:     eapConfig{Method: "ttls", Identity: "user", Phase2Auth: "mschapv2"},
+	}
+	c.Check(cred.check(time.Now()), C.IsNil)
+	c.Check(cred.HomeOIs, C.DeepEquals, []string{"001bc504bd"})
+	c.Check(matchPasspointCredential(cred, []string{"506f9a", "001bc504bd"}), C.Equals, true)
+	c.Check(matchPasspointCredential(cred, []string{"506f9a"}), C.Equals, false)
+
+	c.Check((&passpointCredential{Eap: cred.Eap}).check(time.Now()), C.NotNil)
+	c.Check((&passpointCredential{HomeOIs: []string{"xyz"}, Eap: cred.Eap}).check(time.Now()), C.NotNil)
+
+	cred = &passpointCredential{
+		Realm: "example.com",
+		Eap:   eapConfig{Method: "ttls", Identity: "user@example.com", Phase2Auth: "mschapv2"},
+	}
+	c.Check(matchPasspointCredential(cred, nil), C.Equals, true)
+	data, err := newPasspointConnectionData("carrier", "uuid", []byte("carrier"), "", cred)
+	c.Assert(err, C.IsNil)
+	c.Check(getSetting8021xDomainSuffixMatch(data), C.Equals, "example.com")
+	c.Check(getSetting8021xAnonymousIdentity(data), C.Equals, "anonymous@example.com")
+}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"time"
+)
+
+// 同一 ssid 的热点之间自动切换频段的策略
+const (
+	// 不自动切换
+	roamingPolicyOff = "off"
+	// 当前 5G/6G 热点信号低于阈值时才切换到信号明显更好的热点
+	roamingPolicyPrefer5G = "prefer-5g"
+	// 只要有信号更好的热点就切换
+	roamingPolicyAggressive = "aggressive"
+
+	// 与之前的行为保持一致，默认优先切换到 5G 热点
+	defaultRoamingPolicy = roamingPolicyPrefer5G
+)
+
+const (
+	// 新热点的信号强度至少要比当前热点高出该值才切换
+	roamingStrengthMargin           = 20
+	aggressiveRoamingStrengthMargin = 10
+
+	checkAPStrengthDelay = 3 * time.Second
+)
+
+func isValidRoamingPolicy(policy string) bool {
+	switch policy {
+	case roamingPolicyOff, roamingPolicyPrefer5G, roamingPolicyAggressive:
+		return true
+	}
+	return false
+}
+
+// shouldRoam 判断当前热点是否需要切换到同一 ssid 中信号最强的热点
+func shouldRoam(policy string, threshold uint32, curStrength uint8, curFreq uint32, bestStrength uint8) bool {
+	switch policy {
+	case roamingPolicyPrefer5G:
+		// 当前信号比较好，无需切换
+		if uint32(curStrength) > threshold && isHighBandFrequency(curFreq) {
+			return false
+		}
+		return int(bestStrength) >= int(curStrength)+roamingStrengthMargin
+	case roamingPolicyAggressive:
+		return int(bestStrength) >= int(curStrength)+aggressiveRoamingStrengthMargin
+	}
+	return false
+}
+
+func (m *Manager) getRoamingPolicy() (policy string, threshold uint32) {
+	m.PropsMu.RLock()
+	defer m.PropsMu.RUnlock()
+	return m.RoamingPolicy, m.RoamingStrengthThreshold
+}
+
+// isCapacityRoamingEnabled 按容量切换热点需要管理员开启
+func (m *Manager) isCapacityRoamingEnabled() bool {
+	m.PropsMu.RLock()
+	defer m.PropsMu.RUnlock()
+	return m.capacityRoaming
+}
+
+// scheduleCheckAPStrength 扫描完成后延迟检查是否需要切换热点
+func (m *Manager) scheduleCheckAPStrength() {
+	policy, _ := m.getRoamingPolicy()
+	m.checkAPStrengthLock.Lock()
+	defer m.checkAPStrengthLock.Unlock()
+	if policy == roamingPolicyOff && m.debugChangeAPBand == "" {
+		return
+	}
+	if m.checkAPStrengthTimer != nil {
+		return
+	}
+	m.checkAPStrengthTimer = time.AfterFunc(checkAPStrengthDelay, m.checkAPStrength)
+}
+
+func (m *Manager) stopCheckAPStrength() {
+	m.checkAPStrengthLock.Lock()
+	defer m.checkAPStrengthLock.Unlock()
+	if m.checkAPStrengthTimer != nil {
+		m.checkAPStrengthTimer.Stop()
+		m.checkAPStrengthTimer = nil
+	}
+}
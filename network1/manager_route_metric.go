@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// route-metric 为 -1 时使用 NetworkManager 根据设备类型决定的默认值
+const defaultRouteMetric = -1
+
+// routingOrderItem 激活连接提供的默认路由，metric 越小越优先
+type routingOrderItem struct {
+	ConnectionUuid  string
+	ConnectionName  string
+	DeviceInterface string
+	// 没有默认路由时为空
+	IPv4Metric *uint32 `json:",omitempty"`
+	IPv6Metric *uint32 `json:",omitempty"`
+	// 当前是否提供默认路由
+	IPv4Default bool
+	IPv6Default bool
+}
+
+func checkRouteMetric(metric int64) error {
+	if metric < defaultRouteMetric || metric > math.MaxUint32 {
+		return fmt.Errorf("route metric %d is out of range, use -1 for default or 0-%d", metric, uint32(math.MaxUint32))
+	}
+	return nil
+}
+
+// getDefaultRouteMetric 返回 IP4Config/IP6Config 的 RouteData 中默认路由的最小 metric
+func getDefaultRouteMetric(routeData []map[string]dbus.Variant) (metric uint32, ok bool) {
+	for _, route := range routeData {
+		prefix, _ := route["prefix"].Value().(uint32)
+		if prefix != 0 {
+			continue
+		}
+		value, _ := route["metric"].Value().(uint32)
+		if !ok || value < metric {
+			metric, ok = value, true
+		}
+	}
+	return
+}
+
+// sortRoutingOrder 按默认路由的 metric 排序，ipv4 优先，没有默认路由的排在最后
+func sortRoutingOrder(items []*routingOrderItem) {
+	less := func(a, b *uint32) (less, equal bool) {
+		switch {
+		case a == nil && b == nil:
+			return false, true
+		case a == nil || b == nil:
+			return b == nil, false
+		}
+		return *a < *b, *a == *b
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if l, equal := less(items[i].IPv4Metric, items[j].IPv4Metric); !equal {
+			return l
+		}
+		l, _ := less(items[i].IPv6Metric, items[j].IPv6Metric)
+		return l
+	})
+}
+
+// SetConnectionRouteMetric set the route metric of the connection, the
+// connection with lower metric provides the default route when several
+// connections are activated, -1 means the default metric of the device
+// type. The changes take effect immediately if the connection is active.
+func (m *Manager) SetConnectionRouteMetric(uuid string, ipv4Metric, ipv6Metric int64) *dbus.Error {
+	err := m.setConnectionRouteMetric(uuid, ipv4Metric, ipv6Metric)
+	if err != nil {
+		logger.Warning("failed to set connection route metric:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setConnectionRouteMetric(uuid string, ipv4Metric, ipv6Metric int64) error {
+	err := checkRouteMetric(ipv4Metric)
+	if err != nil {
+		return fmt.Errorf("invalid ipv4 metric: %v", err)
+	}
+	err = checkRouteMetric(ipv6Metric)
+	if err != nil {
+		return fmt.Errorf("invalid ipv6 metric: %v", err)
+	}
+
+	cpath, data, err := m.getConnectionData(uuid)
+	if err != nil {
+		return err
+	}
+	if isSettingExists(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME) {
+		setSettingIP4ConfigRouteMetric(data, ipv4Metric)
+	}
+	if isSettingExists(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME) {
+		setSettingIP6ConfigRouteMetric(data, ipv6Metric)
+	}
+	err = updateConnectionData(cpath, data)
+	if err != nil {
+		return err
+	}
+
+	// 重新应用已激活的连接，使路由 metric 立即生效
+	nmReapplyActiveConnection(uuid)
+	return nil
+}
+
+// GetEffectiveRoutingOrder return the active connections ordered by the
+// metric of their default routes which marshaled by json, the first one
+// currently provides the default route.
+func (m *Manager) GetEffectiveRoutingOrder() (orderJSON string, busErr *dbus.Error) {
+	items := make([]*routingOrderItem, 0)
+	for _, apath := range nmGetActiveConnections() {
+		item := getRoutingOrderItem(apath)
+		if item != nil {
+			items = append(items, item)
+		}
+	}
+	sortRoutingOrder(items)
+	orderJSON, err := marshalJSON(items)
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	return orderJSON, nil
+}
+
+func getRoutingOrderItem(apath dbus.ObjectPath) *routingOrderItem {
+	aconn, err := nmNewActiveConnection(apath)
+	if err != nil {
+		return nil
+	}
+	item := &routingOrderItem{}
+	item.ConnectionUuid, _ = aconn.Uuid().Get(0)
+	item.ConnectionName, _ = aconn.Id().Get(0)
+	item.IPv4Default, _ = aconn.Default().Get(0)
+	item.IPv6Default, _ = aconn.Default6().Get(0)
+	if devPaths, _ := aconn.Devices().Get(0); len(devPaths) > 0 {
+		item.DeviceInterface = nmGetDeviceInterface(devPaths[0])
+	}
+
+	if ip4Path, _ := aconn.Ip4Config().Get(0); isNmObjectPathValid(ip4Path) {
+		if ip4Config, err := nmNewIP4Config(ip4Path); err == nil {
+			routeData, _ := ip4Config.RouteData().Get(0)
+			if metric, ok := getDefaultRouteMetric(routeData); ok {
+				item.IPv4Metric = &metric
+			}
+		}
+	}
+	if ip6Path, _ := aconn.Ip6Config().Get(0); isNmObjectPathValid(ip6Path) {
+		if ip6Config, err := nmNewIP6Config(ip6Path); err == nil {
+			routeData, _ := ip6Config.RouteData().Get(0)
+			if metric, ok := getDefaultRouteMetric(routeData); ok {
+				item.IPv6Metric = &metric
+			}
+		}
+	}
+	return item
+}
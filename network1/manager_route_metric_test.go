@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	dbus "github.com/godbus/dbus/v5"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestCheckRouteMetric(c *C.C) {
+	c.Check(checkRouteMetric(-1), C.IsNil)
+	c.Check(checkRouteMetric(0), C.IsNil)
+	c.Check(checkRouteMetric(4294967295), C.IsNil)
+	c.Check(checkRouteMetric(-2), C.NotNil)
+	c.Check(checkRouteMetric(4294967296), C.NotNil)
+}
+
+func (*testWrapper) TestGetDefaultRouteMetric(c *C.C) {
+	route := func(dest string, prefix, metric uint32) map[string]dbus.Variant {
+		return map[string]dbus.Variant{
+			"dest":   dbus.MakeVariant(dest),
+			"prefix": dbus.MakeVariant(prefix),
+			"metric": dbus.MakeVariant(metric),
+		}
+	}
+	_, ok := getDefaultRouteMetric([]map[string]dbus.Variant{route("192.168.1.0", 24, 100)})
+	c.Check(ok, C.Equals, false)
+
+	metric, ok := getDefaultRouteMetric([]map[string]dbus.Variant{
+		route("192.168.1.0", 24, 10),
+		route("0.0.0.0", 0, 600),
+		route("0.0.0.0", 0, 100),
+	})
+	c.Check(ok, C.Equals, true)
+	c.Check(metric, C.Equals, uint32(100))
+}
+
+func (*testWrapper) TestSortRoutingOrder(c *C.C) {
+	metric := func(v uint32) *uint32 { return &v }
+	items := []*routingOrderItem{
+		{ConnectionUuid: "none"},
+		{ConnectionUuid: "wifi", IPv4Metric: metric(600)},
+		{ConnectionUuid: "ipv6", IPv6Metric: metric(100)},
+		{ConnectionUuid: "wired", IPv4Metric: metric(100)},
+	}
+	sortRoutingOrder(items)
+	var uuids []string
+	for _, item := range items {
+		uuids = append(uuids, item.ConnectionUuid)
+	}
+	c.Check(uuids, C.DeepEquals, []string{"wired", "wifi", "ipv6", "none"})
+}
@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"fmt"
+	"sort"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+type savedWirelessNetwork struct {
+	Path        dbus.ObjectPath
+	Uuid        string
+	Id          string
+	Ssid        string
+	Hidden      bool
+	KeyMgmt     string
+	Autoconnect bool
+	// 最近一次成功激活的时间，0 表示从未连接过
+	Timestamp uint64
+	Active    bool
+}
+
+// sortSavedWirelessNetworks 最近使用的网络排在前面
+func sortSavedWirelessNetworks(networks []*savedWirelessNetwork) {
+	sort.SliceStable(networks, func(i, j int) bool {
+		if networks[i].Timestamp != networks[j].Timestamp {
+			return networks[i].Timestamp > networks[j].Timestamp
+		}
+		return networks[i].Id < networks[j].Id
+	})
+}
+
+// matchSavedWirelessNetworks 优先按 uuid 匹配，否则返回 ssid 相同的所有网络
+func matchSavedWirelessNetworks(networks []*savedWirelessNetwork, ssidOrUuid string) (result []*savedWirelessNetwork) {
+	for _, network := range networks {
+		if network.Uuid == ssidOrUuid {
+			return []*savedWirelessNetwork{network}
+		}
+	}
+	for _, network := range networks {
+		if network.Ssid == ssidOrUuid {
+			result = append(result, network)
+		}
+	}
+	return
+}
+
+func (m *Manager) getSavedWirelessNetworks() []*savedWirelessNetwork {
+	m.connectionsLock.Lock()
+	conns := make(connectionSlice, len(m.connections[connectionWireless]))
+	copy(conns, m.connections[connectionWireless])
+	m.connectionsLock.Unlock()
+
+	networks := make([]*savedWirelessNetwork, 0, len(conns))
+	for _, conn := range conns {
+		data, err := nmGetConnectionData(conn.Path)
+		if err != nil {
+			logger.Warning(err)
+			continue
+		}
+		apaths, _ := nmGetActiveConnectionByUuid(conn.Uuid)
+		networks = append(networks, &savedWirelessNetwork{
+			Path:        conn.Path,
+			Uuid:        conn.Uuid,
+			Id:          conn.Id,
+			Ssid:        conn.Ssid,
+			Hidden:      conn.Hidden,
+			KeyMgmt:     getSettingVkWirelessSecurityKeyMgmt(data),
+			Autoconnect: getSettingConnectionAutoconnect(data),
+			Timestamp:   getSettingConnectionTimestamp(data),
+			Active:      len(apaths) > 0,
+		})
+	}
+	sortSavedWirelessNetworks(networks)
+	return networks
+}
+
+// ListSavedWirelessNetworks return all saved wireless networks which
+// marshaled by json, the most recently used network comes first.
+func (m *Manager) ListSavedWirelessNetworks() (networksJSON string, busErr *dbus.Error) {
+	networksJSON, err := marshalJSON(m.getSavedWirelessNetworks())
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	return networksJSON, nil
+}
+
+// ForgetWirelessNetwork delete the saved wireless network with the
+// uuid, or all saved wireless networks with the ssid.
+func (m *Manager) ForgetWirelessNetwork(ssidOrUuid string) *dbus.Error {
+	networks := matchSavedWirelessNetworks(m.getSavedWirelessNetworks(), ssidOrUuid)
+	if len(networks) == 0 {
+		err := fmt.Errorf("saved wireless network %q not found", ssidOrUuid)
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	err := m.forgetWirelessNetworks(networks)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+// ForgetAllWirelessNetworks delete all saved wireless networks.
+func (m *Manager) ForgetAllWirelessNetworks() *dbus.Error {
+	err := m.forgetWirelessNetworks(m.getSavedWirelessNetworks())
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+// forgetWirelessNetworks 删除失败时继续删除其他网络，返回最后一个错误
+func (m *Manager) forgetWirelessNetworks(networks []*savedWirelessNetwork) (err error) {
+	for _, network := range networks {
+		logger.Infof("forget wireless network %q, uuid=%s", network.Ssid, network.Uuid)
+		nmConn, err1 := nmNewSettingsConnection(network.Path)
+		if err1 == nil {
+			err1 = nmConn.Delete(0)
+		}
+		if err1 != nil {
+			logger.Warningf("failed to delete connection %s: %v", network.Uuid, err1)
+			err = err1
+		}
+	}
+	return
+}
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestSortSavedWirelessNetworks(c *C.C) {
+	networks := []*savedWirelessNetwork{
+		{Id: "b", Timestamp: 0},
+		{Id: "c", Timestamp: 100},
+		{Id: "a", Timestamp: 0},
+		{Id: "d", Timestamp: 200},
+	}
+	sortSavedWirelessNetworks(networks)
+	var ids []string
+	for _, network := range networks {
+		ids = append(ids, network.Id)
+	}
+	c.Check(ids, C.DeepEquals, []string{"d", "c", "a", "b"})
+}
+
+func (*testWrapper) TestMatchSavedWirelessNetworks(c *C.C) {
+	networks := []*savedWirelessNetwork{
+		{Uuid: "uuid-1", Ssid: "deepin"},
+		{Uuid: "uuid-2", Ssid: "deepin"},
+		{Uuid: "uuid-3", Ssid: "uuid-1"},
+	}
+	// uuid 优先于 ssid
+	c.Check(matchSavedWirelessNetworks(networks, "uuid-1"), C.DeepEquals, networks[:1])
+	c.Check(matchSavedWirelessNetworks(networks, "deepin"), C.DeepEquals, networks[:2])
+	c.Check(matchSavedWirelessNetworks(networks, "unknown"), C.HasLen, 0)
+}
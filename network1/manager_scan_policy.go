@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	power "github.com/linuxdeepin/go-dbus-factory/system/org.deepin.dde.power1"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// 默认不进行后台扫描，NetworkManager 本身会定期扫描
+const defaultWirelessScanInterval time.Duration = 0
+
+const (
+	defaultWirelessScanIntervalOnBattery = 60 * time.Second
+	minWirelessScanInterval              = 5 * time.Second
+	// 当前连接的热点信号强度不低于该值时认为连接良好，不再后台扫描
+	healthyWirelessStrength = 50
+)
+
+// getWirelessScanInterval 返回当前的扫描间隔，使用电池时使用更长的间隔，为 0 时不进行后台扫描
+func (m *Manager) getWirelessScanInterval() time.Duration {
+	m.scanPolicyLock.Lock()
+	defer m.scanPolicyLock.Unlock()
+	if m.scanInterval != 0 && m.onBattery && m.scanIntervalOnBattery > m.scanInterval {
+		return m.scanIntervalOnBattery
+	}
+	return m.scanInterval
+}
+
+func (m *Manager) setWirelessScanIntervals(interval, intervalOnBattery time.Duration) {
+	m.scanPolicyLock.Lock()
+	m.scanInterval = interval
+	m.scanIntervalOnBattery = intervalOnBattery
+	m.scanPolicyLock.Unlock()
+	m.scheduleWirelessScan()
+}
+
+func (m *Manager) setOnBattery(onBattery bool) {
+	m.scanPolicyLock.Lock()
+	changed := m.onBattery != onBattery
+	m.onBattery = onBattery
+	m.scanPolicyLock.Unlock()
+	if changed {
+		logger.Debug("on battery changed:", onBattery)
+		m.scheduleWirelessScan()
+	}
+}
+
+func (m *Manager) initWirelessScanPolicy(systemBus *dbus.Conn) {
+	m.sysPower = power.NewPower(systemBus)
+	onBattery, err := m.sysPower.OnBattery().Get(0)
+	if err != nil {
+		logger.Warning(err)
+	}
+	m.setOnBattery(onBattery)
+
+	m.sysPower.InitSignalExt(m.sysSigLoop, true)
+	err = m.sysPower.OnBattery().ConnectChanged(func(hasValue bool, value bool) {
+		if !hasValue {
+			return
+		}
+		m.setOnBattery(value)
+	})
+	if err != nil {
+		logger.Warning(err)
+	}
+	m.scheduleWirelessScan()
+}
+
+func (m *Manager) destroyWirelessScanPolicy() {
+	if m.sysPower != nil {
+		m.sysPower.RemoveAllHandlers()
+	}
+	m.scanPolicyLock.Lock()
+	if m.scanTimer != nil {
+		m.scanTimer.Stop()
+		m.scanTimer = nil
+	}
+	m.scanPolicyLock.Unlock()
+}
+
+// scheduleWirelessScan 按当前的扫描间隔重新安排下一次后台扫描
+func (m *Manager) scheduleWirelessScan() {
+	interval := m.getWirelessScanInterval()
+
+	m.scanPolicyLock.Lock()
+	defer m.scanPolicyLock.Unlock()
+	if m.scanTimer != nil {
+		m.scanTimer.Stop()
+		m.scanTimer = nil
+	}
+	if interval == 0 {
+		return
+	}
+	m.scanTimer = time.AfterFunc(interval, func() {
+		m.doBackgroundWirelessScan()
+		m.scheduleWirelessScan()
+	})
+}
+
+func (m *Manager) doBackgroundWirelessScan() {
+	// 设备状态在 devicesLock 中更新，需要在锁内读取
+	type wirelessDeviceState struct {
+		dev      *device
+		state    uint32
+		activeAp dbus.ObjectPath
+	}
+	m.devicesLock.Lock()
+	states := make([]wirelessDeviceState, 0, len(m.devices[deviceWifi]))
+	for _, dev := range m.devices[deviceWifi] {
+		states = append(states, wirelessDeviceState{dev: dev, state: dev.State, activeAp: dev.ActiveAp})
+	}
+	m.devicesLock.Unlock()
+
+	for _, s := range states {
+		if m.isWirelessDeviceHealthy(s.dev.Path, s.state, s.activeAp) {
+			continue
+		}
+		err := s.dev.nmDev.Wireless().RequestScan(0, nil)
+		if err != nil {
+			logger.Debug(err)
+		}
+	}
+}
+
+// isWirelessDeviceHealthy 设备已连接且热点信号良好时无需后台扫描
+func (m *Manager) isWirelessDeviceHealthy(devPath dbus.ObjectPath, state uint32, activeAp dbus.ObjectPath) bool {
+	if state != nm.NM_DEVICE_STATE_ACTIVATED || !isObjPathValid(activeAp) {
+		return false
+	}
+	m.accessPointsLock.Lock()
+	defer m.accessPointsLock.Unlock()
+	for _, ap := range m.accessPoints[devPath] {
+		if ap.Path == activeAp {
+			return ap.Strength >= healthyWirelessStrength
+		}
+	}
+	return false
+}
+
+// SetWirelessScanInterval set the background wireless scan interval in
+// seconds, 0 means disable background scans. The interval on battery
+// is never shorter than this.
+func (m *Manager) SetWirelessScanInterval(interval uint32) *dbus.Error {
+	if interval != 0 && time.Duration(interval)*time.Second < minWirelessScanInterval {
+		err := fmt.Errorf("scan interval should not be less than %v", minWirelessScanInterval)
+		return dbusutil.ToError(err)
+	}
+	if m.networkConfig == nil {
+		return dbusutil.ToError(errors.New("network dconfig is not available"))
+	}
+	err := m.networkConfig.SetValue(0, dsettingsWirelessScanInterval, dbus.MakeVariant(interval))
+	if err != nil {
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+
+	m.scanPolicyLock.Lock()
+	intervalOnBattery := m.scanIntervalOnBattery
+	m.scanPolicyLock.Unlock()
+	m.setWirelessScanIntervals(time.Duration(interval)*time.Second, intervalOnBattery)
+	return nil
+}
@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"errors"
+	"fmt"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+const (
+	// 密码由 NetworkManager 保存在系统连接文件中，所有用户可用
+	secretModeSystem = "system"
+	// 密码由 secret agent 保存在当前用户的密钥环中
+	secretModeAgent = "agent"
+)
+
+func getSecretModeFlag(mode string) (flag uint32, err error) {
+	switch mode {
+	case secretModeSystem:
+		return secretFlagNone, nil
+	case secretModeAgent:
+		return secretFlagAgentOwned, nil
+	}
+	return 0, fmt.Errorf("invalid secret mode %q", mode)
+}
+
+// getConnectionSecretMode 根据连接中已设置的密码标志判断密码的保存方式
+func getConnectionSecretMode(data connectionData) string {
+	for settingName, secretKeys := range secretSettingKeys {
+		if !isSettingExists(data, settingName) {
+			continue
+		}
+		for _, key := range secretKeys {
+			flags, ok := getConnectionDataUint32(data, settingName, getSecretFlagsKeyName(key))
+			if ok && flags == secretFlagAgentOwned {
+				return secretModeAgent
+			}
+		}
+	}
+	return secretModeSystem
+}
+
+// applySecretMode 将密码写回连接数据并修改对应的密码标志，
+// 总是询问和不需要的密码保持不变
+func applySecretMode(data connectionData, secrets map[string]map[string]string, flag uint32) (changed bool) {
+	for settingName, secretKeys := range secretSettingKeys {
+		if !isSettingExists(data, settingName) {
+			continue
+		}
+		for _, key := range secretKeys {
+			flagsKey := getSecretFlagsKeyName(key)
+			flags, _ := getConnectionDataUint32(data, settingName, flagsKey)
+			if flags != secretFlagNone && flags != secretFlagAgentOwned {
+				continue
+			}
+			value, ok := secrets[settingName][key]
+			if !ok {
+				continue
+			}
+			data[settingName][key] = dbus.MakeVariant(value)
+			data[settingName][flagsKey] = dbus.MakeVariant(flag)
+			changed = true
+		}
+	}
+	return
+}
+
+// GetConnectionSecretMode get where the secrets of the connection are
+// stored, "system" for the system connection file and "agent" for the
+// keyring of the current user.
+func (m *Manager) GetConnectionSecretMode(uuid string) (mode string, busErr *dbus.Error) {
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	data, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return getConnectionSecretMode(data), nil
+}
+
+// SetConnectionSecretMode move the secrets of the connection between the
+// system connection file and the keyring of the current user, mode is
+// "system" or "agent". Secrets missing from both places will be asked by
+// the secret agent on the next activation.
+func (m *Manager) SetConnectionSecretMode(uuid, mode string) *dbus.Error {
+	err := m.setConnectionSecretMode(uuid, mode)
+	if err != nil {
+		logger.Warning("failed to set connection secret mode:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setConnectionSecretMode(uuid, mode string) (err error) {
+	flag, err := getSecretModeFlag(mode)
+	if err != nil {
+		return
+	}
+	if m.secretAgent == nil {
+		return errors.New("secret agent is not ready")
+	}
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		return
+	}
+	data, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return
+	}
+	if getConnectionSecretMode(data) == mode {
+		return nil
+	}
+
+	// 系统保存的密码由 NetworkManager 提供，用户保存的密码从密钥环中读取
+	secrets := make(map[string]map[string]string)
+	for settingName := range secretSettingKeys {
+		if !isSettingExists(data, settingName) {
+			continue
+		}
+		values := make(map[string]string)
+		systemSecrets, err := nmGetConnectionSecrets(cpath, settingName)
+		if err != nil {
+			logger.Debug(err)
+		}
+		for key, value := range systemSecrets[settingName] {
+			if str, ok := value.Value().(string); ok && str != "" {
+				values[key] = str
+			}
+		}
+		agentSecrets, err := m.secretAgent.getAll(uuid, settingName)
+		if err != nil {
+			logger.Debug(err)
+		}
+		for key, value := range agentSecrets {
+			if _, ok := values[key]; !ok {
+				values[key] = value
+			}
+		}
+		secrets[settingName] = values
+	}
+
+	if !applySecretMode(data, secrets, flag) {
+		return fmt.Errorf("connection %s has no secret to move", uuid)
+	}
+
+	logger.Infof("set secret mode of connection %s to %s", uuid, mode)
+	// 更新后 NetworkManager 会通过 SaveSecrets 把用户保存的密码交给 secret agent 写入密钥环，
+	// 并删除密钥环中已不再由 agent 保存的密码
+	return updateConnectionData(cpath, data)
+}
@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestConnectionSecretMode(c *C.C) {
+	data := newWirelessConnectionData("deepin", "uuid", []byte("deepin"), "wpa-psk", "")
+	c.Check(getConnectionSecretMode(data), C.Equals, secretModeSystem)
+
+	secrets := map[string]map[string]string{
+		nm.NM_SETTING_WIRELESS_SECURITY_SETTING_NAME: {nm.NM_SETTING_WIRELESS_SECURITY_PSK: "12345678"},
+	}
+	c.Check(applySecretMode(data, secrets, secretFlagAgentOwned), C.Equals, true)
+	c.Check(getConnectionSecretMode(data), C.Equals, secretModeAgent)
+	c.Check(getSettingWirelessSecurityPsk(data), C.Equals, "12345678")
+
+	c.Check(applySecretMode(data, nil, secretFlagNone), C.Equals, false)
+	c.Check(applySecretMode(data, secrets, secretFlagNone), C.Equals, true)
+	c.Check(getConnectionSecretMode(data), C.Equals, secretModeSystem)
+
+	// 总是询问的密码不会被移动
+	setSettingWirelessSecurityPskFlags(data, nm.NM_SETTING_SECRET_FLAG_NOT_SAVED)
+	c.Check(applySecretMode(data, secrets, secretFlagAgentOwned), C.Equals, false)
+
+	_, err := getSecretModeFlag("keyring")
+	c.Check(err, C.NotNil)
+}
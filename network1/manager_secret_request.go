@@ -0,0 +1,221 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// 由锁屏、greeter 等客户端实现的接口，用于代替密码对话框输入密码
+const (
+	secretRequestInterface     = "org.deepin.dde.Network1.SecretRequest"
+	secretRequestMethodRequest = secretRequestInterface + ".RequestSecrets"
+	secretRequestMethodCancel  = secretRequestInterface + ".CancelSecrets"
+
+	secretRequestTimeout       = 2 * time.Minute
+	secretRequestCancelTimeout = 5 * time.Second
+)
+
+// 客户端已退出或未实现接口时的错误
+const (
+	dbusErrServiceUnknown   = "org.freedesktop.DBus.Error.ServiceUnknown"
+	dbusErrNameHasNoOwner   = "org.freedesktop.DBus.Error.NameHasNoOwner"
+	dbusErrUnknownObject    = "org.freedesktop.DBus.Error.UnknownObject"
+	dbusErrUnknownMethod    = "org.freedesktop.DBus.Error.UnknownMethod"
+	dbusErrUnknownInterface = "org.freedesktop.DBus.Error.UnknownInterface"
+)
+
+var secretRequestIdSeq uint64
+
+type secretRequestAgent struct {
+	owner string
+	path  dbus.ObjectPath
+}
+
+type pendingSecretRequest struct {
+	id     string
+	cancel context.CancelFunc
+}
+
+func newSecretRequestId() string {
+	return "secret-request-" + strconv.FormatUint(atomic.AddUint64(&secretRequestIdSeq, 1), 10)
+}
+
+// isSecretRequestAgentGone 客户端已退出或没有实现 SecretRequest 接口
+func isSecretRequestAgentGone(err error) bool {
+	var busErr dbus.Error
+	if !errors.As(err, &busErr) {
+		return false
+	}
+	switch busErr.Name {
+	case dbusErrServiceUnknown, dbusErrNameHasNoOwner, dbusErrUnknownObject,
+		dbusErrUnknownMethod, dbusErrUnknownInterface:
+		return true
+	}
+	return false
+}
+
+// getSecretRequestAllowlist 允许注册 SecretRequest 的程序，值为可执行文件的绝对路径
+func (m *Manager) getSecretRequestAllowlist() (allowlist []string) {
+	if m.networkConfig == nil {
+		return nil
+	}
+	v, err := m.networkConfig.Value(0, dsettingsSecretRequestAllowlist)
+	if err != nil {
+		logger.Warning(err)
+		return nil
+	}
+	items, _ := v.Value().([]dbus.Variant)
+	for _, item := range items {
+		if exe, ok := item.Value().(string); ok && exe != "" {
+			allowlist = append(allowlist, exe)
+		}
+	}
+	return
+}
+
+// checkSecretRequestCaller 只允许列表中的程序代替密码对话框接收密码，
+// 只比较绝对路径，避免其他程序使用相同的文件名冒充
+func (m *Manager) checkSecretRequestCaller(sender dbus.Sender) error {
+	pid, err := m.service.GetConnPID(string(sender))
+	if err != nil {
+		return err
+	}
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return err
+	}
+	for _, item := range m.getSecretRequestAllowlist() {
+		if item == exe {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not allowed to register secret request", exe)
+}
+
+func (m *Manager) getSecretRequestAgent() *secretRequestAgent {
+	m.secretRequestLock.Lock()
+	defer m.secretRequestLock.Unlock()
+	return m.secretRequestAgent
+}
+
+// RegisterSecretRequest register the object at path of the caller, which
+// implements org.deepin.dde.Network1.SecretRequest, to answer the password
+// prompts instead of the legacy secret dialog. The daemon calls its
+// RequestSecrets(requestId, requestJSON) -> secrets method and waits for
+// the reply at most 2 minutes, then calls CancelSecrets(requestId) if the
+// request is timed out or canceled by NetworkManager. Only the last
+// registered object is used, and only the programs in the
+// secretRequestAllowlist config are allowed to register.
+func (m *Manager) RegisterSecretRequest(sender dbus.Sender, path dbus.ObjectPath) *dbus.Error {
+	if !path.IsValid() {
+		return dbusutil.ToError(errors.New("invalid object path"))
+	}
+	err := m.checkSecretRequestCaller(sender)
+	if err != nil {
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	m.secretRequestLock.Lock()
+	m.secretRequestAgent = &secretRequestAgent{
+		owner: string(sender),
+		path:  path,
+	}
+	m.secretRequestLock.Unlock()
+	logger.Infof("register secret request %s %s", sender, path)
+	return nil
+}
+
+// UnregisterSecretRequest unregister the SecretRequest object of the
+// caller, the password prompts fall back to the legacy secret dialog.
+func (m *Manager) UnregisterSecretRequest(sender dbus.Sender) *dbus.Error {
+	m.unregisterSecretRequest(string(sender))
+	return nil
+}
+
+func (m *Manager) unregisterSecretRequest(owner string) {
+	m.secretRequestLock.Lock()
+	defer m.secretRequestLock.Unlock()
+	if m.secretRequestAgent != nil && m.secretRequestAgent.owner == owner {
+		logger.Info("unregister secret request", owner)
+		m.secretRequestAgent = nil
+	}
+}
+
+func (sa *SecretAgent) addSecretRequest(key saveSecretsTaskKey, req *pendingSecretRequest) {
+	sa.secretRequestsMu.Lock()
+	old := sa.secretRequests[key]
+	sa.secretRequests[key] = req
+	sa.secretRequestsMu.Unlock()
+	// 同一个连接的上一次请求还没有结束时取消它
+	if old != nil {
+		old.cancel()
+	}
+}
+
+func (sa *SecretAgent) removeSecretRequest(key saveSecretsTaskKey, id string) {
+	sa.secretRequestsMu.Lock()
+	if req := sa.secretRequests[key]; req != nil && req.id == id {
+		delete(sa.secretRequests, key)
+	}
+	sa.secretRequestsMu.Unlock()
+}
+
+func (sa *SecretAgent) cancelSecretRequest(key saveSecretsTaskKey) {
+	sa.secretRequestsMu.Lock()
+	req := sa.secretRequests[key]
+	delete(sa.secretRequests, key)
+	sa.secretRequestsMu.Unlock()
+	if req != nil {
+		logger.Debug("cancel secret request", req.id)
+		req.cancel()
+	}
+}
+
+// requestSecrets 通过客户端的 SecretRequest 接口获取密码，返回的密码与请求中的 Secrets 一一对应
+func (sa *SecretAgent) requestSecrets(agent *secretRequestAgent, connPath dbus.ObjectPath,
+	settingName string, reqJSON []byte) ([]string, error) {
+	key := saveSecretsTaskKey{
+		connPath:    connPath,
+		settingName: settingName,
+	}
+	id := newSecretRequestId()
+	ctx, cancel := context.WithTimeout(context.Background(), secretRequestTimeout)
+	defer cancel()
+	sa.addSecretRequest(key, &pendingSecretRequest{
+		id:     id,
+		cancel: cancel,
+	})
+	defer sa.removeSecretRequest(key, id)
+
+	logger.Debugf("request secrets %s from %s %s", id, agent.owner, agent.path)
+	obj := sa.sessionSigLoop.Conn().Object(agent.owner, agent.path)
+	var secrets []string
+	err := obj.CallWithContext(ctx, secretRequestMethodRequest, 0, id, string(reqJSON)).Store(&secrets)
+	if err == nil {
+		return secrets, nil
+	}
+	if ctx.Err() != nil {
+		// 超时或 NetworkManager 取消了请求，通知客户端关闭密码输入
+		logger.Infof("secret request %s is canceled: %v", id, ctx.Err())
+		cancelCtx, cancelCancel := context.WithTimeout(context.Background(), secretRequestCancelTimeout)
+		defer cancelCancel()
+		cancelErr := obj.CallWithContext(cancelCtx, secretRequestMethodCancel, 0, id).Err
+		if cancelErr != nil {
+			logger.Warning("failed to cancel secret request:", cancelErr)
+		}
+		return nil, errSecretAgentUserCanceled
+	}
+	return nil, err
+}
@@ -0,0 +1,54 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"context"
+	"errors"
+
+	dbus "github.com/godbus/dbus/v5"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestIsSecretRequestAgentGone(c *C.C) {
+	c.Check(isSecretRequestAgentGone(nil), C.Equals, false)
+	c.Check(isSecretRequestAgentGone(errors.New(dbusErrServiceUnknown)), C.Equals, false)
+	c.Check(isSecretRequestAgentGone(dbus.Error{Name: dbusErrServiceUnknown}), C.Equals, true)
+	c.Check(isSecretRequestAgentGone(dbus.Error{Name: dbusErrUnknownMethod}), C.Equals, true)
+	c.Check(isSecretRequestAgentGone(dbus.Error{Name: "org.freedesktop.DBus.Error.Failed"}), C.Equals, false)
+}
+
+func (*testWrapper) TestBuildAskPasswordsResult(c *C.C) {
+	result, err := buildAskPasswordsResult([]string{"identity", "password"}, []string{"user", "secret"})
+	c.Assert(err, C.IsNil)
+	c.Check(result, C.DeepEquals, map[string]string{"identity": "user", "password": "secret"})
+
+	_, err = buildAskPasswordsResult([]string{"psk"}, nil)
+	c.Check(err, C.NotNil)
+}
+
+func (*testWrapper) TestPendingSecretRequests(c *C.C) {
+	sa := &SecretAgent{
+		secretRequests: make(map[saveSecretsTaskKey]*pendingSecretRequest),
+	}
+	key := saveSecretsTaskKey{connPath: "/org/freedesktop/NetworkManager/Settings/1", settingName: "802-11-wireless-security"}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	sa.addSecretRequest(key, &pendingSecretRequest{id: "1", cancel: cancel1})
+	// 同一个连接的新请求会取消旧的请求
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	sa.addSecretRequest(key, &pendingSecretRequest{id: "2", cancel: cancel2})
+	c.Check(ctx1.Err(), C.NotNil)
+	c.Check(ctx2.Err(), C.IsNil)
+
+	// 旧请求结束时不能删除新请求
+	sa.removeSecretRequest(key, "1")
+	c.Check(sa.secretRequests[key], C.NotNil)
+
+	sa.cancelSecretRequest(key)
+	c.Check(ctx2.Err(), C.NotNil)
+	c.Check(sa.secretRequests, C.HasLen, 0)
+	c.Check(newSecretRequestId(), C.Not(C.Equals), newSecretRequestId())
+}
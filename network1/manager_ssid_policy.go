@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"fmt"
+
+	"github.com/linuxdeepin/dde-daemon/common/ssidpolicy"
+)
+
+func (m *Manager) isSsidAllowed(ssid string) bool {
+	m.ssidPolicyLock.Lock()
+	defer m.ssidPolicyLock.Unlock()
+	return m.ssidPolicy.Allows(ssid)
+}
+
+func (m *Manager) checkSsidAllowed(ssid string) error {
+	if !m.isSsidAllowed(ssid) {
+		return fmt.Errorf("wireless network %q is not allowed by the administrator", ssid)
+	}
+	return nil
+}
+
+func (m *Manager) setSsidPolicy(blocklist, allowlist []string) {
+	m.ssidPolicyLock.Lock()
+	m.ssidPolicy = ssidpolicy.Policy{Blocklist: blocklist, Allowlist: allowlist}
+	m.ssidPolicyLock.Unlock()
+	logger.Infof("ssid policy, blocklist: %v, allowlist: %v", blocklist, allowlist)
+}
+
+// applySsidPolicy 重新过滤热点列表，不允许的无线连接由系统服务断开
+func (m *Manager) applySsidPolicy() {
+	m.devicesLock.Lock()
+	devices := make([]*device, len(m.devices[deviceWifi]))
+	copy(devices, m.devices[deviceWifi])
+	m.devicesLock.Unlock()
+
+	m.accessPointsLock.Lock()
+	for _, dev := range devices {
+		for i := len(m.accessPoints[dev.Path]) - 1; i >= 0; i-- {
+			if !m.isSsidAllowed(m.accessPoints[dev.Path][i].Ssid) {
+				m.accessPoints[dev.Path] = m.doRemoveAccessPoint(m.accessPoints[dev.Path], i)
+			}
+		}
+		m.syncAccessPoints(dev.Path, nmGetAccessPoints(dev.Path), false)
+	}
+	m.PropsMu.Lock()
+	m.updatePropWirelessAccessPoints()
+	m.PropsMu.Unlock()
+	m.accessPointsLock.Unlock()
+}
@@ -6,6 +6,7 @@ package network
 
 import (
 	"errors"
+	"fmt"
 
 	dbus "github.com/godbus/dbus/v5"
 	"github.com/linuxdeepin/dde-daemon/network1/nm"
@@ -52,9 +53,97 @@ func (m *Manager) vpnEnabledWriteCb(write *dbusutil.PropertyWrite) *dbus.Error {
 	return nil
 }
 
+func (m *Manager) accessPointStrengthThresholdWriteCb(write *dbusutil.PropertyWrite) *dbus.Error {
+	threshold, ok := write.Value.(uint32)
+	if !ok {
+		err := errors.New("type of value is not uint32")
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	if threshold > 100 {
+		err := fmt.Errorf("invalid threshold %d", threshold)
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+
+	if m.networkConfig != nil {
+		err := m.networkConfig.SetValue(0, dsettingsAccessPointStrengthThreshold, dbus.MakeVariant(threshold))
+		if err != nil {
+			logger.Warning(err)
+			return dbusutil.ToError(err)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) roamingPolicyWriteCb(write *dbusutil.PropertyWrite) *dbus.Error {
+	policy, ok := write.Value.(string)
+	if !ok {
+		err := errors.New("type of value is not string")
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	if !isValidRoamingPolicy(policy) {
+		err := fmt.Errorf("invalid roaming policy %q", policy)
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+
+	if m.networkConfig != nil {
+		err := m.networkConfig.SetValue(0, dsettingsRoamingPolicy, dbus.MakeVariant(policy))
+		if err != nil {
+			logger.Warning(err)
+			return dbusutil.ToError(err)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) roamingStrengthThresholdWriteCb(write *dbusutil.PropertyWrite) *dbus.Error {
+	threshold, ok := write.Value.(uint32)
+	if !ok {
+		err := errors.New("type of value is not uint32")
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	if threshold > 100 {
+		err := fmt.Errorf("invalid threshold %d", threshold)
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+
+	if m.networkConfig != nil {
+		err := m.networkConfig.SetValue(0, dsettingsRoamingStrengthThreshold, dbus.MakeVariant(threshold))
+		if err != nil {
+			logger.Warning(err)
+			return dbusutil.ToError(err)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) showWeakAccessPointsWriteCb(write *dbusutil.PropertyWrite) *dbus.Error {
+	show, ok := write.Value.(bool)
+	if !ok {
+		err := errors.New("type of value is not bool")
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+
+	if m.networkConfig != nil {
+		err := m.networkConfig.SetValue(0, dsettingsShowWeakAccessPoints, dbus.MakeVariant(show))
+		if err != nil {
+			logger.Warning(err)
+			return dbusutil.ToError(err)
+		}
+	}
+	return nil
+}
+
 func (m *Manager) updatePropActiveConnections() {
 	activeConnections, _ := marshalJSON(m.activeConnections)
 	m.setPropActiveConnections(activeConnections)
+	go m.checkWifiOffOnWired()
 }
 
 func (m *Manager) updatePropState() {
@@ -85,6 +174,10 @@ func (m *Manager) updatePropConnections() {
 }
 
 func (m *Manager) updatePropWirelessAccessPoints() {
-	aps, _ := marshalJSON(m.accessPoints)
+	accessPoints := m.accessPoints
+	if !m.ShowWeakAccessPoints {
+		accessPoints = filterWeakAccessPoints(accessPoints)
+	}
+	aps, _ := marshalJSON(accessPoints)
 	m.setPropWirelessAccessPoints(aps)
 }
@@ -0,0 +1,219 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"fmt"
+	"sort"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	nmdbus "github.com/linuxdeepin/go-dbus-factory/system/org.freedesktop.networkmanager"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// 手机共享网络的方式
+const (
+	tetheringTypeUsb       = "usb"
+	tetheringTypeBluetooth = "bluetooth"
+)
+
+// 手机通过 USB 共享网络时使用的网卡驱动，RNDIS 为 Android，ipheth 为 iPhone，
+// cdc_ether 和 cdc_ncm 也用于普通的 USB 有线网卡，不能用来判断
+var usbTetheringDrivers = []string{
+	"rndis_host",
+	"ipheth",
+}
+
+type tetheringDevice struct {
+	Path      dbus.ObjectPath
+	Interface string
+	Type      string
+	Name      string
+	// 用于共享网络的连接的 uuid
+	Uuid string
+}
+
+// getTetheringType 判断设备是否为手机共享网络的设备，不是时返回空字符串
+func getTetheringType(devType uint32, driver string, btCapabilities uint32) string {
+	switch devType {
+	case nm.NM_DEVICE_TYPE_ETHERNET:
+		if isStringInArray(driver, usbTetheringDrivers) {
+			return tetheringTypeUsb
+		}
+	case nm.NM_DEVICE_TYPE_BT:
+		if btCapabilities&nm.NM_BT_CAPABILITY_NAP != 0 {
+			return tetheringTypeBluetooth
+		}
+	}
+	return ""
+}
+
+func newBluetoothPanConnectionData(id, uuid string, bdaddr []byte) (data connectionData) {
+	data = make(connectionData)
+
+	addSetting(data, nm.NM_SETTING_CONNECTION_SETTING_NAME)
+	setSettingConnectionId(data, id)
+	setSettingConnectionUuid(data, uuid)
+	setSettingConnectionType(data, nm.NM_SETTING_BLUETOOTH_SETTING_NAME)
+	// 蓝牙连接只在用户主动发起或开启自动激活时激活
+	setSettingConnectionAutoconnect(data, false)
+
+	addSetting(data, nm.NM_SETTING_BLUETOOTH_SETTING_NAME)
+	setSettingBluetoothBdaddr(data, bdaddr)
+	setSettingBluetoothType(data, nm.NM_SETTING_BLUETOOTH_TYPE_PANU)
+
+	initSettingSectionIpv4(data)
+	initSettingSectionIpv6(data)
+	return
+}
+
+func (m *Manager) initTetheringManage() {
+	_, err := nmManager.ConnectDeviceAdded(func(path dbus.ObjectPath) {
+		m.addTetheringDevice(path)
+	})
+	if err != nil {
+		logger.Warning(err)
+	}
+	_, err = nmManager.ConnectDeviceRemoved(func(path dbus.ObjectPath) {
+		m.removeTetheringDevice(path)
+	})
+	if err != nil {
+		logger.Warning(err)
+	}
+	for _, path := range nmGetDevices() {
+		m.addTetheringDevice(path)
+	}
+}
+
+// addTetheringDevice 为手机共享网络的设备创建连接，开启自动激活时激活连接
+func (m *Manager) addTetheringDevice(devPath dbus.ObjectPath) {
+	nmDev, err := nmNewDevice(devPath)
+	if err != nil {
+		return
+	}
+	devType, _ := nmDev.Device().DeviceType().Get(0)
+	driver, _ := nmDev.Device().Driver().Get(0)
+	var btCapabilities uint32
+	if devType == nm.NM_DEVICE_TYPE_BT {
+		btCapabilities, _ = nmDev.Bluetooth().BtCapabilities().Get(0)
+	}
+	tetheringType := getTetheringType(devType, driver, btCapabilities)
+	if tetheringType == "" {
+		return
+	}
+
+	dev := &tetheringDevice{
+		Path: devPath,
+		Type: tetheringType,
+	}
+	dev.Interface, _ = nmDev.Device().Interface().Get(0)
+	var cpath dbus.ObjectPath
+	switch tetheringType {
+	case tetheringTypeUsb:
+		dev.Name = nmGeneralGetDeviceDesc(devPath)
+		cpath, _, err = m.ensureWiredConnectionExists(devPath, false)
+	case tetheringTypeBluetooth:
+		dev.Name, _ = nmDev.Bluetooth().Name().Get(0)
+		cpath, err = m.ensureBluetoothPanConnectionExists(nmDev, dev.Name)
+	}
+	if err != nil {
+		logger.Warningf("failed to ensure tethering connection of %s: %v", devPath, err)
+		return
+	}
+	dev.Uuid, _ = nmGetConnectionUuid(cpath)
+	logger.Infof("found %s tethering device %s, connection %s", tetheringType, devPath, dev.Uuid)
+
+	m.tetheringLock.Lock()
+	m.tetheringDevices[devPath] = dev
+	m.updatePropTetheringDevices()
+	autoActivate := m.tetheringAutoActivate
+	m.tetheringLock.Unlock()
+
+	err = m.service.Emit(m, "TetheringDeviceAdded", string(devPath), tetheringType)
+	if err != nil {
+		logger.Warning(err)
+	}
+
+	if autoActivate && !isDeviceStateInActivating(nmGetDeviceState(devPath)) {
+		_, err = nmActivateConnection(cpath, devPath)
+		if err != nil {
+			logger.Warning("failed to activate tethering connection:", err)
+		}
+	}
+}
+
+func (m *Manager) removeTetheringDevice(devPath dbus.ObjectPath) {
+	m.tetheringLock.Lock()
+	defer m.tetheringLock.Unlock()
+	if _, ok := m.tetheringDevices[devPath]; !ok {
+		return
+	}
+	delete(m.tetheringDevices, devPath)
+	m.updatePropTetheringDevices()
+}
+
+// ensureBluetoothPanConnectionExists 蓝牙设备的连接 uuid 由蓝牙地址生成，不存在时创建
+func (m *Manager) ensureBluetoothPanConnectionExists(nmDev nmdbus.Device, name string) (cpath dbus.ObjectPath, err error) {
+	hwAddr, err := nmDev.Bluetooth().HwAddress().Get(0)
+	if err != nil {
+		return
+	}
+	uuid := strToUuid(tetheringTypeBluetooth + hwAddr)
+	cpath, err = nmGetConnectionByUuid(uuid)
+	if err == nil {
+		return
+	}
+
+	bdaddr, err := convertMacAddressToArrayByteCheck(hwAddr)
+	if err != nil {
+		return
+	}
+	if name == "" {
+		name = hwAddr
+	}
+	logger.Infof("new bluetooth pan connection, uuid=%s, bdaddr=%s", uuid, hwAddr)
+	return nmAddConnection(newBluetoothPanConnectionData(name, uuid, bdaddr))
+}
+
+// 需要持有 tetheringLock
+func (m *Manager) updatePropTetheringDevices() {
+	devices := make([]*tetheringDevice, 0, len(m.tetheringDevices))
+	for _, dev := range m.tetheringDevices {
+		devices = append(devices, dev)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].Path < devices[j].Path
+	})
+	devicesJSON, _ := marshalJSON(devices)
+	m.PropsMu.Lock()
+	m.setPropTetheringDevices(devicesJSON)
+	m.PropsMu.Unlock()
+}
+
+func (m *Manager) setTetheringAutoActivate(enabled bool) {
+	m.tetheringLock.Lock()
+	m.tetheringAutoActivate = enabled
+	m.tetheringLock.Unlock()
+}
+
+// ActivateTetheringDevice activate the connection of the phone exposed
+// USB or bluetooth tethering device listed in the TetheringDevices
+// property.
+func (m *Manager) ActivateTetheringDevice(devPath dbus.ObjectPath) (connection dbus.ObjectPath, busErr *dbus.Error) {
+	m.tetheringLock.Lock()
+	dev, ok := m.tetheringDevices[devPath]
+	m.tetheringLock.Unlock()
+	if !ok {
+		return "/", dbusutil.ToError(fmt.Errorf("invalid tethering device %q", devPath))
+	}
+
+	cpath, err := m.activateConnection(dev.Uuid, devPath)
+	if err != nil {
+		logger.Warning("failed to activate tethering device:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
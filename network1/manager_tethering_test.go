@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestGetTetheringType(c *C.C) {
+	c.Check(getTetheringType(nm.NM_DEVICE_TYPE_ETHERNET, "rndis_host", 0), C.Equals, tetheringTypeUsb)
+	c.Check(getTetheringType(nm.NM_DEVICE_TYPE_ETHERNET, "ipheth", 0), C.Equals, tetheringTypeUsb)
+	c.Check(getTetheringType(nm.NM_DEVICE_TYPE_ETHERNET, "e1000e", 0), C.Equals, "")
+	// USB 有线网卡
+	c.Check(getTetheringType(nm.NM_DEVICE_TYPE_ETHERNET, "cdc_ether", 0), C.Equals, "")
+	c.Check(getTetheringType(nm.NM_DEVICE_TYPE_ETHERNET, "cdc_ncm", 0), C.Equals, "")
+	c.Check(getTetheringType(nm.NM_DEVICE_TYPE_BT, "", nm.NM_BT_CAPABILITY_NAP|nm.NM_BT_CAPABILITY_DUN), C.Equals, tetheringTypeBluetooth)
+	c.Check(getTetheringType(nm.NM_DEVICE_TYPE_BT, "", nm.NM_BT_CAPABILITY_DUN), C.Equals, "")
+	c.Check(getTetheringType(nm.NM_DEVICE_TYPE_WIFI, "rndis_host", 0), C.Equals, "")
+}
+
+func (*testWrapper) TestNewBluetoothPanConnectionData(c *C.C) {
+	data := newBluetoothPanConnectionData("phone", "uuid", []byte{0, 1, 2, 3, 4, 5})
+	c.Check(getSettingConnectionType(data), C.Equals, nm.NM_SETTING_BLUETOOTH_SETTING_NAME)
+	c.Check(getSettingConnectionAutoconnect(data), C.Equals, false)
+	c.Check(getSettingBluetoothType(data), C.Equals, nm.NM_SETTING_BLUETOOTH_TYPE_PANU)
+	c.Check(getSettingBluetoothBdaddr(data), C.DeepEquals, []byte{0, 1, 2, 3, 4, 5})
+}
@@ -0,0 +1,440 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	"github.com/linuxdeepin/go-lib/utils"
+)
+
+// 默认的链路监测间隔，单位毫秒
+const defaultBondMiimon = "100"
+
+var bondModes = []string{
+	"balance-rr",
+	"active-backup",
+	"balance-xor",
+	"broadcast",
+	"802.3ad",
+	"balance-tlb",
+	"balance-alb",
+}
+
+// virtualDevice 网桥、bond 和 VLAN 设备，不包含在 Devices 属性中
+type virtualDevice struct {
+	Path      dbus.ObjectPath
+	Interface string
+	Type      string
+	State     uint32
+	// 激活的连接的 uuid，未激活时为空
+	Uuid string `json:",omitempty"`
+	// 网桥和 bond 的从设备接口名
+	Slaves []string `json:",omitempty"`
+	// VLAN 的父设备接口名和 VLAN id
+	Parent string `json:",omitempty"`
+	VlanId uint32 `json:",omitempty"`
+}
+
+func checkVirtualInterfaceName(name string) error {
+	if name == "" || len(name) > maxInterfaceNameLength {
+		return fmt.Errorf("invalid interface name %q, the length should be 1-%d", name, maxInterfaceNameLength)
+	}
+	if strings.ContainsAny(name, "/: \t\n") || name == "." || name == ".." {
+		return fmt.Errorf("invalid interface name %q", name)
+	}
+	return nil
+}
+
+func checkVlanId(vlanId uint32) error {
+	if vlanId < 1 || vlanId > 4094 {
+		return fmt.Errorf("invalid vlan id %d, the range is 1-4094", vlanId)
+	}
+	return nil
+}
+
+// getVlanInterfaceName VLAN 接口默认命名为 parent.id，超出长度时使用 vlan<id>
+func getVlanInterfaceName(parent string, vlanId uint32) string {
+	name := fmt.Sprintf("%s.%d", parent, vlanId)
+	if len(name) > maxInterfaceNameLength {
+		name = fmt.Sprintf("vlan%d", vlanId)
+	}
+	return name
+}
+
+func newMasterConnectionData(id, uuid, ifc, connType string) (data connectionData) {
+	data = make(connectionData)
+
+	addSetting(data, nm.NM_SETTING_CONNECTION_SETTING_NAME)
+	setSettingConnectionId(data, id)
+	setSettingConnectionUuid(data, uuid)
+	setSettingConnectionType(data, connType)
+	setSettingConnectionInterfaceName(data, ifc)
+	// 激活主设备时同时激活从设备
+	setSettingConnectionAutoconnectSlaves(data, nm.NM_SETTING_CONNECTION_AUTOCONNECT_SLAVES_YES)
+
+	addSetting(data, connType)
+
+	initSettingSectionIpv4(data)
+	initSettingSectionIpv6(data)
+	return
+}
+
+func newBridgeConnectionData(id, uuid, ifc string) (data connectionData) {
+	data = newMasterConnectionData(id, uuid, ifc, nm.NM_SETTING_BRIDGE_SETTING_NAME)
+	// 桌面环境中一般不存在环路，关闭 STP 避免端口长时间处于监听状态
+	setSettingBridgeStp(data, false)
+	return
+}
+
+func newBondConnectionData(id, uuid, ifc, mode string) (data connectionData) {
+	data = newMasterConnectionData(id, uuid, ifc, nm.NM_SETTING_BOND_SETTING_NAME)
+	setSettingBondOptions(data, map[string]string{
+		nm.NM_SETTING_BOND_OPTION_MODE: mode,
+		"miimon":                       defaultBondMiimon,
+	})
+	return
+}
+
+func newVlanConnectionData(id, uuid, parent string, vlanId uint32) (data connectionData) {
+	data = make(connectionData)
+
+	addSetting(data, nm.NM_SETTING_CONNECTION_SETTING_NAME)
+	setSettingConnectionId(data, id)
+	setSettingConnectionUuid(data, uuid)
+	setSettingConnectionType(data, nm.NM_SETTING_VLAN_SETTING_NAME)
+	setSettingConnectionInterfaceName(data, getVlanInterfaceName(parent, vlanId))
+
+	addSetting(data, nm.NM_SETTING_VLAN_SETTING_NAME)
+	setSettingVlanParent(data, parent)
+	setSettingVlanId(data, vlanId)
+
+	initSettingSectionIpv4(data)
+	initSettingSectionIpv6(data)
+	return
+}
+
+// newSlaveConnectionData 从设备的连接不需要 ip 配置，由主设备统一配置
+func newSlaveConnectionData(id, uuid, ifc, masterUuid, slaveType string) (data connectionData) {
+	data = make(connectionData)
+
+	addSetting(data, nm.NM_SETTING_CONNECTION_SETTING_NAME)
+	setSettingConnectionId(data, id)
+	setSettingConnectionUuid(data, uuid)
+	setSettingConnectionType(data, nm.NM_SETTING_WIRED_SETTING_NAME)
+	setSettingConnectionInterfaceName(data, ifc)
+	setSettingConnectionMaster(data, masterUuid)
+	setSettingConnectionSlaveType(data, slaveType)
+
+	addSetting(data, nm.NM_SETTING_WIRED_SETTING_NAME)
+	return
+}
+
+// isSlaveConnectionOf master 可能是主设备连接的 uuid，也可能是主设备的接口名
+func isSlaveConnectionOf(data connectionData, masterUuid, masterIfc string) bool {
+	if getSettingConnectionSlaveType(data) == "" {
+		return false
+	}
+	master := getSettingConnectionMaster(data)
+	return master != "" && (master == masterUuid || master == masterIfc)
+}
+
+func isVirtualDeviceType(devType uint32) bool {
+	switch devType {
+	case nm.NM_DEVICE_TYPE_BRIDGE, nm.NM_DEVICE_TYPE_BOND, nm.NM_DEVICE_TYPE_VLAN:
+		return true
+	}
+	return false
+}
+
+// getSlaveConnections 获取主设备连接的所有从设备连接
+func getSlaveConnections(masterUuid, masterIfc string) (cpaths []dbus.ObjectPath) {
+	for _, cpath := range nmGetConnectionList() {
+		data, err := nmGetConnectionData(cpath)
+		if err != nil {
+			continue
+		}
+		if isSlaveConnectionOf(data, masterUuid, masterIfc) {
+			cpaths = append(cpaths, cpath)
+		}
+	}
+	return
+}
+
+func (m *Manager) getMasterConnectionData(uuid string) (cpath dbus.ObjectPath, data connectionData, err error) {
+	cpath, data, err = m.getConnectionData(uuid)
+	if err != nil {
+		return
+	}
+	switch getSettingConnectionType(data) {
+	case nm.NM_SETTING_BRIDGE_SETTING_NAME, nm.NM_SETTING_BOND_SETTING_NAME:
+	default:
+		err = errors.New("connection is not a bridge or bond connection")
+	}
+	return
+}
+
+func checkSlaveInterfaces(masterIfc string, slaves []string) error {
+	for _, ifc := range slaves {
+		if err := checkVirtualInterfaceName(ifc); err != nil {
+			return err
+		}
+		if ifc == masterIfc {
+			return fmt.Errorf("interface %s can not be the slave of itself", ifc)
+		}
+	}
+	return nil
+}
+
+// addSlaveConnections 为每个从设备接口创建连接
+func addSlaveConnections(masterUuid, masterIfc, slaveType string, slaves []string) error {
+	for _, ifc := range slaves {
+		id := fmt.Sprintf("%s-slave-%s", masterIfc, ifc)
+		_, err := nmAddConnection(newSlaveConnectionData(id, utils.GenUuid(), ifc, masterUuid, slaveType))
+		if err != nil {
+			return fmt.Errorf("failed to add slave connection of %s: %v", ifc, err)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) createMasterConnection(data connectionData, slaves []string) (cpath dbus.ObjectPath, err error) {
+	ifc := getSettingConnectionInterfaceName(data)
+	uuid := getSettingConnectionUuid(data)
+	err = checkSlaveInterfaces(ifc, slaves)
+	if err != nil {
+		return "/", err
+	}
+	cpath, err = nmAddConnection(data)
+	if err != nil {
+		return "/", err
+	}
+	err = addSlaveConnections(uuid, ifc, getSettingConnectionType(data), slaves)
+	if err != nil {
+		// 从设备创建失败时删除已创建的连接，避免留下不完整的配置
+		if deleteErr := m.deleteVirtualConnection(uuid); deleteErr != nil {
+			logger.Warning(deleteErr)
+		}
+		return "/", err
+	}
+	logger.Infof("create %s connection %s, slaves: %v", getSettingConnectionType(data), ifc, slaves)
+	return cpath, nil
+}
+
+// CreateBridge create a bridge connection with the interface name and
+// the connections which make the slave interfaces the ports of the bridge.
+func (m *Manager) CreateBridge(name string, slaves []string) (connection dbus.ObjectPath, busErr *dbus.Error) {
+	cpath, err := m.createBridge(name, slaves)
+	if err != nil {
+		logger.Warning("failed to create bridge:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+func (m *Manager) createBridge(name string, slaves []string) (cpath dbus.ObjectPath, err error) {
+	err = checkVirtualInterfaceName(name)
+	if err != nil {
+		return "/", err
+	}
+	return m.createMasterConnection(newBridgeConnectionData(name, utils.GenUuid(), name), slaves)
+}
+
+// CreateBond create a bond connection with the interface name, the bond
+// mode such as "active-backup" and "802.3ad", and the connections which
+// make the slave interfaces the slaves of the bond.
+func (m *Manager) CreateBond(name, mode string, slaves []string) (connection dbus.ObjectPath, busErr *dbus.Error) {
+	cpath, err := m.createBond(name, mode, slaves)
+	if err != nil {
+		logger.Warning("failed to create bond:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+func (m *Manager) createBond(name, mode string, slaves []string) (cpath dbus.ObjectPath, err error) {
+	err = checkVirtualInterfaceName(name)
+	if err != nil {
+		return "/", err
+	}
+	if !isStringInArray(mode, bondModes) {
+		return "/", fmt.Errorf("invalid bond mode %q", mode)
+	}
+	return m.createMasterConnection(newBondConnectionData(name, utils.GenUuid(), name, mode), slaves)
+}
+
+// CreateVlan create a VLAN connection on the parent interface, the
+// interface name of the VLAN is "<parent>.<vlanId>".
+func (m *Manager) CreateVlan(parent string, vlanId uint32) (connection dbus.ObjectPath, busErr *dbus.Error) {
+	cpath, err := m.createVlan(parent, vlanId)
+	if err != nil {
+		logger.Warning("failed to create vlan:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+func (m *Manager) createVlan(parent string, vlanId uint32) (cpath dbus.ObjectPath, err error) {
+	err = checkVirtualInterfaceName(parent)
+	if err != nil {
+		return "/", err
+	}
+	err = checkVlanId(vlanId)
+	if err != nil {
+		return "/", err
+	}
+	ifc := getVlanInterfaceName(parent, vlanId)
+	logger.Infof("create vlan connection %s, parent: %s, id: %d", ifc, parent, vlanId)
+	return nmAddConnection(newVlanConnectionData(ifc, utils.GenUuid(), parent, vlanId))
+}
+
+// SetVirtualConnectionSlaves replace the slave interfaces of the bridge
+// or bond connection with the uuid.
+func (m *Manager) SetVirtualConnectionSlaves(uuid string, slaves []string) *dbus.Error {
+	err := m.setVirtualConnectionSlaves(uuid, slaves)
+	if err != nil {
+		logger.Warning("failed to set virtual connection slaves:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setVirtualConnectionSlaves(uuid string, slaves []string) error {
+	_, data, err := m.getMasterConnectionData(uuid)
+	if err != nil {
+		return err
+	}
+	ifc := getSettingConnectionInterfaceName(data)
+	err = checkSlaveInterfaces(ifc, slaves)
+	if err != nil {
+		return err
+	}
+
+	// 保留已存在的从设备连接，删除不再需要的
+	var added []string
+	for _, cpath := range getSlaveConnections(uuid, ifc) {
+		slaveData, err := nmGetConnectionData(cpath)
+		if err != nil {
+			continue
+		}
+		slaveIfc := getSettingConnectionInterfaceName(slaveData)
+		if isStringInArray(slaveIfc, slaves) && !isStringInArray(slaveIfc, added) {
+			added = append(added, slaveIfc)
+			continue
+		}
+		err = m.deleteConnection(getSettingConnectionUuid(slaveData))
+		if err != nil {
+			logger.Warningf("failed to delete slave connection %s: %v", cpath, err)
+		}
+	}
+	var newSlaves []string
+	for _, slave := range slaves {
+		if !isStringInArray(slave, added) && !isStringInArray(slave, newSlaves) {
+			newSlaves = append(newSlaves, slave)
+		}
+	}
+	logger.Infof("set slaves of %s to %v", ifc, slaves)
+	return addSlaveConnections(uuid, ifc, getSettingConnectionType(data), newSlaves)
+}
+
+// DeleteVirtualConnection delete the bridge, bond or VLAN connection with
+// the uuid together with the connections of its slave interfaces.
+func (m *Manager) DeleteVirtualConnection(uuid string) *dbus.Error {
+	_, data, err := m.getConnectionData(uuid)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	switch getSettingConnectionType(data) {
+	case nm.NM_SETTING_BRIDGE_SETTING_NAME, nm.NM_SETTING_BOND_SETTING_NAME, nm.NM_SETTING_VLAN_SETTING_NAME:
+	default:
+		return dbusutil.ToError(errors.New("connection is not a virtual device connection"))
+	}
+	err = m.deleteVirtualConnection(uuid)
+	if err != nil {
+		logger.Warning("failed to delete virtual connection:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) deleteVirtualConnection(uuid string) error {
+	_, data, err := m.getConnectionData(uuid)
+	if err != nil {
+		return err
+	}
+	ifc := getSettingConnectionInterfaceName(data)
+	for _, cpath := range getSlaveConnections(uuid, ifc) {
+		slaveUuid, err := nmGetConnectionUuid(cpath)
+		if err != nil {
+			continue
+		}
+		err = m.deleteConnection(slaveUuid)
+		if err != nil {
+			logger.Warningf("failed to delete slave connection %s: %v", slaveUuid, err)
+		}
+	}
+	logger.Info("delete virtual connection", uuid)
+	return m.deleteConnection(uuid)
+}
+
+// GetVirtualDevices return the bridge, bond and VLAN devices which
+// marshaled by json, they are not included in the Devices property.
+func (m *Manager) GetVirtualDevices() (devicesJSON string, busErr *dbus.Error) {
+	devices := make([]*virtualDevice, 0)
+	for _, devPath := range nmGetDevices() {
+		dev := getVirtualDevice(devPath)
+		if dev != nil {
+			devices = append(devices, dev)
+		}
+	}
+	devicesJSON, err := marshalJSON(devices)
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	return devicesJSON, nil
+}
+
+func getVirtualDevice(devPath dbus.ObjectPath) *virtualDevice {
+	nmDev, err := nmNewDevice(devPath)
+	if err != nil {
+		return nil
+	}
+	devType, _ := nmDev.Device().DeviceType().Get(0)
+	if !isVirtualDeviceType(devType) {
+		return nil
+	}
+	dev := &virtualDevice{
+		Path: devPath,
+		Type: getCustomDeviceType(devType),
+	}
+	dev.Interface, _ = nmDev.Device().Interface().Get(0)
+	dev.State, _ = nmDev.Device().State().Get(0)
+	if apath, _ := nmDev.Device().ActiveConnection().Get(0); isNmObjectPathValid(apath) {
+		dev.Uuid = nmGetActiveConnectionUuid(apath)
+	}
+
+	var slavePaths []dbus.ObjectPath
+	switch devType {
+	case nm.NM_DEVICE_TYPE_BRIDGE:
+		slavePaths, _ = nmDev.Bridge().Slaves().Get(0)
+	case nm.NM_DEVICE_TYPE_BOND:
+		slavePaths, _ = nmDev.Bond().Slaves().Get(0)
+	case nm.NM_DEVICE_TYPE_VLAN:
+		dev.VlanId, _ = nmDev.Vlan().VlanId().Get(0)
+		if parent, _ := nmDev.Vlan().Parent().Get(0); isNmObjectPathValid(parent) {
+			dev.Parent = nmGetDeviceInterface(parent)
+		}
+	}
+	for _, slavePath := range slavePaths {
+		dev.Slaves = append(dev.Slaves, nmGetDeviceInterface(slavePath))
+	}
+	return dev
+}
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestCheckVirtualInterfaceName(c *C.C) {
+	c.Check(checkVirtualInterfaceName("br0"), C.IsNil)
+	c.Check(checkVirtualInterfaceName(""), C.NotNil)
+	c.Check(checkVirtualInterfaceName("a-very-long-bridge"), C.NotNil)
+	c.Check(checkVirtualInterfaceName("br 0"), C.NotNil)
+	c.Check(checkSlaveInterfaces("br0", []string{"eth0", "eth1"}), C.IsNil)
+	c.Check(checkSlaveInterfaces("br0", []string{"br0"}), C.NotNil)
+	c.Check(checkVlanId(100), C.IsNil)
+	c.Check(checkVlanId(0), C.NotNil)
+	c.Check(checkVlanId(4095), C.NotNil)
+}
+
+func (*testWrapper) TestGetVlanInterfaceName(c *C.C) {
+	c.Check(getVlanInterfaceName("eth0", 100), C.Equals, "eth0.100")
+	c.Check(getVlanInterfaceName("enx001122334455", 100), C.Equals, "vlan100")
+}
+
+func (*testWrapper) TestNewVirtualConnectionData(c *C.C) {
+	data := newBondConnectionData("bond0", "uuid", "bond0", "active-backup")
+	c.Check(getSettingConnectionType(data), C.Equals, nm.NM_SETTING_BOND_SETTING_NAME)
+	c.Check(getSettingConnectionInterfaceName(data), C.Equals, "bond0")
+	c.Check(getSettingBondOptions(data)[nm.NM_SETTING_BOND_OPTION_MODE], C.Equals, "active-backup")
+
+	data = newVlanConnectionData("eth0.10", "uuid", "eth0", 10)
+	c.Check(getSettingVlanParent(data), C.Equals, "eth0")
+	c.Check(getSettingVlanId(data), C.Equals, uint32(10))
+	c.Check(getSettingConnectionInterfaceName(data), C.Equals, "eth0.10")
+
+	slave := newSlaveConnectionData("br0-slave-eth0", "slave-uuid", "eth0", "uuid", nm.NM_SETTING_BRIDGE_SETTING_NAME)
+	c.Check(isSlaveConnectionOf(slave, "uuid", "br0"), C.Equals, true)
+	c.Check(isSlaveConnectionOf(slave, "other", "br1"), C.Equals, false)
+	setSettingConnectionMaster(slave, "br0")
+	c.Check(isSlaveConnectionOf(slave, "uuid", "br0"), C.Equals, true)
+	c.Check(isSlaveConnectionOf(newWirelessConnectionData("test", "wireless", []byte("test"), "none", ""), "", ""), C.Equals, false)
+}
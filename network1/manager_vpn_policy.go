@@ -0,0 +1,350 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	"github.com/linuxdeepin/go-lib/strv"
+)
+
+const (
+	// 记录在 VPN 连接 user 设置中，表示 VPN 意外断开时阻断网络
+	userDataVpnKillSwitch = "org.deepin.dde.network.vpn-kill-switch"
+
+	sysNetworkDest      = "org.deepin.dde.Network1"
+	sysNetworkPath      = "/org/deepin/dde/Network1"
+	sysNetworkInterface = "org.deepin.dde.Network1"
+)
+
+// 各类型 VPN 中保存服务器地址的 key
+var vpnServerKeys = []string{
+	nm.NM_SETTING_VPN_OPENVPN_KEY_REMOTE,
+	nm.NM_SETTING_VPN_L2TP_KEY_GATEWAY,
+	nm.NM_SETTING_VPN_STRONGSWAN_KEY_ADDRESS,
+	nm.NM_SETTING_VPN_VPNC_KEY_GATEWAY,
+}
+
+func isVpnKillSwitchEnabled(data connectionData) bool {
+	if !isSettingExists(data, nm.NM_SETTING_USER_SETTING_NAME) {
+		return false
+	}
+	return getSettingUserData(data)[userDataVpnKillSwitch] == "true"
+}
+
+func setVpnKillSwitchEnabled(data connectionData, enabled bool) {
+	userData := make(map[string]string)
+	if isSettingExists(data, nm.NM_SETTING_USER_SETTING_NAME) {
+		for k, v := range getSettingUserData(data) {
+			userData[k] = v
+		}
+	}
+	if enabled {
+		userData[userDataVpnKillSwitch] = "true"
+	} else {
+		delete(userData, userDataVpnKillSwitch)
+	}
+	if len(userData) == 0 {
+		removeSetting(data, nm.NM_SETTING_USER_SETTING_NAME)
+	} else {
+		addSetting(data, nm.NM_SETTING_USER_SETTING_NAME)
+		setSettingUserData(data, userData)
+	}
+}
+
+// parseVpnServerHost 从 VPN 服务器配置中取出主机名，
+// 支持 host:port、[ipv6]:port、openvpn 的 "host port proto" 以及 openconnect 的 url
+func parseVpnServerHost(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "://") {
+		if u, err := url.Parse(value); err == nil {
+			return u.Hostname()
+		}
+	}
+	if fields := strings.Fields(value); len(fields) > 0 {
+		value = fields[0]
+	}
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		return host
+	}
+	return strings.Trim(value, "[]")
+}
+
+// getVpnServerHosts 返回 VPN 连接的服务器地址，阻断网络时需要放行以便重新连接
+func getVpnServerHosts(data connectionData) (hosts []string) {
+	vpnData := getSettingVpnData(data)
+	for _, key := range vpnServerKeys {
+		for _, value := range strings.Split(vpnData[key], ",") {
+			if host := parseVpnServerHost(value); host != "" && !strv.Strv(hosts).Contains(host) {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+	return
+}
+
+func resolveVpnServerAddrs(hosts []string) (addrs []string) {
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			addrs = append(addrs, ip.String())
+			continue
+		}
+		ips, err := net.LookupIP(host)
+		if err != nil {
+			logger.Warning("failed to resolve vpn server:", host, err)
+			continue
+		}
+		for _, ip := range ips {
+			addrs = append(addrs, ip.String())
+		}
+	}
+	return
+}
+
+func (m *Manager) getConnectionData(uuid string) (cpath dbus.ObjectPath, data connectionData, err error) {
+	cpath, err = nmGetConnectionByUuid(uuid)
+	if err != nil {
+		return
+	}
+	data, err = nmGetConnectionData(cpath)
+	return
+}
+
+func (m *Manager) getVpnConnectionData(uuid string) (cpath dbus.ObjectPath, data connectionData, err error) {
+	cpath, data, err = m.getConnectionData(uuid)
+	if err != nil {
+		return
+	}
+	if getSettingConnectionType(data) != nm.NM_SETTING_VPN_SETTING_NAME {
+		err = errors.New("connection is not a vpn connection")
+	}
+	return
+}
+
+// SetVpnAutoconnect set whether to activate the VPN automatically when
+// the base connection is activated, it is implemented by the secondaries
+// of the base connection.
+func (m *Manager) SetVpnAutoconnect(vpnUuid, baseUuid string, enabled bool) *dbus.Error {
+	err := m.setVpnAutoconnect(vpnUuid, baseUuid, enabled)
+	if err != nil {
+		logger.Warning("failed to set vpn autoconnect:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setVpnAutoconnect(vpnUuid, baseUuid string, enabled bool) error {
+	_, _, err := m.getVpnConnectionData(vpnUuid)
+	if err != nil {
+		return err
+	}
+	cpath, data, err := m.getConnectionData(baseUuid)
+	if err != nil {
+		return err
+	}
+	if getSettingConnectionType(data) == nm.NM_SETTING_VPN_SETTING_NAME {
+		return errors.New("base connection could not be a vpn connection")
+	}
+
+	secondaries := strv.Strv(getSettingConnectionSecondaries(data))
+	if secondaries.Contains(vpnUuid) == enabled {
+		return nil
+	}
+	if enabled {
+		secondaries = append(secondaries, vpnUuid)
+	} else {
+		secondaries, _ = secondaries.Delete(vpnUuid)
+	}
+	setSettingConnectionSecondaries(data, secondaries)
+	return updateConnectionData(cpath, data)
+}
+
+// GetVpnAutoconnect get the uuids of the connections which will activate
+// the VPN automatically.
+func (m *Manager) GetVpnAutoconnect(vpnUuid string) (baseUuids []string, busErr *dbus.Error) {
+	baseUuids = make([]string, 0)
+	for _, cpath := range nmGetConnectionList() {
+		data, err := nmGetConnectionData(cpath)
+		if err != nil {
+			continue
+		}
+		if strv.Strv(getSettingConnectionSecondaries(data)).Contains(vpnUuid) {
+			baseUuids = append(baseUuids, getSettingConnectionUuid(data))
+		}
+	}
+	return baseUuids, nil
+}
+
+// EnableVpnKillSwitch block all network traffic except to the VPN server
+// when the VPN drops unexpectedly, until the VPN is reconnected or the
+// kill switch is disabled.
+func (m *Manager) EnableVpnKillSwitch(uuid string) *dbus.Error {
+	err := m.setVpnKillSwitch(uuid, true)
+	if err != nil {
+		logger.Warning("failed to enable vpn kill switch:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+// DisableVpnKillSwitch disable the kill switch of the VPN, and restore
+// the network traffic if it is blocked by the VPN.
+func (m *Manager) DisableVpnKillSwitch(uuid string) *dbus.Error {
+	err := m.setVpnKillSwitch(uuid, false)
+	if err != nil {
+		logger.Warning("failed to disable vpn kill switch:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+// IsVpnKillSwitchEnabled get whether the kill switch of the VPN is enabled.
+func (m *Manager) IsVpnKillSwitchEnabled(uuid string) (enabled bool, busErr *dbus.Error) {
+	_, data, err := m.getVpnConnectionData(uuid)
+	if err != nil {
+		return false, dbusutil.ToError(err)
+	}
+	return isVpnKillSwitchEnabled(data), nil
+}
+
+func (m *Manager) setVpnKillSwitch(uuid string, enabled bool) error {
+	cpath, data, err := m.getVpnConnectionData(uuid)
+	if err != nil {
+		return err
+	}
+	if isVpnKillSwitchEnabled(data) != enabled {
+		setVpnKillSwitchEnabled(data, enabled)
+		err = updateConnectionData(cpath, data)
+		if err != nil {
+			return err
+		}
+	}
+	if !enabled {
+		m.releaseVpnKillSwitch(uuid)
+	}
+	return nil
+}
+
+// handleVpnKillSwitch VPN 意外断开时阻断网络，重新连接或用户主动断开时恢复
+func (m *Manager) handleVpnKillSwitch(uuid string, state, reason uint32) {
+	switch state {
+	case nm.NM_VPN_CONNECTION_STATE_ACTIVATED:
+		m.releaseVpnKillSwitch(uuid)
+	case nm.NM_VPN_CONNECTION_STATE_DISCONNECTED, nm.NM_VPN_CONNECTION_STATE_FAILED:
+		if reason == nm.NM_VPN_CONNECTION_STATE_REASON_USER_DISCONNECTED {
+			m.releaseVpnKillSwitch(uuid)
+			return
+		}
+		_, data, err := m.getVpnConnectionData(uuid)
+		if err != nil {
+			// 连接已被删除
+			m.releaseVpnKillSwitch(uuid)
+			return
+		}
+		if isVpnKillSwitchEnabled(data) {
+			m.engageVpnKillSwitch(uuid, resolveVpnServerAddrs(getVpnServerHosts(data)))
+		}
+	}
+}
+
+func (m *Manager) engageVpnKillSwitch(uuid string, allowedAddrs []string) {
+	m.vpnKillSwitchLock.Lock()
+	defer m.vpnKillSwitchLock.Unlock()
+	if m.vpnKillSwitchUuid == uuid {
+		return
+	}
+	logger.Infof("vpn %s dropped, block network traffic except %v", uuid, allowedAddrs)
+	err := m.callSysNetwork("EnableVpnKillSwitch", allowedAddrs)
+	if err != nil {
+		logger.Warning("failed to engage vpn kill switch:", err)
+		return
+	}
+	m.vpnKillSwitchUuid = uuid
+	m.emitVpnKillSwitchStateChanged(uuid, true)
+}
+
+// releaseVpnKillSwitch 阻断规则保存在系统服务中，session 服务重启后不知道由哪个 VPN 阻断，
+// 此时 vpnKillSwitchUuid 为空，同样解除阻断，系统服务中没有阻断规则时不做处理
+func (m *Manager) releaseVpnKillSwitch(uuid string) {
+	m.vpnKillSwitchLock.Lock()
+	defer m.vpnKillSwitchLock.Unlock()
+	if m.vpnKillSwitchUuid != "" && m.vpnKillSwitchUuid != uuid {
+		return
+	}
+	logger.Infof("restore network traffic blocked by vpn %s", uuid)
+	err := m.callSysNetwork("DisableVpnKillSwitch")
+	if err != nil {
+		logger.Warning("failed to release vpn kill switch:", err)
+		return
+	}
+	if m.vpnKillSwitchUuid != "" {
+		m.vpnKillSwitchUuid = ""
+		m.emitVpnKillSwitchStateChanged(uuid, false)
+	}
+}
+
+// reconcileVpnKillSwitch session 服务启动时检查系统服务中的阻断规则，已经有连接的 VPN
+// 或者所有 VPN 都没有启用 kill switch 时解除阻断，否则保持阻断，直到 VPN 重新连接或用户断开
+func (m *Manager) reconcileVpnKillSwitch() {
+	var engaged bool
+	err := m.sysSigLoop.Conn().Object(sysNetworkDest, sysNetworkPath).
+		Call(sysNetworkInterface+".IsVpnKillSwitchEngaged", 0).Store(&engaged)
+	if err != nil {
+		logger.Warning("failed to get vpn kill switch state:", err)
+		return
+	}
+	if !engaged {
+		return
+	}
+
+	vpnActivated := false
+	m.activeConnectionsLock.Lock()
+	for _, aconn := range m.activeConnections {
+		if aconn.Vpn && aconn.State == nm.NM_ACTIVE_CONNECTION_STATE_ACTIVATED {
+			vpnActivated = true
+			break
+		}
+	}
+	m.activeConnectionsLock.Unlock()
+
+	if !vpnActivated && hasVpnKillSwitchEnabled() {
+		logger.Info("network traffic is still blocked by the vpn kill switch")
+		return
+	}
+	m.releaseVpnKillSwitch("")
+}
+
+// hasVpnKillSwitchEnabled 有 VPN 连接启用了 kill switch 时返回 true
+func hasVpnKillSwitchEnabled() bool {
+	for _, cpath := range nmGetConnectionList() {
+		data, err := nmGetConnectionData(cpath)
+		if err != nil {
+			continue
+		}
+		if getSettingConnectionType(data) == nm.NM_SETTING_VPN_SETTING_NAME && isVpnKillSwitchEnabled(data) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) emitVpnKillSwitchStateChanged(uuid string, engaged bool) {
+	err := m.service.Emit(m, "VpnKillSwitchStateChanged", uuid, engaged)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+// callSysNetwork 调用系统级网络服务中 go-dbus-factory 尚未生成的方法
+func (m *Manager) callSysNetwork(method string, args ...interface{}) error {
+	return m.sysSigLoop.Conn().Object(sysNetworkDest, sysNetworkPath).
+		Call(sysNetworkInterface+"."+method, 0, args...).Err
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestParseVpnServerHost(c *C.C) {
+	c.Check(parseVpnServerHost("vpn.example.com"), C.Equals, "vpn.example.com")
+	c.Check(parseVpnServerHost(" 10.0.0.1:1194 "), C.Equals, "10.0.0.1")
+	c.Check(parseVpnServerHost("[2001:db8::1]:443"), C.Equals, "2001:db8::1")
+	// openvpn remote
+	c.Check(parseVpnServerHost("vpn.example.com 1194 udp"), C.Equals, "vpn.example.com")
+	// openconnect gateway
+	c.Check(parseVpnServerHost("https://vpn.example.com/group"), C.Equals, "vpn.example.com")
+}
+
+func (*testWrapper) TestGetVpnServerHosts(c *C.C) {
+	data := make(connectionData)
+	addSetting(data, nm.NM_SETTING_VPN_SETTING_NAME)
+	setSettingVpnData(data, map[string]string{
+		nm.NM_SETTING_VPN_OPENVPN_KEY_REMOTE: "a.example.com:1194, 10.0.0.1, a.example.com",
+	})
+	c.Check(getVpnServerHosts(data), C.DeepEquals, []string{"a.example.com", "10.0.0.1"})
+}
+
+func (*testWrapper) TestVpnKillSwitchEnabled(c *C.C) {
+	data := make(connectionData)
+	c.Check(isVpnKillSwitchEnabled(data), C.Equals, false)
+	setVpnKillSwitchEnabled(data, true)
+	c.Check(isVpnKillSwitchEnabled(data), C.Equals, true)
+	setVpnKillSwitchEnabled(data, false)
+	c.Check(isVpnKillSwitchEnabled(data), C.Equals, false)
+	c.Check(isSettingExists(data, nm.NM_SETTING_USER_SETTING_NAME), C.Equals, false)
+}
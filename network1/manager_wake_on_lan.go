@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"errors"
+	"fmt"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// 可以组合使用的唤醒方式
+const wakeOnLanFlagsMask = nm.NM_SETTING_WIRED_WAKE_ON_LAN_PHY | nm.NM_SETTING_WIRED_WAKE_ON_LAN_UNICAST |
+	nm.NM_SETTING_WIRED_WAKE_ON_LAN_MULTICAST | nm.NM_SETTING_WIRED_WAKE_ON_LAN_BROADCAST |
+	nm.NM_SETTING_WIRED_WAKE_ON_LAN_ARP | nm.NM_SETTING_WIRED_WAKE_ON_LAN_MAGIC
+
+// checkWakeOnLanArgs 检查唤醒方式和密码，default 和 ignore 不能与其他方式组合，
+// 密码为 MAC 地址格式，只对 magic packet 有效
+func checkWakeOnLanArgs(flags uint32, password string) error {
+	switch flags {
+	case nm.NM_SETTING_WIRED_WAKE_ON_LAN_DEFAULT, nm.NM_SETTING_WIRED_WAKE_ON_LAN_IGNORE:
+	default:
+		if flags&^wakeOnLanFlagsMask != 0 {
+			return fmt.Errorf("invalid wake-on-lan flags 0x%x", flags)
+		}
+	}
+	if password == "" {
+		return nil
+	}
+	if flags&nm.NM_SETTING_WIRED_WAKE_ON_LAN_MAGIC == 0 || flags&^wakeOnLanFlagsMask != 0 {
+		return errors.New("wake-on-lan password requires magic packet")
+	}
+	_, err := convertMacAddressToArrayByteCheck(password)
+	if err != nil {
+		return fmt.Errorf("invalid wake-on-lan password %q", password)
+	}
+	return nil
+}
+
+// GetWiredWakeOnLan get the wake-on-lan flags and password of the
+// connection of the wired device, flags is the combination of the
+// NM_SETTING_WIRED_WAKE_ON_LAN_* values.
+func (m *Manager) GetWiredWakeOnLan(devPath dbus.ObjectPath) (flags uint32, password string, busErr *dbus.Error) {
+	if nmGetDeviceType(devPath) != nm.NM_DEVICE_TYPE_ETHERNET {
+		return 0, "", dbusutil.ToError(fmt.Errorf("invalid wired device %q", devPath))
+	}
+	cpath, _, err := m.ensureWiredConnectionExists(devPath, false)
+	if err != nil {
+		return 0, "", dbusutil.ToError(err)
+	}
+	data, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return 0, "", dbusutil.ToError(err)
+	}
+	flags = nm.NM_SETTING_WIRED_WAKE_ON_LAN_DEFAULT
+	if isSettingWiredWakeOnLanExists(data) {
+		flags = getSettingWiredWakeOnLan(data)
+	}
+	return flags, getSettingWiredWakeOnLanPassword(data), nil
+}
+
+// SetWiredWakeOnLan set the wake-on-lan flags and password of the
+// connection of the wired device, password is optional and in MAC
+// address format, it only works with the magic packet flag.
+func (m *Manager) SetWiredWakeOnLan(devPath dbus.ObjectPath, flags uint32, password string) *dbus.Error {
+	err := m.setWiredWakeOnLan(devPath, flags, password)
+	if err != nil {
+		logger.Warning("failed to set wired wake-on-lan:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) setWiredWakeOnLan(devPath dbus.ObjectPath, flags uint32, password string) (err error) {
+	if nmGetDeviceType(devPath) != nm.NM_DEVICE_TYPE_ETHERNET {
+		return fmt.Errorf("invalid wired device %q", devPath)
+	}
+	err = checkWakeOnLanArgs(flags, password)
+	if err != nil {
+		return
+	}
+	cpath, _, err := m.ensureWiredConnectionExists(devPath, false)
+	if err != nil {
+		return
+	}
+	data, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return
+	}
+
+	setSettingWiredWakeOnLan(data, flags)
+	if password != "" {
+		setSettingWiredWakeOnLanPassword(data, password)
+	} else {
+		removeSettingWiredWakeOnLanPassword(data)
+	}
+	err = updateConnectionData(cpath, data)
+	if err != nil {
+		return
+	}
+
+	// 连接已激活时重新应用配置，使设置立即生效
+	if nmGetDeviceState(devPath) != nm.NM_DEVICE_STATE_ACTIVATED {
+		return nil
+	}
+	nmDev, err := nmNewDevice(devPath)
+	if err != nil {
+		return
+	}
+	err = nmDev.Device().Reapply(0, nil, 0, 0)
+	if err != nil {
+		logger.Warning("failed to reapply wired device:", err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestCheckWakeOnLanArgs(c *C.C) {
+	c.Check(checkWakeOnLanArgs(nm.NM_SETTING_WIRED_WAKE_ON_LAN_DEFAULT, ""), C.IsNil)
+	c.Check(checkWakeOnLanArgs(nm.NM_SETTING_WIRED_WAKE_ON_LAN_IGNORE, ""), C.IsNil)
+	c.Check(checkWakeOnLanArgs(0, ""), C.IsNil)
+	c.Check(checkWakeOnLanArgs(nm.NM_SETTING_WIRED_WAKE_ON_LAN_MAGIC|nm.NM_SETTING_WIRED_WAKE_ON_LAN_PHY, ""), C.IsNil)
+	c.Check(checkWakeOnLanArgs(nm.NM_SETTING_WIRED_WAKE_ON_LAN_MAGIC, "00:11:22:33:44:55"), C.IsNil)
+
+	c.Check(checkWakeOnLanArgs(nm.NM_SETTING_WIRED_WAKE_ON_LAN_DEFAULT|nm.NM_SETTING_WIRED_WAKE_ON_LAN_MAGIC, ""), C.NotNil)
+	c.Check(checkWakeOnLanArgs(0x100, ""), C.NotNil)
+	c.Check(checkWakeOnLanArgs(nm.NM_SETTING_WIRED_WAKE_ON_LAN_UNICAST, "00:11:22:33:44:55"), C.NotNil)
+	c.Check(checkWakeOnLanArgs(nm.NM_SETTING_WIRED_WAKE_ON_LAN_MAGIC, "00:11:22:33:44"), C.NotNil)
+}
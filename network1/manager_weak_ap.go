@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	dbus "github.com/godbus/dbus/v5"
+)
+
+// 信号强度低于该值的热点标记为 Ignored
+const defaultWeakAccessPointStrength = 10
+
+func isWeakAccessPoint(strength uint8, weakStrength uint32) bool {
+	return uint32(strength) < weakStrength
+}
+
+// filterWeakAccessPoints 过滤掉标记为 Ignored 的热点
+func filterWeakAccessPoints(accessPoints map[dbus.ObjectPath][]*accessPoint) map[dbus.ObjectPath][]*accessPoint {
+	result := make(map[dbus.ObjectPath][]*accessPoint, len(accessPoints))
+	for devPath, aps := range accessPoints {
+		filtered := make([]*accessPoint, 0, len(aps))
+		for _, ap := range aps {
+			if !ap.Ignored {
+				filtered = append(filtered, ap)
+			}
+		}
+		result[devPath] = filtered
+	}
+	return result
+}
+
+func (m *Manager) getWeakAccessPointStrength() uint32 {
+	m.PropsMu.RLock()
+	defer m.PropsMu.RUnlock()
+	return m.weakApStrength
+}
+
+// applyWeakAccessPointPolicy 弱信号阈值或是否显示弱信号热点变化后，重新标记热点并更新热点列表
+func (m *Manager) applyWeakAccessPointPolicy() {
+	weakStrength := m.getWeakAccessPointStrength()
+
+	m.accessPointsLock.Lock()
+	defer m.accessPointsLock.Unlock()
+	for _, aps := range m.accessPoints {
+		for _, ap := range aps {
+			ignored := isWeakAccessPoint(ap.Strength, weakStrength)
+			if ap.Ignored == ignored {
+				continue
+			}
+			ap.Ignored = ignored
+			m.apRevisions.touch(ap)
+			m.queueAccessPointChanged(ap)
+		}
+	}
+	m.PropsMu.Lock()
+	m.updatePropWirelessAccessPoints()
+	m.PropsMu.Unlock()
+}
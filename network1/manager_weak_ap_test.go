@@ -0,0 +1,32 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	dbus "github.com/godbus/dbus/v5"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestIsWeakAccessPoint(c *C.C) {
+	c.Check(isWeakAccessPoint(9, defaultWeakAccessPointStrength), C.Equals, true)
+	c.Check(isWeakAccessPoint(10, defaultWeakAccessPointStrength), C.Equals, false)
+	// 阈值为 0 时不标记任何热点
+	c.Check(isWeakAccessPoint(0, 0), C.Equals, false)
+}
+
+func (*testWrapper) TestFilterWeakAccessPoints(c *C.C) {
+	strong := &accessPoint{Ssid: "strong", Strength: 80}
+	weak := &accessPoint{Ssid: "weak", Strength: 5, Ignored: true}
+	aps := map[dbus.ObjectPath][]*accessPoint{
+		"/dev/0": {strong, weak},
+		"/dev/1": {weak},
+	}
+	c.Check(filterWeakAccessPoints(aps), C.DeepEquals, map[dbus.ObjectPath][]*accessPoint{
+		"/dev/0": {strong},
+		"/dev/1": {},
+	})
+	// 不修改原来的热点列表
+	c.Check(aps["/dev/0"], C.HasLen, 2)
+}
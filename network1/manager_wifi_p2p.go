@@ -0,0 +1,295 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	"github.com/linuxdeepin/go-lib/utils"
+)
+
+// NetworkManager 限制一次查找的时间在 1 到 600 秒之间
+const maxWifiP2PFindTimeout = 600
+
+type wifiP2PPeer struct {
+	Path         dbus.ObjectPath
+	Name         string
+	Manufacturer string
+	Model        string
+	HwAddress    string
+	Strength     uint8
+	Flags        uint32
+}
+
+func newWifiP2PPeer(path dbus.ObjectPath, props map[string]dbus.Variant) *wifiP2PPeer {
+	peer := &wifiP2PPeer{Path: path}
+	peer.Name, _ = props["Name"].Value().(string)
+	peer.Manufacturer, _ = props["Manufacturer"].Value().(string)
+	peer.Model, _ = props["Model"].Value().(string)
+	peer.HwAddress, _ = props["HwAddress"].Value().(string)
+	peer.Strength, _ = props["Strength"].Value().(uint8)
+	peer.Flags, _ = props["Flags"].Value().(uint32)
+	return peer
+}
+
+func newWifiP2PConnectionData(id, uuid, peerHwAddr string) (data connectionData) {
+	data = make(connectionData)
+
+	addSetting(data, nm.NM_SETTING_CONNECTION_SETTING_NAME)
+	setSettingConnectionId(data, id)
+	setSettingConnectionUuid(data, uuid)
+	setSettingConnectionType(data, nm.NM_SETTING_WIFI_P2P_SETTING_NAME)
+	// P2P 连接只在用户主动发起时激活
+	setSettingConnectionAutoconnect(data, false)
+
+	addSetting(data, nm.NM_SETTING_WIFI_P2P_SETTING_NAME)
+	setSettingKey(data, nm.NM_SETTING_WIFI_P2P_SETTING_NAME, nm.NM_SETTING_WIFI_P2P_PEER, peerHwAddr)
+
+	initSettingSectionIpv4(data)
+	initSettingSectionIpv6(data)
+	return
+}
+
+func isWifiP2PConnectionOfPeer(data connectionData, peerHwAddr string) bool {
+	if getSettingConnectionType(data) != nm.NM_SETTING_WIFI_P2P_SETTING_NAME {
+		return false
+	}
+	peer, _ := getSettingKey(data, nm.NM_SETTING_WIFI_P2P_SETTING_NAME, nm.NM_SETTING_WIFI_P2P_PEER).(string)
+	return strings.EqualFold(peer, peerHwAddr)
+}
+
+func nmGetWifiP2PPeers(devPath dbus.ObjectPath) (peers []dbus.ObjectPath, err error) {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return
+	}
+	obj := systemBus.Object(nm.NM_DBUS_SERVICE, devPath)
+	variant, err := obj.GetProperty(nm.NM_DBUS_INTERFACE_DEVICE_WIFI_P2P + ".Peers")
+	if err != nil {
+		return
+	}
+	peers, _ = variant.Value().([]dbus.ObjectPath)
+	return
+}
+
+func nmGetWifiP2PPeer(peerPath dbus.ObjectPath) (peer *wifiP2PPeer, err error) {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return
+	}
+	var props map[string]dbus.Variant
+	obj := systemBus.Object(nm.NM_DBUS_SERVICE, peerPath)
+	err = obj.Call("org.freedesktop.DBus.Properties.GetAll", 0, nm.NM_DBUS_INTERFACE_WIFI_P2P_PEER).Store(&props)
+	if err != nil {
+		return
+	}
+	return newWifiP2PPeer(peerPath, props), nil
+}
+
+func nmWifiP2PCall(devPath dbus.ObjectPath, method string, args ...interface{}) error {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	obj := systemBus.Object(nm.NM_DBUS_SERVICE, devPath)
+	return obj.Call(nm.NM_DBUS_INTERFACE_DEVICE_WIFI_P2P+"."+method, 0, args...).Err
+}
+
+func checkWifiP2PDevice(devPath dbus.ObjectPath) error {
+	if nmGetDeviceType(devPath) != nm.NM_DEVICE_TYPE_WIFI_P2P {
+		return fmt.Errorf("invalid wifi p2p device %q", devPath)
+	}
+	return nil
+}
+
+// initWifiP2PManage 转发 P2P 设备的 PeerAdded 和 PeerRemoved 信号
+func (m *Manager) initWifiP2PManage() {
+	for _, member := range []string{"PeerAdded", "PeerRemoved"} {
+		err := dbusutil.NewMatchRuleBuilder().
+			Type("signal").
+			Sender(nm.NM_DBUS_SERVICE).
+			Interface(nm.NM_DBUS_INTERFACE_DEVICE_WIFI_P2P).
+			Member(member).Build().
+			AddTo(m.sysSigLoop.Conn())
+		if err != nil {
+			logger.Warning(err)
+		}
+	}
+
+	m.sysSigLoop.AddHandler(&dbusutil.SignalRule{
+		Name: nm.NM_DBUS_INTERFACE_DEVICE_WIFI_P2P + ".PeerAdded",
+	}, func(sig *dbus.Signal) {
+		if len(sig.Body) != 1 {
+			return
+		}
+		peerPath, ok := sig.Body[0].(dbus.ObjectPath)
+		if !ok {
+			return
+		}
+		peer, err := nmGetWifiP2PPeer(peerPath)
+		if err != nil {
+			logger.Warning(err)
+			return
+		}
+		peerJSON, err := marshalJSON(peer)
+		if err != nil {
+			logger.Warning(err)
+			return
+		}
+		logger.Debugf("wifi p2p peer %s added to %s", peerPath, sig.Path)
+		err = m.service.Emit(m, "P2PPeerAdded", string(sig.Path), peerJSON)
+		if err != nil {
+			logger.Warning(err)
+		}
+	})
+	m.sysSigLoop.AddHandler(&dbusutil.SignalRule{
+		Name: nm.NM_DBUS_INTERFACE_DEVICE_WIFI_P2P + ".PeerRemoved",
+	}, func(sig *dbus.Signal) {
+		if len(sig.Body) != 1 {
+			return
+		}
+		peerPath, ok := sig.Body[0].(dbus.ObjectPath)
+		if !ok {
+			return
+		}
+		logger.Debugf("wifi p2p peer %s removed from %s", peerPath, sig.Path)
+		err := m.service.Emit(m, "P2PPeerRemoved", string(sig.Path), string(peerPath))
+		if err != nil {
+			logger.Warning(err)
+		}
+	})
+}
+
+// StartP2PFind start to discover Wi-Fi P2P peers on the device, timeout
+// is in seconds, 0 means the NetworkManager default of 30 seconds.
+func (m *Manager) StartP2PFind(devPath dbus.ObjectPath, timeout int32) *dbus.Error {
+	err := checkWifiP2PDevice(devPath)
+	if err == nil && (timeout < 0 || timeout > maxWifiP2PFindTimeout) {
+		err = fmt.Errorf("invalid timeout %d", timeout)
+	}
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+
+	options := make(map[string]dbus.Variant)
+	if timeout > 0 {
+		options["timeout"] = dbus.MakeVariant(timeout)
+	}
+	err = nmWifiP2PCall(devPath, "StartFind", options)
+	if err != nil {
+		logger.Warning("failed to start wifi p2p find:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+// StopP2PFind stop discovering Wi-Fi P2P peers on the device.
+func (m *Manager) StopP2PFind(devPath dbus.ObjectPath) *dbus.Error {
+	err := checkWifiP2PDevice(devPath)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	err = nmWifiP2PCall(devPath, "StopFind")
+	if err != nil {
+		logger.Warning("failed to stop wifi p2p find:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+// GetP2PPeers get the Wi-Fi P2P peers discovered by the device, marshaled
+// by json.
+func (m *Manager) GetP2PPeers(devPath dbus.ObjectPath) (peersJSON string, busErr *dbus.Error) {
+	err := checkWifiP2PDevice(devPath)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	peerPaths, err := nmGetWifiP2PPeers(devPath)
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	peers := make([]*wifiP2PPeer, 0, len(peerPaths))
+	for _, peerPath := range peerPaths {
+		peer, err := nmGetWifiP2PPeer(peerPath)
+		if err != nil {
+			logger.Debug(err)
+			continue
+		}
+		peers = append(peers, peer)
+	}
+	peersJSON, err = marshalJSON(peers)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	return peersJSON, nil
+}
+
+// ConnectP2PPeer connect to the Wi-Fi P2P peer from the device, the
+// connection of the peer is created if not exists.
+func (m *Manager) ConnectP2PPeer(devPath, peerPath dbus.ObjectPath) (connection dbus.ObjectPath, busErr *dbus.Error) {
+	cpath, err := m.connectP2PPeer(devPath, peerPath)
+	if err != nil {
+		logger.Warning("failed to connect wifi p2p peer:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+func (m *Manager) connectP2PPeer(devPath, peerPath dbus.ObjectPath) (cpath dbus.ObjectPath, err error) {
+	cpath = "/"
+	err = checkWifiP2PDevice(devPath)
+	if err != nil {
+		return
+	}
+	peer, err := nmGetWifiP2PPeer(peerPath)
+	if err != nil {
+		return
+	}
+	if peer.HwAddress == "" {
+		err = fmt.Errorf("invalid wifi p2p peer %q", peerPath)
+		return
+	}
+
+	for _, path := range nmGetConnectionList() {
+		data, err := nmGetConnectionData(path)
+		if err != nil || !isWifiP2PConnectionOfPeer(data, peer.HwAddress) {
+			continue
+		}
+		_, err = nmActivateConnection(path, devPath)
+		return path, err
+	}
+
+	id := peer.Name
+	if id == "" {
+		id = peer.HwAddress
+	}
+	data := newWifiP2PConnectionData(id, utils.GenUuid(), peer.HwAddress)
+	cpath, _, err = nmAddAndActivateConnection(data, devPath, true)
+	return
+}
+
+// DisconnectP2PPeer disconnect the Wi-Fi P2P connection of the device.
+func (m *Manager) DisconnectP2PPeer(devPath dbus.ObjectPath) *dbus.Error {
+	err := checkWifiP2PDevice(devPath)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	apath := nmGetDeviceActiveConnection(devPath)
+	if apath == "" || apath == "/" {
+		return dbusutil.ToError(errors.New("wifi p2p device is not connected"))
+	}
+	err = nmDeactivateConnection(apath)
+	if err != nil {
+		logger.Warning("failed to disconnect wifi p2p peer:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestNewWifiP2PPeer(c *C.C) {
+	props := map[string]dbus.Variant{
+		"Name":      dbus.MakeVariant("tv"),
+		"Model":     dbus.MakeVariant("m1"),
+		"HwAddress": dbus.MakeVariant("00:11:22:33:44:55"),
+		"Strength":  dbus.MakeVariant(uint8(80)),
+		"Flags":     dbus.MakeVariant(uint32(1)),
+	}
+	peer := newWifiP2PPeer("/peer/0", props)
+	c.Check(peer.Path, C.Equals, dbus.ObjectPath("/peer/0"))
+	c.Check(peer.Name, C.Equals, "tv")
+	c.Check(peer.Model, C.Equals, "m1")
+	c.Check(peer.Manufacturer, C.Equals, "")
+	c.Check(peer.HwAddress, C.Equals, "00:11:22:33:44:55")
+	c.Check(peer.Strength, C.Equals, uint8(80))
+	c.Check(peer.Flags, C.Equals, uint32(1))
+}
+
+func (*testWrapper) TestNewWifiP2PConnectionData(c *C.C) {
+	data := newWifiP2PConnectionData("tv", "uuid", "00:11:22:33:44:55")
+	c.Check(getSettingConnectionType(data), C.Equals, nm.NM_SETTING_WIFI_P2P_SETTING_NAME)
+	c.Check(getSettingConnectionAutoconnect(data), C.Equals, false)
+	c.Check(isWifiP2PConnectionOfPeer(data, "00:11:22:33:44:55"), C.Equals, true)
+	c.Check(isWifiP2PConnectionOfPeer(data, "00:11:22:33:44:AA"), C.Equals, false)
+	// mac 地址不区分大小写
+	c.Check(isWifiP2PConnectionOfPeer(newWifiP2PConnectionData("tv", "uuid", "aa:bb:cc:dd:ee:ff"),
+		"AA:BB:CC:DD:EE:FF"), C.Equals, true)
+}
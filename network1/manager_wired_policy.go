@@ -0,0 +1,278 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"errors"
+	"fmt"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// SetAutoWifiOffOnWired set whether to disconnect wireless network
+// automatically while a wired connection is activated.
+func (m *Manager) SetAutoWifiOffOnWired(enabled bool) *dbus.Error {
+	if m.networkConfig == nil {
+		return dbusutil.ToError(errors.New("network dconfig is not available"))
+	}
+	err := m.networkConfig.SetValue(0, dsettingsAutoWifiOffOnWired, dbus.MakeVariant(enabled))
+	if err != nil {
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	m.setAutoWifiOffOnWired(enabled)
+	return nil
+}
+
+// 有线网络连接时处理无线网络的方式
+const (
+	// 断开无线网络
+	wifiOffOnWiredModeDisconnect = "disconnect"
+	// 保持无线网络连接，但降低其路由优先级
+	wifiOffOnWiredModeDeprioritize = "deprioritize"
+)
+
+// 降低优先级时无线网络使用的路由 metric，NetworkManager 默认有线为 100，无线为 600
+const wirelessDeprioritizedRouteMetric = 20600
+
+func isValidWifiOffOnWiredMode(mode string) bool {
+	return mode == wifiOffOnWiredModeDisconnect || mode == wifiOffOnWiredModeDeprioritize
+}
+
+// SetWifiOffOnWiredMode set how to handle the wireless network while a
+// wired connection is activated and AutoWifiOffOnWired is enabled, mode
+// is "disconnect" or "deprioritize" which keeps the wireless network
+// connected with a lower route priority.
+func (m *Manager) SetWifiOffOnWiredMode(mode string) *dbus.Error {
+	if !isValidWifiOffOnWiredMode(mode) {
+		return dbusutil.ToError(fmt.Errorf("invalid mode %q", mode))
+	}
+	if m.networkConfig == nil {
+		return dbusutil.ToError(errors.New("network dconfig is not available"))
+	}
+	err := m.networkConfig.SetValue(0, dsettingsWifiOffOnWiredMode, dbus.MakeVariant(mode))
+	if err != nil {
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	m.setWifiOffOnWiredMode(mode)
+	return nil
+}
+
+func (m *Manager) setWifiOffOnWiredMode(mode string) {
+	m.PropsMu.Lock()
+	changed := m.setPropWifiOffOnWiredMode(mode)
+	m.PropsMu.Unlock()
+	if changed {
+		go m.checkWifiOffOnWired()
+	}
+}
+
+func (m *Manager) setAutoWifiOffOnWired(enabled bool) {
+	m.PropsMu.Lock()
+	changed := m.setPropAutoWifiOffOnWired(enabled)
+	m.PropsMu.Unlock()
+	if changed {
+		go m.checkWifiOffOnWired()
+	}
+}
+
+// getActivatedConnectionState 返回当前是否存在已激活的有线连接和无线连接
+func (m *Manager) getActivatedConnectionState() (wiredActivated, wirelessActivated bool) {
+	m.activeConnectionsLock.Lock()
+	defer m.activeConnectionsLock.Unlock()
+	for _, aConn := range m.activeConnections {
+		if aConn.State != nm.NM_ACTIVE_CONNECTION_STATE_ACTIVATED {
+			continue
+		}
+		switch aConn.typ {
+		case nm.NM_SETTING_WIRED_SETTING_NAME:
+			wiredActivated = true
+		case nm.NM_SETTING_WIRELESS_SETTING_NAME:
+			wirelessActivated = true
+		}
+	}
+	return
+}
+
+// checkWifiOffOnWired 根据有线网络的连接状态断开或恢复无线网络
+func (m *Manager) checkWifiOffOnWired() {
+	m.wiredPolicyLock.Lock()
+	defer m.wiredPolicyLock.Unlock()
+
+	wiredActivated, wirelessActivated := m.getActivatedConnectionState()
+
+	m.PropsMu.RLock()
+	enabled := m.AutoWifiOffOnWired
+	mode := m.WifiOffOnWiredMode
+	suppressed := m.WifiSuppressedByWired
+	m.PropsMu.RUnlock()
+
+	// 切换处理方式时先恢复无线网络
+	if suppressed && m.wifiSuppressMode != mode {
+		m.restoreWireless()
+		suppressed = false
+	}
+
+	if !wiredActivated || !enabled {
+		if !wiredActivated {
+			m.wifiForcedOnWired = false
+		}
+		if suppressed {
+			m.restoreWireless()
+		}
+		return
+	}
+
+	if mode == wifiOffOnWiredModeDeprioritize {
+		if !wirelessActivated {
+			return
+		}
+		// 已降低优先级时也需要处理之后新激活的无线连接
+		if !suppressed {
+			logger.Info("wired connection is activated, deprioritize wireless network")
+		}
+		m.deprioritizeWirelessDevices()
+		m.wifiSuppressMode = mode
+		m.setWifiSuppressedByWired(true)
+		return
+	}
+
+	if suppressed {
+		// 有线网络连接期间用户手动连接了无线网络，不再断开
+		if wirelessActivated {
+			logger.Info("wireless network is activated by user while wired connection is activated")
+			m.wifiForcedOnWired = true
+			m.setWifiSuppressedByWired(false)
+		}
+		return
+	}
+
+	if m.wifiForcedOnWired || !wirelessActivated {
+		return
+	}
+
+	logger.Info("wired connection is activated, disconnect wireless network")
+	m.disconnectWirelessDevices()
+	m.wifiSuppressMode = mode
+	m.setWifiSuppressedByWired(true)
+}
+
+func (m *Manager) restoreWireless() {
+	logger.Info("restore wireless network")
+	if m.wifiSuppressMode == wifiOffOnWiredModeDeprioritize {
+		m.restoreWirelessRouteMetric()
+	} else {
+		m.restoreWirelessDevices()
+	}
+	m.setWifiSuppressedByWired(false)
+}
+
+func (m *Manager) setWifiSuppressedByWired(value bool) {
+	m.PropsMu.Lock()
+	m.setPropWifiSuppressedByWired(value)
+	m.PropsMu.Unlock()
+}
+
+func (m *Manager) getWirelessDevicePaths() []dbus.ObjectPath {
+	m.devicesLock.Lock()
+	defer m.devicesLock.Unlock()
+	var devPaths []dbus.ObjectPath
+	for _, dev := range m.devices[deviceWifi] {
+		devPaths = append(devPaths, dev.Path)
+	}
+	return devPaths
+}
+
+func (m *Manager) disconnectWirelessDevices() {
+	for _, devPath := range m.getWirelessDevicePaths() {
+		nmDev, err := nmNewDevice(devPath)
+		if err != nil {
+			continue
+		}
+		// 不断开热点
+		mode, _ := nmDev.Wireless().Mode().Get(0)
+		if mode == nm.NM_802_11_MODE_AP {
+			continue
+		}
+		err = m.doDisconnectDevice(devPath)
+		if err != nil {
+			logger.Warning(err)
+		}
+	}
+}
+
+func (m *Manager) restoreWirelessDevices() {
+	// DisconnectDevice 会关闭设备的自动连接，恢复后由 NetworkManager 自动回连
+	for _, devPath := range m.getWirelessDevicePaths() {
+		nmSetDeviceAutoconnect(devPath, true)
+	}
+}
+
+// setAppliedRouteMetric 修改已应用连接的路由 metric，返回连接是否被修改
+func setAppliedRouteMetric(data connectionData, metric int64) (changed bool) {
+	for _, setting := range []string{nm.NM_SETTING_IP4_CONFIG_SETTING_NAME, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME} {
+		if !isSettingExists(data, setting) {
+			continue
+		}
+		if value, _ := getSettingKey(data, setting, nm.NM_SETTING_IP_CONFIG_ROUTE_METRIC).(int64); value == metric {
+			continue
+		}
+		setSettingKey(data, setting, nm.NM_SETTING_IP_CONFIG_ROUTE_METRIC, metric)
+		changed = true
+	}
+	return
+}
+
+// deprioritizeWirelessDevices 通过 Reapply 临时提高无线连接的路由 metric，不修改保存的连接配置
+func (m *Manager) deprioritizeWirelessDevices() {
+	for _, devPath := range m.getWirelessDevicePaths() {
+		nmDev, err := nmNewDevice(devPath)
+		if err != nil {
+			continue
+		}
+		mode, _ := nmDev.Wireless().Mode().Get(0)
+		if mode == nm.NM_802_11_MODE_AP {
+			continue
+		}
+		state, _ := nmDev.Device().State().Get(0)
+		if state != nm.NM_DEVICE_STATE_ACTIVATED {
+			continue
+		}
+		data, version, err := nmDev.Device().GetAppliedConnection(0, 0)
+		if err != nil {
+			logger.Warning(err)
+			continue
+		}
+		if !setAppliedRouteMetric(data, wirelessDeprioritizedRouteMetric) {
+			continue
+		}
+		fixConnectionIP6Data(data)
+		err = nmDev.Device().Reapply(0, data, version, 0)
+		if err != nil {
+			logger.Warning("failed to deprioritize wireless device:", err)
+		}
+	}
+}
+
+// restoreWirelessRouteMetric 使用保存的连接配置重新应用，恢复路由 metric
+func (m *Manager) restoreWirelessRouteMetric() {
+	for _, devPath := range m.getWirelessDevicePaths() {
+		nmDev, err := nmNewDevice(devPath)
+		if err != nil {
+			continue
+		}
+		state, _ := nmDev.Device().State().Get(0)
+		if state != nm.NM_DEVICE_STATE_ACTIVATED {
+			continue
+		}
+		err = nmDev.Device().Reapply(0, nil, 0, 0)
+		if err != nil {
+			logger.Warning("failed to restore wireless device:", err)
+		}
+	}
+}
@@ -0,0 +1,25 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestSetAppliedRouteMetric(c *C.C) {
+	data := make(connectionData)
+	addSetting(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME)
+	addSetting(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME)
+	setSettingKey(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME, nm.NM_SETTING_IP_CONFIG_ROUTE_METRIC, int64(-1))
+
+	c.Check(setAppliedRouteMetric(data, wirelessDeprioritizedRouteMetric), C.Equals, true)
+	for _, setting := range []string{nm.NM_SETTING_IP4_CONFIG_SETTING_NAME, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME} {
+		c.Check(getSettingKey(data, setting, nm.NM_SETTING_IP_CONFIG_ROUTE_METRIC), C.Equals,
+			int64(wirelessDeprioritizedRouteMetric))
+	}
+	// 已经修改过时不需要重新应用
+	c.Check(setAppliedRouteMetric(data, wirelessDeprioritizedRouteMetric), C.Equals, false)
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	"github.com/linuxdeepin/go-lib/utils"
+)
+
+const (
+	defaultWireguardInterface = "wg0"
+	maxInterfaceNameLength    = 15
+)
+
+// getWireguardInterfaceName 根据连接名生成合法的网卡名
+func getWireguardInterfaceName(id string) string {
+	var sb strings.Builder
+	for _, r := range id {
+		if sb.Len() >= maxInterfaceNameLength {
+			break
+		}
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+			strings.ContainsRune("_=+.-", r) {
+			sb.WriteRune(r)
+		}
+	}
+	ifc := sb.String()
+	if ifc == "" || ifc == "." || ifc == ".." {
+		return defaultWireguardInterface
+	}
+	return ifc
+}
+
+func (m *Manager) addWireguardConnection(id string, cfg *wireguardConfig) (cpath dbus.ObjectPath, err error) {
+	cpath = "/"
+	err = cfg.check()
+	if err != nil {
+		return
+	}
+	if id == "" {
+		id = defaultWireguardInterface
+	}
+	data := newWireguardConnectionData(id, utils.GenUuid(), getWireguardInterfaceName(id), cfg)
+	return nmAddConnection(data)
+}
+
+// GenerateWireGuardKeyPair generate a base64 encoded private key and
+// the matched public key for WireGuard.
+func (m *Manager) GenerateWireGuardKeyPair() (privateKey, publicKey string, busErr *dbus.Error) {
+	privateKey, publicKey, err := generateWireguardKeyPair()
+	if err != nil {
+		logger.Warning(err)
+		return "", "", dbusutil.ToError(err)
+	}
+	return privateKey, publicKey, nil
+}
+
+// CreateWireGuardConnection create a WireGuard connection, configJSON is
+// the marshaled wireguardConfig.
+func (m *Manager) CreateWireGuardConnection(id, configJSON string) (connection dbus.ObjectPath, busErr *dbus.Error) {
+	var cfg wireguardConfig
+	err := json.Unmarshal([]byte(configJSON), &cfg)
+	if err != nil {
+		logger.Warning(err)
+		return "/", dbusutil.ToError(err)
+	}
+	cpath, err := m.addWireguardConnection(id, &cfg)
+	if err != nil {
+		logger.Warning("failed to create wireguard connection:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+// ImportWireGuardConfig create a WireGuard connection from a wg-quick
+// configuration file, the connection is named after the file.
+func (m *Manager) ImportWireGuardConfig(filename string) (connection dbus.ObjectPath, busErr *dbus.Error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		logger.Warning(err)
+		return "/", dbusutil.ToError(err)
+	}
+	cfg, err := parseWgQuickConfig(content)
+	if err != nil {
+		logger.Warningf("failed to parse wireguard config %s: %v", filename, err)
+		return "/", dbusutil.ToError(err)
+	}
+	id := strings.TrimSuffix(filepath.Base(filename), ".conf")
+	cpath, err := m.addWireguardConnection(id, cfg)
+	if err != nil {
+		logger.Warning("failed to import wireguard connection:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
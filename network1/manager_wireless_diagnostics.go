@@ -0,0 +1,226 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/iw"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+const (
+	ieHTCapabilities  = 45
+	ieVHTCapabilities = 191
+	ieExtension       = 255
+	// Extension 元素中的 HE Capabilities
+	ieExtHECapabilities = 35
+)
+
+// 无线连接的 PHY 模式
+const (
+	phyModeB       = "b"
+	phyModeG       = "g"
+	phyModeA       = "a"
+	phyModeN       = "n"
+	phyModeAC      = "ac"
+	phyModeAX      = "ax"
+	phyModeUnknown = "unknown"
+)
+
+const procNetWirelessFile = "/proc/net/wireless"
+
+type wirelessDiagnostics struct {
+	Interface string
+	// 以下为当前连接的热点信息，未连接时为空
+	Ssid      string
+	Bssid     string
+	Frequency uint32
+	Channel   uint32
+	Band      string
+	// 信道宽度，如 "80 MHz"
+	ChannelWidth string
+	PhyMode      string
+	// 当前速率，单位 kb/s
+	Bitrate  uint32
+	Strength uint8
+	// 信号和底噪，单位 dBm，0 表示未知
+	Signal int32
+	Noise  int32
+	// 发射功率，单位 dBm，-1 表示未知
+	TxPower float64
+	// /proc/net/wireless 中的统计，重传失败和其他原因丢弃的包，以及丢失的信标数
+	TxRetryDiscarded uint64
+	MiscDiscarded    uint64
+	MissedBeacons    uint64
+	// 网络接口的发送统计
+	TxPackets uint64
+	TxErrors  uint64
+	TxDropped uint64
+}
+
+// getPhyModeFromIEs 根据热点广播的能力信息元素判断支持的最高 PHY 模式
+func getPhyModeFromIEs(ies []byte, freq uint32) string {
+	var ht, vht, he bool
+	for len(ies) >= 2 {
+		id, length := ies[0], int(ies[1])
+		if len(ies) < 2+length {
+			break
+		}
+		switch id {
+		case ieHTCapabilities:
+			ht = true
+		case ieVHTCapabilities:
+			vht = true
+		case ieExtension:
+			if length > 0 && ies[2] == ieExtHECapabilities {
+				he = true
+			}
+		}
+		ies = ies[2+length:]
+	}
+	switch {
+	case he:
+		return phyModeAX
+	case vht:
+		return phyModeAC
+	case ht:
+		return phyModeN
+	case isFrequencyInBand(freq, bandBG):
+		return phyModeG
+	case isHighBandFrequency(freq):
+		return phyModeA
+	}
+	return phyModeUnknown
+}
+
+// parseProcNetWireless 解析 /proc/net/wireless 中网络接口的丢弃包和丢失信标的统计
+func parseProcNetWireless(content, ifc string) (retry, misc, missedBeacons uint64, ok bool) {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// face status link level noise nwid crypt frag retry misc beacon
+		if len(fields) < 11 || fields[0] != ifc+":" {
+			continue
+		}
+		var err error
+		retry, err = strconv.ParseUint(fields[8], 10, 64)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		misc, err = strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		missedBeacons, err = strconv.ParseUint(fields[10], 10, 64)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+		return retry, misc, missedBeacons, true
+	}
+	return 0, 0, 0, false
+}
+
+func readNetStatistics(ifc, name string) uint64 {
+	content, err := os.ReadFile(fmt.Sprintf("/sys/class/net/%s/statistics/%s", ifc, name))
+	if err != nil {
+		return 0
+	}
+	value, _ := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+	return value
+}
+
+// getSupplicantSignalPoll 通过 wpa_supplicant 获取当前连接的信号、底噪和信道宽度
+func getSupplicantSignalPoll(ifc string) (props map[string]dbus.Variant, err error) {
+	systemBus, ifcPath, err := getSupplicantInterface(ifc)
+	if err != nil {
+		return
+	}
+	err = systemBus.Object(wpaSupplicantService, ifcPath).
+		Call(wpaSupplicantInterface+".SignalPoll", 0).Store(&props)
+	return
+}
+
+// GetWirelessDiagnostics return the diagnostics of the wireless device
+// which marshaled by json, including current channel, PHY mode, bitrate,
+// signal, noise, tx power and retransmission statistics.
+func (m *Manager) GetWirelessDiagnostics(devPath dbus.ObjectPath) (diagnosticsJSON string, busErr *dbus.Error) {
+	diag, err := m.getWirelessDiagnostics(devPath)
+	if err != nil {
+		logger.Warning("failed to get wireless diagnostics:", err)
+		return "", dbusutil.ToError(err)
+	}
+	diagnosticsJSON, err = marshalJSON(diag)
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	return diagnosticsJSON, nil
+}
+
+func (m *Manager) getWirelessDiagnostics(devPath dbus.ObjectPath) (diag *wirelessDiagnostics, err error) {
+	devType, i := m.getDeviceIndex(devPath)
+	if i < 0 || devType != deviceWifi {
+		return nil, fmt.Errorf("invalid wireless device %q", devPath)
+	}
+	nmDev, err := nmNewDevice(devPath)
+	if err != nil {
+		return
+	}
+	diag = &wirelessDiagnostics{
+		PhyMode: phyModeUnknown,
+		TxPower: -1,
+	}
+	diag.Interface, _ = nmDev.Device().Interface().Get(0)
+	diag.Bitrate, _ = nmDev.Wireless().Bitrate().Get(0)
+
+	if txPower, err := iw.GetTxPower(diag.Interface); err == nil {
+		diag.TxPower = txPower
+	} else {
+		logger.Debug(err)
+	}
+	if content, err := os.ReadFile(procNetWirelessFile); err == nil {
+		diag.TxRetryDiscarded, diag.MiscDiscarded, diag.MissedBeacons, _ =
+			parseProcNetWireless(string(content), diag.Interface)
+	}
+	diag.TxPackets = readNetStatistics(diag.Interface, "tx_packets")
+	diag.TxErrors = readNetStatistics(diag.Interface, "tx_errors")
+	diag.TxDropped = readNetStatistics(diag.Interface, "tx_dropped")
+
+	apPath, _ := nmDev.Wireless().ActiveAccessPoint().Get(0)
+	if !isNmObjectPathValid(apPath) {
+		return diag, nil
+	}
+	nmAp, err := nmNewAccessPoint(apPath)
+	if err != nil {
+		return diag, nil
+	}
+	ssid, _ := nmAp.Ssid().Get(0)
+	diag.Ssid = decodeSsid(ssid)
+	diag.Bssid, _ = nmAp.HwAddress().Get(0)
+	diag.Frequency, _ = nmAp.Frequency().Get(0)
+	diag.Channel = getChannelByFrequency(diag.Frequency)
+	diag.Band = getBandByFrequency(diag.Frequency)
+	diag.Strength, _ = nmAp.Strength().Get(0)
+
+	if iesMap, err := getSupplicantBssIEs(diag.Interface); err == nil {
+		diag.PhyMode = getPhyModeFromIEs(iesMap[strings.ToLower(diag.Bssid)], diag.Frequency)
+	} else {
+		logger.Debug("failed to get bss information elements:", err)
+	}
+	if props, err := getSupplicantSignalPoll(diag.Interface); err == nil {
+		diag.Signal, _ = props["rssi"].Value().(int32)
+		diag.Noise, _ = props["noise"].Value().(int32)
+		diag.ChannelWidth, _ = props["width"].Value().(string)
+	} else {
+		logger.Debug("failed to poll signal:", err)
+	}
+	return diag, nil
+}
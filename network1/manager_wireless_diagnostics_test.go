@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestGetPhyModeFromIEs(c *C.C) {
+	ssid := []byte{0, 3, 'a', 'b', 'c'}
+	ht := []byte{45, 2, 0, 0}
+	vht := []byte{191, 2, 0, 0}
+	he := []byte{255, 3, 35, 0, 0}
+	join := func(items ...[]byte) (ies []byte) {
+		for _, item := range items {
+			ies = append(ies, item...)
+		}
+		return
+	}
+
+	c.Check(getPhyModeFromIEs(ssid, 2412), C.Equals, phyModeG)
+	c.Check(getPhyModeFromIEs(ssid, 5180), C.Equals, phyModeA)
+	c.Check(getPhyModeFromIEs(join(ssid, ht), 2412), C.Equals, phyModeN)
+	c.Check(getPhyModeFromIEs(join(ssid, ht, vht), 5180), C.Equals, phyModeAC)
+	c.Check(getPhyModeFromIEs(join(ssid, ht, vht, he), 5180), C.Equals, phyModeAX)
+	// 长度不合法的元素被忽略
+	c.Check(getPhyModeFromIEs([]byte{45, 5, 0}, 60480), C.Equals, phyModeUnknown)
+}
+
+func (*testWrapper) TestParseProcNetWireless(c *C.C) {
+	content := `Inter-| sta-|   Quality        |   Discarded packets               | Missed | WE
+ face | tus | link level noise |  nwid  crypt   frag  retry   misc | beacon | 22
+wlp2s0: 0000   58.  -52.  -256        0      0      0     12      3        7
+`
+	retry, misc, missed, ok := parseProcNetWireless(content, "wlp2s0")
+	c.Check(ok, C.Equals, true)
+	c.Check(retry, C.Equals, uint64(12))
+	c.Check(misc, C.Equals, uint64(3))
+	c.Check(missed, C.Equals, uint64(7))
+
+	_, _, _, ok = parseProcNetWireless(content, "wlan0")
+	c.Check(ok, C.Equals, false)
+}
@@ -0,0 +1,255 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// 有多个无线网卡时每个网卡的角色
+const (
+	// 作为客户端连接网络，只在主网卡上自动漫游和切换频段
+	wirelessRolePrimary = "primary"
+	// 作为客户端连接网络，不自动漫游
+	wirelessRoleSecondary = "secondary"
+	// 开启了热点
+	wirelessRoleHotspot = "hotspot"
+)
+
+type wirelessRoleDevice struct {
+	path    dbus.ObjectPath
+	ifc     string
+	hwAddr  string
+	hotspot bool
+}
+
+// getWirelessRoles 开启热点的网卡为 hotspot，其余网卡中 primary 指定的网卡为主网卡，
+// primary 为网卡的 MAC 地址或接口名，未指定或网卡不存在时使用第一个网卡
+func getWirelessRoles(devs []wirelessRoleDevice, primary string) map[dbus.ObjectPath]string {
+	roles := make(map[dbus.ObjectPath]string, len(devs))
+	var primaryPath dbus.ObjectPath
+	for _, dev := range devs {
+		if dev.hotspot {
+			roles[dev.path] = wirelessRoleHotspot
+			continue
+		}
+		roles[dev.path] = wirelessRoleSecondary
+		if primary != "" && (strings.EqualFold(dev.hwAddr, primary) || dev.ifc == primary) {
+			primaryPath = dev.path
+		}
+	}
+	if primaryPath == "" {
+		for _, dev := range devs {
+			if !dev.hotspot {
+				primaryPath = dev.path
+				break
+			}
+		}
+	}
+	if primaryPath != "" {
+		roles[primaryPath] = wirelessRolePrimary
+	}
+	return roles
+}
+
+func isWirelessRolesEqual(a, b map[dbus.ObjectPath]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, role := range a {
+		if b[path] != role {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Manager) setPrimaryWirelessDevice(primary string) {
+	m.wirelessRoleLock.Lock()
+	m.primaryWirelessDevice = primary
+	m.wirelessRoleLock.Unlock()
+	logger.Info("primary wireless device:", primary)
+	m.updateWirelessRoles()
+}
+
+func (m *Manager) getWirelessRoleDevices() []wirelessRoleDevice {
+	m.devicesLock.Lock()
+	defer m.devicesLock.Unlock()
+	devs := make([]wirelessRoleDevice, 0, len(m.devices[deviceWifi]))
+	for _, dev := range m.devices[deviceWifi] {
+		devs = append(devs, wirelessRoleDevice{
+			path:    dev.Path,
+			ifc:     dev.Interface,
+			hwAddr:  dev.HwAddress,
+			hotspot: dev.hotspotEnabled,
+		})
+	}
+	return devs
+}
+
+// updateWirelessRoles 无线网卡增删、热点开关或主网卡变化时重新计算网卡的角色
+func (m *Manager) updateWirelessRoles() {
+	devs := m.getWirelessRoleDevices()
+	m.wirelessRoleLock.Lock()
+	roles := getWirelessRoles(devs, m.primaryWirelessDevice)
+	if isWirelessRolesEqual(roles, m.wirelessRoles) {
+		m.wirelessRoleLock.Unlock()
+		return
+	}
+	m.wirelessRoles = roles
+	m.wirelessRoleLock.Unlock()
+
+	logger.Info("wireless device roles changed:", roles)
+	rolesJSON, err := marshalJSON(roles)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	err = m.service.Emit(m, "WirelessDeviceRolesChanged", rolesJSON)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+func (m *Manager) getWirelessRole(devPath dbus.ObjectPath) string {
+	m.wirelessRoleLock.Lock()
+	defer m.wirelessRoleLock.Unlock()
+	return m.wirelessRoles[devPath]
+}
+
+// getWirelessClientConnection 获取网卡作为客户端连接的无线连接的 uuid
+func getWirelessClientConnection(devPath dbus.ObjectPath) string {
+	nmDev, err := nmNewDevice(devPath)
+	if err != nil {
+		return ""
+	}
+	mode, _ := nmDev.Wireless().Mode().Get(0)
+	if mode != nm.NM_802_11_MODE_INFRA {
+		return ""
+	}
+	apath, _ := nmDev.Device().ActiveConnection().Get(0)
+	if !isNmObjectPathValid(apath) {
+		return ""
+	}
+	aConn, err := nmNewActiveConnection(apath)
+	if err != nil {
+		return ""
+	}
+	uuid, _ := aConn.Uuid().Get(0)
+	return uuid
+}
+
+// handOverWirelessClient 网卡开启热点后，由其他空闲的无线网卡连接原来的网络，优先使用主网卡
+func (m *Manager) handOverWirelessClient(hotspotPath dbus.ObjectPath, uuid string) {
+	var targetPath dbus.ObjectPath
+	for _, dev := range m.getWirelessRoleDevices() {
+		if dev.path == hotspotPath || dev.hotspot {
+			continue
+		}
+		if nmGetDeviceState(dev.path) != nm.NM_DEVICE_STATE_DISCONNECTED {
+			continue
+		}
+		if targetPath == "" || m.getWirelessRole(dev.path) == wirelessRolePrimary {
+			targetPath = dev.path
+		}
+	}
+	if targetPath == "" {
+		logger.Debug("no idle wireless device to keep the connection", uuid)
+		return
+	}
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	// 连接绑定了开启热点的网卡时无法在其他网卡上激活
+	err = unbindWirelessConnection(cpath)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	logger.Infof("hotspot is enabled on %s, connect %s on %s", hotspotPath, uuid, targetPath)
+	_, err = nmActivateConnection(cpath, targetPath)
+	if err != nil {
+		logger.Warning("failed to hand over wireless connection:", err)
+	}
+}
+
+// unbindWirelessConnection 清除无线连接绑定的网卡 mac 地址和接口名
+func unbindWirelessConnection(cpath dbus.ObjectPath) error {
+	data, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return err
+	}
+	if len(getSettingWirelessMacAddress(data)) == 0 && getSettingConnectionInterfaceName(data) == "" {
+		return nil
+	}
+	removeSettingWirelessMacAddress(data)
+	removeSettingConnectionInterfaceName(data)
+	return updateConnectionData(cpath, data)
+}
+
+// SetPrimaryWirelessDevice designate the wireless device as the primary
+// adapter when there are multiple wireless devices, roaming and band
+// switching only work on the primary adapter. The device is remembered
+// by its mac address.
+func (m *Manager) SetPrimaryWirelessDevice(devPath dbus.ObjectPath) *dbus.Error {
+	err := m.doSetPrimaryWirelessDevice(devPath)
+	if err != nil {
+		logger.Warning("failed to set primary wireless device:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (m *Manager) doSetPrimaryWirelessDevice(devPath dbus.ObjectPath) error {
+	var primary string
+	for _, dev := range m.getWirelessRoleDevices() {
+		if dev.path != devPath {
+			continue
+		}
+		primary = dev.hwAddr
+		if primary == "" {
+			primary = dev.ifc
+		}
+		break
+	}
+	if primary == "" {
+		return fmt.Errorf("not a wireless device %s", devPath)
+	}
+	if m.networkConfig == nil {
+		return errors.New("network dconfig is not available")
+	}
+	err := m.networkConfig.SetValue(0, dsettingsPrimaryWirelessDevice, dbus.MakeVariant(primary))
+	if err != nil {
+		return err
+	}
+	m.setPrimaryWirelessDevice(primary)
+	return nil
+}
+
+// GetWirelessDeviceRoles get the roles of the wireless devices marshaled
+// by json, the key is the device path and the value is "primary",
+// "secondary" or "hotspot".
+func (m *Manager) GetWirelessDeviceRoles() (rolesJSON string, busErr *dbus.Error) {
+	m.wirelessRoleLock.Lock()
+	roles := m.wirelessRoles
+	if roles == nil {
+		roles = make(map[dbus.ObjectPath]string)
+	}
+	rolesJSON, err := marshalJSON(roles)
+	m.wirelessRoleLock.Unlock()
+	if err != nil {
+		logger.Warning(err)
+		return "", dbusutil.ToError(err)
+	}
+	return rolesJSON, nil
+}
@@ -0,0 +1,45 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	dbus "github.com/godbus/dbus/v5"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestGetWirelessRoles(c *C.C) {
+	devs := []wirelessRoleDevice{
+		{path: "/dev/0", ifc: "wlan0", hwAddr: "00:11:22:33:44:55"},
+		{path: "/dev/1", ifc: "wlan1", hwAddr: "66:77:88:99:AA:BB"},
+	}
+	c.Check(getWirelessRoles(nil, ""), C.HasLen, 0)
+	c.Check(getWirelessRoles(devs, ""), C.DeepEquals, map[dbus.ObjectPath]string{
+		"/dev/0": wirelessRolePrimary,
+		"/dev/1": wirelessRoleSecondary,
+	})
+	// 按 MAC 地址或接口名指定主网卡
+	c.Check(getWirelessRoles(devs, "66:77:88:99:aa:bb"), C.DeepEquals, map[dbus.ObjectPath]string{
+		"/dev/0": wirelessRoleSecondary,
+		"/dev/1": wirelessRolePrimary,
+	})
+	c.Check(getWirelessRoles(devs, "wlan1")["/dev/1"], C.Equals, wirelessRolePrimary)
+	// 指定的网卡不存在时使用第一个网卡
+	c.Check(getWirelessRoles(devs, "wlan2")["/dev/0"], C.Equals, wirelessRolePrimary)
+
+	// 主网卡开启热点后由另一个网卡作为主网卡
+	devs[1].hotspot = true
+	c.Check(getWirelessRoles(devs, "wlan1"), C.DeepEquals, map[dbus.ObjectPath]string{
+		"/dev/0": wirelessRolePrimary,
+		"/dev/1": wirelessRoleHotspot,
+	})
+}
+
+func (*testWrapper) TestIsWirelessRolesEqual(c *C.C) {
+	a := map[dbus.ObjectPath]string{"/dev/0": wirelessRolePrimary}
+	c.Check(isWirelessRolesEqual(a, map[dbus.ObjectPath]string{"/dev/0": wirelessRolePrimary}), C.Equals, true)
+	c.Check(isWirelessRolesEqual(a, map[dbus.ObjectPath]string{"/dev/0": wirelessRoleHotspot}), C.Equals, false)
+	c.Check(isWirelessRolesEqual(a, nil), C.Equals, false)
+	c.Check(isWirelessRolesEqual(nil, map[dbus.ObjectPath]string{}), C.Equals, true)
+}
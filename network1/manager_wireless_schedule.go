@@ -0,0 +1,360 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// wirelessOffState 临时关闭无线网络的状态，保存在 dconfig 中，守护进程异常退出后仍能恢复
+type wirelessOffState struct {
+	// 恢复无线网络的 unix 时间戳，单位秒，使用墙上时间，重启后仍然有效
+	Until int64
+	// 被关闭的无线网卡的接口名
+	Interfaces []string
+	// 是否由定时计划关闭
+	Scheduled bool
+}
+
+// isExpired 到达恢复时间时需要恢复无线网络
+func (s *wirelessOffState) isExpired(now time.Time) bool {
+	return now.Unix() >= s.Until
+}
+
+// wirelessOffSchedule 每天关闭无线网络的时间段，单位为当天的分钟数，end 小于 start 时跨越午夜
+type wirelessOffSchedule struct {
+	start, end int
+}
+
+// parseWirelessOffSchedule 解析 "23:00-07:00" 格式的时间段
+func parseWirelessOffSchedule(str string) (schedule wirelessOffSchedule, err error) {
+	var startHour, startMinute, endHour, endMinute int
+	_, err = fmt.Sscanf(str, "%d:%d-%d:%d", &startHour, &startMinute, &endHour, &endMinute)
+	if err != nil {
+		return schedule, fmt.Errorf("invalid schedule %q: %v", str, err)
+	}
+	for _, value := range []int{startHour, endHour} {
+		if value < 0 || value > 23 {
+			return schedule, fmt.Errorf("invalid hour of schedule %q", str)
+		}
+	}
+	for _, value := range []int{startMinute, endMinute} {
+		if value < 0 || value > 59 {
+			return schedule, fmt.Errorf("invalid minute of schedule %q", str)
+		}
+	}
+	schedule = wirelessOffSchedule{
+		start: startHour*60 + startMinute,
+		end:   endHour*60 + endMinute,
+	}
+	if schedule.start == schedule.end {
+		return schedule, fmt.Errorf("empty schedule %q", str)
+	}
+	return schedule, nil
+}
+
+func parseWirelessOffSchedules(items []string) (schedules []wirelessOffSchedule, err error) {
+	for _, item := range items {
+		schedule, err := parseWirelessOffSchedule(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+// nextStart 返回 now 之后下一次开始关闭无线网络的时间
+func (s wirelessOffSchedule) nextStart(now time.Time) time.Time {
+	start := time.Date(now.Year(), now.Month(), now.Day(), s.start/60, s.start%60, 0, 0, now.Location())
+	if !start.After(now) {
+		start = start.AddDate(0, 0, 1)
+	}
+	return start
+}
+
+// endAfter 返回从 start 开始的时间段的结束时间
+func (s wirelessOffSchedule) endAfter(start time.Time) time.Time {
+	duration := s.end - s.start
+	if duration < 0 {
+		duration += 24 * 60
+	}
+	return start.Add(time.Duration(duration) * time.Minute)
+}
+
+// getCurrentWirelessOffSchedule 返回 now 所在的时间段的结束时间，有多个时间段时使用最晚的结束时间
+func getCurrentWirelessOffSchedule(schedules []wirelessOffSchedule, now time.Time) (end time.Time, ok bool) {
+	for _, schedule := range schedules {
+		// 最近一次开始的时间段
+		start := schedule.nextStart(now).AddDate(0, 0, -1)
+		scheduleEnd := schedule.endAfter(start)
+		if now.Before(scheduleEnd) && (!ok || scheduleEnd.After(end)) {
+			end, ok = scheduleEnd, true
+		}
+	}
+	return
+}
+
+// getNextWirelessOffSchedule 返回最早开始的时间段的开始和结束时间
+func getNextWirelessOffSchedule(schedules []wirelessOffSchedule, now time.Time) (start, end time.Time, ok bool) {
+	for _, schedule := range schedules {
+		next := schedule.nextStart(now)
+		if !ok || next.Before(start) {
+			start, end, ok = next, schedule.endAfter(next), true
+		}
+	}
+	return
+}
+
+func (m *Manager) loadWirelessOffState() (state *wirelessOffState) {
+	if m.networkConfig == nil {
+		return nil
+	}
+	v, err := m.networkConfig.Value(0, dsettingsWirelessOffState)
+	if err != nil {
+		logger.Warning(err)
+		return nil
+	}
+	str, _ := v.Value().(string)
+	if str == "" {
+		return nil
+	}
+	state = &wirelessOffState{}
+	err = json.Unmarshal([]byte(str), state)
+	if err != nil {
+		logger.Warning("invalid wireless off state:", err)
+		return nil
+	}
+	return state
+}
+
+func (m *Manager) saveWirelessOffState(state *wirelessOffState) error {
+	if m.networkConfig == nil {
+		return errors.New("network dconfig is not available")
+	}
+	var str string
+	if state != nil {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		str = string(data)
+	}
+	return m.networkConfig.SetValue(0, dsettingsWirelessOffState, dbus.MakeVariant(str))
+}
+
+// initWirelessOffState 恢复上次退出前临时关闭的无线网络，已过期时立即打开；
+// 启动时处于定时关闭的时间段内时关闭无线网络，直到时间段结束
+func (m *Manager) initWirelessOffState() {
+	now := time.Now()
+	m.wirelessOffLock.Lock()
+	state := m.loadWirelessOffState()
+	if state != nil {
+		if state.isExpired(now) {
+			m.restoreWirelessOff(state)
+		} else {
+			logger.Infof("wireless network is disabled until %v", time.Unix(state.Until, 0))
+			m.startWirelessOffTimer(time.Unix(state.Until, 0))
+		}
+	}
+	end, ok := getCurrentWirelessOffSchedule(m.wirelessOffSchedules, now)
+	m.wirelessOffLock.Unlock()
+
+	if ok {
+		err := m.disableWirelessUntil(end, true)
+		if err != nil {
+			logger.Warning("failed to disable wireless network by schedule:", err)
+		}
+	}
+}
+
+// 需要持有 wirelessOffLock
+func (m *Manager) startWirelessOffTimer(until time.Time) {
+	if m.wirelessOffTimer != nil {
+		m.wirelessOffTimer.Stop()
+	}
+	m.wirelessOffTimer = time.AfterFunc(time.Until(until), func() {
+		m.wirelessOffLock.Lock()
+		defer m.wirelessOffLock.Unlock()
+		m.wirelessOffTimer = nil
+		m.restoreWirelessOff(m.loadWirelessOffState())
+	})
+}
+
+// disableWirelessUntil 关闭当前打开的无线网卡，并在 until 时重新打开，
+// 已经临时关闭时合并关闭的网卡并使用更晚的恢复时间
+func (m *Manager) disableWirelessUntil(until time.Time, scheduled bool) error {
+	m.wirelessOffLock.Lock()
+	defer m.wirelessOffLock.Unlock()
+
+	state := m.loadWirelessOffState()
+	if state == nil {
+		state = &wirelessOffState{Scheduled: scheduled}
+	}
+	if until.Unix() > state.Until {
+		state.Until = until.Unix()
+	}
+	state.Scheduled = state.Scheduled && scheduled
+
+	var devPaths []dbus.ObjectPath
+	for _, devPath := range m.getWirelessDevicePaths() {
+		if enabled, err := m.getDeviceEnabled(devPath); err != nil || !enabled {
+			continue
+		}
+		devPaths = append(devPaths, devPath)
+		ifc := nmGetDeviceInterface(devPath)
+		if !isStringInArray(ifc, state.Interfaces) {
+			state.Interfaces = append(state.Interfaces, ifc)
+		}
+	}
+	// 先保存状态再关闭网卡，避免关闭后异常退出导致无法恢复
+	err := m.saveWirelessOffState(state)
+	if err != nil {
+		return err
+	}
+	for _, devPath := range devPaths {
+		err = m.enableDevice(devPath, false, false)
+		if err != nil {
+			logger.Warningf("failed to disable wireless device %s: %v", devPath, err)
+		}
+	}
+	logger.Infof("disable wireless network until %v, interfaces: %v", until, state.Interfaces)
+	m.startWirelessOffTimer(time.Unix(state.Until, 0))
+	return nil
+}
+
+// restoreWirelessOff 重新打开临时关闭的无线网卡，需要持有 wirelessOffLock
+func (m *Manager) restoreWirelessOff(state *wirelessOffState) {
+	if m.wirelessOffTimer != nil {
+		m.wirelessOffTimer.Stop()
+		m.wirelessOffTimer = nil
+	}
+	if state == nil {
+		return
+	}
+	logger.Info("restore wireless network, interfaces:", state.Interfaces)
+	for _, devPath := range m.getWirelessDevicePaths() {
+		if !isStringInArray(nmGetDeviceInterface(devPath), state.Interfaces) {
+			continue
+		}
+		err := m.enableDevice(devPath, true, false)
+		if err != nil {
+			logger.Warningf("failed to enable wireless device %s: %v", devPath, err)
+		}
+	}
+	err := m.saveWirelessOffState(nil)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+// setWirelessOffSchedules 设置每天关闭无线网络的时间段，并等待下一个时间段开始
+func (m *Manager) setWirelessOffSchedules(schedules []wirelessOffSchedule) {
+	m.wirelessOffLock.Lock()
+	defer m.wirelessOffLock.Unlock()
+	m.wirelessOffSchedules = schedules
+	m.startWirelessScheduleTimer()
+}
+
+// 需要持有 wirelessOffLock
+func (m *Manager) startWirelessScheduleTimer() {
+	if m.wirelessScheduleTimer != nil {
+		m.wirelessScheduleTimer.Stop()
+		m.wirelessScheduleTimer = nil
+	}
+	start, end, ok := getNextWirelessOffSchedule(m.wirelessOffSchedules, time.Now())
+	if !ok {
+		return
+	}
+	logger.Debugf("next wireless off schedule: %v - %v", start, end)
+	m.wirelessScheduleTimer = time.AfterFunc(time.Until(start), func() {
+		err := m.disableWirelessUntil(end, true)
+		if err != nil {
+			logger.Warning("failed to disable wireless network by schedule:", err)
+		}
+		m.wirelessOffLock.Lock()
+		m.startWirelessScheduleTimer()
+		m.wirelessOffLock.Unlock()
+	})
+}
+
+func (m *Manager) destroyWirelessOff() {
+	m.wirelessOffLock.Lock()
+	defer m.wirelessOffLock.Unlock()
+	// 状态保存在 dconfig 中，下次启动时恢复
+	if m.wirelessOffTimer != nil {
+		m.wirelessOffTimer.Stop()
+		m.wirelessOffTimer = nil
+	}
+	if m.wirelessScheduleTimer != nil {
+		m.wirelessScheduleTimer.Stop()
+		m.wirelessScheduleTimer = nil
+	}
+}
+
+// DisableWirelessFor disable all the wireless devices for seconds and
+// enable them again after the timeout, the timeout is kept across reboots.
+func (m *Manager) DisableWirelessFor(seconds uint32) *dbus.Error {
+	if seconds == 0 {
+		return dbusutil.ToError(errors.New("invalid duration"))
+	}
+	err := m.disableWirelessUntil(time.Now().Add(time.Duration(seconds)*time.Second), false)
+	if err != nil {
+		logger.Warning("failed to disable wireless network:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+// CancelWirelessDisable enable the wireless devices disabled by
+// DisableWirelessFor or the schedules immediately.
+func (m *Manager) CancelWirelessDisable() *dbus.Error {
+	m.wirelessOffLock.Lock()
+	defer m.wirelessOffLock.Unlock()
+	m.restoreWirelessOff(m.loadWirelessOffState())
+	return nil
+}
+
+// GetWirelessDisabledUntil return the unix time in seconds when the
+// temporarily disabled wireless devices will be enabled, 0 means the
+// wireless devices are not disabled temporarily.
+func (m *Manager) GetWirelessDisabledUntil() (until int64, busErr *dbus.Error) {
+	m.wirelessOffLock.Lock()
+	defer m.wirelessOffLock.Unlock()
+	state := m.loadWirelessOffState()
+	if state == nil {
+		return 0, nil
+	}
+	return state.Until, nil
+}
+
+// SetWirelessOffSchedules set the daily periods to disable the wireless
+// devices, such as "23:00-07:00" which crosses midnight.
+func (m *Manager) SetWirelessOffSchedules(schedules []string) *dbus.Error {
+	parsed, err := parseWirelessOffSchedules(schedules)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	if m.networkConfig == nil {
+		return dbusutil.ToError(errors.New("network dconfig is not available"))
+	}
+	if schedules == nil {
+		schedules = []string{}
+	}
+	err = m.networkConfig.SetValue(0, dsettingsWirelessOffSchedules, dbus.MakeVariant(schedules))
+	if err != nil {
+		logger.Warning(err)
+		return dbusutil.ToError(err)
+	}
+	m.setWirelessOffSchedules(parsed)
+	return nil
+}
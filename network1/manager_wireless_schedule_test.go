@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"time"
+
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestParseWirelessOffSchedule(c *C.C) {
+	schedule, err := parseWirelessOffSchedule("23:00-07:30")
+	c.Check(err, C.IsNil)
+	c.Check(schedule, C.Equals, wirelessOffSchedule{start: 23 * 60, end: 7*60 + 30})
+
+	for _, str := range []string{"", "23:00", "24:00-07:00", "23:60-07:00", "08:00-08:00", "a-b"} {
+		_, err = parseWirelessOffSchedule(str)
+		c.Check(err, C.NotNil, C.Commentf("%q", str))
+	}
+	_, err = parseWirelessOffSchedules([]string{"12:00-13:00", "bad"})
+	c.Check(err, C.NotNil)
+}
+
+func (*testWrapper) TestGetNextWirelessOffSchedule(c *C.C) {
+	schedules, err := parseWirelessOffSchedules([]string{"23:00-07:00", "12:00-13:30"})
+	c.Assert(err, C.IsNil)
+	now := time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	start, end, ok := getNextWirelessOffSchedule(schedules, now)
+	c.Check(ok, C.Equals, true)
+	c.Check(start, C.Equals, time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+	c.Check(end, C.Equals, time.Date(2022, 3, 1, 13, 30, 0, 0, time.UTC))
+
+	// 跨越午夜的时间段
+	start, end, _ = getNextWirelessOffSchedule(schedules, time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC))
+	c.Check(start, C.Equals, time.Date(2022, 3, 1, 23, 0, 0, 0, time.UTC))
+	c.Check(end, C.Equals, time.Date(2022, 3, 2, 7, 0, 0, 0, time.UTC))
+
+	start, _, _ = getNextWirelessOffSchedule(schedules, time.Date(2022, 3, 1, 23, 30, 0, 0, time.UTC))
+	c.Check(start, C.Equals, time.Date(2022, 3, 2, 12, 0, 0, 0, time.UTC))
+
+	_, _, ok = getNextWirelessOffSchedule(nil, now)
+	c.Check(ok, C.Equals, false)
+}
+
+func (*testWrapper) TestGetCurrentWirelessOffSchedule(c *C.C) {
+	schedules, err := parseWirelessOffSchedules([]string{"23:00-07:00", "12:00-13:30"})
+	c.Assert(err, C.IsNil)
+
+	end, ok := getCurrentWirelessOffSchedule(schedules, time.Date(2022, 3, 1, 12, 30, 0, 0, time.UTC))
+	c.Check(ok, C.Equals, true)
+	c.Check(end, C.Equals, time.Date(2022, 3, 1, 13, 30, 0, 0, time.UTC))
+
+	// 跨越午夜的时间段，在午夜前后启动
+	end, ok = getCurrentWirelessOffSchedule(schedules, time.Date(2022, 3, 1, 23, 0, 0, 0, time.UTC))
+	c.Check(ok, C.Equals, true)
+	c.Check(end, C.Equals, time.Date(2022, 3, 2, 7, 0, 0, 0, time.UTC))
+	end, ok = getCurrentWirelessOffSchedule(schedules, time.Date(2022, 3, 2, 6, 0, 0, 0, time.UTC))
+	c.Check(ok, C.Equals, true)
+	c.Check(end, C.Equals, time.Date(2022, 3, 2, 7, 0, 0, 0, time.UTC))
+
+	_, ok = getCurrentWirelessOffSchedule(schedules, time.Date(2022, 3, 1, 7, 0, 0, 0, time.UTC))
+	c.Check(ok, C.Equals, false)
+	_, ok = getCurrentWirelessOffSchedule(nil, time.Date(2022, 3, 1, 12, 30, 0, 0, time.UTC))
+	c.Check(ok, C.Equals, false)
+}
+
+func (*testWrapper) TestWirelessOffStateExpired(c *C.C) {
+	state := &wirelessOffState{Until: 2000}
+	c.Check(state.isExpired(time.Unix(1000, 0)), C.Equals, false)
+	c.Check(state.isExpired(time.Unix(2000, 0)), C.Equals, true)
+}
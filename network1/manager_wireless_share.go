@@ -0,0 +1,296 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/common/procsubject"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	polkit "github.com/linuxdeepin/go-dbus-factory/system/org.freedesktop.policykit1"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	"github.com/linuxdeepin/go-lib/utils"
+)
+
+const polkitActionShareWireless = "org.deepin.dde.network.share-wireless"
+
+// 二维码中的加密类型
+const (
+	wirelessShareTypeWpa    = "WPA"
+	wirelessShareTypeSae    = "SAE"
+	wirelessShareTypeWep    = "WEP"
+	wirelessShareTypeNopass = "nopass"
+)
+
+// wirelessShareInfo 无线网络分享二维码的内容，格式为
+// WIFI:T:<type>;S:<ssid>;P:<password>;H:<hidden>;;
+type wirelessShareInfo struct {
+	Ssid     string
+	Type     string
+	Password string
+	Hidden   bool
+}
+
+var wirelessShareEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`)
+
+func (info *wirelessShareInfo) String() string {
+	var sb strings.Builder
+	sb.WriteString("WIFI:")
+	fmt.Fprintf(&sb, "T:%s;", info.Type)
+	fmt.Fprintf(&sb, "S:%s;", wirelessShareEscaper.Replace(info.Ssid))
+	if info.Type != wirelessShareTypeNopass {
+		fmt.Fprintf(&sb, "P:%s;", wirelessShareEscaper.Replace(info.Password))
+	}
+	if info.Hidden {
+		sb.WriteString("H:true;")
+	}
+	sb.WriteString(";")
+	return sb.String()
+}
+
+// splitWirelessSharePayload 按未转义的分号拆分字段，同时去掉转义符
+func splitWirelessSharePayload(content string) (fields []string) {
+	var sb strings.Builder
+	escaped := false
+	for _, ch := range content {
+		switch {
+		case escaped:
+			sb.WriteRune(ch)
+			escaped = false
+		case ch == '\\':
+			escaped = true
+		case ch == ';':
+			fields = append(fields, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteRune(ch)
+		}
+	}
+	if sb.Len() > 0 {
+		fields = append(fields, sb.String())
+	}
+	return
+}
+
+func parseWirelessSharePayload(payload string) (*wirelessShareInfo, error) {
+	if !strings.HasPrefix(payload, "WIFI:") {
+		return nil, errors.New("not a wifi share payload")
+	}
+	info := &wirelessShareInfo{Type: wirelessShareTypeNopass}
+	for _, field := range splitWirelessSharePayload(strings.TrimPrefix(payload, "WIFI:")) {
+		kv := strings.SplitN(field, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "S":
+			info.Ssid = kv[1]
+		case "T":
+			info.Type = kv[1]
+		case "P":
+			info.Password = kv[1]
+		case "H":
+			info.Hidden = strings.EqualFold(kv[1], "true")
+		}
+	}
+
+	if len(info.Ssid) == 0 || len(info.Ssid) > maxSsidLength {
+		return nil, fmt.Errorf("invalid ssid %q", info.Ssid)
+	}
+	switch strings.ToUpper(info.Type) {
+	case "", strings.ToUpper(wirelessShareTypeNopass):
+		info.Type = wirelessShareTypeNopass
+		info.Password = ""
+	case wirelessShareTypeWpa, wirelessShareTypeSae, wirelessShareTypeWep:
+		info.Type = strings.ToUpper(info.Type)
+		if info.Password == "" {
+			return nil, errors.New("password is required")
+		}
+	default:
+		return nil, fmt.Errorf("invalid security type %q", info.Type)
+	}
+	return info, nil
+}
+
+// getWirelessShareType 返回连接在二维码中的加密类型和对应的密码 key
+func getWirelessShareType(data connectionData) (shareType, secretKey string, err error) {
+	if getSettingConnectionType(data) != nm.NM_SETTING_WIRELESS_SETTING_NAME {
+		return "", "", errors.New("connection is not a wireless connection")
+	}
+	if !isSettingExists(data, nm.NM_SETTING_WIRELESS_SECURITY_SETTING_NAME) {
+		return wirelessShareTypeNopass, "", nil
+	}
+	switch getSettingWirelessSecurityKeyMgmt(data) {
+	case "wpa-psk":
+		return wirelessShareTypeWpa, nm.NM_SETTING_WIRELESS_SECURITY_PSK, nil
+	case "sae":
+		return wirelessShareTypeSae, nm.NM_SETTING_WIRELESS_SECURITY_PSK, nil
+	case "none":
+		return wirelessShareTypeWep, fmt.Sprintf("wep-key%d", getSettingWirelessSecurityWepTxKeyidx(data)), nil
+	case "owe":
+		return wirelessShareTypeNopass, "", nil
+	}
+	return "", "", errors.New("enterprise wireless connection can not be shared")
+}
+
+// newWirelessShareInfo 根据连接配置和密码生成分享信息
+func newWirelessShareInfo(data connectionData, secrets map[string]string) (*wirelessShareInfo, error) {
+	shareType, secretKey, err := getWirelessShareType(data)
+	if err != nil {
+		return nil, err
+	}
+	info := &wirelessShareInfo{
+		Ssid:   string(getSettingWirelessSsid(data)),
+		Type:   shareType,
+		Hidden: getSettingWirelessHidden(data),
+	}
+	if secretKey == "" {
+		return info, nil
+	}
+	info.Password = secrets[secretKey]
+	if info.Password == "" {
+		return nil, errors.New("password of the connection is not saved")
+	}
+	return info, nil
+}
+
+func (m *Manager) checkPolkitAuth(sender dbus.Sender, actionId string) error {
+	pid, err := m.service.GetConnPID(string(sender))
+	if err != nil {
+		return err
+	}
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	// 需要调用者进程的启动时间，否则 polkit 无法发现 pid 被其他进程复用
+	subject, err := procsubject.New(pid)
+	if err != nil {
+		return err
+	}
+	authority := polkit.NewAuthority(systemBus)
+	result, err := authority.CheckAuthorization(0, subject, actionId, nil,
+		polkit.CheckAuthorizationFlagsAllowUserInteraction, "")
+	if err != nil {
+		return err
+	}
+	if !result.IsAuthorized {
+		return errors.New("not authorized")
+	}
+	return nil
+}
+
+// getWirelessSecrets 获取无线连接的密码，保存给所有用户的密码由 NetworkManager 提供，
+// 只保存给当前用户的密码由 secret agent 从密钥环中读取
+func (m *Manager) getWirelessSecrets(cpath dbus.ObjectPath, uuid string) map[string]string {
+	result := make(map[string]string)
+	secrets, err := nmGetConnectionSecrets(cpath, nm.NM_SETTING_WIRELESS_SECURITY_SETTING_NAME)
+	if err != nil {
+		logger.Debug(err)
+	}
+	for key, value := range secrets[nm.NM_SETTING_WIRELESS_SECURITY_SETTING_NAME] {
+		if str, ok := value.Value().(string); ok && str != "" {
+			result[key] = str
+		}
+	}
+	if m.secretAgent == nil {
+		return result
+	}
+	saved, err := m.secretAgent.getAll(uuid, nm.NM_SETTING_WIRELESS_SECURITY_SETTING_NAME)
+	if err != nil {
+		logger.Debug(err)
+	}
+	for key, value := range saved {
+		if _, ok := result[key]; !ok {
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// GetWirelessSharePayload get the "WIFI:T:...;S:...;P:...;;" payload of
+// the wireless connection for generating a QR code, the caller needs
+// to pass the polkit authentication as the payload contains the password.
+func (m *Manager) GetWirelessSharePayload(sender dbus.Sender, uuid string) (payload string, busErr *dbus.Error) {
+	payload, err := m.getWirelessSharePayload(sender, uuid)
+	if err != nil {
+		logger.Warning("failed to get wireless share payload:", err)
+		return "", dbusutil.ToError(err)
+	}
+	return payload, nil
+}
+
+func (m *Manager) getWirelessSharePayload(sender dbus.Sender, uuid string) (payload string, err error) {
+	cpath, err := nmGetConnectionByUuid(uuid)
+	if err != nil {
+		return
+	}
+	data, err := nmGetConnectionData(cpath)
+	if err != nil {
+		return
+	}
+	// 先检查连接是否可以分享，避免无意义的认证
+	_, _, err = getWirelessShareType(data)
+	if err != nil {
+		return
+	}
+	err = m.checkPolkitAuth(sender, polkitActionShareWireless)
+	if err != nil {
+		return
+	}
+	info, err := newWirelessShareInfo(data, m.getWirelessSecrets(cpath, uuid))
+	if err != nil {
+		return
+	}
+	return info.String(), nil
+}
+
+// ParseWirelessSharePayload create a wireless connection from the payload
+// of a scanned wifi QR code.
+func (m *Manager) ParseWirelessSharePayload(payload string) (connection dbus.ObjectPath, busErr *dbus.Error) {
+	cpath, err := m.parseWirelessSharePayload(payload)
+	if err != nil {
+		logger.Warning("failed to parse wireless share payload:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+func newWirelessShareConnectionData(info *wirelessShareInfo, uuid string) (data connectionData) {
+	keyMgmt := apSecNone.String()
+	switch info.Type {
+	case wirelessShareTypeWpa:
+		keyMgmt = apSecPsk.String()
+	case wirelessShareTypeSae:
+		keyMgmt = apSecSae.String()
+	case wirelessShareTypeWep:
+		keyMgmt = apSecWep.String()
+	}
+	data = newWirelessConnectionData(info.Ssid, uuid, []byte(info.Ssid), keyMgmt, "")
+	switch info.Type {
+	case wirelessShareTypeWpa, wirelessShareTypeSae:
+		setSettingWirelessSecurityPsk(data, info.Password)
+	case wirelessShareTypeWep:
+		setSettingWirelessSecurityWepKey0(data, info.Password)
+	}
+	if info.Hidden {
+		setSettingWirelessHidden(data, true)
+	}
+	return
+}
+
+func (m *Manager) parseWirelessSharePayload(payload string) (cpath dbus.ObjectPath, err error) {
+	cpath = "/"
+	info, err := parseWirelessSharePayload(payload)
+	if err != nil {
+		return
+	}
+	data := newWirelessShareConnectionData(info, utils.GenUuid())
+	m.applyDefaultWirelessZone(data)
+	return nmAddConnection(data)
+}
@@ -0,0 +1,52 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestWirelessSharePayload(c *C.C) {
+	info := &wirelessShareInfo{Ssid: `my;wifi`, Type: wirelessShareTypeWpa, Password: `p:a\ss`, Hidden: true}
+	payload := info.String()
+	c.Check(payload, C.Equals, `WIFI:T:WPA;S:my\;wifi;P:p\:a\\ss;H:true;;`)
+
+	parsed, err := parseWirelessSharePayload(payload)
+	c.Assert(err, C.IsNil)
+	c.Check(*parsed, C.DeepEquals, *info)
+
+	parsed, err = parseWirelessSharePayload("WIFI:S:guest;T:nopass;;")
+	c.Assert(err, C.IsNil)
+	c.Check(parsed.Type, C.Equals, wirelessShareTypeNopass)
+	c.Check(parsed.String(), C.Equals, "WIFI:T:nopass;S:guest;;")
+
+	_, err = parseWirelessSharePayload("WIFI:S:guest;T:WPA;;")
+	c.Check(err, C.NotNil)
+	_, err = parseWirelessSharePayload("WIFI:T:WPA;P:12345678;;")
+	c.Check(err, C.NotNil)
+	_, err = parseWirelessSharePayload("http://example.com")
+	c.Check(err, C.NotNil)
+}
+
+func (*testWrapper) TestNewWirelessShareInfo(c *C.C) {
+	info := &wirelessShareInfo{Ssid: "deepin", Type: wirelessShareTypeWpa, Password: "12345678"}
+	data := newWirelessShareConnectionData(info, "uuid")
+	c.Check(getSettingWirelessSecurityKeyMgmt(data), C.Equals, "wpa-psk")
+	c.Check(getSettingWirelessSecurityPsk(data), C.Equals, "12345678")
+
+	shared, err := newWirelessShareInfo(data, map[string]string{nm.NM_SETTING_WIRELESS_SECURITY_PSK: "12345678"})
+	c.Assert(err, C.IsNil)
+	c.Check(*shared, C.DeepEquals, *info)
+
+	// 密码未保存时无法分享
+	_, err = newWirelessShareInfo(data, nil)
+	c.Check(err, C.NotNil)
+
+	info = &wirelessShareInfo{Ssid: "guest", Type: wirelessShareTypeNopass}
+	shared, err = newWirelessShareInfo(newWirelessShareConnectionData(info, "uuid"), nil)
+	c.Assert(err, C.IsNil)
+	c.Check(*shared, C.DeepEquals, *info)
+}
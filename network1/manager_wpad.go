@@ -0,0 +1,164 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	// DHCP option 252，NetworkManager 的 Dhcp4Config 中的名称
+	dhcp4OptionWpad = "wpad"
+
+	wpadPacFile    = "wpad.dat"
+	wpadPacTimeout = 5 * time.Second
+	// PAC 文件的最大长度，避免读取异常的响应
+	wpadPacMaxSize = 1 << 20
+)
+
+// 代理配置的来源，ProxyChanged 信号的 source 参数
+const (
+	proxySourceGlobal     = "global"
+	proxySourceConnection = "connection"
+	proxySourceWpad       = "wpad"
+)
+
+func (m *Manager) setProxyAutoDetect(enabled bool) {
+	m.connProxyLock.Lock()
+	m.proxyAutoDetect = enabled
+	m.connProxyLock.Unlock()
+	logger.Info("proxy auto detection:", enabled)
+}
+
+// getWpadUrls 获取 WPAD 地址，优先使用 DHCP 下发的地址，其次按 DNS 域名逐级查找 wpad.<domain>，
+// 最短到可注册的域名，如 example.co.uk，公共后缀下的 wpad.co.uk 可能由任何人注册，不能使用
+func getWpadUrls(dhcpWpad string, domains []string) (urls []string) {
+	if u, err := url.Parse(strings.TrimSpace(dhcpWpad)); err == nil &&
+		(u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+		urls = append(urls, u.String())
+	}
+	for _, domain := range domains {
+		domain = strings.Trim(strings.ToLower(domain), ".")
+		registrable, err := publicsuffix.EffectiveTLDPlusOne(domain)
+		if err != nil {
+			continue
+		}
+		for {
+			wpadUrl := fmt.Sprintf("http://wpad.%s/%s", domain, wpadPacFile)
+			if !isStringInArray(wpadUrl, urls) {
+				urls = append(urls, wpadUrl)
+			}
+			if domain == registrable {
+				break
+			}
+			domain = domain[strings.IndexByte(domain, '.')+1:]
+		}
+	}
+	return
+}
+
+// newPacProxyConfig PAC 可以按地址选择代理或直连，无法转换成手动代理，
+// 使用自动代理交给应用程序执行 PAC，内容不是 PAC 时返回 nil
+func newPacProxyConfig(content []byte, pacUrl string) *connectionProxyConfig {
+	if !bytes.Contains(content, []byte("FindProxyForURL")) {
+		return nil
+	}
+	return &connectionProxyConfig{
+		Method: proxyModeAuto,
+		PacUrl: pacUrl,
+	}
+}
+
+func fetchPac(pacUrl string) ([]byte, error) {
+	// 获取 PAC 时不能使用代理
+	client := &http.Client{
+		Timeout:   wpadPacTimeout,
+		Transport: &http.Transport{Proxy: nil},
+	}
+	resp, err := client.Get(pacUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s failed, status: %s", pacUrl, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, wpadPacMaxSize))
+}
+
+// nmGetWpadUrls 从活动连接的 DHCP 和 DNS 配置中获取 WPAD 地址
+func nmGetWpadUrls(apath dbus.ObjectPath) ([]string, error) {
+	aConn, err := nmNewActiveConnection(apath)
+	if err != nil {
+		return nil, err
+	}
+	var dhcpWpad string
+	if dhcp4Path, _ := aConn.Dhcp4Config().Get(0); isNmObjectPathValid(dhcp4Path) {
+		if info := nmGetDhcp4Info(dhcp4Path); info != nil {
+			dhcpWpad = info.Options[dhcp4OptionWpad]
+		}
+	}
+	var domains []string
+	if ip4Path, _ := aConn.Ip4Config().Get(0); isNmObjectPathValid(ip4Path) {
+		if ip4Config, err := nmNewIP4Config(ip4Path); err == nil {
+			domains, _ = ip4Config.Domains().Get(0)
+			searches, _ := ip4Config.Searches().Get(0)
+			domains = append(domains, searches...)
+		}
+	}
+	return getWpadUrls(dhcpWpad, domains), nil
+}
+
+func detectWpadProxy(apath dbus.ObjectPath) (*connectionProxyConfig, error) {
+	urls, err := nmGetWpadUrls(apath)
+	if err != nil {
+		return nil, err
+	}
+	if len(urls) == 0 {
+		return nil, errors.New("no wpad url provided by dhcp or dns")
+	}
+	for _, pacUrl := range urls {
+		content, err := fetchPac(pacUrl)
+		if err != nil {
+			logger.Debug("failed to fetch pac:", err)
+			continue
+		}
+		cfg := newPacProxyConfig(content, pacUrl)
+		if cfg == nil {
+			logger.Debug("invalid pac:", pacUrl)
+			continue
+		}
+		logger.Info("found pac by wpad:", pacUrl)
+		return cfg, nil
+	}
+	return nil, errors.New("no pac found by wpad")
+}
+
+// applyWpadProxy 主连接未单独设置代理时，通过 WPAD 查找并应用代理
+func (m *Manager) applyWpadProxy(primaryPath dbus.ObjectPath) {
+	cfg, err := detectWpadProxy(primaryPath)
+	if err != nil {
+		logger.Debug(err)
+		return
+	}
+	m.connProxyLock.Lock()
+	defer m.connProxyLock.Unlock()
+	// 检测期间主连接已变化或关闭了自动检测
+	if m.proxyPrimaryPath != primaryPath || !m.proxyAutoDetect {
+		return
+	}
+	logger.Infof("apply proxy detected by wpad, method: %s", cfg.Method)
+	m.applyProxyOverGlobal(cfg, proxySourceWpad)
+}
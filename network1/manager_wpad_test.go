@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestGetWpadUrls(c *C.C) {
+	c.Check(getWpadUrls("", nil), C.IsNil)
+	c.Check(getWpadUrls("http://10.0.0.1/proxy.pac\n", nil), C.DeepEquals,
+		[]string{"http://10.0.0.1/proxy.pac"})
+	c.Check(getWpadUrls("invalid", []string{"Office.Example.com."}), C.DeepEquals, []string{
+		"http://wpad.office.example.com/wpad.dat",
+		"http://wpad.example.com/wpad.dat",
+	})
+	// 重复的域名
+	c.Check(getWpadUrls("", []string{"example.com", "example.com", "com"}), C.DeepEquals,
+		[]string{"http://wpad.example.com/wpad.dat"})
+	// 不查找公共后缀下的地址
+	c.Check(getWpadUrls("", []string{"office.example.co.uk", "co.uk"}), C.DeepEquals, []string{
+		"http://wpad.office.example.co.uk/wpad.dat",
+		"http://wpad.example.co.uk/wpad.dat",
+	})
+}
+
+func (*testWrapper) TestNewPacProxyConfig(c *C.C) {
+	const pacUrl = "http://wpad.example.com/wpad.dat"
+	// 按地址选择直连或代理的 PAC 交给应用程序执行
+	cfg := newPacProxyConfig([]byte(`function FindProxyForURL(url, host) {
+	if (isPlainHostName(host) || dnsDomainIs(host, ".example.com"))
+		return "DIRECT";
+	return 'PROXY proxy.example.com:3128; DIRECT';
+}`), pacUrl)
+	c.Assert(cfg, C.NotNil)
+	c.Check(cfg.Method, C.Equals, proxyModeAuto)
+	c.Check(cfg.PacUrl, C.Equals, pacUrl)
+
+	c.Check(newPacProxyConfig([]byte("<html>not found</html>"), pacUrl), C.IsNil)
+}
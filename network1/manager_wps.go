@@ -0,0 +1,273 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	"github.com/linuxdeepin/go-lib/utils"
+)
+
+// WpsStateChanged 信号中的状态
+const (
+	wpsStateStarted   = "started"
+	wpsStateConnected = "connected"
+	wpsStateFailed    = "failed"
+	wpsStateTimeout   = "timeout"
+)
+
+// WPS 协议规定的 walk time 为 2 分钟
+const wpsTimeout = 2 * time.Minute
+
+// WPS PIN 方式下，NetworkManager 进入 need-auth 状态后会使用随机 PIN 发起 WPS，
+// 等待一段时间后再使用用户输入的 PIN 重新发起
+const wpsPinStartDelay = time.Second
+
+type wpsSession struct {
+	apath dbus.ObjectPath
+	timer *time.Timer
+}
+
+// isValidWpsPin 检查 WPS PIN，8 位 PIN 的最后一位为校验位
+func isValidWpsPin(pin string) bool {
+	if len(pin) != 4 && len(pin) != 8 {
+		return false
+	}
+	for _, ch := range pin {
+		if ch < '0' || ch > '9' {
+			return false
+		}
+	}
+	if len(pin) == 4 {
+		return true
+	}
+
+	sum := 0
+	for i, ch := range pin {
+		digit := int(ch - '0')
+		if i%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
+
+// findWpsAccessPoint 返回支持指定 WPS 方式的热点中信号最强的一个
+func findWpsAccessPoint(accessPoints []*accessPoint, apFlag uint32) (apNow *accessPoint) {
+	for _, ap := range accessPoints {
+		if ap.Stale || ap.SecuredInEap || ap.Flags&apFlag == 0 {
+			continue
+		}
+		if apNow == nil || ap.Strength > apNow.Strength {
+			apNow = ap
+		}
+	}
+	return
+}
+
+// StartWpsPbc connect to the access point in range which is in WPS
+// push-button mode, the button on the router should be pushed within
+// two minutes. The progress is reported by the WpsStateChanged signal.
+func (m *Manager) StartWpsPbc(devPath dbus.ObjectPath) (connection dbus.ObjectPath, busErr *dbus.Error) {
+	cpath, err := m.startWps(devPath, nm.NM_SETTING_WIRELESS_SECURITY_WPS_METHOD_PBC, "")
+	if err != nil {
+		logger.Warning("failed to start wps pbc:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+// StartWpsPin connect to the access point in range which supports WPS
+// PIN onboarding, pin is the 4 or 8 digit PIN. The progress is reported
+// by the WpsStateChanged signal.
+func (m *Manager) StartWpsPin(devPath dbus.ObjectPath, pin string) (connection dbus.ObjectPath, busErr *dbus.Error) {
+	if !isValidWpsPin(pin) {
+		return "/", dbusutil.ToError(errors.New("invalid wps pin"))
+	}
+	cpath, err := m.startWps(devPath, nm.NM_SETTING_WIRELESS_SECURITY_WPS_METHOD_PIN, pin)
+	if err != nil {
+		logger.Warning("failed to start wps pin:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+func (m *Manager) startWps(devPath dbus.ObjectPath, wpsMethod uint32, pin string) (cpath dbus.ObjectPath, err error) {
+	logger.Debugf("startWps: devPath=%s, wpsMethod=%d", devPath, wpsMethod)
+
+	cpath = "/"
+	devType, i := m.getDeviceIndex(devPath)
+	if i < 0 || devType != deviceWifi {
+		err = fmt.Errorf("invalid wireless device %q", devPath)
+		return
+	}
+
+	apFlag := uint32(nm.NM_802_11_AP_FLAGS_WPS_PBC)
+	if wpsMethod == nm.NM_SETTING_WIRELESS_SECURITY_WPS_METHOD_PIN {
+		apFlag = nm.NM_802_11_AP_FLAGS_WPS_PIN
+	}
+	m.accessPointsLock.Lock()
+	ap := findWpsAccessPoint(m.accessPoints[devPath], apFlag)
+	var ssid, bssid string
+	var rawSsid []byte
+	if ap != nil {
+		ssid = ap.Ssid
+		bssid = ap.Bssid
+		rawSsid = ap.RawSsid
+	}
+	m.accessPointsLock.Unlock()
+	if ap == nil {
+		err = errors.New("no access point supports the wps method")
+		return
+	}
+
+	// 检查和占用会话需要在同一次加锁中完成，避免同时发起两次 WPS
+	session := &wpsSession{}
+	m.wpsLock.Lock()
+	_, ok := m.wpsSessions[devPath]
+	if !ok {
+		m.wpsSessions[devPath] = session
+	}
+	m.wpsLock.Unlock()
+	if ok {
+		err = fmt.Errorf("wps is in progress on device %q", devPath)
+		return
+	}
+
+	hwAddr, err := nmGeneralGetDeviceHwAddr(devPath, true)
+	if err != nil {
+		logger.Warning("failed to get mac", err)
+	}
+	// 密码在 WPS 协商成功后由 NetworkManager 写入连接
+	data := newWirelessConnectionData(ssid, utils.GenUuid(), rawSsid, apSecPsk.String(), hwAddr)
+	setSettingWirelessSecurityWpsMethod(data, wpsMethod)
+	m.applyDefaultWirelessZone(data)
+
+	var apath dbus.ObjectPath
+	cpath, apath, err = nmAddAndActivateConnection(data, devPath, true)
+	if err != nil {
+		m.endWpsSession(devPath, session)
+		m.emitWpsStateChanged(devPath, wpsStateFailed, nm.NM_ACTIVE_CONNECTION_STATE_REASON_UNKNOWN)
+		return
+	}
+	m.watchWpsActivation(devPath, apath, session)
+	if pin != "" {
+		m.watchWpsPinAuth(devPath, bssid, pin, session)
+	}
+	return
+}
+
+// watchWpsActivation 监听激活状态，超时未完成时断开连接
+func (m *Manager) watchWpsActivation(devPath, apath dbus.ObjectPath, session *wpsSession) {
+	aConn, err := nmNewActiveConnection(apath)
+	if err != nil {
+		m.endWpsSession(devPath, session)
+		m.emitWpsStateChanged(devPath, wpsStateFailed, nm.NM_ACTIVE_CONNECTION_STATE_REASON_UNKNOWN)
+		return
+	}
+
+	m.wpsLock.Lock()
+	session.apath = apath
+	session.timer = time.AfterFunc(wpsTimeout, func() {
+		if !m.endWpsSession(devPath, session) {
+			return
+		}
+		aConn.RemoveAllHandlers()
+		err := nmDeactivateConnection(apath)
+		if err != nil {
+			logger.Warning(err)
+		}
+		m.emitWpsStateChanged(devPath, wpsStateTimeout, nm.NM_ACTIVE_CONNECTION_STATE_REASON_UNKNOWN)
+	})
+	m.wpsLock.Unlock()
+	m.emitWpsStateChanged(devPath, wpsStateStarted, nm.NM_ACTIVE_CONNECTION_STATE_REASON_NONE)
+
+	aConn.InitSignalExt(m.sysSigLoop, true)
+	_, err = aConn.ConnectStateChanged(func(state uint32, reason uint32) {
+		switch state {
+		case nm.NM_ACTIVE_CONNECTION_STATE_ACTIVATED:
+			aConn.RemoveAllHandlers()
+			if m.endWpsSession(devPath, session) {
+				m.emitWpsStateChanged(devPath, wpsStateConnected, reason)
+			}
+		case nm.NM_ACTIVE_CONNECTION_STATE_DEACTIVATED:
+			aConn.RemoveAllHandlers()
+			if m.endWpsSession(devPath, session) {
+				m.emitWpsStateChanged(devPath, wpsStateFailed, reason)
+			}
+		}
+	})
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+// endWpsSession 结束 WPS 会话，会话已经结束时返回 false
+func (m *Manager) endWpsSession(devPath dbus.ObjectPath, session *wpsSession) bool {
+	m.wpsLock.Lock()
+	defer m.wpsLock.Unlock()
+	if m.wpsSessions[devPath] != session {
+		return false
+	}
+	if session.timer != nil {
+		session.timer.Stop()
+	}
+	delete(m.wpsSessions, devPath)
+	return true
+}
+
+func (m *Manager) isWpsSessionActive(devPath dbus.ObjectPath, session *wpsSession) bool {
+	m.wpsLock.Lock()
+	defer m.wpsLock.Unlock()
+	return m.wpsSessions[devPath] == session
+}
+
+// watchWpsPinAuth NetworkManager 不支持指定 WPS PIN，设备进入 need-auth 状态后
+// 通过系统服务让 wpa_supplicant 使用用户输入的 PIN 重新发起 WPS，
+// NetworkManager 会将协商得到的密码写入正在激活的连接
+func (m *Manager) watchWpsPinAuth(devPath dbus.ObjectPath, bssid, pin string, session *wpsSession) {
+	nmDev, err := nmNewDevice(devPath)
+	if err != nil {
+		return
+	}
+	ifc, _ := nmDev.Device().Interface().Get(0)
+	nmDev.InitSignalExt(m.sysSigLoop, true)
+	_, err = nmDev.Device().ConnectStateChanged(func(newState uint32, oldState uint32, reason uint32) {
+		if !m.isWpsSessionActive(devPath, session) {
+			nmDev.RemoveAllHandlers()
+			return
+		}
+		if newState != nm.NM_DEVICE_STATE_NEED_AUTH {
+			return
+		}
+		nmDev.RemoveAllHandlers()
+		time.AfterFunc(wpsPinStartDelay, func() {
+			if !m.isWpsSessionActive(devPath, session) {
+				return
+			}
+			err := m.callSysNetwork("StartWpsPin", ifc, bssid, pin)
+			if err != nil {
+				logger.Warning("failed to start wps pin:", err)
+			}
+		})
+	})
+	if err != nil {
+		logger.Warning(err)
+	}
+}
+
+func (m *Manager) emitWpsStateChanged(devPath dbus.ObjectPath, state string, reason uint32) {
+	logger.Infof("wps state of %s changed to %s, reason: %d", devPath, state, reason)
+	err := m.service.Emit(m, "WpsStateChanged", string(devPath), state, reason)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
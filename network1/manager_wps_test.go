@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestIsValidWpsPin(c *C.C) {
+	c.Check(isValidWpsPin("1234"), C.Equals, true)
+	c.Check(isValidWpsPin("12345670"), C.Equals, true)
+	// 校验位错误
+	c.Check(isValidWpsPin("12345678"), C.Equals, false)
+	c.Check(isValidWpsPin("123"), C.Equals, false)
+	c.Check(isValidWpsPin("123456"), C.Equals, false)
+	c.Check(isValidWpsPin("12a4"), C.Equals, false)
+	c.Check(isValidWpsPin(""), C.Equals, false)
+}
+
+func (*testWrapper) TestFindWpsAccessPoint(c *C.C) {
+	aps := []*accessPoint{
+		{Ssid: "a", Strength: 90, Path: "/ap/0"},
+		{Ssid: "b", Strength: 40, Path: "/ap/1", Flags: nm.NM_802_11_AP_FLAGS_WPS | nm.NM_802_11_AP_FLAGS_WPS_PBC},
+		{Ssid: "c", Strength: 60, Path: "/ap/2", Flags: nm.NM_802_11_AP_FLAGS_WPS | nm.NM_802_11_AP_FLAGS_WPS_PBC},
+		{Ssid: "d", Strength: 80, Path: "/ap/3", Flags: nm.NM_802_11_AP_FLAGS_WPS | nm.NM_802_11_AP_FLAGS_WPS_PBC,
+			Stale: true},
+		{Ssid: "e", Strength: 50, Path: "/ap/4", Flags: nm.NM_802_11_AP_FLAGS_WPS | nm.NM_802_11_AP_FLAGS_WPS_PIN},
+	}
+	c.Check(findWpsAccessPoint(aps, nm.NM_802_11_AP_FLAGS_WPS_PBC).Path, C.Equals, aps[2].Path)
+	c.Check(findWpsAccessPoint(aps, nm.NM_802_11_AP_FLAGS_WPS_PIN).Path, C.Equals, aps[4].Path)
+	c.Check(findWpsAccessPoint(aps[:1], nm.NM_802_11_AP_FLAGS_WPS_PBC), C.IsNil)
+}
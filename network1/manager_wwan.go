@@ -0,0 +1,431 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	mmdbus "github.com/linuxdeepin/go-dbus-factory/system/org.freedesktop.modemmanager1"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	. "github.com/linuxdeepin/go-lib/gettext"
+	"github.com/linuxdeepin/go-lib/utils"
+)
+
+const (
+	mmSimInterface = "org.freedesktop.ModemManager1.Sim"
+
+	mobileBroadbandProviderInfoFile = "/usr/share/mobile-broadband-provider-info/serviceproviders.xml"
+)
+
+// modem 3gpp registration states
+const (
+	MM_MODEM_3GPP_REGISTRATION_STATE_ROAMING                    = 5
+	MM_MODEM_3GPP_REGISTRATION_STATE_ROAMING_SMS_ONLY           = 7
+	MM_MODEM_3GPP_REGISTRATION_STATE_ROAMING_CSFB_NOT_PREFERRED = 10
+)
+
+// modem lock types
+const (
+	MM_MODEM_LOCK_UNKNOWN = 0
+	MM_MODEM_LOCK_NONE    = 1
+	MM_MODEM_LOCK_SIM_PIN = 2
+	MM_MODEM_LOCK_SIM_PUK = 4
+)
+
+var simPinRegexp = regexp.MustCompile(`^[0-9]{4,8}$`)
+
+func isModemRoaming(registrationState uint32) bool {
+	switch registrationState {
+	case MM_MODEM_3GPP_REGISTRATION_STATE_ROAMING,
+		MM_MODEM_3GPP_REGISTRATION_STATE_ROAMING_SMS_ONLY,
+		MM_MODEM_3GPP_REGISTRATION_STATE_ROAMING_CSFB_NOT_PREFERRED:
+		return true
+	}
+	return false
+}
+
+// modemInfo 返回给前端的 modem 信息
+type modemInfo struct {
+	Path             dbus.ObjectPath
+	DeviceIdentifier string
+	Manufacturer     string
+	Model            string
+	State            int32
+	NetworkType      string
+	SignalQuality    uint32
+	OperatorName     string
+	OperatorCode     string
+	Roaming          bool
+	// 需要解锁的类型，见 MM_MODEM_LOCK_*
+	UnlockRequired uint32
+	UnlockRetries  map[uint32]uint32
+}
+
+func newModemInfo(modem mmdbus.Modem) *modemInfo {
+	info := &modemInfo{Path: modem.Path_()}
+	info.DeviceIdentifier, _ = modem.Modem().DeviceIdentifier().Get(0)
+	info.Manufacturer, _ = modem.Modem().Manufacturer().Get(0)
+	info.Model, _ = modem.Modem().Model().Get(0)
+	info.State, _ = modem.Modem().State().Get(0)
+	accessTech, _ := modem.Modem().AccessTechnologies().Get(0)
+	info.NetworkType = mmDoGetModemMobileNetworkType(accessTech)
+	info.SignalQuality = mmDoGetModemDeviceSignalQuality(modem)
+	info.OperatorName, _ = modem.Modem3gpp().OperatorName().Get(0)
+	info.OperatorCode, _ = modem.Modem3gpp().OperatorCode().Get(0)
+	registrationState, _ := modem.Modem3gpp().RegistrationState().Get(0)
+	info.Roaming = isModemRoaming(registrationState)
+	info.UnlockRequired, _ = modem.Modem().UnlockRequired().Get(0)
+	info.UnlockRetries, _ = modem.Modem().UnlockRetries().Get(0)
+	return info
+}
+
+func mmGetModems() (modems []dbus.ObjectPath, err error) {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return
+	}
+	objects, err := mmdbus.NewManager(systemBus).ObjectManager().GetManagedObjects(0)
+	if err != nil {
+		return
+	}
+	for path, ifcs := range objects {
+		if _, ok := ifcs["org.freedesktop.ModemManager1.Modem"]; ok {
+			modems = append(modems, path)
+		}
+	}
+	return
+}
+
+func mmGetModemSimPath(modem mmdbus.Modem) (simPath dbus.ObjectPath, err error) {
+	simPath, err = modem.Modem().Sim().Get(0)
+	if err != nil {
+		return
+	}
+	if simPath == "" || simPath == "/" {
+		return "", errors.New("no sim card in the modem")
+	}
+	return
+}
+
+// mmGetModemHomeOperatorId 返回 SIM 卡所属运营商的 MCC+MNC，漫游时与当前注册的网络不同
+func mmGetModemHomeOperatorId(modem mmdbus.Modem) (operatorId string) {
+	if simPath, err := mmGetModemSimPath(modem); err == nil {
+		if systemBus, err := dbus.SystemBus(); err == nil {
+			variant, err := systemBus.Object("org.freedesktop.ModemManager1", simPath).
+				GetProperty(mmSimInterface + ".OperatorIdentifier")
+			if err == nil {
+				operatorId, _ = variant.Value().(string)
+			}
+		}
+	}
+	if operatorId == "" {
+		operatorId, _ = modem.Modem3gpp().OperatorCode().Get(0)
+	}
+	return
+}
+
+func mmCallSim(modem mmdbus.Modem, method string, args ...interface{}) error {
+	simPath, err := mmGetModemSimPath(modem)
+	if err != nil {
+		return err
+	}
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	return systemBus.Object("org.freedesktop.ModemManager1", simPath).
+		Call(mmSimInterface+"."+method, 0, args...).Err
+}
+
+// apnInfo mobile-broadband-provider-info 中的 APN 配置
+type apnInfo struct {
+	Provider string
+	Apn      string
+	Name     string
+	Username string
+	Password string
+}
+
+type mbpiServiceProviders struct {
+	Countries []struct {
+		Code      string         `xml:"code,attr"`
+		Providers []mbpiProvider `xml:"provider"`
+	} `xml:"country"`
+}
+
+type mbpiProvider struct {
+	Names []string `xml:"name"`
+	Gsm   struct {
+		NetworkIds []struct {
+			Mcc string `xml:"mcc,attr"`
+			Mnc string `xml:"mnc,attr"`
+		} `xml:"network-id"`
+		Apns []struct {
+			Value string `xml:"value,attr"`
+			Usage []struct {
+				Type string `xml:"type,attr"`
+			} `xml:"usage"`
+			Name     string `xml:"name"`
+			Username string `xml:"username"`
+			Password string `xml:"password"`
+		} `xml:"apn"`
+	} `xml:"gsm"`
+}
+
+func (p *mbpiProvider) hasNetworkId(operatorId string) bool {
+	for _, id := range p.Gsm.NetworkIds {
+		if id.Mcc+id.Mnc == operatorId {
+			return true
+		}
+	}
+	return false
+}
+
+// parseApnSuggestions 从 serviceproviders.xml 中查找运营商用于上网的 APN，
+// 没有 usage 标记的 APN 也认为可以用于上网
+func parseApnSuggestions(r io.Reader, operatorId string) (apns []*apnInfo, err error) {
+	var providers mbpiServiceProviders
+	err = xml.NewDecoder(r).Decode(&providers)
+	if err != nil {
+		return
+	}
+	for _, country := range providers.Countries {
+		for _, provider := range country.Providers {
+			if !provider.hasNetworkId(operatorId) {
+				continue
+			}
+			var providerName string
+			if len(provider.Names) > 0 {
+				providerName = provider.Names[0]
+			}
+			for _, apn := range provider.Gsm.Apns {
+				internet := len(apn.Usage) == 0
+				for _, usage := range apn.Usage {
+					if usage.Type == "internet" {
+						internet = true
+					}
+				}
+				if !internet {
+					continue
+				}
+				apns = append(apns, &apnInfo{
+					Provider: providerName,
+					Apn:      apn.Value,
+					Name:     strings.TrimSpace(apn.Name),
+					Username: strings.TrimSpace(apn.Username),
+					Password: strings.TrimSpace(apn.Password),
+				})
+			}
+		}
+	}
+	return
+}
+
+func getApnSuggestions(operatorId string) (apns []*apnInfo, err error) {
+	if operatorId == "" {
+		return nil, errors.New("unknown operator of the modem")
+	}
+	f, err := os.Open(mobileBroadbandProviderInfoFile)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	return parseApnSuggestions(f, operatorId)
+}
+
+func newGsmConnectionData(id, uuid, deviceId, operatorId string, apn *apnInfo) (data connectionData) {
+	data = make(connectionData)
+
+	addSetting(data, nm.NM_SETTING_CONNECTION_SETTING_NAME)
+	setSettingConnectionId(data, id)
+	setSettingConnectionUuid(data, uuid)
+	setSettingConnectionType(data, nm.NM_SETTING_GSM_SETTING_NAME)
+
+	addSetting(data, nm.NM_SETTING_GSM_SETTING_NAME)
+	setSettingGsmApn(data, apn.Apn)
+	if apn.Username != "" {
+		setSettingGsmUsername(data, apn.Username)
+	}
+	if apn.Password != "" {
+		setSettingGsmPassword(data, apn.Password)
+	}
+	// 绑定到当前的 modem 和 SIM 卡
+	if deviceId != "" {
+		setSettingGsmDeviceId(data, deviceId)
+	}
+	if operatorId != "" {
+		setSettingGsmSimOperatorId(data, operatorId)
+	}
+
+	initSettingSectionIpv4(data)
+	initSettingSectionIpv6(data)
+	return
+}
+
+// initModemOperatorProps 监听 modem 注册的运营商和漫游状态
+func (m *Manager) initModemOperatorProps(dev *device) {
+	modem := dev.mmDevModem
+	devPath := dev.Path
+	updateOperator := func() {
+		name, _ := modem.Modem3gpp().OperatorName().Get(0)
+		registrationState, _ := modem.Modem3gpp().RegistrationState().Get(0)
+		m.devicesLock.Lock()
+		defer m.devicesLock.Unlock()
+		dev.MobileOperator = name
+		dev.MobileRoaming = isModemRoaming(registrationState)
+		m.updatePropDevices()
+	}
+	onChanged := func() {
+		if m.isDeviceExists(devPath) {
+			updateOperator()
+		}
+	}
+
+	err := modem.Modem3gpp().OperatorName().ConnectChanged(func(hasValue bool, value string) {
+		onChanged()
+	})
+	if err != nil {
+		logger.Warning(err)
+	}
+	err = modem.Modem3gpp().RegistrationState().ConnectChanged(func(hasValue bool, value uint32) {
+		onChanged()
+	})
+	if err != nil {
+		logger.Warning(err)
+	}
+	dev.MobileOperator, _ = modem.Modem3gpp().OperatorName().Get(0)
+	registrationState, _ := modem.Modem3gpp().RegistrationState().Get(0)
+	dev.MobileRoaming = isModemRoaming(registrationState)
+}
+
+// GetModems get the json of all modems managed by ModemManager, including
+// signal quality, operator, roaming and SIM lock state.
+func (m *Manager) GetModems() (modemsJSON string, busErr *dbus.Error) {
+	paths, err := mmGetModems()
+	if err != nil {
+		logger.Warning("failed to get modems:", err)
+		return "", dbusutil.ToError(err)
+	}
+	modems := make([]*modemInfo, 0, len(paths))
+	for _, path := range paths {
+		modem, err := mmNewModem(path)
+		if err != nil {
+			continue
+		}
+		modems = append(modems, newModemInfo(modem))
+	}
+	modemsJSON, err = marshalJSON(modems)
+	busErr = dbusutil.ToError(err)
+	return
+}
+
+// GetApnSuggestions get the internet APNs of the SIM card's operator from
+// the mobile-broadband-provider-info database.
+func (m *Manager) GetApnSuggestions(modemPath dbus.ObjectPath) (apnsJSON string, busErr *dbus.Error) {
+	modem, err := mmNewModem(modemPath)
+	if err != nil {
+		return "", dbusutil.ToError(err)
+	}
+	apns, err := getApnSuggestions(mmGetModemHomeOperatorId(modem))
+	if err != nil {
+		logger.Warning("failed to get apn suggestions:", err)
+		return "", dbusutil.ToError(err)
+	}
+	apnsJSON, err = marshalJSON(apns)
+	busErr = dbusutil.ToError(err)
+	return
+}
+
+// CreateGsmConnection create a mobile broadband connection for the modem,
+// if apn is empty, the first suggested APN of the operator will be used.
+func (m *Manager) CreateGsmConnection(modemPath dbus.ObjectPath, apn, username, password string) (connection dbus.ObjectPath,
+	busErr *dbus.Error) {
+	cpath, err := m.createGsmConnection(modemPath, apn, username, password)
+	if err != nil {
+		logger.Warning("failed to create gsm connection:", err)
+		return "/", dbusutil.ToError(err)
+	}
+	return cpath, nil
+}
+
+func (m *Manager) createGsmConnection(modemPath dbus.ObjectPath, apn, username, password string) (cpath dbus.ObjectPath,
+	err error) {
+	cpath = "/"
+	modem, err := mmNewModem(modemPath)
+	if err != nil {
+		return
+	}
+	operatorId := mmGetModemHomeOperatorId(modem)
+	info := &apnInfo{Apn: apn, Username: username, Password: password}
+	if apn == "" {
+		apns, err := getApnSuggestions(operatorId)
+		if err != nil {
+			return cpath, err
+		}
+		if len(apns) == 0 {
+			return cpath, fmt.Errorf("no apn found for operator %q", operatorId)
+		}
+		info = apns[0]
+	} else if apns, err := getApnSuggestions(operatorId); err == nil {
+		// 使用数据库中的运营商名称作为连接名
+		for _, suggestion := range apns {
+			if suggestion.Apn == apn {
+				info.Provider = suggestion.Provider
+				break
+			}
+		}
+	}
+
+	id := info.Provider
+	if id == "" {
+		id = Tr("Mobile Broadband")
+	}
+	deviceId, _ := modem.Modem().DeviceIdentifier().Get(0)
+	return nmAddConnection(newGsmConnectionData(id, utils.GenUuid(), deviceId, operatorId, info))
+}
+
+// UnlockSimPin unlock the SIM card of the modem with the PIN code.
+func (m *Manager) UnlockSimPin(modemPath dbus.ObjectPath, pin string) *dbus.Error {
+	if !simPinRegexp.MatchString(pin) {
+		return dbusutil.ToError(errors.New("invalid pin code"))
+	}
+	modem, err := mmNewModem(modemPath)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	err = mmCallSim(modem, "SendPin", pin)
+	if err != nil {
+		logger.Warning("failed to unlock sim pin:", err)
+	}
+	return dbusutil.ToError(err)
+}
+
+// UnlockSimPuk unlock the blocked SIM card of the modem with the PUK code,
+// and set a new PIN code.
+func (m *Manager) UnlockSimPuk(modemPath dbus.ObjectPath, puk, newPin string) *dbus.Error {
+	if len(puk) != 8 || !simPinRegexp.MatchString(puk) {
+		return dbusutil.ToError(errors.New("invalid puk code"))
+	}
+	if !simPinRegexp.MatchString(newPin) {
+		return dbusutil.ToError(errors.New("invalid pin code"))
+	}
+	modem, err := mmNewModem(modemPath)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	err = mmCallSim(modem, "SendPuk", puk, newPin)
+	if err != nil {
+		logger.Warning("failed to unlock sim puk:", err)
+	}
+	return dbusutil.ToError(err)
+}
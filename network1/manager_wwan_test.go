@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"os"
+
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestParseApnSuggestions(c *C.C) {
+	parse := func(operatorId string) []*apnInfo {
+		f, err := os.Open("testdata/serviceproviders.xml")
+		c.Assert(err, C.IsNil)
+		defer f.Close()
+		apns, err := parseApnSuggestions(f, operatorId)
+		c.Assert(err, C.IsNil)
+		return apns
+	}
+
+	// wap APN 不用于上网
+	apns := parse("46002")
+	c.Assert(apns, C.HasLen, 1)
+	c.Check(*apns[0], C.Equals, apnInfo{Provider: "China Mobile", Apn: "cmnet", Name: "Internet"})
+
+	// 没有 usage 标记
+	apns = parse("46001")
+	c.Assert(apns, C.HasLen, 1)
+	c.Check(*apns[0], C.Equals, apnInfo{Provider: "China Unicom", Apn: "3gnet", Username: "user", Password: "pass"})
+
+	c.Check(parse("31026"), C.HasLen, 0)
+}
+
+func (*testWrapper) TestNewGsmConnectionData(c *C.C) {
+	data := newGsmConnectionData("China Unicom", "uuid", "device-id", "46001",
+		&apnInfo{Apn: "3gnet", Username: "user", Password: "pass"})
+	c.Check(getSettingConnectionType(data), C.Equals, nm.NM_SETTING_GSM_SETTING_NAME)
+	c.Check(getSettingGsmApn(data), C.Equals, "3gnet")
+	c.Check(getSettingGsmUsername(data), C.Equals, "user")
+	c.Check(getSettingGsmPassword(data), C.Equals, "pass")
+	c.Check(getSettingGsmDeviceId(data), C.Equals, "device-id")
+	c.Check(getSettingGsmSimOperatorId(data), C.Equals, "46001")
+}
+
+func (*testWrapper) TestIsModemRoaming(c *C.C) {
+	c.Check(isModemRoaming(MM_MODEM_3GPP_REGISTRATION_STATE_ROAMING), C.Equals, true)
+	c.Check(isModemRoaming(1), C.Equals, false)
+	c.Check(simPinRegexp.MatchString("1234"), C.Equals, true)
+	c.Check(simPinRegexp.MatchString("12a4"), C.Equals, false)
+}
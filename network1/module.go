@@ -74,6 +74,24 @@ func (d *Module) start() error {
 	if err != nil {
 		return err
 	}
+	err = managerServerObj.SetWriteCallback(manager, "AccessPointStrengthThreshold",
+		manager.accessPointStrengthThresholdWriteCb)
+	if err != nil {
+		return err
+	}
+	err = managerServerObj.SetWriteCallback(manager, "RoamingPolicy", manager.roamingPolicyWriteCb)
+	if err != nil {
+		return err
+	}
+	err = managerServerObj.SetWriteCallback(manager, "RoamingStrengthThreshold",
+		manager.roamingStrengthThresholdWriteCb)
+	if err != nil {
+		return err
+	}
+	err = managerServerObj.SetWriteCallback(manager, "ShowWeakAccessPoints", manager.showWeakAccessPointsWriteCb)
+	if err != nil {
+		return err
+	}
 
 	err = managerServerObj.Export()
 	if err != nil {
@@ -144,3 +144,45 @@ const (
 	NM_VPNC_SECRET_FLAG_ASK    = 3
 	NM_VPNC_SECRET_FLAG_UNUSED = 5
 )
+
+// DNS over TLS
+const NM_SETTING_CONNECTION_DNS_OVER_TLS = "dns-over-tls"
+const (
+	NM_SETTING_CONNECTION_DNS_OVER_TLS_DEFAULT       = -1
+	NM_SETTING_CONNECTION_DNS_OVER_TLS_NO            = 0
+	NM_SETTING_CONNECTION_DNS_OVER_TLS_OPPORTUNISTIC = 1
+	NM_SETTING_CONNECTION_DNS_OVER_TLS_YES           = 2
+)
+
+// WireGuard
+const NM_SETTING_WIREGUARD_SETTING_NAME = "wireguard"
+const (
+	NM_SETTING_WIREGUARD_FWMARK            = "fwmark"
+	NM_SETTING_WIREGUARD_LISTEN_PORT       = "listen-port"
+	NM_SETTING_WIREGUARD_MTU               = "mtu"
+	NM_SETTING_WIREGUARD_PEER_ROUTES       = "peer-routes"
+	NM_SETTING_WIREGUARD_PEERS             = "peers"
+	NM_SETTING_WIREGUARD_PRIVATE_KEY       = "private-key"
+	NM_SETTING_WIREGUARD_PRIVATE_KEY_FLAGS = "private-key-flags"
+)
+const (
+	NM_WIREGUARD_PEER_ATTR_ALLOWED_IPS          = "allowed-ips"
+	NM_WIREGUARD_PEER_ATTR_ENDPOINT             = "endpoint"
+	NM_WIREGUARD_PEER_ATTR_PERSISTENT_KEEPALIVE = "persistent-keepalive"
+	NM_WIREGUARD_PEER_ATTR_PRESHARED_KEY        = "preshared-key"
+	NM_WIREGUARD_PEER_ATTR_PRESHARED_KEY_FLAGS  = "preshared-key-flags"
+	NM_WIREGUARD_PEER_ATTR_PUBLIC_KEY           = "public-key"
+)
+
+// Wi-Fi P2P
+const (
+	NM_DBUS_INTERFACE_DEVICE_WIFI_P2P = "org.freedesktop.NetworkManager.Device.WifiP2P"
+	NM_DBUS_INTERFACE_WIFI_P2P_PEER   = "org.freedesktop.NetworkManager.WifiP2PPeer"
+)
+
+const NM_SETTING_WIFI_P2P_SETTING_NAME = "wifi-p2p"
+const (
+	NM_SETTING_WIFI_P2P_PEER       = "peer"
+	NM_SETTING_WIFI_P2P_WFD_IES    = "wfd-ies"
+	NM_SETTING_WIFI_P2P_WPS_METHOD = "wps-method"
+)
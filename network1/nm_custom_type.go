@@ -90,6 +90,7 @@ const (
 	connectionVpnStrongswan   = "vpn-strongswan"
 	connectionVpnPptp         = "vpn-pptp"
 	connectionVpnVpnc         = "vpn-vpnc"
+	connectionWireguard       = "wireguard"
 )
 
 // wrapper for custom connection types
@@ -114,6 +115,7 @@ var supportedConnectionTypes = []string{
 	connectionVpnPptp,
 	connectionVpnStrongswan,
 	connectionVpnVpnc,
+	connectionWireguard,
 }
 
 // return custom connection type, and the wrapper types will be ignored, e.g. connectionMobile.
@@ -141,6 +143,8 @@ func getCustomConnectionType(data connectionData) (connType string) {
 		connType = connectionMobileGsm
 	case nm.NM_SETTING_CDMA_SETTING_NAME:
 		connType = connectionMobileCdma
+	case nm.NM_SETTING_WIREGUARD_SETTING_NAME:
+		connType = connectionWireguard
 	case nm.NM_SETTING_VPN_SETTING_NAME:
 		switch getSettingVpnServiceType(data) {
 		case nm.NM_DBUS_SERVICE_L2TP:
@@ -0,0 +1,666 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	"github.com/linuxdeepin/go-lib/keyfile"
+)
+
+// NetworkManager keyfile 格式中使用的 setting 别名
+var nmKeyfileSettingAliases = map[string]string{
+	"ethernet":      nm.NM_SETTING_WIRED_SETTING_NAME,
+	"wifi":          nm.NM_SETTING_WIRELESS_SETTING_NAME,
+	"wifi-security": nm.NM_SETTING_WIRELESS_SECURITY_SETTING_NAME,
+}
+
+const (
+	nmKeyfileVpnSecretsSection = "vpn-secrets"
+	nmKeyfilePeerSectionPrefix = "wireguard-peer."
+)
+
+// 结构复杂，暂不支持导入导出的 setting
+var nmKeyfileUnsupportedSettings = []string{"ethtool", "tc", "sriov", "match"}
+
+type nmKeyfileValueType int
+
+const (
+	nmKeyfileString nmKeyfileValueType = iota
+	nmKeyfileBool
+	nmKeyfileInt32
+	nmKeyfileUint32
+	nmKeyfileInt64
+	nmKeyfileUint64
+	nmKeyfileStringList
+	nmKeyfileMacAddress
+	nmKeyfileCertPath
+)
+
+// keyfile 中非字符串类型的 key，未列出的 key 按字符串处理，*-flags 为 uint32
+var nmKeyfileValueTypes = map[string]nmKeyfileValueType{
+	"autoconnect":               nmKeyfileBool,
+	"autoconnect-priority":      nmKeyfileInt32,
+	"autoconnect-retries":       nmKeyfileInt32,
+	"auth-retries":              nmKeyfileInt32,
+	"timestamp":                 nmKeyfileUint64,
+	"read-only":                 nmKeyfileBool,
+	"metered":                   nmKeyfileInt32,
+	"multi-connect":             nmKeyfileInt32,
+	"lldp":                      nmKeyfileInt32,
+	"mdns":                      nmKeyfileInt32,
+	"llmnr":                     nmKeyfileInt32,
+	"wait-device-timeout":       nmKeyfileInt32,
+	"permissions":               nmKeyfileStringList,
+	"secondaries":               nmKeyfileStringList,
+	"mtu":                       nmKeyfileUint32,
+	"speed":                     nmKeyfileUint32,
+	"auto-negotiate":            nmKeyfileBool,
+	"wake-on-lan":               nmKeyfileUint32,
+	"mac-address":               nmKeyfileMacAddress,
+	"bssid":                     nmKeyfileMacAddress,
+	"mac-address-blacklist":     nmKeyfileStringList,
+	"seen-bssids":               nmKeyfileStringList,
+	"hidden":                    nmKeyfileBool,
+	"channel":                   nmKeyfileUint32,
+	"rate":                      nmKeyfileUint32,
+	"tx-power":                  nmKeyfileUint32,
+	"powersave":                 nmKeyfileUint32,
+	"mac-address-randomization": nmKeyfileUint32,
+	"ap-isolation":              nmKeyfileInt32,
+	"proto":                     nmKeyfileStringList,
+	"pairwise":                  nmKeyfileStringList,
+	"group":                     nmKeyfileStringList,
+	"wep-key-type":              nmKeyfileUint32,
+	"wep-tx-keyidx":             nmKeyfileUint32,
+	"pmf":                       nmKeyfileInt32,
+	"fils":                      nmKeyfileInt32,
+	"wps-method":                nmKeyfileUint32,
+	"eap":                       nmKeyfileStringList,
+	"ca-cert":                   nmKeyfileCertPath,
+	"client-cert":               nmKeyfileCertPath,
+	"private-key":               nmKeyfileCertPath,
+	"phase2-ca-cert":            nmKeyfileCertPath,
+	"phase2-client-cert":        nmKeyfileCertPath,
+	"phase2-private-key":        nmKeyfileCertPath,
+	"altsubject-matches":        nmKeyfileStringList,
+	"phase2-altsubject-matches": nmKeyfileStringList,
+	"system-ca-certs":           nmKeyfileBool,
+	"auth-timeout":              nmKeyfileInt32,
+	"optional":                  nmKeyfileBool,
+	"dns-search":                nmKeyfileStringList,
+	"dns-options":               nmKeyfileStringList,
+	"dns-priority":              nmKeyfileInt32,
+	"ignore-auto-dns":           nmKeyfileBool,
+	"ignore-auto-routes":        nmKeyfileBool,
+	"never-default":             nmKeyfileBool,
+	"may-fail":                  nmKeyfileBool,
+	"route-metric":              nmKeyfileInt64,
+	"route-table":               nmKeyfileUint32,
+	"dad-timeout":               nmKeyfileInt32,
+	"dhcp-timeout":              nmKeyfileInt32,
+	"dhcp-send-hostname":        nmKeyfileBool,
+	"ip6-privacy":               nmKeyfileInt32,
+	"addr-gen-mode":             nmKeyfileInt32,
+	"persistent":                nmKeyfileBool,
+	"timeout":                   nmKeyfileUint32,
+	"listen-port":               nmKeyfileUint32,
+	"fwmark":                    nmKeyfileUint32,
+	"peer-routes":               nmKeyfileBool,
+	"ip4-auto-default-route":    nmKeyfileInt32,
+	"ip6-auto-default-route":    nmKeyfileInt32,
+	"browser-only":              nmKeyfileBool,
+	"home-only":                 nmKeyfileBool,
+}
+
+func getNMKeyfileValueType(key string) nmKeyfileValueType {
+	if typ, ok := nmKeyfileValueTypes[key]; ok {
+		return typ
+	}
+	if strings.HasSuffix(key, "-flags") {
+		return nmKeyfileUint32
+	}
+	return nmKeyfileString
+}
+
+var (
+	nmKeyfileAddressKeyRegexp = regexp.MustCompile(`^address(es)?[0-9]*$`)
+	nmKeyfileRouteKeyRegexp   = regexp.MustCompile(`^routes?[0-9]*$`)
+)
+
+// parseNMKeyfile 将 NetworkManager keyfile 格式的连接配置转换为 connectionData，包含其中的密码
+func parseNMKeyfile(content []byte) (data connectionData, err error) {
+	kf := keyfile.NewKeyFile()
+	err = kf.LoadFromData(content)
+	if err != nil {
+		return nil, err
+	}
+
+	data = make(connectionData)
+	var peers []map[string]dbus.Variant
+	for _, section := range kf.GetSections() {
+		setting := section
+		if alias, ok := nmKeyfileSettingAliases[section]; ok {
+			setting = alias
+		}
+		switch {
+		case isStringInArray(setting, nmKeyfileUnsupportedSettings):
+			logger.Warningf("ignore unsupported setting %q in keyfile", section)
+		case setting == nm.NM_SETTING_VPN_SETTING_NAME:
+			parseNMKeyfileVpnSetting(kf, section, data)
+		case setting == nmKeyfileVpnSecretsSection:
+			addSetting(data, nm.NM_SETTING_VPN_SETTING_NAME)
+			setSettingVpnSecrets(data, getNMKeyfileStringMap(kf, section))
+		case setting == nm.NM_SETTING_USER_SETTING_NAME:
+			addSetting(data, nm.NM_SETTING_USER_SETTING_NAME)
+			setSettingKey(data, nm.NM_SETTING_USER_SETTING_NAME, "data", getNMKeyfileStringMap(kf, section))
+		case strings.HasPrefix(setting, nmKeyfilePeerSectionPrefix):
+			var peer map[string]dbus.Variant
+			peer, err = parseNMKeyfileWireguardPeer(kf, section)
+			if err != nil {
+				return nil, err
+			}
+			peers = append(peers, peer)
+		case setting == nm.NM_SETTING_IP4_CONFIG_SETTING_NAME || setting == nm.NM_SETTING_IP6_CONFIG_SETTING_NAME:
+			err = parseNMKeyfileIPSetting(kf, section, setting, data)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			addSetting(data, setting)
+			for _, key := range kf.GetKeys(section) {
+				var value interface{}
+				value, err = parseNMKeyfileValue(kf, section, setting, key)
+				if err != nil {
+					return nil, err
+				}
+				setSettingKey(data, setting, key, value)
+			}
+		}
+	}
+	if len(peers) != 0 {
+		addSetting(data, nm.NM_SETTING_WIREGUARD_SETTING_NAME)
+		setSettingKey(data, nm.NM_SETTING_WIREGUARD_SETTING_NAME, nm.NM_SETTING_WIREGUARD_PEERS, peers)
+	}
+
+	if connType, ok := nmKeyfileSettingAliases[getSettingConnectionType(data)]; ok {
+		setSettingConnectionType(data, connType)
+	}
+	if getSettingConnectionId(data) == "" || getSettingConnectionType(data) == "" {
+		return nil, errors.New("connection id or type is missing in keyfile")
+	}
+	return data, nil
+}
+
+func getNMKeyfileStringMap(kf *keyfile.KeyFile, section string) map[string]string {
+	result := make(map[string]string)
+	for _, key := range kf.GetKeys(section) {
+		result[key], _ = kf.GetString(section, key)
+	}
+	return result
+}
+
+func parseNMKeyfileValue(kf *keyfile.KeyFile, section, setting, key string) (value interface{}, err error) {
+	str, err := kf.GetString(section, key)
+	if err != nil {
+		return
+	}
+	if setting == nm.NM_SETTING_WIRELESS_SETTING_NAME && key == nm.NM_SETTING_WIRELESS_SSID {
+		return parseNMKeyfileSsid(str), nil
+	}
+
+	invalidErr := fmt.Errorf("invalid value %q of %s.%s", str, section, key)
+	switch getNMKeyfileValueType(key) {
+	case nmKeyfileBool:
+		switch strings.ToLower(str) {
+		case "true", "yes", "1":
+			return true, nil
+		case "false", "no", "0":
+			return false, nil
+		}
+		return nil, invalidErr
+	case nmKeyfileInt32:
+		if mode, ok := nmKeyfileAddrGenModes[str]; ok && key == "addr-gen-mode" {
+			return mode, nil
+		}
+		var v int64
+		v, err = strconv.ParseInt(str, 10, 32)
+		if err != nil {
+			return nil, invalidErr
+		}
+		return int32(v), nil
+	case nmKeyfileUint32:
+		var v uint64
+		v, err = strconv.ParseUint(str, 10, 32)
+		if err != nil {
+			return nil, invalidErr
+		}
+		return uint32(v), nil
+	case nmKeyfileInt64:
+		var v int64
+		v, err = strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return nil, invalidErr
+		}
+		return v, nil
+	case nmKeyfileUint64:
+		var v uint64
+		v, err = strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			return nil, invalidErr
+		}
+		return v, nil
+	case nmKeyfileStringList:
+		var list []string
+		list, err = kf.GetStringList(section, key)
+		if err != nil {
+			return nil, invalidErr
+		}
+		return list, nil
+	case nmKeyfileMacAddress:
+		var mac []byte
+		mac, err = convertMacAddressToArrayByteCheck(str)
+		if err != nil {
+			return nil, invalidErr
+		}
+		return mac, nil
+	case nmKeyfileCertPath:
+		return strToByteArrayPath(toUriPathFor8021x(str)), nil
+	}
+	return str, nil
+}
+
+// keyfile 中 addr-gen-mode 可能以名称保存
+var nmKeyfileAddrGenModes = map[string]int32{
+	"eui64":            0,
+	"stable-privacy":   1,
+	"default-or-eui64": 2,
+	"default":          3,
+}
+
+var nmKeyfileByteListRegexp = regexp.MustCompile(`^([0-9]+;)+$`)
+
+// parseNMKeyfileSsid ssid 可能是字符串，也可能是以分号分隔的字节列表
+func parseNMKeyfileSsid(str string) []byte {
+	if nmKeyfileByteListRegexp.MatchString(str) {
+		var ssid []byte
+		for _, item := range strings.Split(strings.TrimSuffix(str, ";"), ";") {
+			b, err := strconv.ParseUint(item, 10, 8)
+			if err != nil {
+				return []byte(str)
+			}
+			ssid = append(ssid, byte(b))
+		}
+		return ssid
+	}
+	return []byte(str)
+}
+
+func parseNMKeyfileVpnSetting(kf *keyfile.KeyFile, section string, data connectionData) {
+	addSetting(data, nm.NM_SETTING_VPN_SETTING_NAME)
+	vpnData := make(map[string]string)
+	for _, key := range kf.GetKeys(section) {
+		value, _ := kf.GetString(section, key)
+		switch key {
+		case nm.NM_SETTING_VPN_SERVICE_TYPE:
+			setSettingVpnServiceType(data, value)
+		case nm.NM_SETTING_VPN_USER_NAME:
+			setSettingVpnUserName(data, value)
+		case nm.NM_SETTING_VPN_PERSISTENT:
+			setSettingVpnPersistent(data, value == "true")
+		case nm.NM_SETTING_VPN_TIMEOUT:
+			timeout, err := strconv.ParseUint(value, 10, 32)
+			if err == nil {
+				setSettingVpnTimeout(data, uint32(timeout))
+			}
+		default:
+			vpnData[key] = value
+		}
+	}
+	setSettingVpnData(data, vpnData)
+}
+
+func parseNMKeyfileWireguardPeer(kf *keyfile.KeyFile, section string) (peer map[string]dbus.Variant, err error) {
+	publicKey := strings.TrimPrefix(section, nmKeyfilePeerSectionPrefix)
+	err = checkWireguardKey(publicKey)
+	if err != nil {
+		return
+	}
+	peer = map[string]dbus.Variant{
+		nm.NM_WIREGUARD_PEER_ATTR_PUBLIC_KEY: dbus.MakeVariant(publicKey),
+	}
+	for _, key := range kf.GetKeys(section) {
+		switch key {
+		case nm.NM_WIREGUARD_PEER_ATTR_ALLOWED_IPS:
+			allowedIPs, _ := kf.GetStringList(section, key)
+			peer[key] = dbus.MakeVariant(allowedIPs)
+		case nm.NM_WIREGUARD_PEER_ATTR_PERSISTENT_KEEPALIVE, nm.NM_WIREGUARD_PEER_ATTR_PRESHARED_KEY_FLAGS:
+			str, _ := kf.GetString(section, key)
+			v, err := strconv.ParseUint(str, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q of %s.%s", str, section, key)
+			}
+			peer[key] = dbus.MakeVariant(uint32(v))
+		default:
+			str, _ := kf.GetString(section, key)
+			peer[key] = dbus.MakeVariant(str)
+		}
+	}
+	return
+}
+
+// parseNMKeyfileIPSetting 解析 ipv4/ipv6 setting，addressN 和 routeN 转换为 address-data 和 route-data
+func parseNMKeyfileIPSetting(kf *keyfile.KeyFile, section, setting string, data connectionData) error {
+	addSetting(data, setting)
+	isIPv6 := setting == nm.NM_SETTING_IP6_CONFIG_SETTING_NAME
+	var addresses, routes []map[string]dbus.Variant
+	gateway := ""
+	for _, key := range kf.GetKeys(section) {
+		str, _ := kf.GetString(section, key)
+		invalidErr := fmt.Errorf("invalid value %q of %s.%s", str, section, key)
+		switch {
+		case nmKeyfileAddressKeyRegexp.MatchString(key):
+			fields := strings.Split(str, ",")
+			addr, err := parseNMKeyfileIPPrefix(fields[0], isIPv6)
+			if err != nil {
+				return invalidErr
+			}
+			addresses = append(addresses, addr)
+			if len(fields) > 1 && gateway == "" {
+				gateway = fields[1]
+			}
+		case nmKeyfileRouteKeyRegexp.MatchString(key):
+			fields := strings.Split(str, ",")
+			route, err := parseNMKeyfileIPPrefix(fields[0], isIPv6)
+			if err != nil {
+				return invalidErr
+			}
+			if len(fields) > 1 && fields[1] != "" {
+				route["next-hop"] = dbus.MakeVariant(fields[1])
+			}
+			if len(fields) > 2 {
+				metric, err := strconv.ParseUint(fields[2], 10, 32)
+				if err != nil {
+					return invalidErr
+				}
+				route["metric"] = dbus.MakeVariant(uint32(metric))
+			}
+			routes = append(routes, route)
+		case strings.HasSuffix(key, "_options"):
+			logger.Debugf("ignore %s.%s in keyfile", section, key)
+		case key == "gateway":
+			gateway = str
+		case key == "dns":
+			list, _ := kf.GetStringList(section, key)
+			if isIPv6 {
+				var dns [][]byte
+				for _, item := range list {
+					ip := net.ParseIP(item)
+					if ip == nil || ip.To4() != nil {
+						return invalidErr
+					}
+					dns = append(dns, []byte(ip.To16()))
+				}
+				setSettingKey(data, setting, key, dns)
+			} else {
+				var dns []uint32
+				for _, item := range list {
+					ip := net.ParseIP(item).To4()
+					if ip == nil {
+						return invalidErr
+					}
+					dns = append(dns, htonl(ipToUint32(ip.String())))
+				}
+				setSettingKey(data, setting, key, dns)
+			}
+		default:
+			value, err := parseNMKeyfileValue(kf, section, setting, key)
+			if err != nil {
+				return err
+			}
+			setSettingKey(data, setting, key, value)
+		}
+	}
+	if len(addresses) != 0 {
+		setSettingKey(data, setting, "address-data", addresses)
+	}
+	if len(routes) != 0 {
+		setSettingKey(data, setting, "route-data", routes)
+	}
+	if gateway != "" {
+		setSettingKey(data, setting, "gateway", gateway)
+	}
+	return nil
+}
+
+func parseNMKeyfileIPPrefix(str string, isIPv6 bool) (map[string]dbus.Variant, error) {
+	addr, prefixStr, hasPrefix := strings.Cut(str, "/")
+	ip := net.ParseIP(addr)
+	if ip == nil || (ip.To4() == nil) != isIPv6 {
+		return nil, fmt.Errorf("invalid address %q", str)
+	}
+	prefix := uint64(32)
+	if isIPv6 {
+		prefix = 128
+	}
+	if hasPrefix {
+		var err error
+		prefix, err = strconv.ParseUint(prefixStr, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return map[string]dbus.Variant{
+		"address": dbus.MakeVariant(ip.String()),
+		"prefix":  dbus.MakeVariant(uint32(prefix)),
+	}, nil
+}
+
+// formatNMKeyfile 将 connectionData 转换为 NetworkManager keyfile 格式，secrets 需要提前合并到 data 中
+func formatNMKeyfile(data connectionData) ([]byte, error) {
+	kf := keyfile.NewKeyFile()
+	settings := make([]string, 0, len(data))
+	for setting := range data {
+		settings = append(settings, setting)
+	}
+	// connection 放在最前面，其余按名称排序
+	sort.Slice(settings, func(i, j int) bool {
+		if settings[i] == nm.NM_SETTING_CONNECTION_SETTING_NAME || settings[j] == nm.NM_SETTING_CONNECTION_SETTING_NAME {
+			return settings[i] == nm.NM_SETTING_CONNECTION_SETTING_NAME
+		}
+		return settings[i] < settings[j]
+	})
+
+	for _, setting := range settings {
+		section := getNMKeyfileSectionName(setting)
+		keys := make([]string, 0, len(data[setting]))
+		for key := range data[setting] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			formatNMKeyfileValue(kf, section, setting, key, data[setting][key].Value())
+		}
+	}
+
+	var buf bytes.Buffer
+	err := kf.SaveToWriter(&buf)
+	return buf.Bytes(), err
+}
+
+func getNMKeyfileSectionName(setting string) string {
+	for alias, name := range nmKeyfileSettingAliases {
+		if name == setting {
+			return alias
+		}
+	}
+	return setting
+}
+
+func formatNMKeyfileValue(kf *keyfile.KeyFile, section, setting, key string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		if setting == nm.NM_SETTING_CONNECTION_SETTING_NAME && key == nm.NM_SETTING_CONNECTION_TYPE {
+			v = getNMKeyfileSectionName(v)
+		}
+		kf.SetString(section, key, v)
+	case bool:
+		kf.SetBool(section, key, v)
+	case int32:
+		kf.SetInt64(section, key, int64(v))
+	case uint32:
+		kf.SetUint64(section, key, uint64(v))
+	case int64:
+		kf.SetInt64(section, key, v)
+	case uint64:
+		kf.SetUint64(section, key, v)
+	case []string:
+		kf.SetStringList(section, key, v)
+	case []byte:
+		formatNMKeyfileBytes(kf, section, setting, key, v)
+	case []uint32:
+		// ipv4 dns
+		if key != "dns" {
+			logger.Debugf("ignore %s.%s when export keyfile", setting, key)
+			return
+		}
+		var list []string
+		for _, dns := range v {
+			list = append(list, uint32ToIP(ntohl(dns)))
+		}
+		kf.SetStringList(section, key, list)
+	case [][]byte:
+		// ipv6 dns
+		if key != "dns" {
+			logger.Debugf("ignore %s.%s when export keyfile", setting, key)
+			return
+		}
+		var list []string
+		for _, dns := range v {
+			list = append(list, net.IP(dns).String())
+		}
+		kf.SetStringList(section, key, list)
+	case map[string]string:
+		formatNMKeyfileStringMap(kf, setting, key, v)
+	case []map[string]dbus.Variant:
+		formatNMKeyfileVariantMaps(kf, section, setting, key, v)
+	default:
+		// addresses 和 routes 等旧格式由 address-data 和 route-data 导出
+		logger.Debugf("ignore %s.%s when export keyfile", setting, key)
+	}
+}
+
+func isPrintableSsid(ssid []byte) bool {
+	if !utf8.Valid(ssid) {
+		return false
+	}
+	for _, r := range string(ssid) {
+		if !unicode.IsPrint(r) || r == ';' {
+			return false
+		}
+	}
+	return true
+}
+
+func formatNMKeyfileBytes(kf *keyfile.KeyFile, section, setting, key string, value []byte) {
+	if setting == nm.NM_SETTING_WIRELESS_SETTING_NAME && key == nm.NM_SETTING_WIRELESS_SSID && isPrintableSsid(value) {
+		kf.SetString(section, key, string(value))
+		return
+	}
+	switch getNMKeyfileValueType(key) {
+	case nmKeyfileMacAddress:
+		kf.SetString(section, key, convertMacAddressToString(value))
+		return
+	case nmKeyfileCertPath:
+		kf.SetString(section, key, toLocalPathFor8021x(byteArrayToStrPath(value)))
+		return
+	}
+	if key == "cloned-mac-address" {
+		kf.SetString(section, key, convertMacAddressToString(value))
+		return
+	}
+	var sb strings.Builder
+	for _, b := range value {
+		sb.WriteString(strconv.Itoa(int(b)))
+		sb.WriteByte(';')
+	}
+	kf.SetValue(section, key, sb.String())
+}
+
+func formatNMKeyfileStringMap(kf *keyfile.KeyFile, setting, key string, value map[string]string) {
+	var section string
+	switch {
+	case setting == nm.NM_SETTING_VPN_SETTING_NAME && key == nm.NM_SETTING_VPN_DATA:
+		section = nm.NM_SETTING_VPN_SETTING_NAME
+	case setting == nm.NM_SETTING_VPN_SETTING_NAME && key == nm.NM_SETTING_VPN_SECRETS:
+		section = nmKeyfileVpnSecretsSection
+	case setting == nm.NM_SETTING_USER_SETTING_NAME && key == "data":
+		section = nm.NM_SETTING_USER_SETTING_NAME
+	default:
+		logger.Debugf("ignore %s.%s when export keyfile", setting, key)
+		return
+	}
+	keys := make([]string, 0, len(value))
+	for k := range value {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		kf.SetString(section, k, value[k])
+	}
+}
+
+func formatNMKeyfileVariantMaps(kf *keyfile.KeyFile, section, setting, key string, value []map[string]dbus.Variant) {
+	switch {
+	case key == "address-data" || key == "route-data":
+		prefix := "address"
+		if key == "route-data" {
+			prefix = "route"
+		}
+		for i, item := range value {
+			address, _ := item["address"].Value().(string)
+			prefixLen, _ := item["prefix"].Value().(uint32)
+			str := fmt.Sprintf("%s/%d", address, prefixLen)
+			if key == "route-data" {
+				nextHop, _ := item["next-hop"].Value().(string)
+				metric, hasMetric := item["metric"].Value().(uint32)
+				if hasMetric {
+					str += fmt.Sprintf(",%s,%d", nextHop, metric)
+				} else if nextHop != "" {
+					str += "," + nextHop
+				}
+			}
+			kf.SetString(section, fmt.Sprintf("%s%d", prefix, i+1), str)
+		}
+	case setting == nm.NM_SETTING_WIREGUARD_SETTING_NAME && key == nm.NM_SETTING_WIREGUARD_PEERS:
+		for _, peer := range value {
+			publicKey, _ := peer[nm.NM_WIREGUARD_PEER_ATTR_PUBLIC_KEY].Value().(string)
+			peerSection := nmKeyfilePeerSectionPrefix + publicKey
+			attrs := make([]string, 0, len(peer))
+			for attr := range peer {
+				attrs = append(attrs, attr)
+			}
+			sort.Strings(attrs)
+			for _, attr := range attrs {
+				if attr != nm.NM_WIREGUARD_PEER_ATTR_PUBLIC_KEY {
+					formatNMKeyfileValue(kf, peerSection, setting, attr, peer[attr].Value())
+				}
+			}
+		}
+	default:
+		logger.Debugf("ignore %s.%s when export keyfile", setting, key)
+	}
+}
@@ -0,0 +1,68 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"os"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func checkTestNMKeyfileData(c *C.C, data connectionData) {
+	c.Check(getSettingConnectionId(data), C.Equals, "office")
+	c.Check(getSettingConnectionUuid(data), C.Equals, "2f9c7a4e-3e1b-4a61-9f0d-0a6f1d0c5b8e")
+	c.Check(getSettingConnectionType(data), C.Equals, nm.NM_SETTING_WIRELESS_SETTING_NAME)
+	c.Check(getSettingConnectionAutoconnectPriority(data), C.Equals, int32(10))
+	c.Check(getSettingWirelessSsid(data), C.DeepEquals, []byte("Office WiFi"))
+	c.Check(getSettingWirelessMacAddress(data), C.DeepEquals, []byte{0x00, 0x11, 0x22, 0x33, 0x44, 0x55})
+	c.Check(getSettingWirelessSecurityKeyMgmt(data), C.Equals, "wpa-psk")
+	c.Check(getSettingWirelessSecurityPsk(data), C.Equals, "secret123")
+	c.Check(getSettingIP4ConfigMethod(data), C.Equals, nm.NM_SETTING_IP4_CONFIG_METHOD_MANUAL)
+	c.Check(getSettingIP4ConfigGateway(data), C.Equals, "192.168.1.1")
+	c.Check(getSettingIP4ConfigDns(data), C.DeepEquals,
+		[]uint32{htonl(ipToUint32("8.8.8.8")), htonl(ipToUint32("1.1.1.1"))})
+	c.Check(getSettingIP6ConfigMethod(data), C.Equals, nm.NM_SETTING_IP6_CONFIG_METHOD_AUTO)
+
+	addresses, ok := getSettingKey(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME, "address-data").([]map[string]dbus.Variant)
+	c.Assert(ok, C.Equals, true)
+	c.Assert(addresses, C.HasLen, 1)
+	c.Check(addresses[0]["address"].Value(), C.Equals, "192.168.1.10")
+	c.Check(addresses[0]["prefix"].Value(), C.Equals, uint32(24))
+
+	routes, ok := getSettingKey(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME, "route-data").([]map[string]dbus.Variant)
+	c.Assert(ok, C.Equals, true)
+	c.Assert(routes, C.HasLen, 1)
+	c.Check(routes[0]["address"].Value(), C.Equals, "10.0.0.0")
+	c.Check(routes[0]["next-hop"].Value(), C.Equals, "192.168.1.254")
+	c.Check(routes[0]["metric"].Value(), C.Equals, uint32(100))
+}
+
+func (*testWrapper) TestNMKeyfile(c *C.C) {
+	content, err := os.ReadFile("testdata/test.nmconnection")
+	c.Assert(err, C.IsNil)
+	data, err := parseNMKeyfile(content)
+	c.Assert(err, C.IsNil)
+	checkTestNMKeyfileData(c, data)
+
+	// 导出后再导入，配置不变
+	content, err = formatNMKeyfile(data)
+	c.Assert(err, C.IsNil)
+	data, err = parseNMKeyfile(content)
+	c.Assert(err, C.IsNil)
+	checkTestNMKeyfileData(c, data)
+
+	_, err = parseNMKeyfile([]byte("[connection]\nid=test\n"))
+	c.Check(err, C.NotNil)
+	_, err = parseNMKeyfile([]byte("[connection]\nid=test\ntype=ethernet\nautoconnect=maybe\n"))
+	c.Check(err, C.NotNil)
+}
+
+func (*testWrapper) TestParseNMKeyfileSsid(c *C.C) {
+	c.Check(parseNMKeyfileSsid("home"), C.DeepEquals, []byte("home"))
+	c.Check(parseNMKeyfileSsid("104;111;109;101;"), C.DeepEquals, []byte("home"))
+	c.Check(parseNMKeyfileSsid("1;300;"), C.DeepEquals, []byte("1;300;"))
+}
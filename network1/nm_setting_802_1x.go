@@ -5,11 +5,215 @@
 package network
 
 import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/linuxdeepin/dde-daemon/network1/nm"
 )
 
+// eapConfig 企业级无线网络的认证配置，证书和私钥为本地文件路径
+type eapConfig struct {
+	Method             string // peap, ttls, tls
+	Identity           string
+	AnonymousIdentity  string
+	Password           string
+	Phase2Auth         string
+	CaCert             string
+	DomainSuffixMatch  string
+	ClientCert         string
+	PrivateKey         string
+	PrivateKeyPassword string
+}
+
+var eapPhase2AuthMethods = map[string][]string{
+	"peap": {"mschapv2", "md5", "gtc"},
+	"ttls": {"pap", "chap", "mschap", "mschapv2", "gtc", "md5"},
+}
+
+func (cfg *eapConfig) check(now time.Time) (err error) {
+	if cfg.Identity == "" {
+		return errors.New("identity is empty")
+	}
+	switch cfg.Method {
+	case "peap", "ttls":
+		if cfg.Phase2Auth != "" && !isStringInArray(cfg.Phase2Auth, eapPhase2AuthMethods[cfg.Method]) {
+			return fmt.Errorf("invalid phase2 auth %q for eap method %s", cfg.Phase2Auth, cfg.Method)
+		}
+	case "tls":
+		if cfg.ClientCert == "" || cfg.PrivateKey == "" {
+			return errors.New("client certificate and private key are required for eap method tls")
+		}
+		err = checkCertFile(cfg.ClientCert, now)
+		if err != nil {
+			return
+		}
+		err = checkPrivateKeyFile(cfg.PrivateKey)
+		if err != nil {
+			return
+		}
+	default:
+		return fmt.Errorf("invalid eap method %q", cfg.Method)
+	}
+	if cfg.CaCert != "" {
+		err = checkCertFile(cfg.CaCert, now)
+	}
+	return
+}
+
+func checkEapFilePath(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("%q is not an absolute path", path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%q is a directory", path)
+	}
+	return nil
+}
+
+// checkCertFile 检查证书文件能否解析以及是否在有效期内，支持 PEM 和 DER 格式，
+// PKCS#12 文件无法在此解析，交由 NetworkManager 检查
+func checkCertFile(path string, now time.Time) error {
+	err := checkEapFilePath(path)
+	if err != nil {
+		return err
+	}
+	if isPkcs12File(path) {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	certs, err := parseCerts(content)
+	if err != nil {
+		return fmt.Errorf("invalid certificate %q: %v", path, err)
+	}
+	for _, cert := range certs {
+		if now.After(cert.NotAfter) {
+			return fmt.Errorf("certificate %q expired at %v", path, cert.NotAfter)
+		}
+		if now.Before(cert.NotBefore) {
+			return fmt.Errorf("certificate %q is not valid before %v", path, cert.NotBefore)
+		}
+	}
+	return nil
+}
+
+func parseCerts(content []byte) (certs []*x509.Certificate, err error) {
+	rest := content
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) > 0 {
+		return certs, nil
+	}
+	// 不是 PEM 格式时按 DER 解析
+	return x509.ParseCertificates(content)
+}
+
+func checkPrivateKeyFile(path string) error {
+	err := checkEapFilePath(path)
+	if err != nil {
+		return err
+	}
+	if isPkcs12File(path) {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(content)
+	if block == nil {
+		// DER 格式的私钥
+		if _, err := x509.ParsePKCS8PrivateKey(content); err == nil {
+			return nil
+		}
+		if _, err := x509.ParsePKCS1PrivateKey(content); err == nil {
+			return nil
+		}
+		return fmt.Errorf("invalid private key %q", path)
+	}
+	// 加密的私钥需要密码，只检查类型
+	switch block.Type {
+	case "PRIVATE KEY", "RSA PRIVATE KEY", "EC PRIVATE KEY", "ENCRYPTED PRIVATE KEY":
+		return nil
+	default:
+		return fmt.Errorf("invalid private key %q: unexpected type %s", path, block.Type)
+	}
+}
+
+func isPkcs12File(path string) bool {
+	switch filepath.Ext(path) {
+	case ".p12", ".pfx":
+		return true
+	}
+	return false
+}
+
+func setSetting8021xCertPath(data connectionData, setter func(connectionData, []byte), path string) {
+	if path != "" {
+		setter(data, strToByteArrayPath(toUriPathFor8021x(path)))
+	}
+}
+
+// newEapConnectionData 创建企业级无线网络连接，密码由 secret agent 保存到密钥环中
+func newEapConnectionData(id, uuid string, ssid []byte, macAddress string, cfg *eapConfig) (data connectionData, err error) {
+	data = newWirelessConnectionData(id, uuid, ssid, "wpa-eap", macAddress)
+	err = logicSetSetting8021xEap(data, []string{cfg.Method})
+	if err != nil {
+		return
+	}
+	setSetting8021xIdentity(data, cfg.Identity)
+	if cfg.AnonymousIdentity != "" && cfg.Method != "tls" {
+		setSetting8021xAnonymousIdentity(data, cfg.AnonymousIdentity)
+	}
+	setSetting8021xCertPath(data, setSetting8021xCaCert, cfg.CaCert)
+	if cfg.DomainSuffixMatch != "" {
+		setSetting8021xDomainSuffixMatch(data, cfg.DomainSuffixMatch)
+	}
+
+	switch cfg.Method {
+	case "tls":
+		setSetting8021xCertPath(data, setSetting8021xClientCert, cfg.ClientCert)
+		setSetting8021xCertPath(data, setSetting8021xPrivateKey, cfg.PrivateKey)
+		setSetting8021xPrivateKeyPasswordFlags(data, nm.NM_SETTING_SECRET_FLAG_AGENT_OWNED)
+		if cfg.PrivateKeyPassword != "" {
+			setSetting8021xPrivateKeyPassword(data, cfg.PrivateKeyPassword)
+		}
+	default:
+		if cfg.Phase2Auth != "" {
+			setSetting8021xPhase2Auth(data, cfg.Phase2Auth)
+		}
+		setSetting8021xPasswordFlags(data, nm.NM_SETTING_SECRET_FLAG_AGENT_OWNED)
+		if cfg.Password != "" {
+			setSetting8021xPassword(data, cfg.Password)
+		}
+	}
+	return
+}
+
 // Logic setter
 func logicSetSetting8021xEap(data connectionData, value []string) (err error) {
 	if len(value) == 0 {
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func writeTestCert(c *C.C, dir string, notBefore, notAfter time.Time) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, C.IsNil)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	c.Assert(err, C.IsNil)
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	c.Assert(err, C.IsNil)
+
+	certFile = filepath.Join(dir, "client.pem")
+	keyFile = filepath.Join(dir, "client.key")
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	c.Assert(err, C.IsNil)
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer}), 0600)
+	c.Assert(err, C.IsNil)
+	return
+}
+
+func (*testWrapper) TestEapConfigCheck(c *C.C) {
+	now := time.Now()
+	certFile, keyFile := writeTestCert(c, c.MkDir(), now.Add(-time.Hour), now.Add(time.Hour))
+
+	c.Check((&eapConfig{Method: "peap", Identity: "user"}).check(now), C.IsNil)
+	c.Check((&eapConfig{Method: "ttls", Identity: "user", Phase2Auth: "pap", CaCert: certFile}).check(now), C.IsNil)
+	c.Check((&eapConfig{Method: "tls", Identity: "user", ClientCert: certFile, PrivateKey: keyFile}).check(now), C.IsNil)
+
+	c.Check((&eapConfig{Method: "leap", Identity: "user"}).check(now), C.NotNil)
+	c.Check((&eapConfig{Method: "peap"}).check(now), C.NotNil)
+	c.Check((&eapConfig{Method: "peap", Identity: "user", Phase2Auth: "pap"}).check(now), C.NotNil)
+	c.Check((&eapConfig{Method: "tls", Identity: "user", ClientCert: certFile}).check(now), C.NotNil)
+	// 证书路径必须是存在的绝对路径
+	c.Check((&eapConfig{Method: "peap", Identity: "user", CaCert: "ca.pem"}).check(now), C.NotNil)
+	c.Check((&eapConfig{Method: "peap", Identity: "user", CaCert: "/nonexistent/ca.pem"}).check(now), C.NotNil)
+	// 私钥不能作为证书
+	c.Check((&eapConfig{Method: "peap", Identity: "user", CaCert: keyFile}).check(now), C.NotNil)
+	// 过期的证书
+	c.Check((&eapConfig{Method: "peap", Identity: "user", CaCert: certFile}).check(now.Add(2*time.Hour)), C.NotNil)
+}
+
+func (*testWrapper) TestNewEapConnectionData(c *C.C) {
+	cfg := &eapConfig{
+		Method:     "peap",
+		Identity:   "user",
+		Password:   "password",
+		Phase2Auth: "gtc",
+		CaCert:     "/etc/ssl/ca.pem",
+	}
+	data, err := newEapConnectionData("eduroam", "uuid", []byte("eduroam"), "", cfg)
+	c.Assert(err, C.IsNil)
+	c.Check(getSettingVkWirelessSecurityKeyMgmt(data), C.Equals, "wpa-eap")
+	c.Check(getSettingVk8021xEap(data), C.Equals, "peap")
+	c.Check(getSetting8021xIdentity(data), C.Equals, "user")
+	c.Check(getSetting8021xPhase2Auth(data), C.Equals, "gtc")
+	c.Check(getSetting8021xPassword(data), C.Equals, "password")
+	c.Check(getSetting8021xPasswordFlags(data), C.Equals, uint32(nm.NM_SETTING_SECRET_FLAG_AGENT_OWNED))
+	c.Check(getSetting8021xCaCert(data), C.DeepEquals, strToByteArrayPath("file:///etc/ssl/ca.pem"))
+
+	cfg = &eapConfig{
+		Method:     "tls",
+		Identity:   "user",
+		ClientCert: "/etc/ssl/client.pem",
+		PrivateKey: "/etc/ssl/client.key",
+	}
+	data, err = newEapConnectionData("eduroam", "uuid", []byte("eduroam"), "", cfg)
+	c.Assert(err, C.IsNil)
+	c.Check(getSettingVk8021xEap(data), C.Equals, "tls")
+	c.Check(getSetting8021xPrivateKey(data), C.DeepEquals, strToByteArrayPath("file:///etc/ssl/client.key"))
+	c.Check(getSetting8021xPrivateKeyPasswordFlags(data), C.Equals, uint32(nm.NM_SETTING_SECRET_FLAG_AGENT_OWNED))
+	c.Check(isSetting8021xPasswordExists(data), C.Equals, false)
+}
@@ -5,10 +5,60 @@
 package network
 
 import (
+	"fmt"
+
 	"github.com/linuxdeepin/dde-daemon/network1/nm"
 )
 
 func initSettingSectionIpv6(data connectionData) {
 	addSetting(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME)
 	setSettingIP6ConfigMethod(data, nm.NM_SETTING_IP6_CONFIG_METHOD_AUTO)
+	// 不使用由 MAC 地址生成的 EUI-64 地址
+	setSettingIP6ConfigAddrGenMode(data, nm.NM_SETTING_IP6_CONFIG_ADDR_GEN_MODE_STABLE_PRIVACY)
+}
+
+func isIP6ConfigEnabled(data connectionData) bool {
+	if !isSettingExists(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME) {
+		return false
+	}
+	return !isIP6ConfigDisabled(data)
+}
+
+// Logic setter
+func logicSetSettingIP6ConfigIp6Privacy(data connectionData, value int32) (err error) {
+	switch value {
+	case nm.NM_SETTING_IP6_CONFIG_PRIVACY_UNKNOWN, nm.NM_SETTING_IP6_CONFIG_PRIVACY_DISABLED,
+		nm.NM_SETTING_IP6_CONFIG_PRIVACY_PREFER_PUBLIC_ADDR, nm.NM_SETTING_IP6_CONFIG_PRIVACY_PREFER_TEMP_ADDR:
+	default:
+		return fmt.Errorf("invalid ip6-privacy value %d", value)
+	}
+	if !isIP6ConfigEnabled(data) {
+		return fmt.Errorf("ipv6 is disabled on the connection")
+	}
+	setSettingIP6ConfigIp6Privacy(data, value)
+	return
+}
+
+func logicSetSettingIP6ConfigAddrGenMode(data connectionData, value int32) (err error) {
+	switch value {
+	case nm.NM_SETTING_IP6_CONFIG_ADDR_GEN_MODE_EUI64, nm.NM_SETTING_IP6_CONFIG_ADDR_GEN_MODE_STABLE_PRIVACY:
+	default:
+		return fmt.Errorf("invalid addr-gen-mode value %d", value)
+	}
+	if !isIP6ConfigEnabled(data) {
+		return fmt.Errorf("ipv6 is disabled on the connection")
+	}
+	setSettingIP6ConfigAddrGenMode(data, value)
+	return
+}
+
+// needMigrateIP6AddrGenMode 判断连接是否仍在使用由 MAC 地址生成的 EUI-64 地址
+func needMigrateIP6AddrGenMode(data connectionData) bool {
+	switch getSettingConnectionType(data) {
+	case nm.NM_SETTING_WIRED_SETTING_NAME, nm.NM_SETTING_WIRELESS_SETTING_NAME:
+	default:
+		return false
+	}
+	return isIP6ConfigEnabled(data) &&
+		getSettingIP6ConfigAddrGenMode(data) == nm.NM_SETTING_IP6_CONFIG_ADDR_GEN_MODE_EUI64
 }
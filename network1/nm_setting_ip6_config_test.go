@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func newTestIP6ConnectionData() connectionData {
+	data := make(connectionData)
+	addSetting(data, nm.NM_SETTING_CONNECTION_SETTING_NAME)
+	setSettingConnectionType(data, nm.NM_SETTING_WIRED_SETTING_NAME)
+	initSettingSectionIpv6(data)
+	return data
+}
+
+func (*testWrapper) TestLogicSetSettingIP6ConfigPrivacy(c *C.C) {
+	data := newTestIP6ConnectionData()
+	c.Check(getSettingIP6ConfigAddrGenMode(data), C.Equals, int32(nm.NM_SETTING_IP6_CONFIG_ADDR_GEN_MODE_STABLE_PRIVACY))
+
+	c.Check(logicSetSettingIP6ConfigIp6Privacy(data, nm.NM_SETTING_IP6_CONFIG_PRIVACY_PREFER_TEMP_ADDR), C.IsNil)
+	c.Check(getSettingIP6ConfigIp6Privacy(data), C.Equals, int32(nm.NM_SETTING_IP6_CONFIG_PRIVACY_PREFER_TEMP_ADDR))
+	c.Check(logicSetSettingIP6ConfigAddrGenMode(data, nm.NM_SETTING_IP6_CONFIG_ADDR_GEN_MODE_EUI64), C.IsNil)
+	c.Check(getSettingIP6ConfigAddrGenMode(data), C.Equals, int32(nm.NM_SETTING_IP6_CONFIG_ADDR_GEN_MODE_EUI64))
+
+	c.Check(logicSetSettingIP6ConfigIp6Privacy(data, 3), C.NotNil)
+	c.Check(logicSetSettingIP6ConfigAddrGenMode(data, 5), C.NotNil)
+
+	// ipv6 被禁用时不能设置
+	setSettingIP6ConfigMethod(data, nm.NM_SETTING_IP6_CONFIG_METHOD_IGNORE)
+	c.Check(logicSetSettingIP6ConfigIp6Privacy(data, nm.NM_SETTING_IP6_CONFIG_PRIVACY_DISABLED), C.NotNil)
+}
+
+func (*testWrapper) TestNeedMigrateIP6AddrGenMode(c *C.C) {
+	data := newTestIP6ConnectionData()
+	c.Check(needMigrateIP6AddrGenMode(data), C.Equals, false)
+	setSettingIP6ConfigAddrGenMode(data, nm.NM_SETTING_IP6_CONFIG_ADDR_GEN_MODE_EUI64)
+	c.Check(needMigrateIP6AddrGenMode(data), C.Equals, true)
+	setSettingIP6ConfigMethod(data, nm.NM_SETTING_IP6_CONFIG_METHOD_IGNORE)
+	c.Check(needMigrateIP6AddrGenMode(data), C.Equals, false)
+}
@@ -0,0 +1,468 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+)
+
+// 导入 .ovpn 时内联证书的保存目录，相对于用户主目录
+const openvpnCertDir = ".cert/nm-openvpn"
+
+// .ovpn 中支持内联的块及其对应的 vpn data key
+var openvpnInlineBlocks = map[string]string{
+	"ca":        nm.NM_SETTING_VPN_OPENVPN_KEY_CA,
+	"cert":      nm.NM_SETTING_VPN_OPENVPN_KEY_CERT,
+	"key":       nm.NM_SETTING_VPN_OPENVPN_KEY_KEY,
+	"tls-auth":  nm.NM_SETTING_VPN_OPENVPN_KEY_TA,
+	"tls-crypt": "tls-crypt",
+	"secret":    nm.NM_SETTING_VPN_OPENVPN_KEY_STATIC_KEY,
+}
+
+// 简单的一一对应的指令
+var openvpnSimpleDirectives = map[string]string{
+	"cipher":               nm.NM_SETTING_VPN_OPENVPN_KEY_CIPHER,
+	"auth":                 nm.NM_SETTING_VPN_OPENVPN_KEY_AUTH,
+	"reneg-sec":            nm.NM_SETTING_VPN_OPENVPN_KEY_RENEG_SECONDS,
+	"tun-mtu":              nm.NM_SETTING_VPN_OPENVPN_KEY_TUNNEL_MTU,
+	"fragment":             nm.NM_SETTING_VPN_OPENVPN_KEY_FRAGMENT_SIZE,
+	"mssfix":               nm.NM_SETTING_VPN_OPENVPN_KEY_MSSFIX,
+	"remote-cert-tls":      nm.NM_SETTING_VPN_OPENVPN_KEY_REMOTE_CERT_TLS,
+	"tls-version-min":      "tls-version-min",
+	"ping":                 "ping",
+	"ping-restart":         "ping-restart",
+	"verify-x509-name":     "verify-x509-name",
+	"max-routes":           "max-routes",
+	"tls-cipher":           "tls-cipher",
+	"data-ciphers":         "data-ciphers",
+	"connect-timeout":      "connect-timeout",
+	"ns-cert-type":         "ns-cert-type",
+	"keysize":              "keysize",
+	"remote-random":        nm.NM_SETTING_VPN_OPENVPN_KEY_REMOTE_RANDOM,
+	"float":                "float",
+	"auth-nocache":         "",
+	"persist-key":          "",
+	"persist-tun":          "",
+	"nobind":               "",
+	"client":               "",
+	"resolv-retry":         "",
+	"verb":                 "",
+	"mute":                 "",
+	"mute-replay-warnings": "",
+}
+
+// 无参数的指令在 vpn data 中的值为 yes
+var openvpnFlagDirectives = map[string]bool{
+	"remote-random": true,
+	"float":         true,
+}
+
+// parseOvpnConfig 解析 OpenVPN 的 .ovpn 配置文件，返回 NetworkManager openvpn 插件使用的 vpn data，
+// 相对路径相对于 baseDir，内联的证书和密钥以 name 为前缀保存到 certDir
+func parseOvpnConfig(content []byte, baseDir, certDir, name string) (vpnData map[string]string, err error) {
+	vpnData = make(map[string]string)
+	var remotes []string
+	var inlineBlock string
+	var inlineContent strings.Builder
+	proto, port, keyDirection := "", "", ""
+	authUserPass := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		if inlineBlock != "" {
+			if line == "</"+inlineBlock+">" {
+				var file string
+				file, err = saveOpenvpnInlineFile(certDir, name, inlineBlock, inlineContent.String())
+				if err != nil {
+					return nil, err
+				}
+				vpnData[openvpnInlineBlocks[inlineBlock]] = file
+				inlineBlock = ""
+				inlineContent.Reset()
+			} else {
+				inlineContent.WriteString(line)
+				inlineContent.WriteByte('\n')
+			}
+			continue
+		}
+
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "<") && strings.HasSuffix(line, ">") {
+			block := line[1 : len(line)-1]
+			if _, ok := openvpnInlineBlocks[block]; !ok {
+				return nil, fmt.Errorf("line %d: unsupported inline block %q", lineNum, block)
+			}
+			inlineBlock = block
+			continue
+		}
+
+		fields := splitOpenvpnLine(line)
+		directive := strings.TrimPrefix(fields[0], "--")
+		args := fields[1:]
+		argErr := fmt.Errorf("line %d: invalid arguments of %q", lineNum, directive)
+
+		switch directive {
+		case "remote":
+			if len(args) == 0 {
+				return nil, argErr
+			}
+			remote := args[0]
+			if len(args) > 1 {
+				remote += ":" + args[1]
+			}
+			if len(args) > 2 {
+				remote += ":" + args[2]
+			}
+			remotes = append(remotes, remote)
+		case "port":
+			if len(args) != 1 {
+				return nil, argErr
+			}
+			port = args[0]
+		case "proto":
+			if len(args) != 1 {
+				return nil, argErr
+			}
+			proto = args[0]
+		case "dev":
+			if len(args) != 1 {
+				return nil, argErr
+			}
+			if strings.HasPrefix(args[0], "tap") {
+				vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_TAP_DEV] = "yes"
+			}
+		case "dev-type":
+			if len(args) != 1 {
+				return nil, argErr
+			}
+			if args[0] == "tap" {
+				vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_TAP_DEV] = "yes"
+			}
+		case "ca", "cert", "key", "tls-crypt":
+			if len(args) != 1 {
+				return nil, argErr
+			}
+			if args[0] != "[inline]" {
+				vpnData[openvpnInlineBlocks[directive]] = resolveOpenvpnPath(baseDir, args[0])
+			}
+		case "tls-auth", "secret":
+			if len(args) == 0 || len(args) > 2 {
+				return nil, argErr
+			}
+			if args[0] != "[inline]" {
+				vpnData[openvpnInlineBlocks[directive]] = resolveOpenvpnPath(baseDir, args[0])
+			}
+			if len(args) == 2 {
+				if directive == "tls-auth" {
+					vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_TA_DIR] = args[1]
+				} else {
+					vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_STATIC_KEY_DIRECTION] = args[1]
+				}
+			}
+		case "key-direction":
+			if len(args) != 1 {
+				return nil, argErr
+			}
+			keyDirection = args[0]
+		case "auth-user-pass":
+			authUserPass = true
+		case "comp-lzo":
+			value := "adaptive"
+			if len(args) == 1 {
+				value = args[0]
+			}
+			vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_COMP_LZO] = value
+		case "compress":
+			value := "yes"
+			if len(args) == 1 {
+				value = args[0]
+			}
+			vpnData["compress"] = value
+		case "ifconfig":
+			if len(args) != 2 {
+				return nil, argErr
+			}
+			vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_LOCAL_IP] = args[0]
+			vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_REMOTE_IP] = args[1]
+		default:
+			key, ok := openvpnSimpleDirectives[directive]
+			if !ok {
+				logger.Debugf("ignore unsupported openvpn directive %q", directive)
+				continue
+			}
+			if key == "" {
+				continue
+			}
+			if openvpnFlagDirectives[directive] {
+				vpnData[key] = "yes"
+				continue
+			}
+			if len(args) == 0 {
+				return nil, argErr
+			}
+			vpnData[key] = strings.Join(args, " ")
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if inlineBlock != "" {
+		return nil, fmt.Errorf("inline block %q is not closed", inlineBlock)
+	}
+
+	if len(remotes) == 0 {
+		return nil, errors.New("no remote in openvpn config")
+	}
+	vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_REMOTE] = strings.Join(remotes, ", ")
+	if port != "" {
+		vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_PORT] = port
+	}
+	if strings.HasPrefix(proto, "tcp") {
+		vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_PROTO_TCP] = "yes"
+	}
+
+	// key-direction 同时作用于 tls-auth 和 secret 中未指定方向的密钥
+	if keyDirection != "" {
+		if vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_TA] != "" && vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_TA_DIR] == "" {
+			vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_TA_DIR] = keyDirection
+		}
+		if vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_STATIC_KEY] != "" &&
+			vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_STATIC_KEY_DIRECTION] == "" {
+			vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_STATIC_KEY_DIRECTION] = keyDirection
+		}
+	}
+
+	switch {
+	case vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_STATIC_KEY] != "":
+		vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_CONNECTION_TYPE] = nm.NM_OPENVPN_CONTYPE_STATIC_KEY
+	case authUserPass && vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_CERT] != "":
+		vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_CONNECTION_TYPE] = nm.NM_OPENVPN_CONTYPE_PASSWORD_TLS
+	case authUserPass:
+		vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_CONNECTION_TYPE] = nm.NM_OPENVPN_CONTYPE_PASSWORD
+	default:
+		vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_CONNECTION_TYPE] = nm.NM_OPENVPN_CONTYPE_TLS
+	}
+	if authUserPass {
+		// 密码由用户在连接时输入
+		vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_PASSWORD_FLAGS] = strconv.Itoa(nm.NM_SETTING_SECRET_FLAG_AGENT_OWNED)
+	}
+	if vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_KEY] != "" {
+		vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_CERTPASS_FLAGS] = strconv.Itoa(nm.NM_SETTING_SECRET_FLAG_AGENT_OWNED)
+	}
+
+	err = checkOpenvpnVpnData(vpnData)
+	if err != nil {
+		return nil, err
+	}
+	return vpnData, nil
+}
+
+// splitOpenvpnLine 按空白分割指令，支持引号
+func splitOpenvpnLine(line string) (fields []string) {
+	var sb strings.Builder
+	var quote rune
+	inField := false
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				sb.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inField = true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, sb.String())
+				sb.Reset()
+				inField = false
+			}
+		case r == '#' || r == ';':
+			if !inField {
+				return
+			}
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, sb.String())
+	}
+	return
+}
+
+func resolveOpenvpnPath(baseDir, file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(baseDir, file)
+}
+
+func saveOpenvpnInlineFile(certDir, name, block, content string) (string, error) {
+	err := os.MkdirAll(certDir, 0700)
+	if err != nil {
+		return "", err
+	}
+	suffix := ".pem"
+	if block == "tls-auth" || block == "tls-crypt" || block == "secret" {
+		suffix = ".key"
+	}
+	file := filepath.Join(certDir, fmt.Sprintf("%s-%s%s", name, block, suffix))
+	err = os.WriteFile(file, []byte(content), 0600)
+	if err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+// checkOpenvpnVpnData 检查连接所需的证书和密钥文件是否齐全
+func checkOpenvpnVpnData(vpnData map[string]string) error {
+	var required []string
+	switch vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_CONNECTION_TYPE] {
+	case nm.NM_OPENVPN_CONTYPE_STATIC_KEY:
+		required = []string{nm.NM_SETTING_VPN_OPENVPN_KEY_STATIC_KEY}
+	case nm.NM_OPENVPN_CONTYPE_PASSWORD:
+		required = []string{nm.NM_SETTING_VPN_OPENVPN_KEY_CA}
+	default:
+		required = []string{nm.NM_SETTING_VPN_OPENVPN_KEY_CA, nm.NM_SETTING_VPN_OPENVPN_KEY_CERT,
+			nm.NM_SETTING_VPN_OPENVPN_KEY_KEY}
+	}
+	for _, key := range required {
+		if vpnData[key] == "" {
+			return fmt.Errorf("%s is missing in openvpn config", key)
+		}
+	}
+	for _, key := range []string{nm.NM_SETTING_VPN_OPENVPN_KEY_CA, nm.NM_SETTING_VPN_OPENVPN_KEY_CERT,
+		nm.NM_SETTING_VPN_OPENVPN_KEY_KEY, nm.NM_SETTING_VPN_OPENVPN_KEY_TA, "tls-crypt",
+		nm.NM_SETTING_VPN_OPENVPN_KEY_STATIC_KEY} {
+		file := vpnData[key]
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			return fmt.Errorf("%s file %q is not accessible: %v", key, file, err)
+		}
+	}
+	return nil
+}
+
+func newOpenvpnConnectionData(id, uuid string, vpnData map[string]string) (data connectionData) {
+	data = make(connectionData)
+
+	addSetting(data, nm.NM_SETTING_CONNECTION_SETTING_NAME)
+	setSettingConnectionId(data, id)
+	setSettingConnectionUuid(data, uuid)
+	setSettingConnectionType(data, nm.NM_SETTING_VPN_SETTING_NAME)
+	setSettingConnectionAutoconnect(data, false)
+
+	addSetting(data, nm.NM_SETTING_VPN_SETTING_NAME)
+	setSettingVpnServiceType(data, nm.NM_DBUS_SERVICE_OPENVPN)
+	setSettingVpnData(data, vpnData)
+
+	addSetting(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME)
+	setSettingIP4ConfigMethod(data, nm.NM_SETTING_IP4_CONFIG_METHOD_AUTO)
+	addSetting(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME)
+	setSettingIP6ConfigMethod(data, nm.NM_SETTING_IP6_CONFIG_METHOD_AUTO)
+	return
+}
+
+// formatOvpnConfig 由 vpn data 生成 .ovpn 配置文件，证书和密钥以内联方式导出，便于迁移
+func formatOvpnConfig(vpnData map[string]string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("client\n")
+	if vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_TAP_DEV] == "yes" {
+		buf.WriteString("dev tap\n")
+	} else {
+		buf.WriteString("dev tun\n")
+	}
+	if vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_PROTO_TCP] == "yes" {
+		buf.WriteString("proto tcp\n")
+	} else {
+		buf.WriteString("proto udp\n")
+	}
+	for _, remote := range strings.Split(vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_REMOTE], ",") {
+		remote = strings.TrimSpace(remote)
+		if remote != "" {
+			fmt.Fprintf(&buf, "remote %s\n", strings.ReplaceAll(remote, ":", " "))
+		}
+	}
+	if port := vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_PORT]; port != "" {
+		fmt.Fprintf(&buf, "port %s\n", port)
+	}
+	if localIP := vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_LOCAL_IP]; localIP != "" {
+		fmt.Fprintf(&buf, "ifconfig %s %s\n", localIP, vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_REMOTE_IP])
+	}
+	switch vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_CONNECTION_TYPE] {
+	case nm.NM_OPENVPN_CONTYPE_PASSWORD, nm.NM_OPENVPN_CONTYPE_PASSWORD_TLS:
+		buf.WriteString("auth-user-pass\n")
+	}
+	if comp := vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_COMP_LZO]; comp != "" {
+		fmt.Fprintf(&buf, "comp-lzo %s\n", comp)
+	}
+	if comp := vpnData["compress"]; comp == "yes" {
+		buf.WriteString("compress\n")
+	} else if comp != "" {
+		fmt.Fprintf(&buf, "compress %s\n", comp)
+	}
+
+	directives := make([]string, 0, len(openvpnSimpleDirectives))
+	for directive, key := range openvpnSimpleDirectives {
+		if key != "" && vpnData[key] != "" {
+			directives = append(directives, directive)
+		}
+	}
+	sort.Strings(directives)
+	for _, directive := range directives {
+		if openvpnFlagDirectives[directive] {
+			fmt.Fprintf(&buf, "%s\n", directive)
+		} else {
+			fmt.Fprintf(&buf, "%s %s\n", directive, vpnData[openvpnSimpleDirectives[directive]])
+		}
+	}
+	if dir := vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_TA_DIR]; dir != "" && vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_TA] != "" {
+		fmt.Fprintf(&buf, "key-direction %s\n", dir)
+	}
+	if dir := vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_STATIC_KEY_DIRECTION]; dir != "" &&
+		vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_STATIC_KEY] != "" {
+		fmt.Fprintf(&buf, "key-direction %s\n", dir)
+	}
+
+	for _, block := range []string{"ca", "cert", "key", "tls-auth", "tls-crypt", "secret"} {
+		file := vpnData[openvpnInlineBlocks[block]]
+		if file == "" {
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, "<%s>\n%s", block, content)
+		if len(content) != 0 && content[len(content)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, "</%s>\n", block)
+	}
+	return buf.Bytes(), nil
+}
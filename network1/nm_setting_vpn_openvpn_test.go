@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestParseOvpnConfig(c *C.C) {
+	content, err := os.ReadFile("testdata/client.ovpn")
+	c.Assert(err, C.IsNil)
+	certDir := c.MkDir()
+
+	vpnData, err := parseOvpnConfig(content, "testdata", certDir, "test")
+	c.Assert(err, C.IsNil)
+	c.Check(vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_REMOTE], C.Equals, "vpn.example.com:1194, vpn2.example.com:443:tcp")
+	c.Check(vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_PROTO_TCP], C.Equals, "yes")
+	c.Check(vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_CONNECTION_TYPE], C.Equals, nm.NM_OPENVPN_CONTYPE_PASSWORD_TLS)
+	c.Check(vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_CA], C.Equals, "testdata/ca.crt")
+	c.Check(vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_CIPHER], C.Equals, "AES-256-GCM")
+	c.Check(vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_REMOTE_CERT_TLS], C.Equals, nm.NM_OPENVPN_REM_CERT_TLS_SERVER)
+	c.Check(vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_TA_DIR], C.Equals, "1")
+	c.Check(vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_PASSWORD_FLAGS], C.Equals, "1")
+	// 内联的 tls-auth 密钥保存到证书目录
+	c.Check(vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_TA], C.Equals, filepath.Join(certDir, "test-tls-auth.key"))
+	fi, err := os.Stat(vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_TA])
+	c.Assert(err, C.IsNil)
+	c.Check(fi.Mode().Perm(), C.Equals, os.FileMode(0600))
+
+	// 导出后再导入，配置不变
+	content, err = formatOvpnConfig(vpnData)
+	c.Assert(err, C.IsNil)
+	vpnData1, err := parseOvpnConfig(content, "testdata", c.MkDir(), "test")
+	c.Assert(err, C.IsNil)
+	c.Check(vpnData1[nm.NM_SETTING_VPN_OPENVPN_KEY_REMOTE], C.Equals, vpnData[nm.NM_SETTING_VPN_OPENVPN_KEY_REMOTE])
+	c.Check(vpnData1[nm.NM_SETTING_VPN_OPENVPN_KEY_CONNECTION_TYPE], C.Equals, nm.NM_OPENVPN_CONTYPE_PASSWORD_TLS)
+	c.Check(vpnData1[nm.NM_SETTING_VPN_OPENVPN_KEY_TA_DIR], C.Equals, "1")
+
+	_, err = parseOvpnConfig([]byte("client\nca ca.crt\n"), "testdata", certDir, "test")
+	c.Check(err, C.NotNil)
+	_, err = parseOvpnConfig([]byte("remote vpn.example.com\nca nonexistent.crt\nauth-user-pass\n"), "testdata", certDir, "test")
+	c.Check(err, C.NotNil)
+	_, err = parseOvpnConfig([]byte("remote vpn.example.com\n<ca>\n"), "testdata", certDir, "test")
+	c.Check(err, C.NotNil)
+}
+
+func (*testWrapper) TestSplitOpenvpnLine(c *C.C) {
+	c.Check(splitOpenvpnLine("remote vpn.example.com 1194"), C.DeepEquals, []string{"remote", "vpn.example.com", "1194"})
+	c.Check(splitOpenvpnLine(`ca "my ca.crt" # comment`), C.DeepEquals, []string{"ca", "my ca.crt"})
+}
@@ -0,0 +1,442 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+)
+
+const wireguardKeyLen = 32
+
+type wireguardPeer struct {
+	PublicKey           string
+	PresharedKey        string
+	Endpoint            string
+	AllowedIPs          []string
+	PersistentKeepalive uint32
+}
+
+type wireguardConfig struct {
+	PrivateKey string
+	ListenPort uint32
+	FwMark     uint32
+	MTU        uint32
+	Addresses  []string // CIDR 格式，如 10.0.0.2/24
+	DNS        []string
+	DNSSearch  []string
+	Peers      []wireguardPeer
+}
+
+func checkWireguardKey(key string) error {
+	data, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(data) != wireguardKeyLen {
+		return fmt.Errorf("invalid wireguard key %q", key)
+	}
+	return nil
+}
+
+// generateWireguardKeyPair 生成 base64 编码的 curve25519 密钥对
+func generateWireguardKeyPair() (privateKey, publicKey string, err error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return
+	}
+	privateKey = base64.StdEncoding.EncodeToString(key.Bytes())
+	publicKey = base64.StdEncoding.EncodeToString(key.PublicKey().Bytes())
+	return
+}
+
+func (cfg *wireguardConfig) check() error {
+	if err := checkWireguardKey(cfg.PrivateKey); err != nil {
+		return err
+	}
+	for _, addr := range cfg.Addresses {
+		if _, _, err := parseWireguardAddress(addr); err != nil {
+			return err
+		}
+	}
+	for _, dns := range cfg.DNS {
+		if net.ParseIP(dns) == nil {
+			return fmt.Errorf("invalid dns %q", dns)
+		}
+	}
+	if len(cfg.Peers) == 0 {
+		return fmt.Errorf("no wireguard peer")
+	}
+	for _, peer := range cfg.Peers {
+		if err := checkWireguardKey(peer.PublicKey); err != nil {
+			return err
+		}
+		if peer.PresharedKey != "" {
+			if err := checkWireguardKey(peer.PresharedKey); err != nil {
+				return err
+			}
+		}
+		for _, allowedIP := range peer.AllowedIPs {
+			if _, _, err := net.ParseCIDR(allowedIP); err != nil {
+				return fmt.Errorf("invalid allowed ip %q", allowedIP)
+			}
+		}
+	}
+	return nil
+}
+
+// parseWireguardAddress 解析 wg-quick 格式的地址，未指定前缀时使用主机地址前缀
+func parseWireguardAddress(addr string) (ip net.IP, prefix uint32, err error) {
+	if strings.Contains(addr, "/") {
+		var ipNet *net.IPNet
+		ip, ipNet, err = net.ParseCIDR(addr)
+		if err != nil {
+			return
+		}
+		ones, _ := ipNet.Mask.Size()
+		prefix = uint32(ones)
+		return
+	}
+	ip = net.ParseIP(addr)
+	if ip == nil {
+		err = fmt.Errorf("invalid address %q", addr)
+		return
+	}
+	prefix = 128
+	if ip.To4() != nil {
+		prefix = 32
+	}
+	return
+}
+
+func splitWireguardList(value string) (list []string) {
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			list = append(list, v)
+		}
+	}
+	return
+}
+
+// parseWgQuickConfig 解析 wg-quick 配置文件
+func parseWgQuickConfig(content []byte) (cfg *wireguardConfig, err error) {
+	cfg = &wireguardConfig{}
+	var section string
+	var peer *wireguardPeer
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			switch section {
+			case "interface":
+			case "peer":
+				cfg.Peers = append(cfg.Peers, wireguardPeer{})
+				peer = &cfg.Peers[len(cfg.Peers)-1]
+			default:
+				return nil, fmt.Errorf("line %d: unknown section %q", lineNum, section)
+			}
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("line %d: invalid line %q", lineNum, line)
+		}
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		value := strings.TrimSpace(kv[1])
+
+		switch section {
+		case "interface":
+			err = cfg.setInterfaceKey(key, value)
+		case "peer":
+			err = peer.setKey(key, value)
+		default:
+			err = fmt.Errorf("key %q outside of section", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	err = cfg.check()
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func parseWireguardUint32(key, value string) (uint32, error) {
+	v, err := strconv.ParseUint(value, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return uint32(v), nil
+}
+
+func (cfg *wireguardConfig) setInterfaceKey(key, value string) (err error) {
+	switch key {
+	case "privatekey":
+		cfg.PrivateKey = value
+	case "listenport":
+		cfg.ListenPort, err = parseWireguardUint32(key, value)
+	case "fwmark":
+		if value != "off" {
+			cfg.FwMark, err = parseWireguardUint32(key, value)
+		}
+	case "mtu":
+		cfg.MTU, err = parseWireguardUint32(key, value)
+	case "address":
+		cfg.Addresses = append(cfg.Addresses, splitWireguardList(value)...)
+	case "dns":
+		// wg-quick 的 DNS 中非 IP 的项为搜索域
+		for _, v := range splitWireguardList(value) {
+			if net.ParseIP(v) != nil {
+				cfg.DNS = append(cfg.DNS, v)
+			} else {
+				cfg.DNSSearch = append(cfg.DNSSearch, v)
+			}
+		}
+	default:
+		// 忽略 Table, PreUp, PostUp 等 wg-quick 专有配置
+		logger.Debugf("ignore wireguard interface key %q", key)
+	}
+	return
+}
+
+func (peer *wireguardPeer) setKey(key, value string) (err error) {
+	switch key {
+	case "publickey":
+		peer.PublicKey = value
+	case "presharedkey":
+		peer.PresharedKey = value
+	case "endpoint":
+		peer.Endpoint = value
+	case "allowedips":
+		peer.AllowedIPs = append(peer.AllowedIPs, splitWireguardList(value)...)
+	case "persistentkeepalive":
+		if value != "off" {
+			peer.PersistentKeepalive, err = parseWireguardUint32(key, value)
+		}
+	default:
+		logger.Debugf("ignore wireguard peer key %q", key)
+	}
+	return
+}
+
+func newWireguardConnectionData(id, uuid, ifc string, cfg *wireguardConfig) (data connectionData) {
+	data = make(connectionData)
+
+	addSetting(data, nm.NM_SETTING_CONNECTION_SETTING_NAME)
+	setSettingConnectionId(data, id)
+	setSettingConnectionUuid(data, uuid)
+	setSettingConnectionType(data, nm.NM_SETTING_WIREGUARD_SETTING_NAME)
+	setSettingConnectionInterfaceName(data, ifc)
+	setSettingConnectionAutoconnect(data, false)
+
+	addSetting(data, nm.NM_SETTING_WIREGUARD_SETTING_NAME)
+	setSettingKey(data, nm.NM_SETTING_WIREGUARD_SETTING_NAME, nm.NM_SETTING_WIREGUARD_PRIVATE_KEY, cfg.PrivateKey)
+	setSettingKey(data, nm.NM_SETTING_WIREGUARD_SETTING_NAME, nm.NM_SETTING_WIREGUARD_PRIVATE_KEY_FLAGS,
+		uint32(nm.NM_SETTING_SECRET_FLAG_NONE))
+	if cfg.ListenPort != 0 {
+		setSettingKey(data, nm.NM_SETTING_WIREGUARD_SETTING_NAME, nm.NM_SETTING_WIREGUARD_LISTEN_PORT, cfg.ListenPort)
+	}
+	if cfg.FwMark != 0 {
+		setSettingKey(data, nm.NM_SETTING_WIREGUARD_SETTING_NAME, nm.NM_SETTING_WIREGUARD_FWMARK, cfg.FwMark)
+	}
+	if cfg.MTU != 0 {
+		setSettingKey(data, nm.NM_SETTING_WIREGUARD_SETTING_NAME, nm.NM_SETTING_WIREGUARD_MTU, cfg.MTU)
+	}
+	peers := make([]map[string]dbus.Variant, 0, len(cfg.Peers))
+	for _, peer := range cfg.Peers {
+		peerData := map[string]dbus.Variant{
+			nm.NM_WIREGUARD_PEER_ATTR_PUBLIC_KEY: dbus.MakeVariant(peer.PublicKey),
+		}
+		if peer.PresharedKey != "" {
+			peerData[nm.NM_WIREGUARD_PEER_ATTR_PRESHARED_KEY] = dbus.MakeVariant(peer.PresharedKey)
+			peerData[nm.NM_WIREGUARD_PEER_ATTR_PRESHARED_KEY_FLAGS] = dbus.MakeVariant(uint32(nm.NM_SETTING_SECRET_FLAG_NONE))
+		}
+		if peer.Endpoint != "" {
+			peerData[nm.NM_WIREGUARD_PEER_ATTR_ENDPOINT] = dbus.MakeVariant(peer.Endpoint)
+		}
+		if len(peer.AllowedIPs) != 0 {
+			peerData[nm.NM_WIREGUARD_PEER_ATTR_ALLOWED_IPS] = dbus.MakeVariant(peer.AllowedIPs)
+		}
+		if peer.PersistentKeepalive != 0 {
+			peerData[nm.NM_WIREGUARD_PEER_ATTR_PERSISTENT_KEEPALIVE] = dbus.MakeVariant(peer.PersistentKeepalive)
+		}
+		peers = append(peers, peerData)
+	}
+	setSettingKey(data, nm.NM_SETTING_WIREGUARD_SETTING_NAME, nm.NM_SETTING_WIREGUARD_PEERS, peers)
+
+	setWireguardIPConfig(data, cfg)
+	return
+}
+
+func setWireguardIPConfig(data connectionData, cfg *wireguardConfig) {
+	var ip4Addrs, ip6Addrs []map[string]dbus.Variant
+	for _, addr := range cfg.Addresses {
+		ip, prefix, err := parseWireguardAddress(addr)
+		if err != nil {
+			logger.Warning(err)
+			continue
+		}
+		addrData := map[string]dbus.Variant{
+			"address": dbus.MakeVariant(ip.String()),
+			"prefix":  dbus.MakeVariant(prefix),
+		}
+		if ip.To4() != nil {
+			ip4Addrs = append(ip4Addrs, addrData)
+		} else {
+			ip6Addrs = append(ip6Addrs, addrData)
+		}
+	}
+	var ip4Dns []uint32
+	var ip6Dns [][]byte
+	for _, dns := range cfg.DNS {
+		ip := net.ParseIP(dns)
+		if ip == nil {
+			continue
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			ip4Dns = append(ip4Dns, htonl(ipToUint32(ip4.String())))
+		} else {
+			ip6Dns = append(ip6Dns, []byte(ip.To16()))
+		}
+	}
+
+	addSetting(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME)
+	if len(ip4Addrs) != 0 {
+		setSettingIP4ConfigMethod(data, nm.NM_SETTING_IP4_CONFIG_METHOD_MANUAL)
+		setSettingKey(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME, "address-data", ip4Addrs)
+	} else {
+		setSettingIP4ConfigMethod(data, nm.NM_SETTING_IP4_CONFIG_METHOD_DISABLED)
+	}
+	if len(ip4Dns) != 0 {
+		setSettingKey(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME, "dns", ip4Dns)
+	}
+	if len(cfg.DNSSearch) != 0 {
+		setSettingKey(data, nm.NM_SETTING_IP4_CONFIG_SETTING_NAME, "dns-search", cfg.DNSSearch)
+	}
+
+	addSetting(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME)
+	if len(ip6Addrs) != 0 {
+		setSettingIP6ConfigMethod(data, nm.NM_SETTING_IP6_CONFIG_METHOD_MANUAL)
+		setSettingKey(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME, "address-data", ip6Addrs)
+	} else {
+		setSettingIP6ConfigMethod(data, nm.NM_SETTING_IP6_CONFIG_METHOD_IGNORE)
+	}
+	if len(ip6Dns) != 0 {
+		setSettingKey(data, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME, "dns", ip6Dns)
+	}
+}
+
+// newWireguardConfigFromData 是 newWireguardConnectionData 的逆过程，用于导出 wg-quick 配置
+func newWireguardConfigFromData(data connectionData) (cfg *wireguardConfig) {
+	cfg = &wireguardConfig{}
+	setting := nm.NM_SETTING_WIREGUARD_SETTING_NAME
+	cfg.PrivateKey, _ = getSettingKey(data, setting, nm.NM_SETTING_WIREGUARD_PRIVATE_KEY).(string)
+	cfg.ListenPort, _ = getSettingKey(data, setting, nm.NM_SETTING_WIREGUARD_LISTEN_PORT).(uint32)
+	cfg.FwMark, _ = getSettingKey(data, setting, nm.NM_SETTING_WIREGUARD_FWMARK).(uint32)
+	cfg.MTU, _ = getSettingKey(data, setting, nm.NM_SETTING_WIREGUARD_MTU).(uint32)
+
+	peers, _ := getSettingKey(data, setting, nm.NM_SETTING_WIREGUARD_PEERS).([]map[string]dbus.Variant)
+	for _, peerData := range peers {
+		var peer wireguardPeer
+		peer.PublicKey, _ = peerData[nm.NM_WIREGUARD_PEER_ATTR_PUBLIC_KEY].Value().(string)
+		peer.PresharedKey, _ = peerData[nm.NM_WIREGUARD_PEER_ATTR_PRESHARED_KEY].Value().(string)
+		peer.Endpoint, _ = peerData[nm.NM_WIREGUARD_PEER_ATTR_ENDPOINT].Value().(string)
+		peer.AllowedIPs, _ = peerData[nm.NM_WIREGUARD_PEER_ATTR_ALLOWED_IPS].Value().([]string)
+		peer.PersistentKeepalive, _ = peerData[nm.NM_WIREGUARD_PEER_ATTR_PERSISTENT_KEEPALIVE].Value().(uint32)
+		cfg.Peers = append(cfg.Peers, peer)
+	}
+
+	for _, ipSetting := range []string{nm.NM_SETTING_IP4_CONFIG_SETTING_NAME, nm.NM_SETTING_IP6_CONFIG_SETTING_NAME} {
+		addrs, _ := getSettingKey(data, ipSetting, "address-data").([]map[string]dbus.Variant)
+		for _, addr := range addrs {
+			ip, _ := addr["address"].Value().(string)
+			prefix, _ := addr["prefix"].Value().(uint32)
+			cfg.Addresses = append(cfg.Addresses, fmt.Sprintf("%s/%d", ip, prefix))
+		}
+		switch dns := getSettingKey(data, ipSetting, "dns").(type) {
+		case []uint32:
+			for _, v := range dns {
+				cfg.DNS = append(cfg.DNS, uint32ToIP(ntohl(v)))
+			}
+		case [][]byte:
+			for _, v := range dns {
+				cfg.DNS = append(cfg.DNS, net.IP(v).String())
+			}
+		}
+		search, _ := getSettingKey(data, ipSetting, "dns-search").([]string)
+		for _, v := range search {
+			if !isStringInArray(v, cfg.DNSSearch) {
+				cfg.DNSSearch = append(cfg.DNSSearch, v)
+			}
+		}
+	}
+	return
+}
+
+// formatWgQuickConfig 生成 wg-quick 配置文件
+func formatWgQuickConfig(cfg *wireguardConfig) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("[Interface]\n")
+	fmt.Fprintf(&buf, "PrivateKey = %s\n", cfg.PrivateKey)
+	if len(cfg.Addresses) != 0 {
+		fmt.Fprintf(&buf, "Address = %s\n", strings.Join(cfg.Addresses, ", "))
+	}
+	if len(cfg.DNS) != 0 || len(cfg.DNSSearch) != 0 {
+		dns := append(append([]string{}, cfg.DNS...), cfg.DNSSearch...)
+		fmt.Fprintf(&buf, "DNS = %s\n", strings.Join(dns, ", "))
+	}
+	if cfg.ListenPort != 0 {
+		fmt.Fprintf(&buf, "ListenPort = %d\n", cfg.ListenPort)
+	}
+	if cfg.FwMark != 0 {
+		fmt.Fprintf(&buf, "FwMark = %d\n", cfg.FwMark)
+	}
+	if cfg.MTU != 0 {
+		fmt.Fprintf(&buf, "MTU = %d\n", cfg.MTU)
+	}
+	for _, peer := range cfg.Peers {
+		buf.WriteString("\n[Peer]\n")
+		fmt.Fprintf(&buf, "PublicKey = %s\n", peer.PublicKey)
+		if peer.PresharedKey != "" {
+			fmt.Fprintf(&buf, "PresharedKey = %s\n", peer.PresharedKey)
+		}
+		if peer.Endpoint != "" {
+			fmt.Fprintf(&buf, "Endpoint = %s\n", peer.Endpoint)
+		}
+		if len(peer.AllowedIPs) != 0 {
+			fmt.Fprintf(&buf, "AllowedIPs = %s\n", strings.Join(peer.AllowedIPs, ", "))
+		}
+		if peer.PersistentKeepalive != 0 {
+			fmt.Fprintf(&buf, "PersistentKeepalive = %d\n", peer.PersistentKeepalive)
+		}
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network
+
+import (
+	"os"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	C "gopkg.in/check.v1"
+)
+
+func (*testWrapper) TestParseWgQuickConfig(c *C.C) {
+	content, err := os.ReadFile("testdata/wg0.conf")
+	c.Assert(err, C.IsNil)
+
+	cfg, err := parseWgQuickConfig(content)
+	c.Assert(err, C.IsNil)
+	c.Check(cfg.PrivateKey, C.Equals, "b82HDbw3Wn24QlbwoXG3Tbs8jD765Zg9WpgRRjA31f8=")
+	c.Check(cfg.ListenPort, C.Equals, uint32(51820))
+	c.Check(cfg.Addresses, C.DeepEquals, []string{"10.0.0.2/24", "fd00::2/64"})
+	c.Check(cfg.DNS, C.DeepEquals, []string{"1.1.1.1"})
+	c.Check(cfg.DNSSearch, C.DeepEquals, []string{"example.com"})
+	c.Assert(cfg.Peers, C.HasLen, 1)
+	peer := cfg.Peers[0]
+	c.Check(peer.PublicKey, C.Equals, "/yGCu0nIszQn8ElfWSRd609ty+PDme4eRFWRRG8uYUc=")
+	c.Check(peer.Endpoint, C.Equals, "vpn.example.com:51820")
+	c.Check(peer.AllowedIPs, C.DeepEquals, []string{"0.0.0.0/0", "::/0"})
+	c.Check(peer.PersistentKeepalive, C.Equals, uint32(25))
+
+	_, err = parseWgQuickConfig([]byte("[Interface]\nPrivateKey = invalid\n"))
+	c.Check(err, C.NotNil)
+	_, err = parseWgQuickConfig([]byte("PrivateKey = b82HDbw3Wn24QlbwoXG3Tbs8jD765Zg9WpgRRjA31f8=\n"))
+	c.Check(err, C.NotNil)
+}
+
+func (*testWrapper) TestNewWireguardConnectionData(c *C.C) {
+	content, err := os.ReadFile("testdata/wg0.conf")
+	c.Assert(err, C.IsNil)
+	cfg, err := parseWgQuickConfig(content)
+	c.Assert(err, C.IsNil)
+
+	data := newWireguardConnectionData("wg0", "uuid", "wg0", cfg)
+	c.Check(getCustomConnectionType(data), C.Equals, connectionWireguard)
+	c.Check(getSettingConnectionInterfaceName(data), C.Equals, "wg0")
+	c.Check(getSettingIP4ConfigMethod(data), C.Equals, nm.NM_SETTING_IP4_CONFIG_METHOD_MANUAL)
+	c.Check(getSettingIP6ConfigMethod(data), C.Equals, nm.NM_SETTING_IP6_CONFIG_METHOD_MANUAL)
+
+	peers, ok := data[nm.NM_SETTING_WIREGUARD_SETTING_NAME][nm.NM_SETTING_WIREGUARD_PEERS].Value().([]map[string]dbus.Variant)
+	c.Assert(ok, C.Equals, true)
+	c.Assert(peers, C.HasLen, 1)
+	c.Check(peers[0][nm.NM_WIREGUARD_PEER_ATTR_PUBLIC_KEY].Value(), C.Equals, cfg.Peers[0].PublicKey)
+}
+
+func (*testWrapper) TestGenerateWireguardKeyPair(c *C.C) {
+	privateKey, publicKey, err := generateWireguardKeyPair()
+	c.Assert(err, C.IsNil)
+	c.Check(checkWireguardKey(privateKey), C.IsNil)
+	c.Check(checkWireguardKey(publicKey), C.IsNil)
+	c.Check(privateKey, C.Not(C.Equals), publicKey)
+}
+
+func (*testWrapper) TestGetWireguardInterfaceName(c *C.C) {
+	c.Check(getWireguardInterfaceName("wg0"), C.Equals, "wg0")
+	c.Check(getWireguardInterfaceName("my vpn"), C.Equals, "myvpn")
+	c.Check(getWireguardInterfaceName("a-very-long-interface-name"), C.Equals, "a-very-long-int")
+	c.Check(getWireguardInterfaceName("公司"), C.Equals, defaultWireguardInterface)
+}
+
+func (*testWrapper) TestFormatWgQuickConfig(c *C.C) {
+	content, err := os.ReadFile("testdata/wg0.conf")
+	c.Assert(err, C.IsNil)
+	cfg, err := parseWgQuickConfig(content)
+	c.Assert(err, C.IsNil)
+
+	// 导出后再导入，配置不变
+	data := newWireguardConnectionData("wg0", "uuid", "wg0", cfg)
+	cfg1, err := parseWgQuickConfig(formatWgQuickConfig(newWireguardConfigFromData(data)))
+	c.Assert(err, C.IsNil)
+	c.Check(cfg1, C.DeepEquals, cfg)
+}
@@ -25,6 +25,8 @@ func getSettingVkWirelessSecurityKeyMgmt(data connectionData) (value string) {
 		value = "wpa-psk"
 	case "sae":
 		value = "sae"
+	case "owe":
+		value = "owe"
 	case "wpa-eap":
 		value = "wpa-eap"
 	}
@@ -46,6 +48,8 @@ func getApSecTypeFromConnData(data connectionData) (apSecType, error) {
 		return apSecPsk, nil
 	case "sae":
 		return apSecSae, nil
+	case "owe":
+		return apSecOwe, nil
 	case "wpa-eap":
 		return apSecEap, nil
 	}
@@ -97,6 +101,13 @@ func logicSetSettingVkWirelessSecurityKeyMgmt(data connectionData, value string)
 		)
 		setSettingWirelessSecurityKeyMgmt(data, "sae")
 		setSettingWirelessSecurityPskFlags(data, nm.NM_SETTING_SECRET_FLAG_NONE)
+	case "owe":
+		addSetting(data, nm.NM_SETTING_WIRELESS_SECURITY_SETTING_NAME)
+		removeSetting(data, nm.NM_SETTING_802_1X_SETTING_NAME)
+		removeSettingKeyBut(data, nm.NM_SETTING_WIRELESS_SECURITY_SETTING_NAME,
+			nm.NM_SETTING_WIRELESS_SECURITY_KEY_MGMT,
+		)
+		setSettingWirelessSecurityKeyMgmt(data, "owe")
 	case "wpa-eap", "wpa-eap-suite-b-192":
 		addSetting(data, nm.NM_SETTING_WIRELESS_SECURITY_SETTING_NAME)
 		addSetting(data, nm.NM_SETTING_802_1X_SETTING_NAME)
@@ -67,6 +67,10 @@ type SecretAgent struct {
 	saveSecretsTasks   map[saveSecretsTaskKey]saveSecretsTask
 	saveSecretsTasksMu sync.Mutex
 
+	// update by manager_secret_request.go
+	secretRequests   map[saveSecretsTaskKey]*pendingSecretRequest
+	secretRequestsMu sync.Mutex
+
 	handleProcessMu sync.Mutex
 
 	// sleep for 2 seconds when trying to get keyring in the first boot
@@ -167,6 +171,7 @@ func newSecretAgent(secServiceObj secrets.Service, manager *Manager) (*SecretAge
 	sa.secretSessionPath = sessionPath
 	sa.secretService = secServiceObj
 	sa.saveSecretsTasks = make(map[saveSecretsTaskKey]saveSecretsTask)
+	sa.secretRequests = make(map[saveSecretsTaskKey]*pendingSecretRequest)
 	sa.m = manager
 	sa.needSleep = true
 	logger.Debug("session path:", sessionPath)
@@ -509,6 +514,26 @@ func (sa *SecretAgent) askPasswords(connPath dbus.ObjectPath,
 	// if isSecretDialogExist() {
 	// 	return nil, err
 	// }
+	// 有客户端注册了 SecretRequest 时由客户端输入密码，客户端已退出时使用密码对话框
+	if agent := sa.m.getSecretRequestAgent(); agent != nil {
+		replySecrets, err := sa.requestSecrets(agent, connPath, settingName, reqJSON)
+		if !isSecretRequestAgentGone(err) {
+			if err != nil {
+				return nil, err
+			}
+			return buildAskPasswordsResult(settingKeys, replySecrets)
+		}
+		logger.Warning("secret request is unavailable:", err)
+		sa.m.unregisterSecretRequest(agent.owner)
+	}
+	replySecrets, err := sa.runSecretDialog(connPath, settingName, reqJSON)
+	if err != nil {
+		return nil, err
+	}
+	return buildAskPasswordsResult(settingKeys, replySecrets)
+}
+
+func (sa *SecretAgent) runSecretDialog(connPath dbus.ObjectPath, settingName string, reqJSON []byte) ([]string, error) {
 	// TODO 还是有可能存在第二个getSecrets在第一个之前触发，概率较低;先用该方式规避两次getSecrets间隔很小导致无法正常取消第一个getSecrets。后续需要优化网络库两次激活和后端secret_agent逻辑。
 	sa.handleProcessMu.Lock()
 	process := sa.getSaveSecretsTaskProcess(connPath, settingName)
@@ -525,7 +550,7 @@ func (sa *SecretAgent) askPasswords(connPath dbus.ObjectPath,
 	cmd.Stdin = bytes.NewReader(reqJSON)
 	var cmdOutBuf bytes.Buffer
 	cmd.Stdout = &cmdOutBuf
-	err = cmd.Start()
+	err := cmd.Start()
 	if err != nil {
 		sa.handleProcessMu.Unlock()
 		return nil, err
@@ -542,15 +567,18 @@ func (sa *SecretAgent) askPasswords(connPath dbus.ObjectPath,
 	if err != nil {
 		return nil, err
 	}
+	return reply.Secrets, nil
+}
 
+func buildAskPasswordsResult(settingKeys, secrets []string) (map[string]string, error) {
 	result := make(map[string]string)
 
-	if len(settingKeys) != len(reply.Secrets) {
+	if len(settingKeys) != len(secrets) {
 		return nil, errors.New("secretAgent.askPasswords: length not equal")
 	}
 
 	for i := 0; i < len(settingKeys); i++ {
-		result[settingKeys[i]] = reply.Secrets[i]
+		result[settingKeys[i]] = secrets[i]
 	}
 	return result, nil
 }
@@ -865,6 +893,10 @@ func (sa *SecretAgent) CancelGetSecrets(connectionPath dbus.ObjectPath, settingN
 	logger.Debug("connection path:", connectionPath)
 	logger.Debug("setting name:", settingName)
 
+	sa.cancelSecretRequest(saveSecretsTaskKey{
+		connPath:    connectionPath,
+		settingName: settingName,
+	})
 	process := sa.getSaveSecretsTaskProcess(connectionPath, settingName)
 	if process != nil {
 		logger.Debug("kill process", process.Pid)
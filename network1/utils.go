@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
@@ -56,6 +57,25 @@ func marshalJSON(v interface{}) (jsonStr string, err error) {
 	return
 }
 
+// dconfig 中的整数值可能是 float64 或 int64 类型，负数和超出范围的值视为无效
+func variantToUint32(v dbus.Variant) (uint32, bool) {
+	var value float64
+	switch vv := v.Value().(type) {
+	case float64:
+		value = vv
+	case int64:
+		value = float64(vv)
+	default:
+		logger.Warning("type is wrong!")
+		return 0, false
+	}
+	if value < 0 || value > math.MaxUint32 {
+		logger.Warning("value is out of range:", value)
+		return 0, false
+	}
+	return uint32(value), true
+}
+
 // convert local path to uri, etc "/the/path" -> "file:///the/path"
 func toUriPath(path string) (uriPath string) {
 	return utils.EncodeURI(path, utils.SCHEME_FILE)
@@ -424,6 +424,32 @@ func nmNewIP6Config(path dbus.ObjectPath) (ip6config nmdbus.IP6Config, err error
 	}
 	return
 }
+func nmNewDhcp4Config(path dbus.ObjectPath) (dhcp4config nmdbus.Dhcp4Config, err error) {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return
+	}
+
+	dhcp4config, err = nmdbus.NewDhcp4Config(systemBus, path)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	return
+}
+func nmNewDhcp6Config(path dbus.ObjectPath) (dhcp6config nmdbus.Dhcp6Config, err error) {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return
+	}
+
+	dhcp6config, err = nmdbus.NewDhcp6Config(systemBus, path)
+	if err != nil {
+		logger.Error(err)
+		return
+	}
+	return
+}
 func nmNewSettingsConnection(cpath dbus.ObjectPath) (conn nmdbus.ConnectionSettings, err error) {
 	systemBus, err := dbus.SystemBus()
 	if err != nil {
@@ -453,6 +479,14 @@ func nmDestroyAccessPoint(ap nmdbus.AccessPoint) {
 	ap.RemoveHandler(proxy.RemoveAllHandlers)
 }
 
+func nmDestroyActiveConnection(aconn nmdbus.ActiveConnection) {
+	if aconn == nil {
+		logger.Error("ActiveConnection to destroy is nil")
+		return
+	}
+	aconn.RemoveHandler(proxy.RemoveAllHandlers)
+}
+
 func nmDestroySettingsConnection(conn nmdbus.ConnectionSettings) {
 	if conn == nil {
 		logger.Error("SettingsConnection to destroy is nil")
@@ -530,6 +564,7 @@ func nmAddAndActivateConnection(data connectionData, devPath dbus.ObjectPath, fo
 		logger.Error(err, "devPath:", devPath)
 		return
 	}
+	markUserActivation(cpath)
 	return
 }
 
@@ -540,6 +575,7 @@ func nmActivateConnection(cpath, devPath dbus.ObjectPath) (apath dbus.ObjectPath
 		return
 	}
 	spath := dbus.ObjectPath("/")
+	markUserActivation(cpath)
 	apath, err = nmManager.ActivateConnection(0, cpath, devPath, spath)
 	if err != nil {
 		if data, err := nmGetConnectionData(cpath); err == nil {
@@ -625,6 +661,30 @@ func nmGetActiveConnectionByUuid(uuid string) (apaths []dbus.ObjectPath, err err
 	return
 }
 
+// nmReapplyActiveConnection 重新应用连接对应的设备，使修改后的连接设置立即生效，连接未激活时不做处理
+func nmReapplyActiveConnection(uuid string) {
+	for _, apath := range nmGetActiveConnections() {
+		aconn, err := nmNewActiveConnection(apath)
+		if err != nil {
+			continue
+		}
+		if activeUuid, _ := aconn.Uuid().Get(0); activeUuid != uuid {
+			continue
+		}
+		devPaths, _ := aconn.Devices().Get(0)
+		for _, devPath := range devPaths {
+			nmDev, err := nmNewDevice(devPath)
+			if err != nil {
+				continue
+			}
+			err = nmDev.Device().Reapply(0, nil, 0, 0)
+			if err != nil {
+				logger.Warning("failed to reapply device:", err)
+			}
+		}
+	}
+}
+
 func nmGetActiveConnectionState(apath dbus.ObjectPath) (state uint32) {
 	aconn, err := nmNewActiveConnection(apath)
 	if err != nil {
@@ -635,6 +695,26 @@ func nmGetActiveConnectionState(apath dbus.ObjectPath) (state uint32) {
 	return
 }
 
+func nmGetActiveConnectionUuid(apath dbus.ObjectPath) (uuid string) {
+	aconn, err := nmNewActiveConnection(apath)
+	if err != nil {
+		return
+	}
+
+	uuid, _ = aconn.Uuid().Get(0)
+	return
+}
+
+func nmGetActiveConnectionId(apath dbus.ObjectPath) (id string) {
+	aconn, err := nmNewActiveConnection(apath)
+	if err != nil {
+		return
+	}
+
+	id, _ = aconn.Id().Get(0)
+	return
+}
+
 func nmGetConnectionData(cpath dbus.ObjectPath) (data connectionData, err error) {
 	nmConn, err := nmNewSettingsConnection(cpath)
 	if err != nil {
@@ -649,6 +729,38 @@ func nmGetConnectionData(cpath dbus.ObjectPath) (data connectionData, err error)
 	return
 }
 
+// fixConnectionIP6Data 修正 GetSettings 返回的 ipv6 addresses 和 routes 的数据结构，
+// 它们是 interface{}，不修正无法写回 NetworkManager
+func fixConnectionIP6Data(data connectionData) {
+	if isSettingIP6ConfigAddressesExists(data) {
+		setSettingIP6ConfigAddresses(data, getSettingIP6ConfigAddresses(data))
+	}
+	if isSettingIP6ConfigRoutesExists(data) {
+		setSettingIP6ConfigRoutes(data, getSettingIP6ConfigRoutes(data))
+	}
+}
+
+// updateConnectionData 保存修改后的连接配置
+func updateConnectionData(cpath dbus.ObjectPath, data connectionData) error {
+	nmConn, err := nmNewSettingsConnection(cpath)
+	if err != nil {
+		return err
+	}
+	fixConnectionIP6Data(data)
+	return nmConn.Update(0, data)
+}
+
+// nmGetConnectionSecrets 获取连接中 setting 的密码，仅包含系统保存的密码
+func nmGetConnectionSecrets(cpath dbus.ObjectPath, setting string) (secrets connectionData, err error) {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return
+	}
+	obj := systemBus.Object(nm.NM_DBUS_SERVICE, cpath)
+	err = obj.Call(nm.NM_DBUS_INTERFACE_SETTINGS_CONNECTION+".GetSecrets", 0, setting).Store(&secrets)
+	return
+}
+
 func nmGetConnectionId(cpath dbus.ObjectPath) (id string) {
 	data, err := nmGetConnectionData(cpath)
 	if err != nil {
@@ -6,6 +6,7 @@ package network
 
 import (
 	"container/list"
+	"fmt"
 	"sync"
 	"time"
 
@@ -199,6 +200,20 @@ func notifyVpnFailed(id string, reason uint32) {
 	notify(notifyIconVpnDisconnected, Tr("Disconnected"), vpnErrorTable[reason])
 }
 
+func notifyDataLimitReached(id, action string) {
+	switch action {
+	case dataLimitActionMetered:
+		notify(notifyIconNetworkConnected, Tr("Network"),
+			fmt.Sprintf(Tr("%q has reached its data limit and is set as metered"), id))
+	case dataLimitActionDisconnect:
+		notify(notifyIconNetworkDisconnected, Tr("Disconnected"),
+			fmt.Sprintf(Tr("%q has reached its data limit"), id))
+	default:
+		notify(notifyIconNetworkConnected, Tr("Network"),
+			fmt.Sprintf(Tr("%q has reached its data limit"), id))
+	}
+}
+
 func getMobileConnectedNotifyIcon(mobileNetworkType string) (icon string) {
 	switch mobileNetworkType {
 	case moblieNetworkType4G:
@@ -7,6 +7,7 @@ package network
 import (
 	"testing"
 
+	dbus "github.com/godbus/dbus/v5"
 	"github.com/linuxdeepin/dde-daemon/network1/nm"
 	C "gopkg.in/check.v1"
 )
@@ -389,3 +390,22 @@ func (*testWrapper) TestFixupDeviceDesc(c *C.C) {
 		c.Check(fixupDeviceDesc(d.desc), C.Equals, d.fixedDesc)
 	}
 }
+
+func (*testWrapper) TestVariantToUint32(c *C.C) {
+	value, ok := variantToUint32(dbus.MakeVariant(float64(3)))
+	c.Check(ok, C.Equals, true)
+	c.Check(value, C.Equals, uint32(3))
+	value, ok = variantToUint32(dbus.MakeVariant(int64(5)))
+	c.Check(ok, C.Equals, true)
+	c.Check(value, C.Equals, uint32(5))
+
+	// 负数和超出范围的值无效
+	_, ok = variantToUint32(dbus.MakeVariant(float64(-1)))
+	c.Check(ok, C.Equals, false)
+	_, ok = variantToUint32(dbus.MakeVariant(int64(-1)))
+	c.Check(ok, C.Equals, false)
+	_, ok = variantToUint32(dbus.MakeVariant(int64(1) << 32))
+	c.Check(ok, C.Equals, false)
+	_, ok = variantToUint32(dbus.MakeVariant("1"))
+	c.Check(ok, C.Equals, false)
+}
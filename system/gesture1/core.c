@@ -25,6 +25,7 @@
 struct raw_multitouch_event {
     double dx_unaccel, dy_unaccel;
     double scale;
+    double angle;
     int fingers;
     uint64_t t_start_tap;
     guint tap_id;
@@ -70,6 +71,9 @@ static int long_press_duration2 = 1000;
 static double long_press_distance = LONG_PRESS_MAX_DISTANCE;
 static int short_press_duration = 200;
 static int dblclick_duration = 0; // 判断两次单击的间隔，是否为双击
+static double rotate_angle_threshold = 45.0; // 判断为旋转手势的最小角度，单位度
+static double swipe_distance_threshold = 70.0; // 判断为滑动手势的最小移动距离
+static double pinch_scale_threshold = 1.0; // 判断为捏合手势的最小缩放比例
 
 static uint64_t _prev_ev_time; // 前一个非 TOUCH_FRAME 事件的时间，单位 usec
 static int _prev_ev_type; // 前一个非 TOUCH_FRAME 事件的类型
@@ -151,6 +155,22 @@ set_timer_short_duration(int duration)
     short_press_duration = duration;
 }
 
+void
+set_rotate_angle_threshold(double angle)
+{
+    g_debug("[Rotate angle threshold] set: %f --> %f", rotate_angle_threshold, angle);
+    rotate_angle_threshold = angle;
+}
+
+void
+set_gesture_thresholds(double swipe_distance, double pinch_scale)
+{
+    g_debug("[Swipe distance threshold] set: %f --> %f", swipe_distance_threshold, swipe_distance);
+    g_debug("[Pinch scale threshold] set: %f --> %f", pinch_scale_threshold, pinch_scale);
+    swipe_distance_threshold = swipe_distance;
+    pinch_scale_threshold = pinch_scale;
+}
+
 void set_dblclick_duration(int duration) 
 {
     if (duration == dblclick_duration) {
@@ -169,6 +189,7 @@ raw_event_reset(struct raw_multitouch_event *event, bool reset_dblclick)
     event->dx_unaccel = 0.0;
     event->dy_unaccel = 0.0;
     event->scale = 0.0;
+    event->angle = 0.0;
     event->fingers = 0;
     event->t_start_tap = 0;
     event->tap_id = 0;
@@ -331,13 +352,17 @@ handle_tap_delay()
  * calculation direction
  * Swipe: (begin -> end)
  *     _dx_unaccel += dx_unaccel, _dy_unaccel += dy_unaccel;
- *     filter small movement threshold abs(_dx_unaccel - _dy_unaccel) < 70
+ *     filter small movement threshold abs(_dx_unaccel - _dy_unaccel) < swipe_distance_threshold
  *     if abs(_dx_unaccel) > abs(_dy_unaccel): _dx_unaccel < 0 ? 'left':'right'
  *     else: _dy_unaccel < 0 ? 'up':'down'
  *
  * Pinch: (begin -> end)
  *     _scale += 1.0 - scale;
  *     if _scale != 0: _scale >= 0 ? 'in':'out'
+ *
+ * Rotate: (pinch begin -> pinch end)
+ *     _angle += angle_delta;
+ *     if abs(_angle) >= rotate_angle_threshold: _angle >= 0 ? 'clockwise':'counterclockwise'
  **/
 static void
 handle_gesture_events(struct libinput_event *ev, int type)
@@ -375,6 +400,7 @@ handle_gesture_events(struct libinput_event *ev, int type)
     case LIBINPUT_EVENT_GESTURE_PINCH_UPDATE:{
         double scale = libinput_event_gesture_get_scale(gesture);
         raw->scale += 1.0-scale;
+        raw->angle += libinput_event_gesture_get_angle_delta(gesture);
         break;
     }
     case LIBINPUT_EVENT_GESTURE_SWIPE_UPDATE:{
@@ -387,13 +413,28 @@ handle_gesture_events(struct libinput_event *ev, int type)
         int fingers = libinput_event_gesture_get_finger_count(gesture);
         if (raw->dblclick) {
             handleSwipeMoving(fingers, dx_unaccel, dy_unaccel);
+        } else {
+            // continuous gestures, such as workspace switching, track every update
+            handleSwipeUpdate(fingers, dx_unaccel, dy_unaccel);
         }
 
         break;
     }
     case LIBINPUT_EVENT_GESTURE_PINCH_END:{
+        // rotation takes precedence over scale, fingers usually move apart a little when rotating
+        if (fabs(raw->angle) >= rotate_angle_threshold) {
+            raw->fingers = libinput_event_gesture_get_finger_count(gesture);
+            g_debug("[Rotate] direction: %s, angle: %f, fingers: %d",
+                    raw->angle >= 0?"clockwise":"counterclockwise", raw->angle, raw->fingers);
+            handleGestureEvent(GESTURE_TYPE_ROTATE,
+                               (raw->angle >= 0?GESTURE_DIRECTION_CLOCKWISE:GESTURE_DIRECTION_COUNTERCLOCKWISE),
+                               raw->fingers);
+            raw_event_reset(raw, true);
+            break;
+        }
+
         // filter small scale threshold
-        if (fabs(raw->scale) < 1) {
+        if (fabs(raw->scale) < pinch_scale_threshold) {
             raw_event_reset(raw, true);
             break;
         }
@@ -408,19 +449,23 @@ handle_gesture_events(struct libinput_event *ev, int type)
         break;
     }
     case LIBINPUT_EVENT_GESTURE_SWIPE_END:
+        raw->fingers = libinput_event_gesture_get_finger_count(gesture);
+        if (!raw->dblclick) {
+            // emitted before the discrete swipe event
+            handleSwipeEnd(raw->fingers, libinput_event_gesture_get_cancelled(gesture));
+        }
         if (libinput_event_gesture_get_cancelled(gesture)) {
             raw_event_reset(raw, true);
             break;
         }
 
-        raw->fingers = libinput_event_gesture_get_finger_count(gesture);
         if (raw->dblclick) {
             handleSwipeStop(raw->fingers);
             raw_event_reset(raw, true);
             break;
         }
         // filter small movement threshold
-        if (fabs(raw->dx_unaccel - raw->dy_unaccel) < 70) {
+        if (fabs(raw->dx_unaccel - raw->dy_unaccel) < swipe_distance_threshold) {
             raw_event_reset(raw, true);
             break;
         }
@@ -550,6 +595,8 @@ handle_touch_events(struct libinput_event *ev, int ty,struct movement *m)
         handleTouchUpOrCancel(scale.x, scale.y);
         return;
     case LIBINPUT_EVENT_TOUCH_DOWN: {
+        // 记录触摸的是哪个触摸屏，多个触摸屏时用于查找对应的显示器
+        handleTouchDevice((char *)node);
         handle_touch_event_down(ev, m);
         g_debug("Touch down, id: %u, fingers: %d, sent: %d ",
                 touch_timer.id, touch_timer.fingers, touch_timer.sent);
@@ -16,6 +16,7 @@
 #define GESTURE_TYPE_SWIPE 100
 #define GESTURE_TYPE_PINCH 101
 #define GESTURE_TYPE_TAP 102
+#define GESTURE_TYPE_ROTATE 103
 
 // tap
 #define GESTURE_DIRECTION_NONE 0
@@ -27,6 +28,9 @@
 // pinch
 #define GESTURE_DIRECTION_IN 14
 #define GESTURE_DIRECTION_OUT 15
+// rotate
+#define GESTURE_DIRECTION_CLOCKWISE 16
+#define GESTURE_DIRECTION_COUNTERCLOCKWISE 17
 
 #ifndef LIBINPUT_EVENT_GESTURE_TAP_BEGIN
 #define LIBINPUT_EVENT_GESTURE_TAP_BEGIN 806
@@ -45,6 +49,8 @@ void quit_loop(void);
 void set_timer_duration(int duration);
 void set_timer_short_duration(int duration);
 void set_dblclick_duration(int duration);
+void set_rotate_angle_threshold(double angle);
+void set_gesture_thresholds(double swipe_distance, double pinch_scale);
 void set_device_ignore(const char* node, bool ignore);
 
 #endif
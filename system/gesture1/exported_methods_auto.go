@@ -8,16 +8,51 @@ import (
 
 func (v *Manager) GetExportedMethods() dbusutil.ExportedMethods {
 	return dbusutil.ExportedMethods{
+		{
+			Name:    "GetHeldModifiers",
+			Fn:      v.GetHeldModifiers,
+			OutArgs: []string{"modifiers"},
+		},
+		{
+			Name:    "GetLastTouchDevice",
+			Fn:      v.GetLastTouchDevice,
+			OutArgs: []string{"devNode"},
+		},
+		{
+			Name:   "InjectButton",
+			Fn:     v.InjectButton,
+			InArgs: []string{"button", "press"},
+		},
+		{
+			Name:   "InjectKey",
+			Fn:     v.InjectKey,
+			InArgs: []string{"code", "press"},
+		},
+		{
+			Name:   "InjectMotion",
+			Fn:     v.InjectMotion,
+			InArgs: []string{"dx", "dy"},
+		},
 		{
 			Name:   "SetEdgeMoveStopDuration",
 			Fn:     v.SetEdgeMoveStopDuration,
 			InArgs: []string{"duration"},
 		},
+		{
+			Name:   "SetGestureThresholds",
+			Fn:     v.SetGestureThresholds,
+			InArgs: []string{"swipeDistance", "pinchScale", "movementDistance"},
+		},
 		{
 			Name:   "SetInputIgnore",
 			Fn:     v.SetInputIgnore,
 			InArgs: []string{"node", "isIgnore"},
 		},
+		{
+			Name:   "SetRotateAngleThreshold",
+			Fn:     v.SetRotateAngleThreshold,
+			InArgs: []string{"angle"},
+		},
 		{
 			Name:   "SetShortPressDuration",
 			Fn:     v.SetShortPressDuration,
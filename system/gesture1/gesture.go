@@ -12,6 +12,11 @@ package gesture1
 import "C"
 
 import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
 	dbus "github.com/godbus/dbus/v5"
 	"github.com/linuxdeepin/dde-daemon/loader"
 	"github.com/linuxdeepin/go-lib/dbusutil"
@@ -31,17 +36,20 @@ type TouchType int32
 type TouchDirection int32
 
 var (
-	GestureTypeSwipe = GestureType(C.GESTURE_TYPE_SWIPE)
-	GestureTypePinch = GestureType(C.GESTURE_TYPE_PINCH)
-	GestureTypeTap   = GestureType(C.GESTURE_TYPE_TAP)
-
-	GestureDirectionNone  = GestureType(C.GESTURE_DIRECTION_NONE)
-	GestureDirectionUp    = GestureType(C.GESTURE_DIRECTION_UP)
-	GestureDirectionDown  = GestureType(C.GESTURE_DIRECTION_DOWN)
-	GestureDirectionLeft  = GestureType(C.GESTURE_DIRECTION_LEFT)
-	GestureDirectionRight = GestureType(C.GESTURE_DIRECTION_RIGHT)
-	GestureDirectionIn    = GestureType(C.GESTURE_DIRECTION_IN)
-	GestureDirectionOut   = GestureType(C.GESTURE_DIRECTION_OUT)
+	GestureTypeSwipe  = GestureType(C.GESTURE_TYPE_SWIPE)
+	GestureTypePinch  = GestureType(C.GESTURE_TYPE_PINCH)
+	GestureTypeTap    = GestureType(C.GESTURE_TYPE_TAP)
+	GestureTypeRotate = GestureType(C.GESTURE_TYPE_ROTATE)
+
+	GestureDirectionNone             = GestureType(C.GESTURE_DIRECTION_NONE)
+	GestureDirectionUp               = GestureType(C.GESTURE_DIRECTION_UP)
+	GestureDirectionDown             = GestureType(C.GESTURE_DIRECTION_DOWN)
+	GestureDirectionLeft             = GestureType(C.GESTURE_DIRECTION_LEFT)
+	GestureDirectionRight            = GestureType(C.GESTURE_DIRECTION_RIGHT)
+	GestureDirectionIn               = GestureType(C.GESTURE_DIRECTION_IN)
+	GestureDirectionOut              = GestureType(C.GESTURE_DIRECTION_OUT)
+	GestureDirectionClockwise        = GestureType(C.GESTURE_DIRECTION_CLOCKWISE)
+	GestureDirectionCounterclockwise = GestureType(C.GESTURE_DIRECTION_COUNTERCLOCKWISE)
 
 	TouchTypeRightButton = TouchType(C.TOUCH_TYPE_RIGHT_BUTTON)
 
@@ -68,6 +76,8 @@ func (t GestureType) String() string {
 		return "pinch"
 	case GestureTypeTap:
 		return "tap"
+	case GestureTypeRotate:
+		return "rotate"
 	case GestureDirectionNone:
 		return "none"
 	case GestureDirectionUp:
@@ -82,6 +92,10 @@ func (t GestureType) String() string {
 		return "in"
 	case GestureDirectionOut:
 		return "out"
+	case GestureDirectionClockwise:
+		return "clockwise"
+	case GestureDirectionCounterclockwise:
+		return "counterclockwise"
 	}
 	return "Unknown"
 }
@@ -126,6 +140,23 @@ func (t TouchDirection) String() string {
 type Manager struct {
 	service *dbusutil.Service
 
+	// modifier keys currently held, keys are the key codes in modifierKeyNames
+	heldModifiers   map[uint32]bool
+	heldModifiersMu sync.Mutex
+
+	// device node of the touchscreen touched last, used to find the monitor of touchscreen gestures
+	lastTouchDevice   string
+	lastTouchDeviceMu sync.Mutex
+
+	// SwipeUpdate is broadcast on the system bus, updates arriving faster
+	// than swipeUpdateInterval are merged
+	swipeUpdate   swipeUpdateThrottle
+	swipeUpdateMu sync.Mutex
+
+	// virtual input device used by InjectKey, created on first use
+	uinputMu  sync.Mutex
+	uinputDev *uinputDevice
+
 	// nolint
 	signals *struct {
 		Event struct {
@@ -160,9 +191,24 @@ type Manager struct {
 			fingers int32
 		}
 
+		//gesture swipe update, unaccelerated delta since the previous signal,
+		//updates are merged so that it is emitted at most every swipeUpdateInterval
+		SwipeUpdate struct {
+			fingers int32
+			dx, dy  float64
+		}
+
+		//gesture swipe end, emitted before the swipe Event
+		SwipeEnd struct {
+			fingers   int32
+			cancelled bool
+		}
+
+		// devNode is the touchscreen of the gesture, used to find its monitor
 		TouchEdgeEvent struct {
 			direction      string
 			scaleX, scaleY float64
+			devNode        string
 		}
 
 		TouchMovementEvent struct {
@@ -170,6 +216,7 @@ type Manager struct {
 			fingers                  int32
 			startScaleX, startScaleY float64
 			endScaleX, endScaleY     float64
+			devNode                  string
 		}
 
 		TouchSinglePressTimeout struct {
@@ -201,6 +248,19 @@ type Manager struct {
 		TouchMoving struct {
 			scalex, scaley float64
 		}
+
+		//single finger tap on the touchscreen, the position is scaled to the screen size
+		TouchTap struct {
+			scaleX, scaleY float64
+		}
+
+		//tap with one or more fingers on the touchscreen, the positions of the first
+		//and the last touch are scaled to the screen size
+		TouchTapEvent struct {
+			fingers                  int32
+			startScaleX, startScaleY float64
+			endScaleX, endScaleY     float64
+		}
 	}
 }
 
@@ -243,6 +303,28 @@ func (*Manager) SetEdgeMoveStopDuration(duration int) *dbus.Error {
 	return nil
 }
 
+// angle unit degree, two-finger pinch gestures rotated by at least angle are emitted as rotate events
+func (*Manager) SetRotateAngleThreshold(angle float64) *dbus.Error {
+	if angle <= 0 || angle > 180 {
+		return dbusutil.ToError(fmt.Errorf("invalid rotate angle threshold %v", angle))
+	}
+	C.set_rotate_angle_threshold(C.double(angle))
+	return nil
+}
+
+// swipeDistance is the minimum unaccelerated movement of touchpad swipe gestures,
+// pinchScale is the minimum accumulated scale change of touchpad pinch gestures,
+// movementDistance unit mm, the minimum distance of touchscreen edge and movement gestures
+func (*Manager) SetGestureThresholds(swipeDistance, pinchScale, movementDistance float64) *dbus.Error {
+	if swipeDistance <= 0 || pinchScale <= 0 || movementDistance <= 0 {
+		return dbusutil.ToError(fmt.Errorf("invalid gesture thresholds %v, %v, %v",
+			swipeDistance, pinchScale, movementDistance))
+	}
+	C.set_gesture_thresholds(C.double(swipeDistance), C.double(pinchScale))
+	C.set_min_movement_distance(C.double(movementDistance))
+	return nil
+}
+
 func (*Manager) SetInputIgnore(node string, isIgnore bool) *dbus.Error {
 	C.set_device_ignore(C.CString(node), C.bool(isIgnore))
 	return nil
@@ -285,6 +367,80 @@ func handleSwipeStop(fingers C.int) {
 	}
 }
 
+// the SwipeUpdate signal is emitted at most once in this interval, about the
+// refresh rate of the screen
+const swipeUpdateInterval = 16 * time.Millisecond
+
+// swipeUpdateThrottle merge the swipe updates arriving within swipeUpdateInterval
+type swipeUpdateThrottle struct {
+	fingers  int32
+	dx, dy   float64
+	pending  bool
+	lastEmit time.Time
+}
+
+// add return the merged update to emit, ok is false when the update is
+// kept until the interval passes
+func (t *swipeUpdateThrottle) add(fingers int32, dx, dy float64, now time.Time) (int32, float64, float64, bool) {
+	if t.pending && t.fingers != fingers {
+		t.dx, t.dy = 0, 0
+	}
+	t.fingers = fingers
+	t.dx += dx
+	t.dy += dy
+	t.pending = true
+	if now.Sub(t.lastEmit) < swipeUpdateInterval {
+		return 0, 0, 0, false
+	}
+	t.lastEmit = now
+	return t.flush()
+}
+
+// flush return the update kept by add, it is called when the swipe ends
+func (t *swipeUpdateThrottle) flush() (fingers int32, dx, dy float64, ok bool) {
+	if !t.pending {
+		return 0, 0, 0, false
+	}
+	fingers, dx, dy = t.fingers, t.dx, t.dy
+	t.dx, t.dy = 0, 0
+	t.pending = false
+	return fingers, dx, dy, true
+}
+
+func (m *Manager) emitSwipeUpdate(fingers int32, dx, dy float64) {
+	err := m.service.Emit(m, "SwipeUpdate", fingers, dx, dy)
+	if err != nil {
+		logger.Error("handleSwipeUpdate failed:", err)
+	}
+}
+
+//export handleSwipeUpdate
+func handleSwipeUpdate(fingers C.int, dx, dy C.double) {
+	_m.swipeUpdateMu.Lock()
+	f, mergedDx, mergedDy, ok := _m.swipeUpdate.add(int32(fingers), float64(dx), float64(dy), time.Now())
+	_m.swipeUpdateMu.Unlock()
+	if ok {
+		_m.emitSwipeUpdate(f, mergedDx, mergedDy)
+	}
+}
+
+//export handleSwipeEnd
+func handleSwipeEnd(fingers C.int, cancelled C.bool) {
+	// the last updates kept by the throttle are sent before the end
+	_m.swipeUpdateMu.Lock()
+	f, dx, dy, ok := _m.swipeUpdate.flush()
+	_m.swipeUpdateMu.Unlock()
+	if ok {
+		_m.emitSwipeUpdate(f, dx, dy)
+	}
+
+	logger.Debug("emit SwipeEnd:", int32(fingers), bool(cancelled))
+	err := _m.service.Emit(_m, "SwipeEnd", int32(fingers), bool(cancelled))
+	if err != nil {
+		logger.Error("handleSwipeEnd failed:", err)
+	}
+}
+
 // touchscreen gesture
 //
 //export handleTouchEvent
@@ -296,8 +452,79 @@ func handleTouchEvent(ty, btn C.int) {
 	}
 }
 
+// modifier key codes in linux/input-event-codes.h
+var modifierKeyNames = map[uint32]string{
+	29:  "ctrl",  // KEY_LEFTCTRL
+	97:  "ctrl",  // KEY_RIGHTCTRL
+	56:  "alt",   // KEY_LEFTALT
+	100: "alt",   // KEY_RIGHTALT
+	42:  "shift", // KEY_LEFTSHIFT
+	54:  "shift", // KEY_RIGHTSHIFT
+	125: "super", // KEY_LEFTMETA
+	126: "super", // KEY_RIGHTMETA
+}
+
+// LIBINPUT_KEY_STATE_PRESSED
+const keyStatePressed = 1
+
+func updateHeldModifiers(held map[uint32]bool, key, state uint32) {
+	if _, ok := modifierKeyNames[key]; !ok {
+		return
+	}
+	if state == keyStatePressed {
+		held[key] = true
+	} else {
+		delete(held, key)
+	}
+}
+
+// heldModifierNames returns sorted names without duplicates
+func heldModifierNames(held map[uint32]bool) []string {
+	names := make([]string, 0, len(held))
+	seen := make(map[string]bool)
+	for key := range held {
+		name := modifierKeyNames[key]
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetHeldModifiers returns the modifier keys currently held, such as ctrl and shift,
+// used by gestures which require modifiers
+func (m *Manager) GetHeldModifiers() (modifiers []string, busErr *dbus.Error) {
+	m.heldModifiersMu.Lock()
+	defer m.heldModifiersMu.Unlock()
+	return heldModifierNames(m.heldModifiers), nil
+}
+
+// GetLastTouchDevice returns the device node of the touchscreen touched last,
+// such as /dev/input/event5, the touchscreen gesture signals come from it
+func (m *Manager) GetLastTouchDevice() (devNode string, busErr *dbus.Error) {
+	m.lastTouchDeviceMu.Lock()
+	defer m.lastTouchDeviceMu.Unlock()
+	return m.lastTouchDevice, nil
+}
+
+//export handleTouchDevice
+func handleTouchDevice(node *C.char) {
+	_m.lastTouchDeviceMu.Lock()
+	_m.lastTouchDevice = C.GoString(node)
+	_m.lastTouchDeviceMu.Unlock()
+}
+
 //export handleKeyboardEvent
 func handleKeyboardEvent(key, state C.uint) {
+	_m.heldModifiersMu.Lock()
+	if _m.heldModifiers == nil {
+		_m.heldModifiers = make(map[uint32]bool)
+	}
+	updateHeldModifiers(_m.heldModifiers, uint32(key), uint32(state))
+	_m.heldModifiersMu.Unlock()
+
 	_m.service.Emit(_m, "KeyboardEvent", uint32(key), uint32(state))
 }
 
@@ -312,14 +539,18 @@ func handleMouseEvent(ty uint32, state C.uint, value C.double) {
 
 //export handleTouchScreenEvent
 func handleTouchScreenEvent(ty, direction, fingers C.int, startScaleX, startScaleY, endScaleX, endScaleY C.double) {
+	// the gesture is recognized when the fingers leave, the device is recorded when they touch down
+	_m.lastTouchDeviceMu.Lock()
+	devNode := _m.lastTouchDevice
+	_m.lastTouchDeviceMu.Unlock()
 	switch int(ty) {
 	case int(C.get_edge_type()):
-		err := _m.service.Emit(_m, "TouchEdgeEvent", TouchDirection(direction).String(), float64(endScaleX), float64(endScaleY))
+		err := _m.service.Emit(_m, "TouchEdgeEvent", TouchDirection(direction).String(), float64(endScaleX), float64(endScaleY), devNode)
 		if err != nil {
 			logger.Error("handleTouchScreenEvent failed:", err)
 		}
 	case int(C.get_movement_type()):
-		err := _m.service.Emit(_m, "TouchMovementEvent", TouchDirection(direction).String(), fingers, float64(startScaleX), float64(startScaleY), float64(endScaleX), float64(endScaleY))
+		err := _m.service.Emit(_m, "TouchMovementEvent", TouchDirection(direction).String(), fingers, float64(startScaleX), float64(startScaleY), float64(endScaleX), float64(endScaleY), devNode)
 		if err != nil {
 			logger.Error("handleTouchMovementEvent failed:", err)
 		}
@@ -350,6 +581,22 @@ func handleTouchMoving(scalex, scaley C.double) {
 	}
 }
 
+//export handleTouchTap
+func handleTouchTap(scalex, scaley C.double) {
+	err := _m.service.Emit(_m, "TouchTap", float64(scalex), float64(scaley))
+	if err != nil {
+		logger.Error("handleTouchTap failed:", err)
+	}
+}
+
+//export handleTouchTapEvent
+func handleTouchTapEvent(fingers C.int, startScaleX, startScaleY, endScaleX, endScaleY C.double) {
+	err := _m.service.Emit(_m, "TouchTapEvent", int32(fingers), float64(startScaleX), float64(startScaleY), float64(endScaleX), float64(endScaleY))
+	if err != nil {
+		logger.Error("handleTouchTapEvent failed:", err)
+	}
+}
+
 //export handleTouchShortPress
 func handleTouchShortPress(time C.int, scalex, scaley C.double) {
 	err := _m.service.Emit(_m, "TouchSinglePressTimeout", int32(time), float64(scalex), float64(scaley))
@@ -405,6 +652,7 @@ func (*Daemon) Stop() error {
 		return nil
 	}
 	C.quit_loop()
+	_m.destroyUInputDevice()
 	service := loader.GetService()
 	err := service.StopExport(_m)
 	if err != nil {
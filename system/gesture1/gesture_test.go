@@ -6,25 +6,29 @@ package gesture1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 const (
-	GESTURE_DIRECTION_NONE  = 0
-	GESTURE_DIRECTION_UP    = 10
-	GESTURE_DIRECTION_DOWN  = 11
-	GESTURE_DIRECTION_LEFT  = 12
-	GESTURE_DIRECTION_RIGHT = 13
-	GESTURE_DIRECTION_IN    = 14
-	GESTURE_DIRECTION_OUT   = 15
-	TOUCH_TYPE_RIGHT_BUTTON = 50
-
-	GESTURE_TYPE_SWIPE = 100
-	GESTURE_TYPE_PINCH = 101
-	GESTURE_TYPE_TAP   = 102
-	BUTTON_TYPE_DOWN   = 501
-	BUTTON_TYPE_UP     = 502
+	GESTURE_DIRECTION_NONE             = 0
+	GESTURE_DIRECTION_UP               = 10
+	GESTURE_DIRECTION_DOWN             = 11
+	GESTURE_DIRECTION_LEFT             = 12
+	GESTURE_DIRECTION_RIGHT            = 13
+	GESTURE_DIRECTION_IN               = 14
+	GESTURE_DIRECTION_OUT              = 15
+	GESTURE_DIRECTION_CLOCKWISE        = 16
+	GESTURE_DIRECTION_COUNTERCLOCKWISE = 17
+	TOUCH_TYPE_RIGHT_BUTTON            = 50
+
+	GESTURE_TYPE_SWIPE  = 100
+	GESTURE_TYPE_PINCH  = 101
+	GESTURE_TYPE_TAP    = 102
+	GESTURE_TYPE_ROTATE = 103
+	BUTTON_TYPE_DOWN    = 501
+	BUTTON_TYPE_UP      = 502
 
 	DIR_NONE  = 0
 	DIR_TOP   = 1
@@ -42,17 +46,20 @@ const (
 
 func Test_String_GestureType(t *testing.T) {
 	m1 := map[int]string{
-		GESTURE_DIRECTION_NONE:  "none",
-		GESTURE_DIRECTION_UP:    "up",
-		GESTURE_DIRECTION_DOWN:  "down",
-		GESTURE_DIRECTION_LEFT:  "left",
-		GESTURE_DIRECTION_RIGHT: "right",
-		GESTURE_DIRECTION_IN:    "in",
-		GESTURE_DIRECTION_OUT:   "out",
-		GESTURE_TYPE_SWIPE:      "swipe",
-		GESTURE_TYPE_PINCH:      "pinch",
-		GESTURE_TYPE_TAP:        "tap",
-		UNKNOWN:                 "Unknown",
+		GESTURE_DIRECTION_NONE:             "none",
+		GESTURE_DIRECTION_UP:               "up",
+		GESTURE_DIRECTION_DOWN:             "down",
+		GESTURE_DIRECTION_LEFT:             "left",
+		GESTURE_DIRECTION_RIGHT:            "right",
+		GESTURE_DIRECTION_IN:               "in",
+		GESTURE_DIRECTION_OUT:              "out",
+		GESTURE_DIRECTION_CLOCKWISE:        "clockwise",
+		GESTURE_DIRECTION_COUNTERCLOCKWISE: "counterclockwise",
+		GESTURE_TYPE_SWIPE:                 "swipe",
+		GESTURE_TYPE_PINCH:                 "pinch",
+		GESTURE_TYPE_TAP:                   "tap",
+		GESTURE_TYPE_ROTATE:                "rotate",
+		UNKNOWN:                            "Unknown",
 	}
 
 	g := GestureType(GESTURE_TYPE_SWIPE)
@@ -67,6 +74,10 @@ func Test_String_GestureType(t *testing.T) {
 	rtn = g.String()
 	assert.Equal(t, m1[GESTURE_TYPE_TAP], rtn)
 
+	g = GestureType(GESTURE_TYPE_ROTATE)
+	rtn = g.String()
+	assert.Equal(t, m1[GESTURE_TYPE_ROTATE], rtn)
+
 	g = GestureType(GESTURE_DIRECTION_NONE)
 	rtn = g.String()
 	assert.Equal(t, m1[GESTURE_DIRECTION_NONE], rtn)
@@ -95,6 +106,14 @@ func Test_String_GestureType(t *testing.T) {
 	rtn = g.String()
 	assert.Equal(t, m1[GESTURE_DIRECTION_OUT], rtn)
 
+	g = GestureType(GESTURE_DIRECTION_CLOCKWISE)
+	rtn = g.String()
+	assert.Equal(t, m1[GESTURE_DIRECTION_CLOCKWISE], rtn)
+
+	g = GestureType(GESTURE_DIRECTION_COUNTERCLOCKWISE)
+	rtn = g.String()
+	assert.Equal(t, m1[GESTURE_DIRECTION_COUNTERCLOCKWISE], rtn)
+
 	g = GestureType(UNKNOWN)
 	rtn = g.String()
 	assert.Equal(t, m1[UNKNOWN], rtn)
@@ -179,3 +198,45 @@ func Test_String_TouchDirection(t *testing.T) {
 	rtn = g.String()
 	assert.Equal(t, m1[UNKNOWN], rtn)
 }
+
+func Test_heldModifiers(t *testing.T) {
+	held := make(map[uint32]bool)
+	updateHeldModifiers(held, 29, keyStatePressed)
+	updateHeldModifiers(held, 97, keyStatePressed)
+	updateHeldModifiers(held, 42, keyStatePressed)
+	// not a modifier key
+	updateHeldModifiers(held, 30, keyStatePressed)
+	assert.Equal(t, []string{"ctrl", "shift"}, heldModifierNames(held))
+
+	updateHeldModifiers(held, 29, 0)
+	assert.Equal(t, []string{"ctrl", "shift"}, heldModifierNames(held))
+	updateHeldModifiers(held, 97, 0)
+	updateHeldModifiers(held, 42, 0)
+	assert.Equal(t, []string{}, heldModifierNames(held))
+}
+
+func Test_swipeUpdateThrottle(t *testing.T) {
+	var throttle swipeUpdateThrottle
+	now := time.Unix(1000, 0)
+	fingers, dx, dy, ok := throttle.add(3, 1, 2, now)
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{int32(3), 1.0, 2.0}, []interface{}{fingers, dx, dy})
+
+	// merged until the interval passes
+	_, _, _, ok = throttle.add(3, 1, 1, now.Add(5*time.Millisecond))
+	assert.False(t, ok)
+	_, _, _, ok = throttle.add(3, 2, 1, now.Add(10*time.Millisecond))
+	assert.False(t, ok)
+	fingers, dx, dy, ok = throttle.add(3, 1, 1, now.Add(swipeUpdateInterval))
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{int32(3), 4.0, 3.0}, []interface{}{fingers, dx, dy})
+
+	// the swipe ends before the interval passes
+	_, _, _, ok = throttle.add(3, 1, 0, now.Add(swipeUpdateInterval+time.Millisecond))
+	assert.False(t, ok)
+	fingers, dx, dy, ok = throttle.flush()
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{int32(3), 1.0, 0.0}, []interface{}{fingers, dx, dy})
+	_, _, _, ok = throttle.flush()
+	assert.False(t, ok)
+}
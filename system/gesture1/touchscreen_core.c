@@ -59,6 +59,14 @@ set_edge_move_stop_time(int duration)
 	edge_move_stop_time = duration;
 }
 
+//set minimum distance of edge and movement gestures, in mm
+void
+set_min_movement_distance(double distance)
+{
+    logger("[Distance set_min_movement_distance ] set: %f --> %f", min_edge_distance, distance);
+	min_edge_distance = distance;
+}
+
 // udpate touchscreen first touch point info
 void update_first_point_relative_coordinate(double x, double y) {
     start_point_scale.x = x / screen.width;
@@ -430,6 +438,24 @@ enum Direction edge_move_direction(movement *m, list *ready) {
 	return DIR_NONE;
 }
 
+//single finger touch released quickly without obvious movement
+bool is_tap(const movement *m) {
+	uint32_t duration = (m->t_end - m->t_start) / 1000;
+	return movement_length(m) < tap_max_distance && duration < tap_max_duration;
+}
+
+//all fingers released quickly without obvious movement
+bool is_multi_tap(movement *m, list *ready) {
+	node *cur = ready->head;
+	while (cur != NULL) {
+		if (!is_tap(m + *((size_t *)cur->value))) {
+			return false;
+		}
+		cur = cur->next;
+	}
+	return true;
+}
+
 //get touchscreeen gesture info
 gesture get_gesture(movement *m, list *ready) {
 	gesture g = {0};
@@ -466,6 +492,12 @@ void handle_movements(movement *m) {
 	print_gesture(&g);
 
 	handleTouchScreenEvent((int)g.type, (int)g.dir, g.num, start_point_scale.x, start_point_scale.y, last_point_scale.x, last_point_scale.y);
+	if (g.num == 1 && is_tap(m + *((size_t *)ready->head->value))) {
+		handleTouchTap(start_point_scale.x, start_point_scale.y);
+	}
+	if (g.type == GT_TAP && is_multi_tap(m, ready)) {
+		handleTouchTapEvent(g.num, start_point_scale.x, start_point_scale.y, last_point_scale.x, last_point_scale.y);
+	}
 
 	list_destroy(ready);
 	logger("Handle movements: end\n");
@@ -66,11 +66,14 @@ static int edge_error_limit = 3;	//edge error limit when swipe to touchscreen fr
 static uint32_t edge_move_stop_time = 0;
 static Direction edge_move_stop_direction = DIR_NONE;
 static double min_edge_distance = 10.0;   // minimum gesture distance from edge (in mm)
+static double tap_max_distance = 2.0;     // maximum movement of a tap (in mm)
+static uint32_t tap_max_duration = 250;   // maximum duration of a tap (in ms)
 
 int get_edge_type();
 int get_movement_type();
 point get_last_point_scale();
 void set_edge_move_stop_time(int duration);
+void set_min_movement_distance(double distance);
 void handle_movements(movement *m);
 void get_screen_info(struct libinput_event *event);
 void handle_touch_event_down(struct libinput_event *event, struct movement *m);
@@ -0,0 +1,326 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package gesture1
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/common/procsubject"
+	polkit "github.com/linuxdeepin/go-dbus-factory/system/org.freedesktop.policykit1"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+// the session daemon can not open /dev/uinput, the gesture actions which
+// send keys and mouse buttons on Wayland inject them through these methods
+const polkitActionInjectInput = "org.deepin.dde.gesture.inject-input"
+
+const sessionDaemonExe = "/usr/lib/deepin-daemon/dde-session-daemon"
+
+// definitions in linux/uinput.h and linux/input-event-codes.h
+const (
+	uinputPath = "/dev/uinput"
+
+	uiDevCreate  = 0x5501
+	uiDevDestroy = 0x5502
+	uiSetEvBit   = 0x40045564
+	uiSetKeyBit  = 0x40045565
+	uiSetRelBit  = 0x40045566
+
+	uinputMaxNameSize = 80
+	absCnt            = 64
+	busVirtual        = 0x06
+
+	evSyn     = 0x00
+	evKey     = 0x01
+	evRel     = 0x02
+	synReport = 0
+
+	relX = 0x00
+	relY = 0x01
+
+	btnLeft   = 0x110
+	btnRight  = 0x111
+	btnMiddle = 0x112
+
+	// all keys below this code are registered to the virtual device
+	uinputMaxKeycode = 0x100
+	// the compositor needs some time to pick up a new device,
+	// otherwise the first events are lost
+	uinputCreateDelay = 200 * time.Millisecond
+)
+
+const uinputDeviceName = "dde-daemon gesture virtual input"
+
+type uinputInputId struct {
+	Bustype uint16
+	Vendor  uint16
+	Product uint16
+	Version uint16
+}
+
+// uinputUserDev struct uinput_user_dev
+type uinputUserDev struct {
+	Name         [uinputMaxNameSize]byte
+	Id           uinputInputId
+	FfEffectsMax uint32
+	Absmax       [absCnt]int32
+	Absmin       [absCnt]int32
+	Absfuzz      [absCnt]int32
+	Absflat      [absCnt]int32
+}
+
+// inputEvent struct input_event
+type inputEvent struct {
+	Time  syscall.Timeval
+	Type  uint16
+	Code  uint16
+	Value int32
+}
+
+// uinputDevice virtual keyboard and mouse created by uinput
+type uinputDevice struct {
+	file *os.File
+}
+
+func uinputIoctl(fd uintptr, request, arg uintptr) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, arg)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func newUInputDevice() (*uinputDevice, error) {
+	file, err := os.OpenFile(uinputPath, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	d := &uinputDevice{file: file}
+	err = d.setup()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to create uinput device: %v", err)
+	}
+	time.Sleep(uinputCreateDelay)
+	return d, nil
+}
+
+func (d *uinputDevice) setup() error {
+	fd := d.file.Fd()
+	err := uinputIoctl(fd, uiSetEvBit, evKey)
+	if err != nil {
+		return err
+	}
+	for code := uintptr(1); code < uinputMaxKeycode; code++ {
+		err = uinputIoctl(fd, uiSetKeyBit, code)
+		if err != nil {
+			return err
+		}
+	}
+	for _, code := range []uintptr{btnLeft, btnRight, btnMiddle} {
+		err = uinputIoctl(fd, uiSetKeyBit, code)
+		if err != nil {
+			return err
+		}
+	}
+	err = uinputIoctl(fd, uiSetEvBit, evRel)
+	if err != nil {
+		return err
+	}
+	for _, code := range []uintptr{relX, relY} {
+		err = uinputIoctl(fd, uiSetRelBit, code)
+		if err != nil {
+			return err
+		}
+	}
+
+	dev := uinputUserDev{
+		Id: uinputInputId{
+			Bustype: busVirtual,
+			Version: 1,
+		},
+	}
+	copy(dev.Name[:], uinputDeviceName)
+	var buf bytes.Buffer
+	err = binary.Write(&buf, binary.LittleEndian, &dev)
+	if err != nil {
+		return err
+	}
+	_, err = d.file.Write(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	return uinputIoctl(fd, uiDevCreate, 0)
+}
+
+func (d *uinputDevice) writeEvent(typ, code uint16, value int32) error {
+	ev := inputEvent{
+		Type:  typ,
+		Code:  code,
+		Value: value,
+	}
+	return binary.Write(d.file, binary.LittleEndian, &ev)
+}
+
+func (d *uinputDevice) emitCode(code uint16, press bool) error {
+	var value int32
+	if press {
+		value = 1
+	}
+	err := d.writeEvent(evKey, code, value)
+	if err != nil {
+		return err
+	}
+	return d.writeEvent(evSyn, synReport, 0)
+}
+
+// getButtonCode convert the X11 button number to the evdev button code
+func getButtonCode(button uint8) (uint16, error) {
+	switch button {
+	case 1:
+		return btnLeft, nil
+	case 2:
+		return btnMiddle, nil
+	case 3:
+		return btnRight, nil
+	}
+	return 0, fmt.Errorf("unsupported mouse button %d", button)
+}
+
+func (d *uinputDevice) emitMotion(dx, dy int32) error {
+	err := d.writeEvent(evRel, relX, dx)
+	if err != nil {
+		return err
+	}
+	err = d.writeEvent(evRel, relY, dy)
+	if err != nil {
+		return err
+	}
+	return d.writeEvent(evSyn, synReport, 0)
+}
+
+func (d *uinputDevice) destroy() {
+	err := uinputIoctl(d.file.Fd(), uiDevDestroy, 0)
+	if err != nil {
+		logger.Warning("failed to destroy uinput device:", err)
+	}
+	_ = d.file.Close()
+}
+
+// checkInjectAuth only the session daemon is allowed to inject input, other
+// programs in the session must not be able to send keys to any window.
+// The executable is compared by the absolute path, and the polkit subject
+// carries the start time of the process, so that a reused pid is rejected.
+func (m *Manager) checkInjectAuth(sender dbus.Sender) error {
+	pid, err := m.service.GetConnPID(string(sender))
+	if err != nil {
+		return err
+	}
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return err
+	}
+	if exe != sessionDaemonExe {
+		return fmt.Errorf("%s is not allowed to inject input", exe)
+	}
+
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	subject, err := procsubject.New(pid)
+	if err != nil {
+		return err
+	}
+	authority := polkit.NewAuthority(systemBus)
+	result, err := authority.CheckAuthorization(0, subject, polkitActionInjectInput,
+		nil, polkit.CheckAuthorizationFlagsNone, "")
+	if err != nil {
+		return err
+	}
+	if !result.IsAuthorized {
+		return errors.New("not authorized")
+	}
+	return nil
+}
+
+// getUInputDevice create the virtual device on first use, m.uinputMu must be held
+func (m *Manager) getUInputDevice() (*uinputDevice, error) {
+	if m.uinputDev != nil {
+		return m.uinputDev, nil
+	}
+	dev, err := newUInputDevice()
+	if err != nil {
+		return nil, err
+	}
+	m.uinputDev = dev
+	return dev, nil
+}
+
+func (m *Manager) injectInput(sender dbus.Sender, fn func(dev *uinputDevice) error) *dbus.Error {
+	err := m.checkInjectAuth(sender)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	m.uinputMu.Lock()
+	defer m.uinputMu.Unlock()
+	dev, err := m.getUInputDevice()
+	if err == nil {
+		err = fn(dev)
+	}
+	if err != nil {
+		logger.Warning("failed to inject input:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+// InjectKey press or release the key through a virtual input device, code
+// is the evdev key code, such as 29 for the left ctrl. It is used by the
+// session daemon on Wayland, calls from other programs are rejected.
+func (m *Manager) InjectKey(sender dbus.Sender, code uint16, press bool) *dbus.Error {
+	if code == 0 || code >= uinputMaxKeycode {
+		return dbusutil.ToError(fmt.Errorf("invalid key code %d", code))
+	}
+	return m.injectInput(sender, func(dev *uinputDevice) error {
+		return dev.emitCode(code, press)
+	})
+}
+
+// InjectButton press or release the mouse button through a virtual input
+// device, button is the X11 button number, 1 for left, 2 for middle and 3
+// for right.
+func (m *Manager) InjectButton(sender dbus.Sender, button uint8, press bool) *dbus.Error {
+	code, err := getButtonCode(button)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	return m.injectInput(sender, func(dev *uinputDevice) error {
+		return dev.emitCode(code, press)
+	})
+}
+
+// InjectMotion move the pointer relatively through a virtual input device.
+func (m *Manager) InjectMotion(sender dbus.Sender, dx, dy int32) *dbus.Error {
+	return m.injectInput(sender, func(dev *uinputDevice) error {
+		return dev.emitMotion(dx, dy)
+	})
+}
+
+func (m *Manager) destroyUInputDevice() {
+	m.uinputMu.Lock()
+	defer m.uinputMu.Unlock()
+	if m.uinputDev != nil {
+		m.uinputDev.destroy()
+		m.uinputDev = nil
+	}
+}
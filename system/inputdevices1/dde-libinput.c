@@ -14,6 +14,7 @@
 extern void log_handler_go(enum libinput_log_priority priority, char *);
 extern void handle_device_added(struct libinput_event *, void *);
 extern void handle_device_removed(struct libinput_event *, void *);
+extern void handle_tablet_mode_changed(int, void *);
 
 struct data {
     int stop;
@@ -68,6 +69,15 @@ static void handle_events(struct libinput *li, void *userdata) {
         case LIBINPUT_EVENT_DEVICE_REMOVED:
             handle_device_removed(event, userdata);
             break;
+        case LIBINPUT_EVENT_SWITCH_TOGGLE: {
+            // 可翻转笔记本的平板模式开关 SW_TABLET_MODE
+            struct libinput_event_switch *sw = libinput_event_get_switch_event(event);
+            if (libinput_event_switch_get_switch(sw) == LIBINPUT_SWITCH_TABLET_MODE) {
+                int state = libinput_event_switch_get_switch_state(sw) == LIBINPUT_SWITCH_STATE_ON;
+                handle_tablet_mode_changed(state, userdata);
+            }
+            break;
+        }
         case LIBINPUT_EVENT_TOUCH_DOWN:
         case LIBINPUT_EVENT_TOUCH_UP:
         case LIBINPUT_EVENT_TOUCH_MOTION:
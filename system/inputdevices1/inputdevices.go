@@ -52,6 +52,9 @@ type InputDevices struct {
 	dsgWakeupDeviceStatus []string          //用于存储dsg的数据
 	dsgInputDevices       configManager.Manager
 
+	// 可翻转笔记本是否处于平板模式，由 libinput 的 SW_TABLET_MODE 开关事件更新
+	TabletMode bool
+
 	//nolint
 	signals *struct {
 		TouchscreenAdded, TouchscreenRemoved struct {
@@ -435,6 +438,13 @@ func (m *InputDevices) removeTouchscreen(dev *libinputDevice) {
 	m.service.Emit(m, "TouchscreenRemoved", path)
 }
 
+// setTabletMode 只在 libinput 的事件循环中调用
+func (m *InputDevices) setTabletMode(tabletMode bool) {
+	if m.setPropTabletMode(tabletMode) {
+		logger.Info("tablet mode changed:", tabletMode)
+	}
+}
+
 func (m *InputDevices) getIndexByDevNode(devNode string) int {
 	var path dbus.ObjectPath
 	for i, v := range m.touchscreens {
@@ -38,6 +38,19 @@ func (v *InputDevices) emitPropChangedSupportWakeupDevices(value map[string]stri
 	return v.service.EmitPropertyChanged(v, "SupportWakeupDevices", v.SupportWakeupDevices)
 }
 
+func (v *InputDevices) setPropTabletMode(value bool) (changed bool) {
+	if v.TabletMode != value {
+		v.TabletMode = value
+		v.emitPropChangedTabletMode(value)
+		return true
+	}
+	return false
+}
+
+func (v *InputDevices) emitPropChangedTabletMode(value bool) error {
+	return v.service.EmitPropertyChanged(v, "TabletMode", value)
+}
+
 func (v *Touchpad) setPropEnable(value bool) (changed bool) {
 	if v.Enable != value {
 		v.Enable = value
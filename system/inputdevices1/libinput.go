@@ -45,6 +45,10 @@ func handle_device_added(event *C.struct_libinput_event, userdata unsafe.Pointer
 func handle_device_removed(event *C.struct_libinput_event, userdata unsafe.Pointer) {
 	dev := C.libinput_event_get_device(event)
 	notify_device_changed(event, userdata, false)
+	if C.libinput_device_switch_has_switch(dev, C.LIBINPUT_SWITCH_TABLET_MODE) > 0 {
+		// 平板模式开关所在的设备移除后回到笔记本模式
+		handle_tablet_mode_changed(0, userdata)
+	}
 	if C.libinput_device_has_capability(dev, C.LIBINPUT_DEVICE_CAP_TOUCH) == 0 {
 		return
 	}
@@ -53,6 +57,12 @@ func handle_device_removed(event *C.struct_libinput_event, userdata unsafe.Point
 	l.i.removeTouchscreen(newLibinputDevice(dev))
 }
 
+//export handle_tablet_mode_changed
+func handle_tablet_mode_changed(state C.int, userdata unsafe.Pointer) {
+	l := (*libinput)(userdata)
+	l.i.setTabletMode(state != 0)
+}
+
 func notify_device_changed(event *C.struct_libinput_event, userdata unsafe.Pointer, state bool) {
 	dev := C.libinput_event_get_device(event)
 	devName := C.GoString(C.libinput_device_get_name(dev))
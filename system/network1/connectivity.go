@@ -0,0 +1,137 @@
+// SPDX-FileCopyrightText: 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network1
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+	"github.com/linuxdeepin/go-lib/keyfile"
+)
+
+const (
+	polkitActionConnectivityCheck = "org.deepin.dde.network.connectivity-check"
+
+	// 随 dde-daemon 安装的配置，未设置 uri 时使用默认地址
+	daemonConfFile = "/etc/NetworkManager/conf.d/deepin.dde.daemon.conf"
+	// 文件名排在 deepin.dde.daemon.conf 之后，NetworkManager 按文件名顺序加载，后加载的配置生效
+	connectivityConfFile = "/etc/NetworkManager/conf.d/deepin.dde.daemon.connectivity.conf"
+
+	kfSectionConnectivity     = "connectivity"
+	kfKeyConnectivityUri      = "uri"
+	kfKeyConnectivityInterval = "interval"
+
+	defaultConnectivityUri = "http://detectportal.deepin.com"
+	// 与 deepin.dde.daemon.conf 一致，默认不进行周期检查
+	defaultConnectivityInterval = 0
+	// NetworkManager 的 interval 为秒，最大为 7 天
+	maxConnectivityInterval = 7 * 24 * 60 * 60
+
+	// NM_MANAGER_RELOAD_FLAG_CONF 只重新加载配置文件
+	nmManagerReloadFlagConf = 0x1
+)
+
+func checkConnectivityUri(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("invalid connectivity uri %q: %v", uri, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid connectivity uri %q, require http or https", uri)
+	}
+	return nil
+}
+
+// loadConnectivityCheck 按 NetworkManager 的加载顺序读取检查地址和间隔，
+// 后面的配置覆盖前面的配置，未设置 uri 时使用默认地址
+func loadConnectivityCheck(kfs ...*keyfile.KeyFile) (uri string, interval uint32) {
+	interval = defaultConnectivityInterval
+	for _, kf := range kfs {
+		if value, err := kf.GetString(kfSectionConnectivity, kfKeyConnectivityUri); err == nil {
+			uri = value
+		}
+		value, err := kf.GetInt(kfSectionConnectivity, kfKeyConnectivityInterval)
+		if err == nil && value >= 0 {
+			interval = uint32(value)
+		}
+	}
+	if uri == "" {
+		uri = defaultConnectivityUri
+	}
+	return
+}
+
+func newConnectivityCheckKeyFile(uri string, interval uint32) *keyfile.KeyFile {
+	kf := keyfile.NewKeyFile()
+	kf.SetString(kfSectionConnectivity, kfKeyConnectivityUri, uri)
+	kf.SetInt(kfSectionConnectivity, kfKeyConnectivityInterval, int(interval))
+	return kf
+}
+
+// GetConnectivityCheck get the uri and the interval in seconds which
+// NetworkManager uses to check the connectivity and detect the captive
+// portal, interval 0 means no periodic checks.
+func (n *Network) GetConnectivityCheck() (uri string, interval uint32, busErr *dbus.Error) {
+	var kfs []*keyfile.KeyFile
+	for _, file := range []string{daemonConfFile, connectivityConfFile} {
+		kf := keyfile.NewKeyFile()
+		err := kf.LoadFromFile(file)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logger.Warningf("failed to load %s: %v", file, err)
+			}
+			continue
+		}
+		kfs = append(kfs, kf)
+	}
+	uri, interval = loadConnectivityCheck(kfs...)
+	return uri, interval, nil
+}
+
+// SetConnectivityCheck set the uri and the interval in seconds which
+// NetworkManager uses to check the connectivity, an empty uri means the
+// default deepin endpoint. It requires the administrator authorization.
+func (n *Network) SetConnectivityCheck(sender dbus.Sender, uri string, interval uint32) *dbus.Error {
+	err := checkPolkitAuth(sender, polkitActionConnectivityCheck)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	err = n.setConnectivityCheck(uri, interval)
+	if err != nil {
+		logger.Warning("failed to set connectivity check:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func (n *Network) setConnectivityCheck(uri string, interval uint32) error {
+	if uri == "" {
+		uri = defaultConnectivityUri
+	}
+	err := checkConnectivityUri(uri)
+	if err != nil {
+		return err
+	}
+	if interval > maxConnectivityInterval {
+		return fmt.Errorf("connectivity interval %d is out of range, max is %d", interval, maxConnectivityInterval)
+	}
+
+	err = os.MkdirAll(filepath.Dir(connectivityConfFile), 0755)
+	if err != nil {
+		return err
+	}
+	err = newConnectivityCheckKeyFile(uri, interval).SaveToFile(connectivityConfFile)
+	if err != nil {
+		return err
+	}
+	logger.Infof("set connectivity check, uri: %s, interval: %d", uri, interval)
+
+	// 通知 NetworkManager 重新加载配置使其立即生效
+	return n.nmManager.Reload(0, nmManagerReloadFlagConf)
+}
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network1
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/linuxdeepin/go-lib/keyfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkConnectivityUri(t *testing.T) {
+	assert.Nil(t, checkConnectivityUri("http://detectportal.deepin.com"))
+	assert.Nil(t, checkConnectivityUri("https://example.com/check"))
+	assert.NotNil(t, checkConnectivityUri("ftp://example.com"))
+	assert.NotNil(t, checkConnectivityUri("example.com"))
+	assert.NotNil(t, checkConnectivityUri(""))
+}
+
+func Test_loadConnectivityCheck(t *testing.T) {
+	uri, interval := loadConnectivityCheck()
+	assert.Equal(t, defaultConnectivityUri, uri)
+	assert.Equal(t, uint32(defaultConnectivityInterval), interval)
+
+	// deepin.dde.daemon.conf 未设置 uri，使用默认地址
+	daemonKf := keyfile.NewKeyFile()
+	err := daemonKf.LoadFromData([]byte("[connectivity]\ninterval=0\n"))
+	assert.Nil(t, err)
+	uri, interval = loadConnectivityCheck(daemonKf)
+	assert.Equal(t, defaultConnectivityUri, uri)
+	assert.Equal(t, uint32(0), interval)
+
+	// uri 为空时也使用默认地址
+	emptyKf := keyfile.NewKeyFile()
+	err = emptyKf.LoadFromData([]byte("[connectivity]\nuri=\ninterval=60\n"))
+	assert.Nil(t, err)
+	uri, interval = loadConnectivityCheck(daemonKf, emptyKf)
+	assert.Equal(t, defaultConnectivityUri, uri)
+	assert.Equal(t, uint32(60), interval)
+
+	var buf bytes.Buffer
+	err = newConnectivityCheckKeyFile("https://example.com/check", 300).SaveToWriter(&buf)
+	assert.Nil(t, err)
+	kf := keyfile.NewKeyFile()
+	err = kf.LoadFromData(buf.Bytes())
+	assert.Nil(t, err)
+	uri, interval = loadConnectivityCheck(daemonKf, kf)
+	assert.Equal(t, "https://example.com/check", uri)
+	assert.Equal(t, uint32(300), interval)
+}
@@ -8,23 +8,58 @@ import (
 
 func (v *Network) GetExportedMethods() dbusutil.ExportedMethods {
 	return dbusutil.ExportedMethods{
+		{
+			Name: "DisableVpnKillSwitch",
+			Fn:   v.DisableVpnKillSwitch,
+		},
 		{
 			Name:    "EnableDevice",
 			Fn:      v.EnableDevice,
 			InArgs:  []string{"pathOrIface", "enabled"},
 			OutArgs: []string{"cpath"},
 		},
+		{
+			Name:   "EnableVpnKillSwitch",
+			Fn:     v.EnableVpnKillSwitch,
+			InArgs: []string{"allowedAddrs"},
+		},
+		{
+			Name:    "GetBssInformationElements",
+			Fn:      v.GetBssInformationElements,
+			InArgs:  []string{"ifc"},
+			OutArgs: []string{"iesMap"},
+		},
+		{
+			Name:    "GetConnectivityCheck",
+			Fn:      v.GetConnectivityCheck,
+			OutArgs: []string{"uri", "interval"},
+		},
 		{
 			Name:    "IsDeviceEnabled",
 			Fn:      v.IsDeviceEnabled,
 			InArgs:  []string{"pathOrIface"},
 			OutArgs: []string{"enabled"},
 		},
+		{
+			Name:    "IsVpnKillSwitchEngaged",
+			Fn:      v.IsVpnKillSwitchEngaged,
+			OutArgs: []string{"engaged"},
+		},
 		{
 			Name:   "Ping",
 			Fn:     v.Ping,
 			InArgs: []string{"host"},
 		},
+		{
+			Name:   "SetConnectivityCheck",
+			Fn:     v.SetConnectivityCheck,
+			InArgs: []string{"uri", "interval"},
+		},
+		{
+			Name:   "StartWpsPin",
+			Fn:     v.StartWpsPin,
+			InArgs: []string{"ifc", "bssid", "pin"},
+		},
 		{
 			Name:    "ToggleWirelessEnabled",
 			Fn:      v.ToggleWirelessEnabled,
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network1
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+
+	dbus "github.com/godbus/dbus/v5"
+	polkit "github.com/linuxdeepin/go-dbus-factory/system/org.freedesktop.policykit1"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+const (
+	polkitActionVpnKillSwitch = "org.deepin.dde.network.vpn-kill-switch"
+
+	killSwitchTable = "inet dde_vpn_killswitch"
+)
+
+// buildKillSwitchRules 生成阻断网络的 nftables 规则，只放行本地回环、DHCP、
+// VPN 隧道接口和 VPN 服务器地址。先创建再删除表使规则可以重复应用
+func buildKillSwitchRules(allowedAddrs []string) (string, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "table %s\ndelete table %s\n", killSwitchTable, killSwitchTable)
+	fmt.Fprintf(&sb, "table %s {\n", killSwitchTable)
+	sb.WriteString("\tchain output {\n")
+	sb.WriteString("\t\ttype filter hook output priority 0; policy drop;\n")
+	sb.WriteString("\t\toifname \"lo\" accept\n")
+	for _, ifc := range []string{"tun*", "tap*", "wg*", "ppp*"} {
+		fmt.Fprintf(&sb, "\t\toifname \"%s\" accept\n", ifc)
+	}
+	sb.WriteString("\t\tudp sport 68 udp dport 67 accept\n")
+	sb.WriteString("\t\tudp sport 546 udp dport 547 accept\n")
+	for _, addr := range allowedAddrs {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return "", fmt.Errorf("invalid address %q", addr)
+		}
+		if ip.To4() != nil {
+			fmt.Fprintf(&sb, "\t\tip daddr %s accept\n", ip)
+		} else {
+			fmt.Fprintf(&sb, "\t\tip6 daddr %s accept\n", ip)
+		}
+	}
+	sb.WriteString("\t}\n}\n")
+	return sb.String(), nil
+}
+
+func runNft(rules string) error {
+	cmd := exec.Command("nft", "-f", "-")
+	cmd.Stdin = strings.NewReader(rules)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("nft: %v, %s", err, out)
+	}
+	return nil
+}
+
+func checkPolkitAuth(sender dbus.Sender, actionId string) error {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return err
+	}
+	authority := polkit.NewAuthority(systemBus)
+	subject := polkit.MakeSubject(polkit.SubjectKindSystemBusName)
+	subject.SetDetail("name", string(sender))
+	result, err := authority.CheckAuthorization(0, subject, actionId,
+		nil, polkit.CheckAuthorizationFlagsAllowUserInteraction, "")
+	if err != nil {
+		return err
+	}
+	if !result.IsAuthorized {
+		return errors.New("not authorized")
+	}
+	return nil
+}
+
+// EnableVpnKillSwitch block all outgoing traffic except to the allowed
+// addresses of the VPN servers, it is used when the VPN drops unexpectedly.
+func (n *Network) EnableVpnKillSwitch(sender dbus.Sender, allowedAddrs []string) *dbus.Error {
+	err := checkPolkitAuth(sender, polkitActionVpnKillSwitch)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	rules, err := buildKillSwitchRules(allowedAddrs)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	logger.Info("enable vpn kill switch, allowed addresses:", allowedAddrs)
+	err = runNft(rules)
+	if err != nil {
+		logger.Warning(err)
+	}
+	return dbusutil.ToError(err)
+}
+
+// IsVpnKillSwitchEngaged get whether the traffic is blocked by EnableVpnKillSwitch.
+// The rules outlive the session daemon, so it checks the state after a restart.
+func (n *Network) IsVpnKillSwitchEngaged() (engaged bool, busErr *dbus.Error) {
+	args := append([]string{"list", "table"}, strings.Fields(killSwitchTable)...)
+	// #nosec G204
+	err := exec.Command("nft", args...).Run()
+	return err == nil, nil
+}
+
+// DisableVpnKillSwitch restore the traffic blocked by EnableVpnKillSwitch,
+// it succeeds even if the traffic is not blocked.
+func (n *Network) DisableVpnKillSwitch(sender dbus.Sender) *dbus.Error {
+	err := checkPolkitAuth(sender, polkitActionVpnKillSwitch)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	logger.Info("disable vpn kill switch")
+	err = runNft(fmt.Sprintf("table %s\ndelete table %s\n", killSwitchTable, killSwitchTable))
+	if err != nil {
+		logger.Warning(err)
+	}
+	return dbusutil.ToError(err)
+}
@@ -0,0 +1,25 @@
+// SPDX-FileCopyrightText: 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network1
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_buildKillSwitchRules(t *testing.T) {
+	rules, err := buildKillSwitchRules([]string{"10.0.0.1", "2001:db8::1"})
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(rules, "policy drop;"))
+	assert.True(t, strings.Contains(rules, "ip daddr 10.0.0.1 accept"))
+	assert.True(t, strings.Contains(rules, "ip6 daddr 2001:db8::1 accept"))
+	assert.True(t, strings.Contains(rules, `oifname "ppp*" accept`))
+
+	// 防止注入规则
+	_, err = buildKillSwitchRules([]string{"10.0.0.1 accept; flush ruleset"})
+	assert.NotNil(t, err)
+}
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/common/ssidpolicy"
 	"github.com/linuxdeepin/dde-daemon/loader"
 	"github.com/linuxdeepin/dde-daemon/network1/nm"
 	airplanemode "github.com/linuxdeepin/go-dbus-factory/system/org.deepin.dde.airplanemode1"
@@ -105,6 +106,9 @@ type Network struct {
 	sigLoop    *dbusutil.SignalLoop
 	airplane   airplanemode.AirplaneMode
 
+	ssidPolicyMu sync.Mutex
+	ssidPolicy   ssidpolicy.Policy
+
 	// nolint
 	signals *struct {
 		DeviceEnabled struct {
@@ -127,6 +131,7 @@ func (n *Network) init() error {
 	n.addDevicesWithRetry()
 	// get vpn enable state from config
 	n.VpnEnabled = n.config.VpnEnabled
+	n.initSsidPolicy()
 
 	return nil
 }
@@ -288,6 +293,8 @@ func (n *Network) addDevice(devPath dbus.ObjectPath) error {
 					logger.Warning(err)
 				}
 			}
+		} else if newState == nm.NM_DEVICE_STATE_PREPARE && dev.type0 == nm.NM_DEVICE_TYPE_WIFI {
+			n.enforceSsidPolicy(nmDev)
 		}
 	})
 
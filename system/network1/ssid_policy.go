@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network1
+
+import (
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/dde-daemon/common/dconfig"
+	"github.com/linuxdeepin/dde-daemon/common/ssidpolicy"
+	"github.com/linuxdeepin/dde-daemon/network1/nm"
+	networkmanager "github.com/linuxdeepin/go-dbus-factory/system/org.freedesktop.networkmanager"
+)
+
+func toStringList(value interface{}) (list []string) {
+	items, _ := value.([]dbus.Variant)
+	for _, item := range items {
+		if str, ok := item.Value().(string); ok && str != "" {
+			list = append(list, str)
+		}
+	}
+	return
+}
+
+// initSsidPolicy 读取管理员配置的无线网络黑白名单，在系统服务中断开不允许的连接，
+// 不依赖会话服务，nmcli 等其他程序激活的连接同样受限制
+func (n *Network) initSsidPolicy() {
+	dc, err := dconfig.NewDConfig(ssidpolicy.DSettingsAppID, ssidpolicy.DSettingsName, "")
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	load := func() {
+		var policy ssidpolicy.Policy
+		if value, err := dc.GetValue(ssidpolicy.DSettingsKeyBlocklist); err == nil {
+			policy.Blocklist = toStringList(value)
+		}
+		if value, err := dc.GetValue(ssidpolicy.DSettingsKeyAllowlist); err == nil {
+			policy.Allowlist = toStringList(value)
+		}
+		n.ssidPolicyMu.Lock()
+		n.ssidPolicy = policy
+		n.ssidPolicyMu.Unlock()
+		logger.Infof("ssid policy, blocklist: %v, allowlist: %v", policy.Blocklist, policy.Allowlist)
+	}
+	load()
+	onChanged := func(interface{}) {
+		load()
+		for _, dev := range n.getWirelessDevices() {
+			n.enforceSsidPolicy(dev.nmDevice)
+		}
+	}
+	dc.ConnectConfigChanged(ssidpolicy.DSettingsKeyBlocklist, onChanged)
+	dc.ConnectConfigChanged(ssidpolicy.DSettingsKeyAllowlist, onChanged)
+}
+
+func (n *Network) isSsidAllowed(ssid string) bool {
+	n.ssidPolicyMu.Lock()
+	defer n.ssidPolicyMu.Unlock()
+	return n.ssidPolicy.Allows(ssid)
+}
+
+// enforceSsidPolicy 断开设备上不允许的无线连接，热点模式不受限制
+func (n *Network) enforceSsidPolicy(nmDev networkmanager.Device) {
+	apath, err := nmDev.Device().ActiveConnection().Get(0)
+	if err != nil || apath == "/" {
+		return
+	}
+	aConn, err := networkmanager.NewActiveConnection(n.getSysBus(), apath)
+	if err != nil {
+		return
+	}
+	cpath, err := aConn.Connection().Get(0)
+	if err != nil {
+		return
+	}
+	conn, err := networkmanager.NewConnectionSettings(n.getSysBus(), cpath)
+	if err != nil {
+		return
+	}
+	settings, err := conn.GetSettings(0)
+	if err != nil {
+		logger.Warning(err)
+		return
+	}
+	wireless := settings[nm.NM_SETTING_WIRELESS_SETTING_NAME]
+	if wireless == nil {
+		return
+	}
+	if mode, _ := wireless[nm.NM_SETTING_WIRELESS_MODE].Value().(string); mode == nm.NM_SETTING_WIRELESS_MODE_AP {
+		return
+	}
+	ssid, _ := wireless[nm.NM_SETTING_WIRELESS_SSID].Value().([]byte)
+	if n.isSsidAllowed(string(ssid)) {
+		return
+	}
+	logger.Infof("deactivate wireless network %q which is not allowed", ssid)
+	err = n.nmManager.DeactivateConnection(0, apath)
+	if err != nil {
+		logger.Warning(err)
+	}
+}
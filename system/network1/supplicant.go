@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2018 - 2022 UnionTech Software Technology Co., Ltd.
+//
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package network1
+
+import (
+	"fmt"
+	"net"
+
+	dbus "github.com/godbus/dbus/v5"
+	"github.com/linuxdeepin/go-lib/dbusutil"
+)
+
+const (
+	polkitActionWps = "org.deepin.dde.network.wps"
+
+	wpaSupplicantService      = "fi.w1.wpa_supplicant1"
+	wpaSupplicantPath         = "/fi/w1/wpa_supplicant1"
+	wpaSupplicantInterface    = "fi.w1.wpa_supplicant1.Interface"
+	wpaSupplicantInterfaceWps = "fi.w1.wpa_supplicant1.Interface.WPS"
+	wpaSupplicantBSS          = "fi.w1.wpa_supplicant1.BSS"
+)
+
+// getSupplicantInterface 获取网络接口在 wpa_supplicant 中对应的对象
+func getSupplicantInterface(ifc string) (dbus.BusObject, error) {
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return nil, err
+	}
+	var ifcPath dbus.ObjectPath
+	err = systemBus.Object(wpaSupplicantService, wpaSupplicantPath).
+		Call(wpaSupplicantService+".GetInterface", 0, ifc).Store(&ifcPath)
+	if err != nil {
+		return nil, err
+	}
+	return systemBus.Object(wpaSupplicantService, ifcPath), nil
+}
+
+// StartWpsPin start the WPS PIN enrollment on the wireless interface with
+// the PIN entered by the user, bssid selects the access point and may be
+// empty. NetworkManager applies the credentials received from the access
+// point to the connection it is activating.
+func (n *Network) StartWpsPin(sender dbus.Sender, ifc string, bssid string, pin string) *dbus.Error {
+	err := checkPolkitAuth(sender, polkitActionWps)
+	if err != nil {
+		return dbusutil.ToError(err)
+	}
+	err = startWpsPin(ifc, bssid, pin)
+	if err != nil {
+		logger.Warning("failed to start wps pin:", err)
+		return dbusutil.ToError(err)
+	}
+	return nil
+}
+
+func startWpsPin(ifc, bssid, pin string) error {
+	// PIN 的格式由 wpa_supplicant 检查
+	args := map[string]dbus.Variant{
+		"Role": dbus.MakeVariant("enrollee"),
+		"Type": dbus.MakeVariant("pin"),
+		"Pin":  dbus.MakeVariant(pin),
+	}
+	if bssid != "" {
+		hwAddr, err := net.ParseMAC(bssid)
+		if err != nil || len(hwAddr) != 6 {
+			return fmt.Errorf("invalid bssid %q", bssid)
+		}
+		args["Bssid"] = dbus.MakeVariant([]byte(hwAddr))
+	}
+	obj, err := getSupplicantInterface(ifc)
+	if err != nil {
+		return err
+	}
+	// 会取消 NetworkManager 使用随机 PIN 发起的 WPS
+	var output map[string]dbus.Variant
+	return obj.Call(wpaSupplicantInterfaceWps+".Start", 0, args).Store(&output)
+}
+
+// GetBssInformationElements return the information elements of the BSSs
+// found by wpa_supplicant on the wireless interface, keyed by the lower
+// case BSSID.
+func (n *Network) GetBssInformationElements(ifc string) (iesMap map[string][]byte, busErr *dbus.Error) {
+	iesMap, err := getBssInformationElements(ifc)
+	if err != nil {
+		return nil, dbusutil.ToError(err)
+	}
+	return iesMap, nil
+}
+
+func getBssInformationElements(ifc string) (map[string][]byte, error) {
+	obj, err := getSupplicantInterface(ifc)
+	if err != nil {
+		return nil, err
+	}
+	variant, err := obj.GetProperty(wpaSupplicantInterface + ".BSSs")
+	if err != nil {
+		return nil, err
+	}
+	bssPaths, _ := variant.Value().([]dbus.ObjectPath)
+
+	systemBus, err := dbus.SystemBus()
+	if err != nil {
+		return nil, err
+	}
+	iesMap := make(map[string][]byte)
+	for _, bssPath := range bssPaths {
+		var props map[string]dbus.Variant
+		err := systemBus.Object(wpaSupplicantService, bssPath).
+			Call("org.freedesktop.DBus.Properties.GetAll", 0, wpaSupplicantBSS).Store(&props)
+		if err != nil {
+			// 扫描结果更新时 BSS 对象可能已经被移除
+			continue
+		}
+		bssid, _ := props["BSSID"].Value().([]byte)
+		ies, _ := props["IEs"].Value().([]byte)
+		if len(bssid) == 6 {
+			iesMap[net.HardwareAddr(bssid).String()] = ies
+		}
+	}
+	return iesMap, nil
+}